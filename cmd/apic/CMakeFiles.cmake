@@ -18,7 +18,10 @@
 # build and the file will be recreated, check in the new version.
 
 set(files
+    diff.go
+    doc.go
     format.go
+    import_registry.go
     main.go
     template.go
     validate.go
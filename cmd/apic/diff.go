@@ -0,0 +1,74 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diff registers and implements the "diff" apic command.
+//
+// The diff command compares two versions of an API file, reporting the changes
+// to the commands, types and state, and whether they break the decoding of
+// existing captures.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/gapil"
+	"github.com/google/gapid/gapil/diff"
+	"github.com/google/gapid/gapil/semantic"
+)
+
+func init() {
+	app.AddVerb(&app.Verb{
+		Name:      "diff",
+		ShortHelp: "Reports the semantic differences between two api files",
+		Action:    &diffVerb{},
+	})
+}
+
+type diffVerb struct {
+	Breaking bool `help:"Only report the changes that break existing captures"`
+}
+
+func (v *diffVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	args := flags.Args()
+	if len(args) < 2 {
+		app.Usage(ctx, "Expected the old and new api files")
+		return nil
+	}
+	apis := make([]*semantic.API, 2)
+	for i, apiName := range args[:2] {
+		// Each version needs its own processor, as the files share paths.
+		processor := gapil.NewProcessor()
+		compiled, errs := processor.Resolve(apiName)
+		if err := gapil.CheckErrors(apiName, errs, maxErrors); err != nil {
+			return err
+		}
+		apis[i] = compiled
+	}
+	changes := diff.Diff(apis[0], apis[1])
+	breaking := changes.Breaking()
+	if v.Breaking {
+		changes = breaking
+	}
+	if len(changes) > 0 {
+		fmt.Fprintf(os.Stdout, "%v\n", changes)
+	}
+	if c := len(breaking); c > 0 {
+		return fmt.Errorf("%d changes break existing captures", c)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package doc registers and implements the "doc" apic command.
+//
+// The doc command generates reference documentation for an API file, listing
+// the commands, types and state of the API.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapil"
+	"github.com/google/gapid/gapil/doc"
+)
+
+func init() {
+	app.AddVerb(&app.Verb{
+		Name:      "doc",
+		ShortHelp: "Generates reference documentation for an api file",
+		Action:    &docVerb{Format: "markdown"},
+	})
+}
+
+type docVerb struct {
+	Format string `help:"The output format: markdown or html"`
+	Out    string `help:"The output file. Defaults to stdout"`
+	Title  string `help:"The document title. Defaults to the api name"`
+}
+
+func (v *docVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	args := flags.Args()
+	if len(args) < 1 {
+		app.Usage(ctx, "Missing api file")
+		return nil
+	}
+	apiName := args[0]
+	format, err := doc.ParseFormat(v.Format)
+	if err != nil {
+		return err
+	}
+	processor := gapil.NewProcessor()
+	compiled, errs := processor.Resolve(apiName)
+	if err := gapil.CheckErrors(apiName, errs, maxErrors); err != nil {
+		return err
+	}
+	title := v.Title
+	if title == "" {
+		title = compiled.Name()
+	}
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(apiName), filepath.Ext(apiName))
+	}
+
+	if v.Out == "" {
+		return doc.Write(os.Stdout, compiled, title, format)
+	}
+	log.I(ctx, "Writing %v documentation to %v", format, v.Out)
+	file, err := os.Create(v.Out)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := doc.Write(file, compiled, title, format); err != nil {
+		return err
+	}
+	return file.Close()
+}
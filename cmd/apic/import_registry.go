@@ -0,0 +1,75 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package import_registry registers and implements the "import-registry" apic
+// command.
+//
+// The import-registry command reads a Khronos XML registry (vk.xml, gl.xml)
+// and generates skeleton api declarations for the requested extensions.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapil/registry"
+)
+
+func init() {
+	app.AddVerb(&app.Verb{
+		Name:      "import-registry",
+		ShortHelp: "Generates api declarations for extensions in a Khronos XML registry",
+		Action:    &importRegistryVerb{},
+	})
+}
+
+type importRegistryVerb struct {
+	API string `help:"Only import the declarations required by this api, for example gles2"`
+	Out string `help:"The output file. Defaults to stdout"`
+}
+
+func (v *importRegistryVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	args := flags.Args()
+	if len(args) < 2 {
+		app.Usage(ctx, "Expected the registry file and at least one extension name")
+		return nil
+	}
+	in, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	reg, err := registry.Parse(in)
+	if err != nil {
+		return err
+	}
+	options := registry.Options{API: v.API}
+
+	if v.Out == "" {
+		return registry.Import(reg, args[1:], options, os.Stdout)
+	}
+	log.I(ctx, "Writing declarations to %v", v.Out)
+	file, err := os.Create(v.Out)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := registry.Import(reg, args[1:], options, file); err != nil {
+		return err
+	}
+	return file.Close()
+}
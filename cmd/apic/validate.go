@@ -38,7 +38,14 @@ func init() {
 	})
 }
 
-type validateVerb struct{}
+type validateVerb struct {
+	MapReads           bool `help:"Also report reads of map entries that may not be initialized"`
+	NoReplayWrites     bool `help:"Also report state writes by commands annotated with @no_replay"`
+	UnobservedPointers bool `help:"Also report pointer parameters that are never read or written"`
+	ShadowedLocals     bool `help:"Also report locals that shadow globals, parameters or other locals"`
+	EnumSwitches       bool `help:"Also report enum switches without a default that miss entries"`
+	All                bool `help:"Perform all of the optional checks"`
+}
 
 func (v *validateVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 	args := flags.Args()
@@ -46,6 +53,14 @@ func (v *validateVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 		app.Usage(ctx, "Missing api file")
 		return nil
 	}
+	options := &validate.Options{
+		CheckUnused:             true,
+		CheckMapReads:           v.All || v.MapReads,
+		CheckNoReplayWrites:     v.All || v.NoReplayWrites,
+		CheckUnobservedPointers: v.All || v.UnobservedPointers,
+		CheckShadowedLocals:     v.All || v.ShadowedLocals,
+		CheckEnumSwitches:       v.All || v.EnumSwitches,
+	}
 	for _, apiName := range args {
 		processor := gapil.NewProcessor()
 		compiled, errs := processor.Resolve(apiName)
@@ -53,7 +68,7 @@ func (v *validateVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 			return err
 		}
 		log.I(ctx, "Validating %v", apiName)
-		issues := validate.Validate(compiled, processor.Mappings, nil)
+		issues := validate.Validate(compiled, processor.Mappings, options)
 		fmt.Fprintf(os.Stderr, "%v\n", issues)
 		if c := len(issues); c > 0 {
 			return fmt.Errorf("%d issues found", c)
@@ -25,6 +25,7 @@ import (
 	"github.com/google/gapid/core/app"
 	"github.com/google/gapid/core/app/auth"
 	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/image"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/core/os/android/adb"
 	"github.com/google/gapid/core/os/device/bind"
@@ -55,6 +56,13 @@ var (
 	adbPath         = flag.String("adb", "", "Path to the adb executable; leave empty to search the environment")
 )
 
+var textureEncodeQuality = image.NormalQuality
+
+func init() {
+	flag.Var(&textureEncodeQuality, "texture-encode-quality",
+		"Quality of textures re-encoded into a format supported by the replay device: fast, normal or best")
+}
+
 func main() {
 	app.ShortHelp = "GAPIS is the graphics API server"
 	app.Name = "GAPIS" // Has to be this for version parsing compatability
@@ -80,6 +88,7 @@ func run(ctx context.Context) error {
 	logBroadcaster := log.Broadcast()
 	addFallbackLogHandler(logBroadcaster, log.GetHandler(ctx))
 	ctx = log.PutHandler(ctx, logBroadcaster)
+	ctx = image.PutEncoderQuality(ctx, textureEncodeQuality)
 
 	if *adbPath != "" {
 		adb.ADB = file.Abs(*adbPath)
@@ -18,18 +18,24 @@
 # build and the file will be recreated, check in the new version.
 
 set(files
+    buffer.go
+    buffer_test.go
     commands.go
     common.go
+    counters.go
     devices.go
     dump.go
     dump_shaders.go
+    export.go
     flags.go
     info.go
     inputs.go
+    logcat.go
     main.go
     packages.go
     report.go
     screenshot.go
+    sidecar.go
     state.go
     stresstest.go
     sxs_video.go
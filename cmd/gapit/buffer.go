@@ -0,0 +1,193 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/gapid/core/app"
+	"github.com/google/gapid/core/app/flags"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/stream"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+type bufferVerb struct{ BufferFlags }
+
+func init() {
+	verb := &bufferVerb{
+		BufferFlags{
+			At:        flags.U64Slice{},
+			Attribute: -1,
+			Ubo:       -1,
+		},
+	}
+
+	app.AddVerb(&app.Verb{
+		Name:      "buffer",
+		ShortHelp: "Prints the decoded rows of a buffer at a point in a .gfxtrace file",
+		Action:    verb,
+	})
+}
+
+func (verb *bufferVerb) Run(ctx context.Context, flags flag.FlagSet) error {
+	if flags.NArg() != 1 {
+		app.Usage(ctx, "Exactly one gfx trace file expected, got %d", flags.NArg())
+		return nil
+	}
+
+	var layout *path.BufferLayout
+	if verb.Format != "" {
+		fields, err := parseBufferFields(verb.Format)
+		if err != nil {
+			return err
+		}
+		layout = &path.BufferLayout{
+			Offset: verb.Offset,
+			Stride: uint32(verb.Stride),
+			Count:  uint32(verb.Count),
+			Fields: fields,
+		}
+	}
+
+	client, err := getGapis(ctx, verb.Gapis, verb.Gapir)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to connect to the GAPIS server")
+	}
+	defer client.Close()
+
+	filepath, err := filepath.Abs(flags.Arg(0))
+	ctx = log.V{"filepath": filepath}.Bind(ctx)
+	if err != nil {
+		return log.Err(ctx, err, "Could not find capture file")
+	}
+
+	c, err := client.LoadCapture(ctx, filepath)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to load the capture file")
+	}
+
+	if len(verb.At) == 0 {
+		boxedCapture, err := client.Get(ctx, c.Path())
+		if err != nil {
+			return log.Err(ctx, err, "Failed to load the capture")
+		}
+		verb.At = []uint64{uint64(boxedCapture.(*service.Capture).NumCommands) - 1}
+	}
+
+	cmd := c.Command(verb.At[0], verb.At[1:]...)
+	var p *path.BufferView
+	switch {
+	case verb.Attribute >= 0:
+		p = cmd.BoundBufferView(path.BufferBinding_VertexAttribute, uint32(verb.Attribute), layout)
+	case verb.Ubo >= 0:
+		p = cmd.BoundBufferView(path.BufferBinding_UniformBlock, uint32(verb.Ubo), layout)
+	case layout == nil:
+		app.Usage(ctx, "A format is required unless the buffer is identified by attribute or ubo")
+		return nil
+	default:
+		p = cmd.BufferView(verb.Buffer, layout)
+	}
+
+	boxedView, err := client.Get(ctx, p.Path())
+	if err != nil {
+		return log.Err(ctx, err, "Failed to load the buffer view")
+	}
+	view := boxedView.(*service.BufferView)
+
+	for _, row := range view.Rows {
+		fmt.Fprintf(os.Stdout, "%#8x:", row.Offset)
+		for i, f := range row.Fields {
+			fmt.Fprintf(os.Stdout, " %v=%v", view.Layout.Fields[i].Name, f.Components)
+		}
+		fmt.Fprintln(os.Stdout)
+	}
+	return nil
+}
+
+// parseBufferFields parses a comma-separated list of buffer fields, each of the
+// form [name:]type[@offset].
+// The type is one of s8, u8, s16, u16, s32, u32, f16, f32 or f64, optionally
+// followed by x2, x3 or x4 for vectors, and n for normalized integers.
+// Fields without an offset directly follow the previous field.
+func parseBufferFields(s string) ([]*path.BufferField, error) {
+	dataTypes := map[string]stream.DataType{
+		"s8": stream.S8, "u8": stream.U8,
+		"s16": stream.S16, "u16": stream.U16,
+		"s32": stream.S32, "u32": stream.U32,
+		"f16": stream.F16, "f32": stream.F32, "f64": stream.F64,
+	}
+	xyzw := []stream.Channel{
+		stream.Channel_X,
+		stream.Channel_Y,
+		stream.Channel_Z,
+		stream.Channel_W,
+	}
+
+	fields := []*path.BufferField{}
+	offset := uint32(0)
+	for i, desc := range strings.Split(s, ",") {
+		name, ty := fmt.Sprintf("field%d", i), strings.TrimSpace(desc)
+		if j := strings.Index(ty, ":"); j >= 0 {
+			name, ty = ty[:j], ty[j+1:]
+		}
+		if j := strings.Index(ty, "@"); j >= 0 {
+			o, err := strconv.ParseUint(ty[j+1:], 0, 32)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid offset in buffer field '%v': %v", desc, err)
+			}
+			ty, offset = ty[:j], uint32(o)
+		}
+
+		sampling := stream.Linear
+		if strings.HasSuffix(ty, "n") {
+			ty, sampling = strings.TrimSuffix(ty, "n"), stream.LinearNormalized
+		}
+		count := 1
+		if j := strings.Index(ty, "x"); j >= 0 {
+			c, err := strconv.Atoi(ty[j+1:])
+			if err != nil || c < 1 || c > len(xyzw) {
+				return nil, fmt.Errorf("Invalid vector size in buffer field '%v'", desc)
+			}
+			ty, count = ty[:j], c
+		}
+		dt, ok := dataTypes[ty]
+		if !ok {
+			return nil, fmt.Errorf("Unknown type '%v' in buffer field '%v'", ty, desc)
+		}
+		if sampling.Normalized && !dt.IsInteger() {
+			return nil, fmt.Errorf("Only integer types can be normalized in buffer field '%v'", desc)
+		}
+
+		format := &stream.Format{Components: make([]*stream.Component, count)}
+		for c := range format.Components {
+			format.Components[c] = &stream.Component{
+				DataType: &dt,
+				Sampling: sampling,
+				Channel:  xyzw[c],
+			}
+		}
+		fields = append(fields, &path.BufferField{Name: name, Offset: offset, Format: format})
+		offset += uint32(format.Stride())
+	}
+	return fields, nil
+}
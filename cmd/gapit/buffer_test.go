@@ -0,0 +1,80 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/stream"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+func TestParseBufferFields(t *testing.T) {
+	assert := assert.To(t)
+
+	field := func(name string, offset uint32, dt stream.DataType, sampling *stream.Sampling, count int) *path.BufferField {
+		xyzw := []stream.Channel{stream.Channel_X, stream.Channel_Y, stream.Channel_Z, stream.Channel_W}
+		f := &stream.Format{Components: make([]*stream.Component, count)}
+		for i := range f.Components {
+			f.Components[i] = &stream.Component{DataType: &dt, Sampling: sampling, Channel: xyzw[i]}
+		}
+		return &path.BufferField{Name: name, Offset: offset, Format: f}
+	}
+
+	for _, test := range []struct {
+		desc     string
+		expected []*path.BufferField
+	}{
+		{"u8", []*path.BufferField{
+			field("field0", 0, stream.U8, stream.Linear, 1),
+		}},
+		{"u8, s16, f32, f64", []*path.BufferField{
+			field("field0", 0, stream.U8, stream.Linear, 1),
+			field("field1", 1, stream.S16, stream.Linear, 1),
+			field("field2", 3, stream.F32, stream.Linear, 1),
+			field("field3", 7, stream.F64, stream.Linear, 1),
+		}},
+		{"pos:f32x3,uv:u16x2n@16,f16", []*path.BufferField{
+			field("pos", 0, stream.F32, stream.Linear, 3),
+			field("uv", 16, stream.U16, stream.LinearNormalized, 2),
+			field("field2", 20, stream.F16, stream.Linear, 1),
+		}},
+		{"a:s8x4n@0x8,b:u32@4", []*path.BufferField{
+			field("a", 8, stream.S8, stream.LinearNormalized, 4),
+			field("b", 4, stream.U32, stream.Linear, 1),
+		}},
+	} {
+		fields, err := parseBufferFields(test.desc)
+		if assert.For("%s err", test.desc).ThatError(err).Succeeded() {
+			assert.For("%s fields", test.desc).That(fields).DeepEquals(test.expected)
+		}
+	}
+
+	for _, desc := range []string{
+		"",
+		"i8",
+		"f32n",
+		"u8x0",
+		"u8x5",
+		"u8xn",
+		"u8@",
+		"u8@-1",
+		"u8,,u8",
+	} {
+		_, err := parseBufferFields(desc)
+		assert.For("%q err", desc).ThatError(err).Failed()
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/android/adb"
+	"github.com/google/gapid/gapis/capture"
+)
+
+// maxCounterBackoff is the longest time to wait between samples when sampling
+// the performance counters fails, unless the sampling interval is longer.
+const maxCounterBackoff = 10 * time.Second
+
+// counterSampler periodically samples the device performance counters while
+// the capture is being taken. Each sample is tagged with the capture stream
+// offset when it was taken, so gapis can find the commands that were being
+// traced at the time.
+type counterSampler struct {
+	d        adb.Device
+	interval time.Duration
+	cancel   task.CancelFunc
+	done     chan struct{}
+	counters capture.Counters
+}
+
+func newCounterSampler(d adb.Device, interval time.Duration) *counterSampler {
+	return &counterSampler{d: d, interval: interval, done: make(chan struct{})}
+}
+
+func (r *counterSampler) start(ctx context.Context, s *captureStream) {
+	ctx, r.cancel = task.WithCancel(ctx)
+	go func() {
+		defer close(r.done)
+		wait, limit := r.interval, maxCounterBackoff
+		if limit < r.interval {
+			limit = r.interval
+		}
+		for {
+			start := time.Now()
+			offset := s.offset()
+			sample, err := r.d.PerfCounters(ctx)
+			switch {
+			case task.Stopped(ctx):
+				return
+			case err != nil:
+				// Keep sampling, but back off while the device is failing.
+				wait *= 2
+				if wait > limit {
+					wait = limit
+				}
+				log.W(ctx, "Sampling performance counters failed, retrying in %v: %v", wait, err)
+			default:
+				wait = r.interval
+				t := sample.Time
+				if t.IsZero() {
+					// The device could not report its time.
+					t = start
+				}
+				for _, c := range sample.Counters {
+					r.counters.Add(c.Name, c.Unit, &capture.CounterSample{
+						Timestamp:     t.UnixNano(),
+						Value:         c.Value,
+						CaptureOffset: offset,
+					})
+				}
+			}
+			select {
+			case <-task.ShouldStop(ctx):
+				return
+			case <-time.After(wait - time.Since(start)):
+			}
+		}
+	}()
+	log.I(ctx, "Sampling performance counters every %v", r.interval)
+}
+
+func (r *counterSampler) stop(ctx context.Context, out string) {
+	r.cancel()
+	<-r.done
+	log.I(ctx, "Writing %d performance counters to '%v'", len(r.counters.Counters), out+capture.CountersExtension)
+	if err := capture.WriteCounters(ctx, &r.counters, out); err != nil {
+		log.W(ctx, "Failed to write performance counters: %v", err)
+	}
+}
@@ -17,10 +17,13 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
 	"github.com/google/gapid/core/app"
+	img "github.com/google/gapid/core/image"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/gapis/api"
 	"github.com/google/gapid/gapis/service"
@@ -36,7 +39,7 @@ func init() {
 	}
 	app.AddVerb(&app.Verb{
 		Name:      "dump_resources",
-		ShortHelp: "Dump all shaders at a particular command from a .gfxtrace",
+		ShortHelp: "Dump all shaders, and optionally textures, at a particular command from a .gfxtrace",
 		Action:    verb,
 	})
 }
@@ -58,6 +61,15 @@ func (verb *dumpShadersVerb) Run(ctx context.Context, flags flag.FlagSet) error
 	}
 	defer client.Close()
 
+	// Textures are only dumped when asked for with -format or -contactsheet.
+	dumpTextures := verb.Format != "" || verb.ContactSheet
+	var format *img.Format
+	if verb.Format != "" {
+		if format, err = getExportFormat(verb.Format); err != nil {
+			return err
+		}
+	}
+
 	capture, err := client.LoadCapture(ctx, filepath)
 	if err != nil {
 		return log.Errf(ctx, err, "Failed to load the capture file '%v'", filepath)
@@ -78,6 +90,34 @@ func (verb *dumpShadersVerb) Run(ctx context.Context, flags flag.FlagSet) error
 	}
 
 	for _, types := range resources.GetTypes() {
+		if types.Type == api.ResourceType_TextureResource && dumpTextures {
+			for _, v := range types.GetResources() {
+				if !v.Id.IsValid() {
+					log.E(ctx, "Got resource with invalid ID!\n%+v", v)
+					continue
+				}
+				resourcePath := capture.Command(uint64(verb.At)).ResourceAfter(v.Id)
+				resourceData, err := client.Get(ctx, resourcePath.Path())
+				if err != nil {
+					log.E(ctx, "Could not get data for texture: %v %v", v, err)
+					continue
+				}
+
+				levels, cubemap, err := textureLevels(resourceData.(*api.ResourceData).GetTexture())
+				if err != nil {
+					log.E(ctx, "Could not get images of texture: %v %v", v, err)
+					continue
+				}
+				if verb.ContactSheet {
+					err = writeContactSheet(ctx, client, v.GetHandle(), levels)
+				} else {
+					err = writeTexture(ctx, client, v.GetHandle(), levels, cubemap, format)
+				}
+				if err != nil {
+					log.E(ctx, "Could not write texture %s %v", v.GetHandle(), err)
+				}
+			}
+		}
 		if types.Type == api.ResourceType_ShaderResource {
 			for _, v := range types.GetResources() {
 				if !v.Id.IsValid() {
@@ -91,7 +131,8 @@ func (verb *dumpShadersVerb) Run(ctx context.Context, flags flag.FlagSet) error
 					continue
 				}
 
-				shaderSource := resourceData.(*api.ResourceData).GetShader().GetSource()
+				shader := resourceData.(*api.ResourceData).GetShader()
+				shaderSource := shader.GetSource()
 
 				f, err := os.Create(v.GetHandle())
 				if err != nil {
@@ -100,9 +141,62 @@ func (verb *dumpShadersVerb) Run(ctx context.Context, flags flag.FlagSet) error
 				}
 				defer f.Close()
 				f.WriteString(shaderSource)
+
+				if r := shader.GetReflection(); r != nil {
+					f, err := os.Create(v.GetHandle() + ".reflection")
+					if err != nil {
+						log.E(ctx, "Could open file to write %s.reflection %v", v.GetHandle(), err)
+						continue
+					}
+					defer f.Close()
+					writeShaderReflection(f, r)
+				}
 			}
 		}
 	}
 
 	return nil
 }
+
+// writeShaderReflection writes a human readable description of the shader
+// interface r to w.
+func writeShaderReflection(w io.Writer, r *api.ShaderReflection) {
+	writeBlock := func(b *api.ShaderBlock) {
+		for _, m := range b.Members {
+			fmt.Fprintf(w, "    offset %-4d %s %s (%d bytes)\n", m.Offset, m.Type, m.Name, m.Size)
+		}
+	}
+	for _, e := range r.EntryPoints {
+		fmt.Fprintf(w, "entry point %s (%v)\n", e.Name, e.Stage)
+	}
+	for _, d := range r.Descriptors {
+		count := ""
+		if d.Count != 1 {
+			count = fmt.Sprintf("[%d]", d.Count)
+		}
+		if d.Count == 0 {
+			count = "[]"
+		}
+		fmt.Fprintf(w, "descriptor set=%d binding=%d %v %s %s%s\n", d.Set, d.Binding, d.Kind, d.Type, d.Name, count)
+		if d.Block != nil {
+			writeBlock(d.Block)
+		}
+	}
+	if pc := r.PushConstants; pc != nil {
+		fmt.Fprintf(w, "push constants %s (%d bytes)\n", pc.Name, pc.Size)
+		writeBlock(pc)
+	}
+	for _, v := range r.Inputs {
+		fmt.Fprintf(w, "input location=%d %s %s\n", v.Location, v.Type, v.Name)
+	}
+	for _, v := range r.Outputs {
+		fmt.Fprintf(w, "output location=%d %s %s\n", v.Location, v.Type, v.Name)
+	}
+	for _, c := range r.SpecConstants {
+		fmt.Fprintf(w, "spec constant id=%d %s %s", c.Id, c.Type, c.Name)
+		if c.Value != nil {
+			fmt.Fprintf(w, " = %v", c.Value.Get())
+		}
+		fmt.Fprintln(w)
+	}
+}
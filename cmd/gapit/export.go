@@ -0,0 +1,199 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+
+	img "github.com/google/gapid/core/image"
+)
+
+// exportFormats are the image formats that can be selected with the --format
+// flags. The format name is used as the file extension.
+var exportFormats = []*img.Format{img.PNG, img.EXR, img.HDR, img.DDS, img.KTX, img.KTX2}
+
+// getExportFormat returns the export format with the given name.
+func getExportFormat(name string) (*img.Format, error) {
+	for _, f := range exportFormats {
+		if f.Name == name {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("Unknown image format '%s'. Expected png, exr, hdr, dds, ktx or ktx2", name)
+}
+
+// getImageData returns the image described by info.
+func getImageData(ctx context.Context, client service.Service, info *img.Info) (*img.Data, error) {
+	boxedBytes, err := client.Get(ctx, path.NewBlob(info.Bytes.ID()).Path())
+	if err != nil {
+		return nil, log.Errf(ctx, err, "Get image data failed")
+	}
+	return &img.Data{
+		Format: info.Format,
+		Width:  info.Width,
+		Height: info.Height,
+		Depth:  info.Depth,
+		Bytes:  boxedBytes.([]byte),
+	}, nil
+}
+
+// textureLevels returns the images of each mip level of the texture, with the
+// layers of each level in the order expected by img.EncodeKTX and
+// img.EncodeDDS.
+func textureLevels(t *api.Texture) (levels [][]*img.Info, cubemap bool, err error) {
+	// layered transposes the levels of each layer into the layers of each
+	// level.
+	layered := func(layers [][]*img.Info) [][]*img.Info {
+		out := [][]*img.Info{}
+		for _, layer := range layers {
+			for i, level := range layer {
+				if i >= len(out) {
+					out = append(out, nil)
+				}
+				out[i] = append(out[i], level)
+			}
+		}
+		return out
+	}
+	single := func(levels []*img.Info) [][]*img.Info {
+		return layered([][]*img.Info{levels})
+	}
+	cube := func(c *api.Cubemap) [][]*img.Info {
+		out := [][]*img.Info{}
+		for _, l := range c.Levels {
+			out = append(out, []*img.Info{
+				l.PositiveX, l.NegativeX, l.PositiveY, l.NegativeY, l.PositiveZ, l.NegativeZ,
+			})
+		}
+		return out
+	}
+
+	switch t := t.Type.(type) {
+	case *api.Texture_Texture_1D:
+		return single(t.Texture_1D.Levels), false, nil
+	case *api.Texture_Texture_1DArray:
+		layers := [][]*img.Info{}
+		for _, l := range t.Texture_1DArray.Layers {
+			layers = append(layers, l.Levels)
+		}
+		return layered(layers), false, nil
+	case *api.Texture_Texture_2D:
+		return single(t.Texture_2D.Levels), false, nil
+	case *api.Texture_Texture_2DArray:
+		layers := [][]*img.Info{}
+		for _, l := range t.Texture_2DArray.Layers {
+			layers = append(layers, l.Levels)
+		}
+		return layered(layers), false, nil
+	case *api.Texture_Texture_3D:
+		return single(t.Texture_3D.Levels), false, nil
+	case *api.Texture_Cubemap:
+		return cube(t.Cubemap), true, nil
+	case *api.Texture_CubemapArray:
+		out := [][]*img.Info{}
+		for _, c := range t.CubemapArray.Layers {
+			for i, faces := range cube(c) {
+				if i >= len(out) {
+					out = append(out, nil)
+				}
+				out[i] = append(out[i], faces...)
+			}
+		}
+		return out, true, nil
+	default:
+		return nil, false, fmt.Errorf("Unsupported texture type %T", t)
+	}
+}
+
+// getTextureData returns the images of each mip level of a texture.
+func getTextureData(ctx context.Context, client service.Service, levels [][]*img.Info) ([][]*img.Data, error) {
+	images := make([][]*img.Data, len(levels))
+	for i, level := range levels {
+		for _, info := range level {
+			data, err := getImageData(ctx, client, info)
+			if err != nil {
+				return nil, err
+			}
+			images[i] = append(images[i], data)
+		}
+	}
+	return images, nil
+}
+
+// writeTexture writes the images of a texture in the format f. KTX and DDS
+// formats hold every level and layer in the single file name.<format>. Other
+// formats write one file per image, suffixed with the level and layer.
+func writeTexture(ctx context.Context, client service.Service, name string, levels [][]*img.Info, cubemap bool, f *img.Format) error {
+	images, err := getTextureData(ctx, client, levels)
+	if err != nil {
+		return err
+	}
+
+	var container []byte
+	switch {
+	case f.GetKtx() != nil:
+		container, err = img.EncodeKTX(f.GetKtx().Version, images, cubemap)
+	case f.GetDds() != nil:
+		container, err = img.EncodeDDS(images, cubemap)
+	}
+	if err != nil {
+		return log.Errf(ctx, err, "Failed to encode %v", f.Name)
+	}
+	if container != nil {
+		return ioutil.WriteFile(name+"."+f.Name, container, 0666)
+	}
+
+	for i, level := range images {
+		for j, image := range level {
+			data, err := image.Convert(f)
+			if err != nil {
+				return log.Errf(ctx, err, "Failed to convert level %d layer %d to %v", i, j, f.Name)
+			}
+			fn := name + "." + f.Name
+			if len(images) > 1 || len(level) > 1 {
+				fn = fmt.Sprintf("%s_level%d_layer%d.%s", name, i, j, f.Name)
+			}
+			if err := ioutil.WriteFile(fn, data.Bytes, 0666); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeContactSheet writes the images of a texture to the single file
+// name.png, with the mip levels on separate rows and the layers side by side.
+func writeContactSheet(ctx context.Context, client service.Service, name string, levels [][]*img.Info) error {
+	images, err := getTextureData(ctx, client, levels)
+	if err != nil {
+		return err
+	}
+	sheet, err := img.ContactSheet(images)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to build contact sheet")
+	}
+	data, err := sheet.Convert(img.PNG)
+	if err != nil {
+		return log.Err(ctx, err, "Failed to convert contact sheet to png")
+	}
+	return ioutil.WriteFile(name+".png", data.Bytes, 0666)
+}
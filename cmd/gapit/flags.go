@@ -121,9 +121,11 @@ type (
 		}
 	}
 	DumpShadersFlags struct {
-		Gapis GapisFlags
-		Gapir GapirFlags
-		At    int `help:"command index to dump the resources after"`
+		Gapis        GapisFlags
+		Gapir        GapirFlags
+		At           int    `help:"command index to dump the resources after"`
+		Format       string `help:"if set then textures are also dumped in this image format: png, exr, hdr, dds, ktx or ktx2"`
+		ContactSheet bool   `help:"if true then textures are also dumped, each as a single png tiling its faces, layers and mip levels"`
 	}
 	DumpFlags struct {
 		Gapis          GapisFlags
@@ -166,7 +168,7 @@ type (
 		Local struct {
 			Port       int       `help:"capture a local program instead of using ADB"`
 			App        file.Path `help:"a local program to trace"`
-			Args       string    `help:"arguments to pass to the traced program"`
+			Args       string    `help:"arguments to pass to the traced program, double quoted where they hold spaces"`
 			WorkingDir string    `help:"working directory for the process"`
 		}
 		Android struct {
@@ -181,12 +183,16 @@ type (
 			Draws  uint `help:"capture the framebuffer every n draws (0 to disable)"`
 		}
 		Disable struct {
-			PCS bool `help:"disable pre-compiled shaders"`
+			PCS    bool `help:"disable pre-compiled shaders"`
+			Logcat bool `help:"disable recording the logcat of the traced package"`
 		}
 		Record struct {
 			Errors bool `help:"record device error state"`
 			Inputs bool `help:"record the inputs to file"`
 		}
+		Counters struct {
+			Interval time.Duration `help:"sample the device performance counters at this interval, for example 250ms (off by default)"`
+		}
 		Clear struct {
 			Cache bool `help:"clear package data before running it"`
 		}
@@ -210,17 +216,33 @@ type (
 	}
 	PackagesFlags struct {
 		DeviceFlags
-		Icons       bool           `help:"if true then package icons are also dumped."`
-		IconDensity float64        `help:"scale multiplier on icon density."`
-		Format      PackagesOutput `help:"output format"`
-		Out         string         `help:"output file, standard output if none"`
-		DataHeader  string         `help:"marker to write before package data"`
-		ADB         string         `help:"Path to the adb executable; leave empty to search the environment"`
+		Icons         bool           `help:"if true then package icons are also dumped."`
+		IconDensity   float64        `help:"scale multiplier on icon density."`
+		Analyze       bool           `help:"if true then the package APKs are pulled and analyzed for graphics API usage."`
+		AnalyzeSystem bool           `help:"if true then system packages are also analyzed."`
+		Format        PackagesOutput `help:"output format"`
+		Out           string         `help:"output file, standard output if none"`
+		DataHeader    string         `help:"marker to write before package data"`
+		ADB           string         `help:"Path to the adb executable; leave empty to search the environment"`
 	}
 	ScreenshotFlags struct {
-		Gapis GapisFlags
-		Gapir GapirFlags
-		At    flags.U64Slice `help:"command/subcommand index for the screenshot. Empty for last"`
+		Gapis  GapisFlags
+		Gapir  GapirFlags
+		At     flags.U64Slice `help:"command/subcommand index for the screenshot. Empty for last"`
+		Format string         `help:"image format: png, exr, hdr, dds, ktx or ktx2"`
+		All    bool           `help:"write every framebuffer attachment, with depth and stencil as viewable images"`
+	}
+	BufferFlags struct {
+		Gapis     GapisFlags
+		Gapir     GapirFlags
+		At        flags.U64Slice `help:"command/subcommand index to read the buffer after. Empty for last"`
+		Buffer    uint64         `help:"API handle of the buffer to read"`
+		Attribute int            `help:"read the buffer bound to this vertex attribute location (-1 to disable)"`
+		Ubo       int            `help:"read the buffer bound to this uniform block index of the bound program (-1 to disable)"`
+		Format    string         `help:"row fields as [name:]type[@offset],... e.g. pos:f32x3,uv:u16x2n@12. Inferred from attribute or ubo if empty"`
+		Offset    uint64         `help:"offset in bytes of the first row"`
+		Stride    uint           `help:"bytes between the start of each row, 0 for tightly packed"`
+		Count     uint           `help:"maximum number of rows, 0 for all"`
 	}
 	UnpackFlags struct{}
 )
@@ -0,0 +1,86 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+
+	"github.com/google/gapid/core/event/task"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/android"
+	"github.com/google/gapid/core/os/android/adb"
+	"github.com/google/gapid/gapis/capture"
+)
+
+// logcatRecorder records the logcat messages of the traced process while the
+// capture is being taken. Each message is tagged with the capture stream
+// offset when it was received, so gapis can find the commands that were being
+// traced when the message was logged.
+type logcatRecorder struct {
+	d      adb.Device
+	pid    int
+	cancel task.CancelFunc
+	done   chan struct{}
+	log    capture.Log
+}
+
+// newLogcatRecorder returns a recorder for the logcat messages of the running
+// process of pkg. If the process cannot be found, then a warning is logged and
+// nil is returned.
+func newLogcatRecorder(ctx context.Context, d adb.Device, pkg *android.InstalledPackage) *logcatRecorder {
+	pid, err := pkg.Pid(ctx)
+	if err != nil {
+		log.W(ctx, "Not recording logcat, as the process could not be found: %v", err)
+		return nil
+	}
+	return &logcatRecorder{d: d, pid: pid, done: make(chan struct{})}
+}
+
+func (r *logcatRecorder) start(ctx context.Context, s *captureStream) {
+	ctx, r.cancel = task.WithCancel(ctx)
+	msgs := make(chan android.LogcatMessage, 64)
+	go func() {
+		if err := r.d.Logcat(ctx, msgs); err != nil && !task.Stopped(ctx) {
+			log.W(ctx, "Logcat failed: %v", err)
+		}
+	}()
+	go func() {
+		defer close(r.done)
+		for m := range msgs {
+			if m.ProcessID != r.pid {
+				continue
+			}
+			r.log.Messages = append(r.log.Messages, &capture.LogMessage{
+				Timestamp:     m.Timestamp.UnixNano(),
+				Severity:      int32(m.Priority.Severity()),
+				Tag:           m.Tag,
+				ProcessId:     int32(m.ProcessID),
+				ThreadId:      int32(m.ThreadID),
+				Text:          m.Message,
+				CaptureOffset: s.offset(),
+			})
+		}
+	}()
+	log.I(ctx, "Recording logcat for process %d", r.pid)
+}
+
+func (r *logcatRecorder) stop(ctx context.Context, out string) {
+	r.cancel()
+	<-r.done
+	log.I(ctx, "Writing %d logcat messages to '%v'", len(r.log.Messages), out+capture.LogExtension)
+	if err := capture.WriteLog(ctx, &r.log, out); err != nil {
+		log.W(ctx, "Failed to write logcat: %v", err)
+	}
+}
@@ -17,16 +17,23 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/google/gapid/core/app"
 	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/android"
 	"github.com/google/gapid/core/os/android/adb"
+	"github.com/google/gapid/core/os/android/apk"
 	"github.com/google/gapid/core/os/file"
 	"github.com/google/gapid/gapidapk"
+	"github.com/google/gapid/gapidapk/pkginfo"
 )
 
 type packagesVerb struct{ PackagesFlags }
@@ -59,6 +66,10 @@ func (verb *packagesVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 		return log.Err(ctx, err, "getting package list")
 	}
 
+	if verb.Analyze {
+		pkgs.Analysis = analyzePackages(ctx, d, pkgs, verb.AnalyzeSystem)
+	}
+
 	w := os.Stdout
 	if verb.Out != "" {
 		f, err := os.OpenFile(verb.Out, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
@@ -98,9 +109,93 @@ func (verb *packagesVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 	case SimpleList:
 		w.Write(header)
 		for _, a := range pkgs.GetPackages() {
-			fmt.Fprintf(w, "%s\n", a.Name)
+			info, ok := pkgs.Analysis[a.Name]
+			if !ok {
+				fmt.Fprintf(w, "%s\n", a.Name)
+				continue
+			}
+			apis := make([]string, len(info.GraphicsAPIs))
+			for i, api := range info.GraphicsAPIs {
+				apis[i] = api.String()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", a.Name, info.Engine, strings.Join(apis, ","))
 		}
 	}
 
 	return nil
 }
+
+// analyzePackages pulls the APKs of the packages from the device and analyzes
+// them. Packages that cannot be analyzed are skipped with a warning. System
+// packages are only analyzed if system is true.
+func analyzePackages(ctx context.Context, d adb.Device, pkgs *pkginfo.PackageList, system bool) map[string]*apk.Information {
+	tmp, err := ioutil.TempDir("", "gapit-packages")
+	if err != nil {
+		log.W(ctx, "Couldn't create temporary directory: %v", err)
+		return nil
+	}
+	defer os.RemoveAll(tmp)
+
+	out := map[string]*apk.Information{}
+	for _, p := range pkgs.GetPackages() {
+		ctx := log.V{"package": p.Name}.Bind(ctx)
+		info, err := analyzePackage(ctx, d, p.Name, tmp, system)
+		switch {
+		case err == errSystemPackage:
+			log.D(ctx, "Skipping system package")
+			continue
+		case err != nil:
+			log.W(ctx, "Couldn't analyze package: %v", err)
+			continue
+		}
+		out[p.Name] = info
+	}
+	return out
+}
+
+// systemAPKPrefixes are the device directories holding the APKs of the
+// packages that are part of the system image.
+var systemAPKPrefixes = []string{"/system/", "/vendor/", "/product/", "/oem/"}
+
+// errSystemPackage is returned by analyzePackage for system packages when they
+// are not being analyzed.
+var errSystemPackage = errors.New("System package")
+
+// analyzePackage pulls the base and split APKs of the package to the tmp
+// directory and analyzes them. If system is false, then system packages are
+// not pulled and errSystemPackage is returned.
+func analyzePackage(ctx context.Context, d adb.Device, name, tmp string, system bool) (*apk.Information, error) {
+	pkg := &android.InstalledPackage{Name: name, Device: d}
+	paths, err := pkg.Paths(ctx)
+	if err != nil {
+		return nil, log.Err(ctx, err, "Finding APKs")
+	}
+	if !system && isSystemAPK(paths[0]) {
+		return nil, errSystemPackage
+	}
+	apks := make([][]byte, len(paths))
+	for i, path := range paths {
+		local := filepath.Join(tmp, fmt.Sprintf("%s-%d.apk", name, i))
+		if err := d.Pull(ctx, path, local); err != nil {
+			return nil, log.Err(ctx, err, "Pulling APK")
+		}
+		data, err := ioutil.ReadFile(local)
+		os.Remove(local)
+		if err != nil {
+			return nil, log.Err(ctx, err, "Reading APK")
+		}
+		apks[i] = data
+	}
+	return apk.Analyze(ctx, apks[0], apks[1:]...)
+}
+
+// isSystemAPK returns true if the APK at the device path is part of the system
+// image.
+func isSystemAPK(path string) bool {
+	for _, prefix := range systemAPKPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
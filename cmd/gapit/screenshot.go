@@ -19,8 +19,10 @@ import (
 	"flag"
 	"image"
 	"image/png"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/google/gapid/core/app"
 	"github.com/google/gapid/core/app/flags"
@@ -37,7 +39,8 @@ type screenshotVerb struct{ ScreenshotFlags }
 func init() {
 	verb := &screenshotVerb{
 		ScreenshotFlags{
-			At: flags.U64Slice{},
+			At:     flags.U64Slice{},
+			Format: "png",
 		},
 	}
 
@@ -59,6 +62,11 @@ func (verb *screenshotVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 		return log.Errf(ctx, err, "Finding file: %v", flags.Arg(0))
 	}
 
+	format, err := getExportFormat(verb.Format)
+	if err != nil {
+		return err
+	}
+
 	client, err := getGapis(ctx, verb.Gapis, verb.Gapir)
 	if err != nil {
 		return log.Err(ctx, err, "Failed to connect to the GAPIS server")
@@ -77,6 +85,22 @@ func (verb *screenshotVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 
 	command := capture.Command(verb.At[0], verb.At[1:]...)
 
+	if verb.All {
+		return verb.writeAllAttachments(ctx, command, device, client, format)
+	}
+
+	if format != img.PNG {
+		frame, err := getFrameData(ctx, command, device, client)
+		if err != nil {
+			return err
+		}
+		data, err := flipData(frame).Convert(format)
+		if err != nil {
+			return log.Errf(ctx, err, "Failed to convert frame to %v", format.Name)
+		}
+		return ioutil.WriteFile("screenshot."+format.Name, data.Bytes, 0666)
+	}
+
 	if frame, err := getSingleFrame(ctx, command, device, client); err == nil {
 		return verb.writeSingleFrame(flipImg(frame), "screenshot.png")
 	} else {
@@ -85,6 +109,40 @@ func (verb *screenshotVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 
 }
 
+// writeAllAttachments writes every attachment of the framebuffer after cmd to
+// a separate file. Depth and stencil attachments are written using their
+// visualizations.
+func (verb *screenshotVerb) writeAllAttachments(ctx context.Context, cmd *path.Command, device *path.Device, client service.Service, format *img.Format) error {
+	boxed, err := client.Get(ctx, cmd.FramebufferAttachments(device).Path())
+	if err != nil {
+		return log.Errf(ctx, err, "Get framebuffer attachments failed")
+	}
+	for _, a := range boxed.(*service.FramebufferAttachments).Attachments {
+		ctx := log.V{"attachment": a.Attachment}.Bind(ctx)
+		iip := a.Image
+		if a.Visual != nil {
+			iip = a.Visual
+		}
+		iio, err := client.Get(ctx, iip.Path())
+		if err != nil {
+			return log.Errf(ctx, err, "Get attachment image.Info failed")
+		}
+		data, err := getImageData(ctx, client, iio.(*img.Info))
+		if err != nil {
+			return err
+		}
+		if data, err = flipData(data).Convert(format); err != nil {
+			return log.Errf(ctx, err, "Failed to convert attachment to %v", format.Name)
+		}
+
+		fn := "screenshot_" + strings.ToLower(a.Attachment.String()) + "." + format.Name
+		if err := ioutil.WriteFile(fn, data.Bytes, 0666); err != nil {
+			return log.Errf(ctx, err, "Failed to write %v", fn)
+		}
+	}
+	return nil
+}
+
 func (verb *screenshotVerb) writeSingleFrame(frame image.Image, fn string) error {
 	out, err := os.Create(fn)
 	if err != nil {
@@ -94,7 +152,20 @@ func (verb *screenshotVerb) writeSingleFrame(frame image.Image, fn string) error
 	return png.Encode(out, frame)
 }
 
-func getSingleFrame(ctx context.Context, cmd *path.Command, device *path.Device, client service.Service) (*image.NRGBA, error) {
+// flipData returns a copy of the uncompressed image with the rows in reverse
+// order.
+func flipData(data *img.Data) *img.Data {
+	out := *data
+	out.Bytes = make([]byte, len(data.Bytes))
+	rowSize := data.Format.Size(int(data.Width), 1, 1)
+	for src, dst := 0, len(out.Bytes)-rowSize; dst >= 0; src, dst = src+rowSize, dst-rowSize {
+		copy(out.Bytes[dst:dst+rowSize], data.Bytes[src:src+rowSize])
+	}
+	return &out
+}
+
+// getFrameData returns the color attachment of the framebuffer after cmd.
+func getFrameData(ctx context.Context, cmd *path.Command, device *path.Device, client service.Service) (*img.Data, error) {
 	ctx = log.V{"cmd": cmd.Indices}.Bind(ctx)
 	settings := &service.RenderSettings{MaxWidth: uint32(0xFFFFFFFF), MaxHeight: uint32(0xFFFFFFFF)}
 	iip, err := client.GetFramebufferAttachment(ctx, device, cmd, api.FramebufferAttachment_Color0, settings, nil)
@@ -106,21 +177,25 @@ func getSingleFrame(ctx context.Context, cmd *path.Command, device *path.Device,
 		return nil, log.Errf(ctx, err, "Get frame image.Info failed")
 	}
 	ii := iio.(*img.Info)
-	dataO, err := client.Get(ctx, path.NewBlob(ii.Bytes.ID()).Path())
+	if ii.Width == 0 || ii.Height == 0 {
+		return nil, log.Err(ctx, nil, "Framebuffer has zero dimensions")
+	}
+	return getImageData(ctx, client, ii)
+}
+
+func getSingleFrame(ctx context.Context, cmd *path.Command, device *path.Device, client service.Service) (*image.NRGBA, error) {
+	frame, err := getFrameData(ctx, cmd, device, client)
 	if err != nil {
-		return nil, log.Errf(ctx, err, "Get frame image data failed")
+		return nil, err
 	}
-	w, h, data := int(ii.Width), int(ii.Height), dataO.([]byte)
+	w, h := int(frame.Width), int(frame.Height)
 
 	ctx = log.V{
 		"width":  w,
 		"height": h,
-		"format": ii.Format,
+		"format": frame.Format,
 	}.Bind(ctx)
-	if ii.Width == 0 || ii.Height == 0 {
-		return nil, log.Err(ctx, nil, "Framebuffer has zero dimensions")
-	}
-	data, err = img.Convert(data, w, h, 1, ii.Format, img.RGBA_U8_NORM)
+	data, err := img.Convert(frame.Bytes, w, h, 1, frame.Format, img.RGBA_U8_NORM)
 	if err != nil {
 		return nil, log.Err(ctx, err, "Failed to convert frame to RGBA")
 	}
@@ -0,0 +1,50 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+)
+
+// sidecarRecorder is the interface implemented by types that record device
+// data while a capture is being taken, and store it next to the capture file.
+type sidecarRecorder interface {
+	// start starts recording. s is the capture stream, which is used to find
+	// the commands being traced when the data was recorded.
+	start(ctx context.Context, s *captureStream)
+	// stop stops recording and writes the recorded data next to the capture
+	// file at out.
+	stop(ctx context.Context, out string)
+}
+
+// captureStream is an io.Writer that writes the capture, counting the bytes
+// written.
+type captureStream struct {
+	w       io.Writer
+	written uint64 // Accessed atomically.
+}
+
+func (s *captureStream) Write(p []byte) (int, error) {
+	n, err := s.w.Write(p)
+	atomic.AddUint64(&s.written, uint64(n))
+	return n, err
+}
+
+// offset returns the number of bytes of the capture written so far.
+func (s *captureStream) offset() uint64 {
+	return atomic.LoadUint64(&s.written)
+}
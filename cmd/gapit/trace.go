@@ -35,6 +35,7 @@ import (
 	"github.com/google/gapid/core/os/file"
 	"github.com/google/gapid/core/os/process"
 	"github.com/google/gapid/core/os/shell"
+	"github.com/google/gapid/core/text"
 	"github.com/google/gapid/core/vulkan/loader"
 	"github.com/google/gapid/gapii/client"
 )
@@ -142,8 +143,7 @@ func (verb *traceVerb) startLocalApp(ctx context.Context) (func(), error) {
 		return cleanup, err
 	}
 
-	r := regexp.MustCompile("'.+'|\".+\"|\\S+")
-	args := r.FindAllString(verb.Local.Args, -1)
+	args := text.SplitArgs(verb.Local.Args)
 	ctx, cancel := context.WithCancel(ctx)
 	boundPort, err := process.Start(ctx, verb.Local.App.System(), process.StartOptions{
 		Env:        env,
@@ -310,10 +310,20 @@ func (verb *traceVerb) captureADB(ctx context.Context, flags flag.FlagSet, start
 		}
 	}
 
-	return doCapture(ctx, process, output, start, verb.For)
+	sidecars := []sidecarRecorder{}
+	if !verb.Disable.Logcat {
+		if r := newLogcatRecorder(ctx, d, pkg); r != nil {
+			sidecars = append(sidecars, r)
+		}
+	}
+	if verb.Counters.Interval > 0 {
+		sidecars = append(sidecars, newCounterSampler(d, verb.Counters.Interval))
+	}
+
+	return doCapture(ctx, process, output, start, verb.For, sidecars...)
 }
 
-func doCapture(ctx context.Context, process *client.Process, out string, start task.Signal, duration time.Duration) error {
+func doCapture(ctx context.Context, process *client.Process, out string, start task.Signal, duration time.Duration, sidecars ...sidecarRecorder) error {
 	log.I(ctx, "Creating file '%v'", out)
 	os.MkdirAll(filepath.Dir(out), 0755)
 	file, err := os.Create(out)
@@ -322,11 +332,17 @@ func doCapture(ctx context.Context, process *client.Process, out string, start t
 	}
 	defer file.Close()
 
+	stream := &captureStream{w: file}
+	for _, r := range sidecars {
+		r.start(ctx, stream)
+		defer r.stop(ctx, out)
+	}
+
 	if duration > 0 {
 		ctx, _ = task.WithTimeout(ctx, duration)
 	}
 
-	_, err = process.Capture(ctx, start, file)
+	_, err = process.Capture(ctx, start, stream)
 	if err != nil {
 		return err
 	}
@@ -14,6 +14,12 @@
 
 // Command make-debuggable takes an apk and makes it debuggable, saving it
 // to a different path.
+//
+// The apk is aligned and signed with v1 (JAR) and v2 signatures by
+// make-debuggable itself, so jarsigner and zipalign are no longer needed.
+// The -jarsigner and -zipalign flags that used to locate them are still
+// accepted so that existing scripts keep working, but they are ignored and
+// will be removed in a future release.
 package main
 
 import (
@@ -24,17 +30,20 @@ import (
 	"github.com/google/gapid/core/app"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/core/os/android/apk"
+	"github.com/google/gapid/core/os/android/apksig"
 	"github.com/google/gapid/core/os/file"
 )
 
 var (
-	jarSignCmd     = flag.String("jarsigner", "jarsigner", "path to jarsigner")
-	zipAlignCmd    = flag.String("zipalign", "zipalign", "path to zipalign")
-	keyPass        = flag.String("keypass", "android", "key passphrase")
-	keyAlias       = flag.String("keyalias", "androiddebugkey", "key alias")
-	storePass      = flag.String("storepass", "android", "key store passphrase")
-	keyStore       = flag.String("keystore", "~/.android/debug.keystore", "key store location")
+	keyPass        = flag.String("keypass", apksig.DebugKeyPass, "key passphrase")
+	keyAlias       = flag.String("keyalias", apksig.DebugKeyAlias, "key alias")
+	storePass      = flag.String("storepass", apksig.DebugStorePass, "key store passphrase")
+	keyStore       = flag.String("keystore", "~/.android/debug.keystore", "JKS key store location, created if it does not exist")
 	forceOverwrite = flag.Bool("y", false, "overwrite existing destination")
+
+	// Deprecated: the apk is aligned and signed without external tools.
+	jarSignCmd  = flag.String("jarsigner", "", "deprecated and ignored, jarsigner is no longer used")
+	zipAlignCmd = flag.String("zipalign", "", "deprecated and ignored, zipalign is no longer used")
 )
 
 func main() {
@@ -48,6 +57,11 @@ func run(ctx context.Context) error {
 		app.Usage(ctx, "")
 	}
 
+	if *jarSignCmd != "" || *zipAlignCmd != "" {
+		log.W(ctx, "The -jarsigner and -zipalign flags are deprecated and ignored. "+
+			"The apk is now aligned and signed without external tools.")
+	}
+
 	src := flag.Arg(0)
 	dst := flag.Arg(1)
 
@@ -65,8 +79,6 @@ func run(ctx context.Context) error {
 	}
 
 	return apk.ApkDebugifier{
-		JarSignCmd:   *jarSignCmd,
-		ZipAlignCmd:  *zipAlignCmd,
 		KeyPass:      *keyPass,
 		KeyAlias:     *keyAlias,
 		StorePass:    *storePass,
@@ -25,6 +25,7 @@ set(files
     master.go
     replay.go
     report.go
+    search.go
     stash.go
     subject.go
     trace.go
@@ -35,6 +35,7 @@ import (
 	"github.com/google/gapid/core/os/device/host"
 	"github.com/google/gapid/core/os/file"
 	"github.com/google/gapid/test/robot/build"
+	"github.com/google/gapid/test/robot/search/eval"
 	"github.com/google/gapid/test/robot/search/script"
 	"google.golang.org/grpc"
 )
@@ -278,6 +279,12 @@ func zipArtifacts(ctx context.Context, artifactFile file.Path) error {
 		"gapid/VirtualSwapchainLayer.json": func(ctx context.Context) (file.Path, error) {
 			return layout.Json(ctx, layout.LibVirtualSwapChain)
 		},
+		"gapid/libgapii.so": func(ctx context.Context) (file.Path, error) {
+			return layout.Library(ctx, layout.LibGraphicsSpy)
+		},
+		"gapid/GraphicsSpyLayer.json": func(ctx context.Context) (file.Path, error) {
+			return layout.Json(ctx, layout.LibGraphicsSpy)
+		},
 	}
 	for toolName, pathFunc := range toolSetPathFunc {
 		path, err := pathFunc(ctx)
@@ -318,14 +325,20 @@ func (v *artifactSearchVerb) Run(ctx context.Context, flags flag.FlagSet) error
 	return grpcutil.Client(ctx, v.ServerAddress, func(ctx context.Context, conn *grpc.ClientConn) error {
 		b := build.NewRemote(ctx, conn)
 		expression := strings.Join(flags.Args(), " ")
-		out := os.Stdout
 		expr, err := script.Parse(ctx, expression)
 		if err != nil {
 			return log.Err(ctx, err, "Malformed search query")
 		}
-		return b.SearchArtifacts(ctx, expr.Query(), func(ctx context.Context, entry *build.Artifact) error {
-			proto.MarshalText(out, entry)
-			return nil
+		query := expr.Query()
+		if eval.Aggregated(query) {
+			stream, err := build.NewServiceClient(conn).SearchArtifactRows(ctx, query)
+			if err != nil {
+				return err
+			}
+			return writeSearchRows(ctx, stream, os.Stdout)
+		}
+		return b.SearchArtifacts(ctx, query, func(ctx context.Context, entry *build.Artifact) error {
+			return proto.MarshalText(os.Stdout, entry)
 		})
 	}, grpc.WithInsecure())
 }
@@ -338,14 +351,20 @@ func (v *packageSearchVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 	return grpcutil.Client(ctx, v.ServerAddress, func(ctx context.Context, conn *grpc.ClientConn) error {
 		b := build.NewRemote(ctx, conn)
 		expression := strings.Join(flags.Args(), " ")
-		out := os.Stdout
 		expr, err := script.Parse(ctx, expression)
 		if err != nil {
 			return log.Err(ctx, err, "Malformed search query")
 		}
-		return b.SearchPackages(ctx, expr.Query(), func(ctx context.Context, entry *build.Package) error {
-			proto.MarshalText(out, entry)
-			return nil
+		query := expr.Query()
+		if eval.Aggregated(query) {
+			stream, err := build.NewServiceClient(conn).SearchPackageRows(ctx, query)
+			if err != nil {
+				return err
+			}
+			return writeSearchRows(ctx, stream, os.Stdout)
+		}
+		return b.SearchPackages(ctx, query, func(ctx context.Context, entry *build.Package) error {
+			return proto.MarshalText(os.Stdout, entry)
 		})
 	}, grpc.WithInsecure())
 }
@@ -358,14 +377,20 @@ func (v *trackSearchVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 	return grpcutil.Client(ctx, v.ServerAddress, func(ctx context.Context, conn *grpc.ClientConn) error {
 		b := build.NewRemote(ctx, conn)
 		expression := strings.Join(flags.Args(), " ")
-		out := os.Stdout
 		expr, err := script.Parse(ctx, expression)
 		if err != nil {
 			return log.Err(ctx, err, "Malformed search query")
 		}
-		return b.SearchTracks(ctx, expr.Query(), func(ctx context.Context, entry *build.Track) error {
-			proto.MarshalText(out, entry)
-			return nil
+		query := expr.Query()
+		if eval.Aggregated(query) {
+			stream, err := build.NewServiceClient(conn).SearchTrackRows(ctx, query)
+			if err != nil {
+				return err
+			}
+			return writeSearchRows(ctx, stream, os.Stdout)
+		}
+		return b.SearchTracks(ctx, query, func(ctx context.Context, entry *build.Track) error {
+			return proto.MarshalText(os.Stdout, entry)
 		})
 	}, grpc.WithInsecure())
 }
@@ -31,6 +31,7 @@ import (
 	"github.com/google/gapid/test/robot/monitor"
 	"github.com/google/gapid/test/robot/replay"
 	"github.com/google/gapid/test/robot/report"
+	"github.com/google/gapid/test/robot/search/eval"
 	"github.com/google/gapid/test/robot/search/script"
 	stashgrpc "github.com/google/gapid/test/robot/stash/grpc"
 	"github.com/google/gapid/test/robot/trace"
@@ -65,14 +66,20 @@ func (v *deviceSearchFlags) Run(ctx context.Context, flags flag.FlagSet) error {
 	return grpcutil.Client(ctx, v.ServerAddress, func(ctx context.Context, conn *grpc.ClientConn) error {
 		w := job.NewRemote(ctx, conn)
 		expression := strings.Join(flags.Args(), " ")
-		out := os.Stdout
 		expr, err := script.Parse(ctx, expression)
 		if err != nil {
 			return log.Err(ctx, err, "Malformed search query")
 		}
-		return w.SearchDevices(ctx, expr.Query(), func(ctx context.Context, entry *job.Device) error {
-			proto.MarshalText(out, entry)
-			return nil
+		query := expr.Query()
+		if eval.Aggregated(query) {
+			stream, err := job.NewServiceClient(conn).SearchDeviceRows(ctx, query)
+			if err != nil {
+				return err
+			}
+			return writeSearchRows(ctx, stream, os.Stdout)
+		}
+		return w.SearchDevices(ctx, query, func(ctx context.Context, entry *job.Device) error {
+			return proto.MarshalText(os.Stdout, entry)
 		})
 	}, grpc.WithInsecure())
 }
@@ -85,14 +92,20 @@ func (v *workerSearchFlags) Run(ctx context.Context, flags flag.FlagSet) error {
 	return grpcutil.Client(ctx, v.ServerAddress, func(ctx context.Context, conn *grpc.ClientConn) error {
 		w := job.NewRemote(ctx, conn)
 		expression := strings.Join(flags.Args(), " ")
-		out := os.Stdout
 		expr, err := script.Parse(ctx, expression)
 		if err != nil {
 			return log.Err(ctx, err, "Malformed search query")
 		}
-		return w.SearchWorkers(ctx, expr.Query(), func(ctx context.Context, entry *job.Worker) error {
-			proto.MarshalText(out, entry)
-			return nil
+		query := expr.Query()
+		if eval.Aggregated(query) {
+			stream, err := job.NewServiceClient(conn).SearchWorkerRows(ctx, query)
+			if err != nil {
+				return err
+			}
+			return writeSearchRows(ctx, stream, os.Stdout)
+		}
+		return w.SearchWorkers(ctx, query, func(ctx context.Context, entry *job.Worker) error {
+			return proto.MarshalText(os.Stdout, entry)
 		})
 	}, grpc.WithInsecure())
 }
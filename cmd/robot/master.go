@@ -21,6 +21,7 @@ import (
 	"io/ioutil"
 	"net"
 	"net/url"
+	"os"
 	"strings"
 
 	"github.com/google/gapid/core/app"
@@ -35,6 +36,7 @@ import (
 	"github.com/google/gapid/test/robot/replay"
 	"github.com/google/gapid/test/robot/report"
 	"github.com/google/gapid/test/robot/scheduler"
+	"github.com/google/gapid/test/robot/search/eval"
 	"github.com/google/gapid/test/robot/search/script"
 	"github.com/google/gapid/test/robot/stash"
 	stashgrpc "github.com/google/gapid/test/robot/stash/grpc"
@@ -229,7 +231,15 @@ func (v *masterSearchVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 		if err != nil {
 			return log.Err(ctx, err, "Malformed search query")
 		}
-		return m.Search(ctx, expr.Query(), func(ctx context.Context, entry *master.Satellite) error {
+		query := expr.Query()
+		if eval.Aggregated(query) {
+			stream, err := master.NewServiceClient(conn).SearchRows(ctx, query)
+			if err != nil {
+				return err
+			}
+			return writeSearchRows(ctx, stream, os.Stdout)
+		}
+		return m.Search(ctx, query, func(ctx context.Context, entry *master.Satellite) error {
 			log.I(ctx, "%s", entry.String())
 			return nil
 		})
@@ -25,6 +25,7 @@ import (
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/core/net/grpcutil"
 	"github.com/google/gapid/test/robot/replay"
+	"github.com/google/gapid/test/robot/search/eval"
 	"github.com/google/gapid/test/robot/search/script"
 	"google.golang.org/grpc"
 )
@@ -46,14 +47,20 @@ func (v *replaySearchFlags) Run(ctx context.Context, flags flag.FlagSet) error {
 	return grpcutil.Client(ctx, v.ServerAddress, func(ctx context.Context, conn *grpc.ClientConn) error {
 		replays := replay.NewRemote(ctx, conn)
 		expression := strings.Join(flags.Args(), " ")
-		out := os.Stdout
 		expr, err := script.Parse(ctx, expression)
 		if err != nil {
 			return log.Err(ctx, err, "Malformed search query")
 		}
-		return replays.Search(ctx, expr.Query(), func(ctx context.Context, entry *replay.Action) error {
-			proto.MarshalText(out, entry)
-			return nil
+		query := expr.Query()
+		if eval.Aggregated(query) {
+			stream, err := replay.NewServiceClient(conn).SearchRows(ctx, query)
+			if err != nil {
+				return err
+			}
+			return writeSearchRows(ctx, stream, os.Stdout)
+		}
+		return replays.Search(ctx, query, func(ctx context.Context, entry *replay.Action) error {
+			return proto.MarshalText(os.Stdout, entry)
 		})
 	}, grpc.WithInsecure())
 }
@@ -25,6 +25,7 @@ import (
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/core/net/grpcutil"
 	"github.com/google/gapid/test/robot/report"
+	"github.com/google/gapid/test/robot/search/eval"
 	"github.com/google/gapid/test/robot/search/script"
 	"google.golang.org/grpc"
 )
@@ -46,14 +47,20 @@ func (v *reportSearchFlags) Run(ctx context.Context, flags flag.FlagSet) error {
 	return grpcutil.Client(ctx, v.ServerAddress, func(ctx context.Context, conn *grpc.ClientConn) error {
 		reports := report.NewRemote(ctx, conn)
 		expression := strings.Join(flags.Args(), " ")
-		out := os.Stdout
 		expr, err := script.Parse(ctx, expression)
 		if err != nil {
 			return log.Err(ctx, err, "Malformed search query")
 		}
-		return reports.Search(ctx, expr.Query(), func(ctx context.Context, entry *report.Action) error {
-			proto.MarshalText(out, entry)
-			return nil
+		query := expr.Query()
+		if eval.Aggregated(query) {
+			stream, err := report.NewServiceClient(conn).SearchRows(ctx, query)
+			if err != nil {
+				return err
+			}
+			return writeSearchRows(ctx, stream, os.Stdout)
+		}
+		return reports.Search(ctx, query, func(ctx context.Context, entry *report.Action) error {
+			return proto.MarshalText(os.Stdout, entry)
 		})
 	}, grpc.WithInsecure())
 }
@@ -0,0 +1,43 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/google/gapid/core/event"
+	"github.com/google/gapid/core/net/grpcutil"
+	"github.com/google/gapid/test/robot/search"
+	"github.com/google/gapid/test/robot/search/eval"
+)
+
+// writeSearchRows writes the rows of an aggregated search from a grpc stream to
+// an output stream, one tab separated line per row.
+func writeSearchRows(ctx context.Context, stream interface{}, out io.Writer) error {
+	return event.Feed(ctx, event.AsHandler(ctx, func(ctx context.Context, row *search.Row) error {
+		for i, v := range row.Values {
+			sep := "\t"
+			if i == len(row.Values)-1 {
+				sep = "\n"
+			}
+			if _, err := fmt.Fprint(out, eval.ValueOf(v), sep); err != nil {
+				return err
+			}
+		}
+		return nil
+	}), grpcutil.ToProducer(stream))
+}
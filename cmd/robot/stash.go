@@ -24,6 +24,7 @@ import (
 	"github.com/google/gapid/core/app"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/core/net/grpcutil"
+	"github.com/google/gapid/test/robot/search/eval"
 	"github.com/google/gapid/test/robot/search/script"
 	"github.com/google/gapid/test/robot/stash"
 	stashgrpc "github.com/google/gapid/test/robot/stash/grpc"
@@ -66,14 +67,20 @@ func (v *stashSearchVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 			return err
 		}
 		expression := strings.Join(flags.Args(), " ")
-		out := os.Stdout
 		expr, err := script.Parse(ctx, expression)
 		if err != nil {
 			return log.Err(ctx, err, "Malformed search query")
 		}
-		return store.Search(ctx, expr.Query(), func(ctx context.Context, entry *stash.Entity) error {
-			proto.MarshalText(out, entry)
-			return nil
+		query := expr.Query()
+		if eval.Aggregated(query) {
+			stream, err := stashgrpc.NewServiceClient(conn).SearchRows(ctx, query)
+			if err != nil {
+				return err
+			}
+			return writeSearchRows(ctx, stream, os.Stdout)
+		}
+		return store.Search(ctx, query, func(ctx context.Context, entry *stash.Entity) error {
+			return proto.MarshalText(os.Stdout, entry)
 		})
 	}, grpc.WithInsecure())
 }
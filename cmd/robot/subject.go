@@ -26,6 +26,8 @@ import (
 	"github.com/google/gapid/core/app"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/core/net/grpcutil"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/test/robot/search/eval"
 	"github.com/google/gapid/test/robot/search/script"
 	"github.com/google/gapid/test/robot/subject"
 	"google.golang.org/grpc"
@@ -49,7 +51,14 @@ func init() {
 type subjectUploadVerb struct {
 	RobotOptions
 	TraceTime time.Duration `help:"trace time override (if non-zero)"`
-	subjects  subject.Subjects
+	Host      struct {
+		Launch string `help:"upload host application archives, launched with this archive relative path"`
+		Args   string `help:"arguments to pass to the host application"`
+		Name   string `help:"the name of the host application"`
+		API    string `help:"only trace the given API for the host application, valid options are gles and vulkan"`
+		OS     string `help:"the operating system the host application was built for, detected from the launch executable if not set"`
+	}
+	subjects subject.Subjects
 }
 
 func (v *subjectUploadVerb) Run(ctx context.Context, flags flag.FlagSet) error {
@@ -64,7 +73,24 @@ func (v *subjectUploadVerb) process(ctx context.Context, id string) error {
 	if v.TraceTime != 0 {
 		hints = &subject.Hints{TraceTime: ptypes.DurationProto(v.TraceTime)}
 	}
-	subject, created, err := v.subjects.Add(ctx, id, hints)
+	var hostApp *subject.HostApp
+	if v.Host.Launch != "" {
+		hostApp = &subject.HostApp{
+			Name:   v.Host.Name,
+			Launch: v.Host.Launch,
+			Args:   strings.Fields(v.Host.Args),
+			API:    v.Host.API,
+		}
+		if v.Host.OS != "" {
+			switch kind := device.OSKind(device.OSKind_value[v.Host.OS]); kind {
+			case device.Windows, device.OSX, device.Linux:
+				hostApp.OS = kind
+			default:
+				return log.Errf(ctx, nil, "Invalid host application operating system %s, valid options are Windows, OSX and Linux", v.Host.OS)
+			}
+		}
+	}
+	subject, created, err := v.subjects.Add(ctx, id, hints, hostApp)
 	if err != nil {
 		return log.Err(ctx, err, "Failed processing subject")
 	}
@@ -86,14 +112,20 @@ func (v *subjectSearchVerb) Run(ctx context.Context, flags flag.FlagSet) error {
 	return grpcutil.Client(ctx, v.ServerAddress, func(ctx context.Context, conn *grpc.ClientConn) error {
 		subjects := subject.NewRemote(ctx, conn)
 		expression := strings.Join(flags.Args(), " ")
-		out := os.Stdout
 		expr, err := script.Parse(ctx, expression)
 		if err != nil {
 			return log.Err(ctx, err, "Malformed search query")
 		}
-		return subjects.Search(ctx, expr.Query(), func(ctx context.Context, entry *subject.Subject) error {
-			proto.MarshalText(out, entry)
-			return nil
+		query := expr.Query()
+		if eval.Aggregated(query) {
+			stream, err := subject.NewServiceClient(conn).SearchRows(ctx, query)
+			if err != nil {
+				return err
+			}
+			return writeSearchRows(ctx, stream, os.Stdout)
+		}
+		return subjects.Search(ctx, query, func(ctx context.Context, entry *subject.Subject) error {
+			return proto.MarshalText(os.Stdout, entry)
 		})
 	}, grpc.WithInsecure())
 }
@@ -43,3 +43,14 @@ type Events interface {
 	// identifier.
 	ChildObject(ctx context.Context, msg proto.Message, parentID uint64) error
 }
+
+// ChunkEvents is an optional extension of Events for consumers that need to
+// know where in the stream each object was encoded.
+type ChunkEvents interface {
+	Events
+
+	// ChunkEnd is called before the events of each chunk are delivered, with
+	// the stream offset of the byte following the chunk. Offsets count every
+	// byte of the stream, including the magic and header.
+	ChunkEnd(ctx context.Context, offset uint64) error
+}
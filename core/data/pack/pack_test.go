@@ -17,6 +17,7 @@ package pack_test
 import (
 	"bytes"
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/golang/protobuf/proto"
@@ -133,3 +134,42 @@ func TestReaderWriter(t *testing.T) {
 
 	assert.For(ctx, "events").ThatSlice(got).DeepEquals(expected)
 }
+
+type chunkEvents struct {
+	events
+	offset  uint64
+	offsets []uint64
+}
+
+func (e *chunkEvents) ChunkEnd(ctx context.Context, offset uint64) error {
+	e.offset = offset
+	return nil
+}
+
+func (e *chunkEvents) Object(ctx context.Context, msg proto.Message) error {
+	e.offsets = append(e.offsets, e.offset)
+	return e.events.Object(ctx, msg)
+}
+
+func TestReaderChunkOffsets(t *testing.T) {
+	assert := assert.To(t)
+	ctx := log.Testing(t)
+	buf := &bytes.Buffer{}
+
+	w, err := pack.NewWriter(buf)
+	assert.For("NewWriter").ThatError(err).Succeeded()
+
+	// Large objects force the reader to refill its buffer part way through the
+	// stream, small ones leave several chunks buffered ahead of the reader.
+	expected := []uint64{}
+	for i := 0; i < 64; i++ {
+		msg := &testprotos.MsgA{U32: uint32(i), Str: strings.Repeat("x", i*i)}
+		assert.For("Object").ThatError(w.Object(ctx, msg)).Succeeded()
+		expected = append(expected, uint64(buf.Len()))
+	}
+
+	got := &chunkEvents{}
+	err = pack.Read(ctx, buf, got)
+	assert.For("Read").ThatError(err).Succeeded()
+	assert.For("offsets").ThatSlice(got.offsets).Equals(expected)
+}
@@ -70,12 +70,18 @@ type reader struct {
 	bufOffset int
 	pb        *proto.Buffer
 	from      io.Reader
+	read      uint64 // total number of bytes read from from.
 }
 
 func (r *reader) unmarshal(ctx context.Context) error {
 	if err := r.readChunk(); err != nil {
 		return err
 	}
+	if c, ok := r.events.(ChunkEvents); ok {
+		if err := c.ChunkEnd(ctx, r.offset()); err != nil {
+			return err
+		}
+	}
 	tag, err := r.pb.DecodeZigzag64()
 	if err != nil {
 		return err
@@ -185,6 +191,12 @@ func (r *reader) readChunk() error {
 	return r.readN(int(size))
 }
 
+// offset returns the stream offset of the first byte that has not yet been
+// consumed from the buffer.
+func (r *reader) offset() uint64 {
+	return r.read - uint64(len(r.buf)-r.bufOffset)
+}
+
 // readN makes sure there is size bytes available in the buffer if possible
 func (r *reader) readN(size int) error {
 	remains := r.buf[r.bufOffset:]
@@ -208,6 +220,7 @@ func (r *reader) readN(size int) error {
 	copy(r.buf, remains)
 	// Read at least the extra bytes we need, but possibly more
 	n, err := io.ReadAtLeast(r.from, r.buf[len(remains):], extra)
+	r.read += uint64(n)
 	// Slice back down to the amount we actually got
 	r.buf = r.buf[:len(remains)+n]
 	if size > len(r.buf) {
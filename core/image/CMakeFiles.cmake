@@ -20,29 +20,52 @@
 set(files
     astc.go
     atc.go
+    bc6h.go
+    bc7.go
+    bptc.go
     convert.go
     convertable.go
+    dds.go
+    dds_test.go
     decompress_test.go
     doc.go
+    encode.go
+    encode_test.go
     etc1.go
     etc2.go
+    etc2_encode.go
+    exr.go
     format.go
+    hdr.go
+    hdr_test.go
     id.go
     image.go
     image.pb.go
     image.proto
     image_test.go
+    ktx.go
+    ktx_format.go
+    ktx_test.go
     png.go
+    pvrtc.go
+    pvrtc_test.go
     resizer.go
     rgba_f32.go
     rgba_f32_test.go
+    rgtc.go
+    rgtc_encode.go
     s3.go
     s3_dxt1_rgb.go
     s3_dxt1_rgba.go
     s3_dxt3_rgba.go
     s3_dxt5_rgba.go
+    s3_encode.go
+    texture.go
+    texture_test.go
     thumbnailer.go
     uncompressed.go
+    visualize.go
+    visualize_test.go
 )
 set(dirs
     font
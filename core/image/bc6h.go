@@ -0,0 +1,300 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/gapid/core/data/binary"
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/math/f16"
+	"github.com/google/gapid/core/math/sint"
+	"github.com/google/gapid/core/os/device"
+)
+
+// bc6hMode describes the layout of a BC6H block for one of the 14 modes.
+type bc6hMode struct {
+	regions     int
+	transformed bool   // The endpoints other than the first are deltas.
+	bits        uint32 // Precision of the endpoints.
+	deltaBits   [3]uint32
+	layout      []bc6hBits
+}
+
+// bc6hBits is a run of bits of a block header, assigned to the bits of a
+// field in order from first to last.
+type bc6hBits struct {
+	field       int
+	first, last uint32
+}
+
+// The indices of the header fields. w, x, y and z are the endpoints of the
+// block, each holding red, green and blue values. d is the partition.
+const (
+	bc6hW = 0
+	bc6hX = 3
+	bc6hY = 6
+	bc6hZ = 9
+	bc6hD = 12
+)
+
+// bc6hModes holds the modes keyed by their 2 or 5 bit mode value.
+// The layouts use the notation of the BC6H specification. A field written as
+// [hi:lo] is stored least significant bit first, [lo:hi] is stored most
+// significant bit first.
+var bc6hModes = map[uint64]*bc6hMode{
+	0x00: newBC6HMode(2, true, 10, 5, 5, 5, "gy[4] by[4] bz[4] rw[9:0] gw[9:0] bw[9:0] rx[4:0] gz[4] gy[3:0] gx[4:0] bz[0] gz[3:0] bx[4:0] bz[1] by[3:0] ry[4:0] bz[2] rz[4:0] bz[3] d[4:0]"),
+	0x01: newBC6HMode(2, true, 7, 6, 6, 6, "gy[5] gz[4] gz[5] rw[6:0] bz[0] bz[1] by[4] gw[6:0] by[5] bz[2] gy[4] bw[6:0] bz[3] bz[5] bz[4] rx[5:0] gy[3:0] gx[5:0] gz[3:0] bx[5:0] by[3:0] ry[5:0] rz[5:0] d[4:0]"),
+	0x02: newBC6HMode(2, true, 11, 5, 4, 4, "rw[9:0] gw[9:0] bw[9:0] rx[4:0] rw[10] gy[3:0] gx[3:0] gw[10] bz[0] gz[3:0] bx[3:0] bw[10] bz[1] by[3:0] ry[4:0] bz[2] rz[4:0] bz[3] d[4:0]"),
+	0x06: newBC6HMode(2, true, 11, 4, 5, 4, "rw[9:0] gw[9:0] bw[9:0] rx[3:0] rw[10] gz[4] gy[3:0] gx[4:0] gw[10] gz[3:0] bx[3:0] bw[10] bz[1] by[3:0] ry[3:0] bz[0] bz[2] rz[3:0] gy[4] bz[3] d[4:0]"),
+	0x0a: newBC6HMode(2, true, 11, 4, 4, 5, "rw[9:0] gw[9:0] bw[9:0] rx[3:0] rw[10] by[4] gy[3:0] gx[3:0] gw[10] bz[0] gz[3:0] bx[4:0] bw[10] by[3:0] ry[3:0] bz[1] bz[2] rz[3:0] bz[4] bz[3] d[4:0]"),
+	0x0e: newBC6HMode(2, true, 9, 5, 5, 5, "rw[8:0] by[4] gw[8:0] gy[4] bw[8:0] bz[4] rx[4:0] gz[4] gy[3:0] gx[4:0] bz[0] gz[3:0] bx[4:0] bz[1] by[3:0] ry[4:0] bz[2] rz[4:0] bz[3] d[4:0]"),
+	0x12: newBC6HMode(2, true, 8, 6, 5, 5, "rw[7:0] gz[4] by[4] gw[7:0] bz[2] gy[4] bw[7:0] bz[3] bz[4] rx[5:0] gy[3:0] gx[4:0] bz[0] gz[3:0] bx[4:0] bz[1] by[3:0] ry[5:0] rz[5:0] d[4:0]"),
+	0x16: newBC6HMode(2, true, 8, 5, 6, 5, "rw[7:0] bz[0] by[4] gw[7:0] gy[5] gy[4] bw[7:0] gz[5] bz[4] rx[4:0] gz[4] gy[3:0] gx[5:0] gz[3:0] bx[4:0] bz[1] by[3:0] ry[4:0] bz[2] rz[4:0] bz[3] d[4:0]"),
+	0x1a: newBC6HMode(2, true, 8, 5, 5, 6, "rw[7:0] bz[1] by[4] gw[7:0] by[5] gy[4] bw[7:0] bz[5] bz[4] rx[4:0] gz[4] gy[3:0] gx[4:0] bz[0] gz[3:0] bx[5:0] by[3:0] ry[4:0] bz[2] rz[4:0] bz[3] d[4:0]"),
+	0x1e: newBC6HMode(2, false, 6, 6, 6, 6, "rw[5:0] gz[4] bz[0] bz[1] by[4] gw[5:0] gy[5] by[5] bz[2] gy[4] bw[5:0] gz[5] bz[3] bz[5] bz[4] rx[5:0] gy[3:0] gx[5:0] gz[3:0] bx[5:0] by[3:0] ry[5:0] rz[5:0] d[4:0]"),
+	0x03: newBC6HMode(1, false, 10, 10, 10, 10, "rw[9:0] gw[9:0] bw[9:0] rx[9:0] gx[9:0] bx[9:0]"),
+	0x07: newBC6HMode(1, true, 11, 9, 9, 9, "rw[9:0] gw[9:0] bw[9:0] rx[8:0] rw[10] gx[8:0] gw[10] bx[8:0] bw[10]"),
+	0x0b: newBC6HMode(1, true, 12, 8, 8, 8, "rw[9:0] gw[9:0] bw[9:0] rx[7:0] rw[10:11] gx[7:0] gw[10:11] bx[7:0] bw[10:11]"),
+	0x0f: newBC6HMode(1, true, 16, 4, 4, 4, "rw[9:0] gw[9:0] bw[9:0] rx[3:0] rw[10:15] gx[3:0] gw[10:15] bx[3:0] bw[10:15]"),
+}
+
+// newBC6HMode returns a new bc6hMode, parsing the layout.
+func newBC6HMode(regions int, transformed bool, bits, deltaR, deltaG, deltaB uint32, layout string) *bc6hMode {
+	m := &bc6hMode{
+		regions:     regions,
+		transformed: transformed,
+		bits:        bits,
+		deltaBits:   [3]uint32{deltaR, deltaG, deltaB},
+	}
+	for _, f := range strings.Fields(layout) {
+		open, sep := strings.IndexRune(f, '['), strings.IndexRune(f, ':')
+		name, bits := f[:open], f[open+1:len(f)-1]
+		b := bc6hBits{}
+		switch name[len(name)-1] {
+		case 'w':
+			b.field = bc6hW
+		case 'x':
+			b.field = bc6hX
+		case 'y':
+			b.field = bc6hY
+		case 'z':
+			b.field = bc6hZ
+		}
+		switch name[0] {
+		case 'g':
+			b.field++
+		case 'b':
+			b.field += 2
+		case 'd':
+			b.field = bc6hD
+		}
+		if sep < 0 {
+			b.first, b.last = mustParseUint32(bits), mustParseUint32(bits)
+		} else {
+			b.first, b.last = mustParseUint32(f[sep+1:len(f)-1]), mustParseUint32(f[open+1:sep])
+		}
+		m.layout = append(m.layout, b)
+	}
+	return m
+}
+
+func mustParseUint32(s string) uint32 {
+	v, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		panic(fmt.Errorf("Invalid BC6H layout bit '%s'", s))
+	}
+	return uint32(v)
+}
+
+func decodeBC6H(src []byte, width, height, depth int, signed bool) ([]byte, error) {
+	texels, err := decodeBC6HTexels(src, width, height, depth, signed)
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, len(texels)*4*4))
+	w := endian.Writer(buf, device.LittleEndian)
+	for _, t := range texels {
+		w.Float32(t[0])
+		w.Float32(t[1])
+		w.Float32(t[2])
+		w.Float32(1)
+	}
+	return buf.Bytes(), w.Error()
+}
+
+// decodeBC6HToU8 decodes the BC6H image to RGBA_U8_NORM, clamping the
+// colors to [0, 1].
+func decodeBC6HToU8(src []byte, width, height, depth int, signed bool) ([]byte, error) {
+	texels, err := decodeBC6HTexels(src, width, height, depth, signed)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(texels)*4)
+	for _, t := range texels {
+		for _, f := range t {
+			out = append(out, sint.Byte(int(float64(f)*255+0.5)))
+		}
+		out = append(out, 255)
+	}
+	return out, nil
+}
+
+// decodeBC6HTexels returns the RGB values of each texel of the BC6H image.
+func decodeBC6HTexels(src []byte, width, height, depth int, signed bool) ([][3]float32, error) {
+	dst := make([][3]float32, width*height*depth)
+	block, texels := make([]byte, 16), [16][3]float32{}
+	r := endian.Reader(bytes.NewReader(src), device.LittleEndian)
+	for z := 0; z < depth; z++ {
+		dst := dst[z*width*height:]
+		for y := 0; y < height; y += 4 {
+			for x := 0; x < width; x += 4 {
+				r.Data(block)
+				decodeBC6HBlock(block, &texels, signed)
+				for dy := 0; dy < 4 && y+dy < height; dy++ {
+					for dx := 0; dx < 4 && x+dx < width; dx++ {
+						dst[(y+dy)*width+x+dx] = texels[dy*4+dx]
+					}
+				}
+			}
+		}
+	}
+	return dst, r.Error()
+}
+
+// decodeBC6HBlock decodes the 16 byte BC6H block into the 16 texels of dst.
+func decodeBC6HBlock(block []byte, dst *[16][3]float32, signed bool) {
+	s := binary.BitStream{Data: block}
+	mode := s.Read(2)
+	if mode > 1 {
+		mode |= s.Read(3) << 2
+	}
+	m, ok := bc6hModes[mode]
+	if !ok {
+		// Reserved mode. Decodes to black.
+		*dst = [16][3]float32{}
+		return
+	}
+
+	fields := [13]int{}
+	for _, b := range m.layout {
+		for i := b.first; ; {
+			fields[b.field] |= int(s.ReadBit()) << i
+			if i == b.last {
+				break
+			}
+			if b.first < b.last {
+				i++
+			} else {
+				i--
+			}
+		}
+	}
+
+	// Reconstruct the endpoints: w and x for the first region, y and z for
+	// the second. Deltas are relative to the quantized w endpoint.
+	endpoints := [4][3]int{}
+	for c := 0; c < 3; c++ {
+		w := fields[bc6hW+c]
+		if signed {
+			w = bc6hSignExtend(w, m.bits)
+		}
+		for e := 0; e < m.regions*2; e++ {
+			v := fields[e*3+c]
+			switch {
+			case e == 0:
+				v = w
+			case m.transformed:
+				v = bc6hSignExtend(v, m.deltaBits[c])
+				v = (v + w) & (1<<m.bits - 1)
+				if signed {
+					v = bc6hSignExtend(v, m.bits)
+				}
+			case signed:
+				v = bc6hSignExtend(v, m.deltaBits[c])
+			}
+			endpoints[e][c] = bc6hUnquantize(v, m.bits, signed)
+		}
+	}
+
+	partition := fields[bc6hD]
+	indexBits := 3
+	if m.regions == 1 {
+		indexBits = 4
+	}
+	for i := range dst {
+		bits := uint32(indexBits)
+		if bptcIsAnchor(m.regions, partition, i) {
+			bits--
+		}
+		index := int(s.Read(bits))
+		e := endpoints[bptcSubset(m.regions, partition, i)*2:]
+		for c := 0; c < 3; c++ {
+			v := bptcInterpolate(e[0][c], e[1][c], index, indexBits)
+			dst[i][c] = bc6hFinishUnquantize(v, signed).Float32()
+		}
+	}
+}
+
+func bc6hSignExtend(v int, bits uint32) int {
+	shift := 32 - bits
+	return int(int32(uint32(v)<<shift) >> shift)
+}
+
+// bc6hUnquantize expands the endpoint value v of the given precision to the
+// 16 bit range used for interpolation.
+func bc6hUnquantize(v int, bits uint32, signed bool) int {
+	if !signed {
+		switch {
+		case bits >= 15 || v == 0:
+			return v
+		case v == 1<<bits-1:
+			return 0xffff
+		default:
+			return (v<<16 + 0x8000) >> bits
+		}
+	}
+	if bits >= 16 || v == 0 {
+		return v
+	}
+	neg := v < 0
+	if neg {
+		v = -v
+	}
+	if v >= 1<<(bits-1)-1 {
+		v = 0x7fff
+	} else {
+		v = (v<<15 + 0x4000) >> (bits - 1)
+	}
+	if neg {
+		return -v
+	}
+	return v
+}
+
+// bc6hFinishUnquantize scales the interpolated value v to the half-float
+// range.
+func bc6hFinishUnquantize(v int, signed bool) f16.Number {
+	if !signed {
+		return f16.Number((v * 31) >> 6)
+	}
+	if v < 0 {
+		return f16.Number(0x8000 | ((-v * 31) >> 5))
+	}
+	return f16.Number((v * 31) >> 5)
+}
@@ -0,0 +1,172 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"github.com/google/gapid/core/data/binary"
+)
+
+// bc7Mode describes the layout of a BC7 block for one of the 8 modes.
+type bc7Mode struct {
+	subsets            int
+	partitionBits      uint32
+	rotationBits       uint32
+	indexSelectionBits uint32
+	colorBits          uint32
+	alphaBits          uint32
+	endpointPBits      bool // One P-bit per endpoint.
+	sharedPBits        bool // One P-bit per subset.
+	indexBits          uint32
+	secondaryIndexBits uint32
+}
+
+var bc7Modes = [8]bc7Mode{
+	{3, 4, 0, 0, 4, 0, true, false, 3, 0},
+	{2, 6, 0, 0, 6, 0, false, true, 3, 0},
+	{3, 6, 0, 0, 5, 0, false, false, 2, 0},
+	{2, 6, 0, 0, 7, 0, true, false, 2, 0},
+	{1, 0, 2, 1, 5, 6, false, false, 2, 3},
+	{1, 0, 2, 0, 7, 8, false, false, 2, 2},
+	{1, 0, 0, 0, 7, 7, true, false, 4, 0},
+	{2, 6, 0, 0, 5, 5, true, false, 2, 0},
+}
+
+func decodeBC7(src []byte, width, height, depth int) ([]byte, error) {
+	block := make([]byte, 16)
+	return decode4x4Blocks(src, width, height, depth, func(r binary.Reader, dst []pixel) {
+		r.Data(block)
+		decodeBC7Block(block, dst)
+	})
+}
+
+// decodeBC7Block decodes the 16 byte BC7 block into the 16 texels of dst.
+func decodeBC7Block(block []byte, dst []pixel) {
+	s := binary.BitStream{Data: block}
+	mode := 0
+	for mode < len(bc7Modes) && s.ReadBit() == 0 {
+		mode++
+	}
+	if mode == len(bc7Modes) {
+		// Reserved mode. Decodes to transparent black.
+		for i := range dst {
+			dst[i] = pixel{}
+		}
+		return
+	}
+	m := bc7Modes[mode]
+	partition := int(s.Read(m.partitionBits))
+	rotation := s.Read(m.rotationBits)
+	indexSelection := s.Read(m.indexSelectionBits)
+
+	// endpoints holds the RGBA endpoint pairs of each subset.
+	endpoints := [3][2][4]int{}
+	for c := 0; c < 4; c++ {
+		bits := m.colorBits
+		if c == 3 {
+			bits = m.alphaBits
+		}
+		for i := 0; i < m.subsets; i++ {
+			for e := range endpoints[i] {
+				endpoints[i][e][c] = int(s.Read(bits))
+			}
+		}
+	}
+
+	// Append the P-bits as the new least significant bit of each channel.
+	colorBits, alphaBits := int(m.colorBits), int(m.alphaBits)
+	if m.endpointPBits || m.sharedPBits {
+		colorBits++
+		if alphaBits > 0 {
+			alphaBits++
+		}
+		for i := 0; i < m.subsets; i++ {
+			p := 0
+			for e := range endpoints[i] {
+				if m.endpointPBits || e == 0 {
+					p = int(s.ReadBit())
+				}
+				for c := range endpoints[i][e] {
+					endpoints[i][e][c] = endpoints[i][e][c]<<1 | p
+				}
+			}
+		}
+	}
+
+	// Expand the endpoints to 8 bits by replicating the most significant bits.
+	expand := func(v, bits int) int {
+		v <<= uint(8 - bits)
+		return v | v>>uint(bits)
+	}
+	for i := 0; i < m.subsets; i++ {
+		for e := range endpoints[i] {
+			ep := &endpoints[i][e]
+			for c := 0; c < 3; c++ {
+				ep[c] = expand(ep[c], colorBits)
+			}
+			if alphaBits > 0 {
+				ep[3] = expand(ep[3], alphaBits)
+			} else {
+				ep[3] = 255
+			}
+		}
+	}
+
+	indices := [16]int{}
+	for i := range indices {
+		bits := m.indexBits
+		if bptcIsAnchor(m.subsets, partition, i) {
+			bits--
+		}
+		indices[i] = int(s.Read(bits))
+	}
+	secondary := [16]int{}
+	if m.secondaryIndexBits > 0 {
+		for i := range secondary {
+			bits := m.secondaryIndexBits
+			if i == 0 {
+				bits--
+			}
+			secondary[i] = int(s.Read(bits))
+		}
+	}
+
+	for i := range dst {
+		ep := endpoints[bptcSubset(m.subsets, partition, i)]
+		colorIndex, colorIndexBits := indices[i], int(m.indexBits)
+		alphaIndex, alphaIndexBits := colorIndex, colorIndexBits
+		if m.secondaryIndexBits > 0 {
+			alphaIndex, alphaIndexBits = secondary[i], int(m.secondaryIndexBits)
+			if indexSelection == 1 {
+				colorIndex, colorIndexBits, alphaIndex, alphaIndexBits =
+					alphaIndex, alphaIndexBits, colorIndex, colorIndexBits
+			}
+		}
+		p := pixel{
+			bptcInterpolate(ep[0][0], ep[1][0], colorIndex, colorIndexBits),
+			bptcInterpolate(ep[0][1], ep[1][1], colorIndex, colorIndexBits),
+			bptcInterpolate(ep[0][2], ep[1][2], colorIndex, colorIndexBits),
+			bptcInterpolate(ep[0][3], ep[1][3], alphaIndex, alphaIndexBits),
+		}
+		switch rotation {
+		case 1:
+			p.r, p.a = p.a, p.r
+		case 2:
+			p.g, p.a = p.a, p.g
+		case 3:
+			p.b, p.a = p.a, p.b
+		}
+		dst[i] = p
+	}
+}
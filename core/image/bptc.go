@@ -0,0 +1,218 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"github.com/google/gapid/core/math/sint"
+	"github.com/google/gapid/core/stream"
+)
+
+var (
+	BC6H_RGB_U16_FLOAT = NewBC6H_RGB_U16_FLOAT("BC6H_RGB_U16_FLOAT")
+	BC6H_RGB_S16_FLOAT = NewBC6H_RGB_S16_FLOAT("BC6H_RGB_S16_FLOAT")
+	BC7_RGBA_U8_NORM   = NewBC7_RGBA_U8_NORM("BC7_RGBA_U8_NORM")
+	BC7_SRGBA_U8_NORM  = NewBC7_SRGBA_U8_NORM("BC7_SRGBA_U8_NORM")
+)
+
+// NewBC6H_RGB_U16_FLOAT returns a format representing the
+// COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT block texture compression format.
+func NewBC6H_RGB_U16_FLOAT(name string) *Format {
+	return &Format{name, &Format_Bc6HRgbU16Float{&FmtBC6H_RGB_U16_FLOAT{}}}
+}
+
+func (f *FmtBC6H_RGB_U16_FLOAT) key() interface{} {
+	return *f
+}
+func (*FmtBC6H_RGB_U16_FLOAT) size(w, h, d int) int {
+	return d * (sint.Max(sint.AlignUp(w, 4), 4) * sint.Max(sint.AlignUp(h, 4), 4))
+}
+func (f *FmtBC6H_RGB_U16_FLOAT) check(data []byte, w, h, d int) error {
+	return checkSize(data, f, w, h, d)
+}
+func (*FmtBC6H_RGB_U16_FLOAT) channels() []stream.Channel {
+	return []stream.Channel{stream.Channel_Red, stream.Channel_Green, stream.Channel_Blue}
+}
+
+// NewBC6H_RGB_S16_FLOAT returns a format representing the
+// COMPRESSED_RGB_BPTC_SIGNED_FLOAT block texture compression format.
+func NewBC6H_RGB_S16_FLOAT(name string) *Format {
+	return &Format{name, &Format_Bc6HRgbS16Float{&FmtBC6H_RGB_S16_FLOAT{}}}
+}
+
+func (f *FmtBC6H_RGB_S16_FLOAT) key() interface{} {
+	return *f
+}
+func (*FmtBC6H_RGB_S16_FLOAT) size(w, h, d int) int {
+	return d * (sint.Max(sint.AlignUp(w, 4), 4) * sint.Max(sint.AlignUp(h, 4), 4))
+}
+func (f *FmtBC6H_RGB_S16_FLOAT) check(data []byte, w, h, d int) error {
+	return checkSize(data, f, w, h, d)
+}
+func (*FmtBC6H_RGB_S16_FLOAT) channels() []stream.Channel {
+	return []stream.Channel{stream.Channel_Red, stream.Channel_Green, stream.Channel_Blue}
+}
+
+// NewBC7_RGBA_U8_NORM returns a format representing the
+// COMPRESSED_RGBA_BPTC_UNORM block texture compression format.
+func NewBC7_RGBA_U8_NORM(name string) *Format {
+	return &Format{name, &Format_Bc7RgbaU8Norm{&FmtBC7_RGBA_U8_NORM{}}}
+}
+
+// NewBC7_SRGBA_U8_NORM returns a format representing the
+// COMPRESSED_SRGB_ALPHA_BPTC_UNORM block texture compression format.
+func NewBC7_SRGBA_U8_NORM(name string) *Format {
+	return &Format{name, &Format_Bc7RgbaU8Norm{&FmtBC7_RGBA_U8_NORM{Srgb: true}}}
+}
+
+func (f *FmtBC7_RGBA_U8_NORM) key() interface{} {
+	return *f
+}
+func (*FmtBC7_RGBA_U8_NORM) size(w, h, d int) int {
+	return d * (sint.Max(sint.AlignUp(w, 4), 4) * sint.Max(sint.AlignUp(h, 4), 4))
+}
+func (f *FmtBC7_RGBA_U8_NORM) check(data []byte, w, h, d int) error {
+	return checkSize(data, f, w, h, d)
+}
+func (*FmtBC7_RGBA_U8_NORM) channels() []stream.Channel {
+	return []stream.Channel{stream.Channel_Red, stream.Channel_Green, stream.Channel_Blue, stream.Channel_Alpha}
+}
+
+func init() {
+	RegisterConverter(BC6H_RGB_U16_FLOAT, RGBA_F32, func(src []byte, w, h, d int) ([]byte, error) {
+		return decodeBC6H(src, w, h, d, false)
+	})
+	RegisterConverter(BC6H_RGB_S16_FLOAT, RGBA_F32, func(src []byte, w, h, d int) ([]byte, error) {
+		return decodeBC6H(src, w, h, d, true)
+	})
+	RegisterConverter(BC6H_RGB_U16_FLOAT, RGBA_U8_NORM, func(src []byte, w, h, d int) ([]byte, error) {
+		return decodeBC6HToU8(src, w, h, d, false)
+	})
+	RegisterConverter(BC6H_RGB_S16_FLOAT, RGBA_U8_NORM, func(src []byte, w, h, d int) ([]byte, error) {
+		return decodeBC6HToU8(src, w, h, d, true)
+	})
+	RegisterConverter(BC7_RGBA_U8_NORM, RGBA_U8_NORM, func(src []byte, w, h, d int) ([]byte, error) {
+		return decodeBC7(src, w, h, d)
+	})
+	RegisterConverter(BC7_SRGBA_U8_NORM, SRGBA_U8_NORM, func(src []byte, w, h, d int) ([]byte, error) {
+		return decodeBC7(src, w, h, d)
+	})
+}
+
+// bptcPartitions2 holds the subset of each texel for the 64 two-subset
+// partitions, one bit per texel.
+var bptcPartitions2 = [64]uint16{
+	0xcccc, 0x8888, 0xeeee, 0xecc8, 0xc880, 0xfeec, 0xfec8, 0xec80,
+	0xc800, 0xffec, 0xfe80, 0xe800, 0xffe8, 0xff00, 0xfff0, 0xf000,
+	0xf710, 0x008e, 0x7100, 0x08ce, 0x008c, 0x7310, 0x3100, 0x8cce,
+	0x088c, 0x3110, 0x6666, 0x366c, 0x17e8, 0x0ff0, 0x718e, 0x399c,
+	0xaaaa, 0xf0f0, 0x5a5a, 0x33cc, 0x3c3c, 0x55aa, 0x9696, 0xa55a,
+	0x73ce, 0x13c8, 0x324c, 0x3bdc, 0x6996, 0xc33c, 0x9966, 0x0660,
+	0x0272, 0x04e4, 0x4e40, 0x2720, 0xc936, 0x936c, 0x39c6, 0x639c,
+	0x9336, 0x9cc6, 0x817e, 0xe718, 0xccf0, 0x0fcc, 0x7744, 0xee22,
+}
+
+// bptcPartitions3 holds the subset of each texel for the 64 three-subset
+// partitions, two bits per texel.
+var bptcPartitions3 = [64]uint32{
+	0xaa685050, 0x6a5a5040, 0x5a5a4200, 0x5450a0a8, 0xa5a50000, 0xa0a05050, 0x5555a0a0, 0x5a5a5050,
+	0xaa550000, 0xaa555500, 0xaaaa5500, 0x90909090, 0x94949494, 0xa4a4a4a4, 0xa9a59450, 0x2a0a4250,
+	0xa5945040, 0x0a425054, 0xa5a5a500, 0x55a0a0a0, 0xa8a85454, 0x6a6a4040, 0xa4a45000, 0x1a1a0500,
+	0x0050a4a4, 0xaaa59090, 0x14696914, 0x69691400, 0xa08585a0, 0xaa821414, 0x50a4a450, 0x6a5a0200,
+	0xa9a58000, 0x5090a0a8, 0xa8a09050, 0x24242424, 0x00aa5500, 0x24924924, 0x24499224, 0x50a50a50,
+	0x500aa550, 0xaaaa4444, 0x66660000, 0xa5a0a5a0, 0x50a050a0, 0x69286928, 0x44aaaa44, 0x66666600,
+	0xaa444444, 0x54a854a8, 0x95809580, 0x96969600, 0xa85454a8, 0x80959580, 0xaa141414, 0x96960000,
+	0xaaaa1414, 0xa05050a0, 0xa0a5a5a0, 0x96000000, 0x40804080, 0xa9a8a9a8, 0xaaaaaa44, 0x2a4a5254,
+}
+
+// bptcAnchors2 holds the index of the anchor texel of the second subset of
+// the two-subset partitions.
+var bptcAnchors2 = [64]uint8{
+	15, 15, 15, 15, 15, 15, 15, 15,
+	15, 15, 15, 15, 15, 15, 15, 15,
+	15, 2, 8, 2, 2, 8, 8, 15,
+	2, 8, 2, 2, 8, 8, 2, 2,
+	15, 15, 6, 8, 2, 8, 15, 15,
+	2, 8, 2, 2, 2, 15, 15, 6,
+	6, 2, 6, 8, 15, 15, 2, 2,
+	15, 15, 15, 15, 15, 2, 2, 15,
+}
+
+// bptcAnchors3 holds the indices of the anchor texels of the second and third
+// subsets of the three-subset partitions.
+var bptcAnchors3 = [2][64]uint8{
+	{
+		3, 3, 15, 15, 8, 3, 15, 15,
+		8, 8, 6, 6, 6, 5, 3, 3,
+		3, 3, 8, 15, 3, 3, 6, 10,
+		5, 8, 8, 6, 8, 5, 15, 15,
+		8, 15, 3, 5, 6, 10, 8, 15,
+		15, 3, 15, 5, 15, 15, 15, 15,
+		3, 15, 5, 5, 5, 8, 5, 10,
+		5, 10, 8, 13, 15, 12, 3, 3,
+	},
+	{
+		15, 8, 8, 3, 15, 15, 3, 8,
+		15, 15, 15, 15, 15, 15, 15, 8,
+		15, 8, 15, 3, 15, 8, 15, 8,
+		3, 15, 6, 10, 15, 15, 10, 8,
+		15, 3, 15, 10, 10, 8, 9, 10,
+		6, 15, 8, 15, 3, 6, 6, 8,
+		15, 3, 15, 15, 15, 15, 15, 15,
+		15, 15, 15, 15, 3, 15, 15, 8,
+	},
+}
+
+// bptcWeights holds the interpolation weights for 2, 3 and 4 bit indices.
+var bptcWeights = [5][]int{
+	2: {0, 21, 43, 64},
+	3: {0, 9, 18, 27, 37, 46, 55, 64},
+	4: {0, 4, 9, 13, 17, 21, 26, 30, 34, 38, 43, 47, 51, 55, 60, 64},
+}
+
+// bptcSubset returns the subset of texel i for the given partition of a block
+// with the given number of subsets.
+func bptcSubset(subsets, partition, i int) int {
+	switch subsets {
+	case 2:
+		return int(bptcPartitions2[partition]>>uint(i)) & 1
+	case 3:
+		return int(bptcPartitions3[partition]>>uint(i*2)) & 3
+	default:
+		return 0
+	}
+}
+
+// bptcIsAnchor returns true if texel i is the anchor texel of a subset for
+// the given partition of a block with the given number of subsets. The index
+// of an anchor texel is stored with one less bit.
+func bptcIsAnchor(subsets, partition, i int) bool {
+	switch {
+	case i == 0:
+		return true
+	case subsets == 2:
+		return i == int(bptcAnchors2[partition])
+	case subsets == 3:
+		return i == int(bptcAnchors3[0][partition]) || i == int(bptcAnchors3[1][partition])
+	default:
+		return false
+	}
+}
+
+// bptcInterpolate returns the value interpolated between e0 and e1 by the
+// weight for index with the given number of index bits.
+func bptcInterpolate(e0, e1, index, indexBits int) int {
+	w := bptcWeights[indexBits][index]
+	return ((64-w)*e0 + w*e1 + 32) >> 6
+}
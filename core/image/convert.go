@@ -27,9 +27,18 @@ import (
 // the converted image data is returned, otherwise an error is returned.
 type Converter func(data []byte, width, height, depth int) ([]byte, error)
 
+// Encoder is used to encode the image formed from the parameters data, width,
+// height and depth into a block compressed format, using the quality q. If the
+// encoding succeeds then the encoded image data is returned, otherwise an
+// error is returned.
+type Encoder func(data []byte, width, height, depth int, q Quality) ([]byte, error)
+
 type srcDstFmt struct{ src, dst interface{} }
 
-var registeredConverters = make(map[srcDstFmt]Converter)
+var (
+	registeredConverters = make(map[srcDstFmt]Converter)
+	registeredEncoders   = make(map[srcDstFmt]Encoder)
+)
 
 // RegisterConverter registers the Converter for converting from src to dst
 // formats. If a converter already exists for converting from src to dst, then
@@ -42,12 +51,31 @@ func RegisterConverter(src, dst *Format, c Converter) {
 	registeredConverters[key] = c
 }
 
+// RegisterEncoder registers the Encoder for converting from src to dst
+// formats. If an encoder already exists for converting from src to dst, then
+// this function panics.
+func RegisterEncoder(src, dst *Format, e Encoder) {
+	key := srcDstFmt{src.Key(), dst.Key()}
+	if _, found := registeredEncoders[key]; found {
+		panic(fmt.Errorf("Encoder from %s to %s already registered", src, dst))
+	}
+	registeredEncoders[key] = e
+}
+
 func registered(src, dst *Format) bool {
 	key := srcDstFmt{src.Key(), dst.Key()}
 	_, found := registeredConverters[key]
 	return found
 }
 
+// container is the interface implemented by formats that can hold images of
+// other formats.
+type container interface {
+	// wrap returns the image formed from data, width, height, depth in srcFmt
+	// stored in the container format.
+	wrap(data []byte, width, height, depth int, srcFmt *Format) ([]byte, error)
+}
+
 // converter is the interface implemented by formats that support format
 // conversion.
 type converter interface {
@@ -61,8 +89,15 @@ type converter interface {
 // data, width and height from srcFmt to dstFmt.
 // If no direct converter has been registered to convert from srcFmt to dstFmt,
 // then Convert may try converting via an intermediate format.
+// Any Encoder used for the conversion is given NormalQuality.
 func Convert(data []byte, width, height, depth int, srcFmt, dstFmt *Format) ([]byte, error) {
-	out, err := convertDirect(data, width, height, depth, srcFmt, dstFmt)
+	return Encode(data, width, height, depth, srcFmt, dstFmt, NormalQuality)
+}
+
+// Encode is like Convert, but passes the quality q to any registered Encoder
+// used for the conversion.
+func Encode(data []byte, width, height, depth int, srcFmt, dstFmt *Format, q Quality) ([]byte, error) {
+	out, err := convertDirect(data, width, height, depth, srcFmt, dstFmt, q)
 	if err != nil {
 		return nil, err
 	}
@@ -71,13 +106,17 @@ func Convert(data []byte, width, height, depth int, srcFmt, dstFmt *Format) ([]b
 	}
 
 	// No direct conversion found. Try going via a common intermediate formats.
-	for _, via := range []*Format{
-		RGBA_U8_NORM, SRGBA_U8_NORM,
+	// RGBA_F32 is tried after the 8-bit formats so that high dynamic range
+	// images can reach formats that are only converted from RGBA_F32.
+	for _, via := range [][]*Format{
+		{RGBA_U8_NORM},
+		{SRGBA_U8_NORM},
+		{RGBA_F32},
+		{RGBA_U8_NORM, RGBA_F32},
+		{SRGBA_U8_NORM, RGBA_F32},
 	} {
-		if data, _ := convertDirect(data, width, height, depth, srcFmt, via); data != nil {
-			if data, _ := convertDirect(data, width, height, depth, via, dstFmt); data != nil {
-				return data, nil
-			}
+		if data := convertVia(data, width, height, depth, srcFmt, dstFmt, via, q); data != nil {
+			return data, nil
 		}
 	}
 
@@ -85,7 +124,21 @@ func Convert(data []byte, width, height, depth int, srcFmt, dstFmt *Format) ([]b
 		srcFmt, dstFmt)
 }
 
-func convertDirect(data []byte, width, height, depth int, srcFmt, dstFmt *Format) ([]byte, error) {
+// convertVia converts the image from srcFmt to dstFmt by directly converting
+// to each of the formats in via in turn. If any step fails then nil is
+// returned.
+func convertVia(data []byte, width, height, depth int, srcFmt, dstFmt *Format, via []*Format, q Quality) []byte {
+	from := srcFmt
+	for _, to := range append(via, dstFmt) {
+		if data, _ = convertDirect(data, width, height, depth, from, to, q); data == nil {
+			return nil
+		}
+		from = to
+	}
+	return data
+}
+
+func convertDirect(data []byte, width, height, depth int, srcFmt, dstFmt *Format, q Quality) ([]byte, error) {
 	srcKey, dstKey := srcFmt.Key(), dstFmt.Key()
 	if srcKey == dstKey {
 		return data, nil // No conversion required.
@@ -100,6 +153,16 @@ func convertDirect(data []byte, width, height, depth int, srcFmt, dstFmt *Format
 		return conv(data, width, height, depth)
 	}
 
+	// Look for a registered encoder.
+	if enc, found := registeredEncoders[srcDstFmt{srcKey, dstKey}]; found {
+		return enc(data, width, height, depth, q)
+	}
+
+	// Check if the destination format can hold the source image as is.
+	if c, ok := protoutil.OneOf(dstFmt.Format).(container); ok {
+		return c.wrap(data, width, height, depth, srcFmt)
+	}
+
 	// Check if the source format supports the converter interface.
 	if c, ok := protoutil.OneOf(srcFmt.Format).(converter); ok {
 		return c.convert(data, width, height, depth, dstFmt)
@@ -143,7 +206,7 @@ func (r *ConvertResolvable) Resolve(ctx context.Context) (interface{}, error) {
 		}
 	}
 
-	bytes, err = Convert(bytes, int(r.Width), int(r.Height), int(r.Depth), from, to)
+	bytes, err = Encode(bytes, int(r.Width), int(r.Height), int(r.Depth), from, to, Quality(r.Quality))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,238 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"bytes"
+
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/data/protoutil"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/stream"
+	"github.com/google/gapid/core/stream/fmts"
+)
+
+var DDS = NewDDS("dds")
+
+// NewDDS returns a format representing a DirectDraw Surface texture container.
+func NewDDS(name string) *Format { return &Format{name, &Format_Dds{&FmtDDS{}}} }
+
+func (f *FmtDDS) key() interface{}                   { return *f }
+func (*FmtDDS) size(w, h, d int) int                 { return -1 }
+func (*FmtDDS) check(data []byte, w, h, d int) error { return nil }
+func (*FmtDDS) channels() []stream.Channel {
+	return nil
+}
+
+// wrap returns the single image formed from data, width, height and depth as
+// a DDS container, preserving the source format where possible.
+func (f *FmtDDS) wrap(data []byte, width, height, depth int, srcFmt *Format) ([]byte, error) {
+	img := &Data{
+		Bytes:  data,
+		Width:  uint32(width),
+		Height: uint32(height),
+		Depth:  uint32(depth),
+		Format: srcFmt,
+	}
+	return EncodeDDS([][]*Data{{img}}, false)
+}
+
+// DDS header flags.
+const (
+	ddsdCaps        = 0x1
+	ddsdHeight      = 0x2
+	ddsdWidth       = 0x4
+	ddsdPitch       = 0x8
+	ddsdPixelFormat = 0x1000
+	ddsdMipMapCount = 0x20000
+	ddsdLinearSize  = 0x80000
+	ddsdDepth       = 0x800000
+
+	ddpfFourCC = 0x4
+
+	ddscapsComplex = 0x8
+	ddscapsTexture = 0x1000
+	ddscapsMipMap  = 0x400000
+
+	ddscaps2Cubemap  = 0x200
+	ddscaps2AllFaces = 0xFC00
+	ddscaps2Volume   = 0x200000
+
+	ddsResourceDimensionTexture2D = 3
+	ddsResourceDimensionTexture3D = 4
+	ddsResourceMiscTextureCube    = 0x4
+)
+
+// ddsUncompressed lists the uncompressed formats that can be stored in DDS
+// containers without conversion, with their DXGI format.
+var ddsUncompressed = []struct {
+	format *stream.Format
+	dxgi   uint32
+}{
+	{fmts.RGBA_F32, 2},
+	{fmts.RGB_F32, 6},
+	{fmts.RGBA_F16, 10},
+	{fmts.RGBA_U16_NORM, 11},
+	{fmts.RG_F32, 16},
+	{fmts.BGR_F10F11F11, 26},
+	{fmts.RGBA_U8_NORM, 28},
+	{fmts.SRGBA_U8_NORM, 29},
+	{fmts.RGBA_S8_NORM, 31},
+	{fmts.RG_F16, 34},
+	{fmts.RG_U16_NORM, 35},
+	{fmts.RG_S16_NORM, 37},
+	{fmts.D_F32, 40},
+	{fmts.R_F32, 41},
+	{fmts.RG_U8_NORM, 49},
+	{fmts.RG_S8_NORM, 51},
+	{fmts.R_F16, 54},
+	{fmts.D_U16_NORM, 55},
+	{fmts.R_U16_NORM, 56},
+	{fmts.R_S16_NORM, 58},
+	{fmts.R_U8_NORM, 61},
+	{fmts.R_S8_NORM, 63},
+	{fmts.RGBE_U9U9U9U5, 67},
+	{fmts.BGRA_U8_NORM, 87},
+	{fmts.BGRA_N_sRGBU8N_sRGBU8N_sRGBU8NU8, 91},
+}
+
+// ddsFormatOf returns the DXGI format used to store images of format f in DDS
+// containers, or 0 if f cannot be stored without conversion.
+func ddsFormatOf(f *Format) uint32 {
+	switch t := protoutil.OneOf(f.Format).(type) {
+	case *FmtUncompressed:
+		for _, u := range ddsUncompressed {
+			if u.format.String() == t.Format.String() {
+				return u.dxgi
+			}
+		}
+	case *FmtS3_DXT1_RGB, *FmtS3_DXT1_RGBA:
+		return 71 // BC1_UNORM
+	case *FmtS3_DXT3_RGBA:
+		return 74 // BC2_UNORM
+	case *FmtS3_DXT5_RGBA:
+		return 77 // BC3_UNORM
+	case *FmtBC4_R_U8_NORM:
+		return 80
+	case *FmtBC4_R_S8_NORM:
+		return 81
+	case *FmtBC5_RG_U8_NORM:
+		return 83
+	case *FmtBC5_RG_S8_NORM:
+		return 84
+	case *FmtBC6H_RGB_U16_FLOAT:
+		return 95
+	case *FmtBC6H_RGB_S16_FLOAT:
+		return 96
+	case *FmtBC7_RGBA_U8_NORM:
+		if t.Srgb {
+			return 99
+		}
+		return 98
+	}
+	return 0
+}
+
+// EncodeDDS returns the images of a texture as a DDS container with a DX10
+// header. levels holds the images of each mip level, starting with the
+// largest. Each level holds the same number of layers, and every image must
+// share the same format. If cubemap is true then the layers of each level are
+// the faces +X, -X, +Y, -Y, +Z, -Z of each cubemap in turn.
+//
+// The images are stored in their original format if there is an equivalent
+// DXGI format, otherwise they are converted to RGBA_F32 so that no data is
+// lost.
+func EncodeDDS(levels [][]*Data, cubemap bool) ([]byte, error) {
+	if err := checkTexture(levels, cubemap); err != nil {
+		return nil, err
+	}
+	dxgi := ddsFormatOf(levels[0][0].Format)
+	if dxgi == 0 {
+		// Not representable in the container. Store the images as floats.
+		var err error
+		if levels, err = convertTexture(levels, RGBA_F32); err != nil {
+			return nil, err
+		}
+		dxgi = ddsFormatOf(RGBA_F32)
+	}
+
+	base, layers := levels[0][0], len(levels[0])
+	flags := uint32(ddsdCaps | ddsdHeight | ddsdWidth | ddsdPixelFormat | ddsdMipMapCount)
+	caps, caps2 := uint32(ddscapsTexture), uint32(0)
+	dimension, misc, arraySize := uint32(ddsResourceDimensionTexture2D), uint32(0), uint32(layers)
+	pitch, depth := uint32(0), uint32(0)
+	if base.Format.GetUncompressed() != nil {
+		flags |= ddsdPitch
+		pitch = uint32(base.Format.Size(int(base.Width), 1, 1))
+	} else {
+		flags |= ddsdLinearSize
+		pitch = uint32(len(base.Bytes))
+	}
+	if len(levels) > 1 {
+		caps |= ddscapsComplex | ddscapsMipMap
+	}
+	if layers > 1 {
+		caps |= ddscapsComplex
+	}
+	if cubemap {
+		caps2 |= ddscaps2Cubemap | ddscaps2AllFaces
+		misc |= ddsResourceMiscTextureCube
+		arraySize /= 6
+	}
+	if base.Depth > 1 {
+		flags |= ddsdDepth
+		caps |= ddscapsComplex
+		caps2 |= ddscaps2Volume
+		dimension = ddsResourceDimensionTexture3D
+		depth = base.Depth
+	}
+
+	buf := &bytes.Buffer{}
+	w := endian.Writer(buf, device.LittleEndian)
+	w.Data([]byte("DDS "))
+	w.Uint32(124) // dwSize
+	w.Uint32(flags)
+	w.Uint32(base.Height)
+	w.Uint32(base.Width)
+	w.Uint32(pitch)
+	w.Uint32(depth)
+	w.Uint32(uint32(len(levels)))
+	w.Data(make([]byte, 11*4)) // dwReserved1
+	w.Uint32(32)               // ddspf.dwSize
+	w.Uint32(ddpfFourCC)
+	w.Data([]byte("DX10"))
+	w.Data(make([]byte, 5*4)) // ddspf.dwRGBBitCount and masks
+	w.Uint32(caps)
+	w.Uint32(caps2)
+	w.Data(make([]byte, 3*4)) // dwCaps3, dwCaps4, dwReserved2
+
+	w.Uint32(dxgi)
+	w.Uint32(dimension)
+	w.Uint32(misc)
+	w.Uint32(arraySize)
+	w.Uint32(0) // miscFlags2: unknown alpha mode
+
+	// Each layer (or cubemap face) is stored with all of its mip levels,
+	// starting with the largest.
+	for layer := range levels[0] {
+		for _, level := range levels {
+			w.Data(level[layer].Bytes)
+		}
+	}
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,72 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/google/gapid/core/image"
+)
+
+func TestDDSCubemapMipmaps(t *testing.T) {
+	levels := [][]*image.Data{}
+	for size := uint32(8); size >= 1; size /= 2 {
+		faces := []*image.Data{}
+		for face := 0; face < 12; face++ {
+			data := bytes.Repeat([]byte{byte(face)}, image.S3_DXT1_RGB.Size(int(size), int(size), 1))
+			faces = append(faces, &image.Data{Format: image.S3_DXT1_RGB, Width: size, Height: size, Depth: 1, Bytes: data})
+		}
+		levels = append(levels, faces)
+	}
+
+	data, err := image.EncodeDDS(levels, true)
+	if err != nil {
+		t.Fatalf("EncodeDDS returned error: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("DDS ")) {
+		t.Fatalf("Unexpected magic: %q", data[:4])
+	}
+	u32 := func(offset int) uint32 { return binary.LittleEndian.Uint32(data[offset:]) }
+	if width, height, count := u32(16), u32(12), u32(28); width != 8 || height != 8 || count != 4 {
+		t.Errorf("Unexpected width, height or levels: %v, %v, %v", width, height, count)
+	}
+	if fourCC := string(data[84:88]); fourCC != "DX10" {
+		t.Errorf("Unexpected pixel format FourCC: %q", fourCC)
+	}
+	if caps2 := u32(112); caps2 != 0xFE00 {
+		t.Errorf("Unexpected caps2: 0x%x", caps2)
+	}
+	if format, misc, arraySize := u32(128), u32(136), u32(140); format != 71 || misc != 4 || arraySize != 2 {
+		t.Errorf("Unexpected DXGI format, misc flags or array size: %v, 0x%x, %v", format, misc, arraySize)
+	}
+
+	// Each face is stored with all of its mip levels.
+	const headerSize = 4 + 124 + 20
+	faceSize := 0
+	for _, level := range levels {
+		faceSize += len(level[0].Bytes)
+	}
+	if got, expected := len(data), headerSize+12*faceSize; got != expected {
+		t.Fatalf("File size was not as expected. Got: %v, expected: %v", got, expected)
+	}
+	for face := 0; face < 12; face++ {
+		start := headerSize + face*faceSize
+		if data[start] != byte(face) || data[start+faceSize-1] != byte(face) {
+			t.Errorf("Face %d is not in order", face)
+		}
+	}
+}
@@ -158,9 +158,11 @@ func loadASTC(data []byte) (*image.Data, error) {
 }
 
 func TestDecompressors(t *testing.T) {
-	// For these tests we need to check that the S16_NORM formats match the
-	// U8_NORM PNGs. There's no generic way to do this, so we declare our
-	// expected converter here.
+	// For these tests we need to check that the S8_NORM and S16_NORM formats
+	// match the U8_NORM PNGs. There's no generic way to do this, so we declare
+	// our expected converters here.
+	image.RegisterConverter(image.R_S8_NORM, image.RGBA_U8_NORM, s8ToU8)
+	image.RegisterConverter(image.RG_S8_NORM, image.RGBA_U8_NORM, s8ToU8)
 	image.RegisterConverter(image.R_S16_NORM, image.RGBA_U8_NORM, s16ToU8)
 	image.RegisterConverter(image.RG_S16_NORM, image.RGBA_U8_NORM, s16ToU8)
 
@@ -179,6 +181,19 @@ func TestDecompressors(t *testing.T) {
 		{image.S3_DXT1_RGBA, ".bin"},
 		{image.S3_DXT3_RGBA, ".bin"},
 		{image.S3_DXT5_RGBA, ".bin"},
+		{image.BC4_R_U8_NORM, ".bin"},
+		{image.BC4_R_S8_NORM, ".bin"},
+		{image.BC5_RG_U8_NORM, ".bin"},
+		{image.BC5_RG_S8_NORM, ".bin"},
+		{image.BC6H_RGB_U16_FLOAT, ".bin"},
+		{image.BC6H_RGB_S16_FLOAT, ".bin"},
+		{image.BC7_RGBA_U8_NORM, ".bin"},
+		{image.PVRTC1_RGB_2BPP, ".bin"},
+		{image.PVRTC1_RGB_4BPP, ".bin"},
+		{image.PVRTC1_RGBA_2BPP, ".bin"},
+		{image.PVRTC1_RGBA_4BPP, ".bin"},
+		{image.PVRTC2_RGBA_2BPP, ".bin"},
+		{image.PVRTC2_RGBA_4BPP, ".bin"},
 		{astc.RGBA_4x4, ".astc"},
 	} {
 		name := test.fmt.Name
@@ -290,6 +305,22 @@ func TestDecompressors(t *testing.T) {
 	}
 }
 
+func s8ToU8(src []byte, w, h, d int) ([]byte, error) {
+	pixels := w * h * d
+	channels := len(src) / pixels
+	out := make([]byte, 0, pixels*4)
+	for i := 0; i < pixels; i++ {
+		pixel := [4]byte{0, 0, 0, 255}
+		for c := 0; c < channels; c++ {
+			s8 := int(int8(src[0]))
+			pixel[c] = sint.Byte(s8 << 1)
+			src = src[1:]
+		}
+		out = append(out, pixel[0], pixel[1], pixel[2], pixel[3])
+	}
+	return out, nil
+}
+
 func s16ToU8(src []byte, w, h, d int) ([]byte, error) {
 	pixels := w * h * d
 	channels := len(src) / (pixels * 2)
@@ -0,0 +1,145 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/google/gapid/core/context/keys"
+	"github.com/google/gapid/core/math/sint"
+)
+
+// Quality controls the trade-off between speed and output quality made by the
+// block texture encoders.
+type Quality int
+
+const (
+	// NormalQuality tries several encodings of each block and refines the
+	// block endpoints to reduce the error. This is the default.
+	NormalQuality Quality = iota
+	// FastQuality encodes each block using the first reasonable fit.
+	FastQuality
+	// BestQuality additionally searches the neighbourhood of the best
+	// encoding found for each block. This is considerably slower.
+	BestQuality
+)
+
+type qualityKeyTy string
+
+const qualityKey = qualityKeyTy("encoderQuality")
+
+// PutEncoderQuality returns a new context with the encoder Quality q attached.
+func PutEncoderQuality(ctx context.Context, q Quality) context.Context {
+	return keys.WithValue(ctx, qualityKey, q)
+}
+
+// GetEncoderQuality returns the encoder Quality attached to the context by
+// PutEncoderQuality, or NormalQuality if there is none.
+func GetEncoderQuality(ctx context.Context) Quality {
+	if q, ok := ctx.Value(qualityKey).(Quality); ok {
+		return q
+	}
+	return NormalQuality
+}
+
+func (q Quality) String() string {
+	switch q {
+	case FastQuality:
+		return "fast"
+	case NormalQuality:
+		return "normal"
+	case BestQuality:
+		return "best"
+	default:
+		return fmt.Sprintf("Quality<%d>", int(q))
+	}
+}
+
+// Set parses the quality from its name, allowing a Quality to be used as a
+// flag.Value.
+func (q *Quality) Set(s string) error {
+	for _, v := range []Quality{FastQuality, NormalQuality, BestQuality} {
+		if s == v.String() {
+			*q = v
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown encoder quality '%s'. Expected fast, normal or best", s)
+}
+
+const maxErr = math.MaxInt32
+
+func sq(i int) int { return i * i }
+
+// encode4x4Blocks encodes the RGBA_U8_NORM image src by calling encodeBlock
+// for each 4x4 block of pixels, in the same order as decode4x4Blocks reads
+// them. Each block produces blockSize bytes. Pixels of the blocks that lie
+// outside the image are clamped to the image edge.
+func encode4x4Blocks(src []byte, width, height, depth, blockSize int, encodeBlock func(src *[16]pixel, dst []byte)) []byte {
+	blocksX, blocksY := sint.Max((width+3)/4, 1), sint.Max((height+3)/4, 1)
+	dst := make([]byte, blocksX*blocksY*depth*blockSize)
+	if width == 0 || height == 0 {
+		return dst
+	}
+	block := [16]pixel{}
+	out := dst
+	for z := 0; z < depth; z++ {
+		src := src[z*width*height*4:]
+		for by := 0; by < blocksY; by++ {
+			for bx := 0; bx < blocksX; bx++ {
+				for i := range block {
+					x := sint.Min(bx*4+i%4, width-1)
+					y := sint.Min(by*4+i/4, height-1)
+					p := src[(y*width+x)*4:]
+					block[i] = pixel{int(p[0]), int(p[1]), int(p[2]), int(p[3])}
+				}
+				encodeBlock(&block, out[:blockSize])
+				out = out[blockSize:]
+			}
+		}
+	}
+	return dst
+}
+
+// refine performs a greedy search for values that reduce the error returned
+// by eval. Each of values is repeatedly nudged by ±1 within [0, limits[i]],
+// keeping the changes that lower the error, until no further improvement can
+// be found. eval should return maxErr for invalid combinations of values.
+// refine returns the lowest error found, which is never greater than err.
+func refine(values, limits []int, err int, eval func() int) int {
+	for improved := true; improved; {
+		improved = false
+		for i := range values {
+			for _, step := range []int{-1, 1} {
+				for {
+					v := values[i] + step
+					if v < 0 || v > limits[i] {
+						break
+					}
+					values[i] = v
+					e := eval()
+					if e >= err {
+						values[i] = v - step
+						break
+					}
+					err, improved = e, true
+				}
+			}
+		}
+	}
+	return err
+}
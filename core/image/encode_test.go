@@ -0,0 +1,123 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/gapid/core/image"
+	"github.com/google/gapid/core/log"
+)
+
+func TestEncoders(t *testing.T) {
+	refPath := filepath.Join("test_data", "testcardf.png")
+	refPNGData, err := ioutil.ReadFile(refPath)
+	if err != nil {
+		t.Fatalf("Failed to read '%s': %v", refPath, err)
+	}
+	refPNG, err := image.PNGFrom(refPNGData)
+	if err != nil {
+		t.Fatalf("Failed to read PNG '%s': %v", refPath, err)
+	}
+	ref, err := refPNG.Convert(image.RGBA_U8_NORM)
+	if err != nil {
+		t.Fatalf("Failed to convert '%s' from PNG to %v: %v", refPath, image.RGBA_U8_NORM, err)
+	}
+
+	for _, test := range []struct {
+		fmt *image.Format
+		// The format the encoded image is decoded to for comparison. Only the
+		// channels in this format are compared.
+		decoded *image.Format
+	}{
+		{image.S3_DXT1_RGB, image.RGB_U8_NORM},
+		{image.S3_DXT1_RGBA, image.RGBA_U8_NORM},
+		{image.S3_DXT3_RGBA, image.RGBA_U8_NORM},
+		{image.S3_DXT5_RGBA, image.RGBA_U8_NORM},
+		{image.BC4_R_U8_NORM, image.R_U8_NORM},
+		{image.BC5_RG_U8_NORM, image.RG_U8_NORM},
+		{image.ETC2_RGB_U8_NORM, image.RGB_U8_NORM},
+		{image.ETC2_RGBA_U8_NORM, image.RGBA_U8_NORM},
+	} {
+		prev := float32(1)
+		for _, q := range []image.Quality{image.FastQuality, image.NormalQuality, image.BestQuality} {
+			encoded, err := ref.Encode(test.fmt, q)
+			if err != nil {
+				t.Errorf("Failed to encode %v with %v quality: %v", test.fmt.Name, q, err)
+				continue
+			}
+			if err := test.fmt.Check(encoded.Bytes, int(ref.Width), int(ref.Height), 1); err != nil {
+				t.Errorf("Encoding %v with %v quality produced invalid data: %v", test.fmt.Name, q, err)
+				continue
+			}
+			out, err := encoded.Convert(test.decoded)
+			if err != nil {
+				t.Errorf("Failed to decode %v: %v", test.fmt.Name, err)
+				continue
+			}
+			diff, err := image.Difference(out, ref)
+			if err != nil {
+				t.Errorf("Difference returned error: %v", err)
+				continue
+			}
+
+			if diff > 0.001 {
+				t.Errorf("%v with %v quality produced an unexpectedly large difference (%v)", test.fmt.Name, q, diff)
+			}
+			if diff > prev {
+				t.Errorf("%v with %v quality produced a larger difference than the lower quality (%v > %v)",
+					test.fmt.Name, q, diff, prev)
+			}
+			prev = diff
+		}
+	}
+}
+
+func TestQualityFlag(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		expected image.Quality
+	}{
+		{"fast", image.FastQuality},
+		{"normal", image.NormalQuality},
+		{"best", image.BestQuality},
+	} {
+		var q image.Quality
+		if err := q.Set(test.name); err != nil {
+			t.Errorf("Set(%q) returned error: %v", test.name, err)
+			continue
+		}
+		if q != test.expected || q.String() != test.name {
+			t.Errorf("Set(%q) gave %v, expected %v", test.name, q, test.expected)
+		}
+	}
+	var q image.Quality
+	if err := q.Set("ultra"); err == nil {
+		t.Errorf("Set(\"ultra\") did not return an error")
+	}
+}
+
+func TestEncoderQualityContext(t *testing.T) {
+	ctx := log.Testing(t)
+	if q := image.GetEncoderQuality(ctx); q != image.NormalQuality {
+		t.Errorf("GetEncoderQuality without a quality gave %v, expected %v", q, image.NormalQuality)
+	}
+	ctx = image.PutEncoderQuality(ctx, image.BestQuality)
+	if q := image.GetEncoderQuality(ctx); q != image.BestQuality {
+		t.Errorf("GetEncoderQuality gave %v, expected %v", q, image.BestQuality)
+	}
+}
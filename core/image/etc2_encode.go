@@ -0,0 +1,407 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"encoding/binary"
+
+	"github.com/google/gapid/core/math/sint"
+	"github.com/google/gapid/core/math/u64"
+)
+
+func init() {
+	for _, conv := range []struct {
+		src, dst *Format
+		alpha    bool
+	}{
+		{RGBA_U8_NORM, ETC2_RGB_U8_NORM, false},
+		{RGBA_U8_NORM, ETC2_RGBA_U8_NORM, true},
+		{SRGBA_U8_NORM, ETC2_SRGB_U8_NORM, false},
+		{SRGBA_U8_NORM, ETC2_SRGBA_U8_NORM, true},
+	} {
+		conv := conv
+		RegisterEncoder(conv.src, conv.dst, func(src []byte, w, h, d int, q Quality) ([]byte, error) {
+			return encodeETC(src, w, h, d, conv.alpha, q), nil
+		})
+	}
+}
+
+// etcModifiers are the intensity modifiers of the individual and differential
+// modes, indexed by table and then by code.
+var etcModifiers = [8][4]int{
+	{2, 8, -2, -8},
+	{5, 17, -5, -17},
+	{9, 29, -9, -29},
+	{13, 42, -13, -42},
+	{18, 60, -18, -60},
+	{24, 80, -24, -80},
+	{33, 106, -33, -106},
+	{47, 183, -47, -183},
+}
+
+// etcDiffs are the values of the 3-bit base color deltas of the differential
+// mode.
+var etcDiffs = [8]int{0, 1, 2, 3, -4, -3, -2, -1}
+
+// encodeETC encodes the RGBA_U8_NORM image src as ETC2 RGB8, or as ETC2 RGBA8
+// with EAC alpha if alpha is true. The T and H modes are not used.
+func encodeETC(src []byte, width, height, depth int, alpha bool, q Quality) []byte {
+	blockSize := 8
+	if alpha {
+		blockSize = 16
+	}
+	return encode4x4Blocks(src, width, height, depth, blockSize, func(block *[16]pixel, dst []byte) {
+		if alpha {
+			binary.BigEndian.PutUint64(dst, encodeEACBlock(blockChannel(block, 3), q))
+			dst = dst[8:]
+		}
+		binary.BigEndian.PutUint64(dst, encodeETCColorBlock(block, q))
+	})
+}
+
+// etcBit returns the index of the bit holding the least significant bit of the
+// code for the pixel at (x, y). ETC blocks store pixels in column-major order.
+func etcBit(x, y int) uint { return uint(x*4 + y) }
+
+// etcHalf is one of the two 2x4 or 4x2 sub-blocks of an ETC block.
+type etcHalf struct {
+	pixels [8]pixel
+	bits   [8]uint // etcBit of each of the pixels.
+}
+
+// etcHalves splits the block into the two sub-blocks used by the individual
+// and differential modes. If flip is 0 the block is split into left and right
+// halves, otherwise it is split into top and bottom halves.
+func etcHalves(block *[16]pixel, flip int) [2]etcHalf {
+	halves, n := [2]etcHalf{}, [2]int{}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			h := x / 2
+			if flip != 0 {
+				h = y / 2
+			}
+			halves[h].pixels[n[h]] = block[y*4+x]
+			halves[h].bits[n[h]] = etcBit(x, y)
+			n[h]++
+		}
+	}
+	return halves
+}
+
+// quantize returns the average color of the sub-block quantized to values in
+// the range [0, max].
+func (h *etcHalf) quantize(max int) [3]int {
+	sum := [3]int{}
+	for _, p := range h.pixels {
+		sum[0], sum[1], sum[2] = sum[0]+p.r, sum[1]+p.g, sum[2]+p.b
+	}
+	out := [3]int{}
+	for c, s := range sum {
+		out[c] = (s*max + 255*4) / (255 * 8)
+	}
+	return out
+}
+
+// fit returns the modifier table and codes that give the lowest error for the
+// sub-block when using the given 8-bit base color.
+func (h *etcHalf) fit(base [3]int) (table int, codes [8]int, err int) {
+	err = maxErr
+	for t, mods := range etcModifiers {
+		tErr, tCodes := 0, [8]int{}
+		for i, p := range h.pixels {
+			best, bestErr := 0, maxErr
+			for c, m := range mods {
+				e := sq(int(sint.Byte(base[0]+m))-p.r) +
+					sq(int(sint.Byte(base[1]+m))-p.g) +
+					sq(int(sint.Byte(base[2]+m))-p.b)
+				if e < bestErr {
+					best, bestErr = c, e
+				}
+			}
+			tCodes[i], tErr = best, tErr+bestErr
+			if tErr >= err {
+				break
+			}
+		}
+		if tErr < err {
+			table, codes, err = t, tCodes, tErr
+		}
+	}
+	return table, codes, err
+}
+
+// etcDiffInRange returns true if the two 5-bit base colors can be encoded with
+// the differential mode.
+func etcDiffInRange(bases [2][3]int) bool {
+	for c := range bases[0] {
+		if d := bases[1][c] - bases[0][c]; d < -4 || d > 3 {
+			return false
+		}
+	}
+	return true
+}
+
+// etcPackHalves returns the individual or differential mode encoding of the two
+// sub-blocks using the quantized base colors, along with its error.
+func etcPackHalves(halves *[2]etcHalf, flip int, diff bool, bases [2][3]int) (uint64, int) {
+	v := uint64(flip) << 32
+	expanded := [2][3]int{}
+	for c := 0; c < 3; c++ {
+		shift := uint(c * 8)
+		if diff {
+			v |= uint64(bases[0][c])<<(59-shift) | uint64((bases[1][c]-bases[0][c])&7)<<(56-shift)
+			expanded[0][c] = int(u64.Expand5to8(uint64(bases[0][c])))
+			expanded[1][c] = int(u64.Expand5to8(uint64(bases[1][c])))
+		} else {
+			v |= uint64(bases[0][c])<<(60-shift) | uint64(bases[1][c])<<(56-shift)
+			expanded[0][c] = int(u64.Expand4to8(uint64(bases[0][c])))
+			expanded[1][c] = int(u64.Expand4to8(uint64(bases[1][c])))
+		}
+	}
+	if diff {
+		v |= 1 << 33
+	}
+	err := 0
+	for h := range halves {
+		table, codes, e := halves[h].fit(expanded[h])
+		err += e
+		v |= uint64(table) << uint(37-h*3)
+		for i, c := range codes {
+			bit := halves[h].bits[i]
+			v |= uint64(c&1)<<bit | uint64(c>>1)<<(bit+16)
+		}
+	}
+	return v, err
+}
+
+// etcMode returns the mode that decodeETC will use for the opaque color block
+// v: 0 for individual or differential, 1 for T, 2 for H and 3 for planar.
+func etcMode(v uint64) int {
+	if (v>>33)&1 == 0 {
+		return 0
+	}
+	for i := uint(0); i < 3; i++ {
+		a := int((v >> (59 - i*8)) & 31)
+		d := (v >> (56 - i*8)) & 7
+		if b := a + etcDiffs[d]; b < 0 || b > 31 {
+			return int(i) + 1
+		}
+	}
+	return 0
+}
+
+// etcPlanar returns the planar mode encoding of the block, along with its
+// error.
+func etcPlanar(block *[16]pixel, q Quality) (uint64, int) {
+	// Least squares fit of the plane c(x, y) = O + x(H-O)/4 + y(V-O)/4.
+	// The pixel coordinates are centered so the x and y terms are independent.
+	values := make([]int, 9) // O, H and V, each as R, G, B.
+	limits := []int{63, 127, 63, 63, 127, 63, 63, 127, 63}
+	for c := 0; c < 3; c++ {
+		mean, sx, sy := 0.0, 0.0, 0.0
+		for i, p := range block {
+			v := float64([3]int{p.r, p.g, p.b}[c])
+			mean += v
+			sx += (float64(i%4) - 1.5) * v
+			sy += (float64(i/4) - 1.5) * v
+		}
+		mean, sx, sy = mean/16, sx/20, sy/20
+		o := mean - 1.5*sx - 1.5*sy
+		for i, v := range []float64{o, o + 4*sx, o + 4*sy} {
+			max := limits[i*3+c]
+			values[i*3+c] = sint.Clamp(int(v*float64(max)/255+0.5), 0, max)
+		}
+	}
+
+	eval := func() int {
+		c := [9]int{}
+		for i, v := range values {
+			if limits[i] == 127 {
+				c[i] = int(u64.Expand7to8(uint64(v)))
+			} else {
+				c[i] = int(u64.Expand6to8(uint64(v)))
+			}
+		}
+		err := 0
+		for i, p := range block {
+			x, y := i%4, i/4
+			for ch, v := range [3]int{p.r, p.g, p.b} {
+				o, h, v2 := c[ch], c[3+ch], c[6+ch]
+				d := int(sint.Byte((x*(h-o) + y*(v2-o) + 4*o + 2) >> 2))
+				err += sq(d - v)
+			}
+		}
+		return err
+	}
+	err := eval()
+	if q >= BestQuality && err > 0 {
+		err = refine(values, limits, err, eval)
+	}
+
+	r0, g0, b0 := uint64(values[0]), uint64(values[1]), uint64(values[2])
+	r1, g1, b1 := uint64(values[3]), uint64(values[4]), uint64(values[5])
+	r2, g2, b2 := uint64(values[6]), uint64(values[7]), uint64(values[8])
+	v := r0<<57 |
+		(g0>>6)<<56 | (g0&63)<<49 |
+		(b0>>5)<<48 | ((b0>>3)&3)<<43 | (b0&7)<<39 |
+		(r1>>1)<<34 | 1<<33 | (r1&1)<<32 |
+		g1<<25 | b1<<19 |
+		r2<<13 | g2<<6 | b2
+
+	// The remaining bits must be chosen so the block is decoded as planar.
+	free := []uint{63, 55, 47, 46, 45, 42}
+	for bits := 0; bits < 1<<uint(len(free)); bits++ {
+		candidate := v
+		for i, bit := range free {
+			candidate |= uint64((bits>>uint(i))&1) << bit
+		}
+		if etcMode(candidate) == 3 {
+			return candidate, err
+		}
+	}
+	return v, maxErr
+}
+
+// encodeETCColorBlock returns the 64-bit ETC2 encoding of the colors of the
+// block.
+func encodeETCColorBlock(block *[16]pixel, q Quality) uint64 {
+	best, bestErr := uint64(0), maxErr
+	try := func(v uint64, err int) {
+		if err < bestErr {
+			best, bestErr = v, err
+		}
+	}
+
+	for flip := 0; flip < 2 && bestErr > 0; flip++ {
+		halves := etcHalves(block, flip)
+		base5 := [2][3]int{halves[0].quantize(31), halves[1].quantize(31)}
+		base4 := [2][3]int{halves[0].quantize(15), halves[1].quantize(15)}
+
+		type candidate struct {
+			diff  bool
+			bases [2][3]int
+		}
+		candidates := []candidate{}
+		if etcDiffInRange(base5) {
+			candidates = append(candidates, candidate{true, base5})
+		}
+		if len(candidates) == 0 || q >= NormalQuality {
+			candidates = append(candidates, candidate{false, base4})
+		}
+		if !etcDiffInRange(base5) && q >= NormalQuality {
+			// Pull the second base color into range of the first.
+			clamped := base5
+			for c := range clamped[1] {
+				clamped[1][c] = base5[0][c] + sint.Clamp(base5[1][c]-base5[0][c], -4, 3)
+			}
+			candidates = append(candidates, candidate{true, clamped})
+		}
+
+		flipBest, flipErr := candidate{}, maxErr
+		for _, c := range candidates {
+			v, err := etcPackHalves(&halves, flip, c.diff, c.bases)
+			try(v, err)
+			if err < flipErr {
+				flipBest, flipErr = c, err
+			}
+		}
+
+		if q >= BestQuality && flipErr > 0 {
+			c := flipBest
+			values := []int{
+				c.bases[0][0], c.bases[0][1], c.bases[0][2],
+				c.bases[1][0], c.bases[1][1], c.bases[1][2],
+			}
+			max := 15
+			if c.diff {
+				max = 31
+			}
+			limits := []int{max, max, max, max, max, max}
+			bases := func() [2][3]int {
+				return [2][3]int{
+					{values[0], values[1], values[2]},
+					{values[3], values[4], values[5]},
+				}
+			}
+			refine(values, limits, flipErr, func() int {
+				if c.diff && !etcDiffInRange(bases()) {
+					return maxErr
+				}
+				_, err := etcPackHalves(&halves, flip, c.diff, bases())
+				return err
+			})
+			try(etcPackHalves(&halves, flip, c.diff, bases()))
+		}
+	}
+
+	if q >= NormalQuality && bestErr > 0 {
+		try(etcPlanar(block, q))
+	}
+	return best
+}
+
+// encodeEACBlock returns the 64-bit EAC encoding of the 8-bit values of the
+// block.
+func encodeEACBlock(values *[16]int, q Quality) uint64 {
+	min, max := 255, 0
+	for _, v := range values {
+		min, max = sint.Min(min, v), sint.Max(max, v)
+	}
+
+	best, bestErr := uint64(0), maxErr
+	try := func(base, mul, table int, mods [8]int) {
+		if base < 0 || base > 255 || mul < 1 || mul > 15 {
+			return
+		}
+		codes, err := uint64(0), 0
+		for x := 0; x < 4; x++ {
+			for y := 0; y < 4; y++ {
+				v := values[y*4+x]
+				code, codeErr := 0, maxErr
+				for c, m := range mods {
+					if e := sq(int(sint.Byte(base+m*mul)) - v); e < codeErr {
+						code, codeErr = c, e
+					}
+				}
+				codes = codes<<3 | uint64(code)
+				err += codeErr
+			}
+		}
+		if err < bestErr {
+			best = uint64(base)<<56 | uint64(mul)<<52 | uint64(table)<<48 | codes
+			bestErr = err
+		}
+	}
+
+	for table := 0; table < 16 && bestErr > 0; table++ {
+		_, _, mods := decodeETCBaseMulModTbl(uint64(table) << 48)
+		lo, hi := mods[3], mods[7] // The most negative and positive modifiers.
+		mul := sint.Clamp((max-min+(hi-lo)/2)/(hi-lo), 1, 15)
+		base := sint.Clamp((max+min-mul*(hi+lo)+1)/2, 0, 255)
+		try(base, mul, table, mods)
+		if q >= NormalQuality {
+			try(base, mul-1, table, mods)
+			try(base, mul+1, table, mods)
+		}
+		if q >= BestQuality {
+			for b := base - 3; b <= base+3; b++ {
+				for m := mul - 1; m <= mul+1; m++ {
+					try(b, m, table, mods)
+				}
+			}
+		}
+	}
+	return best
+}
@@ -0,0 +1,248 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/google/gapid/core/data/binary"
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/stream"
+)
+
+var EXR = NewEXR("exr")
+
+// NewEXR returns a format representing an OpenEXR image file.
+func NewEXR(name string) *Format { return &Format{name, &Format_Exr{&FmtEXR{}}} }
+
+func (f *FmtEXR) key() interface{}                   { return *f }
+func (*FmtEXR) size(w, h, d int) int                 { return -1 }
+func (*FmtEXR) check(data []byte, w, h, d int) error { return nil }
+func (*FmtEXR) channels() []stream.Channel {
+	return nil
+}
+
+const exrMagic = 0x01312f76
+
+// OpenEXR channel pixel types.
+const (
+	exrUint  = 0
+	exrHalf  = 1
+	exrFloat = 2
+)
+
+func init() {
+	RegisterConverter(RGBA_F32, EXR, encodeEXR)
+	RegisterConverter(EXR, RGBA_F32, decodeEXR)
+}
+
+// encodeEXR returns the RGBA_F32 image src as an uncompressed, scanline
+// OpenEXR file. The channels are stored as 32-bit floats so that no precision
+// is lost.
+func encodeEXR(src []byte, width, height, depth int) ([]byte, error) {
+	if depth != 1 {
+		return nil, fmt.Errorf("Cannot encode EXR with depth of %d", depth)
+	}
+	buf := &bytes.Buffer{}
+	w := endian.Writer(buf, device.LittleEndian)
+	w.Uint32(exrMagic)
+	w.Uint32(2) // Version 2, single-part scanline file.
+
+	attr := func(name, ty string, size int32) {
+		w.String(name)
+		w.String(ty)
+		w.Int32(size)
+	}
+	box := func(name string) {
+		attr(name, "box2i", 16)
+		w.Int32(0)
+		w.Int32(0)
+		w.Int32(int32(width - 1))
+		w.Int32(int32(height - 1))
+	}
+
+	// Channels must be listed in alphabetical order.
+	channels := []string{"A", "B", "G", "R"}
+	attr("channels", "chlist", int32(len(channels)*18+1))
+	for _, c := range channels {
+		w.String(c)
+		w.Int32(exrFloat)
+		w.Uint8(0) // pLinear
+		w.Data([]byte{0, 0, 0})
+		w.Int32(1) // xSampling
+		w.Int32(1) // ySampling
+	}
+	w.Uint8(0)
+	attr("compression", "compression", 1)
+	w.Uint8(0) // NO_COMPRESSION
+	box("dataWindow")
+	box("displayWindow")
+	attr("lineOrder", "lineOrder", 1)
+	w.Uint8(0) // INCREASING_Y
+	attr("pixelAspectRatio", "float", 4)
+	w.Float32(1)
+	attr("screenWindowCenter", "v2f", 8)
+	w.Float32(0)
+	w.Float32(0)
+	attr("screenWindowWidth", "float", 4)
+	w.Float32(1)
+	w.Uint8(0) // End of header.
+
+	// Offset table, one entry per scanline.
+	lineSize := width * len(channels) * 4
+	offset := uint64(buf.Len() + height*8)
+	for y := 0; y < height; y++ {
+		w.Uint64(offset)
+		offset += uint64(8 + lineSize)
+	}
+
+	// The source channel index for each of the channels, in file order.
+	order := []int{3, 2, 1, 0}
+	r := endian.Reader(bytes.NewReader(src), device.LittleEndian)
+	line := make([]float32, width*4)
+	for y := 0; y < height; y++ {
+		for i := range line {
+			line[i] = r.Float32()
+		}
+		w.Int32(int32(y))
+		w.Int32(int32(lineSize))
+		for _, c := range order {
+			for x := 0; x < width; x++ {
+				w.Float32(line[x*4+c])
+			}
+		}
+	}
+	if err := r.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), w.Error()
+}
+
+// decodeEXR returns the uncompressed, scanline OpenEXR file src as a
+// RGBA_F32 image.
+func decodeEXR(src []byte, width, height, depth int) ([]byte, error) {
+	if depth != 1 {
+		return nil, fmt.Errorf("Cannot decode EXR with depth of %d", depth)
+	}
+	r := endian.Reader(bytes.NewReader(src), device.LittleEndian)
+	if magic := r.Uint32(); magic != exrMagic {
+		return nil, fmt.Errorf("Invalid EXR magic: 0x%x", magic)
+	}
+	if version := r.Uint32(); version&0xff != 2 || version&0x1e00 != 0 {
+		return nil, fmt.Errorf("Unsupported EXR version: 0x%x", version)
+	}
+
+	type channel struct {
+		name string
+		ty   int32
+	}
+	channels := []channel{}
+	compression := -1
+	xMin, yMin, xMax, yMax := int32(0), int32(0), int32(-1), int32(-1)
+	for {
+		name := r.String()
+		if name == "" || r.Error() != nil {
+			break
+		}
+		_ = r.String() // Type
+		size := r.Int32()
+		switch name {
+		case "channels":
+			for c := r.String(); c != "" && r.Error() == nil; c = r.String() {
+				ty := r.Int32()
+				r.Data(make([]byte, 12)) // pLinear, reserved, xSampling, ySampling
+				channels = append(channels, channel{c, ty})
+			}
+		case "compression":
+			compression = int(r.Uint8())
+		case "dataWindow":
+			xMin, yMin, xMax, yMax = r.Int32(), r.Int32(), r.Int32(), r.Int32()
+		default:
+			r.Data(make([]byte, size))
+		}
+	}
+	if err := r.Error(); err != nil {
+		return nil, err
+	}
+	if compression != 0 {
+		return nil, fmt.Errorf("Unsupported EXR compression: %d", compression)
+	}
+	if w, h := int(xMax-xMin+1), int(yMax-yMin+1); w != width || h != height {
+		return nil, fmt.Errorf("EXR size was not as expected. Got: %dx%d, expected: %dx%d", w, h, width, height)
+	}
+
+	out := make([]float32, width*height*4)
+	for i := 3; i < len(out); i += 4 {
+		out[i] = 1 // Alpha defaults to opaque.
+	}
+	// The destination channel index for each of the supported channel names.
+	index := map[string]int{"R": 0, "G": 1, "B": 2, "A": 3}
+	offsets := make([]uint64, height)
+	for i := range offsets {
+		offsets[i] = r.Uint64()
+	}
+	if err := r.Error(); err != nil {
+		return nil, err
+	}
+	for _, offset := range offsets {
+		if offset >= uint64(len(src)) {
+			return nil, fmt.Errorf("EXR scanline offset 0x%x is out of bounds", offset)
+		}
+		r := endian.Reader(bytes.NewReader(src[offset:]), device.LittleEndian)
+		y := int(r.Int32() - yMin)
+		r.Int32() // Data size
+		if y < 0 || y >= height {
+			return nil, fmt.Errorf("EXR scanline %d is out of bounds", y)
+		}
+		line := out[y*width*4:]
+		for _, c := range channels {
+			i, ok := index[c.name]
+			for x := 0; x < width; x++ {
+				v, err := readEXRValue(r, c.ty)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					line[x*4+i] = v
+				}
+			}
+		}
+		if err := r.Error(); err != nil {
+			return nil, err
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	w := endian.Writer(buf, device.LittleEndian)
+	for _, v := range out {
+		w.Float32(v)
+	}
+	return buf.Bytes(), nil
+}
+
+func readEXRValue(r binary.Reader, ty int32) (float32, error) {
+	switch ty {
+	case exrUint:
+		return float32(r.Uint32()), nil
+	case exrHalf:
+		return r.Float16().Float32(), nil
+	case exrFloat:
+		return r.Float32(), nil
+	default:
+		return 0, fmt.Errorf("Unsupported EXR pixel type: %d", ty)
+	}
+}
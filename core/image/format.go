@@ -53,6 +53,10 @@ type format interface {
 var _ = []format{
 	&FmtUncompressed{},
 	&FmtPNG{},
+	&FmtDDS{},
+	&FmtEXR{},
+	&FmtHDR{},
+	&FmtKTX{},
 	&FmtATC_RGB_AMD{},
 	&FmtATC_RGBA_EXPLICIT_ALPHA_AMD{},
 	&FmtATC_RGBA_INTERPOLATED_ALPHA_AMD{},
@@ -69,6 +73,15 @@ var _ = []format{
 	&FmtS3_DXT3_RGBA{},
 	&FmtS3_DXT5_RGBA{},
 	&FmtASTC{},
+	&FmtBC4_R_U8_NORM{},
+	&FmtBC4_R_S8_NORM{},
+	&FmtBC5_RG_U8_NORM{},
+	&FmtBC5_RG_S8_NORM{},
+	&FmtBC6H_RGB_U16_FLOAT{},
+	&FmtBC6H_RGB_S16_FLOAT{},
+	&FmtBC7_RGBA_U8_NORM{},
+	&FmtPVRTC1{},
+	&FmtPVRTC2{},
 }
 
 // Check returns an error if the combination of data, image width, image
@@ -0,0 +1,256 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/stream"
+)
+
+var HDR = NewHDR("hdr")
+
+// NewHDR returns a format representing a Radiance RGBE (.hdr) image file.
+func NewHDR(name string) *Format { return &Format{name, &Format_Hdr{&FmtHDR{}}} }
+
+func (f *FmtHDR) key() interface{}                   { return *f }
+func (*FmtHDR) size(w, h, d int) int                 { return -1 }
+func (*FmtHDR) check(data []byte, w, h, d int) error { return nil }
+func (*FmtHDR) channels() []stream.Channel {
+	return nil
+}
+
+func init() {
+	RegisterConverter(RGBA_F32, HDR, encodeHDR)
+	RegisterConverter(HDR, RGBA_F32, decodeHDR)
+}
+
+// The range of widths that can be run-length encoded.
+const (
+	hdrMinRLEWidth = 8
+	hdrMaxRLEWidth = 0x7fff
+)
+
+// encodeHDR returns the RGBA_F32 image src as a run-length encoded Radiance
+// RGBE file. The alpha channel is discarded and negative values are clamped
+// to zero.
+func encodeHDR(src []byte, width, height, depth int) ([]byte, error) {
+	if depth != 1 {
+		return nil, fmt.Errorf("Cannot encode HDR with depth of %d", depth)
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y %d +X %d\n", height, width)
+
+	r := endian.Reader(bytes.NewReader(src), device.LittleEndian)
+	line := make([]byte, width*4)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			red, green, blue := r.Float32(), r.Float32(), r.Float32()
+			r.Float32() // Alpha
+			copy(line[x*4:], rgbe(red, green, blue))
+		}
+		if width < hdrMinRLEWidth || width > hdrMaxRLEWidth {
+			buf.Write(line)
+			continue
+		}
+		buf.Write([]byte{2, 2, byte(width >> 8), byte(width)})
+		for c := 0; c < 4; c++ {
+			values := make([]byte, width)
+			for x := range values {
+				values[x] = line[x*4+c]
+			}
+			writeHDRRuns(buf, values)
+		}
+	}
+	if err := r.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// rgbe returns the shared exponent encoding of the color.
+func rgbe(red, green, blue float32) []byte {
+	positive := func(f float32) float64 {
+		if f > 0 {
+			return float64(f)
+		}
+		return 0 // Also clamps NaNs.
+	}
+	r, g, b := positive(red), positive(green), positive(blue)
+	v := math.Max(r, math.Max(g, b))
+	if v < 1e-32 {
+		return []byte{0, 0, 0, 0}
+	}
+	m, e := math.Frexp(v)
+	if e > 127 {
+		return []byte{255, 255, 255, 255}
+	}
+	scale := m * 256 / v
+	return []byte{byte(r * scale), byte(g * scale), byte(b * scale), byte(e + 128)}
+}
+
+// writeHDRRuns writes the values of a single component of a scanline as a
+// sequence of runs of up to 127 identical values and literal spans of up to
+// 128 values.
+func writeHDRRuns(w io.Writer, values []byte) {
+	const minRun = 3 // Shorter runs are cheaper to write as literals.
+	for len(values) > 0 {
+		// Find the next run of at least minRun identical values.
+		start, run := len(values), 0
+		for i := 0; i < len(values); {
+			n := 1
+			for i+n < len(values) && n < 127 && values[i+n] == values[i] {
+				n++
+			}
+			if n >= minRun {
+				start, run = i, n
+				break
+			}
+			i += n
+		}
+		// Write the literals that precede the run.
+		for lit := values[:start]; len(lit) > 0; {
+			n := len(lit)
+			if n > 128 {
+				n = 128
+			}
+			w.Write([]byte{byte(n)})
+			w.Write(lit[:n])
+			lit = lit[n:]
+		}
+		if run > 0 {
+			w.Write([]byte{byte(128 + run), values[start]})
+		}
+		values = values[start+run:]
+	}
+}
+
+// decodeHDR returns the Radiance RGBE file src as a RGBA_F32 image.
+func decodeHDR(src []byte, width, height, depth int) ([]byte, error) {
+	if depth != 1 {
+		return nil, fmt.Errorf("Cannot decode HDR with depth of %d", depth)
+	}
+	r := bufio.NewReader(bytes.NewReader(src))
+	readLine := func() (string, error) {
+		s, err := r.ReadString('\n')
+		return strings.TrimRight(s, "\n"), err
+	}
+	if magic, err := readLine(); err != nil || !strings.HasPrefix(magic, "#?") {
+		return nil, fmt.Errorf("Invalid HDR header")
+	}
+	for {
+		l, err := readLine()
+		if err != nil {
+			return nil, err
+		}
+		if l == "" {
+			break
+		}
+		if strings.HasPrefix(l, "FORMAT=") && l != "FORMAT=32-bit_rle_rgbe" {
+			return nil, fmt.Errorf("Unsupported HDR format '%s'", l[7:])
+		}
+	}
+	var w, h int
+	if l, err := readLine(); err != nil {
+		return nil, err
+	} else if _, err := fmt.Sscanf(l, "-Y %d +X %d", &h, &w); err != nil {
+		return nil, fmt.Errorf("Unsupported HDR resolution '%s'", l)
+	}
+	if w != width || h != height {
+		return nil, fmt.Errorf("HDR size was not as expected. Got: %dx%d, expected: %dx%d", w, h, width, height)
+	}
+
+	buf := &bytes.Buffer{}
+	out := endian.Writer(buf, device.LittleEndian)
+	line := make([]byte, width*4)
+	for y := 0; y < height; y++ {
+		if err := readHDRScanline(r, line, width); err != nil {
+			return nil, err
+		}
+		for x := 0; x < width; x++ {
+			p := line[x*4:]
+			f := float32(0)
+			if p[3] != 0 {
+				f = float32(math.Ldexp(1, int(p[3])-(128+8)))
+			}
+			for c := 0; c < 3; c++ {
+				out.Float32((float32(p[c]) + 0.5) * f)
+			}
+			out.Float32(1)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// readHDRScanline reads a single scanline of RGBE pixels into line, which
+// may either be run-length encoded or flat.
+func readHDRScanline(r *bufio.Reader, line []byte, width int) error {
+	header, err := r.Peek(4)
+	if err != nil {
+		return err
+	}
+	if width < hdrMinRLEWidth || width > hdrMaxRLEWidth ||
+		header[0] != 2 || header[1] != 2 || header[2]&0x80 != 0 {
+		_, err := io.ReadFull(r, line)
+		return err
+	}
+	if w := int(header[2])<<8 | int(header[3]); w != width {
+		return fmt.Errorf("HDR scanline width was not as expected. Got: %d, expected: %d", w, width)
+	}
+	r.Discard(4)
+	for c := 0; c < 4; c++ {
+		for x := 0; x < width; {
+			n, err := r.ReadByte()
+			if err != nil {
+				return err
+			}
+			if n > 128 {
+				count := int(n) - 128
+				v, err := r.ReadByte()
+				if err != nil {
+					return err
+				}
+				if x+count > width {
+					return fmt.Errorf("HDR run overflows scanline")
+				}
+				for i := 0; i < count; i++ {
+					line[(x+i)*4+c] = v
+				}
+				x += count
+			} else {
+				count := int(n)
+				if count == 0 || x+count > width {
+					return fmt.Errorf("HDR literal overflows scanline")
+				}
+				for i := 0; i < count; i++ {
+					v, err := r.ReadByte()
+					if err != nil {
+						return err
+					}
+					line[(x+i)*4+c] = v
+				}
+				x += count
+			}
+		}
+	}
+	return nil
+}
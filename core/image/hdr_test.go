@@ -0,0 +1,98 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/image"
+	"github.com/google/gapid/core/math/f16"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/stream/fmts"
+)
+
+// hdrTestImage returns a RGBA_F16 image holding values well outside of [0, 1].
+func hdrTestImage(width, height int) *image.Data {
+	buf := &bytes.Buffer{}
+	w := endian.Writer(buf, device.LittleEndian)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Repeat each value a few times so that the HDR encoder uses runs.
+			v := float32(x/3+y) * 4.5
+			w.Float16(f16.From(v))
+			w.Float16(f16.From(v / 8))
+			w.Float16(f16.From(1000 - v))
+			w.Float16(f16.From(1))
+		}
+	}
+	return &image.Data{
+		Width:  uint32(width),
+		Height: uint32(height),
+		Depth:  1,
+		Bytes:  buf.Bytes(),
+		Format: image.NewUncompressed("RGBA_F16", fmts.RGBA_F16),
+	}
+}
+
+func readFloats(data []byte) []float32 {
+	r := endian.Reader(bytes.NewReader(data), device.LittleEndian)
+	out := make([]float32, len(data)/4)
+	for i := range out {
+		out[i] = r.Float32()
+	}
+	return out
+}
+
+func TestHDRFormats(t *testing.T) {
+	for _, test := range []struct {
+		fmt *image.Format
+		// The maximum error relative to the largest color channel of the pixel.
+		tolerance float64
+	}{
+		{image.EXR, 0},
+		{image.HDR, 1.0 / 128},
+	} {
+		for _, width := range []int{5, 64} {
+			src := hdrTestImage(width, 9)
+			ref, err := src.Convert(image.RGBA_F32)
+			if err != nil {
+				t.Fatalf("Failed to convert to %v: %v", image.RGBA_F32.Name, err)
+			}
+			encoded, err := src.Convert(test.fmt)
+			if err != nil {
+				t.Errorf("Failed to convert %dx9 image to %v: %v", width, test.fmt.Name, err)
+				continue
+			}
+			decoded, err := encoded.Convert(image.RGBA_F32)
+			if err != nil {
+				t.Errorf("Failed to convert %dx9 image from %v: %v", width, test.fmt.Name, err)
+				continue
+			}
+			expected, got := readFloats(ref.Bytes), readFloats(decoded.Bytes)
+			for i := range expected {
+				p := expected[i-i%4:]
+				max := math.Max(float64(p[0]), math.Max(float64(p[1]), float64(p[2])))
+				if diff := math.Abs(float64(got[i] - expected[i])); diff > max*test.tolerance {
+					t.Errorf("%v %dx9 value %d was not as expected. Got: %v, expected: %v",
+						test.fmt.Name, width, i, got[i], expected[i])
+					break
+				}
+			}
+		}
+	}
+}
@@ -35,6 +35,12 @@ func (i *Info) ConvertTo(ctx context.Context, f *Format) (interface{}, error) {
 
 // Convert returns this image Info converted to the format f.
 func (i *Info) Convert(ctx context.Context, f *Format) (*Info, error) {
+	return i.Encode(ctx, f, NormalQuality)
+}
+
+// Encode returns this image Info converted to the format f, using the quality
+// q for any block compression.
+func (i *Info) Encode(ctx context.Context, f *Format, q Quality) (*Info, error) {
 	id, err := database.Store(ctx, &ConvertResolvable{
 		Bytes:      i.Bytes,
 		Width:      i.Width,
@@ -42,6 +48,7 @@ func (i *Info) Convert(ctx context.Context, f *Format) (*Info, error) {
 		Depth:      i.Depth,
 		FormatFrom: i.Format,
 		FormatTo:   f,
+		Quality:    uint32(q),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("Failed to convert ImageInfo to format %v: %v", f, err)
@@ -81,7 +88,13 @@ func (i *Info) Resize(ctx context.Context, w, h, d uint32) (*Info, error) {
 
 // Convert returns the Data converted to the format to.
 func (b *Data) Convert(to *Format) (*Data, error) {
-	bytes, err := Convert(b.Bytes, int(b.Width), int(b.Height), int(b.Depth), b.Format, to)
+	return b.Encode(to, NormalQuality)
+}
+
+// Encode returns the Data converted to the format to, using the quality q for
+// any block compression.
+func (b *Data) Encode(to *Format, q Quality) (*Data, error) {
+	bytes, err := Encode(b.Bytes, int(b.Width), int(b.Height), int(b.Depth), b.Format, to, q)
 	if err != nil {
 		return nil, err
 	}
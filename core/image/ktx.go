@@ -0,0 +1,253 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/google/gapid/core/data/binary"
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/math/sint"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/stream"
+)
+
+var (
+	KTX  = NewKTX("ktx", 1)
+	KTX2 = NewKTX("ktx2", 2)
+)
+
+// NewKTX returns a format representing a Khronos KTX texture container of the
+// given version, which must be 1 or 2.
+func NewKTX(name string, version uint32) *Format {
+	return &Format{name, &Format_Ktx{&FmtKTX{version}}}
+}
+
+func (f *FmtKTX) key() interface{}                   { return *f }
+func (*FmtKTX) size(w, h, d int) int                 { return -1 }
+func (*FmtKTX) check(data []byte, w, h, d int) error { return nil }
+func (*FmtKTX) channels() []stream.Channel {
+	return nil
+}
+
+// wrap returns the single image formed from data, width, height and depth as
+// a KTX container, preserving the source format where possible.
+func (f *FmtKTX) wrap(data []byte, width, height, depth int, srcFmt *Format) ([]byte, error) {
+	img := &Data{
+		Bytes:  data,
+		Width:  uint32(width),
+		Height: uint32(height),
+		Depth:  uint32(depth),
+		Format: srcFmt,
+	}
+	return EncodeKTX(f.Version, [][]*Data{{img}}, false)
+}
+
+var (
+	ktx1Identifier = []byte{0xAB, 'K', 'T', 'X', ' ', '1', '1', 0xBB, '\r', '\n', 0x1A, '\n'}
+	ktx2Identifier = []byte{0xAB, 'K', 'T', 'X', ' ', '2', '0', 0xBB, '\r', '\n', 0x1A, '\n'}
+)
+
+// EncodeKTX returns the images of a texture as a KTX container of the given
+// version (1 or 2). levels holds the images of each mip level, starting with
+// the largest. Each level holds the same number of layers, and every image
+// must share the same format. If cubemap is true then the layers of each level
+// are the faces +X, -X, +Y, -Y, +Z, -Z of each cubemap in turn. Textures with
+// more than one layer (or more than one cubemap) are stored as array textures.
+//
+// The images are stored in their original format if the container supports
+// it, otherwise they are converted to RGBA_F32 so that no data is lost.
+func EncodeKTX(version uint32, levels [][]*Data, cubemap bool) ([]byte, error) {
+	if version != 1 && version != 2 {
+		return nil, fmt.Errorf("Unsupported KTX version %d", version)
+	}
+	if err := checkTexture(levels, cubemap); err != nil {
+		return nil, err
+	}
+	layers, faces := len(levels[0]), 1
+	if cubemap {
+		faces = 6
+	}
+
+	k, err := ktxFormatOf(levels[0][0].Format)
+	if err != nil || (version == 2 && k.vkFormat == 0) {
+		// Not representable in the container. Store the images as floats.
+		if levels, err = convertTexture(levels, RGBA_F32); err != nil {
+			return nil, err
+		}
+		if k, err = ktxFormatOf(RGBA_F32); err != nil {
+			return nil, err
+		}
+	}
+
+	elements := layers / faces
+	if elements == 1 {
+		elements = 0 // Not an array texture.
+	}
+
+	buf := &bytes.Buffer{}
+	w := endian.Writer(buf, device.LittleEndian)
+	if version == 1 {
+		writeKTX1(w, k, levels, faces, elements)
+	} else {
+		writeKTX2(buf, w, k, levels, faces, elements)
+	}
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ktxDepth returns the pixel depth stored in a KTX header for the image,
+// which is 0 for 2D images.
+func ktxDepth(img *Data) uint32 {
+	if img.Depth == 1 {
+		return 0
+	}
+	return img.Depth
+}
+
+func writeKTX1(w binary.Writer, k ktxFormat, levels [][]*Data, faces, elements int) {
+	base := levels[0][0]
+	w.Data(ktx1Identifier)
+	w.Uint32(0x04030201) // Endianness
+	w.Uint32(k.glType)
+	w.Uint32(k.typeSize)
+	w.Uint32(k.glFormat)
+	w.Uint32(k.glInternalFormat)
+	w.Uint32(k.glBaseInternalFormat)
+	w.Uint32(base.Width)
+	w.Uint32(base.Height)
+	w.Uint32(ktxDepth(base))
+	w.Uint32(uint32(elements))
+	w.Uint32(uint32(faces))
+	w.Uint32(uint32(len(levels)))
+	w.Uint32(0) // bytesOfKeyValueData
+
+	for _, level := range levels {
+		// Rows of uncompressed images are aligned to 4 bytes.
+		img := level[0]
+		rowSize := k.blockSize * int(img.Width)
+		rows := int(img.Height * img.Depth)
+		if k.glType == 0 {
+			rowSize = len(img.Bytes)
+			rows = 1
+		}
+		padded := sint.AlignUp(rowSize, 4)
+		imageSize := padded * rows
+		if faces == 1 || elements != 0 {
+			imageSize *= len(level)
+		}
+		w.Uint32(uint32(imageSize))
+		for _, img := range level {
+			for r := 0; r < rows; r++ {
+				w.Data(img.Bytes[r*rowSize : (r+1)*rowSize])
+				w.Data(make([]byte, padded-rowSize))
+			}
+		}
+	}
+}
+
+func writeKTX2(buf *bytes.Buffer, w binary.Writer, k ktxFormat, levels [][]*Data, faces, elements int) {
+	const headerSize = 12 + 9*4 + 4*4 + 2*8
+	levelIndexSize := len(levels) * 3 * 8
+	dfd := ktxDataFormatDescriptor(k)
+
+	base := levels[0][0]
+	w.Data(ktx2Identifier)
+	w.Uint32(k.vkFormat)
+	w.Uint32(k.typeSize)
+	w.Uint32(base.Width)
+	w.Uint32(base.Height)
+	w.Uint32(ktxDepth(base))
+	w.Uint32(uint32(elements))
+	w.Uint32(uint32(faces))
+	w.Uint32(uint32(len(levels)))
+	w.Uint32(0) // supercompressionScheme
+
+	dfdOffset := headerSize + levelIndexSize
+	w.Uint32(uint32(dfdOffset))
+	w.Uint32(uint32(len(dfd)))
+	w.Uint32(0) // kvdByteOffset
+	w.Uint32(0) // kvdByteLength
+	w.Uint64(0) // sgdByteOffset
+	w.Uint64(0) // sgdByteLength
+
+	// Level data is stored from the smallest level to the largest, with each
+	// level aligned to the least common multiple of the block size and 4.
+	alignment := k.blockSize
+	for alignment%4 != 0 {
+		alignment += k.blockSize
+	}
+	offsets, sizes := make([]int, len(levels)), make([]int, len(levels))
+	offset := dfdOffset + len(dfd)
+	for i := len(levels) - 1; i >= 0; i-- {
+		offset = sint.AlignUp(offset, alignment)
+		for _, img := range levels[i] {
+			sizes[i] += len(img.Bytes)
+		}
+		offsets[i] = offset
+		offset += sizes[i]
+	}
+	for i := range levels {
+		w.Uint64(uint64(offsets[i]))
+		w.Uint64(uint64(sizes[i])) // byteLength
+		w.Uint64(uint64(sizes[i])) // uncompressedByteLength
+	}
+	w.Data(dfd)
+
+	for i := len(levels) - 1; i >= 0; i-- {
+		w.Data(make([]byte, offsets[i]-buf.Len()))
+		for _, img := range levels[i] {
+			w.Data(img.Bytes)
+		}
+	}
+}
+
+// ktxDataFormatDescriptor returns the Khronos data format descriptor of the
+// format, consisting of a single basic descriptor block.
+func ktxDataFormatDescriptor(k ktxFormat) []byte {
+	blockSize := 24 + 16*len(k.samples)
+	buf := &bytes.Buffer{}
+	w := endian.Writer(buf, device.LittleEndian)
+	w.Uint32(uint32(4 + blockSize)) // dfdTotalSize
+	w.Uint32(0)                     // vendorId, descriptorType
+	w.Uint16(2)                     // versionNumber
+	w.Uint16(uint16(blockSize))
+	w.Uint8(k.model)
+	w.Uint8(1) // colorPrimaries: BT709
+	if k.srgb {
+		w.Uint8(2) // transferFunction: sRGB
+	} else {
+		w.Uint8(1) // transferFunction: linear
+	}
+	w.Uint8(0) // flags: straight alpha
+	w.Uint8(uint8(k.blockW - 1))
+	w.Uint8(uint8(k.blockH - 1))
+	w.Uint8(0)
+	w.Uint8(0)
+	w.Uint8(uint8(k.blockSize)) // bytesPlane0
+	w.Data(make([]byte, 7))     // bytesPlane1-7
+	for _, s := range k.samples {
+		w.Uint16(s.bitOffset)
+		w.Uint8(s.bitLength - 1)
+		w.Uint8(s.channel | s.qualifiers<<4)
+		w.Uint32(0) // samplePosition0-3
+		w.Uint32(s.lower)
+		w.Uint32(s.upper)
+	}
+	return buf.Bytes()
+}
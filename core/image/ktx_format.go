@@ -0,0 +1,379 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/google/gapid/core/data/protoutil"
+	"github.com/google/gapid/core/stream"
+	"github.com/google/gapid/core/stream/fmts"
+)
+
+// ktxFormat describes how images of a format are stored in KTX containers.
+type ktxFormat struct {
+	glInternalFormat     uint32
+	glFormat             uint32 // 0 for compressed formats.
+	glType               uint32 // 0 for compressed formats.
+	glBaseInternalFormat uint32
+	typeSize             uint32
+	vkFormat             uint32 // 0 if there is no equivalent Vulkan format.
+	// The data format descriptor model, block dimensions and samples.
+	model     uint8
+	blockW    int
+	blockH    int
+	blockSize int
+	samples   []ktxSample
+	srgb      bool
+}
+
+// ktxSample is a sample of a Khronos data format descriptor.
+type ktxSample struct {
+	bitOffset  uint16
+	bitLength  uint8
+	channel    uint8
+	qualifiers uint8
+	lower      uint32
+	upper      uint32
+}
+
+// Khronos data format descriptor color models.
+const (
+	ktxModelRGBSDA = 1
+	ktxModelBC1A   = 128
+	ktxModelBC2    = 129
+	ktxModelBC3    = 130
+	ktxModelBC4    = 131
+	ktxModelBC5    = 132
+	ktxModelBC6H   = 133
+	ktxModelBC7    = 134
+	ktxModelETC2   = 161
+	ktxModelASTC   = 162
+	ktxModelPVRTC  = 164
+	ktxModelPVRTC2 = 165
+)
+
+// Khronos data format descriptor sample qualifiers.
+const (
+	ktxLinear   = 0x1
+	ktxExponent = 0x2
+	ktxSigned   = 0x4
+	ktxFloat    = 0x8
+)
+
+// GL enums used by KTX 1 containers.
+const (
+	glByte                   = 0x1400
+	glUnsignedByte           = 0x1401
+	glShort                  = 0x1402
+	glUnsignedShort          = 0x1403
+	glFloat                  = 0x1406
+	glHalfFloat              = 0x140B
+	glUnsignedInt5999Rev     = 0x8C3E
+	glUnsignedInt10F11F11Rev = 0x8C3B
+	glDepthComponent         = 0x1902
+	glRed                    = 0x1903
+	glRGB                    = 0x1907
+	glRGBA                   = 0x1908
+	glRG                     = 0x8227
+)
+
+// ktxUncompressed lists the uncompressed formats that can be stored in KTX
+// containers without conversion.
+var ktxUncompressed = []struct {
+	format                             *stream.Format
+	glInternalFormat, glFormat, glType uint32
+	vkFormat                           uint32
+}{
+	{fmts.R_U8_NORM, 0x8229, glRed, glUnsignedByte, 9},
+	{fmts.R_S8_NORM, 0x8F94, glRed, glByte, 10},
+	{fmts.RG_U8_NORM, 0x822B, glRG, glUnsignedByte, 16},
+	{fmts.RG_S8_NORM, 0x8F95, glRG, glByte, 17},
+	{fmts.RGB_U8_NORM, 0x8051, glRGB, glUnsignedByte, 23},
+	{fmts.SRGB_U8_NORM, 0x8C41, glRGB, glUnsignedByte, 29},
+	{fmts.RGBA_U8_NORM, 0x8058, glRGBA, glUnsignedByte, 37},
+	{fmts.RGBA_S8_NORM, 0x8F97, glRGBA, glByte, 38},
+	{fmts.SRGBA_U8_NORM, 0x8C43, glRGBA, glUnsignedByte, 43},
+	{fmts.R_U16_NORM, 0x822A, glRed, glUnsignedShort, 70},
+	{fmts.R_S16_NORM, 0x8F98, glRed, glShort, 71},
+	{fmts.R_F16, 0x822D, glRed, glHalfFloat, 76},
+	{fmts.RG_U16_NORM, 0x822C, glRG, glUnsignedShort, 77},
+	{fmts.RG_S16_NORM, 0x8F99, glRG, glShort, 78},
+	{fmts.RG_F16, 0x822F, glRG, glHalfFloat, 83},
+	{fmts.RGB_F16, 0x881B, glRGB, glHalfFloat, 90},
+	{fmts.RGBA_U16_NORM, 0x805B, glRGBA, glUnsignedShort, 91},
+	{fmts.RGBA_F16, 0x881A, glRGBA, glHalfFloat, 97},
+	{fmts.R_F32, 0x822E, glRed, glFloat, 100},
+	{fmts.RG_F32, 0x8230, glRG, glFloat, 103},
+	{fmts.RGB_F32, 0x8815, glRGB, glFloat, 106},
+	{fmts.RGBA_F32, 0x8814, glRGBA, glFloat, 109},
+	{fmts.BGR_F10F11F11, 0x8C3A, glRGB, glUnsignedInt10F11F11Rev, 122},
+	{fmts.RGBE_U9U9U9U5, 0x8C3D, glRGB, glUnsignedInt5999Rev, 123},
+	{fmts.D_U16_NORM, 0x81A5, glDepthComponent, glUnsignedShort, 124},
+	{fmts.D_F32, 0x8CAC, glDepthComponent, glFloat, 126},
+}
+
+// ktxFormatOf returns the description of how images of format f are stored in
+// KTX containers, or an error if f cannot be stored without conversion.
+func ktxFormatOf(f *Format) (ktxFormat, error) {
+	// compressed returns a ktxFormat for a block compressed format.
+	compressed := func(glInternalFormat, glBase, vkFormat uint32, model uint8, blockW, blockH int, samples ...ktxSample) ktxFormat {
+		return ktxFormat{
+			glInternalFormat:     glInternalFormat,
+			glBaseInternalFormat: glBase,
+			typeSize:             1,
+			vkFormat:             vkFormat,
+			model:                model,
+			blockW:               blockW,
+			blockH:               blockH,
+			blockSize:            f.Size(blockW, blockH, 1),
+			samples:              samples,
+		}
+	}
+	// block returns a sample of bits bits of a block starting at offset.
+	block := func(offset uint16, bits uint8, channel uint8, signed bool) ktxSample {
+		if signed {
+			return ktxSample{offset, bits, channel, ktxSigned, 0x80000000, 0x7fffffff}
+		}
+		return ktxSample{offset, bits, channel, 0, 0, 0xffffffff}
+	}
+	srgb := func(k ktxFormat, srgb bool) ktxFormat {
+		k.srgb = srgb
+		return k
+	}
+
+	switch t := protoutil.OneOf(f.Format).(type) {
+	case *FmtUncompressed:
+		return ktxUncompressedFormat(t.Format)
+	case *FmtETC1_RGB_U8_NORM:
+		// ETC1 data is valid ETC2 data, which has a Vulkan format.
+		return compressed(0x8D64, glRGB, 147, ktxModelETC2, 4, 4, block(0, 64, 2, false)), nil
+	case *FmtETC2_RGB_U8_NORM:
+		if t.Srgb {
+			return srgb(compressed(0x9275, glRGB, 148, ktxModelETC2, 4, 4, block(0, 64, 2, false)), true), nil
+		}
+		return compressed(0x9274, glRGB, 147, ktxModelETC2, 4, 4, block(0, 64, 2, false)), nil
+	case *FmtETC2_RGBA_U8U8U8U1_NORM:
+		if t.Srgb {
+			return srgb(compressed(0x9277, glRGBA, 150, ktxModelETC2, 4, 4, block(0, 64, 2, false)), true), nil
+		}
+		return compressed(0x9276, glRGBA, 149, ktxModelETC2, 4, 4, block(0, 64, 2, false)), nil
+	case *FmtETC2_RGBA_U8_NORM:
+		if t.Srgb {
+			return srgb(compressed(0x9279, glRGBA, 152, ktxModelETC2, 4, 4,
+				block(0, 64, 15, false), block(64, 64, 2, false)), true), nil
+		}
+		return compressed(0x9278, glRGBA, 151, ktxModelETC2, 4, 4,
+			block(0, 64, 15, false), block(64, 64, 2, false)), nil
+	case *FmtETC2_R_U11_NORM:
+		return compressed(0x9270, glRed, 153, ktxModelETC2, 4, 4, block(0, 64, 0, false)), nil
+	case *FmtETC2_R_S11_NORM:
+		return compressed(0x9271, glRed, 154, ktxModelETC2, 4, 4, block(0, 64, 0, true)), nil
+	case *FmtETC2_RG_U11_NORM:
+		return compressed(0x9272, glRG, 155, ktxModelETC2, 4, 4,
+			block(0, 64, 0, false), block(64, 64, 1, false)), nil
+	case *FmtETC2_RG_S11_NORM:
+		return compressed(0x9273, glRG, 156, ktxModelETC2, 4, 4,
+			block(0, 64, 0, true), block(64, 64, 1, true)), nil
+	case *FmtS3_DXT1_RGB:
+		return compressed(0x83F0, glRGB, 131, ktxModelBC1A, 4, 4, block(0, 64, 0, false)), nil
+	case *FmtS3_DXT1_RGBA:
+		return compressed(0x83F1, glRGBA, 133, ktxModelBC1A, 4, 4, block(0, 64, 1, false)), nil
+	case *FmtS3_DXT3_RGBA:
+		return compressed(0x83F2, glRGBA, 135, ktxModelBC2, 4, 4,
+			block(0, 64, 15, false), block(64, 64, 0, false)), nil
+	case *FmtS3_DXT5_RGBA:
+		return compressed(0x83F3, glRGBA, 137, ktxModelBC3, 4, 4,
+			block(0, 64, 15, false), block(64, 64, 0, false)), nil
+	case *FmtBC4_R_U8_NORM:
+		return compressed(0x8DBB, glRed, 139, ktxModelBC4, 4, 4, block(0, 64, 0, false)), nil
+	case *FmtBC4_R_S8_NORM:
+		return compressed(0x8DBC, glRed, 140, ktxModelBC4, 4, 4, block(0, 64, 0, true)), nil
+	case *FmtBC5_RG_U8_NORM:
+		return compressed(0x8DBD, glRG, 141, ktxModelBC5, 4, 4,
+			block(0, 64, 0, false), block(64, 64, 1, false)), nil
+	case *FmtBC5_RG_S8_NORM:
+		return compressed(0x8DBE, glRG, 142, ktxModelBC5, 4, 4,
+			block(0, 64, 0, true), block(64, 64, 1, true)), nil
+	case *FmtBC6H_RGB_U16_FLOAT:
+		return compressed(0x8E8F, glRGB, 143, ktxModelBC6H, 4, 4,
+			ktxSample{0, 128, 0, ktxFloat, 0, math.Float32bits(1)}), nil
+	case *FmtBC6H_RGB_S16_FLOAT:
+		return compressed(0x8E8E, glRGB, 144, ktxModelBC6H, 4, 4,
+			ktxSample{0, 128, 0, ktxFloat | ktxSigned, math.Float32bits(-1), math.Float32bits(1)}), nil
+	case *FmtBC7_RGBA_U8_NORM:
+		if t.Srgb {
+			return srgb(compressed(0x8E8D, glRGBA, 146, ktxModelBC7, 4, 4, block(0, 128, 0, false)), true), nil
+		}
+		return compressed(0x8E8C, glRGBA, 145, ktxModelBC7, 4, 4, block(0, 128, 0, false)), nil
+	case *FmtASTC:
+		for i, size := range [][2]uint32{
+			{4, 4}, {5, 4}, {5, 5}, {6, 5}, {6, 6}, {8, 5}, {8, 6},
+			{8, 8}, {10, 5}, {10, 6}, {10, 8}, {10, 10}, {12, 10}, {12, 12},
+		} {
+			if size != [2]uint32{t.BlockWidth, t.BlockHeight} {
+				continue
+			}
+			bw, bh := int(t.BlockWidth), int(t.BlockHeight)
+			if t.Srgb {
+				return srgb(compressed(0x93D0+uint32(i), glRGBA, 158+uint32(i)*2, ktxModelASTC, bw, bh,
+					block(0, 128, 0, false)), true), nil
+			}
+			return compressed(0x93B0+uint32(i), glRGBA, 157+uint32(i)*2, ktxModelASTC, bw, bh,
+				block(0, 128, 0, false)), nil
+		}
+	case *FmtPVRTC1:
+		base, bw := uint32(glRGB), 4
+		if t.Alpha {
+			base = glRGBA
+		}
+		if t.BitsPerPixel == 2 {
+			bw = 8
+		}
+		gl := map[[3]bool]uint32{
+			{false, false, false}: 0x8C00, {false, true, false}: 0x8C02,
+			{true, false, false}: 0x8C01, {true, true, false}: 0x8C03,
+			{false, false, true}: 0x8A55, {false, true, true}: 0x8A57,
+			{true, false, true}: 0x8A54, {true, true, true}: 0x8A56,
+		}[[3]bool{bw == 8, t.Alpha, t.Srgb}]
+		vk := map[[2]bool]uint32{
+			{true, false}: 1000054000, {false, false}: 1000054001,
+			{true, true}: 1000054004, {false, true}: 1000054005,
+		}[[2]bool{bw == 8, t.Srgb}]
+		k := srgb(compressed(gl, base, vk, ktxModelPVRTC, bw, 4, block(0, 64, 0, false)), t.Srgb)
+		k.blockSize = 8 // Size() rounds small images up to the minimum size.
+		return k, nil
+	case *FmtPVRTC2:
+		bw := 4
+		if t.BitsPerPixel == 2 {
+			bw = 8
+		}
+		gl := map[[2]bool]uint32{
+			{true, false}: 0x9137, {false, false}: 0x9138,
+			{true, true}: 0x93F0, {false, true}: 0x93F1,
+		}[[2]bool{bw == 8, t.Srgb}]
+		vk := map[[2]bool]uint32{
+			{true, false}: 1000054002, {false, false}: 1000054003,
+			{true, true}: 1000054006, {false, true}: 1000054007,
+		}[[2]bool{bw == 8, t.Srgb}]
+		return srgb(compressed(gl, glRGBA, vk, ktxModelPVRTC2, bw, 4, block(0, 64, 0, false)), t.Srgb), nil
+	case *FmtATC_RGB_AMD:
+		return compressed(0x8C92, glRGB, 0, 0, 4, 4), nil
+	case *FmtATC_RGBA_EXPLICIT_ALPHA_AMD:
+		return compressed(0x8C93, glRGBA, 0, 0, 4, 4), nil
+	case *FmtATC_RGBA_INTERPOLATED_ALPHA_AMD:
+		return compressed(0x87EE, glRGBA, 0, 0, 4, 4), nil
+	}
+	return ktxFormat{}, fmt.Errorf("Format %v cannot be stored in a KTX container", f.Name)
+}
+
+// ktxUncompressedFormat returns the description of the uncompressed format f
+// for KTX containers.
+func ktxUncompressedFormat(f *stream.Format) (ktxFormat, error) {
+	for _, u := range ktxUncompressed {
+		if u.format.String() != f.String() {
+			continue
+		}
+		k := ktxFormat{
+			glInternalFormat:     u.glInternalFormat,
+			glFormat:             u.glFormat,
+			glType:               u.glType,
+			glBaseInternalFormat: u.glFormat,
+			vkFormat:             u.vkFormat,
+			model:                ktxModelRGBSDA,
+			blockW:               1,
+			blockH:               1,
+			blockSize:            f.Stride(),
+		}
+		switch u.glType {
+		case glUnsignedByte, glByte:
+			k.typeSize = 1
+		case glUnsignedShort, glShort, glHalfFloat:
+			k.typeSize = 2
+		default:
+			k.typeSize = 4
+		}
+
+		offsets := f.BitOffsets()
+		var exponent *stream.Component
+		for _, c := range f.Components {
+			if c.Channel == stream.Channel_SharedExponent {
+				exponent = c
+			}
+			if c.GetSampling().GetCurve() == stream.Curve_sRGB {
+				k.srgb = true
+			}
+		}
+		for _, c := range f.Components {
+			if c == exponent {
+				continue
+			}
+			s := ktxSample{
+				bitOffset: uint16(offsets[c]),
+				bitLength: uint8(c.DataType.Bits()),
+			}
+			switch c.Channel {
+			case stream.Channel_Red:
+				s.channel = 0
+			case stream.Channel_Green:
+				s.channel = 1
+			case stream.Channel_Blue:
+				s.channel = 2
+			case stream.Channel_Stencil:
+				s.channel = 13
+			case stream.Channel_Depth:
+				s.channel = 14
+			case stream.Channel_Alpha:
+				s.channel = 15
+				if k.srgb {
+					s.qualifiers |= ktxLinear
+				}
+			}
+			bits := c.DataType.Bits()
+			switch {
+			case exponent != nil:
+				// The mantissa of a shared exponent format.
+				s.upper = 1 << (bits - 1)
+			case c.DataType.IsFloat():
+				s.qualifiers |= ktxFloat
+				s.upper = math.Float32bits(1)
+				if c.DataType.Signed {
+					s.qualifiers |= ktxSigned
+					s.lower = math.Float32bits(-1)
+				}
+			case c.DataType.Signed:
+				s.qualifiers |= ktxSigned
+				s.upper = 1<<(bits-1) - 1
+				s.lower = -s.upper
+			default:
+				s.upper = 1<<bits - 1
+			}
+			k.samples = append(k.samples, s)
+			if exponent != nil {
+				k.samples = append(k.samples, ktxSample{
+					bitOffset:  uint16(offsets[exponent]),
+					bitLength:  uint8(exponent.DataType.Bits()),
+					channel:    s.channel,
+					qualifiers: ktxExponent,
+					lower:      15, // Exponent bias.
+					upper:      1<<exponent.DataType.Bits() - 1,
+				})
+			}
+		}
+		return k, nil
+	}
+	return ktxFormat{}, fmt.Errorf("Format %v cannot be stored in a KTX container", f)
+}
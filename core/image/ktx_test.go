@@ -0,0 +1,131 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/google/gapid/core/image"
+)
+
+func TestKTXHeader(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		img           *image.Data
+		container     *image.Format
+		identifier    string
+		formatOffset  int
+		format        uint32
+		imageSize     uint32 // KTX 1 only.
+		imageSizeFrom int
+	}{
+		{
+			name:          "DXT1 in KTX",
+			img:           &image.Data{Format: image.S3_DXT1_RGB, Width: 8, Height: 8, Depth: 1, Bytes: make([]byte, 32)},
+			container:     image.KTX,
+			identifier:    "\xABKTX 11\xBB\r\n\x1A\n",
+			formatOffset:  28, // glInternalFormat
+			format:        0x83F0,
+			imageSize:     32,
+			imageSizeFrom: 64,
+		}, {
+			name: "RGB8 in KTX",
+			// Rows of 3 pixels are padded from 9 to 12 bytes.
+			img:           &image.Data{Format: image.RGB_U8_NORM, Width: 3, Height: 2, Depth: 1, Bytes: make([]byte, 18)},
+			container:     image.KTX,
+			identifier:    "\xABKTX 11\xBB\r\n\x1A\n",
+			formatOffset:  28, // glInternalFormat
+			format:        0x8051,
+			imageSize:     24,
+			imageSizeFrom: 64,
+		}, {
+			name:         "DXT1 in KTX2",
+			img:          &image.Data{Format: image.S3_DXT1_RGB, Width: 8, Height: 8, Depth: 1, Bytes: make([]byte, 32)},
+			container:    image.KTX2,
+			identifier:   "\xABKTX 20\xBB\r\n\x1A\n",
+			formatOffset: 12, // vkFormat
+			format:       131,
+		}, {
+			name: "ATC in KTX2",
+			// ATC has no Vulkan format, so is stored as RGBA_F32.
+			img:          &image.Data{Format: image.ATC_RGB_AMD, Width: 4, Height: 4, Depth: 1, Bytes: make([]byte, 8)},
+			container:    image.KTX2,
+			identifier:   "\xABKTX 20\xBB\r\n\x1A\n",
+			formatOffset: 12, // vkFormat
+			format:       109,
+		},
+	} {
+		out, err := test.img.Convert(test.container)
+		if err != nil {
+			t.Errorf("%v: Convert returned error: %v", test.name, err)
+			continue
+		}
+		data := out.Bytes
+		if !bytes.HasPrefix(data, []byte(test.identifier)) {
+			t.Errorf("%v: Unexpected identifier: %q", test.name, data[:12])
+			continue
+		}
+		if got := binary.LittleEndian.Uint32(data[test.formatOffset:]); got != test.format {
+			t.Errorf("%v: Format was not as expected. Got: 0x%x, expected: 0x%x", test.name, got, test.format)
+		}
+		if test.imageSizeFrom != 0 {
+			if got := binary.LittleEndian.Uint32(data[test.imageSizeFrom:]); got != test.imageSize {
+				t.Errorf("%v: Image size was not as expected. Got: %v, expected: %v", test.name, got, test.imageSize)
+			}
+			if got, expected := len(data), test.imageSizeFrom+4+int(test.imageSize); got != expected {
+				t.Errorf("%v: File size was not as expected. Got: %v, expected: %v", test.name, got, expected)
+			}
+		}
+	}
+}
+
+func TestKTXCubemapMipmaps(t *testing.T) {
+	levels := [][]*image.Data{}
+	for size := uint32(8); size >= 1; size /= 2 {
+		faces := []*image.Data{}
+		for face := 0; face < 6; face++ {
+			data := bytes.Repeat([]byte{byte(face)}, image.S3_DXT1_RGB.Size(int(size), int(size), 1))
+			faces = append(faces, &image.Data{Format: image.S3_DXT1_RGB, Width: size, Height: size, Depth: 1, Bytes: data})
+		}
+		levels = append(levels, faces)
+	}
+
+	data, err := image.EncodeKTX(2, levels, true)
+	if err != nil {
+		t.Fatalf("EncodeKTX returned error: %v", err)
+	}
+	u32 := func(offset int) uint32 { return binary.LittleEndian.Uint32(data[offset:]) }
+	u64 := func(offset int) uint64 { return binary.LittleEndian.Uint64(data[offset:]) }
+	if layers, faces, count := u32(32), u32(36), u32(40); layers != 0 || faces != 6 || count != 4 {
+		t.Errorf("Unexpected layers, faces or levels: %v, %v, %v", layers, faces, count)
+	}
+	for i, level := range levels {
+		offset, length := u64(80+i*24), u64(88+i*24)
+		if expected := uint64(len(level[0].Bytes) * 6); length != expected {
+			t.Errorf("Level %d length was not as expected. Got: %v, expected: %v", i, length, expected)
+			continue
+		}
+		if offset%8 != 0 || offset+length > uint64(len(data)) {
+			t.Errorf("Level %d has invalid offset %v", i, offset)
+			continue
+		}
+		faces := data[offset : offset+length]
+		if faces[0] != 0 || faces[len(faces)-1] != 5 {
+			t.Errorf("Level %d faces are not in order", i)
+		}
+	}
+}
@@ -0,0 +1,436 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"fmt"
+
+	"github.com/google/gapid/core/math/sint"
+	"github.com/google/gapid/core/stream"
+)
+
+var (
+	PVRTC1_RGB_2BPP   = NewPVRTC1_RGB_2BPP("PVRTC1_RGB_2BPP")
+	PVRTC1_RGB_4BPP   = NewPVRTC1_RGB_4BPP("PVRTC1_RGB_4BPP")
+	PVRTC1_RGBA_2BPP  = NewPVRTC1_RGBA_2BPP("PVRTC1_RGBA_2BPP")
+	PVRTC1_RGBA_4BPP  = NewPVRTC1_RGBA_4BPP("PVRTC1_RGBA_4BPP")
+	PVRTC1_SRGB_2BPP  = NewPVRTC1_SRGB_2BPP("PVRTC1_SRGB_2BPP")
+	PVRTC1_SRGB_4BPP  = NewPVRTC1_SRGB_4BPP("PVRTC1_SRGB_4BPP")
+	PVRTC1_SRGBA_2BPP = NewPVRTC1_SRGBA_2BPP("PVRTC1_SRGBA_2BPP")
+	PVRTC1_SRGBA_4BPP = NewPVRTC1_SRGBA_4BPP("PVRTC1_SRGBA_4BPP")
+	PVRTC2_RGBA_2BPP  = NewPVRTC2_RGBA_2BPP("PVRTC2_RGBA_2BPP")
+	PVRTC2_RGBA_4BPP  = NewPVRTC2_RGBA_4BPP("PVRTC2_RGBA_4BPP")
+	PVRTC2_SRGBA_2BPP = NewPVRTC2_SRGBA_2BPP("PVRTC2_SRGBA_2BPP")
+	PVRTC2_SRGBA_4BPP = NewPVRTC2_SRGBA_4BPP("PVRTC2_SRGBA_4BPP")
+)
+
+// NewPVRTC1 returns a format representing the PVRTC1 texture compression
+// format with the given number of bits per pixel (2 or 4).
+func NewPVRTC1(name string, bitsPerPixel uint32, alpha, srgb bool) *Format {
+	return &Format{name, &Format_Pvrtc1{&FmtPVRTC1{bitsPerPixel, alpha, srgb}}}
+}
+
+// NewPVRTC1_RGB_2BPP returns a format representing the
+// COMPRESSED_RGB_PVRTC_2BPPV1_IMG texture compression format.
+func NewPVRTC1_RGB_2BPP(name string) *Format { return NewPVRTC1(name, 2, false, false) }
+
+// NewPVRTC1_RGB_4BPP returns a format representing the
+// COMPRESSED_RGB_PVRTC_4BPPV1_IMG texture compression format.
+func NewPVRTC1_RGB_4BPP(name string) *Format { return NewPVRTC1(name, 4, false, false) }
+
+// NewPVRTC1_RGBA_2BPP returns a format representing the
+// COMPRESSED_RGBA_PVRTC_2BPPV1_IMG texture compression format.
+func NewPVRTC1_RGBA_2BPP(name string) *Format { return NewPVRTC1(name, 2, true, false) }
+
+// NewPVRTC1_RGBA_4BPP returns a format representing the
+// COMPRESSED_RGBA_PVRTC_4BPPV1_IMG texture compression format.
+func NewPVRTC1_RGBA_4BPP(name string) *Format { return NewPVRTC1(name, 4, true, false) }
+
+// NewPVRTC1_SRGB_2BPP returns a format representing the
+// COMPRESSED_SRGB_PVRTC_2BPPV1_EXT texture compression format.
+func NewPVRTC1_SRGB_2BPP(name string) *Format { return NewPVRTC1(name, 2, false, true) }
+
+// NewPVRTC1_SRGB_4BPP returns a format representing the
+// COMPRESSED_SRGB_PVRTC_4BPPV1_EXT texture compression format.
+func NewPVRTC1_SRGB_4BPP(name string) *Format { return NewPVRTC1(name, 4, false, true) }
+
+// NewPVRTC1_SRGBA_2BPP returns a format representing the
+// COMPRESSED_SRGB_ALPHA_PVRTC_2BPPV1_EXT texture compression format.
+func NewPVRTC1_SRGBA_2BPP(name string) *Format { return NewPVRTC1(name, 2, true, true) }
+
+// NewPVRTC1_SRGBA_4BPP returns a format representing the
+// COMPRESSED_SRGB_ALPHA_PVRTC_4BPPV1_EXT texture compression format.
+func NewPVRTC1_SRGBA_4BPP(name string) *Format { return NewPVRTC1(name, 4, true, true) }
+
+// NewPVRTC2 returns a format representing the PVRTC2 texture compression
+// format with the given number of bits per pixel (2 or 4).
+//
+// PVRTC2 words share the layout of PVRTC1 words, but both colors share one
+// opacity flag and the freed bit is a hard transition flag. Texels of words
+// with the flag set take the colors of their own word instead of
+// interpolating them from the neighbouring words. In 4bpp mode, a hard
+// transition word with the modulation mode flag set uses a local palette,
+// which is approximated by the standard modulation of its own colors. Words
+// are stored in row-major order and images are not padded beyond a whole
+// number of words.
+func NewPVRTC2(name string, bitsPerPixel uint32, srgb bool) *Format {
+	return &Format{name, &Format_Pvrtc2{&FmtPVRTC2{bitsPerPixel, srgb}}}
+}
+
+// NewPVRTC2_RGBA_2BPP returns a format representing the
+// COMPRESSED_RGBA_PVRTC_2BPPV2_IMG texture compression format.
+func NewPVRTC2_RGBA_2BPP(name string) *Format { return NewPVRTC2(name, 2, false) }
+
+// NewPVRTC2_RGBA_4BPP returns a format representing the
+// COMPRESSED_RGBA_PVRTC_4BPPV2_IMG texture compression format.
+func NewPVRTC2_RGBA_4BPP(name string) *Format { return NewPVRTC2(name, 4, false) }
+
+// NewPVRTC2_SRGBA_2BPP returns a format representing the
+// COMPRESSED_SRGB_ALPHA_PVRTC_2BPPV2_IMG texture compression format.
+func NewPVRTC2_SRGBA_2BPP(name string) *Format { return NewPVRTC2(name, 2, true) }
+
+// NewPVRTC2_SRGBA_4BPP returns a format representing the
+// COMPRESSED_SRGB_ALPHA_PVRTC_4BPPV2_IMG texture compression format.
+func NewPVRTC2_SRGBA_4BPP(name string) *Format { return NewPVRTC2(name, 4, true) }
+
+func (f *FmtPVRTC1) key() interface{} {
+	return *f
+}
+func (f *FmtPVRTC1) size(w, h, d int) int {
+	xWords, yWords := pvrtcWords(w, h, int(f.BitsPerPixel))
+	return d * xWords * yWords * 8
+}
+func (f *FmtPVRTC1) check(data []byte, w, h, d int) error {
+	if f.BitsPerPixel != 2 && f.BitsPerPixel != 4 {
+		return fmt.Errorf("Invalid PVRTC1 bits per pixel: %d", f.BitsPerPixel)
+	}
+	return checkSize(data, f, w, h, d)
+}
+func (f *FmtPVRTC1) channels() []stream.Channel {
+	if f.Alpha {
+		return []stream.Channel{stream.Channel_Red, stream.Channel_Green, stream.Channel_Blue, stream.Channel_Alpha}
+	}
+	return []stream.Channel{stream.Channel_Red, stream.Channel_Green, stream.Channel_Blue}
+}
+
+func (f *FmtPVRTC2) key() interface{} {
+	return *f
+}
+func (f *FmtPVRTC2) size(w, h, d int) int {
+	xWords, yWords := pvrtc2Words(w, h, int(f.BitsPerPixel))
+	return d * xWords * yWords * 8
+}
+func (f *FmtPVRTC2) check(data []byte, w, h, d int) error {
+	if f.BitsPerPixel != 2 && f.BitsPerPixel != 4 {
+		return fmt.Errorf("Invalid PVRTC2 bits per pixel: %d", f.BitsPerPixel)
+	}
+	return checkSize(data, f, w, h, d)
+}
+func (f *FmtPVRTC2) channels() []stream.Channel {
+	return []stream.Channel{stream.Channel_Red, stream.Channel_Green, stream.Channel_Blue, stream.Channel_Alpha}
+}
+
+func init() {
+	for _, f := range []*Format{
+		PVRTC1_RGB_2BPP, PVRTC1_RGB_4BPP, PVRTC1_RGBA_2BPP, PVRTC1_RGBA_4BPP,
+		PVRTC1_SRGB_2BPP, PVRTC1_SRGB_4BPP, PVRTC1_SRGBA_2BPP, PVRTC1_SRGBA_4BPP,
+	} {
+		pvrtc := f.GetPvrtc1()
+		dst := RGBA_U8_NORM
+		if pvrtc.Srgb {
+			dst = SRGBA_U8_NORM
+		}
+		RegisterConverter(f, dst, func(src []byte, w, h, d int) ([]byte, error) {
+			return decodePVRTC(src, w, h, d, int(pvrtc.BitsPerPixel), pvrtc.Alpha, false)
+		})
+	}
+	for _, f := range []*Format{
+		PVRTC2_RGBA_2BPP, PVRTC2_RGBA_4BPP, PVRTC2_SRGBA_2BPP, PVRTC2_SRGBA_4BPP,
+	} {
+		pvrtc := f.GetPvrtc2()
+		dst := RGBA_U8_NORM
+		if pvrtc.Srgb {
+			dst = SRGBA_U8_NORM
+		}
+		RegisterConverter(f, dst, func(src []byte, w, h, d int) ([]byte, error) {
+			return decodePVRTC(src, w, h, d, int(pvrtc.BitsPerPixel), true, true)
+		})
+	}
+}
+
+// pvrtcWords returns the number of 64 bit words used to encode each row and
+// column of a PVRTC1 image of the given size. Each word encodes 4x4 texels
+// in 4bpp mode and 8x4 texels in 2bpp mode, and images are padded to be at
+// least two words wide and high.
+func pvrtcWords(width, height, bpp int) (x, y int) {
+	wordWidth := 16 / bpp
+	return sint.Max(sint.AlignUp(width, wordWidth), wordWidth*2) / wordWidth,
+		sint.Max(sint.AlignUp(height, 4), 8) / 4
+}
+
+// pvrtc2Words returns the number of 64 bit words used to encode each row and
+// column of a PVRTC2 image of the given size.
+func pvrtc2Words(width, height, bpp int) (x, y int) {
+	wordWidth := 16 / bpp
+	return sint.AlignUp(width, wordWidth) / wordWidth, sint.AlignUp(height, 4) / 4
+}
+
+// decodePVRTC decodes the PVRTC1, or PVRTC2 if v2 is true, image src with the
+// given number of bits per pixel to RGBA_U8_NORM.
+func decodePVRTC(src []byte, width, height, depth, bpp int, alpha, v2 bool) ([]byte, error) {
+	wordWidth, wordHeight := 16/bpp, 4
+	xWords, yWords := pvrtcWords(width, height, bpp)
+	version := "PVRTC1"
+	if v2 {
+		xWords, yWords = pvrtc2Words(width, height, bpp)
+		version = "PVRTC2"
+	}
+	paddedWidth, paddedHeight := xWords*wordWidth, yWords*wordHeight
+	sliceSize := xWords * yWords * 8
+	if len(src) < sliceSize*depth {
+		return nil, fmt.Errorf("%s data too small (0x%x) for dimensions %dx%dx%d",
+			version, len(src), width, height, depth)
+	}
+
+	dst := make([]byte, width*height*depth*4)
+	out := dst
+	mod := make([]int, paddedWidth*paddedHeight)
+	modes := make([]int, paddedWidth*paddedHeight)
+	for z := 0; z < depth; z++ {
+		words := src[sliceSize*z : sliceSize*(z+1)]
+		// word returns the modulation and color data of the word at x, y,
+		// with PVRTC2 colors converted to the PVRTC1 encoding, and whether
+		// the word has the PVRTC2 hard transition flag set.
+		word := func(x, y int) (modulation, color uint32, hard bool) {
+			i := 0
+			if v2 {
+				x, y = sint.Clamp(x, 0, xWords-1), sint.Clamp(y, 0, yWords-1)
+				i = 8 * (y*xWords + x)
+			} else {
+				x, y = (x+xWords)%xWords, (y+yWords)%yWords
+				i = 8 * pvrtcTwiddle(xWords, yWords, x, y)
+			}
+			w := words[i : i+8]
+			modulation = uint32(w[0]) | uint32(w[1])<<8 | uint32(w[2])<<16 | uint32(w[3])<<24
+			color = uint32(w[4]) | uint32(w[5])<<8 | uint32(w[6])<<16 | uint32(w[7])<<24
+			if v2 {
+				hard = color&0x8000 != 0
+				color = color&^0x8000 | (color>>16)&0x8000
+				if hard && bpp == 4 {
+					color &^= 1 // Local palette approximated by standard modulation.
+				}
+			}
+			return
+		}
+
+		for y := 0; y < yWords; y++ {
+			for x := 0; x < xWords; x++ {
+				m, c, _ := word(x, y)
+				pvrtcUnpackModulation(m, c, bpp, mod, modes, x*wordWidth, y*wordHeight, paddedWidth)
+			}
+		}
+
+		for y := 0; y < height; y++ {
+			// The word colors are centered on each word, so each texel is
+			// interpolated from the four words with centers surrounding it.
+			wy, ty := (y+wordHeight/2)/wordHeight-1, (y+wordHeight/2)%wordHeight
+			for x := 0; x < width; x++ {
+				wx, tx := (x+wordWidth/2)/wordWidth-1, (x+wordWidth/2)%wordWidth
+				_, p, _ := word(wx, wy)
+				_, q, _ := word(wx+1, wy)
+				_, r, _ := word(wx, wy+1)
+				_, s, _ := word(wx+1, wy+1)
+				weights := [4]int{
+					(wordWidth - tx) * (wordHeight - ty),
+					tx * (wordHeight - ty),
+					(wordWidth - tx) * ty,
+					tx * ty,
+				}
+				if _, c, hard := word(x/wordWidth, y/wordHeight); hard {
+					p, weights = c, [4]int{wordWidth * wordHeight, 0, 0, 0}
+				}
+				a := pvrtcInterpolate(weights, bpp, pvrtcColorA(p), pvrtcColorA(q), pvrtcColorA(r), pvrtcColorA(s))
+				b := pvrtcInterpolate(weights, bpp, pvrtcColorB(p), pvrtcColorB(q), pvrtcColorB(r), pvrtcColorB(s))
+
+				m := pvrtcModulation(mod, modes, x, y, paddedWidth, paddedHeight, bpp, v2)
+				punchThrough := m > 8
+				if punchThrough {
+					m -= 10
+				}
+				for i := range a {
+					out[i] = byte((a[i]*(8-m) + b[i]*m) / 8)
+				}
+				switch {
+				case !alpha:
+					out[3] = 255
+				case punchThrough:
+					out[3] = 0
+				}
+				out = out[4:]
+			}
+		}
+	}
+	return dst, nil
+}
+
+// pvrtcTwiddle returns the index of the word at x, y. Words are stored in
+// Morton order over the smaller of the two dimensions, with y in the lowest
+// bit.
+func pvrtcTwiddle(xWords, yWords, x, y int) int {
+	min, rest := xWords, y
+	if yWords < xWords {
+		min, rest = yWords, x
+	}
+	out, shift := 0, uint(0)
+	for bit := 1; bit < min; bit <<= 1 {
+		if y&bit != 0 {
+			out |= 1 << (2 * shift)
+		}
+		if x&bit != 0 {
+			out |= 2 << (2 * shift)
+		}
+		shift++
+	}
+	return out | (rest>>shift)<<(2*shift)
+}
+
+// pvrtcUnpackModulation writes the modulation values and modes of the word at
+// the texel x, y to mod and modes. In 4bpp mode the values are the final
+// weights of color B in eighths, with 10 added for punch-through texels. In
+// 2bpp mode the values are 2 bit indices, and only stored for the texels that
+// are not interpolated from their neighbours.
+func pvrtcUnpackModulation(bits, color uint32, bpp int, mod, modes []int, x, y, stride int) {
+	mode := int(color & 1)
+	if bpp == 4 {
+		for i := 0; i < 16; i++ {
+			v := int(bits>>uint(2*i)) & 3
+			if mode == 0 {
+				v = [4]int{0, 3, 5, 8}[v]
+			} else {
+				v = [4]int{0, 4, 14, 8}[v]
+			}
+			mod[(y+i/4)*stride+x+i%4] = v
+		}
+		return
+	}
+
+	if mode == 0 {
+		// One bit per texel, selecting either color A or color B.
+		for i := 0; i < 32; i++ {
+			idx := (y+i/8)*stride + x + i%8
+			mod[idx], modes[idx] = int(bits>>uint(i)&1)*3, 0
+		}
+		return
+	}
+
+	// Half of the texels are stored in a checkerboard pattern, the other
+	// half are interpolated. The lowest bit of the first texel selects
+	// whether the interpolation is horizontal and vertical, or in just one
+	// direction, in which case the lowest bit of the center texel selects
+	// the direction.
+	if bits&1 != 0 {
+		if bits&(1<<20) != 0 {
+			mode = 3 // Vertical only.
+		} else {
+			mode = 2 // Horizontal only.
+		}
+		bits = bits&^(1<<20) | (bits>>21&1)<<20
+	}
+	bits = bits&^1 | (bits>>1)&1
+	for ty := 0; ty < 4; ty++ {
+		for tx := 0; tx < 8; tx++ {
+			idx := (y+ty)*stride + x + tx
+			modes[idx] = mode
+			if (tx^ty)&1 == 0 {
+				mod[idx] = int(bits & 3)
+				bits >>= 2
+			}
+		}
+	}
+}
+
+// pvrtcModulation returns the weight of color B in eighths for the texel x, y,
+// with 10 added for punch-through texels. Interpolated modulation values wrap
+// around the image edges in PVRTC1, and are clamped to them in PVRTC2.
+func pvrtcModulation(mod, modes []int, x, y, width, height, bpp int, v2 bool) int {
+	if bpp == 4 {
+		return mod[y*width+x]
+	}
+	weights := [4]int{0, 3, 5, 8}
+	at := func(x, y int) int {
+		if v2 {
+			x, y = sint.Clamp(x, 0, width-1), sint.Clamp(y, 0, height-1)
+		} else {
+			x, y = (x+width)%width, (y+height)%height
+		}
+		return weights[mod[y*width+x]]
+	}
+	mode := modes[y*width+x]
+	switch {
+	case mode == 0 || (x^y)&1 == 0:
+		return at(x, y)
+	case mode == 1:
+		return (at(x, y-1) + at(x, y+1) + at(x-1, y) + at(x+1, y) + 2) / 4
+	case mode == 2:
+		return (at(x-1, y) + at(x+1, y) + 1) / 2
+	default:
+		return (at(x, y-1) + at(x, y+1) + 1) / 2
+	}
+}
+
+// pvrtcColorA returns the 5 bit red, green and blue and 4 bit alpha of color
+// A of the word color data c.
+func pvrtcColorA(c uint32) [4]int {
+	if c&0x8000 != 0 {
+		// Opaque RGB554.
+		r, g, b := int(c>>10)&0x1f, int(c>>5)&0x1f, int(c>>1)&0xf
+		return [4]int{r, g, b<<1 | b>>3, 0xf}
+	}
+	// Translucent ARGB3443.
+	a, r, g, b := int(c>>12)&0x7, int(c>>8)&0xf, int(c>>4)&0xf, int(c>>1)&0x7
+	return [4]int{r<<1 | r>>3, g<<1 | g>>3, b<<2 | b>>1, a << 1}
+}
+
+// pvrtcColorB returns the 5 bit red, green and blue and 4 bit alpha of color
+// B of the word color data c.
+func pvrtcColorB(c uint32) [4]int {
+	if c&0x80000000 != 0 {
+		// Opaque RGB555.
+		return [4]int{int(c>>26) & 0x1f, int(c>>21) & 0x1f, int(c>>16) & 0x1f, 0xf}
+	}
+	// Translucent ARGB3444.
+	a, r, g, b := int(c>>28)&0x7, int(c>>24)&0xf, int(c>>20)&0xf, int(c>>16)&0xf
+	return [4]int{r<<1 | r>>3, g<<1 | g>>3, b<<1 | b>>3, a << 1}
+}
+
+// pvrtcInterpolate returns the 8 bit color bilinearly interpolated from the
+// four word colors p, q, r and s with the given weights. The weights sum to
+// 16 in 4bpp mode and 32 in 2bpp mode.
+func pvrtcInterpolate(weights [4]int, bpp int, p, q, r, s [4]int) [4]int {
+	out := [4]int{}
+	for i := range out {
+		v := p[i]*weights[0] + q[i]*weights[1] + r[i]*weights[2] + s[i]*weights[3]
+		if bpp == 4 {
+			v <<= 1
+		}
+		// v is now a 5 bit color or 4 bit alpha scaled by 32.
+		if i < 3 {
+			out[i] = v>>7 + v>>2
+		} else {
+			out[i] = v>>5 + v>>1
+		}
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/image"
+	"github.com/google/gapid/core/os/device"
+)
+
+func TestPVRTC2HardTransition(t *testing.T) {
+	assert := assert.To(t)
+
+	// Opaque colors, with the PVRTC1 color A opacity flag (bit 15) clear.
+	const (
+		opaque = 0x80000000
+		hard   = 0x8000
+		red    = 0x1f << 10 // Color A red.
+		blue   = 0xf << 1   // Color A blue.
+	)
+	// A 2x2 word image where every texel selects color A, and only the top
+	// left word has the hard transition flag set.
+	buf := &bytes.Buffer{}
+	w := endian.Writer(buf, device.LittleEndian)
+	for _, color := range []uint32{opaque | hard | red, opaque | blue, opaque | blue, opaque | blue} {
+		w.Uint32(0)
+		w.Uint32(color)
+	}
+	in := &image.Data{
+		Width:  8,
+		Height: 8,
+		Depth:  1,
+		Bytes:  buf.Bytes(),
+		Format: image.PVRTC2_RGBA_4BPP,
+	}
+	out, err := in.Convert(image.RGBA_U8_NORM)
+	if !assert.For("convert").ThatError(err).Succeeded() {
+		return
+	}
+
+	texel := func(x, y int) []byte {
+		i := (y*8 + x) * 4
+		return out.Bytes[i : i+4]
+	}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			assert.For("alpha at %d, %d", x, y).That(texel(x, y)[3]).Equals(byte(255))
+		}
+	}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			assert.For("hard texel %d, %d", x, y).ThatSlice(texel(x, y)).Equals([]byte{255, 0, 0, 255})
+		}
+	}
+	assert.For("soft texel").That(texel(4, 4)).DeepNotEquals([]byte{255, 0, 0, 255})
+	assert.For("soft texel blends").That(texel(4, 4)[0]).NotEquals(byte(0))
+}
@@ -0,0 +1,202 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"bytes"
+
+	"github.com/google/gapid/core/data/binary"
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/math/sint"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/stream"
+)
+
+var (
+	BC4_R_U8_NORM  = NewBC4_R_U8_NORM("BC4_R_U8_NORM")
+	BC4_R_S8_NORM  = NewBC4_R_S8_NORM("BC4_R_S8_NORM")
+	BC5_RG_U8_NORM = NewBC5_RG_U8_NORM("BC5_RG_U8_NORM")
+	BC5_RG_S8_NORM = NewBC5_RG_S8_NORM("BC5_RG_S8_NORM")
+)
+
+// NewBC4_R_U8_NORM returns a format representing the COMPRESSED_RED_RGTC1
+// block texture compression format.
+func NewBC4_R_U8_NORM(name string) *Format {
+	return &Format{name, &Format_Bc4RU8Norm{&FmtBC4_R_U8_NORM{}}}
+}
+
+func (f *FmtBC4_R_U8_NORM) key() interface{} {
+	return *f
+}
+func (*FmtBC4_R_U8_NORM) size(w, h, d int) int {
+	return d * (sint.Max(sint.AlignUp(w, 4), 4) * sint.Max(sint.AlignUp(h, 4), 4)) / 2
+}
+func (f *FmtBC4_R_U8_NORM) check(data []byte, w, h, d int) error {
+	return checkSize(data, f, w, h, d)
+}
+func (*FmtBC4_R_U8_NORM) channels() []stream.Channel {
+	return []stream.Channel{stream.Channel_Red}
+}
+
+// NewBC4_R_S8_NORM returns a format representing the
+// COMPRESSED_SIGNED_RED_RGTC1 block texture compression format.
+func NewBC4_R_S8_NORM(name string) *Format {
+	return &Format{name, &Format_Bc4RS8Norm{&FmtBC4_R_S8_NORM{}}}
+}
+
+func (f *FmtBC4_R_S8_NORM) key() interface{} {
+	return *f
+}
+func (*FmtBC4_R_S8_NORM) size(w, h, d int) int {
+	return d * (sint.Max(sint.AlignUp(w, 4), 4) * sint.Max(sint.AlignUp(h, 4), 4)) / 2
+}
+func (f *FmtBC4_R_S8_NORM) check(data []byte, w, h, d int) error {
+	return checkSize(data, f, w, h, d)
+}
+func (*FmtBC4_R_S8_NORM) channels() []stream.Channel {
+	return []stream.Channel{stream.Channel_Red}
+}
+
+// NewBC5_RG_U8_NORM returns a format representing the COMPRESSED_RG_RGTC2
+// block texture compression format.
+func NewBC5_RG_U8_NORM(name string) *Format {
+	return &Format{name, &Format_Bc5RgU8Norm{&FmtBC5_RG_U8_NORM{}}}
+}
+
+func (f *FmtBC5_RG_U8_NORM) key() interface{} {
+	return *f
+}
+func (*FmtBC5_RG_U8_NORM) size(w, h, d int) int {
+	return d * (sint.Max(sint.AlignUp(w, 4), 4) * sint.Max(sint.AlignUp(h, 4), 4))
+}
+func (f *FmtBC5_RG_U8_NORM) check(data []byte, w, h, d int) error {
+	return checkSize(data, f, w, h, d)
+}
+func (*FmtBC5_RG_U8_NORM) channels() []stream.Channel {
+	return []stream.Channel{stream.Channel_Red, stream.Channel_Green}
+}
+
+// NewBC5_RG_S8_NORM returns a format representing the
+// COMPRESSED_SIGNED_RG_RGTC2 block texture compression format.
+func NewBC5_RG_S8_NORM(name string) *Format {
+	return &Format{name, &Format_Bc5RgS8Norm{&FmtBC5_RG_S8_NORM{}}}
+}
+
+func (f *FmtBC5_RG_S8_NORM) key() interface{} {
+	return *f
+}
+func (*FmtBC5_RG_S8_NORM) size(w, h, d int) int {
+	return d * (sint.Max(sint.AlignUp(w, 4), 4) * sint.Max(sint.AlignUp(h, 4), 4))
+}
+func (f *FmtBC5_RG_S8_NORM) check(data []byte, w, h, d int) error {
+	return checkSize(data, f, w, h, d)
+}
+func (*FmtBC5_RG_S8_NORM) channels() []stream.Channel {
+	return []stream.Channel{stream.Channel_Red, stream.Channel_Green}
+}
+
+func init() {
+	RegisterConverter(BC4_R_U8_NORM, R_U8_NORM, func(src []byte, w, h, d int) ([]byte, error) {
+		return decodeRGTC(src, w, h, d, 1, false)
+	})
+	RegisterConverter(BC4_R_S8_NORM, R_S8_NORM, func(src []byte, w, h, d int) ([]byte, error) {
+		return decodeRGTC(src, w, h, d, 1, true)
+	})
+	RegisterConverter(BC5_RG_U8_NORM, RG_U8_NORM, func(src []byte, w, h, d int) ([]byte, error) {
+		return decodeRGTC(src, w, h, d, 2, false)
+	})
+	RegisterConverter(BC5_RG_S8_NORM, RG_S8_NORM, func(src []byte, w, h, d int) ([]byte, error) {
+		return decodeRGTC(src, w, h, d, 2, true)
+	})
+
+	for _, conv := range []struct {
+		src, dst *Format
+	}{
+		{BC4_R_U8_NORM, R_U8_NORM},
+		{BC4_R_S8_NORM, R_S8_NORM},
+		{BC5_RG_U8_NORM, RG_U8_NORM},
+		{BC5_RG_S8_NORM, RG_S8_NORM},
+	} {
+		conv := conv
+		for _, to := range []*Format{RGB_U8_NORM, RGBA_U8_NORM} {
+			to := to
+			RegisterConverter(conv.src, to, func(src []byte, w, h, d int) ([]byte, error) {
+				data, err := Convert(src, w, h, d, conv.src, conv.dst)
+				if err != nil {
+					return nil, err
+				}
+				return Convert(data, w, h, d, conv.dst, to)
+			})
+		}
+	}
+}
+
+// decodeRGTC decodes the RGTC (BC4 or BC5) image src holding the given number
+// of channels, each stored as a separate 8 byte block.
+func decodeRGTC(src []byte, width, height, depth, channels int, signed bool) ([]byte, error) {
+	dst := make([]byte, width*height*depth*channels)
+	block := [16]int{}
+	r := endian.Reader(bytes.NewReader(src), device.LittleEndian)
+	for z := 0; z < depth; z++ {
+		dst := dst[z*width*height*channels:]
+		for y := 0; y < height; y += 4 {
+			for x := 0; x < width; x += 4 {
+				for c := 0; c < channels; c++ {
+					decodeRGTCBlock(r, &block, signed)
+					for dy := 0; dy < 4 && y+dy < height; dy++ {
+						for dx := 0; dx < 4 && x+dx < width; dx++ {
+							dst[((y+dy)*width+x+dx)*channels+c] = byte(block[dy*4+dx])
+						}
+					}
+				}
+			}
+		}
+	}
+	return dst, r.Error()
+}
+
+// decodeRGTCBlock decodes a single channel 8 byte block of two 8-bit
+// endpoints followed by sixteen 3-bit codes. This is also the encoding of the
+// alpha channel of DXT5.
+func decodeRGTCBlock(r binary.Reader, dst *[16]int, signed bool) {
+	var c0, c1, min, max int
+	if signed {
+		c0, c1, min, max = int(int8(r.Uint8())), int(int8(r.Uint8())), -127, 127
+		// -128 and -127 both represent -1.0.
+		c0, c1 = sint.Max(c0, min), sint.Max(c1, min)
+	} else {
+		c0, c1, min, max = int(r.Uint8()), int(r.Uint8()), 0, 255
+	}
+	codes := uint64(r.Uint16()) | (uint64(r.Uint32()) << 16)
+
+	for i := range dst {
+		c := int(codes & 0x7)
+		switch {
+		case c == 0:
+			dst[i] = c0
+		case c == 1:
+			dst[i] = c1
+		case c0 > c1:
+			dst[i] = (c0*(8-c) + c1*(c-1)) / 7
+		case c <= 5:
+			dst[i] = (c0*(6-c) + c1*(c-1)) / 5
+		case c == 6:
+			dst[i] = min
+		default:
+			dst[i] = max
+		}
+		codes >>= 3
+	}
+}
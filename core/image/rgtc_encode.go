@@ -0,0 +1,121 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import "github.com/google/gapid/core/math/sint"
+
+func init() {
+	RegisterEncoder(RGBA_U8_NORM, BC4_R_U8_NORM, func(src []byte, w, h, d int, q Quality) ([]byte, error) {
+		return encode4x4Blocks(src, w, h, d, 8, func(block *[16]pixel, dst []byte) {
+			encodeRGTCBlock(blockChannel(block, 0), dst, q)
+		}), nil
+	})
+	RegisterEncoder(RGBA_U8_NORM, BC5_RG_U8_NORM, func(src []byte, w, h, d int, q Quality) ([]byte, error) {
+		return encode4x4Blocks(src, w, h, d, 16, func(block *[16]pixel, dst []byte) {
+			encodeRGTCBlock(blockChannel(block, 0), dst[:8], q)
+			encodeRGTCBlock(blockChannel(block, 1), dst[8:], q)
+		}), nil
+	})
+}
+
+// blockChannel returns the values of a single channel of the block, where
+// channel 0 is red and 3 is alpha.
+func blockChannel(block *[16]pixel, channel int) *[16]int {
+	out := [16]int{}
+	for i, p := range block {
+		out[i] = [4]int{p.r, p.g, p.b, p.a}[channel]
+	}
+	return &out
+}
+
+// rgtcPalette returns the eight values selectable by the codes of an unsigned
+// RGTC block with the endpoints c0 and c1, as decoded by decodeRGTCBlock.
+func rgtcPalette(c0, c1 int) [8]int {
+	p := [8]int{c0, c1}
+	for c := 2; c < 8; c++ {
+		switch {
+		case c0 > c1:
+			p[c] = (c0*(8-c) + c1*(c-1)) / 7
+		case c <= 5:
+			p[c] = (c0*(6-c) + c1*(c-1)) / 5
+		case c == 6:
+			p[c] = 0
+		default:
+			p[c] = 255
+		}
+	}
+	return p
+}
+
+// rgtcCodes returns the codes selecting the palette entries closest to each of
+// the values, along with the total squared error.
+func rgtcCodes(values *[16]int, palette [8]int) (codes uint64, err int) {
+	for i := 15; i >= 0; i-- {
+		best, bestErr := 0, maxErr
+		for c, p := range palette {
+			if e := sq(values[i] - p); e < bestErr {
+				best, bestErr = c, e
+			}
+		}
+		codes = codes<<3 | uint64(best)
+		err += bestErr
+	}
+	return codes, err
+}
+
+// encodeRGTCBlock encodes the 16 values into the 8 byte block dst, in the
+// format read by decodeRGTCBlock. This is also the encoding of the alpha
+// channel of DXT5.
+func encodeRGTCBlock(values *[16]int, dst []byte, q Quality) {
+	min, max := 255, 0
+	// The 6 value mode has explicit codes for 0 and 255, so only the remaining
+	// values need to be spanned by the endpoints.
+	innerMin, innerMax := 255, 0
+	for _, v := range values {
+		min, max = sint.Min(min, v), sint.Max(max, v)
+		if v != 0 && v != 255 {
+			innerMin, innerMax = sint.Min(innerMin, v), sint.Max(innerMax, v)
+		}
+	}
+
+	ends := []int{max, min}
+	codes, err := rgtcCodes(values, rgtcPalette(max, min))
+	try := func(c0, c1 int) {
+		if c, e := rgtcCodes(values, rgtcPalette(c0, c1)); e < err {
+			ends[0], ends[1], codes, err = c0, c1, c, e
+		}
+	}
+	if q >= NormalQuality && err > 0 && innerMin <= innerMax {
+		try(innerMin, innerMax)
+	}
+	if q >= BestQuality && err > 0 {
+		// Search around the best endpoints, preserving their order as that
+		// selects between the 8 and 6 value modes.
+		eightValues := ends[0] > ends[1]
+		err = refine(ends, []int{255, 255}, err, func() int {
+			if (ends[0] > ends[1]) != eightValues {
+				return maxErr
+			}
+			_, e := rgtcCodes(values, rgtcPalette(ends[0], ends[1]))
+			return e
+		})
+		codes, _ = rgtcCodes(values, rgtcPalette(ends[0], ends[1]))
+	}
+
+	dst[0], dst[1] = byte(ends[0]), byte(ends[1])
+	for i := 0; i < 6; i++ {
+		dst[2+i] = byte(codes >> uint(i*8))
+	}
+}
@@ -159,38 +159,10 @@ func decodeAlphaDXT3(r binary.Reader, dst []pixel) {
 }
 
 func decodeAlphaDXT5(r binary.Reader, dst []pixel) {
-	a0, a1, codes := int(r.Uint8()), int(r.Uint8()), uint64(r.Uint16())|(uint64(r.Uint32())<<16)
-
-	if a0 > a1 {
-		for i := 0; i < 16; i++ {
-			c := int(codes & 0x7)
-			switch c {
-			case 0:
-				dst[i].a = a0
-			case 1:
-				dst[i].a = a1
-			default:
-				dst[i].a = (a0*(8-c) + a1*(c-1)) / 7
-			}
-			codes >>= 3
-		}
-	} else {
-		for i := 0; i < 16; i++ {
-			c := int(codes & 0x7)
-			switch {
-			case c == 0:
-				dst[i].a = a0
-			case c == 1:
-				dst[i].a = a1
-			case c >= 2 && c <= 5:
-				dst[i].a = (a0*(6-c) + a1*(c-1)) / 5
-			case c == 6:
-				dst[i].a = 0
-			case c == 7:
-				dst[i].a = 255
-			}
-			codes >>= 3
-		}
+	alpha := [16]int{}
+	decodeRGTCBlock(r, &alpha, false)
+	for i := range alpha {
+		dst[i].a = alpha[i]
 	}
 }
 
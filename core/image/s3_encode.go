@@ -0,0 +1,315 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"math"
+
+	"github.com/google/gapid/core/math/sint"
+)
+
+func init() {
+	RegisterEncoder(RGBA_U8_NORM, S3_DXT1_RGB, func(src []byte, w, h, d int, q Quality) ([]byte, error) {
+		return encode4x4Blocks(src, w, h, d, 8, func(block *[16]pixel, dst []byte) {
+			encodeColorDXT(block, dst, dxtOpaque, q)
+		}), nil
+	})
+	RegisterEncoder(RGBA_U8_NORM, S3_DXT1_RGBA, func(src []byte, w, h, d int, q Quality) ([]byte, error) {
+		return encode4x4Blocks(src, w, h, d, 8, func(block *[16]pixel, dst []byte) {
+			encodeColorDXT(block, dst, dxtPunchThrough, q)
+		}), nil
+	})
+	RegisterEncoder(RGBA_U8_NORM, S3_DXT3_RGBA, func(src []byte, w, h, d int, q Quality) ([]byte, error) {
+		return encode4x4Blocks(src, w, h, d, 16, func(block *[16]pixel, dst []byte) {
+			encodeAlphaDXT3(block, dst[:8])
+			encodeColorDXT(block, dst[8:], dxtFourColor, q)
+		}), nil
+	})
+	RegisterEncoder(RGBA_U8_NORM, S3_DXT5_RGBA, func(src []byte, w, h, d int, q Quality) ([]byte, error) {
+		return encode4x4Blocks(src, w, h, d, 16, func(block *[16]pixel, dst []byte) {
+			encodeRGTCBlock(blockChannel(block, 3), dst[:8], q)
+			encodeColorDXT(block, dst[8:], dxtFourColor, q)
+		}), nil
+	})
+}
+
+type dxtMode int
+
+const (
+	// dxtOpaque is DXT1 without alpha, where the third color mode decodes
+	// code 3 as black.
+	dxtOpaque = dxtMode(iota)
+	// dxtPunchThrough is DXT1 with alpha, where the third color mode decodes
+	// code 3 as transparent black.
+	dxtPunchThrough
+	// dxtFourColor is the color block of DXT3 and DXT5, which is always
+	// decoded with four colors.
+	dxtFourColor
+)
+
+// dxtPunchThroughThreshold is the alpha value below which pixels are encoded
+// as transparent by dxtPunchThrough.
+const dxtPunchThroughThreshold = 128
+
+// dxtColors returns the four colors selectable by the codes of a DXT color
+// block with the 565 endpoints c0 and c1, as decoded by decodeDXT1 and
+// decodeColorDXT3_5.
+func dxtColors(c0, c1 int, mode dxtMode) [4]pixel {
+	p := [4]pixel{expand565(c0), expand565(c1)}
+	if c0 > c1 || mode == dxtFourColor {
+		p[2].setToMix3(p[0], p[1])
+		p[3].setToMix3(p[1], p[0])
+	} else {
+		p[2].setToAverage(p[0], p[1])
+	}
+	return p
+}
+
+// dxtCodes returns the codes selecting the colors closest to each pixel of the
+// block, along with the total squared error. If transparent is true then
+// transparent pixels are given code 3. If reserved is true then code 3 is not
+// used for any other pixel.
+func dxtCodes(block *[16]pixel, colors [4]pixel, reserved, transparent bool) (codes uint32, err int) {
+	n := 4
+	if reserved {
+		n = 3
+	}
+	for i := 15; i >= 0; i-- {
+		p := block[i]
+		best, bestErr := 3, 0
+		if !transparent || p.a >= dxtPunchThroughThreshold {
+			best, bestErr = 0, maxErr
+			for c, col := range colors[:n] {
+				if e := sq(p.r-col.r) + sq(p.g-col.g) + sq(p.b-col.b); e < bestErr {
+					best, bestErr = c, e
+				}
+			}
+		}
+		codes = codes<<2 | uint32(best)
+		err += bestErr
+	}
+	return codes, err
+}
+
+// pack565 returns the 565 encoding of the color, which is clamped to [0, 255].
+func pack565(r, g, b float64) int {
+	q := func(v float64, max int) int {
+		return sint.Clamp(int(v*float64(max)/255+0.5), 0, max)
+	}
+	return q(r, 31)<<11 | q(g, 63)<<5 | q(b, 31)
+}
+
+// dxtPrincipalAxis returns the endpoints of the line through the colors of the
+// pixels along their axis of greatest variance.
+func dxtPrincipalAxis(pixels []pixel) (e0, e1 [3]float64) {
+	mean, min, max := [3]float64{}, [3]float64{255, 255, 255}, [3]float64{}
+	for _, p := range pixels {
+		for c, v := range [3]float64{float64(p.r), float64(p.g), float64(p.b)} {
+			mean[c] += v
+			min[c], max[c] = math.Min(min[c], v), math.Max(max[c], v)
+		}
+	}
+	for c := range mean {
+		mean[c] /= float64(len(pixels))
+	}
+
+	cov := [3][3]float64{}
+	for _, p := range pixels {
+		d := [3]float64{float64(p.r) - mean[0], float64(p.g) - mean[1], float64(p.b) - mean[2]}
+		for i := range d {
+			for j := range d {
+				cov[i][j] += d[i] * d[j]
+			}
+		}
+	}
+
+	// Power iteration, starting with the diagonal of the bounding box.
+	axis := [3]float64{max[0] - min[0], max[1] - min[1], max[2] - min[2]}
+	for iter := 0; iter < 8; iter++ {
+		next, length := [3]float64{}, 0.0
+		for i := range next {
+			next[i] = cov[i][0]*axis[0] + cov[i][1]*axis[1] + cov[i][2]*axis[2]
+			length = math.Max(length, math.Abs(next[i]))
+		}
+		if length == 0 {
+			break
+		}
+		for i := range next {
+			axis[i] = next[i] / length
+		}
+	}
+	length := math.Sqrt(axis[0]*axis[0] + axis[1]*axis[1] + axis[2]*axis[2])
+	if length == 0 {
+		return mean, mean
+	}
+
+	tMin, tMax := math.Inf(1), math.Inf(-1)
+	for _, p := range pixels {
+		t := ((float64(p.r)-mean[0])*axis[0] +
+			(float64(p.g)-mean[1])*axis[1] +
+			(float64(p.b)-mean[2])*axis[2]) / length
+		tMin, tMax = math.Min(tMin, t), math.Max(tMax, t)
+	}
+	for c := range mean {
+		e0[c] = mean[c] + axis[c]/length*tMax
+		e1[c] = mean[c] + axis[c]/length*tMin
+	}
+	return e0, e1
+}
+
+// dxtLeastSquares returns the endpoints that minimize the squared error of the
+// block for the given codes, or false if the codes do not constrain both
+// endpoints.
+func dxtLeastSquares(block *[16]pixel, codes uint32, threeColor bool) (e0, e1 [3]float64, ok bool) {
+	weights := [4]float64{1, 0, 2.0 / 3, 1.0 / 3}
+	if threeColor {
+		weights[2] = 0.5
+	}
+	aa, bb, ab := 0.0, 0.0, 0.0
+	ax, bx := [3]float64{}, [3]float64{}
+	for i, p := range block {
+		code := (codes >> uint(i*2)) & 3
+		if threeColor && code == 3 {
+			continue // Black or transparent.
+		}
+		a := weights[code]
+		b := 1 - a
+		aa, bb, ab = aa+a*a, bb+b*b, ab+a*b
+		for c, v := range [3]float64{float64(p.r), float64(p.g), float64(p.b)} {
+			ax[c] += a * v
+			bx[c] += b * v
+		}
+	}
+	det := aa*bb - ab*ab
+	if math.Abs(det) < 1e-6 {
+		return e0, e1, false
+	}
+	for c := range e0 {
+		e0[c] = (ax[c]*bb - bx[c]*ab) / det
+		e1[c] = (bx[c]*aa - ax[c]*ab) / det
+	}
+	return e0, e1, true
+}
+
+// encodeColorDXT encodes the colors of the block into the 8 byte color block
+// dst.
+func encodeColorDXT(block *[16]pixel, dst []byte, mode dxtMode, q Quality) {
+	transparent := false
+	opaque := make([]pixel, 0, 16)
+	for _, p := range block {
+		if mode == dxtPunchThrough && p.a < dxtPunchThroughThreshold {
+			transparent = true
+		} else {
+			opaque = append(opaque, p)
+		}
+	}
+
+	if len(opaque) == 0 {
+		// Every pixel is transparent.
+		putDXTColor(dst, 0, 0, 0xffffffff)
+		return
+	}
+
+	// Transparency requires the three color mode, which is selected by
+	// c0 <= c1. The four color mode is selected by c0 > c1.
+	encode := func(c0, c1 int, threeColor bool) (int, int, uint32, int) {
+		if (c0 > c1) == threeColor {
+			c0, c1 = c1, c0
+		}
+		// In the three color mode of DXT1 with alpha, code 3 is reserved for
+		// transparent pixels.
+		reserved := mode == dxtPunchThrough && c0 <= c1
+		codes, err := dxtCodes(block, dxtColors(c0, c1, mode), reserved, transparent)
+		return c0, c1, codes, err
+	}
+
+	type candidate struct {
+		c0, c1     int
+		codes      uint32
+		err        int
+		threeColor bool
+	}
+	fit := func(e0, e1 [3]float64, threeColor bool) candidate {
+		c0, c1, codes, err := encode(pack565(e0[0], e0[1], e0[2]), pack565(e1[0], e1[1], e1[2]), threeColor)
+		return candidate{c0, c1, codes, err, threeColor}
+	}
+
+	iterations := 0
+	switch q {
+	case NormalQuality:
+		iterations = 1
+	case BestQuality:
+		iterations = 8
+	}
+
+	modes := []bool{transparent}
+	if q >= BestQuality && mode == dxtOpaque {
+		// The three color mode also provides black.
+		modes = []bool{false, true}
+	}
+	best := candidate{err: maxErr}
+	for _, threeColor := range modes {
+		e0, e1 := dxtPrincipalAxis(opaque)
+		c := fit(e0, e1, threeColor)
+		// Refit the endpoints to the chosen codes while that reduces the error.
+		for i := 0; i < iterations && c.err > 0; i++ {
+			e0, e1, ok := dxtLeastSquares(block, c.codes, threeColor)
+			if !ok {
+				break
+			}
+			next := fit(e0, e1, threeColor)
+			if next.err >= c.err {
+				break
+			}
+			c = next
+		}
+		if c.err < best.err {
+			best = c
+		}
+	}
+
+	if q >= BestQuality && best.err > 0 {
+		unpack := func(c int) []int { return []int{c >> 11, (c >> 5) & 63, c & 31} }
+		ends := append(unpack(best.c0), unpack(best.c1)...)
+		limits := []int{31, 63, 31, 31, 63, 31}
+		pack := func(e []int) int { return e[0]<<11 | e[1]<<5 | e[2] }
+		refine(ends, limits, best.err, func() int {
+			_, _, _, err := encode(pack(ends[:3]), pack(ends[3:]), best.threeColor)
+			return err
+		})
+		c0, c1, codes, err := encode(pack(ends[:3]), pack(ends[3:]), best.threeColor)
+		if err < best.err {
+			best = candidate{c0, c1, codes, err, best.threeColor}
+		}
+	}
+
+	putDXTColor(dst, best.c0, best.c1, best.codes)
+}
+
+func putDXTColor(dst []byte, c0, c1 int, codes uint32) {
+	dst[0], dst[1] = byte(c0), byte(c0>>8)
+	dst[2], dst[3] = byte(c1), byte(c1>>8)
+	dst[4], dst[5], dst[6], dst[7] = byte(codes), byte(codes>>8), byte(codes>>16), byte(codes>>24)
+}
+
+// encodeAlphaDXT3 encodes the alpha of the block into the 8 byte explicit
+// alpha block dst.
+func encodeAlphaDXT3(block *[16]pixel, dst []byte) {
+	for i := range dst {
+		lo := (block[i*2].a*15 + 127) / 255
+		hi := (block[i*2+1].a*15 + 127) / 255
+		dst[i] = byte(lo | hi<<4)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import "fmt"
+
+// checkTexture returns an error if levels does not describe a valid texture.
+// levels holds the images of each mip level, starting with the largest. Each
+// level must hold the same number of layers, and every image must share the
+// same format. If cubemap is true then the number of layers must be a
+// multiple of 6.
+func checkTexture(levels [][]*Data, cubemap bool) error {
+	if len(levels) == 0 || len(levels[0]) == 0 {
+		return fmt.Errorf("No images to encode")
+	}
+	layers, format := len(levels[0]), levels[0][0].Format
+	if cubemap && layers%6 != 0 {
+		return fmt.Errorf("Cubemap has %d faces, which is not a multiple of 6", layers)
+	}
+	for i, level := range levels {
+		if len(level) != layers {
+			return fmt.Errorf("Mip level %d has %d layers, expected %d", i, len(level), layers)
+		}
+		for _, img := range level {
+			if img.Format.Key() != format.Key() {
+				return fmt.Errorf("Mip level %d has format %v, expected %v", i, img.Format, format)
+			}
+			if err := img.Format.Check(img.Bytes, int(img.Width), int(img.Height), int(img.Depth)); err != nil {
+				return fmt.Errorf("Mip level %d is invalid: %v", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// convertTexture returns the images of each mip level of a texture converted
+// to the format f.
+func convertTexture(levels [][]*Data, f *Format) ([][]*Data, error) {
+	out := make([][]*Data, len(levels))
+	for i, level := range levels {
+		out[i] = make([]*Data, len(level))
+		for j, img := range level {
+			converted, err := img.Convert(f)
+			if err != nil {
+				return nil, err
+			}
+			out[i][j] = converted
+		}
+	}
+	return out, nil
+}
+
+// ContactSheet returns a single RGBA_U8_NORM image that tiles every image of
+// a texture for quick viewing. levels holds the images of each mip level,
+// starting with the largest, as for EncodeKTX. Each mip level is drawn on its
+// own row, with the layers of the level (and the depth slices of each layer)
+// side by side. Images are separated by a transparent gap.
+func ContactSheet(levels [][]*Data) (*Data, error) {
+	if err := checkTexture(levels, false); err != nil {
+		return nil, err
+	}
+	const gap = 2
+	base := levels[0][0]
+	cellW, cellH, slices := int(base.Width)+gap, int(base.Height)+gap, int(base.Depth)
+	width, height := len(levels[0])*slices*cellW-gap, len(levels)*cellH-gap
+	out := make([]byte, width*height*4)
+	for i, level := range levels {
+		for j, img := range level {
+			rgba, err := img.Convert(RGBA_U8_NORM)
+			if err != nil {
+				return nil, err
+			}
+			w, h, d := int(img.Width), int(img.Height), int(img.Depth)
+			for z := 0; z < d; z++ {
+				x0, y0 := (j*slices+z)*cellW, i*cellH
+				for y := 0; y < h; y++ {
+					src := rgba.Bytes[(z*h+y)*w*4:][:w*4]
+					copy(out[((y0+y)*width+x0)*4:], src)
+				}
+			}
+		}
+	}
+	return &Data{
+		Bytes:  out,
+		Width:  uint32(width),
+		Height: uint32(height),
+		Depth:  1,
+		Format: RGBA_U8_NORM,
+	}, nil
+}
@@ -0,0 +1,64 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/gapid/core/image"
+)
+
+func TestContactSheet(t *testing.T) {
+	levels := [][]*image.Data{}
+	for size := uint32(4); size >= 1; size /= 2 {
+		layers := []*image.Data{}
+		for layer := 0; layer < 3; layer++ {
+			data := bytes.Repeat([]byte{byte(layer + 1), byte(size), 0, 255}, int(size*size))
+			layers = append(layers, &image.Data{Format: image.RGBA_U8_NORM, Width: size, Height: size, Depth: 1, Bytes: data})
+		}
+		levels = append(levels, layers)
+	}
+
+	sheet, err := image.ContactSheet(levels)
+	if err != nil {
+		t.Fatalf("ContactSheet returned error: %v", err)
+	}
+	// 3 columns and 3 rows of 4x4 cells, separated by 2 pixels.
+	if sheet.Width != 16 || sheet.Height != 16 {
+		t.Fatalf("Unexpected sheet size: %vx%v", sheet.Width, sheet.Height)
+	}
+	pixel := func(x, y int) []byte {
+		i := (y*int(sheet.Width) + x) * 4
+		return sheet.Bytes[i : i+4]
+	}
+	for _, test := range []struct {
+		x, y     int
+		expected []byte
+	}{
+		{0, 0, []byte{1, 4, 0, 255}},
+		{3, 3, []byte{1, 4, 0, 255}},
+		{4, 0, []byte{0, 0, 0, 0}},
+		{12, 3, []byte{3, 4, 0, 255}},
+		{6, 7, []byte{2, 2, 0, 255}},
+		{8, 7, []byte{0, 0, 0, 0}},
+		{12, 12, []byte{3, 1, 0, 255}},
+		{13, 12, []byte{0, 0, 0, 0}},
+	} {
+		if got := pixel(test.x, test.y); !bytes.Equal(got, test.expected) {
+			t.Errorf("Pixel at %d, %d was not as expected. Got: %v, expected: %v", test.x, test.y, got, test.expected)
+		}
+	}
+}
@@ -28,6 +28,10 @@ var (
 	RGBA_U8_NORM  = newUncompressed(fmts.RGBA_U8_NORM)
 	SRGB_U8_NORM  = newUncompressed(fmts.SRGB_U8_NORM)
 	SRGBA_U8_NORM = newUncompressed(fmts.SRGBA_U8_NORM)
+	R_U8_NORM     = newUncompressed(fmts.R_U8_NORM)
+	RG_U8_NORM    = newUncompressed(fmts.RG_U8_NORM)
+	R_S8_NORM     = newUncompressed(fmts.R_S8_NORM)
+	RG_S8_NORM    = newUncompressed(fmts.RG_S8_NORM)
 	R_U16_NORM    = newUncompressed(fmts.R_U16_NORM)
 	RG_U16_NORM   = newUncompressed(fmts.RG_U16_NORM)
 	R_S16_NORM    = newUncompressed(fmts.R_S16_NORM)
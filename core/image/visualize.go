@@ -0,0 +1,125 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"bytes"
+	"math"
+
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/stream"
+	"github.com/google/gapid/core/stream/fmts"
+)
+
+var (
+	dF32 = newUncompressed(fmts.D_F32)
+	sU8  = newUncompressed(&stream.Format{
+		Components: []*stream.Component{{
+			DataType: &stream.U8,
+			Sampling: stream.Linear,
+			Channel:  stream.Channel_Stencil,
+		}},
+	})
+)
+
+// NormalizeDepth returns the depth channel of the image as a grey RGBA_U8_NORM
+// image. The depths are remapped so that the nearest depth is black and the
+// furthest is white, making small differences in depth visible.
+func (b *Data) NormalizeDepth() (*Data, error) {
+	depth, err := b.Convert(dF32)
+	if err != nil {
+		return nil, err
+	}
+	r := endian.Reader(bytes.NewReader(depth.Bytes), device.LittleEndian)
+	values := make([]float32, len(depth.Bytes)/4)
+	min, max := float32(math.Inf(1)), float32(math.Inf(-1))
+	for i := range values {
+		v := r.Float32()
+		values[i] = v
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			continue
+		}
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	scale := float32(0)
+	if max > min {
+		scale = 255 / (max - min)
+	}
+
+	out := make([]byte, 0, len(values)*4)
+	for _, v := range values {
+		grey := byte(0)
+		if f := float64((v - min) * scale); f >= 255 {
+			grey = 255
+		} else if f > 0 {
+			grey = byte(f + 0.5)
+		}
+		out = append(out, grey, grey, grey, 255)
+	}
+	return &Data{Bytes: out, Width: b.Width, Height: b.Height, Depth: b.Depth, Format: RGBA_U8_NORM}, nil
+}
+
+// StencilPalette returns the stencil channel of the image as a RGBA_U8_NORM
+// image, with each stencil value given a distinct color. Zero is black.
+func (b *Data) StencilPalette() (*Data, error) {
+	stencil, err := b.Convert(sU8)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(stencil.Bytes)*4)
+	for _, v := range stencil.Bytes {
+		c := stencilPalette[v]
+		out = append(out, c[0], c[1], c[2], 255)
+	}
+	return &Data{Bytes: out, Width: b.Width, Height: b.Height, Depth: b.Depth, Format: RGBA_U8_NORM}, nil
+}
+
+// stencilPalette holds the RGB color of each stencil value. Consecutive
+// values are spaced by the golden angle around the hue circle so that
+// neighbouring values are easy to tell apart.
+var stencilPalette = func() (out [256][3]byte) {
+	for i := 1; i < len(out); i++ {
+		h := math.Mod(float64(i)*0.618033988749895, 1) * 6
+		s, v := 0.75, 1.0
+		if i%2 == 0 {
+			s, v = 1.0, 0.75
+		}
+		f := h - math.Floor(h)
+		p, q, t := v*(1-s), v*(1-s*f), v*(1-s*(1-f))
+		var r, g, b float64
+		switch int(h) {
+		case 0:
+			r, g, b = v, t, p
+		case 1:
+			r, g, b = q, v, p
+		case 2:
+			r, g, b = p, v, t
+		case 3:
+			r, g, b = p, q, v
+		case 4:
+			r, g, b = t, p, v
+		default:
+			r, g, b = v, p, q
+		}
+		out[i] = [3]byte{byte(r*255 + 0.5), byte(g*255 + 0.5), byte(b*255 + 0.5)}
+	}
+	return out
+}()
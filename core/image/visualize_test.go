@@ -0,0 +1,67 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/gapid/core/image"
+	"github.com/google/gapid/core/stream"
+)
+
+func TestNormalizeDepth(t *testing.T) {
+	// 0x0000, 0x4000 and 0xffff in D_U16_NORM.
+	src := &image.Data{
+		Bytes:  []byte{0x00, 0x00, 0x00, 0x40, 0xff, 0xff},
+		Width:  3,
+		Height: 1,
+		Depth:  1,
+		Format: image.D_U16_NORM,
+	}
+	got, err := src.NormalizeDepth()
+	if err != nil {
+		t.Fatalf("NormalizeDepth returned error: %v", err)
+	}
+	expected := []byte{0, 0, 0, 255, 64, 64, 64, 255, 255, 255, 255, 255}
+	if !bytes.Equal(got.Bytes, expected) {
+		t.Errorf("NormalizeDepth returned unexpected data. Got: %v, expected: %v", got.Bytes, expected)
+	}
+}
+
+func TestStencilPalette(t *testing.T) {
+	s8 := image.NewUncompressed("S8", &stream.Format{
+		Components: []*stream.Component{{
+			DataType: &stream.U8,
+			Sampling: stream.Linear,
+			Channel:  stream.Channel_Stencil,
+		}},
+	})
+	src := &image.Data{Bytes: []byte{0, 1, 1, 2}, Width: 4, Height: 1, Depth: 1, Format: s8}
+	got, err := src.StencilPalette()
+	if err != nil {
+		t.Fatalf("StencilPalette returned error: %v", err)
+	}
+	pixel := func(i int) []byte { return got.Bytes[i*4 : i*4+4] }
+	if !bytes.Equal(pixel(0), []byte{0, 0, 0, 255}) {
+		t.Errorf("Stencil value 0 was not black. Got: %v", pixel(0))
+	}
+	if !bytes.Equal(pixel(1), pixel(2)) {
+		t.Errorf("Equal stencil values have different colors: %v, %v", pixel(1), pixel(2))
+	}
+	if bytes.Equal(pixel(1), pixel(3)) || bytes.Equal(pixel(1), pixel(0)) {
+		t.Errorf("Different stencil values have the same color: %v", got.Bytes)
+	}
+}
@@ -19,6 +19,7 @@
 
 set(files
     assignable.go
+    breakpoint.go
     errors.go
     failure.go
     jdbg.go
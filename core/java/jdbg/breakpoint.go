@@ -0,0 +1,134 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jdbg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/core/java/jdwp"
+)
+
+// MethodEntry describes a breakpoint on the entry of a method.
+type MethodEntry struct {
+	// Class is the name of the class or interface that declares the method.
+	// For example: "android.opengl.GLSurfaceView$Renderer"
+	Class string
+	// Method is the name of the method.
+	// For example: "onDrawFrame"
+	Method string
+	// Signature is the optional signature of the method. If empty, then all
+	// overloads of the method are matched.
+	// For example: "(Ljavax/microedition/khronos/opengles/GL10;)V"
+	Signature string
+	// Condition is an optional predicate called each time the breakpoint is
+	// hit. If Condition returns false then the hit is ignored.
+	Condition func(j *JDbg) bool
+}
+
+func (e MethodEntry) String() string {
+	return fmt.Sprintf("%v.%v%v", e.Class, e.Method, e.Signature)
+}
+
+// WatchMethodEntry sets breakpoints on the entry of the method described by e,
+// for the declaring class and all the loaded classes that derive from it, and
+// then calls onHit each time a breakpoint is hit and its condition holds.
+// Condition and onHit are called with a JDbg bound to the thread that hit the
+// breakpoint, and all the other threads suspended.
+// Classes that are loaded after WatchMethodEntry is called are not watched.
+// WatchMethodEntry blocks until ctx is cancelled, or onHit returns an error.
+func WatchMethodEntry(ctx context.Context, conn *jdwp.Connection, e MethodEntry, onHit func(j *JDbg) error) error {
+	var locations []jdwp.Location
+	err := Do(conn, 0, func(j *JDbg) error {
+		locations = j.methodEntries(e)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(locations) == 0 {
+		return fmt.Errorf("No implementations of %v found", e)
+	}
+
+	requests := map[jdwp.EventRequestID]bool{}
+	defer func() {
+		for id := range requests {
+			conn.ClearEvent(jdwp.Breakpoint, id)
+		}
+	}()
+
+	setBreakpoints := func() error {
+		for _, l := range locations {
+			id, err := conn.SetEvent(jdwp.Breakpoint, jdwp.SuspendAll, jdwp.LocationOnlyEventModifier(l))
+			if err != nil {
+				return err
+			}
+			requests[id] = true
+		}
+		return nil
+	}
+
+	var hitErr error
+	err = conn.WatchEventsFrom(ctx, setBreakpoints, func(event jdwp.Event, _ jdwp.SuspendPolicy) bool {
+		hit, ok := event.(*jdwp.EventBreakpoint)
+		if !ok || !requests[hit.Request] {
+			return true
+		}
+		hitErr = Do(conn, hit.Thread, func(j *JDbg) error {
+			if e.Condition != nil && !e.Condition(j) {
+				return nil
+			}
+			return onHit(j)
+		})
+		if err := conn.ResumeAll(); hitErr == nil {
+			hitErr = err
+		}
+		return hitErr == nil
+	})
+	if err != nil {
+		return err
+	}
+	return hitErr
+}
+
+// methodEntries returns the locations of the entry of the method described by
+// e for all the loaded classes that are, or derive from e.Class.
+func (j *JDbg) methodEntries(e MethodEntry) []jdwp.Location {
+	base := j.Class(e.Class)
+	out := []jdwp.Location{}
+	for _, class := range j.AllClasses() {
+		if !class.CastableTo(base) {
+			continue
+		}
+		methods, err := j.conn.GetMethods(class.class.TypeID)
+		if err != nil {
+			j.fail("GetMethods() returned: %v", err)
+		}
+		for _, m := range methods {
+			if m.Name != e.Method || (e.Signature != "" && m.Signature != e.Signature) {
+				continue
+			}
+			if m.ModBits.Abstract() || m.ModBits.Native() {
+				continue // No code to break on.
+			}
+			out = append(out, jdwp.Location{
+				Type:   class.class.Kind,
+				Class:  class.class.ClassID(),
+				Method: m.ID,
+			})
+		}
+	}
+	return out
+}
@@ -206,6 +206,15 @@ func (j *JDbg) classFromSig(sig string) (*Class, error) {
 	if err != nil {
 		return nil, err
 	}
+	return j.classFromInfo(class)
+}
+
+// classFromInfo returns the class type for the loaded class.
+func (j *JDbg) classFromInfo(class jdwp.ClassInfo) (*Class, error) {
+	sig := class.Signature
+	if cached, ok := j.cache.classes[sig]; ok && cached.class.TypeID == class.TypeID {
+		return cached, nil
+	}
 
 	superid, err := j.conn.GetSuperClass(class.ClassID())
 	if err != nil {
@@ -233,11 +242,38 @@ func (j *JDbg) classFromSig(sig string) (*Class, error) {
 	name := strings.Replace(strings.TrimRight(strings.TrimLeft(sig, "[L"), ";"), "/", ".", -1)
 
 	ty := &Class{j: j, signature: sig, name: name, class: class, super: super, implements: implements, fields: fields}
+	if _, ok := j.cache.classes[sig]; ok {
+		// Another class loader has loaded a class with the same signature.
+		// Don't replace the class resolved by signature.
+		return ty, nil
+	}
 	j.cache.classes[sig] = ty
 	j.cache.idToSig[class.TypeID] = sig
 	return ty, nil
 }
 
+// AllClasses returns all the loaded and prepared classes and interfaces.
+// Note that this resolves the type hierarchy of every loaded class, which can
+// be slow for large applications.
+func (j *JDbg) AllClasses() []*Class {
+	infos, err := j.conn.GetAllClasses()
+	if err != nil {
+		j.fail("GetAllClasses() returned: %v", err)
+	}
+	out := make([]*Class, 0, len(infos))
+	for _, info := range infos {
+		if info.Kind == jdwp.Array || info.Status&jdwp.StatusPrepared == 0 {
+			continue
+		}
+		class, err := j.classFromInfo(info)
+		if err != nil {
+			j.fail("Failed to resolve class '%v': %v", info.Signature, err)
+		}
+		out = append(out, class)
+	}
+	return out
+}
+
 func (j *JDbg) typeFromID(id jdwp.ReferenceTypeID) Type {
 	sig, ok := j.cache.idToSig[id]
 	if !ok {
@@ -351,3 +387,39 @@ func (j *JDbg) value(o interface{}) Value {
 		return Value{}
 	}
 }
+
+// fieldValue returns the value v of the field f.
+func (j *JDbg) fieldValue(f *jdwp.Field, v jdwp.Value) Value {
+	if obj, ok := v.(jdwp.Object); ok {
+		if obj.ID() == 0 {
+			return Value{j.cache.objTy, obj} // null pointer
+		}
+		return j.object(obj)
+	}
+	offset := 0
+	ty, err := j.parseSignature(f.Signature, &offset)
+	if err != nil {
+		j.fail("Failed to parse signature of field '%v': %v", f.Name, err)
+	}
+	return newValue(ty, v)
+}
+
+// taggedObject returns the object identifier of o, typed by o's tag.
+func taggedObject(o jdwp.TaggedObjectID) jdwp.Object {
+	switch o.Type {
+	case jdwp.TagArray:
+		return jdwp.ArrayID(o.Object)
+	case jdwp.TagString:
+		return jdwp.StringID(o.Object)
+	case jdwp.TagThread:
+		return jdwp.ThreadID(o.Object)
+	case jdwp.TagThreadGroup:
+		return jdwp.ThreadGroupID(o.Object)
+	case jdwp.TagClassLoader:
+		return jdwp.ClassLoaderID(o.Object)
+	case jdwp.TagClassObject:
+		return jdwp.ClassObjectID(o.Object)
+	default:
+		return o.Object
+	}
+}
@@ -18,8 +18,10 @@ import (
 	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/event/task"
 	"github.com/google/gapid/core/java/jdbg"
 	"github.com/google/gapid/core/java/jdwp"
 	"github.com/google/gapid/core/java/jdwp/test"
@@ -47,6 +49,44 @@ public class Calculator {
 	public void Add(int a) { value += a; }
 
 	public int Result() { return value; }
+
+	public static void startTicking() {
+		Thread thread = new Thread(new Ticker(new Counter()));
+		thread.setDaemon(true);
+		thread.start();
+	}
+}
+`,
+	"Listener.java": `
+public interface Listener {
+	void onTick();
+}
+`,
+	"Counter.java": `
+public class Counter implements Listener {
+	public int count = 0;
+	public String name = "counter";
+	public Counter next = null;
+
+	public void onTick() { count++; }
+}
+`,
+	"Ticker.java": `
+public class Ticker implements Runnable {
+	private final Listener listener;
+
+	public Ticker(Listener listener) { this.listener = listener; }
+
+	public void run() {
+		while (true) {
+			listener.onTick();
+			try {
+				Thread.sleep(10);
+			} catch (InterruptedException e) {
+				return;
+			}
+		}
+	}
 }
 `,
 }
@@ -67,6 +107,15 @@ func TestMain(m *testing.M) {
 			return -1
 		}
 
+		// Start a thread calling Listener.onTick for the breakpoint tests.
+		if err := jdbg.Do(c, t, func(j *jdbg.JDbg) error {
+			j.Class("Calculator").Call("startTicking")
+			return nil
+		}); err != nil {
+			log.F(ctx, "Failed to start ticking. Error: %v", err)
+			return -1
+		}
+
 		conn, thread = c, t
 		return m.Run()
 	}))
@@ -113,3 +162,81 @@ func TestInvokeMethod(t *testing.T) {
 	assert.For(ctx, "err").That(err).Equals(nil)
 
 }
+
+func TestAllClasses(t *testing.T) {
+	ctx := assert.Context(t)
+	err := jdbg.Do(conn, thread, func(j *jdbg.JDbg) error {
+		found := false
+		for _, class := range j.AllClasses() {
+			if class.String() == "Calculator" {
+				found = true
+			}
+		}
+		assert.For(ctx, "found").That(found).Equals(true)
+		return nil
+	})
+	assert.For(ctx, "err").That(err).Equals(nil)
+}
+
+func TestInstances(t *testing.T) {
+	ctx := assert.Context(t)
+	err := jdbg.Do(conn, thread, func(j *jdbg.JDbg) error {
+		calcTy := j.Class("Calculator")
+		calc := calcTy.New()
+		calc.Call("Add", 42)
+
+		assert.For(ctx, "count").That(calcTy.InstanceCount() > 0).Equals(true)
+		found := false
+		for _, instance := range calcTy.Instances(0) {
+			if instance.Fields()["value"].Get() == 42 {
+				found = true
+			}
+		}
+		assert.For(ctx, "found").That(found).Equals(true)
+		return nil
+	})
+	assert.For(ctx, "err").That(err).Equals(nil)
+}
+
+func TestFields(t *testing.T) {
+	ctx := assert.Context(t)
+	err := jdbg.Do(conn, thread, func(j *jdbg.JDbg) error {
+		fields := j.Class("Counter").New().Fields()
+		assert.For(ctx, "fields").That(len(fields)).Equals(3)
+		assert.For(ctx, "count").That(fields["count"].Get()).Equals(0)
+		assert.For(ctx, "name").That(fields["name"].Get()).Equals("counter")
+		assert.For(ctx, "next").That(fields["next"].IsNull()).Equals(true)
+		return nil
+	})
+	assert.For(ctx, "err").That(err).Equals(nil)
+}
+
+func TestWatchMethodEntry(t *testing.T) {
+	ctx := assert.Context(t)
+	c, cancel := task.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	hits := []int{}
+	bp := jdbg.MethodEntry{
+		Class:  "Listener",
+		Method: "onTick",
+		Condition: func(j *jdbg.JDbg) bool {
+			return j.This().Field("count").Get().(int) >= 3
+		},
+	}
+	err := jdbg.WatchMethodEntry(c, conn, bp, func(j *jdbg.JDbg) error {
+		if task.Stopped(c) {
+			return nil
+		}
+		hits = append(hits, j.This().Field("count").Get().(int))
+		if len(hits) == 2 {
+			cancel()
+		}
+		return nil
+	})
+	assert.For(ctx, "err").That(err).Equals(nil)
+	if assert.For(ctx, "hits").ThatSlice(hits).IsLength(2) {
+		assert.For(ctx, "condition").That(hits[0] >= 3).Equals(true)
+		assert.For(ctx, "sequential").That(hits[1]).Equals(hits[0] + 1)
+	}
+}
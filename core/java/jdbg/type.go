@@ -212,11 +212,47 @@ func (t *Class) Call(method string, args ...interface{}) Value {
 // Field returns the value of the static field with the given name.
 func (t *Class) Field(name string) Value {
 	field := t.resolve().fields.FindByName(name)
+	if field == nil {
+		t.j.fail("Class '%v' does not contain field '%v'", t.name, name)
+	}
 	values, err := t.j.conn.GetStaticFieldValues(t.class.TypeID, field.ID)
 	if err != nil {
 		t.j.fail("GetValues() returned: %v", err)
 	}
-	return t.j.value(values[0])
+	return t.j.fieldValue(field, values[0])
+}
+
+// IsInterface returns true if the type is an interface.
+func (t *Class) IsInterface() bool {
+	return t.class.Kind == jdwp.Interface
+}
+
+// InstanceCount returns the number of reachable instances of the class.
+// Instances of classes derived from the class are not counted.
+func (t *Class) InstanceCount() int {
+	counts, err := t.j.conn.GetInstanceCounts(t.class.TypeID)
+	if err != nil {
+		t.j.fail("GetInstanceCounts() returned: %v", err)
+	}
+	if len(counts) != 1 {
+		t.j.fail("GetInstanceCounts() returned %d counts, expected 1", len(counts))
+	}
+	return int(counts[0])
+}
+
+// Instances returns up to max reachable instances of the class.
+// If max is 0 then all the reachable instances are returned.
+// Instances of classes derived from the class are not returned.
+func (t *Class) Instances(max int) []Value {
+	instances, err := t.j.conn.GetInstances(t.class.TypeID, max)
+	if err != nil {
+		t.j.fail("GetInstances() returned: %v", err)
+	}
+	out := make([]Value, len(instances))
+	for i, o := range instances {
+		out[i] = newValue(t, taggedObject(o))
+	}
+	return out
 }
 
 // Super returns the super type.
@@ -283,7 +319,10 @@ func (t *Class) field(object Value, name string) Value {
 	if object == nilValue {
 		t.j.fail("Cannot get field '%v' on nill object", name)
 	}
-	f := t.fields.FindByName(name)
+	var f *jdwp.Field
+	for c := t; c != nil && f == nil; c = c.super {
+		f = c.fields.FindByName(name)
+	}
 	if f == nil {
 		t.j.fail("Class '%v' does not contain field '%v'", t.name, name)
 	}
@@ -296,9 +335,41 @@ func (t *Class) field(object Value, name string) Value {
 		t.j.fail("GetFieldValues() returned: %v", err)
 	}
 	if len(vals) != 1 {
-		t.j.fail("GetFieldValues() returned %n values, expected 1", len(vals))
+		t.j.fail("GetFieldValues() returned %d values, expected 1", len(vals))
+	}
+	return t.j.fieldValue(f, vals[0])
+}
+
+func (t *Class) fieldValues(object Value) map[string]Value {
+	obj, ok := object.val.(jdwp.Object)
+	if !ok || obj.ID() == 0 {
+		t.j.fail("Cannot get fields of '%v' value %v", t.name, object.val)
+	}
+	out := map[string]Value{}
+	for c := t; c != nil; c = c.super {
+		fields := make([]jdwp.Field, 0, len(c.fields))
+		ids := make([]jdwp.FieldID, 0, len(c.fields))
+		for _, f := range c.fields {
+			if _, shadowed := out[f.Name]; !f.ModBits.Static() && !shadowed {
+				fields = append(fields, f)
+				ids = append(ids, f.ID)
+			}
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		vals, err := t.j.conn.GetFieldValues(obj.ID(), ids...)
+		if err != nil {
+			t.j.fail("GetFieldValues() returned: %v", err)
+		}
+		if len(vals) != len(ids) {
+			t.j.fail("GetFieldValues() returned %d values, expected %d", len(vals), len(ids))
+		}
+		for i := range fields {
+			out[fields[i].Name] = t.j.fieldValue(&fields[i], vals[i])
+		}
 	}
-	return t.j.value(vals[0])
+	return out
 }
 
 func (t *Class) jdwp() *JDbg { return t.j }
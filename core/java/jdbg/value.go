@@ -49,6 +49,23 @@ func (v Value) Field(name string) Value {
 	return v.ty.field(v, name)
 }
 
+// Fields returns the values of all the instance fields of the object, including
+// those declared by its super classes, keyed by field name. Fields that are
+// hidden by a field of the same name in a derived class are not returned.
+func (v Value) Fields() map[string]Value {
+	class, ok := v.ty.(*Class)
+	if !ok {
+		v.ty.jdwp().fail("Type '%v' does not support fields", v.ty)
+	}
+	return class.fieldValues(v)
+}
+
+// IsNull returns true if the value is a null object.
+func (v Value) IsNull() bool {
+	obj, ok := v.val.(jdwp.Object)
+	return ok && obj.ID() == 0
+}
+
 // Get returns the value, unmarshalled.
 func (v Value) Get() interface{} {
 	return v.ty.jdwp().unmarshal(v.val)
@@ -52,3 +52,14 @@ func (c *Connection) GetImplemented(ty ReferenceTypeID) ([]InterfaceID, error) {
 	err := c.get(cmdSetReferenceType, 10, ty, &res)
 	return res, err
 }
+
+// GetInstances returns up to max reachable instances of the specified type.
+// If max is 0 then all the reachable instances are returned.
+func (c *Connection) GetInstances(ty ReferenceTypeID, max int) ([]TaggedObjectID, error) {
+	var res []TaggedObjectID
+	err := c.get(cmdSetReferenceType, 16, struct {
+		Ty  ReferenceTypeID
+		Max int
+	}{ty, max}, &res)
+	return res, err
+}
@@ -105,3 +105,11 @@ func (c *Connection) CreateString(str string) (StringID, error) {
 	err := c.get(cmdSetVirtualMachine, 11, str, &res)
 	return res, err
 }
+
+// GetInstanceCounts returns the number of reachable instances of each of the
+// specified types.
+func (c *Connection) GetInstanceCounts(types ...ReferenceTypeID) ([]int64, error) {
+	var res []int64
+	err := c.get(cmdSetVirtualMachine, 21, types, &res)
+	return res, err
+}
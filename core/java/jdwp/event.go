@@ -27,17 +27,31 @@ type OnEvent func(Event, SuspendPolicy) bool
 // WatchEvents calls cb each time a new event arrives.
 // WatchEvents will block until cb returns false, or the ctx is cancelled.
 func (c *Connection) WatchEvents(ctx context.Context, cb OnEvent) {
+	c.WatchEventsFrom(ctx, func() error { return nil }, cb)
+}
+
+// WatchEventsFrom calls start and then cb each time a new event arrives.
+// Unlike calling start before WatchEvents, no events raised between start
+// returning and the events being watched are missed.
+// If start returns an error then WatchEventsFrom returns it immediately.
+// Otherwise WatchEventsFrom will block until cb returns false, or the ctx is
+// cancelled.
+func (c *Connection) WatchEventsFrom(ctx context.Context, start func() error, cb OnEvent) error {
 	id, events := c.newEventsHandler()
 	defer c.deleteEventsHandler(id)
 
+	if err := start(); err != nil {
+		return err
+	}
+
 	for {
 		select {
 		case <-task.ShouldStop(ctx):
-			return
+			return nil
 		case list := <-events:
 			for _, event := range list.Events {
 				if !cb(event, list.SuspendPolicy) {
-					return
+					return nil
 				}
 			}
 		}
@@ -28,6 +28,7 @@ set(files
 set(dirs
     adb
     apk
+    apksig
     binaryxml
     manifest
 )
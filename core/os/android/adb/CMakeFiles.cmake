@@ -36,6 +36,10 @@ set(files
     installed_package_test.go
     logcat.go
     logcat_test.go
+    network.go
+    network_test.go
+    perf_counters.go
+    perf_counters_test.go
     screen.go
     screen_test.go
 )
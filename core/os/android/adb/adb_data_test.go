@@ -46,6 +46,7 @@ no_pgrep_no_ps_device    unknown
 no_pgrep_ok_ps_device    offline
 ok_pgrep_no_ps_device    device
 ok_pgrep_ok_ps_device    unauthorized
+perf_device              device
 production_device        unknown
 pull_device              offline
 push_device              device
@@ -189,6 +190,22 @@ untagSocket(48) failed with errno -22
 [1] PackageVerificationReceiver.onReceive: Verification requested, id = 331
 `),
 
+		// Performance counter responses
+		stub.Regex(`adb -s perf_device shell grep -H \. .*`, stub.Respond(`
+/sys/devices/system/cpu/cpu0/cpufreq/scaling_cur_freq:1497600
+/sys/devices/system/cpu/cpu1/cpufreq/scaling_cur_freq:300000
+/sys/class/kgsl/kgsl-3d0/gpuclk:257000000
+/sys/class/kgsl/kgsl-3d0/gpu_busy_percentage:12 %
+/sys/class/thermal/thermal_zone0/type:cpu-0-0-usr
+/sys/class/thermal/thermal_zone1/type:battery
+/sys/class/thermal/thermal_zone0/temp:41300
+/sys/class/thermal/thermal_zone1/temp:27
+/proc/meminfo:MemTotal:        3809036 kB
+/proc/meminfo:MemFree:          113548 kB
+/proc/meminfo:MemAvailable:    1460344 kB
+/proc/meminfo:Buffers:           94828 kB
+time:2017-03-29_15:16:32.205000000`)),
+
 		// Common responses to all devices
 		stub.Regex(`adb -s .* shell getprop ro\.build\.product`, stub.Respond("hammerhead")),
 		stub.Regex(`adb -s .* shell getprop ro\.build\.version\.release`, stub.Respond("6.0.1")),
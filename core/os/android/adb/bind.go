@@ -16,6 +16,7 @@ package adb
 
 import (
 	"context"
+	"sync"
 
 	"github.com/google/gapid/core/os/android"
 	"github.com/google/gapid/core/os/device/bind"
@@ -34,6 +35,16 @@ type Device interface {
 	Forward(ctx context.Context, local, device Port) error
 	// RemoveForward removes a port forward made by Forward.
 	RemoveForward(ctx context.Context, local Port) error
+	// Connect reconnects to a device connected over the network. If the device
+	// is not a network device then Connect will return ErrNotNetworkDevice.
+	Connect(ctx context.Context) error
+	// Disconnect disconnects from a device connected over the network, and
+	// stops it being automatically reconnected. If the device is not a network
+	// device then Disconnect will return ErrNotNetworkDevice.
+	Disconnect(ctx context.Context) error
+	// PerfCounters samples the device's performance counters, such as CPU and
+	// GPU frequencies, temperatures and memory usage.
+	PerfCounters(ctx context.Context) (*PerfCounterSample, error)
 }
 
 // DeviceList is a list of devices.
@@ -53,6 +64,21 @@ func (l DeviceList) FindBySerial(serial string) Device {
 // binding represents an attached Android device.
 type binding struct {
 	bind.Simple
+	statusMutex sync.Mutex // Guards Simple.LastStatus once the device is registered.
+}
+
+// Status returns the last status of the device reported by adb.
+func (b *binding) Status() bind.Status {
+	b.statusMutex.Lock()
+	defer b.statusMutex.Unlock()
+	return b.LastStatus
+}
+
+// setStatus updates the status of the device reported by adb.
+func (b *binding) setStatus(status bind.Status) {
+	b.statusMutex.Lock()
+	defer b.statusMutex.Unlock()
+	b.LastStatus = status
 }
 
 // verify that binding implements Device
@@ -177,17 +177,15 @@ func allZero(bytes []byte) bool {
 
 // scanDevices returns the list of attached Android devices.
 func scanDevices(ctx context.Context) error {
-	exe, err := adb()
-	if err != nil {
-		return log.Err(ctx, err, "")
-	}
-	stdout, err := shell.Command(exe.System(), "devices").Call(ctx)
+	parsed, err := listDevices(ctx)
 	if err != nil {
 		return err
 	}
-	parsed, err := parseDevices(ctx, stdout)
-	if err != nil {
-		return err
+	if reconnectDevices(ctx, parsed) {
+		// List the devices again to pick up the reconnected devices.
+		if parsed, err = listDevices(ctx); err != nil {
+			return err
+		}
 	}
 
 	cacheMutex.Lock()
@@ -203,11 +201,21 @@ func scanDevices(ctx context.Context) error {
 			cache[serial] = device
 			registry.AddDevice(ctx, device)
 		}
+		device.setStatus(status)
+		if status == bind.Status_Online && IsNetworkSerial(serial) {
+			track(serial)
+		}
 	}
 
 	// Remove cached results for removed devices.
 	for serial, device := range cache {
 		if _, found := parsed[serial]; !found {
+			if isTracked(serial) {
+				// Keep lost network devices in the registry while they are
+				// being reconnected, so they keep the same identity.
+				device.setStatus(bind.Status_Offline)
+				continue
+			}
 			delete(cache, serial)
 			registry.RemoveDevice(ctx, device)
 		}
@@ -216,6 +224,19 @@ func scanDevices(ctx context.Context) error {
 	return nil
 }
 
+// listDevices returns the serials and statuses of the devices listed by adb.
+func listDevices(ctx context.Context) (map[string]bind.Status, error) {
+	exe, err := adb()
+	if err != nil {
+		return nil, log.Err(ctx, err, "")
+	}
+	stdout, err := shell.Command(exe.System(), "devices").Call(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parseDevices(ctx, stdout)
+}
+
 func parseDevices(ctx context.Context, out string) (map[string]bind.Status, error) {
 	a := strings.SplitAfter(out, "List of devices attached")
 	if len(a) != 2 {
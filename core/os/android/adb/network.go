@@ -0,0 +1,266 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adb
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/gapid/core/fault"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device/bind"
+	"github.com/google/gapid/core/os/shell"
+)
+
+const (
+	// ErrConnectFailed May be returned if adb could not connect to a network device.
+	ErrConnectFailed = fault.Const("Failed to connect to network device")
+	// ErrNotNetworkDevice May be returned if a network operation is attempted on a device
+	// that is not connected over the network.
+	ErrNotNetworkDevice = fault.Const("Device is not connected over the network")
+	// ErrInvalidAddress May be returned if a network device address is not of the form host:port.
+	ErrInvalidAddress = fault.Const("Invalid network device address")
+
+	// minReconnectDelay is the delay before the second attempt to reconnect a lost network device.
+	minReconnectDelay = time.Second
+	// maxReconnectDelay is the longest delay between attempts to reconnect a lost network device.
+	maxReconnectDelay = time.Minute
+)
+
+var (
+	// network is a map of network device addresses to their reconnection state.
+	// Devices in this map are kept in the registry while they are disconnected,
+	// and adb is asked to reconnect to them until Disconnect is called.
+	network      = map[string]*reconnect{}
+	networkMutex sync.Mutex // Guards network.
+)
+
+// reconnect holds the reconnection backoff state of a network device.
+type reconnect struct {
+	next  time.Time     // The earliest time of the next reconnection attempt.
+	delay time.Duration // The delay to apply after the next failed attempt.
+}
+
+// IsNetworkSerial returns true if serial is the serial of a device connected
+// with adb connect, which takes the form host:port.
+func IsNetworkSerial(serial string) bool {
+	_, port, err := net.SplitHostPort(serial)
+	if err != nil {
+		return false
+	}
+	_, err = strconv.ParseUint(port, 10, 16)
+	return err == nil
+}
+
+// Connect asks adb to connect to the device listening on address, which must
+// be of the form host:port. Once connected, the device is added to the
+// registry and will be automatically reconnected if the connection drops,
+// until Disconnect is called.
+func Connect(ctx context.Context, address string) (Device, error) {
+	ctx = log.V{"address": address}.Bind(ctx)
+	if !IsNetworkSerial(address) {
+		return nil, log.Err(ctx, ErrInvalidAddress, "")
+	}
+	if err := connect(ctx, address); err != nil {
+		return nil, err
+	}
+
+	track(address)
+
+	if err := scanDevices(ctx); err != nil {
+		return nil, err
+	}
+
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+	d, ok := cache[address]
+	if !ok {
+		return nil, log.Err(ctx, ErrConnectFailed, "Device not listed after connecting")
+	}
+	return d, nil
+}
+
+// Disconnect asks adb to disconnect from the network device at address,
+// and once adb has succeeded, removes the device from the registry. The
+// device will not be reconnected.
+func Disconnect(ctx context.Context, address string) error {
+	ctx = log.V{"address": address}.Bind(ctx)
+	if !IsNetworkSerial(address) {
+		return log.Err(ctx, ErrInvalidAddress, "")
+	}
+
+	exe, err := adb()
+	if err != nil {
+		return log.Err(ctx, err, "")
+	}
+
+	// Stop tracking the device before disconnecting, so that it is not seen
+	// offline and reconnected while adb disconnect runs.
+	networkMutex.Lock()
+	state, tracked := network[address]
+	delete(network, address)
+	networkMutex.Unlock()
+
+	if err := shell.Command(exe.System(), "disconnect", address).Run(ctx); err != nil {
+		if tracked {
+			networkMutex.Lock()
+			network[address] = state
+			networkMutex.Unlock()
+		}
+		return err
+	}
+
+	cacheMutex.Lock()
+	d, ok := cache[address]
+	delete(cache, address)
+	cacheMutex.Unlock()
+	if ok {
+		registry.RemoveDevice(ctx, d)
+	}
+	return nil
+}
+
+// Connect reconnects the device if it is a network device.
+func (b *binding) Connect(ctx context.Context) error {
+	if !IsNetworkSerial(b.To.Serial) {
+		return log.Err(ctx, ErrNotNetworkDevice, "")
+	}
+	_, err := Connect(ctx, b.To.Serial)
+	return err
+}
+
+// Disconnect disconnects the device if it is a network device.
+func (b *binding) Disconnect(ctx context.Context) error {
+	if !IsNetworkSerial(b.To.Serial) {
+		return log.Err(ctx, ErrNotNetworkDevice, "")
+	}
+	return Disconnect(ctx, b.To.Serial)
+}
+
+// connect runs adb connect for address, returning an error if adb reports
+// that the connection failed.
+func connect(ctx context.Context, address string) error {
+	exe, err := adb()
+	if err != nil {
+		return log.Err(ctx, err, "")
+	}
+	output, err := shell.Command(exe.System(), "connect", address).Call(ctx)
+	if err != nil {
+		return err
+	}
+	// adb connect exits successfully even if the connection failed, so the
+	// output has to be checked.
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "connected to ") ||
+			strings.HasPrefix(line, "already connected to ") {
+			return nil
+		}
+	}
+	return log.Errf(ctx, ErrConnectFailed, "adb connect gave output: %v", output)
+}
+
+// reconnectDevices attempts to reconnect the tracked network devices that are
+// missing or offline in the parsed device list, backing off exponentially
+// between attempts for each device. It returns true if any device was
+// reconnected.
+// adb connect can take a long time to fail, so it is run without holding
+// networkMutex.
+func reconnectDevices(ctx context.Context, parsed map[string]bind.Status) bool {
+	reconnected := false
+	for _, address := range dueReconnects(parsed, time.Now()) {
+		ctx := log.V{"address": address}.Bind(ctx)
+		if err := connect(ctx, address); err != nil {
+			log.W(ctx, "Reconnection failed. Retrying in %v", backoff(address))
+			continue
+		}
+		log.I(ctx, "Reconnected to network device")
+		resetBackoff(address)
+		reconnected = true
+	}
+	return reconnected
+}
+
+// dueReconnects returns the addresses of the tracked network devices that are
+// missing or offline in the parsed device list, and that are not backing off
+// at time t. The backoff of the devices that are connected is reset.
+func dueReconnects(parsed map[string]bind.Status, t time.Time) []string {
+	networkMutex.Lock()
+	defer networkMutex.Unlock()
+	due := []string{}
+	for address, state := range network {
+		if status, found := parsed[address]; found && status != bind.Status_Offline {
+			// Connected. Reset the backoff for the next time the connection drops.
+			state.next, state.delay = time.Time{}, minReconnectDelay
+			continue
+		}
+		if t.Before(state.next) {
+			continue
+		}
+		due = append(due, address)
+	}
+	return due
+}
+
+// backoff delays the next reconnection attempt of the network device at
+// address after a failed attempt, returning the delay.
+func backoff(address string) time.Duration {
+	networkMutex.Lock()
+	defer networkMutex.Unlock()
+	state, ok := network[address]
+	if !ok {
+		return 0 // Disconnected while reconnecting.
+	}
+	delay := state.delay
+	state.next = time.Now().Add(delay)
+	state.delay *= 2
+	if state.delay > maxReconnectDelay {
+		state.delay = maxReconnectDelay
+	}
+	return delay
+}
+
+// resetBackoff resets the reconnection backoff of the network device at
+// address after a successful attempt.
+func resetBackoff(address string) {
+	networkMutex.Lock()
+	defer networkMutex.Unlock()
+	if state, ok := network[address]; ok {
+		state.next, state.delay = time.Time{}, minReconnectDelay
+	}
+}
+
+// isTracked returns true if the network device at address should be kept in
+// the registry while it is disconnected.
+func isTracked(address string) bool {
+	networkMutex.Lock()
+	defer networkMutex.Unlock()
+	_, ok := network[address]
+	return ok
+}
+
+// track starts tracking the network device at address for reconnection, if
+// it is not already tracked.
+func track(address string) {
+	networkMutex.Lock()
+	defer networkMutex.Unlock()
+	if _, ok := network[address]; !ok {
+		network[address] = &reconnect{delay: minReconnectDelay}
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adb_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/android/adb"
+	"github.com/google/gapid/core/os/device/bind"
+	"github.com/google/gapid/core/os/shell/stub"
+)
+
+const networkDevice = "192.168.0.10:5555"
+
+var (
+	networkDeviceOnline = stub.RespondTo(adbPath.System()+` devices`, `
+List of devices attached
+192.168.0.10:5555        device
+`)
+	networkDeviceOffline = stub.RespondTo(adbPath.System()+` devices`, `
+List of devices attached
+192.168.0.10:5555        offline
+`)
+	networkDeviceMissing = emptyDevices
+	connected            = stub.RespondTo(adbPath.System()+` connect `+networkDevice, `connected to `+networkDevice)
+	alreadyConnected     = stub.RespondTo(adbPath.System()+` connect `+networkDevice, `already connected to `+networkDevice)
+	connectFailed        = stub.RespondTo(adbPath.System()+` connect `+networkDevice, `failed to connect to '`+networkDevice+`': Connection refused`)
+	disconnected         = stub.RespondTo(adbPath.System()+` disconnect `+networkDevice, `disconnected `+networkDevice)
+	disconnectFailed     = stub.Match(adbPath.System()+` disconnect `+networkDevice, &stub.Response{WaitErr: errors.New("adb failed")})
+)
+
+func TestIsNetworkSerial(t_ *testing.T) {
+	ctx := log.Testing(t_)
+	for _, test := range []struct {
+		serial   string
+		expected bool
+	}{
+		{"192.168.0.10:5555", true},
+		{"localhost:5555", true},
+		{"[::1]:5555", true},
+		{"emulator-5554", false},
+		{"production_device", false},
+		{"192.168.0.10", false},
+		{"192.168.0.10:port", false},
+	} {
+		assert.For(ctx, test.serial).That(adb.IsNetworkSerial(test.serial)).Equals(test.expected)
+	}
+}
+
+func TestNetworkDeviceReconnect(t_ *testing.T) {
+	ctx := log.Testing(t_)
+	defer func() { devices.Handlers[0] = validDevices }()
+
+	devices.Handlers[0] = stub.OneOf(connected, networkDeviceOnline)
+	d, err := adb.Connect(ctx, networkDevice)
+	assert.For(ctx, "Connect").ThatError(err).Succeeded()
+	assert.For(ctx, "Connect serial").ThatString(d.Instance().Serial).Equals(networkDevice)
+	assert.For(ctx, "Connect status").That(d.Status()).Equals(bind.Status_Online)
+	id := d.Instance().Id.ID()
+
+	// The connection drops, and the first reconnection attempt fails.
+	devices.Handlers[0] = stub.OneOf(connectFailed, networkDeviceMissing)
+	got, err := adb.Devices(ctx)
+	assert.For(ctx, "Lost devices").ThatError(err).Succeeded()
+	lost := got.FindBySerial(networkDevice)
+	assert.For(ctx, "Lost device").That(lost).Equals(d)
+	assert.For(ctx, "Lost status").That(lost.Status()).Equals(bind.Status_Offline)
+	assert.For(ctx, "Lost identity").That(lost.Instance().Id.ID()).Equals(id)
+
+	// The reconnection is backing off, so adb connect must not be called and
+	// the device list is only fetched once.
+	devices.Handlers[0] = stub.OneOf(connected, &stub.Sequence{networkDeviceMissing, networkDeviceOnline})
+	got, err = adb.Devices(ctx)
+	assert.For(ctx, "Backoff devices").ThatError(err).Succeeded()
+	assert.For(ctx, "Backoff status").That(got.FindBySerial(networkDevice).Status()).Equals(bind.Status_Offline)
+
+	// The device reconnects with the same identity.
+	devices.Handlers[0] = stub.OneOf(alreadyConnected, networkDeviceOnline)
+	err = d.Connect(ctx)
+	assert.For(ctx, "Reconnect").ThatError(err).Succeeded()
+	got, err = adb.Devices(ctx)
+	assert.For(ctx, "Reconnected devices").ThatError(err).Succeeded()
+	back := got.FindBySerial(networkDevice)
+	assert.For(ctx, "Reconnected device").That(back).Equals(d)
+	assert.For(ctx, "Reconnected status").That(back.Status()).Equals(bind.Status_Online)
+	assert.For(ctx, "Reconnected identity").That(back.Instance().Id.ID()).Equals(id)
+
+	// A device that goes offline is reconnected by the next scan.
+	devices.Handlers[0] = stub.OneOf(alreadyConnected, &stub.Sequence{networkDeviceOffline, networkDeviceOnline})
+	got, err = adb.Devices(ctx)
+	assert.For(ctx, "Offline devices").ThatError(err).Succeeded()
+	assert.For(ctx, "Offline status").That(got.FindBySerial(networkDevice).Status()).Equals(bind.Status_Online)
+
+	// A device that adb fails to disconnect is kept.
+	devices.Handlers[0] = stub.OneOf(disconnectFailed, networkDeviceOnline)
+	err = d.Disconnect(ctx)
+	assert.For(ctx, "Disconnect failed").ThatError(err).Failed()
+	got, err = adb.Devices(ctx)
+	assert.For(ctx, "Disconnect failed devices").ThatError(err).Succeeded()
+	assert.For(ctx, "Disconnect failed device").That(got.FindBySerial(networkDevice)).Equals(d)
+
+	// It is still tracked, so it is reconnected when it goes offline.
+	devices.Handlers[0] = stub.OneOf(alreadyConnected, &stub.Sequence{networkDeviceOffline, networkDeviceOnline})
+	got, err = adb.Devices(ctx)
+	assert.For(ctx, "Disconnect failed offline devices").ThatError(err).Succeeded()
+	assert.For(ctx, "Disconnect failed offline status").That(got.FindBySerial(networkDevice).Status()).Equals(bind.Status_Online)
+
+	// Disconnected devices are removed, and not reconnected.
+	devices.Handlers[0] = stub.OneOf(disconnected, networkDeviceMissing)
+	err = d.Disconnect(ctx)
+	assert.For(ctx, "Disconnect").ThatError(err).Succeeded()
+	got, err = adb.Devices(ctx)
+	assert.For(ctx, "Disconnected devices").ThatError(err).Succeeded()
+	assert.For(ctx, "Disconnected device").That(got.FindBySerial(networkDevice)).IsNil()
+}
+
+func TestConnectErrors(t_ *testing.T) {
+	ctx := log.Testing(t_)
+	defer func() { devices.Handlers[0] = validDevices }()
+
+	_, err := adb.Connect(ctx, "production_device")
+	assert.For(ctx, "Invalid address").ThatError(err).HasCause(adb.ErrInvalidAddress)
+
+	devices.Handlers[0] = connectFailed
+	_, err = adb.Connect(ctx, networkDevice)
+	assert.For(ctx, "Connect failed").ThatError(err).HasCause(adb.ErrConnectFailed)
+
+	devices.Handlers[0] = validDevices
+	d := mustConnect(ctx, "production_device")
+	err = d.Disconnect(ctx)
+	assert.For(ctx, "Not network device").ThatError(err).HasCause(adb.ErrNotNetworkDevice)
+}
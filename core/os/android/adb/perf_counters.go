@@ -0,0 +1,176 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adb
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PerfCounterSample is a sample of all the device performance counters.
+type PerfCounterSample struct {
+	// Time is the device time the counters were sampled. Like the timestamps
+	// of logcat messages, it is the device's local time read in the host's
+	// time zone. Time is zero if the device could not report its time.
+	Time time.Time
+	// Counters are the sampled counters.
+	Counters []PerfCounter
+}
+
+// PerfCounter is a single sample of a device performance counter.
+type PerfCounter struct {
+	// Name is the name of the counter, for example "cpu0.frequency".
+	Name string
+	// Unit is the unit of Value, for example "Hz".
+	Unit string
+	// Value is the sampled value of the counter.
+	Value float64
+}
+
+// perfCounterFiles are the files read to sample the performance counters.
+// Files that do not exist on the device are ignored.
+var perfCounterFiles = []string{
+	"/sys/devices/system/cpu/cpu[0-9]*/cpufreq/scaling_cur_freq",
+	"/sys/class/kgsl/kgsl-3d0/gpuclk",
+	"/sys/class/kgsl/kgsl-3d0/gpu_busy_percentage",
+	"/sys/class/misc/mali0/device/clock",
+	"/sys/class/misc/mali0/device/utilization",
+	"/sys/class/thermal/thermal_zone[0-9]*/type",
+	"/sys/class/thermal/thermal_zone[0-9]*/temp",
+	"/proc/meminfo",
+}
+
+// perfCounterTimeArg is the date argument used to print the device time after
+// the counters are read, and perfCounterTimeFormat is the layout it prints.
+const (
+	perfCounterTimeFormat = "2006-01-02_15:04:05.999999999"
+	perfCounterTimeArg    = "+time:%Y-%m-%d_%H:%M:%S.%N"
+)
+
+var (
+	cpuFreqRegex     = regexp.MustCompile(`^/sys/devices/system/cpu/(cpu[0-9]+)/cpufreq/scaling_cur_freq$`)
+	thermalZoneRegex = regexp.MustCompile(`^/sys/class/thermal/thermal_zone([0-9]+)/(type|temp)$`)
+)
+
+// PerfCounters samples the device's CPU frequencies, GPU frequency and
+// utilization, thermal zone temperatures and memory usage.
+// Only the counters exposed by the device are returned.
+func (b *binding) PerfCounters(ctx context.Context) (*PerfCounterSample, error) {
+	args := append([]string{"-H", "."}, perfCounterFiles...)
+	args = append(args, "2>/dev/null", ";", "date", perfCounterTimeArg)
+	out, err := b.Shell("grep", args...).Call(ctx)
+	if err != nil && out == "" {
+		// grep fails if any of the files are missing, so only treat this as an
+		// error if nothing was read.
+		return nil, err
+	}
+	return parsePerfCounters(out), nil
+}
+
+func parsePerfCounters(out string) *PerfCounterSample {
+	sample := &PerfCounterSample{}
+	counters := []PerfCounter{}
+	add := func(name, unit, value string, scale float64) {
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			return
+		}
+		v, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return
+		}
+		counters = append(counters, PerfCounter{Name: name, Unit: unit, Value: v * scale})
+	}
+
+	type thermalZone struct{ name, temp string }
+	zones := []*thermalZone{}
+	zonesByID := map[string]*thermalZone{}
+
+	for _, line := range strings.Split(out, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		file, value := parts[0], strings.TrimSpace(parts[1])
+		if file == "time" {
+			// Devices without nanosecond support in date leave %N unexpanded.
+			value = strings.TrimSuffix(value, ".%N")
+			if t, err := time.ParseInLocation(perfCounterTimeFormat, value, time.Local); err == nil {
+				sample.Time = t
+			}
+			continue
+		}
+		if m := cpuFreqRegex.FindStringSubmatch(file); m != nil {
+			add(m[1]+".frequency", "Hz", value, 1e3) // kHz
+			continue
+		}
+		if m := thermalZoneRegex.FindStringSubmatch(file); m != nil {
+			zone, ok := zonesByID[m[1]]
+			if !ok {
+				zone = &thermalZone{name: "zone" + m[1]}
+				zonesByID[m[1]] = zone
+				zones = append(zones, zone)
+			}
+			if m[2] == "type" {
+				zone.name = value
+			} else {
+				zone.temp = value
+			}
+			continue
+		}
+		switch file {
+		case "/sys/class/kgsl/kgsl-3d0/gpuclk":
+			add("gpu.frequency", "Hz", value, 1)
+		case "/sys/class/misc/mali0/device/clock":
+			add("gpu.frequency", "Hz", value, 1e6) // MHz
+		case "/sys/class/kgsl/kgsl-3d0/gpu_busy_percentage",
+			"/sys/class/misc/mali0/device/utilization":
+			add("gpu.utilization", "%", strings.TrimSuffix(value, "%"), 1)
+		case "/proc/meminfo":
+			field := strings.SplitN(value, ":", 2)
+			if len(field) != 2 {
+				continue
+			}
+			switch field[0] {
+			case "MemTotal":
+				add("memory.total", "B", field[1], 1024) // kB
+			case "MemFree":
+				add("memory.free", "B", field[1], 1024) // kB
+			case "MemAvailable":
+				add("memory.available", "B", field[1], 1024) // kB
+			}
+		}
+	}
+
+	for _, zone := range zones {
+		if zone.temp == "" {
+			continue
+		}
+		count := len(counters)
+		add("thermal."+zone.name, "°C", zone.temp, 1)
+		if len(counters) > count {
+			if t := &counters[count]; math.Abs(t.Value) >= 1000 {
+				// Most devices report millidegrees.
+				t.Value /= 1000
+			}
+		}
+	}
+	sample.Counters = counters
+	return sample
+}
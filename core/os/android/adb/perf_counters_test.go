@@ -0,0 +1,45 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/android/adb"
+)
+
+func TestPerfCounters(t_ *testing.T) {
+	ctx := log.Testing(t_)
+	d := mustConnect(ctx, "perf_device")
+	got, err := d.PerfCounters(ctx)
+	if !assert.For(ctx, "err").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For(ctx, "time").That(got.Time).Equals(time.Date(2017, 3, 29, 15, 16, 32, 205000000, time.Local))
+	assert.For(ctx, "counters").That(got.Counters).DeepEquals([]adb.PerfCounter{
+		{Name: "cpu0.frequency", Unit: "Hz", Value: 1497600000},
+		{Name: "cpu1.frequency", Unit: "Hz", Value: 300000000},
+		{Name: "gpu.frequency", Unit: "Hz", Value: 257000000},
+		{Name: "gpu.utilization", Unit: "%", Value: 12},
+		{Name: "memory.total", Unit: "B", Value: 3809036 * 1024},
+		{Name: "memory.free", Unit: "B", Value: 113548 * 1024},
+		{Name: "memory.available", Unit: "B", Value: 1460344 * 1024},
+		{Name: "thermal.cpu-0-0-usr", Unit: "°C", Value: 41.3},
+		{Name: "thermal.battery", Unit: "°C", Value: 27},
+	})
+}
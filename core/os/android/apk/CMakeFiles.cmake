@@ -19,12 +19,15 @@
 
 set(files
     analysis.go
+    analysis_test.go
     apk.go
     apk.pb.go
     apk.proto
     debugifier.go
     doc.go
+    native.go
+    native_test.go
 )
 set(dirs
-    
+    testdata
 )
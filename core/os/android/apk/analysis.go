@@ -18,19 +18,42 @@ import (
 	"archive/zip"
 	"context"
 	"path/filepath"
+	"strings"
 
 	"github.com/google/gapid/core/log"
 )
 
-// engineSignatures is used to identify the middleware engine used based on
-// files found in the APK.
-var engineSignatures = map[string]string{
-	"libunity.so":         "unity",
-	"libUnrealEngine3.so": "unreal3",
+// signature is used to identify an engine or middleware used by an APK
+// based on the files found in the APK.
+type signature struct {
+	name      string   // The name of the engine or middleware.
+	engine    bool     // True if the signature identifies an engine.
+	libraries []string // Native library file names.
+	paths     []string // Path prefixes of files in the APK.
+}
+
+// signatures is the list of known engines and middleware. Engines are listed
+// in order of precedence.
+var signatures = []signature{
+	{name: "unity", engine: true, libraries: []string{"libunity.so", "libil2cpp.so"}, paths: []string{"assets/bin/Data/"}},
+	{name: "unreal4", engine: true, libraries: []string{"libUE4.so"}, paths: []string{"assets/UE4Game/"}},
+	{name: "unreal3", engine: true, libraries: []string{"libUnrealEngine3.so"}},
+	{name: "cocos2d-x", engine: true, libraries: []string{"libcocos2dcpp.so", "libcocos2djs.so", "libcocos2dlua.so"}},
+	{name: "godot", engine: true, libraries: []string{"libgodot_android.so"}},
+	{name: "gamemaker", engine: true, libraries: []string{"libyoyo.so"}},
+	{name: "defold", engine: true, libraries: []string{"libdmengine.so"}},
+	{name: "libgdx", engine: true, libraries: []string{"libgdx.so"}},
+	{name: "xamarin", libraries: []string{"libmonodroid.so", "libmonosgen-2.0.so"}},
+	{name: "gvr", libraries: []string{"libgvr.so"}},
+	{name: "oculus", libraries: []string{"libvrapi.so"}},
+	{name: "fmod", libraries: []string{"libfmod.so", "libfmodstudio.so"}},
+	{name: "wwise", libraries: []string{"libAkSoundEngine.so"}},
 }
 
 // Analyze parses the APK file and returns the APK's information.
-func Analyze(ctx context.Context, apkData []byte) (*Information, error) {
+// The files of the optional split APKs of the package are included in the
+// analysis of the native libraries, engines and middleware.
+func Analyze(ctx context.Context, apkData []byte, splits ...[]byte) (*Information, error) {
 	files, err := Read(ctx, apkData)
 	if err != nil {
 		return nil, err
@@ -39,32 +62,63 @@ func Analyze(ctx context.Context, apkData []byte) (*Information, error) {
 	if err != nil {
 		return nil, err
 	}
+	for _, split := range splits {
+		splitFiles, err := Read(ctx, split)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, splitFiles...)
+	}
 
 	activity, action, err := m.MainActivity(ctx)
 	if err != nil {
 		return nil, log.Err(ctx, err, "Finding launch activity")
 	}
-	return &Information{
+	engine, frameworks := identify(files)
+	info := &Information{
 		Name:        m.Package, // TODO
 		VersionCode: int32(m.VersionCode),
 		VersionName: m.VersionName,
 		Package:     m.Package,
 		Activity:    activity,
 		Action:      action,
-		Engine:      engine(files),
+		Engine:      engine,
 		ABI:         GatherABIs(files),
 		Debuggable:  m.Application.Debuggable,
-	}, nil
+		Frameworks:  frameworks,
+	}
+	analyzeNativeLibraries(ctx, files, info)
+	return info, nil
 }
 
-func engine(files []*zip.File) string {
+// identify returns the engine, and all the engines and middleware identified
+// from the files in the APK.
+func identify(files []*zip.File) (string, []string) {
+	found := make([]bool, len(signatures))
 	for _, file := range files {
 		_, name := filepath.Split(file.Name)
-		for sig, engine := range engineSignatures {
-			if name == sig {
-				return engine
+		for i, sig := range signatures {
+			for _, lib := range sig.libraries {
+				if name == lib {
+					found[i] = true
+				}
 			}
+			for _, path := range sig.paths {
+				if strings.HasPrefix(file.Name, path) {
+					found[i] = true
+				}
+			}
+		}
+	}
+	engine, frameworks := "<unknown>", []string{}
+	for i, sig := range signatures {
+		if !found[i] {
+			continue
+		}
+		if sig.engine && engine == "<unknown>" {
+			engine = sig.name
 		}
+		frameworks = append(frameworks, sig.name)
 	}
-	return "<unknown>"
+	return engine, frameworks
 }
@@ -0,0 +1,127 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apk_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/android/apk"
+)
+
+type apkFile struct {
+	name string
+	data []byte
+}
+
+// writeAPK returns an APK holding the files, in order.
+func writeAPK(t *testing.T, files ...apkFile) []byte {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for _, f := range files {
+		fw, err := w.Create(f.name)
+		if err != nil {
+			t.Fatalf("Couldn't create %v: %v", f.name, err)
+		}
+		fw.Write(f.data)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Couldn't write APK: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// testdata returns a file holding the contents of the file in testdata.
+func testdata(t *testing.T, name, path string) apkFile {
+	data, err := ioutil.ReadFile("testdata/" + path)
+	if err != nil {
+		t.Fatalf("Couldn't read %v: %v", path, err)
+	}
+	return apkFile{name, data}
+}
+
+// manifest returns the manifest file of the test APKs.
+func manifest(t *testing.T) apkFile {
+	return testdata(t, "AndroidManifest.xml", "AndroidManifest.xml")
+}
+
+func TestAnalyzeEngines(t *testing.T) {
+	assert := assert.To(t)
+	ctx := log.Testing(t)
+	notELF := []byte("not an ELF file")
+	for _, test := range []struct {
+		name       string
+		files      []apkFile
+		splits     [][]apkFile
+		engine     string
+		frameworks []string
+	}{
+		{
+			name:       "none",
+			engine:     "<unknown>",
+			frameworks: []string{},
+		}, {
+			name:       "library",
+			files:      []apkFile{{"lib/armeabi-v7a/libunity.so", notELF}},
+			engine:     "unity",
+			frameworks: []string{"unity"},
+		}, {
+			name: "path",
+			files: []apkFile{
+				{"assets/UE4Game/Game.pak", nil},
+				{"lib/arm64-v8a/libfmod.so", notELF},
+			},
+			engine:     "unreal4",
+			frameworks: []string{"unreal4", "fmod"},
+		}, {
+			name: "precedence",
+			files: []apkFile{
+				{"lib/armeabi-v7a/libgdx.so", notELF},
+				{"lib/armeabi-v7a/libil2cpp.so", notELF},
+			},
+			engine:     "unity",
+			frameworks: []string{"unity", "libgdx"},
+		}, {
+			name:       "split",
+			splits:     [][]apkFile{{{"lib/arm64-v8a/libgvr.so", notELF}}},
+			engine:     "<unknown>",
+			frameworks: []string{"gvr"},
+		},
+	} {
+		base := writeAPK(t, append([]apkFile{manifest(t)}, test.files...)...)
+		splits := make([][]byte, len(test.splits))
+		for i, files := range test.splits {
+			splits[i] = writeAPK(t, files...)
+		}
+		info, err := apk.Analyze(ctx, base, splits...)
+		if !assert.For("%s err", test.name).ThatError(err).Succeeded() {
+			continue
+		}
+		assert.For("%s package", test.name).That(info.Package).Equals("com.google.gltests")
+		assert.For("%s engine", test.name).That(info.Engine).Equals(test.engine)
+		assert.For("%s frameworks", test.name).ThatSlice(info.Frameworks).Equals(test.frameworks)
+	}
+}
+
+func TestAnalyzeMissingManifest(t *testing.T) {
+	assert := assert.To(t)
+	ctx := log.Testing(t)
+	_, err := apk.Analyze(ctx, writeAPK(t, apkFile{"lib/armeabi-v7a/libunity.so", nil}))
+	assert.For("err").ThatError(err).Failed()
+}
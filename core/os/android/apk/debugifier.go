@@ -17,60 +17,53 @@ package apk
 import (
 	"archive/zip"
 	"context"
-	"io"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"os/user"
 	"path/filepath"
-	"regexp"
 	"strings"
 
 	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/android/apksig"
 	"github.com/google/gapid/core/os/android/binaryxml"
 )
 
 // ApkDebugifier makes an APK debuggable. The fields in the struct
-// are used to configure the various paths and passwords required,
-// as well as providing a log context.
-// Intended use is ApkDebugifier{Ctx: ..., JarSignCmd: "jarsigner", ..., KeyStorePath: "..."}.Run(...).
+// are used to configure the various paths and passwords required.
+// Intended use is ApkDebugifier{KeyStorePath: "...", ...}.Run(ctx, src, dst).
 type ApkDebugifier struct {
-	JarSignCmd   string // path to the jarsigner binary
-	ZipAlignCmd  string // path to the zipalign binary
 	KeyPass      string // key passphrase
 	KeyAlias     string // key alias for signing
 	StorePass    string // keystore passphrase
-	KeyStorePath string // path to keystore (e.g. /path/to/debug.keystore)
+	KeyStorePath string // path to the JKS keystore (e.g. /path/to/debug.keystore)
+
+	// ManifestPatches are additional modifications applied to the manifest
+	// after the debuggable flag has been set.
+	ManifestPatches []func(*binaryxml.Document) error
 }
 
 // Run takes the path (src) to an APK, sets the debuggable flag in its manifest,
 // re-signs and aligns it, and saves it to a different path (dst).
+// If the keystore does not exist, then a new debug key is generated and saved
+// to the keystore path.
 func (a ApkDebugifier) Run(ctx context.Context, src string, dst string) error {
-	tempFile, err := ioutil.TempFile("", "debuggable.apk")
+	key, err := a.signingKey(ctx)
 	if err != nil {
 		return err
 	}
-	defer os.Remove(tempFile.Name())
 
-	log.I(ctx, "Making apk %s debuggable and saving to %s", src, tempFile.Name())
-	err = a.makeApkDebuggableAndRemoveSignatureFiles(ctx, src, tempFile)
+	log.I(ctx, "Making apk %s debuggable", src)
+	files, err := a.makeApkDebuggableAndRemoveSignatureFiles(ctx, src)
 	if err != nil {
 		return err
 	}
 
-	log.I(ctx, "Signing apk %s", tempFile.Name())
-	err = a.jarSign(ctx, tempFile.Name())
+	log.I(ctx, "Signing and aligning apk to %s", dst)
+	data, err := apksig.Sign(files, key)
 	if err != nil {
-		return err
+		return log.Err(ctx, err, "Signing apk")
 	}
-
-	log.I(ctx, "Zipaligning %s to %s", tempFile.Name(), dst)
-	err = a.zipAlign(ctx, tempFile.Name(), dst)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return ioutil.WriteFile(dst, data, 0644)
 }
 
 func expandHomeDir(p string) string {
@@ -84,86 +77,90 @@ func expandHomeDir(p string) string {
 	return filepath.Join(user.HomeDir, strings.TrimLeft(p, "~"))
 }
 
-func (a ApkDebugifier) execCommand(ctx context.Context, name string, args ...string) error {
-	cmd := exec.Command(name, args...)
-	log.I(ctx, "Executing %v", cmd.Args)
-	out, err := cmd.CombinedOutput()
-	logger := log.From(ctx).Writer(log.Debug)
+// signingKey loads the signing key from the keystore, generating a new debug
+// keystore if it does not exist.
+func (a ApkDebugifier) signingKey(ctx context.Context) (*apksig.Key, error) {
+	path := expandHomeDir(a.KeyStorePath)
+	ctx = log.V{"keystore": path}.Bind(ctx)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		log.I(ctx, "Keystore not found, generating a new debug key")
+		key, err := apksig.GenerateDebugKey()
+		if err != nil {
+			return nil, log.Err(ctx, err, "Generating debug key")
+		}
+		data, err := apksig.EncodeKeyStore(key, a.StorePass, a.KeyAlias, a.KeyPass)
+		if err != nil {
+			return nil, log.Err(ctx, err, "Encoding keystore")
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, log.Err(ctx, err, "Creating keystore directory")
+		}
+		if err := ioutil.WriteFile(path, data, 0600); err != nil {
+			return nil, log.Err(ctx, err, "Writing keystore")
+		}
+		return key, nil
+	}
+	key, err := apksig.LoadKeyStore(path, a.StorePass, a.KeyAlias, a.KeyPass)
 	if err != nil {
-		logger = log.From(ctx).Writer(log.Error)
+		return nil, log.Err(ctx, err, "Loading keystore")
 	}
-	logger.Write(out)
-	return err
-}
-
-func (a ApkDebugifier) jarSign(ctx context.Context, apk string) error {
-	return a.execCommand(ctx,
-		expandHomeDir(a.JarSignCmd),
-		"-sigalg", "SHA1withRSA",
-		"-digestalg", "SHA1",
-		"-keystore", expandHomeDir(a.KeyStorePath),
-		"-storepass", a.StorePass,
-		"-keypass", a.KeyPass,
-		apk,
-		a.KeyAlias,
-	)
-}
-
-func (a ApkDebugifier) zipAlign(ctx context.Context, src string, dst string) error {
-	return a.execCommand(ctx, expandHomeDir(a.ZipAlignCmd), "-v", "-f", "4", src, dst)
+	return key, nil
 }
 
-func (a ApkDebugifier) makeApkDebuggableAndRemoveSignatureFiles(ctx context.Context, src string, outFile *os.File) error {
+// makeApkDebuggableAndRemoveSignatureFiles returns the files of the APK at src,
+// with the manifest made debuggable, and without the files of the existing
+// signature.
+func (a ApkDebugifier) makeApkDebuggableAndRemoveSignatureFiles(ctx context.Context, src string) ([]apksig.File, error) {
 	inZip, err := zip.OpenReader(src)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer inZip.Close()
 
-	defer outFile.Close()
-	w := zip.NewWriter(outFile)
-	defer w.Close()
-
-	jarSignatureFilePattern := regexp.MustCompile(`META-INF/[^/]*(DSA|RSA|SF)`)
-
+	files := make([]apksig.File, 0, len(inZip.File))
 	for _, zf := range inZip.File {
-		if jarSignatureFilePattern.MatchString(zf.Name) {
+		if apksig.IsSignatureFile(zf.Name) {
 			log.I(ctx, "Skipping file %s", zf.Name)
 			continue
 		}
 
 		fr, err := zf.Open()
 		if err != nil {
-			return err
+			return nil, err
 		}
-		defer fr.Close()
-
-		fw, err := w.CreateHeader(&zip.FileHeader{
-			Name:         zf.Name,
-			Method:       zf.Method,
-			ModifiedDate: zf.ModifiedDate,
-			ModifiedTime: zf.ModifiedTime,
-		})
+		data, err := ioutil.ReadAll(fr)
+		fr.Close()
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if zf.Name == "AndroidManifest.xml" {
 			log.I(ctx, "Modifying manifest file")
-			err := binaryxml.SetDebuggableFlag(fr, fw)
+			doc, err := binaryxml.DecodeDocument(data)
 			if err != nil {
-				return err
+				return nil, err
 			}
-		} else {
-			_, err = io.Copy(fw, fr)
+			if err := binaryxml.SetDebuggable(doc); err != nil {
+				return nil, err
+			}
+			for _, patch := range a.ManifestPatches {
+				if err := patch(doc); err != nil {
+					return nil, err
+				}
+			}
+			data = doc.Encode()
 		}
 
-		if err != nil {
-			return err
-		}
+		files = append(files, apksig.File{
+			Name:         zf.Name,
+			Method:       zf.Method,
+			ModifiedDate: zf.ModifiedDate,
+			ModifiedTime: zf.ModifiedTime,
+			Data:         data,
+		})
 	}
 
-	return nil
+	return files, nil
 }
 
 func IsApkDebuggable(ctx context.Context, apk string) (bool, error) {
@@ -0,0 +1,163 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apk
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"debug/elf"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/gapid/core/log"
+)
+
+// graphicsLibraries maps the names of the system graphics libraries to the
+// graphics API they implement.
+var graphicsLibraries = map[string]GraphicsAPI{
+	"libEGL.so":       GraphicsAPI_EGL,
+	"libGLESv1_CM.so": GraphicsAPI_GLES1,
+	"libGLESv2.so":    GraphicsAPI_GLES2,
+	"libGLESv3.so":    GraphicsAPI_GLES3,
+	"libvulkan.so":    GraphicsAPI_Vulkan,
+}
+
+var (
+	// graphicsImportRegex matches the names of EGL, OpenGL ES and Vulkan entry
+	// points.
+	graphicsImportRegex = regexp.MustCompile(`^(egl|gl|vk)[A-Z]`)
+	// extensionRegex matches EGL, OpenGL ES and Vulkan extension names.
+	extensionRegex = regexp.MustCompile(`^(EGL|GL|VK)_[A-Z0-9]+_[A-Za-z0-9_]+$`)
+)
+
+// isNativeLibrary returns true if the APK file at path is a native library.
+func isNativeLibrary(path string) bool {
+	parts := strings.Split(path, "/")
+	return len(parts) == 3 && parts[0] == "lib" && strings.HasSuffix(parts[2], ".so")
+}
+
+// analyzeNativeLibraries parses the ELF files of all the native libraries in
+// files, adding the libraries, graphics APIs and extensions to info.
+// Libraries that cannot be parsed are skipped with a warning.
+func analyzeNativeLibraries(ctx context.Context, files []*zip.File, info *Information) {
+	apis := map[GraphicsAPI]bool{}
+	extensions := map[string]bool{}
+	for _, file := range files {
+		if !isNativeLibrary(file.Name) {
+			continue
+		}
+		ctx := log.V{"library": file.Name}.Bind(ctx)
+		lib, exts, err := analyzeNativeLibrary(file)
+		if err != nil {
+			log.W(ctx, "Couldn't analyze native library: %v", err)
+			continue
+		}
+		for _, api := range lib.GraphicsAPIs {
+			apis[api] = true
+		}
+		for _, ext := range exts {
+			extensions[ext] = true
+		}
+		info.Libraries = append(info.Libraries, lib)
+	}
+	for api := range apis {
+		info.GraphicsAPIs = append(info.GraphicsAPIs, api)
+	}
+	sort.Slice(info.GraphicsAPIs, func(i, j int) bool { return info.GraphicsAPIs[i] < info.GraphicsAPIs[j] })
+	info.Extensions = sortedKeys(extensions)
+}
+
+// analyzeNativeLibrary parses the ELF file of the native library, returning
+// its information and the graphics extension names it references.
+func analyzeNativeLibrary(file *zip.File) (*NativeLibrary, []string, error) {
+	r, err := file.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := elf.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	lib := &NativeLibrary{
+		Path: file.Name,
+		Abi:  strings.Split(file.Name, "/")[1],
+	}
+	apis := map[GraphicsAPI]bool{}
+
+	// Libraries linked against.
+	if lib.Needed, err = f.ImportedLibraries(); err != nil {
+		return nil, nil, err
+	}
+	for _, needed := range lib.Needed {
+		if api, ok := graphicsLibraries[needed]; ok {
+			apis[api] = true
+		}
+	}
+
+	// Entry points imported from the linked libraries.
+	// Libraries without a dynamic symbol table import nothing.
+	symbols, _ := f.ImportedSymbols()
+	imports := map[string]bool{}
+	for _, s := range symbols {
+		if graphicsImportRegex.MatchString(s.Name) {
+			imports[s.Name] = true
+		}
+	}
+	lib.GraphicsImports = sortedKeys(imports)
+
+	// Graphics libraries loaded with dlopen() and extension names are found
+	// in the strings of the read-only data.
+	extensions := map[string]bool{}
+	if rodata := f.Section(".rodata"); rodata != nil && rodata.Type != elf.SHT_NOBITS {
+		data, err := rodata.Data()
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, s := range bytes.Split(data, []byte{0}) {
+			str := string(s)
+			if api, ok := graphicsLibraries[str]; ok {
+				apis[api] = true
+			}
+			if extensionRegex.MatchString(str) {
+				extensions[str] = true
+			}
+		}
+	}
+
+	for api := range apis {
+		lib.GraphicsAPIs = append(lib.GraphicsAPIs, api)
+	}
+	sort.Slice(lib.GraphicsAPIs, func(i, j int) bool { return lib.GraphicsAPIs[i] < lib.GraphicsAPIs[j] })
+	return lib, sortedKeys(extensions), nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,77 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apk_test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/android/apk"
+)
+
+// gameLibrary returns the information of testdata/libgame.so at path in an APK.
+// libgame.so is a x86_64 library that links against libGLESv2.so and
+// libEGL.so, imports glClear, eglGetDisplay and vkCreateInstance, loads
+// libvulkan.so with dlopen() and references the GL_OES_texture_npot extension.
+func gameLibrary(path, abi string) *apk.NativeLibrary {
+	return &apk.NativeLibrary{
+		Path:            path,
+		Abi:             abi,
+		Needed:          []string{"libGLESv2.so", "libEGL.so"},
+		GraphicsAPIs:    []apk.GraphicsAPI{apk.GraphicsAPI_EGL, apk.GraphicsAPI_GLES2, apk.GraphicsAPI_Vulkan},
+		GraphicsImports: []string{"eglGetDisplay", "glClear", "vkCreateInstance"},
+	}
+}
+
+func TestAnalyzeNativeLibraries(t *testing.T) {
+	assert := assert.To(t)
+	ctx := log.Testing(t)
+	base := writeAPK(t,
+		manifest(t),
+		testdata(t, "lib/x86_64/libgame.so", "libgame.so"),
+		// Not an ELF file, skipped.
+		apkFile{"lib/x86_64/libbroken.so", []byte("not an ELF file")},
+		// Not in a lib/<abi>/ directory, ignored.
+		testdata(t, "assets/libgame.so", "libgame.so"),
+		testdata(t, "lib/x86_64/plugins/libgame.so", "libgame.so"),
+	)
+	split := writeAPK(t, testdata(t, "lib/x86/libgame.so", "libgame.so"))
+
+	info, err := apk.Analyze(ctx, base, split)
+	if !assert.For("err").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For("libraries").That(info.Libraries).DeepEquals([]*apk.NativeLibrary{
+		gameLibrary("lib/x86_64/libgame.so", "x86_64"),
+		gameLibrary("lib/x86/libgame.so", "x86"),
+	})
+	assert.For("graphics APIs").That(info.GraphicsAPIs).DeepEquals([]apk.GraphicsAPI{
+		apk.GraphicsAPI_EGL, apk.GraphicsAPI_GLES2, apk.GraphicsAPI_Vulkan,
+	})
+	assert.For("extensions").That(info.Extensions).DeepEquals([]string{"GL_OES_texture_npot"})
+}
+
+func TestAnalyzeWithoutNativeLibraries(t *testing.T) {
+	assert := assert.To(t)
+	ctx := log.Testing(t)
+	info, err := apk.Analyze(ctx, writeAPK(t, manifest(t)))
+	if !assert.For("err").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For("libraries").That(info.Libraries).IsNil()
+	assert.For("graphics APIs").That(info.GraphicsAPIs).IsNil()
+	assert.For("extensions").ThatSlice(info.Extensions).IsEmpty()
+}
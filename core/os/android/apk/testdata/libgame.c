@@ -0,0 +1,33 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Source of libgame.so, used by the native library analysis tests.
+// Built on x86_64 Linux against stub libGLESv2.so and libEGL.so libraries with:
+//   gcc -shared -fPIC -O1 -nostdlib -Wl,--no-as-needed -Wl,--build-id=none \
+//       -o libgame.so libgame.c -L. -lGLESv2 -lEGL && strip libgame.so
+
+#include <dlfcn.h>
+
+extern void glClear(unsigned int);
+extern void* eglGetDisplay(void*);
+extern int vkCreateInstance(const void*, const void*, void*);
+
+const char* game_extension = "GL_OES_texture_npot";
+
+int game_frame(void) {
+  void* vk = dlopen("libvulkan.so", 0);
+  glClear(0x4000);
+  eglGetDisplay(0);
+  return vk != 0 && game_extension[0] && vkCreateInstance(0, 0, 0);
+}
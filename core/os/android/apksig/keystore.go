@@ -0,0 +1,359 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apksig
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/google/gapid/core/data/binary"
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/fault"
+	"github.com/google/gapid/core/os/device"
+)
+
+const (
+	// ErrInvalidKeyStore is returned when the key store data is malformed.
+	ErrInvalidKeyStore = fault.Const("Invalid key store")
+	// ErrUnsupportedKeyStore is returned when the key store is not a JKS key
+	// store.
+	ErrUnsupportedKeyStore = fault.Const("Unsupported key store type, only JKS key stores are supported")
+	// ErrIncorrectPassword is returned when the key store or key password is
+	// incorrect.
+	ErrIncorrectPassword = fault.Const("Incorrect key store password")
+	// ErrAliasNotFound is returned when the key store has no private key with
+	// the requested alias.
+	ErrAliasNotFound = fault.Const("Key alias not found in key store")
+	// ErrUnsupportedKey is returned when the private key is not an RSA key.
+	ErrUnsupportedKey = fault.Const("Unsupported private key type, only RSA keys are supported")
+)
+
+// The default values used by the Android SDK for the debug key store.
+const (
+	DebugKeyAlias  = "androiddebugkey"
+	DebugKeyPass   = "android"
+	DebugStorePass = "android"
+)
+
+// Key is a private key and its certificate, used to sign APKs.
+type Key struct {
+	PrivateKey  *rsa.PrivateKey
+	Certificate *x509.Certificate
+}
+
+// GenerateDebugKey returns a new self-signed key, with the same distinguished
+// name as the Android SDK debug key.
+func GenerateDebugKey() (*Key, error) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 63))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   "Android Debug",
+			Organization: []string{"Android"},
+			Country:      []string{"US"},
+		},
+		NotBefore:          now,
+		NotAfter:           now.AddDate(30, 0, 0),
+		SignatureAlgorithm: x509.SHA256WithRSA,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{priv, cert}, nil
+}
+
+// JKS format constants.
+// See: http://hg.openjdk.java.net/jdk8/jdk8/jdk/file/tip/src/share/classes/sun/security/provider/JavaKeyStore.java
+const (
+	jksMagic         = 0xfeedfeed
+	jceksMagic       = 0xcececece
+	jksVersion       = 2
+	privateKeyEntry  = 1
+	trustedCertEntry = 2
+	jksDigestSalt    = "Mighty Aphrodite"
+	certificateType  = "X.509"
+)
+
+// jksKeyProtectorOID is the identifier of the proprietary algorithm used to
+// protect the private keys of a JKS key store.
+var jksKeyProtectorOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 42, 2, 17, 1, 1}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// LoadKeyStore loads the private key with the given alias from the JKS key
+// store file at path.
+func LoadKeyStore(path, storePass, alias, keyPass string) (*Key, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeKeyStore(data, storePass, alias, keyPass)
+}
+
+// DecodeKeyStore decodes the private key with the given alias from the JKS key
+// store data.
+func DecodeKeyStore(data []byte, storePass, alias, keyPass string) (*Key, error) {
+	if len(data) < 12+sha1.Size {
+		return nil, ErrInvalidKeyStore
+	}
+	body, digest := data[:len(data)-sha1.Size], data[len(data)-sha1.Size:]
+	r := endian.Reader(bytes.NewReader(body), device.BigEndian)
+	switch r.Uint32() {
+	case jksMagic:
+	case jceksMagic:
+		return nil, ErrUnsupportedKeyStore
+	default:
+		if data[0] == 0x30 { // ASN.1 sequence, most likely PKCS#12.
+			return nil, ErrUnsupportedKeyStore
+		}
+		return nil, ErrInvalidKeyStore
+	}
+	if r.Uint32() != jksVersion {
+		return nil, ErrUnsupportedKeyStore
+	}
+	if !bytes.Equal(keyStoreDigest(storePass, body), digest) {
+		return nil, ErrIncorrectPassword
+	}
+
+	count := r.Uint32()
+	for i := uint32(0); i < count; i++ {
+		tag := r.Uint32()
+		name := readUTF(r)
+		r.Uint64() // Creation time.
+		switch tag {
+		case privateKeyEntry:
+			protected := readBytes(r, len(body))
+			chain := make([][]byte, readCount(r, len(body)))
+			for j := range chain {
+				readUTF(r) // Certificate type.
+				chain[j] = readBytes(r, len(body))
+			}
+			if err := r.Error(); err != nil {
+				return nil, ErrInvalidKeyStore
+			}
+			if strings.EqualFold(name, alias) {
+				return decodeKeyEntry(protected, chain, keyPass)
+			}
+		case trustedCertEntry:
+			readUTF(r) // Certificate type.
+			readBytes(r, len(body))
+		default:
+			return nil, ErrInvalidKeyStore
+		}
+		if err := r.Error(); err != nil {
+			return nil, ErrInvalidKeyStore
+		}
+	}
+	return nil, ErrAliasNotFound
+}
+
+// EncodeKeyStore returns a JKS key store holding key with the given alias.
+func EncodeKeyStore(key *Key, storePass, alias, keyPass string) ([]byte, error) {
+	plain, err := x509.MarshalPKCS8PrivateKey(key.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	protected, err := protectKey(plain, keyPass)
+	if err != nil {
+		return nil, err
+	}
+	info, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  jksKeyProtectorOID,
+			Parameters: asn1.RawValue{Tag: asn1.TagNull},
+		},
+		EncryptedData: protected,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	w := endian.Writer(buf, device.BigEndian)
+	w.Uint32(jksMagic)
+	w.Uint32(jksVersion)
+	w.Uint32(1) // Entry count.
+	w.Uint32(privateKeyEntry)
+	writeUTF(w, strings.ToLower(alias))
+	w.Uint64(uint64(time.Now().UnixNano() / int64(time.Millisecond)))
+	w.Uint32(uint32(len(info)))
+	w.Data(info)
+	w.Uint32(1) // Certificate chain length.
+	writeUTF(w, certificateType)
+	w.Uint32(uint32(len(key.Certificate.Raw)))
+	w.Data(key.Certificate.Raw)
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	buf.Write(keyStoreDigest(storePass, buf.Bytes()))
+	return buf.Bytes(), nil
+}
+
+func decodeKeyEntry(protected []byte, chain [][]byte, keyPass string) (*Key, error) {
+	info := encryptedPrivateKeyInfo{}
+	if _, err := asn1.Unmarshal(protected, &info); err != nil {
+		return nil, ErrInvalidKeyStore
+	}
+	if !info.Algorithm.Algorithm.Equal(jksKeyProtectorOID) {
+		return nil, ErrUnsupportedKeyStore
+	}
+	plain, err := unprotectKey(info.EncryptedData, keyPass)
+	if err != nil {
+		return nil, err
+	}
+	priv, err := x509.ParsePKCS8PrivateKey(plain)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := priv.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+	if len(chain) == 0 {
+		return nil, ErrInvalidKeyStore
+	}
+	cert, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return nil, err
+	}
+	return &Key{rsaKey, cert}, nil
+}
+
+// protectKey encrypts the private key with the JKS key protector algorithm.
+// The result is the random salt, followed by the encrypted key, followed by a
+// digest of the plain key used to check the password.
+func protectKey(plain []byte, pass string) ([]byte, error) {
+	salt := make([]byte, sha1.Size)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	out := append(salt, keyProtectorXOR(plain, pass, salt)...)
+	return append(out, keyProtectorCheck(plain, pass)...), nil
+}
+
+// unprotectKey decrypts the private key protected by protectKey.
+func unprotectKey(protected []byte, pass string) ([]byte, error) {
+	if len(protected) < 2*sha1.Size {
+		return nil, ErrInvalidKeyStore
+	}
+	salt := protected[:sha1.Size]
+	encrypted := protected[sha1.Size : len(protected)-sha1.Size]
+	check := protected[len(protected)-sha1.Size:]
+	plain := keyProtectorXOR(encrypted, pass, salt)
+	if !bytes.Equal(keyProtectorCheck(plain, pass), check) {
+		return nil, ErrIncorrectPassword
+	}
+	return plain, nil
+}
+
+// keyProtectorXOR returns data XORed with the key stream derived from the
+// password and salt. The key stream is formed of consecutive SHA-1 digests of
+// the password and the previous digest, starting with the salt.
+func keyProtectorXOR(data []byte, pass string, salt []byte) []byte {
+	password := passwordBytes(pass)
+	out := make([]byte, len(data))
+	digest := salt
+	for i := range data {
+		if i%sha1.Size == 0 {
+			h := sha1.New()
+			h.Write(password)
+			h.Write(digest)
+			digest = h.Sum(nil)
+		}
+		out[i] = data[i] ^ digest[i%sha1.Size]
+	}
+	return out
+}
+
+func keyProtectorCheck(plain []byte, pass string) []byte {
+	h := sha1.New()
+	h.Write(passwordBytes(pass))
+	h.Write(plain)
+	return h.Sum(nil)
+}
+
+// keyStoreDigest returns the integrity digest of the key store data.
+func keyStoreDigest(pass string, data []byte) []byte {
+	h := sha1.New()
+	h.Write(passwordBytes(pass))
+	h.Write([]byte(jksDigestSalt))
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// passwordBytes returns the password as big-endian UTF-16, as used by the JKS
+// digests.
+func passwordBytes(pass string) []byte {
+	out := []byte{}
+	for _, c := range utf16.Encode([]rune(pass)) {
+		out = append(out, byte(c>>8), byte(c))
+	}
+	return out
+}
+
+// readCount reads a 32 bit count, failing the reader if it is larger than max.
+func readCount(r binary.Reader, max int) uint32 {
+	n := r.Uint32()
+	if n > uint32(max) {
+		r.SetError(ErrInvalidKeyStore)
+		return 0
+	}
+	return n
+}
+
+func readBytes(r binary.Reader, max int) []byte {
+	out := make([]byte, readCount(r, max))
+	r.Data(out)
+	return out
+}
+
+// readUTF reads a Java modified UTF-8 string, which is prefixed with its 16
+// bit length.
+func readUTF(r binary.Reader) string {
+	out := make([]byte, r.Uint16())
+	r.Data(out)
+	return string(out)
+}
+
+func writeUTF(w binary.Writer, s string) {
+	w.Uint16(uint16(len(s)))
+	w.Data([]byte(s))
+}
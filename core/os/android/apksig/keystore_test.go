@@ -0,0 +1,61 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apksig_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/os/android/apksig"
+)
+
+var (
+	testKeyOnce sync.Once
+	testKey     *apksig.Key
+)
+
+func debugKey(ctx assert.Manager) *apksig.Key {
+	testKeyOnce.Do(func() {
+		var err error
+		testKey, err = apksig.GenerateDebugKey()
+		assert.With(ctx).ThatError(err).Succeeded()
+	})
+	return testKey
+}
+
+func TestKeyStoreRoundTrip(t *testing.T) {
+	ctx := assert.Context(t)
+	key := debugKey(ctx)
+	assert.With(ctx).ThatString(key.Certificate.Subject.CommonName).Equals("Android Debug")
+
+	data, err := apksig.EncodeKeyStore(key, "storepass", "MyKey", "keypass")
+	assert.With(ctx).ThatError(err).Succeeded()
+
+	got, err := apksig.DecodeKeyStore(data, "storepass", "mykey", "keypass")
+	assert.With(ctx).ThatError(err).Succeeded()
+	assert.For(ctx, "certificate").ThatSlice(got.Certificate.Raw).Equals(key.Certificate.Raw)
+	assert.For(ctx, "modulus").That(got.PrivateKey.N.Cmp(key.PrivateKey.N)).Equals(0)
+	assert.For(ctx, "exponent").That(got.PrivateKey.D.Cmp(key.PrivateKey.D)).Equals(0)
+
+	_, err = apksig.DecodeKeyStore(data, "wrong", "mykey", "keypass")
+	assert.For(ctx, "store pass").ThatError(err).Equals(apksig.ErrIncorrectPassword)
+	_, err = apksig.DecodeKeyStore(data, "storepass", "mykey", "wrong")
+	assert.For(ctx, "key pass").ThatError(err).Equals(apksig.ErrIncorrectPassword)
+	_, err = apksig.DecodeKeyStore(data, "storepass", "other", "keypass")
+	assert.For(ctx, "alias").ThatError(err).Equals(apksig.ErrAliasNotFound)
+	_, err = apksig.DecodeKeyStore(data[:len(data)/2], "storepass", "mykey", "keypass")
+	assert.For(ctx, "truncated").ThatError(err).Failed()
+}
@@ -0,0 +1,41 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apksig
+
+// Sign returns the APK holding files, aligned and signed with key using both
+// the v1 (JAR) and v2 APK signature schemes. Any existing v1 signature files in
+// files are replaced.
+// The v1 signature uses SHA-256 digests, so the APK requires API level 18 or
+// later.
+func Sign(files []File, key *Key) ([]byte, error) {
+	if key.PrivateKey == nil {
+		return nil, ErrUnsupportedKey
+	}
+	unsigned := make([]File, 0, len(files))
+	for _, f := range files {
+		if !IsSignatureFile(f.Name) {
+			unsigned = append(unsigned, f)
+		}
+	}
+	v1, err := signV1(unsigned, key)
+	if err != nil {
+		return nil, err
+	}
+	apk, centralDirectory, err := writeAlignedZip(append(v1, unsigned...))
+	if err != nil {
+		return nil, err
+	}
+	return signV2(apk, centralDirectory, key)
+}
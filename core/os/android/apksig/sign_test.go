@@ -0,0 +1,234 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apksig_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/os/android/apksig"
+)
+
+var testFiles = []apksig.File{
+	{Name: "AndroidManifest.xml", Method: zip.Deflate, Data: []byte("<manifest/>")},
+	{Name: "META-INF/OLD.SF", Method: zip.Deflate, Data: []byte("stale signature")},
+	{Name: "classes.dex", Method: zip.Deflate, Data: bytes.Repeat([]byte("dex\n"), 1000)},
+	{Name: "res/", Method: zip.Store},
+	{Name: "res/raw/a.bin", Method: zip.Store, Data: []byte{1, 2, 3}},
+	{Name: "res/raw/long_name_that_needs_the_manifest_line_to_be_broken_over_several_lines.bin", Method: zip.Store, Data: []byte{4, 5}},
+	// Multi-byte characters that straddle the manifest line breaks.
+	{Name: "res/raw/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaéééééééééééééééééééééééééééééééééééééééé.bin", Method: zip.Store, Data: []byte{6}},
+	{Name: "lib/arm64-v8a/libfoo.so", Method: zip.Store, Data: bytes.Repeat([]byte{0x7f}, 5000)},
+	{Name: "resources.arsc", Method: zip.Store, Data: []byte("resources")},
+}
+
+func TestSign(t *testing.T) {
+	ctx := assert.Context(t)
+	key := debugKey(ctx)
+	apk, err := apksig.Sign(testFiles, key)
+	assert.With(ctx).ThatError(err).Succeeded()
+
+	r, err := zip.NewReader(bytes.NewReader(apk), int64(len(apk)))
+	assert.With(ctx).ThatError(err).Succeeded()
+	files := map[string][]byte{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		assert.With(ctx).ThatError(err).Succeeded()
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		assert.For(ctx, "read %v", f.Name).ThatError(err).Succeeded()
+		files[f.Name] = data
+
+		if f.Method == zip.Store {
+			offset, err := f.DataOffset()
+			assert.With(ctx).ThatError(err).Succeeded()
+			alignment := int64(4)
+			if strings.HasSuffix(f.Name, ".so") {
+				alignment = 4096
+			}
+			assert.For(ctx, "%v alignment", f.Name).That(offset % alignment).Equals(int64(0))
+		}
+	}
+	assert.For(ctx, "stale signature").That(files["META-INF/OLD.SF"]).IsNil()
+	for _, f := range testFiles {
+		if f.Name != "META-INF/OLD.SF" {
+			assert.For(ctx, "%v", f.Name).ThatSlice(files[f.Name]).Equals(f.Data)
+		}
+	}
+
+	verifyV1(ctx, files, key)
+	verifyV2(ctx, apk, key)
+}
+
+func digest(data []byte) string {
+	d := sha256.Sum256(data)
+	return base64.StdEncoding.EncodeToString(d[:])
+}
+
+// unwrap joins the continuation lines of a JAR manifest.
+func unwrap(manifest []byte) string {
+	return strings.Replace(string(manifest), "\r\n ", "", -1)
+}
+
+func verifyV1(ctx assert.Manager, files map[string][]byte, key *apksig.Key) {
+	manifest := files["META-INF/MANIFEST.MF"]
+	signature := files["META-INF/CERT.SF"]
+	block := files["META-INF/CERT.RSA"]
+
+	for _, line := range strings.Split(string(manifest), "\r\n") {
+		assert.For(ctx, "line length").That(len(line) <= 72).Equals(true)
+		assert.For(ctx, "line %q is UTF-8", line).That(utf8.ValidString(line)).Equals(true)
+	}
+	for _, f := range testFiles {
+		if strings.HasSuffix(f.Name, "/") || strings.HasPrefix(f.Name, "META-INF/") {
+			continue
+		}
+		entry := "Name: " + f.Name + "\r\nSHA-256-Digest: " + digest(f.Data) + "\r\n"
+		assert.For(ctx, "%v digest", f.Name).ThatString(unwrap(manifest)).Contains(entry)
+	}
+	assert.For(ctx, "directory").ThatString(unwrap(manifest)).DoesNotContain("Name: res/\r\n")
+	assert.For(ctx, "manifest digest").ThatString(unwrap(signature)).Contains(
+		"SHA-256-Digest-Manifest: " + digest(manifest) + "\r\n")
+	assert.For(ctx, "v2 marker").ThatString(unwrap(signature)).Contains("X-Android-APK-Signed: 2\r\n")
+
+	var contentInfo struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue `asn1:"explicit,tag:0"`
+	}
+	_, err := asn1.Unmarshal(block, &contentInfo)
+	assert.For(ctx, "content info").ThatError(err).Succeeded()
+	var signedData struct {
+		Version          int
+		DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+		ContentInfo      struct{ ContentType asn1.ObjectIdentifier }
+		Certificates     asn1.RawValue `asn1:"tag:0"`
+		SignerInfos      []struct {
+			Version               int
+			IssuerAndSerialNumber struct {
+				Issuer       asn1.RawValue
+				SerialNumber *big.Int
+			}
+			DigestAlgorithm           pkix.AlgorithmIdentifier
+			DigestEncryptionAlgorithm pkix.AlgorithmIdentifier
+			EncryptedDigest           []byte
+		} `asn1:"set"`
+	}
+	_, err = asn1.Unmarshal(contentInfo.Content.Bytes, &signedData)
+	assert.For(ctx, "signed data").ThatError(err).Succeeded()
+
+	cert, err := x509.ParseCertificate(signedData.Certificates.Bytes)
+	assert.For(ctx, "certificate").ThatError(err).Succeeded()
+	assert.For(ctx, "certificate").ThatSlice(cert.Raw).Equals(key.Certificate.Raw)
+	assert.For(ctx, "signers").That(len(signedData.SignerInfos)).Equals(1)
+	signer := signedData.SignerInfos[0]
+	assert.For(ctx, "serial").That(signer.IssuerAndSerialNumber.SerialNumber.Cmp(cert.SerialNumber)).Equals(0)
+	assert.For(ctx, "issuer").ThatSlice(signer.IssuerAndSerialNumber.Issuer.FullBytes).Equals(cert.RawIssuer)
+
+	hash := sha256.Sum256(signature)
+	err = rsa.VerifyPKCS1v15(cert.PublicKey.(*rsa.PublicKey), crypto.SHA256, hash[:], signer.EncryptedDigest)
+	assert.For(ctx, "v1 signature").ThatError(err).Succeeded()
+}
+
+// next returns the next length-prefixed value from b.
+func next(ctx assert.Manager, b *[]byte) []byte {
+	assert.For(ctx, "length").That(len(*b) >= 4).Equals(true)
+	n := binary.LittleEndian.Uint32(*b)
+	assert.For(ctx, "value").That(uint32(len(*b)-4) >= n).Equals(true)
+	out := (*b)[4 : 4+n]
+	*b = (*b)[4+n:]
+	return out
+}
+
+func verifyV2(ctx assert.Manager, apk []byte, key *apksig.Key) {
+	eocd := apk[len(apk)-22:]
+	centralDirectory := binary.LittleEndian.Uint32(eocd[16:])
+	magic := apk[centralDirectory-16 : centralDirectory]
+	assert.For(ctx, "magic").ThatString(string(magic)).Equals("APK Sig Block 42")
+	size := binary.LittleEndian.Uint64(apk[centralDirectory-24:])
+	start := centralDirectory - uint32(size) - 8
+	assert.For(ctx, "block size").That(binary.LittleEndian.Uint64(apk[start:])).Equals(size)
+
+	pairs := apk[start+8 : centralDirectory-24]
+	pairSize := binary.LittleEndian.Uint64(pairs)
+	assert.For(ctx, "pair size").That(pairSize).Equals(uint64(len(pairs) - 8))
+	assert.For(ctx, "block id").That(binary.LittleEndian.Uint32(pairs[8:])).Equals(uint32(0x7109871a))
+	v2 := pairs[12:]
+
+	signers := next(ctx, &v2)
+	signer := next(ctx, &signers)
+	signedData := next(ctx, &signer)
+	signatures := next(ctx, &signer)
+	publicKey := next(ctx, &signer)
+
+	pub, err := x509.ParsePKIXPublicKey(publicKey)
+	assert.For(ctx, "public key").ThatError(err).Succeeded()
+	assert.For(ctx, "public key").That(pub.(*rsa.PublicKey).N.Cmp(key.PrivateKey.N)).Equals(0)
+
+	signature := next(ctx, &signatures)
+	assert.For(ctx, "signature algorithm").That(binary.LittleEndian.Uint32(signature)).Equals(uint32(0x0103))
+	signature = signature[4:]
+	hash := sha256.Sum256(signedData)
+	err = rsa.VerifyPKCS1v15(pub.(*rsa.PublicKey), crypto.SHA256, hash[:], next(ctx, &signature))
+	assert.For(ctx, "v2 signature").ThatError(err).Succeeded()
+
+	digests := next(ctx, &signedData)
+	certificates := next(ctx, &signedData)
+	assert.For(ctx, "certificate").ThatSlice(next(ctx, &certificates)).Equals(key.Certificate.Raw)
+	d := next(ctx, &digests)
+	assert.For(ctx, "digest algorithm").That(binary.LittleEndian.Uint32(d)).Equals(uint32(0x0103))
+	d = d[4:]
+
+	// The digest covers the entries, the central directory and the end of
+	// central directory, with the central directory offset pointing at the
+	// signing block.
+	eocdCopy := append([]byte{}, eocd...)
+	binary.LittleEndian.PutUint32(eocdCopy[16:], start)
+	chunks := []byte{}
+	count := uint32(0)
+	for _, section := range [][]byte{apk[:start], apk[centralDirectory : len(apk)-22], eocdCopy} {
+		for len(section) > 0 {
+			n := 1 << 20
+			if n > len(section) {
+				n = len(section)
+			}
+			h := sha256.New()
+			h.Write([]byte{0xa5})
+			binary.Write(h, binary.LittleEndian, uint32(n))
+			h.Write(section[:n])
+			chunks = h.Sum(chunks)
+			section = section[n:]
+			count++
+		}
+	}
+	h := sha256.New()
+	h.Write([]byte{0x5a})
+	binary.Write(h, binary.LittleEndian, count)
+	h.Write(chunks)
+	assert.For(ctx, "v2 digest").ThatSlice(next(ctx, &d)).Equals(h.Sum(nil))
+}
@@ -0,0 +1,207 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apksig
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"math/big"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	manifestPath      = "META-INF/MANIFEST.MF"
+	signatureFilePath = "META-INF/CERT.SF"
+	signatureBlock    = "META-INF/CERT.RSA"
+	createdBy         = "1.0 (Android)"
+	// maxManifestLineLength is the maximum length in bytes of a manifest line,
+	// excluding the line break.
+	maxManifestLineLength = 70
+)
+
+var signatureFileRegex = regexp.MustCompile(`^META-INF/([^/]*\.(SF|RSA|DSA|EC)|SIG-[^/]*|MANIFEST\.MF)$`)
+
+// IsSignatureFile returns true if name is the path of a file in the APK that is
+// part of a v1 signature. These files are replaced when the APK is signed.
+func IsSignatureFile(name string) bool {
+	return signatureFileRegex.MatchString(name)
+}
+
+var (
+	oidData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidSHA256        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSAEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	asn1Null         = asn1.RawValue{Tag: asn1.TagNull}
+)
+
+// PKCS #7 structures.
+// See: https://tools.ietf.org/html/rfc2315
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional"`
+}
+
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      pkcs7ContentInfo
+	Certificates     asn1.RawValue
+	SignerInfos      []pkcs7SignerInfo `asn1:"set"`
+}
+
+type pkcs7IssuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type pkcs7SignerInfo struct {
+	Version                   int
+	IssuerAndSerialNumber     pkcs7IssuerAndSerialNumber
+	DigestAlgorithm           pkix.AlgorithmIdentifier
+	DigestEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedDigest           []byte
+}
+
+// signV1 returns the JAR manifest, signature file and signature block files
+// that sign files with key.
+func signV1(files []File, key *Key) ([]File, error) {
+	manifest := &bytes.Buffer{}
+	writeManifestAttribute(manifest, "Manifest-Version", "1.0")
+	writeManifestAttribute(manifest, "Created-By", createdBy)
+	manifest.WriteString("\r\n")
+
+	sections := &bytes.Buffer{}
+	for _, f := range files {
+		if strings.HasSuffix(f.Name, "/") || IsSignatureFile(f.Name) {
+			continue
+		}
+		section := &bytes.Buffer{}
+		writeManifestAttribute(section, "Name", f.Name)
+		writeManifestAttribute(section, "SHA-256-Digest", digestBase64(f.Data))
+		section.WriteString("\r\n")
+
+		writeManifestAttribute(sections, "Name", f.Name)
+		writeManifestAttribute(sections, "SHA-256-Digest", digestBase64(section.Bytes()))
+		sections.WriteString("\r\n")
+
+		manifest.Write(section.Bytes())
+	}
+
+	signature := &bytes.Buffer{}
+	writeManifestAttribute(signature, "Signature-Version", "1.0")
+	writeManifestAttribute(signature, "Created-By", createdBy)
+	writeManifestAttribute(signature, "SHA-256-Digest-Manifest", digestBase64(manifest.Bytes()))
+	// Tells the v1 verifier that the APK is also signed with the v2 scheme, so
+	// stripping the v2 signature is detected.
+	writeManifestAttribute(signature, "X-Android-APK-Signed", "2")
+	signature.WriteString("\r\n")
+	signature.Write(sections.Bytes())
+
+	block, err := signatureBlockFor(signature.Bytes(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := func(name string, data []byte) File {
+		return File{Name: name, Method: zip.Deflate, Data: data}
+	}
+	return []File{
+		meta(manifestPath, manifest.Bytes()),
+		meta(signatureFilePath, signature.Bytes()),
+		meta(signatureBlock, block),
+	}, nil
+}
+
+// signatureBlockFor returns the PKCS #7 detached signature of data.
+func signatureBlockFor(data []byte, key *Key) ([]byte, error) {
+	digest := sha256.Sum256(data)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key.PrivateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	sha256Algorithm := pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1Null}
+	signedData, err := asn1.Marshal(pkcs7SignedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256Algorithm},
+		ContentInfo:      pkcs7ContentInfo{ContentType: oidData},
+		Certificates: asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        0,
+			IsCompound: true,
+			Bytes:      key.Certificate.Raw,
+		},
+		SignerInfos: []pkcs7SignerInfo{{
+			Version: 1,
+			IssuerAndSerialNumber: pkcs7IssuerAndSerialNumber{
+				Issuer:       asn1.RawValue{FullBytes: key.Certificate.RawIssuer},
+				SerialNumber: key.Certificate.SerialNumber,
+			},
+			DigestAlgorithm:           sha256Algorithm,
+			DigestEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1Null},
+			EncryptedDigest:           sig,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(pkcs7ContentInfo{
+		ContentType: oidSignedData,
+		Content: asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        0,
+			IsCompound: true,
+			Bytes:      signedData,
+		},
+	})
+}
+
+// writeManifestAttribute writes the JAR manifest attribute to buf, breaking
+// lines longer than the maximum manifest line length between characters.
+func writeManifestAttribute(buf *bytes.Buffer, name, value string) {
+	line := name + ": " + value
+	for first := true; len(line) > 0; first = false {
+		n := maxManifestLineLength
+		if !first {
+			buf.WriteByte(' ')
+			n--
+		}
+		if n >= len(line) {
+			n = len(line)
+		} else {
+			// Don't split a multi-byte character over two lines.
+			for n > 1 && !utf8.RuneStart(line[n]) {
+				n--
+			}
+		}
+		buf.WriteString(line[:n])
+		buf.WriteString("\r\n")
+		line = line[n:]
+	}
+}
+
+func digestBase64(data []byte) string {
+	digest := sha256.Sum256(data)
+	return base64.StdEncoding.EncodeToString(digest[:])
+}
@@ -0,0 +1,159 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apksig
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+
+	"github.com/google/gapid/core/data/binary"
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/os/device"
+)
+
+const (
+	// signingBlockMagic is the magic at the end of the APK Signing Block.
+	signingBlockMagic = "APK Sig Block 42"
+	// v2BlockID is the identifier of the v2 signature in the APK Signing Block.
+	v2BlockID = 0x7109871a
+	// rsaPKCS1SHA256 is the v2 identifier of the RSASSA-PKCS1-v1_5 with SHA2-256
+	// signature algorithm.
+	rsaPKCS1SHA256 = 0x0103
+	// chunkSize is the size of the chunks the signed sections of the APK are
+	// split into for digesting.
+	chunkSize = 1 << 20
+)
+
+// signV2 returns the zip archive apk, with the APK Signing Block holding the
+// v2 signature inserted before the central directory at the given offset.
+func signV2(apk []byte, centralDirectory uint32, key *Key) ([]byte, error) {
+	eocd := apk[len(apk)-endOfCentralDirSize:]
+	digest := v2Digest(apk[:centralDirectory], apk[centralDirectory:len(apk)-endOfCentralDirSize], eocd)
+
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PrivateKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	signedData := build(func(w binary.Writer) {
+		// Digests.
+		writeLengthPrefixed(w, func(w binary.Writer) {
+			writeLengthPrefixed(w, func(w binary.Writer) {
+				w.Uint32(rsaPKCS1SHA256)
+				writeBytes(w, digest)
+			})
+		})
+		// Certificates.
+		writeLengthPrefixed(w, func(w binary.Writer) {
+			writeBytes(w, key.Certificate.Raw)
+		})
+		// Additional attributes.
+		writeLengthPrefixed(w, func(w binary.Writer) {})
+	})
+	hash := sha256.Sum256(signedData)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key.PrivateKey, crypto.SHA256, hash[:])
+	if err != nil {
+		return nil, err
+	}
+	v2Block := build(func(w binary.Writer) {
+		// Signers.
+		writeLengthPrefixed(w, func(w binary.Writer) {
+			writeLengthPrefixed(w, func(w binary.Writer) {
+				writeBytes(w, signedData)
+				// Signatures.
+				writeLengthPrefixed(w, func(w binary.Writer) {
+					writeLengthPrefixed(w, func(w binary.Writer) {
+						w.Uint32(rsaPKCS1SHA256)
+						writeBytes(w, signature)
+					})
+				})
+				writeBytes(w, publicKey)
+			})
+		})
+	})
+
+	pairs := &bytes.Buffer{}
+	w := endian.Writer(pairs, device.LittleEndian)
+	w.Uint64(uint64(4 + len(v2Block)))
+	w.Uint32(v2BlockID)
+	w.Data(v2Block)
+	blockSize := uint64(8 + pairs.Len() + len(signingBlockMagic))
+
+	out := &bytes.Buffer{}
+	w = endian.Writer(out, device.LittleEndian)
+	w.Data(apk[:centralDirectory])
+	w.Uint64(blockSize)
+	w.Data(pairs.Bytes())
+	w.Uint64(blockSize)
+	w.Data([]byte(signingBlockMagic))
+	w.Data(apk[centralDirectory : len(apk)-endOfCentralDirSize])
+	w.Data(eocd[:endOfCentralDirOffsetField])
+	w.Uint32(centralDirectory + uint32(8+blockSize))
+	w.Data(eocd[endOfCentralDirOffsetField+4:])
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// v2Digest returns the v2 digest of the contents of the zip entries, the
+// central directory and the end of central directory record. The central
+// directory offset in the end of central directory record must be the offset
+// of the APK Signing Block, which is the same as the offset of the central
+// directory of the unsigned APK.
+func v2Digest(sections ...[]byte) []byte {
+	digests := &bytes.Buffer{}
+	count := uint32(0)
+	for _, section := range sections {
+		for len(section) > 0 {
+			n := chunkSize
+			if n > len(section) {
+				n = len(section)
+			}
+			digests.Write(chunkDigest(0xa5, uint32(n), section[:n]))
+			section = section[n:]
+			count++
+		}
+	}
+	return chunkDigest(0x5a, count, digests.Bytes())
+}
+
+func chunkDigest(prefix byte, size uint32, data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{prefix, byte(size), byte(size >> 8), byte(size >> 16), byte(size >> 24)})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// build returns the data written by f.
+func build(f func(w binary.Writer)) []byte {
+	buf := &bytes.Buffer{}
+	f(endian.Writer(buf, device.LittleEndian))
+	return buf.Bytes()
+}
+
+// writeLengthPrefixed writes the data written by f, prefixed with its 32 bit
+// length.
+func writeLengthPrefixed(w binary.Writer, f func(w binary.Writer)) {
+	writeBytes(w, build(f))
+}
+
+func writeBytes(w binary.Writer, data []byte) {
+	w.Uint32(uint32(len(data)))
+	w.Data(data)
+}
@@ -0,0 +1,188 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apksig
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"strings"
+
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/os/device"
+)
+
+// File is a file stored in an APK.
+type File struct {
+	Name         string // path of the file in the APK
+	Method       uint16 // zip.Store or zip.Deflate
+	ModifiedTime uint16 // MS-DOS time
+	ModifiedDate uint16 // MS-DOS date
+	Data         []byte // uncompressed contents
+}
+
+const (
+	// alignment is the alignment of the data of uncompressed files, matching
+	// 'zipalign 4'.
+	alignment = 4
+	// libraryAlignment is the alignment of the data of uncompressed native
+	// libraries, so they can be memory mapped directly from the APK, matching
+	// 'zipalign -p'.
+	libraryAlignment = 4096
+)
+
+// Zip format constants.
+// See: https://pkware.cachefly.net/webdocs/casestudies/APPNOTE.TXT
+const (
+	localFileHeaderSignature   = 0x04034b50
+	centralDirectorySignature  = 0x02014b50
+	endOfCentralDirSignature   = 0x06054b50
+	localFileHeaderSize        = 30
+	endOfCentralDirSize        = 22
+	endOfCentralDirOffsetField = 16
+	zipVersion                 = 20
+	utf8Flag                   = 0x800
+	// alignmentExtraID is the identifier of the extra field used by the
+	// Android tools to pad the local file headers.
+	alignmentExtraID   = 0xd935
+	alignmentExtraSize = 6
+)
+
+// fileAlignment returns the required alignment of the data of the file, or 0
+// if the data does not need to be aligned.
+func fileAlignment(f File) int {
+	switch {
+	case f.Method != zip.Store:
+		return 0
+	case strings.HasPrefix(f.Name, "lib/") && strings.HasSuffix(f.Name, ".so"):
+		return libraryAlignment
+	default:
+		return alignment
+	}
+}
+
+// writeAlignedZip returns the zip archive containing files, with the data of
+// all uncompressed files aligned, along with the offset of the central
+// directory in the archive.
+func writeAlignedZip(files []File) ([]byte, uint32, error) {
+	type entry struct {
+		offset           uint32
+		crc              uint32
+		compressedSize   uint32
+		uncompressedSize uint32
+	}
+	if len(files) > math.MaxUint16 {
+		return nil, 0, fmt.Errorf("Too many files, zip64 is not supported")
+	}
+	entries := make([]entry, len(files))
+
+	buf := &bytes.Buffer{}
+	w := endian.Writer(buf, device.LittleEndian)
+	for i, f := range files {
+		var data []byte
+		switch f.Method {
+		case zip.Store:
+			data = f.Data
+		case zip.Deflate:
+			compressed := &bytes.Buffer{}
+			fw, err := flate.NewWriter(compressed, flate.BestCompression)
+			if err != nil {
+				return nil, 0, err
+			}
+			fw.Write(f.Data)
+			if err := fw.Close(); err != nil {
+				return nil, 0, err
+			}
+			data = compressed.Bytes()
+		default:
+			return nil, 0, fmt.Errorf("Unsupported compression method %d for '%v'", f.Method, f.Name)
+		}
+		if uint64(len(f.Data)) > math.MaxUint32 || uint64(buf.Len()+len(data)) > math.MaxUint32 {
+			return nil, 0, fmt.Errorf("APK too large, zip64 is not supported")
+		}
+
+		extra := []byte{}
+		if align := fileAlignment(f); align > 0 {
+			offset := buf.Len() + localFileHeaderSize + len(f.Name) + alignmentExtraSize
+			padding := (align - offset%align) % align
+			extra = make([]byte, alignmentExtraSize+padding)
+			extra[0], extra[1] = alignmentExtraID&0xff, alignmentExtraID>>8
+			extra[2], extra[3] = byte(len(extra)-4), byte((len(extra)-4)>>8)
+			extra[4], extra[5] = byte(align), byte(align>>8)
+		}
+
+		e := &entries[i]
+		e.offset = uint32(buf.Len())
+		e.crc = crc32.ChecksumIEEE(f.Data)
+		e.compressedSize = uint32(len(data))
+		e.uncompressedSize = uint32(len(f.Data))
+
+		w.Uint32(localFileHeaderSignature)
+		w.Uint16(zipVersion)
+		w.Uint16(utf8Flag)
+		w.Uint16(f.Method)
+		w.Uint16(f.ModifiedTime)
+		w.Uint16(f.ModifiedDate)
+		w.Uint32(e.crc)
+		w.Uint32(e.compressedSize)
+		w.Uint32(e.uncompressedSize)
+		w.Uint16(uint16(len(f.Name)))
+		w.Uint16(uint16(len(extra)))
+		w.Data([]byte(f.Name))
+		w.Data(extra)
+		w.Data(data)
+	}
+
+	centralDirectory := uint32(buf.Len())
+	for i, f := range files {
+		e := entries[i]
+		w.Uint32(centralDirectorySignature)
+		w.Uint16(zipVersion) // Version made by.
+		w.Uint16(zipVersion) // Version needed to extract.
+		w.Uint16(utf8Flag)
+		w.Uint16(f.Method)
+		w.Uint16(f.ModifiedTime)
+		w.Uint16(f.ModifiedDate)
+		w.Uint32(e.crc)
+		w.Uint32(e.compressedSize)
+		w.Uint32(e.uncompressedSize)
+		w.Uint16(uint16(len(f.Name)))
+		w.Uint16(0) // Extra field length.
+		w.Uint16(0) // Comment length.
+		w.Uint16(0) // Disk number.
+		w.Uint16(0) // Internal attributes.
+		w.Uint32(0) // External attributes.
+		w.Uint32(e.offset)
+		w.Data([]byte(f.Name))
+	}
+	centralDirectorySize := uint32(buf.Len()) - centralDirectory
+
+	w.Uint32(endOfCentralDirSignature)
+	w.Uint16(0) // Disk number.
+	w.Uint16(0) // Disk with the central directory.
+	w.Uint16(uint16(len(files)))
+	w.Uint16(uint16(len(files)))
+	w.Uint32(centralDirectorySize)
+	w.Uint32(centralDirectory)
+	w.Uint16(0) // Comment length.
+
+	if err := w.Error(); err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), centralDirectory, nil
+}
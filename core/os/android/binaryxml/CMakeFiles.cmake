@@ -23,6 +23,8 @@ set(files
     decode.go
     decode_test.go
     doc.go
+    document.go
+    document_test.go
     string_pool.go
     value.go
     xml_attribute.go
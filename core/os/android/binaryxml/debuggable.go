@@ -19,52 +19,68 @@ import (
 	"io"
 )
 
-const debuggableAttr uint32 = 0x0101000f
+// application returns the <application/> element of the manifest document.
+func application(d *Document) (*Element, error) {
+	manifest := d.Root()
+	if manifest == nil || manifest.Name() != "manifest" {
+		return nil, fmt.Errorf("Document is not a manifest")
+	}
+	app := manifest.Child("application")
+	if app == nil {
+		return nil, fmt.Errorf("Manifest has no application element")
+	}
+	return app, nil
+}
 
-func startElementVisitor(path string, f func(*xmlContext, *xmlStartElement)) chunkVisitor {
-	return func(ctx *xmlContext, c chunk, when int) {
-		xse, ok := c.(*xmlStartElement)
-		if ok && when == afterContextChange && ctx.path() == path {
-			f(ctx, xse)
+// SetDebuggable sets android:debuggable="true" under the <application/>
+// element of the manifest document.
+func SetDebuggable(d *Document) error {
+	app, err := application(d)
+	if err != nil {
+		return err
+	}
+	return app.SetAttribute(AndroidNamespace, "debuggable", true)
+}
+
+// SetExtractNativeLibs returns a function that sets android:extractNativeLibs
+// under the <application/> element of the manifest document.
+func SetExtractNativeLibs(extract bool) func(*Document) error {
+	return func(d *Document) error {
+		app, err := application(d)
+		if err != nil {
+			return err
 		}
+		return app.SetAttribute(AndroidNamespace, "extractNativeLibs", extract)
+	}
+}
+
+// AddUsesPermission returns a function that adds a <uses-permission/> element
+// for the named permission to the manifest document, if it is not already
+// present.
+func AddUsesPermission(permission string) func(*Document) error {
+	return func(d *Document) error {
+		manifest := d.Root()
+		if manifest == nil || manifest.Name() != "manifest" {
+			return fmt.Errorf("Document is not a manifest")
+		}
+		for _, e := range manifest.ChildrenNamed("uses-permission") {
+			if name, _ := e.Attribute(AndroidNamespace, "name"); name == permission {
+				return nil
+			}
+		}
+		return manifest.AddChild("uses-permission").SetAttribute(AndroidNamespace, "name", permission)
 	}
 }
 
 // setManifestApplicationDebuggable sets android:debuggable="true" under the <application/> element of the manifest.
 // The function returns true on success. It will fail if it cannot find the application element.
 func setManifestApplicationDebuggableAttributeToTrue(xml *xmlTree) (success bool) {
-	success = false
-	xml.visit(startElementVisitor("manifest/application", func(ctx *xmlContext, xse *xmlStartElement) {
-		refDebuggable := xml.ensureAttributeNameMapsToResource(debuggableAttr, "debuggable")
-
-		if at, ok := xse.attributes.forName(refDebuggable); ok {
-			at.typedValue = valIntBoolean(true)
-		} else {
-			xse.addAttribute(&xmlAttribute{
-				namespace:  ctx.strings.ref("http://schemas.android.com/apk/res/android"),
-				name:       refDebuggable,
-				rawValue:   invalidStringPoolRef,
-				typedValue: valIntBoolean(true),
-			})
-		}
-		success = true
-	}))
-	return success
+	return SetDebuggable(&Document{xml}) == nil
 }
 
 // SetDebuggableFlag takes a Reader that produces a manifest binary xml,
 // modifies it to set android:debuggable="true" under the <application/> element
 // and writes it to the provided Writer.
 func SetDebuggableFlag(r io.Reader, w io.Writer) error {
-	tree, err := decodeXmlTree(r)
-	if err != nil {
-		return err
-
-	}
-
-	if !setManifestApplicationDebuggableAttributeToTrue(tree) {
-		return fmt.Errorf("error modifying manifest")
-	}
-	_, err = w.Write(tree.encode())
-	return err
+	return Patch(r, w, SetDebuggable)
 }
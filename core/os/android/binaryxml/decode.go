@@ -89,6 +89,8 @@ type chunk interface {
 	decode(header, data []byte) error
 	xml(*xmlContext) string
 	encode() []byte
+	// refs returns all the string pool references held by the chunk.
+	refs() []stringPoolRef
 }
 
 func decodeXmlTree(r io.Reader) (*xmlTree, error) {
@@ -0,0 +1,321 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package binaryxml
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// AndroidNamespace is the URI of the android XML namespace.
+const AndroidNamespace = "http://schemas.android.com/apk/res/android"
+
+// androidAttributeIDs maps the names of the attributes in the android
+// namespace that can be set to their resource identifiers.
+// See: https://developer.android.com/reference/android/R.attr.html
+var androidAttributeIDs = map[string]uint32{
+	"label":                0x01010001,
+	"icon":                 0x01010002,
+	"name":                 0x01010003,
+	"permission":           0x01010006,
+	"enabled":              0x0101000e,
+	"debuggable":           0x0101000f,
+	"exported":             0x01010010,
+	"process":              0x01010011,
+	"value":                0x01010024,
+	"minSdkVersion":        0x0101020c,
+	"versionCode":          0x0101021b,
+	"versionName":          0x0101021c,
+	"targetSdkVersion":     0x01010270,
+	"maxSdkVersion":        0x01010271,
+	"allowBackup":          0x01010280,
+	"glEsVersion":          0x01010281,
+	"required":             0x0101028e,
+	"extractNativeLibs":    0x010104ea,
+	"usesCleartextTraffic": 0x010104ec,
+}
+
+// Document is a decoded binary XML document that can be modified and encoded
+// back to binary XML.
+type Document struct {
+	tree *xmlTree
+}
+
+// DecodeDocument decodes the binary XML document from data.
+func DecodeDocument(data []byte) (*Document, error) {
+	return ReadDocument(bytes.NewReader(data))
+}
+
+// ReadDocument decodes the binary XML document from r.
+func ReadDocument(r io.Reader) (*Document, error) {
+	tree, err := decodeXmlTree(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{tree}, nil
+}
+
+// Encode returns the document encoded as binary XML. Strings that are no
+// longer used by the document are removed from the string pool.
+func (d *Document) Encode() []byte {
+	return d.tree.encode()
+}
+
+// String returns the document as XML text.
+func (d *Document) String() string {
+	return d.tree.toXmlString()
+}
+
+// Root returns the root element of the document, or nil if the document has
+// no elements.
+func (d *Document) Root() *Element {
+	for _, c := range d.tree.chunks {
+		if start, ok := c.(*xmlStartElement); ok {
+			return &Element{d, start}
+		}
+	}
+	return nil
+}
+
+// Patch reads the binary XML document from r, calls f to modify the document,
+// and then writes the modified document to w.
+func Patch(r io.Reader, w io.Writer, f func(*Document) error) error {
+	doc, err := ReadDocument(r)
+	if err != nil {
+		return err
+	}
+	if err := f(doc); err != nil {
+		return err
+	}
+	_, err = w.Write(doc.Encode())
+	return err
+}
+
+// Element is an element of a Document.
+type Element struct {
+	doc   *Document
+	start *xmlStartElement
+}
+
+// Name returns the name of the element.
+func (e *Element) Name() string {
+	return e.start.name.get()
+}
+
+// Parent returns the parent of the element, or nil if the element is the root
+// of the document.
+func (e *Element) Parent() *Element {
+	begin, _ := e.span()
+	depth := 0
+	for i := begin - 1; i >= 0; i-- {
+		switch c := e.doc.tree.chunks[i].(type) {
+		case *xmlEndElement:
+			depth++
+		case *xmlStartElement:
+			if depth == 0 {
+				return &Element{e.doc, c}
+			}
+			depth--
+		}
+	}
+	return nil
+}
+
+// Children returns the child elements of the element.
+func (e *Element) Children() []*Element {
+	begin, end := e.span()
+	out := []*Element{}
+	depth := 0
+	for _, c := range e.doc.tree.chunks[begin+1 : end] {
+		switch c := c.(type) {
+		case *xmlStartElement:
+			if depth == 0 {
+				out = append(out, &Element{e.doc, c})
+			}
+			depth++
+		case *xmlEndElement:
+			depth--
+		}
+	}
+	return out
+}
+
+// ChildrenNamed returns the child elements of the element with the given name.
+func (e *Element) ChildrenNamed(name string) []*Element {
+	out := []*Element{}
+	for _, c := range e.Children() {
+		if c.Name() == name {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Child returns the first child element with the given name, or nil if there
+// is no such child.
+func (e *Element) Child(name string) *Element {
+	if children := e.ChildrenNamed(name); len(children) > 0 {
+		return children[0]
+	}
+	return nil
+}
+
+// AddChild adds a new element with the given name, and no attributes, as the
+// last child of the element.
+func (e *Element) AddChild(name string) *Element {
+	tree := e.doc.tree
+	_, end := e.span()
+	nameRef := tree.ref(name)
+	start := &xmlStartElement{
+		lineNumber: e.start.lineNumber,
+		comment:    invalidStringPoolRef,
+		namespace:  invalidStringPoolRef,
+		name:       nameRef,
+	}
+	start.setRoot(tree)
+	finish := &xmlEndElement{
+		lineNumber: e.start.lineNumber,
+		comment:    invalidStringPoolRef,
+		namespace:  invalidStringPoolRef,
+		name:       nameRef,
+	}
+	finish.setRoot(tree)
+
+	chunks := append([]chunk{}, tree.chunks[:end]...)
+	chunks = append(chunks, start, finish)
+	tree.chunks = append(chunks, tree.chunks[end:]...)
+	return &Element{e.doc, start}
+}
+
+// Remove removes the element, and all its children, from the document.
+func (e *Element) Remove() {
+	tree := e.doc.tree
+	begin, end := e.span()
+	tree.chunks = append(tree.chunks[:begin], tree.chunks[end+1:]...)
+}
+
+// Attribute returns the value of the attribute with the namespace URI ns and
+// the given name, and whether the attribute was found. Attributes without a
+// namespace are found with an empty ns.
+func (e *Element) Attribute(ns, name string) (string, bool) {
+	at := e.attribute(ns, name)
+	if at == nil {
+		return "", false
+	}
+	if at.rawValue.isValid() {
+		return at.rawValue.get(), true
+	}
+	return at.typedValue.String(), true
+}
+
+// SetAttribute sets the value of the attribute with the namespace URI ns and
+// the given name, adding the attribute if it does not exist.
+// value must be a bool, int or string.
+// Attributes in the android namespace must be known to the package, as they
+// need to be mapped to their resource identifier.
+func (e *Element) SetAttribute(ns, name string, value interface{}) error {
+	tree := e.doc.tree
+
+	var raw stringPoolRef
+	var typed typedValue
+	switch v := value.(type) {
+	case bool:
+		raw, typed = invalidStringPoolRef, valIntBoolean(v)
+	case int:
+		raw, typed = invalidStringPoolRef, valIntDec(v)
+	case string:
+		raw = tree.ref(v)
+		typed = valStringID(raw)
+	default:
+		return fmt.Errorf("Unsupported attribute value type %T", value)
+	}
+
+	if at := e.attribute(ns, name); at != nil {
+		at.rawValue, at.typedValue = raw, typed
+		return nil
+	}
+
+	nameRef := invalidStringPoolRef
+	if ns == AndroidNamespace {
+		id, ok := androidAttributeIDs[name]
+		if !ok {
+			return fmt.Errorf("Unknown resource identifier for attribute android:%v", name)
+		}
+		nameRef = tree.ensureAttributeNameMapsToResource(id, name)
+	} else {
+		nameRef = tree.ref(name)
+	}
+	nsRef := invalidStringPoolRef
+	if ns != "" {
+		nsRef = tree.ref(ns)
+	}
+	e.start.addAttribute(&xmlAttribute{
+		namespace:  nsRef,
+		name:       nameRef,
+		rawValue:   raw,
+		typedValue: typed,
+	})
+	return nil
+}
+
+// RemoveAttribute removes the attribute with the namespace URI ns and the
+// given name, returning true if the attribute was found.
+func (e *Element) RemoveAttribute(ns, name string) bool {
+	for i := range e.start.attributes {
+		if e.start.attributes[i].is(ns, name) {
+			e.start.attributes = append(e.start.attributes[:i], e.start.attributes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Element) attribute(ns, name string) *xmlAttribute {
+	for i := range e.start.attributes {
+		if at := &e.start.attributes[i]; at.is(ns, name) {
+			return at
+		}
+	}
+	return nil
+}
+
+// span returns the indices of the start and end chunks of the element.
+func (e *Element) span() (begin, end int) {
+	chunks := e.doc.tree.chunks
+	begin = -1
+	for i, c := range chunks {
+		if c == chunk(e.start) {
+			begin = i
+			break
+		}
+	}
+	if begin < 0 {
+		panic(fmt.Errorf("Element <%v> is not part of the document", e.Name()))
+	}
+	depth := 0
+	for i := begin; i < len(chunks); i++ {
+		switch chunks[i].(type) {
+		case *xmlStartElement:
+			depth++
+		case *xmlEndElement:
+			depth--
+			if depth == 0 {
+				return begin, i
+			}
+		}
+	}
+	panic(fmt.Errorf("Element <%v> has no end", e.Name()))
+}
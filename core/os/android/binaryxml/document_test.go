@@ -0,0 +1,137 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package binaryxml
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+)
+
+var manifests = []string{
+	"testdata/manifest1.binxml",
+	"testdata/manifest2.binxml",
+	"testdata/manifest3.binxml",
+	"testdata/manifest4.binxml",
+	"testdata/manifest5.binxml",
+	"testdata/manifest6.binxml",
+	"testdata/manifest7.binxml",
+}
+
+func loadDocument(ctx assert.Manager, fn string) *Document {
+	data, err := ioutil.ReadFile(fn)
+	assert.With(ctx).ThatError(err).Succeeded()
+	doc, err := DecodeDocument(data)
+	assert.With(ctx).ThatError(err).Succeeded()
+	return doc
+}
+
+// reencode encodes the document and decodes it again, checking that the
+// re-decoded document is identical.
+func reencode(ctx assert.Manager, doc *Document) *Document {
+	data := doc.Encode()
+	out, err := DecodeDocument(data)
+	assert.With(ctx).ThatError(err).Succeeded()
+	assert.With(ctx).ThatString(out.String()).Equals(doc.String())
+	assert.With(ctx).ThatSlice(out.Encode()).Equals(data)
+	return out
+}
+
+func TestDocumentRoundTrip(t *testing.T) {
+	ctx := assert.Context(t)
+	for _, fn := range manifests {
+		data, err := ioutil.ReadFile(fn)
+		assert.With(ctx).ThatError(err).Succeeded()
+		buf := &bytes.Buffer{}
+		err = Patch(bytes.NewReader(data), buf, func(*Document) error { return nil })
+		assert.With(ctx).ThatError(err).Succeeded()
+		assert.With(ctx).ThatSlice(buf.Bytes()).Equals(data)
+	}
+}
+
+func TestDocumentSetAttribute(t *testing.T) {
+	ctx := assert.Context(t)
+	for _, fn := range manifests {
+		doc := loadDocument(ctx, fn)
+		app := doc.Root().Child("application")
+		assert.With(ctx).That(app).IsNotNil()
+
+		assert.With(ctx).ThatError(SetExtractNativeLibs(false)(doc)).Succeeded()
+		doc = reencode(ctx, doc)
+		app = doc.Root().Child("application")
+		value, ok := app.Attribute(AndroidNamespace, "extractNativeLibs")
+		assert.With(ctx).That(ok).Equals(true)
+		assert.With(ctx).ThatString(value).Equals("false")
+
+		assert.With(ctx).ThatError(app.SetAttribute(AndroidNamespace, "extractNativeLibs", true)).Succeeded()
+		assert.With(ctx).ThatError(app.SetAttribute("", "gapid", "traced")).Succeeded()
+		doc = reencode(ctx, doc)
+		app = doc.Root().Child("application")
+		value, _ = app.Attribute(AndroidNamespace, "extractNativeLibs")
+		assert.With(ctx).ThatString(value).Equals("true")
+		value, _ = app.Attribute("", "gapid")
+		assert.With(ctx).ThatString(value).Equals("traced")
+
+		assert.With(ctx).ThatError(app.SetAttribute(AndroidNamespace, "notAnAttribute", true)).Failed()
+		assert.With(ctx).ThatError(app.SetAttribute("", "gapid", 1.0)).Failed()
+	}
+}
+
+func TestDocumentAddElement(t *testing.T) {
+	ctx := assert.Context(t)
+	const permission = "android.permission.INTERNET_FOR_GAPID"
+	for _, fn := range manifests {
+		doc := loadDocument(ctx, fn)
+		count := len(doc.Root().ChildrenNamed("uses-permission"))
+
+		assert.With(ctx).ThatError(AddUsesPermission(permission)(doc)).Succeeded()
+		assert.With(ctx).ThatError(AddUsesPermission(permission)(doc)).Succeeded()
+		doc = reencode(ctx, doc)
+
+		permissions := doc.Root().ChildrenNamed("uses-permission")
+		assert.With(ctx).That(len(permissions)).Equals(count + 1)
+		added := permissions[len(permissions)-1]
+		name, _ := added.Attribute(AndroidNamespace, "name")
+		assert.With(ctx).ThatString(name).Equals(permission)
+		assert.With(ctx).That(added.Parent().Name()).Equals("manifest")
+		assert.With(ctx).That(len(added.Children())).Equals(0)
+	}
+}
+
+func TestDocumentRemove(t *testing.T) {
+	ctx := assert.Context(t)
+	for _, fn := range manifests {
+		doc := loadDocument(ctx, fn)
+		assert.With(ctx).ThatString(doc.String()).Contains("<application")
+
+		doc.Root().Child("application").Remove()
+		doc = reencode(ctx, doc)
+		assert.With(ctx).ThatString(doc.String()).DoesNotContain("<application")
+		assert.With(ctx).That(doc.Root().Child("application")).IsNil()
+		_, found := doc.tree.strings.find("application")
+		assert.With(ctx).That(found).Equals(false)
+
+		root := doc.Root()
+		_, ok := root.Attribute("", "package")
+		assert.With(ctx).That(ok).Equals(true)
+		assert.With(ctx).That(root.RemoveAttribute("", "package")).Equals(true)
+		assert.With(ctx).That(root.RemoveAttribute("", "package")).Equals(false)
+		doc = reencode(ctx, doc)
+		_, ok = doc.Root().Attribute("", "package")
+		assert.With(ctx).That(ok).Equals(false)
+	}
+}
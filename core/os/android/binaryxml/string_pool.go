@@ -114,6 +114,8 @@ func (c *stringPool) decode(header, data []byte) error {
 
 func (stringPool) xml(*xmlContext) string { return "" }
 
+func (stringPool) refs() []stringPoolRef { return nil }
+
 func utf16EncodeStringPoolEntry(str string) []byte {
 	var b bytes.Buffer
 	w := endian.Writer(&b, device.LittleEndian)
@@ -182,7 +184,7 @@ func (p *stringPool) findFromStringPoolIndex(idx uint32) (stringPoolRef, bool) {
 
 func (p *stringPool) find(str string) (stringPoolRef, bool) {
 	for i, ptr := range p.ptrs {
-		if p.strings[ptr] == str {
+		if ptr != missingString && p.strings[ptr] == str {
 			return stringPoolRef{p, uint32(i)}, true
 		}
 	}
@@ -149,6 +149,14 @@ func (v valNull) encode(w binary.Writer) {
 	w.Uint32(uint32(v))
 }
 
+// valueRefs returns the string pool references held by the value v.
+func valueRefs(v typedValue) []stringPoolRef {
+	if s, ok := v.(valStringID); ok {
+		return []stringPoolRef{stringPoolRef(s)}
+	}
+	return nil
+}
+
 func writeTypedValueHeader(w binary.Writer, ty valueType) {
 	w.Uint16(8)
 	w.Uint8(0)
@@ -83,6 +83,18 @@ func (a *xmlAttribute) decode(r binary.Reader, root *xmlTree) error {
 	return err
 }
 
+func (a *xmlAttribute) refs() []stringPoolRef {
+	return append([]stringPoolRef{a.namespace, a.name, a.rawValue}, valueRefs(a.typedValue)...)
+}
+
+// is returns true if the attribute has the namespace URI ns and the given name.
+func (a *xmlAttribute) is(ns, name string) bool {
+	if a.namespace.isValid() != (ns != "") {
+		return false
+	}
+	return a.name.get() == name && (ns == "" || a.namespace.get() == ns)
+}
+
 func (a *xmlAttribute) encode(w binary.Writer) {
 	a.namespace.encode(w)
 	a.name.encode(w)
@@ -62,6 +62,10 @@ func (c *xmlCData) xml(ctx *xmlContext) string {
 	return b.String()
 }
 
+func (c *xmlCData) refs() []stringPoolRef {
+	return append([]stringPoolRef{c.comment, c.data}, valueRefs(c.typedValue)...)
+}
+
 func (c *xmlCData) encode() []byte {
 	return encodeChunk(resXMLCDataType, func(w binary.Writer) {
 		w.Uint32(c.lineNumber)
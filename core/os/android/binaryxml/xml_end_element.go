@@ -64,6 +64,10 @@ func (c *xmlEndElement) updateContext(ctx *xmlContext) {
 	ctx.indent--
 }
 
+func (c *xmlEndElement) refs() []stringPoolRef {
+	return []stringPoolRef{c.comment, c.namespace, c.name}
+}
+
 func (c *xmlEndElement) encode() []byte {
 	return encodeChunk(resXMLEndElementType, func(w binary.Writer) {
 		w.Uint32(c.lineNumber)
@@ -57,6 +57,10 @@ func (c *xmlEndNamespace) updateContext(ctx *xmlContext) {
 	ctx.stack.pop()
 }
 
+func (c *xmlEndNamespace) refs() []stringPoolRef {
+	return []stringPoolRef{c.comment, c.namespacePrefix, c.namespaceURI}
+}
+
 func (c *xmlEndNamespace) encode() []byte {
 	return encodeChunk(resXMLEndNamespaceType, func(w binary.Writer) {
 		w.Uint32(c.lineNumber)
@@ -49,6 +49,8 @@ func (c *xmlResourceMap) decode(header, data []byte) error {
 
 func (xmlResourceMap) xml(*xmlContext) string { return "" }
 
+func (xmlResourceMap) refs() []stringPoolRef { return nil }
+
 func (c *xmlResourceMap) indexOf(attr uint32) (uint32, bool) {
 	for i, id := range c.ids {
 		if id == attr {
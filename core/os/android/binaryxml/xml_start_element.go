@@ -118,6 +118,14 @@ func (c *xmlStartElement) encode() []byte {
 	})
 }
 
+func (c *xmlStartElement) refs() []stringPoolRef {
+	refs := []stringPoolRef{c.comment, c.namespace, c.name}
+	for _, at := range c.attributes {
+		refs = append(refs, at.refs()...)
+	}
+	return refs
+}
+
 func (c *xmlStartElement) addAttribute(attr *xmlAttribute) {
 	c.attributes = append(c.attributes, *attr)
 	sort.Sort(attributesByResourceId{c.attributes, c.root()})
@@ -55,6 +55,10 @@ func (c *xmlStartNamespace) updateContext(ctx *xmlContext) {
 	ctx.stack.push(c)
 }
 
+func (c *xmlStartNamespace) refs() []stringPoolRef {
+	return []stringPoolRef{c.comment, c.namespacePrefix, c.namespaceURI}
+}
+
 func (c *xmlStartNamespace) encode() []byte {
 	return encodeChunk(resXMLStartNamespaceType, func(w binary.Writer) {
 		w.Uint32(c.lineNumber)
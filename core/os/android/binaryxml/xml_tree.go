@@ -29,6 +29,9 @@ type xmlTree struct {
 	strings     *stringPool
 	resourceMap *xmlResourceMap
 	chunks      []chunk
+	// unreferenced holds the strings of the decoded string pool that were not
+	// referenced by any chunk. These are preserved when the pool is rebuilt.
+	unreferenced []stringPoolRef
 }
 
 func (c xmlTree) xml(ctx *xmlContext) string {
@@ -85,6 +88,7 @@ func (c *xmlTree) decode(header, data []byte) error {
 		switch err {
 		case nil:
 		case io.EOF:
+			c.unreferenced = c.unreferencedStrings()
 			return nil
 		default:
 			return err
@@ -93,7 +97,12 @@ func (c *xmlTree) decode(header, data []byte) error {
 	}
 }
 
+func (xmlTree) refs() []stringPoolRef { return nil }
+
+// encode returns the tree encoded as binary XML. The string pool and resource
+// map are rebuilt before encoding.
 func (c *xmlTree) encode() []byte {
+	c.rebuild()
 	return encodeChunk(resXMLType, func(w binary.Writer) {
 		// No custom header.
 	}, func(w binary.Writer) {
@@ -105,6 +114,77 @@ func (c *xmlTree) encode() []byte {
 	})
 }
 
+// used returns a slice marking the strings in the pool that are referenced by
+// the chunks.
+func (c *xmlTree) used() []bool {
+	used := make([]bool, len(c.strings.strings))
+	for _, chunk := range c.chunks {
+		for _, ref := range chunk.refs() {
+			if ref.isValid() && ref.sp == c.strings {
+				used[c.strings.ptrs[ref.idx]] = true
+			}
+		}
+	}
+	return used
+}
+
+func (c *xmlTree) unreferencedStrings() []stringPoolRef {
+	out := []stringPoolRef{}
+	for i, used := range c.used() {
+		if !used {
+			ref, _ := c.strings.findFromStringPoolIndex(uint32(i))
+			out = append(out, ref)
+		}
+	}
+	return out
+}
+
+// rebuild removes all the strings that are no longer referenced by the chunks
+// from the string pool, along with their resource map entries. The order of the
+// remaining strings is preserved, so rebuilding an unmodified tree does not
+// change it.
+func (c *xmlTree) rebuild() {
+	used := c.used()
+	for _, ref := range c.unreferenced {
+		if ptr := c.strings.ptrs[ref.idx]; ptr != missingString {
+			used[ptr] = true
+		}
+	}
+
+	remap := make([]int, len(used))
+	strings := make([]string, 0, len(used))
+	ids := make([]uint32, 0, len(c.resourceMap.ids))
+	for i, str := range c.strings.strings {
+		if !used[i] {
+			remap[i] = missingString
+			continue
+		}
+		remap[i] = len(strings)
+		strings = append(strings, str)
+		if i < len(c.resourceMap.ids) {
+			ids = append(ids, c.resourceMap.ids[i])
+		}
+	}
+	for i, ptr := range c.strings.ptrs {
+		if ptr != missingString {
+			c.strings.ptrs[i] = remap[ptr]
+		}
+	}
+	c.strings.strings = strings
+	c.resourceMap.ids = ids
+}
+
+// ref returns a reference to the string str in the string pool that is not
+// associated with a resource identifier, adding the string if necessary.
+func (c *xmlTree) ref(str string) stringPoolRef {
+	for i, ptr := range c.strings.ptrs {
+		if ptr != missingString && ptr >= len(c.resourceMap.ids) && c.strings.strings[ptr] == str {
+			return stringPoolRef{c.strings, uint32(i)}
+		}
+	}
+	return c.strings.insertStringAtIndex(str, len(c.strings.strings))
+}
+
 func (c *xmlTree) toXmlString() string {
 	return c.xml(&xmlContext{
 		strings:    c.strings,
@@ -128,18 +128,33 @@ func (p *InstalledPackage) Stop(ctx context.Context) error {
 	return p.Device.Shell("am", "force-stop", p.Name).Run(ctx)
 }
 
-// Path returns the absolute path of the installed package on the device.
+// Path returns the absolute path of the installed package's base APK on the
+// device.
 func (p *InstalledPackage) Path(ctx context.Context) (string, error) {
-	out, err := p.Device.Shell("pm", "path", p.Name).Call(ctx)
+	paths, err := p.Paths(ctx)
 	if err != nil {
 		return "", err
 	}
+	return paths[0], nil
+}
+
+// Paths returns the absolute paths of the installed package's base APK
+// followed by any split APKs on the device.
+func (p *InstalledPackage) Paths(ctx context.Context) ([]string, error) {
+	out, err := p.Device.Shell("pm", "path", p.Name).Call(ctx)
+	if err != nil {
+		return nil, err
+	}
 	prefix := "package:"
-	if !strings.HasPrefix(out, prefix) {
-		return "", fmt.Errorf("Unexpected output: '%s'", out)
+	paths := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, prefix) {
+			return nil, fmt.Errorf("Unexpected output: '%s'", out)
+		}
+		paths = append(paths, line[len(prefix):])
 	}
-	path := out[len(prefix):]
-	return path, err
+	return paths, nil
 }
 
 // FileDir returns the absolute path of the installed packages files directory.
@@ -16,6 +16,7 @@ package text
 
 import (
 	"bytes"
+	"strings"
 )
 
 // SplitArgs splits and returns the string s separated by non-quoted whitespace.
@@ -49,3 +50,31 @@ func SplitArgs(s string) []string {
 	flush()
 	return out
 }
+
+// JoinArgs returns the arguments joined into a single string that SplitArgs
+// splits back into args. Arguments holding spaces, quotes or backslashes are
+// quoted. Empty arguments are dropped, as SplitArgs cannot represent them.
+func JoinArgs(args []string) string {
+	b := bytes.Buffer{}
+	for _, arg := range args {
+		if arg == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteRune(' ')
+		}
+		if !strings.ContainsAny(arg, ` "\`) {
+			b.WriteString(arg)
+			continue
+		}
+		b.WriteRune('"')
+		for _, r := range arg {
+			if r == '"' || r == '\\' {
+				b.WriteRune('\\')
+			}
+			b.WriteRune(r)
+		}
+		b.WriteRune('"')
+	}
+	return b.String()
+}
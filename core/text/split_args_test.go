@@ -52,3 +52,28 @@ func TestSplitArgs(t *testing.T) {
 		assert.For(ctx, "text.SplitArgs(%v)", test.str).ThatSlice(got).Equals(test.expected)
 	}
 }
+
+func TestJoinArgs(t *testing.T) {
+	ctx := log.Testing(t)
+	for _, test := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{`a`, `b`, `c`}, `a b c`},
+		{[]string{`a b`, `c`}, `"a b" c`},
+		{[]string{`/path with/spaces`, `-x`}, `"/path with/spaces" -x`},
+		{[]string{`meow " woof`}, `"meow \" woof"`},
+		{[]string{`\a`, `b\`}, `"\\a" "b\\"`},
+		{[]string{`a`, ``, `b`}, `a b`},
+	} {
+		got := text.JoinArgs(test.args)
+		assert.For(ctx, "text.JoinArgs(%v)", test.args).ThatString(got).Equals(test.expected)
+		nonEmpty := []string{}
+		for _, arg := range test.args {
+			if arg != "" {
+				nonEmpty = append(nonEmpty, arg)
+			}
+		}
+		assert.For(ctx, "text.SplitArgs(text.JoinArgs(%v))", test.args).ThatSlice(text.SplitArgs(got)).Equals(nonEmpty)
+	}
+}
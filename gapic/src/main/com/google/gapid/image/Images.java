@@ -134,13 +134,21 @@ public class Images {
     switch (format.getFormatCase()) {
       case UNCOMPRESSED:
         return getChannelCount(format.getUncompressed().getFormat(), interestedChannels);
+      case PVRTC1:
+        return format.getPvrtc1().getAlpha() ? 4 : 3;
+      case BC4_R_U8_NORM:
+      case BC4_R_S8_NORM:
       case ETC2_R_U11_NORM:
       case ETC2_R_S11_NORM:
         return 1;
+      case BC5_RG_U8_NORM:
+      case BC5_RG_S8_NORM:
       case ETC2_RG_U11_NORM:
       case ETC2_RG_S11_NORM:
         return 2;
       case ATC_RGB_AMD:
+      case BC6H_RGB_U16_FLOAT:
+      case BC6H_RGB_S16_FLOAT:
       case ETC1_RGB_U8_NORM:
       case ETC2_RGB_U8_NORM:
       case S3_DXT1_RGB:
@@ -148,9 +156,11 @@ public class Images {
       case ASTC:
       case ATC_RGBA_EXPLICIT_ALPHA_AMD:
       case ATC_RGBA_INTERPOLATED_ALPHA_AMD:
+      case BC7_RGBA_U8_NORM:
       case ETC2_RGBA_U8_NORM:
       case ETC2_RGBA_U8U8U8U1_NORM:
       case PNG:
+      case PVRTC2:
       case S3_DXT1_RGBA:
       case S3_DXT3_RGBA:
       case S3_DXT5_RGBA:
@@ -175,8 +185,12 @@ public class Images {
     switch (format.getFormatCase()) {
       case UNCOMPRESSED:
         return are8BitsEnough(format.getUncompressed().getFormat(), interestedChannels);
+      case BC6H_RGB_U16_FLOAT:
+      case BC6H_RGB_S16_FLOAT:
+        // BC6H holds HDR half-float data.
+        return false;
       default:
-        // All Compressed formats can fully be represented as 8 bits (at this time).
+        // All other compressed formats can fully be represented as 8 bits.
         return true;
     }
   }
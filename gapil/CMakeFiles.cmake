@@ -23,10 +23,14 @@ set(files
 set(dirs
     analysis
     ast
+    diff
+    doc
     format
     fuzz
+    interpreter
     langsvr
     parser
+    registry
     resolver
     semantic
     template
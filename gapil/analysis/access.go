@@ -0,0 +1,228 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"sort"
+
+	"github.com/google/gapid/gapil/ast"
+	"github.com/google/gapid/gapil/semantic"
+)
+
+// Access holds the API state read and written by a command.
+//
+// The state is identified by paths rooted at the API globals. Fields are
+// separated by '.', and map and array elements are denoted by '[]', for
+// example: "Contexts[].Bound.ArrayBuffer".
+type Access struct {
+	// Reads is the sorted list of the state paths read by the command.
+	Reads []string
+	// Writes is the sorted list of the state paths written by the command.
+	Writes []string
+}
+
+// Accesses returns the state accessed by each of the API commands, including
+// the state accessed by the subroutines they call.
+//
+// Locals and subroutine return values are followed back to the state they
+// were taken from, so writes through references are attributed to the state
+// holding the reference.
+func Accesses(api *semantic.API) map[*semantic.Function]*Access {
+	returns := map[*semantic.Function]*returnPath{}
+	out := make(map[*semantic.Function]*Access, len(api.Functions))
+	for _, f := range api.Functions {
+		a := &accessor{
+			reads:   map[string]struct{}{},
+			writes:  map[string]struct{}{},
+			visited: map[*semantic.Function]struct{}{},
+			returns: returns,
+		}
+		a.function(f)
+		out[f] = &Access{Reads: sorted(a.reads), Writes: sorted(a.writes)}
+	}
+	return out
+}
+
+// returnPath is the state path returned by a subroutine.
+type returnPath struct {
+	path string
+	ok   bool
+}
+
+// accessor collects the state accessed by a command.
+type accessor struct {
+	reads   map[string]struct{}
+	writes  map[string]struct{}
+	visited map[*semantic.Function]struct{}
+	returns map[*semantic.Function]*returnPath
+}
+
+func (a *accessor) function(f *semantic.Function) {
+	if _, ok := a.visited[f]; ok || f.Block == nil {
+		return
+	}
+	a.visited[f] = struct{}{}
+	a.read(f.Block)
+}
+
+// path returns the state path of the expression e, if e is a location in the
+// API state.
+func (a *accessor) path(e semantic.Expression) (string, bool) {
+	switch e := e.(type) {
+	case *semantic.Global:
+		return e.Name(), true
+	case *semantic.Member:
+		if p, ok := a.path(e.Object); ok {
+			return p + "." + e.Field.Name(), true
+		}
+	case *semantic.MapIndex:
+		if p, ok := a.path(e.Map); ok {
+			return p + "[]", true
+		}
+	case *semantic.ArrayIndex:
+		if p, ok := a.path(e.Array); ok {
+			return p + "[]", true
+		}
+	case *semantic.Local:
+		if e.Value != nil {
+			return a.path(e.Value)
+		}
+	case *semantic.Call:
+		r := a.returned(e.Target.Function)
+		return r.path, r.ok
+	}
+	return "", false
+}
+
+// returned returns the state path returned by the subroutine f, if all the
+// return statements of f return the same state path.
+func (a *accessor) returned(f *semantic.Function) *returnPath {
+	if r, ok := a.returns[f]; ok {
+		return r
+	}
+	r := &returnPath{}
+	a.returns[f] = r // Guards against recursion.
+	if f.Block == nil {
+		return r
+	}
+	paths := map[string]struct{}{}
+	all := true
+	var visit func(semantic.Node)
+	visit = func(n semantic.Node) {
+		switch n := n.(type) {
+		case *semantic.Return:
+			if n.Value == nil {
+				return
+			}
+			if p, ok := a.path(n.Value); ok {
+				paths[p] = struct{}{}
+			} else {
+				all = false
+			}
+		case semantic.Statement:
+			semantic.Visit(n, visit)
+		}
+	}
+	visit(f.Block)
+	if all && len(paths) == 1 {
+		for p := range paths {
+			r.path, r.ok = p, true
+		}
+	}
+	return r
+}
+
+// read records the state read by the node n.
+func (a *accessor) read(n semantic.Node) {
+	switch n := n.(type) {
+	case nil, semantic.Type, *semantic.Function, *semantic.Parameter:
+		// Types and declarations are not evaluated.
+	case *semantic.Call:
+		if n.Target.Object != nil {
+			a.read(n.Target.Object)
+		}
+		for _, arg := range n.Arguments {
+			a.read(arg)
+		}
+		a.function(n.Target.Function)
+	case *semantic.Global, *semantic.Member, *semantic.MapIndex, *semantic.ArrayIndex, *semantic.Local:
+		e := n.(semantic.Expression)
+		if p, ok := a.path(e); ok {
+			a.reads[p] = struct{}{}
+			a.indices(e)
+		} else {
+			semantic.Visit(n, a.read)
+		}
+	case *semantic.Assign:
+		if n.Operator != ast.OpAssign {
+			a.read(n.LHS)
+		}
+		a.write(n.LHS, "")
+		a.read(n.RHS)
+	case *semantic.ArrayAssign:
+		a.write(n.To.Array, "[]")
+		a.read(n.To.Index)
+		a.read(n.Value)
+	case *semantic.MapAssign:
+		a.write(n.To.Map, "[]")
+		a.read(n.To.Index)
+		a.read(n.Value)
+	case *semantic.MapRemove:
+		a.write(n.Map, "[]")
+		a.read(n.Key)
+	default:
+		semantic.Visit(n, a.read)
+	}
+}
+
+// write records the write to the location e. suffix is appended to the state
+// path of e.
+func (a *accessor) write(e semantic.Expression, suffix string) {
+	if p, ok := a.path(e); ok {
+		a.writes[p+suffix] = struct{}{}
+		a.indices(e)
+		return
+	}
+	if m, ok := e.(*semantic.Member); ok {
+		// A field of a class value that is not part of the state.
+		a.read(m.Object)
+	}
+}
+
+// indices records the state read by the map and array indices and the
+// subroutine calls of the location e.
+func (a *accessor) indices(e semantic.Expression) {
+	switch e := e.(type) {
+	case *semantic.Member:
+		a.indices(e.Object)
+	case *semantic.MapIndex:
+		a.read(e.Index)
+		a.indices(e.Map)
+	case *semantic.ArrayIndex:
+		a.read(e.Index)
+		a.indices(e.Array)
+	case *semantic.Call:
+		a.read(e)
+	}
+}
+
+func sorted(set map[string]struct{}) []string {
+	out := make([]string, 0, len(set))
+	for s := range set {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,88 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis_test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapil/analysis"
+)
+
+func TestAccesses(t *testing.T) {
+	ctx := log.Testing(t)
+
+	common := `
+class Binding {
+  u32 Buffer
+  u32 Texture
+}
+class Context {
+  Binding Bound
+  map!(u32, u32) Buffers
+}
+map!(u32, ref!Context) Contexts
+u32 Current
+u32 Count
+sub ref!Context GetContext() { return Contexts[Current] }
+`
+
+	for _, test := range []struct {
+		source string
+		reads  []string
+		writes []string
+	}{
+		{
+			`cmd void c() { Count = 1 }`,
+			[]string{},
+			[]string{"Count"},
+		}, {
+			`cmd void c() { Count += 1 }`,
+			[]string{"Count"},
+			[]string{"Count"},
+		}, {
+			`cmd void c(u32 b) { ctx := Contexts[Current]  ctx.Bound.Buffer = b }`,
+			[]string{"Contexts[]", "Current"},
+			[]string{"Contexts[].Bound.Buffer"},
+		}, {
+			`cmd void c(u32 b) { GetContext().Bound.Texture = b }`,
+			[]string{"Contexts[]", "Current"},
+			[]string{"Contexts[].Bound.Texture"},
+		}, {
+			`cmd void c(u32 id) { ctx := GetContext()  ctx.Buffers[id] = ctx.Bound.Buffer }`,
+			[]string{"Contexts[]", "Contexts[].Bound.Buffer", "Current"},
+			[]string{"Contexts[].Buffers[]"},
+		}, {
+			`cmd void c(u32 id) { ctx := GetContext()  delete(ctx.Buffers, id) }`,
+			[]string{"Contexts[]", "Current"},
+			[]string{"Contexts[].Buffers[]"},
+		}, {
+			`cmd void c(u32 id) { if id in Contexts { Count = as!u32(len(Contexts)) } }`,
+			[]string{"Contexts"},
+			[]string{"Count"},
+		},
+	} {
+		api, _, err := compile(ctx, common+" "+test.source)
+		assert.For(ctx, "compile").ThatError(err).Succeeded()
+		if err != nil {
+			continue
+		}
+		accesses := analysis.Accesses(api)
+		access := accesses[api.Functions[0]]
+		assert.For(ctx, "%v reads", test.source).ThatSlice(access.Reads).Equals(test.reads)
+		assert.For(ctx, "%v writes", test.source).ThatSlice(access.Writes).Equals(test.writes)
+	}
+}
@@ -0,0 +1,455 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diff compares two versions of a resolved API.
+//
+// The differences are reported as a list of changes to the commands, types
+// and state of the API. Changes that alter the protobuf messages generated for
+// the commands and serialized classes, or the meaning of the serialized
+// values, are flagged as breaking the decoding of existing captures.
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/gapid/gapil/semantic"
+	"github.com/google/gapid/gapil/semantic/printer"
+)
+
+// Kind is the kind of a Change.
+type Kind int
+
+const (
+	// Added is a declaration only present in the new API.
+	Added Kind = iota
+	// Removed is a declaration only present in the old API.
+	Removed
+	// Changed is a declaration present in both APIs, that differs.
+	Changed
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	default:
+		return fmt.Sprintf("Kind<%d>", int(k))
+	}
+}
+
+// Change is a single difference between two versions of an API.
+type Change struct {
+	Kind     Kind   // The kind of change.
+	Path     string // The changed declaration, for example "cmd glBindBuffer(target)".
+	Message  string // The description of the change.
+	Breaking bool   // True if the change breaks the decoding of existing captures.
+}
+
+func (c Change) String() string {
+	s := fmt.Sprintf("%v %v", c.Kind, c.Path)
+	if c.Message != "" {
+		s += ": " + c.Message
+	}
+	if c.Breaking {
+		s += " [breaks captures]"
+	}
+	return s
+}
+
+// Changes is a list of changes.
+type Changes []Change
+
+func (l Changes) String() string {
+	lines := make([]string, len(l))
+	for i, c := range l {
+		lines[i] = c.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Breaking returns the changes of l that break the decoding of existing
+// captures.
+func (l Changes) Breaking() Changes {
+	out := Changes{}
+	for _, c := range l {
+		if c.Breaking {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (l *Changes) add(kind Kind, path string, breaking bool, msg string, args ...interface{}) {
+	*l = append(*l, Change{Kind: kind, Path: path, Message: fmt.Sprintf(msg, args...), Breaking: breaking})
+}
+
+// Diff returns the changes between the old and new versions of an API.
+func Diff(old, new *semantic.API) Changes {
+	l := Changes{}
+	l.commands(commands(old), commands(new))
+	l.enums(old.Enums, new.Enums)
+	l.classes(old.Classes, new.Classes)
+	l.pseudonyms(old.Pseudonyms, new.Pseudonyms)
+	l.globals(old.Globals, new.Globals)
+	return l
+}
+
+// commands returns all the commands of the API, including the class methods.
+func commands(api *semantic.API) []*semantic.Function {
+	out := append([]*semantic.Function{}, api.Functions...)
+	for _, c := range api.Classes {
+		out = append(out, c.Methods...)
+	}
+	for _, p := range api.Pseudonyms {
+		out = append(out, p.Methods...)
+	}
+	return out
+}
+
+func (l *Changes) commands(old, new []*semantic.Function) {
+	names := map[string]struct{}{}
+	oldByName := map[string]*semantic.Function{}
+	for _, f := range old {
+		oldByName[f.Name()] = f
+		names[f.Name()] = struct{}{}
+	}
+	newByName := map[string]*semantic.Function{}
+	for _, f := range new {
+		newByName[f.Name()] = f
+		names[f.Name()] = struct{}{}
+	}
+	for _, name := range sorted(names) {
+		o, n := oldByName[name], newByName[name]
+		path := "cmd " + name
+		switch {
+		case o == nil:
+			l.add(Added, path, false, "")
+		case n == nil:
+			l.add(Removed, path, true, "")
+		default:
+			l.command(path, o, n)
+		}
+	}
+}
+
+func (l *Changes) command(path string, o, n *semantic.Function) {
+	// The generated command messages start with the thread field.
+	const firstParamID = 2
+	serialized := o.GetAnnotation("pfn") == nil && n.GetAnnotation("pfn") == nil
+	oldParams, newParams := paramFields(o.CallParameters()), paramFields(n.CallParameters())
+	l.fields(path, "parameter", oldParams, newParams, firstParamID, serialized)
+
+	// Commands that did not return a value have no call message.
+	l.types(path, "return type", o.Return.Type, n.Return.Type, serialized && o.Return.Type != semantic.VoidType)
+	l.annotations(path, o.Annotations, n.Annotations)
+}
+
+// field is a named and typed member of a generated message.
+type field struct {
+	name string
+	ty   semantic.Type
+}
+
+func paramFields(l []*semantic.Parameter) []field {
+	out := make([]field, len(l))
+	for i, p := range l {
+		out[i] = field{p.Name(), p.Type}
+	}
+	return out
+}
+
+func classFields(l []*semantic.Field) []field {
+	out := make([]field, len(l))
+	for i, f := range l {
+		out[i] = field{f.Name(), f.Type}
+	}
+	return out
+}
+
+// fields reports the changes between the old and new lists of fields. The
+// fields are numbered from firstID in the generated messages if serialized is
+// true.
+func (l *Changes) fields(path, what string, old, new []field, firstID int, serialized bool) {
+	oldIndex := map[string]int{}
+	for i, f := range old {
+		oldIndex[f.name] = i
+	}
+	newIndex := map[string]int{}
+	for i, f := range new {
+		newIndex[f.name] = i
+	}
+	for i, o := range old {
+		if _, ok := newIndex[o.name]; !ok {
+			l.add(Removed, fmt.Sprintf("%v(%v)", path, o.name), serialized,
+				"%v was field %d", what, firstID+i)
+		}
+	}
+	for j, n := range new {
+		fieldPath := fmt.Sprintf("%v(%v)", path, n.name)
+		i, ok := oldIndex[n.name]
+		if !ok {
+			// Appended fields are decoded from existing captures as zero values.
+			l.add(Added, fieldPath, false, "%v is field %d", what, firstID+j)
+			continue
+		}
+		o := old[i]
+		if i != j {
+			l.add(Changed, fieldPath, serialized, "%v renumbered from field %d to %d", what, firstID+i, firstID+j)
+		}
+		l.types(fieldPath, what+" type", o.ty, n.ty, serialized)
+	}
+}
+
+// types reports the changes between the old and new types of what. Types with
+// the same name may still be encoded differently if a pseudonym they use has
+// changed.
+func (l *Changes) types(path, what string, old, new semantic.Type, serialized bool) {
+	oldName, newName := typeString(old), typeString(new)
+	oldProto, newProto := protoType(old), protoType(new)
+	switch {
+	case oldName != newName:
+		l.add(Changed, path, serialized && oldProto != newProto,
+			"%v changed from %v to %v", what, oldName, newName)
+	case serialized && oldProto != newProto:
+		l.add(Changed, path, true, "%v encoding changed from %v to %v", what, oldProto, newProto)
+	}
+}
+
+func (l *Changes) annotations(path string, old, new semantic.Annotations) {
+	o, n := annotationStrings(old), annotationStrings(new)
+	if strings.Join(o, " ") != strings.Join(n, " ") {
+		l.add(Changed, path, false, "annotations changed from [%v] to [%v]",
+			strings.Join(o, " "), strings.Join(n, " "))
+	}
+}
+
+func (l *Changes) enums(old, new []*semantic.Enum) {
+	names := map[string]struct{}{}
+	oldByName := map[string]*semantic.Enum{}
+	for _, e := range old {
+		oldByName[e.Name()] = e
+		names[e.Name()] = struct{}{}
+	}
+	newByName := map[string]*semantic.Enum{}
+	for _, e := range new {
+		newByName[e.Name()] = e
+		names[e.Name()] = struct{}{}
+	}
+	for _, name := range sorted(names) {
+		o, n := oldByName[name], newByName[name]
+		kind := "enum "
+		if (o != nil && o.IsBitfield) || (n != nil && n.IsBitfield) {
+			kind = "bitfield "
+		}
+		path := kind + name
+		switch {
+		case o == nil:
+			l.add(Added, path, false, "")
+		case n == nil:
+			l.add(Removed, path, false, "")
+		default:
+			if o.IsBitfield != n.IsBitfield {
+				l.add(Changed, path, false, "changed between enum and bitfield")
+			}
+			l.entries(path, o.Entries, n.Entries)
+		}
+	}
+}
+
+func (l *Changes) entries(path string, old, new []*semantic.EnumEntry) {
+	names := map[string]struct{}{}
+	oldByName := map[string]*semantic.EnumEntry{}
+	for _, e := range old {
+		oldByName[e.Name()] = e
+		names[e.Name()] = struct{}{}
+	}
+	newByName := map[string]*semantic.EnumEntry{}
+	for _, e := range new {
+		newByName[e.Name()] = e
+		names[e.Name()] = struct{}{}
+	}
+	for _, name := range sorted(names) {
+		o, n := oldByName[name], newByName[name]
+		entryPath := path + "." + name
+		switch {
+		case o == nil:
+			l.add(Added, entryPath, false, "value 0x%x", n.Value)
+		case n == nil:
+			l.add(Removed, entryPath, false, "value 0x%x", o.Value)
+		case o.Value != n.Value:
+			// Captures hold the enum values, so they would decode as a
+			// different entry.
+			l.add(Changed, entryPath, true, "value changed from 0x%x to 0x%x", o.Value, n.Value)
+		}
+	}
+}
+
+func (l *Changes) classes(old, new []*semantic.Class) {
+	names := map[string]struct{}{}
+	oldByName := map[string]*semantic.Class{}
+	for _, c := range old {
+		oldByName[c.Name()] = c
+		names[c.Name()] = struct{}{}
+	}
+	newByName := map[string]*semantic.Class{}
+	for _, c := range new {
+		newByName[c.Name()] = c
+		names[c.Name()] = struct{}{}
+	}
+	for _, name := range sorted(names) {
+		o, n := oldByName[name], newByName[name]
+		path := "class " + name
+		switch {
+		case o == nil:
+			l.add(Added, path, false, "")
+		case n == nil:
+			l.add(Removed, path, o.GetAnnotation("serialize") != nil, "")
+		default:
+			// Only the classes annotated with @serialize have generated messages.
+			serialized := o.GetAnnotation("serialize") != nil && n.GetAnnotation("serialize") != nil
+			l.fields(path, "field", classFields(o.Fields), classFields(n.Fields), 1, serialized)
+			l.annotations(path, o.Annotations, n.Annotations)
+		}
+	}
+}
+
+func (l *Changes) pseudonyms(old, new []*semantic.Pseudonym) {
+	names := map[string]struct{}{}
+	oldByName := map[string]*semantic.Pseudonym{}
+	for _, p := range old {
+		oldByName[p.Name()] = p
+		names[p.Name()] = struct{}{}
+	}
+	newByName := map[string]*semantic.Pseudonym{}
+	for _, p := range new {
+		newByName[p.Name()] = p
+		names[p.Name()] = struct{}{}
+	}
+	for _, name := range sorted(names) {
+		o, n := oldByName[name], newByName[name]
+		path := "type " + name
+		switch {
+		case o == nil:
+			l.add(Added, path, false, "")
+		case n == nil:
+			l.add(Removed, path, false, "")
+		case typeString(o.To) != typeString(n.To):
+			// The uses of the type in the generated messages are reported
+			// separately.
+			l.add(Changed, path, false, "underlying type changed from %v to %v", typeString(o.To), typeString(n.To))
+		}
+	}
+}
+
+func (l *Changes) globals(old, new []*semantic.Global) {
+	names := map[string]struct{}{}
+	oldByName := map[string]*semantic.Global{}
+	for _, g := range old {
+		oldByName[g.Name()] = g
+		names[g.Name()] = struct{}{}
+	}
+	newByName := map[string]*semantic.Global{}
+	for _, g := range new {
+		newByName[g.Name()] = g
+		names[g.Name()] = struct{}{}
+	}
+	for _, name := range sorted(names) {
+		o, n := oldByName[name], newByName[name]
+		path := "global " + name
+		switch {
+		case o == nil:
+			l.add(Added, path, false, "")
+		case n == nil:
+			l.add(Removed, path, false, "")
+		case typeString(o.Type) != typeString(n.Type):
+			l.add(Changed, path, false, "type changed from %v to %v", typeString(o.Type), typeString(n.Type))
+		}
+	}
+}
+
+func sorted(set map[string]struct{}) []string {
+	out := make([]string, 0, len(set))
+	for n := range set {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func typeString(t semantic.Type) string {
+	return printer.New().WriteType(t).String()
+}
+
+func annotationStrings(l semantic.Annotations) []string {
+	out := make([]string, len(l))
+	for i, a := range l {
+		s := "@" + a.Name()
+		if len(a.Arguments) > 0 {
+			args := make([]string, len(a.Arguments))
+			for j, arg := range a.Arguments {
+				args[j] = printer.New().WriteExpression(arg).String()
+			}
+			s += "(" + strings.Join(args, ", ") + ")"
+		}
+		out[i] = s
+	}
+	return out
+}
+
+// protoType returns the type used to encode t in the generated protobuf
+// messages. Types with the same protoType are wire compatible.
+func protoType(t semantic.Type) string {
+	switch t := t.(type) {
+	case *semantic.Builtin:
+		switch t {
+		case semantic.IntType:
+			return "sint64"
+		case semantic.UintType, semantic.SizeType, semantic.Uint64Type:
+			return "uint64"
+		case semantic.CharType:
+			return "int32"
+		case semantic.Uint8Type, semantic.Uint16Type, semantic.Uint32Type:
+			return "uint32"
+		case semantic.Int8Type, semantic.Int16Type, semantic.Int32Type:
+			return "sint32"
+		case semantic.Int64Type:
+			return "int64"
+		case semantic.Float32Type:
+			return "float"
+		case semantic.Float64Type:
+			return "double"
+		}
+		return t.Name()
+	case *semantic.Pseudonym:
+		return protoType(t.To)
+	case *semantic.Enum:
+		return "uint32"
+	case *semantic.Pointer:
+		return "memory_pb.Pointer"
+	case *semantic.Slice:
+		return "memory_pb.Slice"
+	case *semantic.StaticArray:
+		return "repeated " + protoType(t.ValueType)
+	case *semantic.Map:
+		return fmt.Sprintf("repeated (%v, %v)", protoType(t.KeyType), protoType(t.ValueType))
+	default:
+		return typeString(t)
+	}
+}
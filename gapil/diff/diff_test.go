@@ -0,0 +1,107 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapil"
+	"github.com/google/gapid/gapil/ast"
+	"github.com/google/gapid/gapil/diff"
+	"github.com/google/gapid/gapil/parser"
+	"github.com/google/gapid/gapil/resolver"
+	"github.com/google/gapid/gapil/semantic"
+)
+
+func compile(ctx context.Context, source string) *semantic.API {
+	const maxErrors = 10
+	mappings := resolver.NewMappings()
+	parsed, errs := parser.Parse("diff_test.api", source, mappings)
+	assert.For(ctx, "parse").ThatError(gapil.CheckErrors(source, errs, maxErrors)).Succeeded()
+	api, errs := resolver.Resolve([]*ast.API{parsed}, mappings)
+	assert.For(ctx, "resolve").ThatError(gapil.CheckErrors(source, errs, maxErrors)).Succeeded()
+	return api
+}
+
+func TestDiff(t *testing.T) {
+	ctx := log.Testing(t)
+	old := compile(ctx, `
+enum E {
+  E_A = 1
+  E_B = 2
+  E_C = 3
+}
+type u32 Handle
+@serialize
+class S {
+  u32 A
+  u32 B
+}
+class State {
+  u32 X
+}
+State St
+cmd void a(u32 x, u32 y) {}
+cmd void b(Handle h) {}
+cmd u32 c() { return ? }
+cmd void d() {}
+`)
+	new := compile(ctx, `
+enum E {
+  E_A = 1
+  E_B = 4
+  E_D = 5
+}
+type u64 Handle
+@serialize
+class S {
+  u32 B
+  u32 A
+  u32 C
+}
+class State {
+  s32 X
+}
+State St
+u32 Count
+@synthetic
+cmd void a(u32 y, u32 x) {}
+cmd void b(Handle h) {}
+cmd u64 c() { return ? }
+cmd void e() {}
+`)
+	changes := diff.Diff(old, new)
+	assert.For(ctx, "changes").ThatSlice(changes).Equals(diff.Changes{
+		{diff.Changed, "cmd a(y)", "parameter renumbered from field 3 to 2", true},
+		{diff.Changed, "cmd a(x)", "parameter renumbered from field 2 to 3", true},
+		{diff.Changed, "cmd a", "annotations changed from [] to [@synthetic]", false},
+		{diff.Changed, "cmd b(h)", "parameter type encoding changed from uint32 to uint64", true},
+		{diff.Changed, "cmd c", "return type changed from u32 to u64", true},
+		{diff.Removed, "cmd d", "", true},
+		{diff.Added, "cmd e", "", false},
+		{diff.Changed, "enum E.E_B", "value changed from 0x2 to 0x4", true},
+		{diff.Removed, "enum E.E_C", "value 0x3", false},
+		{diff.Added, "enum E.E_D", "value 0x5", false},
+		{diff.Changed, "class S(B)", "field renumbered from field 2 to 1", true},
+		{diff.Changed, "class S(A)", "field renumbered from field 1 to 2", true},
+		{diff.Added, "class S(C)", "field is field 3", false},
+		{diff.Changed, "class State(X)", "field type changed from u32 to s32", false},
+		{diff.Changed, "type Handle", "underlying type changed from u32 to u64", false},
+		{diff.Added, "global Count", "", false},
+	})
+}
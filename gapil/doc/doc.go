@@ -0,0 +1,221 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package doc generates reference documentation for a resolved API.
+//
+// The documentation lists every command, class, enum, bitfield, pseudonym and
+// state global of the API, along with their documentation comments and
+// annotations. Types are cross-linked to their declarations, and each command
+// lists the state it reads and writes, as computed by the analysis package.
+package doc
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/google/gapid/gapil/analysis"
+	"github.com/google/gapid/gapil/semantic"
+	"github.com/google/gapid/gapil/semantic/printer"
+)
+
+// Format is an output format for the documentation.
+type Format int
+
+const (
+	// Markdown produces a single markdown document.
+	Markdown Format = iota
+	// HTML produces a single self-contained HTML page.
+	HTML
+)
+
+func (f Format) String() string {
+	switch f {
+	case Markdown:
+		return "markdown"
+	case HTML:
+		return "html"
+	default:
+		return fmt.Sprintf("Format<%d>", int(f))
+	}
+}
+
+// ParseFormat returns the Format with the name s.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "markdown", "md":
+		return Markdown, nil
+	case "html":
+		return HTML, nil
+	default:
+		return 0, fmt.Errorf("Unknown documentation format '%s'", s)
+	}
+}
+
+// document is the root object passed to the templates.
+type document struct {
+	Title     string
+	Commands  []*semantic.Function
+	Classes   []*semantic.Class
+	Enums     []*semantic.Enum
+	Bitfields []*semantic.Enum
+	Types     []*semantic.Pseudonym
+	State     []*semantic.Global
+}
+
+// Write writes the documentation for api to w in the format f.
+func Write(w io.Writer, api *semantic.API, title string, f Format) error {
+	d := &document{Title: title}
+	d.Commands = append(d.Commands, api.Functions...)
+	sort.Slice(d.Commands, func(i, j int) bool { return d.Commands[i].Name() < d.Commands[j].Name() })
+	d.Classes = append(d.Classes, api.Classes...)
+	sort.Slice(d.Classes, func(i, j int) bool { return d.Classes[i].Name() < d.Classes[j].Name() })
+	for _, e := range api.Enums {
+		if e.IsBitfield {
+			d.Bitfields = append(d.Bitfields, e)
+		} else {
+			d.Enums = append(d.Enums, e)
+		}
+	}
+	sort.Slice(d.Enums, func(i, j int) bool { return d.Enums[i].Name() < d.Enums[j].Name() })
+	sort.Slice(d.Bitfields, func(i, j int) bool { return d.Bitfields[i].Name() < d.Bitfields[j].Name() })
+	d.Types = append(d.Types, api.Pseudonyms...)
+	sort.Slice(d.Types, func(i, j int) bool { return d.Types[i].Name() < d.Types[j].Name() })
+	d.State = append(d.State, api.Globals...)
+	sort.Slice(d.State, func(i, j int) bool { return d.State[i].Name() < d.State[j].Name() })
+
+	accesses := analysis.Accesses(api)
+	funcs := map[string]interface{}{
+		"access":      func(f *semantic.Function) *analysis.Access { return accesses[f] },
+		"annotations": annotations,
+		"params":      func(f *semantic.Function) []*semantic.Parameter { return f.CallParameters() },
+		"value":       value,
+	}
+
+	switch f {
+	case Markdown:
+		funcs["docs"] = func(d semantic.Documentation) string {
+			return strings.Replace(docs(d), "|", `\|`, -1)
+		}
+		funcs["text"] = escapeMarkdown
+		funcs["type"] = func(t semantic.Type) string { return typeString(t, markdownLink, escapeMarkdown) }
+		funcs["state"] = func(path string) string { return markdownLink(path, stateAnchor(path)) }
+		t, err := template.New("markdown").Funcs(funcs).Parse(markdownTemplate)
+		if err != nil {
+			return err
+		}
+		return t.Execute(w, d)
+	case HTML:
+		funcs["docs"] = docs
+		funcs["type"] = func(t semantic.Type) htmltemplate.HTML {
+			return htmltemplate.HTML(typeString(t, htmlLink, htmltemplate.HTMLEscapeString))
+		}
+		funcs["state"] = func(path string) htmltemplate.HTML {
+			return htmltemplate.HTML(htmlLink(path, stateAnchor(path)))
+		}
+		t, err := htmltemplate.New("html").Funcs(funcs).Parse(htmlTemplate)
+		if err != nil {
+			return err
+		}
+		return t.Execute(w, d)
+	default:
+		return fmt.Errorf("Unsupported documentation format %v", f)
+	}
+}
+
+// typeString returns the rendering of the type t, using link to link the
+// declared types to their documentation and escape to escape the rest.
+func typeString(t semantic.Type, link func(name, anchor string) string, escape func(string) string) string {
+	switch t := t.(type) {
+	case *semantic.Class:
+		return link(t.Name(), "class-"+t.Name())
+	case *semantic.Enum:
+		return link(t.Name(), "enum-"+t.Name())
+	case *semantic.Pseudonym:
+		return link(t.Name(), "type-"+t.Name())
+	case *semantic.Pointer:
+		s := typeString(t.To, link, escape) + escape("*")
+		if t.Const {
+			s = "const " + s
+		}
+		return s
+	case *semantic.Slice:
+		return typeString(t.To, link, escape) + escape("[]")
+	case *semantic.StaticArray:
+		return typeString(t.ValueType, link, escape) + escape(fmt.Sprintf("[%d]", t.Size))
+	case *semantic.Map:
+		return escape("map!(") + typeString(t.KeyType, link, escape) + ", " +
+			typeString(t.ValueType, link, escape) + ")"
+	case *semantic.Reference:
+		return escape("ref!") + typeString(t.To, link, escape)
+	default:
+		return escape(printer.New().WriteType(t).String())
+	}
+}
+
+// stateAnchor returns the anchor of the global at the root of the state path.
+func stateAnchor(path string) string {
+	if i := strings.IndexAny(path, ".["); i >= 0 {
+		path = path[:i]
+	}
+	return "state-" + path
+}
+
+func markdownLink(name, anchor string) string {
+	return fmt.Sprintf("[%s](#%s)", escapeMarkdown(name), anchor)
+}
+
+func htmlLink(name, anchor string) string {
+	return fmt.Sprintf(`<a href="#%s">%s</a>`, anchor, htmltemplate.HTMLEscapeString(name))
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "|", `\|`, "!", `\!`,
+)
+
+func escapeMarkdown(s string) string { return markdownEscaper.Replace(s) }
+
+// docs returns the documentation lines of d as a single line.
+func docs(d semantic.Documentation) string {
+	return strings.TrimSpace(strings.Join(d, " "))
+}
+
+// annotations returns the source form of each of the annotations a.
+func annotations(a semantic.Annotations) []string {
+	out := make([]string, len(a))
+	for i, a := range a {
+		s := "@" + a.Name()
+		if len(a.Arguments) > 0 {
+			args := make([]string, len(a.Arguments))
+			for j, arg := range a.Arguments {
+				args[j] = printer.New().WriteExpression(arg).String()
+			}
+			s += "(" + strings.Join(args, ", ") + ")"
+		}
+		out[i] = s
+	}
+	return out
+}
+
+// value returns the value of the enum entry e, in hexadecimal for bitfields.
+func value(e *semantic.EnumEntry) string {
+	if enum, ok := e.Owner().(*semantic.Enum); ok && enum.IsBitfield {
+		return fmt.Sprintf("0x%x", e.Value)
+	}
+	return fmt.Sprint(e.Value)
+}
@@ -0,0 +1,117 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doc_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapil"
+	"github.com/google/gapid/gapil/ast"
+	"github.com/google/gapid/gapil/doc"
+	"github.com/google/gapid/gapil/parser"
+	"github.com/google/gapid/gapil/resolver"
+)
+
+const source = `
+/// The kind of a thing.
+enum Kind {
+  KIND_A = 1
+  KIND_B = 2
+}
+
+bitfield Flags {
+  FLAG_X = 0x1
+  FLAG_Y = 0x10
+}
+
+/// A handle to a thing.
+type u32 Handle
+
+/// A thing.
+class Thing {
+  /// The kind of the thing.
+  Kind  Kind
+  Flags Flags
+}
+
+map!(Handle, ref!Thing) Things
+
+@synthetic
+/// Creates a thing.
+cmd void createThing(
+    /// The handle of the new thing.
+    Handle h,
+    Kind k) {
+  Things[h] = new!Thing(Kind: k)
+}
+
+@spy_disabled
+cmd Kind thingKind(Handle h) {
+  t := Things[h]
+  return t.Kind
+}
+`
+
+func TestWrite(t *testing.T) {
+	ctx := log.Testing(t)
+	const maxErrors = 10
+	mappings := resolver.NewMappings()
+	parsed, errs := parser.Parse("doc_test.api", source, mappings)
+	assert.For(ctx, "parse").ThatError(gapil.CheckErrors(source, errs, maxErrors)).Succeeded()
+	api, errs := resolver.Resolve([]*ast.API{parsed}, mappings)
+	assert.For(ctx, "resolve").ThatError(gapil.CheckErrors(source, errs, maxErrors)).Succeeded()
+
+	for _, test := range []struct {
+		format   doc.Format
+		expected []string
+	}{
+		{doc.Markdown, []string{
+			"# Test API",
+			`### <a name="cmd-createThing"></a>createThing`,
+			"`@synthetic`",
+			"Creates a thing.",
+			"| h | [Handle](#type-Handle) | The handle of the new thing. |",
+			"**Writes:** [Things\\[\\]](#state-Things)",
+			"`@spy_disabled`",
+			"**Returns:** [Kind](#enum-Kind)",
+			"**Reads:** [Things\\[\\]](#state-Things), [Things\\[\\].Kind](#state-Things)",
+			"| Kind | [Kind](#enum-Kind) | The kind of the thing. |",
+			"| KIND\\_B | 2 |",
+			"| FLAG\\_Y | 0x10 |",
+			`| <a name="type-Handle"></a>Handle | u32 | A handle to a thing. |`,
+			`| <a name="state-Things"></a>Things | map\!(` +
+				"[Handle](#type-Handle), ref\\![Thing](#class-Thing)) |",
+		}},
+		{doc.HTML, []string{
+			"<title>Test API</title>",
+			`<h3 id="cmd-createThing">createThing</h3>`,
+			"<code>@synthetic</code>",
+			`<td>h</td><td><a href="#type-Handle">Handle</a></td>`,
+			`<b>Writes:</b> <a href="#state-Things">Things[]</a>`,
+			`<h3 id="enum-Flags">Flags</h3>`,
+			`<td>FLAG_Y</td><td>0x10</td>`,
+		}},
+	} {
+		buf := &bytes.Buffer{}
+		err := doc.Write(buf, api, "Test API", test.format)
+		assert.For(ctx, "%v write", test.format).ThatError(err).Succeeded()
+		for _, s := range test.expected {
+			assert.For(ctx, "%v output", test.format).ThatString(buf.String()).Contains(s)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doc
+
+const markdownTemplate = `# {{text .Title}}
+
+{{if .Commands}}- [Commands](#commands)
+{{end}}{{if .Classes}}- [Classes](#classes)
+{{end}}{{if .Enums}}- [Enums](#enums)
+{{end}}{{if .Bitfields}}- [Bitfields](#bitfields)
+{{end}}{{if .Types}}- [Types](#types)
+{{end}}{{if .State}}- [State](#state)
+{{end}}
+{{- if .Commands}}
+## Commands
+{{range .Commands}}
+### <a name="cmd-{{.Name}}"></a>{{text .Name}}
+{{with .Annotations}}
+{{range annotations .}}` + "`{{.}}`" + ` {{end}}
+{{end}}{{with docs .Docs}}
+{{.}}
+{{end}}{{with params .}}
+| Parameter | Type | Description |
+| --------- | ---- | ----------- |
+{{range .}}| {{text .Name}} | {{type .Type}} | {{docs .Docs}} |
+{{end}}{{end}}
+**Returns:** {{type .Return.Type}}
+{{with access .}}{{if .Reads}}
+**Reads:** {{range $i, $p := .Reads}}{{if $i}}, {{end}}{{state $p}}{{end}}
+{{end}}{{if .Writes}}
+**Writes:** {{range $i, $p := .Writes}}{{if $i}}, {{end}}{{state $p}}{{end}}
+{{end}}{{end}}{{end}}{{end}}
+{{- if .Classes}}
+## Classes
+{{range .Classes}}
+### <a name="class-{{.Name}}"></a>{{text .Name}}
+{{with .Annotations}}
+{{range annotations .}}` + "`{{.}}`" + ` {{end}}
+{{end}}{{with docs .Docs}}
+{{.}}
+{{end}}{{with .Fields}}
+| Field | Type | Description |
+| ----- | ---- | ----------- |
+{{range .}}| {{text .Name}} | {{type .Type}} | {{docs .Docs}} |
+{{end}}{{end}}{{end}}{{end}}
+{{- if .Enums}}
+## Enums
+{{range .Enums}}{{template "enum" .}}{{end}}{{end}}
+{{- if .Bitfields}}
+## Bitfields
+{{range .Bitfields}}{{template "enum" .}}{{end}}{{end}}
+{{- if .Types}}
+## Types
+
+| Type | Underlying type | Description |
+| ---- | --------------- | ----------- |
+{{range .Types}}| <a name="type-{{.Name}}"></a>{{text .Name}} | {{type .To}} | {{docs .Docs}} |
+{{end}}{{end}}
+{{- if .State}}
+## State
+
+| Global | Type | Annotations |
+| ------ | ---- | ----------- |
+{{range .State}}| <a name="state-{{.Name}}"></a>{{text .Name}} | {{type .Type}} | {{range annotations .Annotations}}` + "`{{.}}`" + ` {{end}}|
+{{end}}{{end}}
+{{- define "enum"}}
+### <a name="enum-{{.Name}}"></a>{{text .Name}}
+{{with .Annotations}}
+{{range annotations .}}` + "`{{.}}`" + ` {{end}}
+{{end}}{{with docs .Docs}}
+{{.}}
+{{end}}{{with .Entries}}
+| Entry | Value | Description |
+| ----- | ----- | ----------- |
+{{range .}}| {{text .Name}} | {{value .}} | {{docs .Docs}} |
+{{end}}{{end}}{{end}}`
+
+const htmlTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: left; vertical-align: top; }
+code { background: #f4f4f4; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<ul>
+{{if .Commands}}<li><a href="#commands">Commands</a></li>{{end}}
+{{if .Classes}}<li><a href="#classes">Classes</a></li>{{end}}
+{{if .Enums}}<li><a href="#enums">Enums</a></li>{{end}}
+{{if .Bitfields}}<li><a href="#bitfields">Bitfields</a></li>{{end}}
+{{if .Types}}<li><a href="#types">Types</a></li>{{end}}
+{{if .State}}<li><a href="#state">State</a></li>{{end}}
+</ul>
+{{if .Commands}}
+<h2 id="commands">Commands</h2>
+{{range .Commands}}
+<h3 id="cmd-{{.Name}}">{{.Name}}</h3>
+{{template "annotations" .Annotations}}
+{{with docs .Docs}}<p>{{.}}</p>{{end}}
+{{with params .}}
+<table>
+<tr><th>Parameter</th><th>Type</th><th>Description</th></tr>
+{{range .}}<tr><td>{{.Name}}</td><td>{{type .Type}}</td><td>{{docs .Docs}}</td></tr>
+{{end}}</table>
+{{end}}
+<p><b>Returns:</b> {{type .Return.Type}}</p>
+{{with access .}}
+{{if .Reads}}<p><b>Reads:</b> {{range $i, $p := .Reads}}{{if $i}}, {{end}}{{state $p}}{{end}}</p>{{end}}
+{{if .Writes}}<p><b>Writes:</b> {{range $i, $p := .Writes}}{{if $i}}, {{end}}{{state $p}}{{end}}</p>{{end}}
+{{end}}
+{{end}}
+{{end}}
+{{if .Classes}}
+<h2 id="classes">Classes</h2>
+{{range .Classes}}
+<h3 id="class-{{.Name}}">{{.Name}}</h3>
+{{template "annotations" .Annotations}}
+{{with docs .Docs}}<p>{{.}}</p>{{end}}
+{{with .Fields}}
+<table>
+<tr><th>Field</th><th>Type</th><th>Description</th></tr>
+{{range .}}<tr><td>{{.Name}}</td><td>{{type .Type}}</td><td>{{docs .Docs}}</td></tr>
+{{end}}</table>
+{{end}}
+{{end}}
+{{end}}
+{{if .Enums}}
+<h2 id="enums">Enums</h2>
+{{range .Enums}}{{template "enum" .}}{{end}}
+{{end}}
+{{if .Bitfields}}
+<h2 id="bitfields">Bitfields</h2>
+{{range .Bitfields}}{{template "enum" .}}{{end}}
+{{end}}
+{{if .Types}}
+<h2 id="types">Types</h2>
+<table>
+<tr><th>Type</th><th>Underlying type</th><th>Description</th></tr>
+{{range .Types}}<tr id="type-{{.Name}}"><td>{{.Name}}</td><td>{{type .To}}</td><td>{{docs .Docs}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .State}}
+<h2 id="state">State</h2>
+<table>
+<tr><th>Global</th><th>Type</th><th>Annotations</th></tr>
+{{range .State}}<tr id="state-{{.Name}}"><td>{{.Name}}</td><td>{{type .Type}}</td><td>{{range annotations .Annotations}}<code>{{.}}</code> {{end}}</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+{{define "annotations"}}{{with .}}<p>{{range annotations .}}<code>{{.}}</code> {{end}}</p>{{end}}{{end}}
+{{define "enum"}}
+<h3 id="enum-{{.Name}}">{{.Name}}</h3>
+{{template "annotations" .Annotations}}
+{{with docs .Docs}}<p>{{.}}</p>{{end}}
+{{with .Entries}}
+<table>
+<tr><th>Entry</th><th>Value</th><th>Description</th></tr>
+{{range .}}<tr><td>{{.Name}}</td><td>{{value .}}</td><td>{{docs .Docs}}</td></tr>
+{{end}}</table>
+{{end}}
+{{end}}`
@@ -0,0 +1,404 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/google/gapid/gapil/ast"
+	"github.com/google/gapid/gapil/semantic"
+	"github.com/google/gapid/gapis/memory"
+)
+
+// isNumber returns true if v is a Go value that can be used as a number.
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, memory.Int,
+		uint, uint8, uint16, uint32, uint64, memory.Uint, memory.Size, memory.Char,
+		float32, float64, Pointer:
+		return true
+	}
+	return false
+}
+
+// isNumeric returns true if values of the type ty are represented by numbers.
+func isNumeric(ty semantic.Type) bool {
+	switch ty := semantic.Underlying(ty).(type) {
+	case *semantic.Builtin:
+		switch ty {
+		case semantic.StringType, semantic.AnyType, semantic.VoidType, semantic.MessageType:
+			return false
+		}
+		return true
+	case *semantic.Enum:
+		return true
+	}
+	return false
+}
+
+func isFloat(ty semantic.Type) bool {
+	ty = semantic.Underlying(ty)
+	return ty == semantic.Float32Type || ty == semantic.Float64Type
+}
+
+func isSigned(ty semantic.Type) bool {
+	switch semantic.Underlying(ty) {
+	case semantic.Int8Type, semantic.Int16Type, semantic.Int32Type, semantic.Int64Type, semantic.IntType:
+		return true
+	}
+	return false
+}
+
+func toUint64(v interface{}) uint64 {
+	switch v := v.(type) {
+	case int:
+		return uint64(v)
+	case int8:
+		return uint64(v)
+	case int16:
+		return uint64(v)
+	case int32:
+		return uint64(v)
+	case int64:
+		return uint64(v)
+	case memory.Int:
+		return uint64(v)
+	case uint:
+		return uint64(v)
+	case uint8:
+		return uint64(v)
+	case uint16:
+		return uint64(v)
+	case uint32:
+		return uint64(v)
+	case uint64:
+		return v
+	case memory.Uint:
+		return uint64(v)
+	case memory.Size:
+		return uint64(v)
+	case memory.Char:
+		return uint64(v)
+	case float32:
+		return uint64(v)
+	case float64:
+		return uint64(v)
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case Pointer:
+		return v.Address
+	}
+	panic(fmt.Errorf("%T is not a number", v))
+}
+
+func toInt64(v interface{}) int64 {
+	switch v := v.(type) {
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case memory.Int:
+		return int64(v)
+	case float32:
+		return int64(v)
+	case float64:
+		return int64(v)
+	}
+	return int64(toUint64(v))
+}
+
+func toFloat64(v interface{}) float64 {
+	switch v := v.(type) {
+	case float32:
+		return float64(v)
+	case float64:
+		return v
+	case int, int8, int16, int32, int64, memory.Int:
+		return float64(toInt64(v))
+	}
+	return float64(toUint64(v))
+}
+
+// convert returns the number v converted to the representation of the
+// numeric type ty.
+func convert(ty semantic.Type, v interface{}) interface{} {
+	switch ty := semantic.Underlying(ty).(type) {
+	case *semantic.Enum:
+		return uint32(toUint64(v))
+	case *semantic.Builtin:
+		switch ty {
+		case semantic.BoolType:
+			if b, ok := v.(bool); ok {
+				return b
+			}
+			return toUint64(v) != 0
+		case semantic.Int8Type:
+			return int8(toInt64(v))
+		case semantic.Int16Type:
+			return int16(toInt64(v))
+		case semantic.Int32Type:
+			return int32(toInt64(v))
+		case semantic.Int64Type:
+			return toInt64(v)
+		case semantic.IntType:
+			return memory.Int(toInt64(v))
+		case semantic.Uint8Type:
+			return uint8(toUint64(v))
+		case semantic.Uint16Type:
+			return uint16(toUint64(v))
+		case semantic.Uint32Type:
+			return uint32(toUint64(v))
+		case semantic.Uint64Type:
+			return toUint64(v)
+		case semantic.UintType:
+			return memory.Uint(toUint64(v))
+		case semantic.SizeType:
+			return memory.Size(toUint64(v))
+		case semantic.CharType:
+			return memory.Char(toUint64(v))
+		case semantic.Float32Type:
+			return float32(toFloat64(v))
+		case semantic.Float64Type:
+			return toFloat64(v)
+		}
+	}
+	panic(fmt.Errorf("Cannot convert %T to %v", v, ty.Name()))
+}
+
+// compare returns -1, 0 or 1 if the number l is respectively less than, equal
+// to or greater than the number r.
+func compare(l, r interface{}) int {
+	_, lf := l.(float32)
+	_, rf := r.(float32)
+	if _, ok := l.(float64); ok || lf {
+		return compareFloat(toFloat64(l), toFloat64(r))
+	}
+	if _, ok := r.(float64); ok || rf {
+		return compareFloat(toFloat64(l), toFloat64(r))
+	}
+	if signed(l) || signed(r) {
+		a, b := toInt64(l), toInt64(r)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	}
+	a, b := toUint64(l), toUint64(r)
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func signed(v interface{}) bool {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, memory.Int:
+		return true
+	}
+	return false
+}
+
+// binaryOp returns the result of the binary operation op applied to l and r.
+// ty is the type of the operands.
+func (f *frame) binaryOp(n semantic.Node, op string, ty semantic.Type, l, r interface{}) interface{} {
+	switch op {
+	case ast.OpEQ:
+		return equal(l, r)
+	case ast.OpNE:
+		return !equal(l, r)
+	case ast.OpLT, ast.OpLE, ast.OpGT, ast.OpGE:
+		var c int
+		if ls, ok := l.(string); ok {
+			rs := r.(string)
+			switch {
+			case ls < rs:
+				c = -1
+			case ls > rs:
+				c = 1
+			}
+		} else {
+			c = compare(l, r)
+		}
+		switch op {
+		case ast.OpLT:
+			return c < 0
+		case ast.OpLE:
+			return c <= 0
+		case ast.OpGT:
+			return c > 0
+		default:
+			return c >= 0
+		}
+	}
+
+	if semantic.Underlying(ty) == semantic.StringType && op == ast.OpPlus {
+		return l.(string) + r.(string)
+	}
+	if !isNumeric(ty) {
+		f.fail(n, "Operator %v is not supported for %v", op, ty.Name())
+	}
+
+	switch {
+	case isFloat(ty):
+		a, b := toFloat64(l), toFloat64(r)
+		switch op {
+		case ast.OpPlus:
+			return convert(ty, a+b)
+		case ast.OpMinus:
+			return convert(ty, a-b)
+		case ast.OpMultiply:
+			return convert(ty, a*b)
+		case ast.OpDivide:
+			return convert(ty, a/b)
+		}
+	case isSigned(ty):
+		a, b := toInt64(l), toInt64(r)
+		switch op {
+		case ast.OpPlus:
+			return convert(ty, a+b)
+		case ast.OpMinus:
+			return convert(ty, a-b)
+		case ast.OpMultiply:
+			return convert(ty, a*b)
+		case ast.OpDivide:
+			if b == 0 {
+				f.fail(n, "Division by zero")
+			}
+			return convert(ty, a/b)
+		case ast.OpBitwiseAnd:
+			return convert(ty, a&b)
+		case ast.OpBitwiseOr:
+			return convert(ty, a|b)
+		case ast.OpBitShiftLeft:
+			return convert(ty, a<<toUint64(r))
+		case ast.OpBitShiftRight:
+			return convert(ty, a>>toUint64(r))
+		}
+	default:
+		a, b := toUint64(l), toUint64(r)
+		switch op {
+		case ast.OpPlus:
+			return convert(ty, a+b)
+		case ast.OpMinus:
+			return convert(ty, a-b)
+		case ast.OpMultiply:
+			return convert(ty, a*b)
+		case ast.OpDivide:
+			if b == 0 {
+				f.fail(n, "Division by zero")
+			}
+			return convert(ty, a/b)
+		case ast.OpBitwiseAnd:
+			return convert(ty, a&b)
+		case ast.OpBitwiseOr:
+			return convert(ty, a|b)
+		case ast.OpBitShiftLeft:
+			return convert(ty, a<<b)
+		case ast.OpBitShiftRight:
+			return convert(ty, a>>b)
+		}
+	}
+	f.fail(n, "Operator %v is not supported for %v", op, ty.Name())
+	return nil
+}
+
+// assignOp returns the value to store for the assignment operator op, given
+// the old and new values.
+func (f *frame) assignOp(n semantic.Node, op string, ty semantic.Type, old, v interface{}) interface{} {
+	switch op {
+	case ast.OpAssign:
+		return copyValue(ty, v)
+	case ast.OpAssignPlus:
+		return f.binaryOp(n, ast.OpPlus, ty, old, v)
+	case ast.OpAssignMinus:
+		return f.binaryOp(n, ast.OpMinus, ty, old, v)
+	}
+	f.fail(n, "Unsupported assignment operator %v", op)
+	return nil
+}
+
+// cast returns the value v of type from converted to the type to.
+func (f *frame) cast(from, to semantic.Type, v interface{}) interface{} {
+	src, dst := semantic.Underlying(from), semantic.Underlying(to)
+	switch dst := dst.(type) {
+	case *semantic.Pointer:
+		switch v := v.(type) {
+		case Pointer:
+			return v
+		case Slice:
+			return Pointer{Address: v.Base, Pool: v.Pool}
+		}
+		if isNumber(v) {
+			return Pointer{Address: toUint64(v), Pool: memory.ApplicationPool}
+		}
+	case *semantic.Slice:
+		switch v := v.(type) {
+		case Slice:
+			if src, ok := src.(*semantic.Slice); ok {
+				if from, to := f.sizeOf(src.To), f.sizeOf(dst.To); from != to && to != 0 {
+					v.Count = v.Count * from / to
+				}
+			}
+			return v
+		case string:
+			return f.stringToSlice(v)
+		}
+	case *semantic.Builtin:
+		if dst == semantic.StringType {
+			switch v := v.(type) {
+			case string:
+				return v
+			case Pointer:
+				return f.readString(v)
+			case Slice:
+				return f.sliceToString(v)
+			}
+		}
+	}
+	if isNumeric(to) && (isNumber(v) || isBool(v)) {
+		return convert(to, v)
+	}
+	return v
+}
+
+func isBool(v interface{}) bool {
+	_, ok := v.(bool)
+	return ok
+}
@@ -0,0 +1,296 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interpreter
+
+import (
+	"github.com/google/gapid/gapil/ast"
+	"github.com/google/gapid/gapil/semantic"
+)
+
+// eval returns the value of the expression e.
+func (f *frame) eval(e semantic.Expression) interface{} {
+	switch e := e.(type) {
+	case semantic.BoolValue:
+		return bool(e)
+	case semantic.StringValue:
+		return string(e)
+	case semantic.Int8Value:
+		return int8(e)
+	case semantic.Uint8Value:
+		return uint8(e)
+	case semantic.Int16Value:
+		return int16(e)
+	case semantic.Uint16Value:
+		return uint16(e)
+	case semantic.Int32Value:
+		return int32(e)
+	case semantic.Uint32Value:
+		return uint32(e)
+	case semantic.Int64Value:
+		return int64(e)
+	case semantic.Uint64Value:
+		return uint64(e)
+	case semantic.Float32Value:
+		return float32(e)
+	case semantic.Float64Value:
+		return float64(e)
+	case *semantic.EnumEntry:
+		return e.Value
+	case *semantic.Label:
+		return f.cast(e.Value.ExpressionType(), e.ExpressionType(), f.eval(e.Value))
+	case *semantic.DefinitionUsage:
+		return f.eval(e.Expression)
+	case *semantic.Null:
+		return f.zero(e.Type)
+	case *semantic.Global:
+		v, ok := f.i.Globals[e]
+		if !ok {
+			f.fail(e, "Global '%v' used before it is initialized", e.Name())
+		}
+		return v
+	case *semantic.Parameter:
+		return f.params[e]
+	case *semantic.Observed:
+		return f.params[e.Parameter]
+	case *semantic.Local:
+		v, ok := f.locals[e]
+		if !ok {
+			f.fail(e, "Local '%v' used before it is declared", e.Name())
+		}
+		return v
+	case *semantic.Unknown:
+		if e.Inferred != nil {
+			return f.eval(e.Inferred)
+		}
+		return nil
+	case *semantic.UnaryOp:
+		if e.Operator == ast.OpNot {
+			return !f.eval(e.Expression).(bool)
+		}
+		f.fail(e, "Unsupported unary operator %v", e.Operator)
+	case *semantic.BinaryOp:
+		return f.evalBinaryOp(e)
+	case *semantic.BitTest:
+		return toUint64(f.eval(e.Bitfield))&toUint64(f.eval(e.Bits)) != 0
+	case *semantic.Cast:
+		return f.cast(e.Object.ExpressionType(), e.Type, f.eval(e.Object))
+	case *semantic.Select:
+		return f.evalSelect(e)
+	case *semantic.Member:
+		return f.object(e.Object)[e.Field]
+	case *semantic.ClassInitializer:
+		return f.evalClassInitializer(e)
+	case *semantic.Create:
+		return f.evalClassInitializer(e.Initializer)
+	case *semantic.New:
+		f.fail(e, "new!%v is not supported", e.Type.To.Name())
+	case *semantic.ArrayInitializer:
+		ty := semantic.Underlying(e.Array).(*semantic.StaticArray)
+		out := make([]interface{}, ty.Size)
+		for i := range out {
+			if i < len(e.Values) {
+				v := e.Values[i]
+				out[i] = copyValue(ty.ValueType, f.cast(v.ExpressionType(), ty.ValueType, f.eval(v)))
+			} else {
+				out[i] = f.zero(ty.ValueType)
+			}
+		}
+		return out
+	case *semantic.MessageValue:
+		m := &Message{Name: e.AST.Name.Value, Arguments: map[string]interface{}{}}
+		for _, a := range e.Arguments {
+			m.Arguments[a.Field.Name()] = f.eval(a.Value)
+		}
+		return m
+	case *semantic.Call:
+		return f.evalCall(e)
+	case *semantic.Length:
+		var n int
+		switch v := f.eval(e.Object).(type) {
+		case Slice:
+			n = int(v.Count)
+		case string:
+			n = len(v)
+		case *Map:
+			n = v.Len()
+		case []interface{}:
+			n = len(v)
+		default:
+			f.fail(e, "Cannot take the length of %T", v)
+		}
+		return convert(e.Type, n)
+	case *semantic.ArrayIndex:
+		array := f.eval(e.Array).([]interface{})
+		return array[f.arrayIndex(e, array)]
+	case *semantic.MapIndex:
+		m := f.evalMap(e.Map)
+		if v, ok := m.Get(f.eval(e.Index)); ok {
+			return v
+		}
+		return f.zero(e.Type.ValueType)
+	case *semantic.MapContains:
+		_, ok := f.evalMap(e.Map).Get(f.eval(e.Key))
+		return ok
+	case *semantic.SliceIndex:
+		s := f.eval(e.Slice).(Slice)
+		return f.load(e, s.Pool, f.sliceElement(e, s), e.Type.To)
+	case *semantic.SliceContains:
+		s := f.eval(e.Slice).(Slice)
+		v := f.eval(e.Value)
+		size := f.sizeOf(e.Type.To)
+		for i := uint64(0); i < s.Count; i++ {
+			if equal(f.load(e, s.Pool, s.Base+i*size, e.Type.To), v) {
+				return true
+			}
+		}
+		return false
+	case *semantic.SliceRange:
+		s := f.eval(e.Slice).(Slice)
+		from, to := f.evalRange(e.Range, 0, s.Count)
+		if from > to || to > s.Count {
+			f.fail(e, "Slice range [%d:%d] out of bounds for slice of %d elements", from, to, s.Count)
+		}
+		s.Base += from * f.sizeOf(e.Type.To)
+		s.Count = to - from
+		return s
+	case *semantic.PointerRange:
+		p := f.eval(e.Pointer).(Pointer)
+		if e.Range.RHS == nil {
+			f.fail(e, "Pointer range must have an upper bound")
+		}
+		from, to := f.evalRange(e.Range, 0, 0)
+		if from > to {
+			f.fail(e, "Invalid pointer range [%d:%d]", from, to)
+		}
+		size := f.sizeOf(e.Type.To)
+		return Slice{Root: p.Address, Base: p.Address + from*size, Count: to - from, Pool: p.Pool}
+	case *semantic.Make:
+		id, _ := f.i.Memory.New()
+		return Slice{Count: toUint64(f.eval(e.Size)), Pool: id}
+	case *semantic.Clone:
+		s := f.eval(e.Slice).(Slice)
+		rng := f.rangeOf(s, e.Type.To)
+		data := f.pool(e, s.Pool).Slice(rng)
+		id, pool := f.i.Memory.New()
+		pool.Write(0, data)
+		return Slice{Count: s.Count, Pool: id}
+	}
+	f.fail(e, "Unsupported expression %T", e)
+	return nil
+}
+
+func (f *frame) evalBinaryOp(e *semantic.BinaryOp) interface{} {
+	switch e.Operator {
+	case ast.OpAnd:
+		return f.eval(e.LHS).(bool) && f.eval(e.RHS).(bool)
+	case ast.OpOr:
+		return f.eval(e.LHS).(bool) || f.eval(e.RHS).(bool)
+	}
+	ty := e.Type
+	switch e.Operator {
+	case ast.OpEQ, ast.OpNE, ast.OpLT, ast.OpLE, ast.OpGT, ast.OpGE:
+		ty = e.LHS.ExpressionType()
+	}
+	return f.binaryOp(e, e.Operator, ty, f.eval(e.LHS), f.eval(e.RHS))
+}
+
+// evalRange returns the bounds of the range r, using from and to for the
+// missing bounds.
+func (f *frame) evalRange(r *semantic.BinaryOp, from, to uint64) (uint64, uint64) {
+	if r.LHS != nil {
+		from = toUint64(f.eval(r.LHS))
+	}
+	if r.RHS != nil {
+		to = toUint64(f.eval(r.RHS))
+	}
+	return from, to
+}
+
+func (f *frame) evalSelect(e *semantic.Select) interface{} {
+	v := f.eval(e.Value)
+	for _, c := range e.Choices {
+		for _, cond := range c.Conditions {
+			if equal(v, f.eval(cond)) {
+				return f.eval(c.Expression)
+			}
+		}
+	}
+	if e.Default != nil {
+		return f.eval(e.Default)
+	}
+	return f.zero(e.Type)
+}
+
+func (f *frame) evalClassInitializer(e *semantic.ClassInitializer) interface{} {
+	o := &Object{Class: e.Class, Fields: make(map[*semantic.Field]interface{}, len(e.Class.Fields))}
+	for i, v := range e.InitialValues() {
+		field := e.Class.Fields[i]
+		if v != nil {
+			o.Fields[field] = copyValue(field.Type, f.cast(v.ExpressionType(), field.Type, f.eval(v)))
+		} else {
+			o.Fields[field] = f.zero(field.Type)
+		}
+	}
+	return o
+}
+
+func (f *frame) evalCall(e *semantic.Call) interface{} {
+	fn := e.Target.Function
+	var this interface{}
+	if e.Target.Object != nil {
+		this = f.eval(e.Target.Object)
+	}
+	args := make([]interface{}, len(e.Arguments))
+	for i, a := range e.Arguments {
+		args[i] = f.eval(a)
+	}
+	return f.invoke(e, fn, this, args, nil)
+}
+
+// object returns the fields of the class value or reference e.
+func (f *frame) object(e semantic.Expression) map[*semantic.Field]interface{} {
+	o, ok := f.eval(e).(*Object)
+	if !ok || o == nil {
+		f.fail(e, "Null dereference")
+	}
+	return o.Fields
+}
+
+func (f *frame) evalMap(e semantic.Expression) *Map {
+	m, ok := f.eval(e).(*Map)
+	if !ok || m == nil {
+		f.fail(e, "Null map")
+	}
+	return m
+}
+
+// arrayIndex returns the bounds checked index of the static array access e.
+func (f *frame) arrayIndex(e *semantic.ArrayIndex, array []interface{}) uint64 {
+	i := toUint64(f.eval(e.Index))
+	if i >= uint64(len(array)) {
+		f.fail(e, "Index %d out of bounds for array of %d elements", i, len(array))
+	}
+	return i
+}
+
+// sliceElement returns the address of the bounds checked element of the slice
+// access e.
+func (f *frame) sliceElement(e *semantic.SliceIndex, s Slice) uint64 {
+	i := toUint64(f.eval(e.Index))
+	if i >= s.Count {
+		f.fail(e, "Index %d out of bounds for slice of %d elements", i, s.Count)
+	}
+	return s.Base + i*f.sizeOf(e.Type.To)
+}
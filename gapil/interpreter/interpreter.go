@@ -0,0 +1,264 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package interpreter executes the semantic tree of an API file by walking it.
+//
+// The interpreter holds a dynamic model of the API state: the API globals and
+// a set of memory pools used by the slice and pointer types. API functions can
+// be called with Interpreter.Call, which executes the body of the function
+// against the state and returns the result.
+//
+// The values held by the interpreter are represented by the following Go
+// types:
+//
+//   bool, s8..s64, u8..u64, f32, f64 → bool, int8..int64, uint8..uint64,
+//                                      float32, float64
+//   int, uint, size, char            → memory.Int, memory.Uint, memory.Size,
+//                                      memory.Char
+//   string                           → string
+//   enum                             → uint32
+//   class, ref!class                 → *Object
+//   map                              → *Map
+//   T[N]                             → []interface{}
+//   T[]                              → Slice
+//   T*                               → Pointer
+//   message                          → *Message
+package interpreter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/core/fault"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapil/resolver"
+	"github.com/google/gapid/gapil/semantic"
+	"github.com/google/gapid/gapis/memory"
+)
+
+// ErrAborted is the error returned by Call when the function executes an
+// abort statement.
+const ErrAborted = fault.Const("Aborted")
+
+// Extern is the implementation of an extern function.
+// args holds the call arguments, in declaration order.
+type Extern func(ctx context.Context, args []interface{}) (interface{}, error)
+
+// Interpreter executes API functions against a dynamic state.
+type Interpreter struct {
+	// API is the API being executed.
+	API *semantic.API
+	// Layout is the memory layout used to encode and decode the values stored
+	// in the memory pools.
+	Layout *device.MemoryLayout
+	// Mappings is used to add source locations to errors. Optional.
+	Mappings *resolver.Mappings
+	// Globals holds the values of the API globals.
+	Globals map[*semantic.Global]interface{}
+	// Memory holds the memory pools referenced by the slices and pointers.
+	Memory memory.Pools
+	// Externs holds the implementations of the API extern functions, keyed by
+	// name. Calling an extern without an implementation fails.
+	Externs map[string]Extern
+	// OnFence, if not nil, is called each time the execution of a function
+	// reaches its fence.
+	OnFence func(ctx context.Context)
+}
+
+// failure is the panic value used to unwind the interpreter stack on error.
+type failure struct{ err error }
+
+// New returns a new Interpreter for the API, with the globals set to their
+// initial values.
+func New(ctx context.Context, api *semantic.API, layout *device.MemoryLayout) (*Interpreter, error) {
+	i := &Interpreter{
+		API:     api,
+		Layout:  layout,
+		Globals: map[*semantic.Global]interface{}{},
+		Memory:  memory.NewPools(),
+		Externs: map[string]Extern{},
+	}
+	err := i.run(ctx, func(f *frame) {
+		for _, g := range api.Globals {
+			if g.Default != nil {
+				i.Globals[g] = f.cast(g.Default.ExpressionType(), g.Type, f.eval(g.Default))
+			} else {
+				i.Globals[g] = f.zero(g.Type)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// Global returns the value of the API global with the given name.
+func (i *Interpreter) Global(name string) (interface{}, bool) {
+	for _, g := range i.API.Globals {
+		if g.Name() == name {
+			v, ok := i.Globals[g]
+			return v, ok
+		}
+	}
+	return nil, false
+}
+
+// Function returns the API function or subroutine with the given name, or nil
+// if there is no function with that name.
+func (i *Interpreter) Function(name string) *semantic.Function {
+	for _, list := range [][]*semantic.Function{i.API.Functions, i.API.Subroutines} {
+		for _, f := range list {
+			if f.Name() == name {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// Call executes the API function or subroutine with the given name and
+// returns its result.
+//
+// args holds the values of the function parameters. Go numbers are converted
+// to the numeric or pointer type of the parameter they are passed for. If the
+// function returns a value, the value observed from the real function call
+// can be passed as an extra argument, and is then used by expressions that
+// infer the result.
+//
+// If the function aborts, the error ErrAborted is returned.
+func (i *Interpreter) Call(ctx context.Context, name string, args ...interface{}) (interface{}, error) {
+	fn := i.Function(name)
+	if fn == nil {
+		return nil, fmt.Errorf("Function '%v' not found", name)
+	}
+	params := fn.CallParameters()
+	returns := fn.Return != nil && fn.Return.Type != semantic.VoidType
+	switch {
+	case len(args) == len(params):
+	case returns && len(args) == len(params)+1:
+	default:
+		return nil, fmt.Errorf("Function '%v' takes %d arguments, got %d", name, len(params), len(args))
+	}
+	var result interface{}
+	err := i.run(ctx, func(f *frame) {
+		values := make([]interface{}, len(params))
+		for j, p := range params {
+			values[j] = f.convertArg(p.Type, args[j])
+		}
+		var observed interface{}
+		if len(args) > len(params) {
+			observed = f.convertArg(fn.Return.Type, args[len(params)])
+		}
+		result = f.invoke(nil, fn, nil, values, observed)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// run calls f with a new frame, returning the error that made the execution
+// fail.
+func (i *Interpreter) run(ctx context.Context, f func(*frame)) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			fail, ok := r.(failure)
+			if !ok {
+				panic(r)
+			}
+			err = fail.err
+		}
+	}()
+	f(i.newFrame(ctx, nil))
+	return nil
+}
+
+// frame holds the state of a function being executed.
+type frame struct {
+	ctx    context.Context
+	i      *Interpreter
+	fn     *semantic.Function
+	params map[*semantic.Parameter]interface{}
+	locals map[*semantic.Local]interface{}
+	result interface{}
+}
+
+func (i *Interpreter) newFrame(ctx context.Context, fn *semantic.Function) *frame {
+	return &frame{
+		ctx:    ctx,
+		i:      i,
+		fn:     fn,
+		params: map[*semantic.Parameter]interface{}{},
+		locals: map[*semantic.Local]interface{}{},
+	}
+}
+
+// fail aborts the execution with an error, adding the source location of n
+// if it is known.
+func (f *frame) fail(n semantic.Node, msg string, args ...interface{}) {
+	err := fmt.Errorf(msg, args...)
+	if m := f.i.Mappings; m != nil && n != nil {
+		if p := m.ParseNode(n); p != nil {
+			err = fmt.Errorf("%v: %v", p.Token().At(), err)
+		}
+	}
+	panic(failure{err})
+}
+
+// invoke executes the function fn with the given this object and arguments
+// in a new frame. observed is the value of the return parameter.
+func (f *frame) invoke(n semantic.Node, fn *semantic.Function, this interface{}, args []interface{}, observed interface{}) interface{} {
+	if fn.Extern {
+		ext, ok := f.i.Externs[fn.Name()]
+		if !ok {
+			f.fail(n, "Extern '%v' is not implemented", fn.Name())
+		}
+		res, err := ext(f.ctx, args)
+		if err != nil {
+			f.fail(n, "Extern '%v' failed: %v", fn.Name(), err)
+		}
+		return res
+	}
+	if fn.Block == nil {
+		f.fail(n, "Function '%v' has no body", fn.Name())
+	}
+	callee := f.i.newFrame(f.ctx, fn)
+	params := fn.CallParameters()
+	if fn.This != nil {
+		callee.params[fn.This] = this
+		params = params[1:]
+	}
+	for j, p := range params {
+		callee.params[p] = copyValue(p.Type, args[j])
+	}
+	if ret := fn.Return; ret != nil && ret.Type != semantic.VoidType {
+		if observed == nil {
+			observed = callee.zero(ret.Type)
+		}
+		callee.params[ret] = observed
+		callee.result = observed
+	}
+	callee.block(fn.Block)
+	return callee.result
+}
+
+// convertArg converts the Go value v passed to Call to the representation of
+// ty.
+func (f *frame) convertArg(ty semantic.Type, v interface{}) interface{} {
+	if isNumber(v) {
+		return f.cast(nil, ty, v)
+	}
+	return v
+}
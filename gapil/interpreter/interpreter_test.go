@@ -0,0 +1,283 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interpreter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapil"
+	"github.com/google/gapid/gapil/ast"
+	"github.com/google/gapid/gapil/interpreter"
+	"github.com/google/gapid/gapil/parser"
+	"github.com/google/gapid/gapil/resolver"
+	"github.com/google/gapid/gapis/memory"
+)
+
+func compile(ctx context.Context, source string) *interpreter.Interpreter {
+	const maxErrors = 10
+	mappings := resolver.NewMappings()
+	parsed, errs := parser.Parse("interpreter_test.api", source, mappings)
+	assert.For(ctx, "parse").ThatError(gapil.CheckErrors(source, errs, maxErrors)).Succeeded()
+	compiled, errs := resolver.Resolve([]*ast.API{parsed}, mappings)
+	assert.For(ctx, "resolve").ThatError(gapil.CheckErrors(source, errs, maxErrors)).Succeeded()
+	i, err := interpreter.New(ctx, compiled, device.Little64)
+	assert.For(ctx, "new").ThatError(err).Succeeded()
+	i.Mappings = mappings
+	return i
+}
+
+func global(ctx context.Context, i *interpreter.Interpreter, name string) interface{} {
+	v, ok := i.Global(name)
+	assert.For(ctx, "global %v", name).That(ok).Equals(true)
+	return v
+}
+
+func call(ctx context.Context, i *interpreter.Interpreter, name string, args ...interface{}) interface{} {
+	res, err := i.Call(ctx, name, args...)
+	assert.For(ctx, "call %v", name).ThatError(err).Succeeded()
+	return res
+}
+
+func TestGlobalsAndSubroutines(t *testing.T) {
+	ctx := log.Testing(t)
+	i := compile(ctx, `
+u32 G = 10
+s32 S
+string Name = "a"
+sub u32 double(u32 x) { return x * 2 }
+cmd void setG(u32 a) { G = double(a) + 1 }
+cmd void add(s32 a) { S += a }
+cmd u32 getG() { return G }
+cmd void concat(string s) { Name = Name + s }
+cmd void loop(u32 n) { for j in 0 .. n { G += j } }
+cmd void branch(u32 a) {
+  switch a {
+    case 0, 1: G = 100
+    default: G = switch a { case 2: 200 default: 300 }
+  }
+}
+`)
+	assert.For(ctx, "initial").That(global(ctx, i, "G")).Equals(uint32(10))
+	assert.For(ctx, "zero").That(global(ctx, i, "S")).Equals(int32(0))
+
+	call(ctx, i, "setG", 4)
+	assert.For(ctx, "setG").That(global(ctx, i, "G")).Equals(uint32(9))
+	assert.For(ctx, "getG").That(call(ctx, i, "getG")).Equals(uint32(9))
+
+	call(ctx, i, "add", -3)
+	call(ctx, i, "add", -4)
+	assert.For(ctx, "add").That(global(ctx, i, "S")).Equals(int32(-7))
+
+	call(ctx, i, "concat", "bc")
+	assert.For(ctx, "concat").That(global(ctx, i, "Name")).Equals("abc")
+
+	call(ctx, i, "setG", 0)
+	call(ctx, i, "loop", 5)
+	assert.For(ctx, "loop").That(global(ctx, i, "G")).Equals(uint32(11))
+
+	for _, test := range []struct {
+		a        uint32
+		expected uint32
+	}{{0, 100}, {1, 100}, {2, 200}, {3, 300}} {
+		call(ctx, i, "branch", test.a)
+		assert.For(ctx, "branch(%v)", test.a).That(global(ctx, i, "G")).Equals(test.expected)
+	}
+}
+
+func TestAbort(t *testing.T) {
+	ctx := log.Testing(t)
+	i := compile(ctx, `
+u32 G
+cmd void c(u32 a) { if a > 2 { abort } G = a }
+`)
+	_, err := i.Call(ctx, "c", 3)
+	assert.For(ctx, "abort").ThatError(err).Equals(interpreter.ErrAborted)
+	assert.For(ctx, "aborted").That(global(ctx, i, "G")).Equals(uint32(0))
+	call(ctx, i, "c", 2)
+	assert.For(ctx, "not aborted").That(global(ctx, i, "G")).Equals(uint32(2))
+
+	_, err = i.Call(ctx, "c")
+	assert.For(ctx, "missing argument").ThatError(err).Failed()
+	_, err = i.Call(ctx, "d")
+	assert.For(ctx, "missing function").ThatError(err).Failed()
+}
+
+func TestClasses(t *testing.T) {
+	ctx := log.Testing(t)
+	i := compile(ctx, `
+class Point {
+  s32 X
+  s32 Y = 2
+}
+Point P
+ref!Point R
+ref!Point Null
+cmd void setP(s32 x) { P.X = x }
+cmd void copyP() { p := P  R = new!Point(X: p.X) }
+cmd void setR(s32 y) { r := R  r.Y = y }
+cmd s32 deref() { return Null.X }
+`)
+	p := global(ctx, i, "P").(*interpreter.Object)
+	assert.For(ctx, "default").That(p.Field("Y")).Equals(int32(2))
+
+	call(ctx, i, "setP", 5)
+	assert.For(ctx, "setP").That(p.Field("X")).Equals(int32(5))
+
+	call(ctx, i, "copyP")
+	r := global(ctx, i, "R").(*interpreter.Object)
+	assert.For(ctx, "new X").That(r.Field("X")).Equals(int32(5))
+	assert.For(ctx, "new Y").That(r.Field("Y")).Equals(int32(2))
+
+	call(ctx, i, "setR", 7)
+	assert.For(ctx, "reference").That(r.Field("Y")).Equals(int32(7))
+	assert.For(ctx, "value").That(p.Field("Y")).Equals(int32(2))
+
+	_, err := i.Call(ctx, "deref")
+	assert.For(ctx, "null dereference").ThatError(err).Failed()
+}
+
+func TestMaps(t *testing.T) {
+	ctx := log.Testing(t)
+	i := compile(ctx, `
+map!(u32, string) M
+u32 Count
+string Joined
+cmd void set(u32 k, string v) { M[k] = v }
+cmd void remove(u32 k) { delete(M, k) }
+cmd bool contains(u32 k) { return k in M }
+cmd string get(u32 k) { return M[k] }
+cmd void join() {
+  Count = as!u32(len(M))
+  Joined = ""
+  for _, k, v in M { Joined = Joined + v }
+}
+`)
+	call(ctx, i, "set", 3, "c")
+	call(ctx, i, "set", 1, "a")
+	call(ctx, i, "set", 2, "b")
+	call(ctx, i, "set", 4, "d")
+	call(ctx, i, "remove", 4)
+
+	assert.For(ctx, "contains").That(call(ctx, i, "contains", 2)).Equals(true)
+	assert.For(ctx, "removed").That(call(ctx, i, "contains", 4)).Equals(false)
+	assert.For(ctx, "get").That(call(ctx, i, "get", 3)).Equals("c")
+	assert.For(ctx, "missing").That(call(ctx, i, "get", 5)).Equals("")
+
+	call(ctx, i, "join")
+	assert.For(ctx, "len").That(global(ctx, i, "Count")).Equals(uint32(3))
+	assert.For(ctx, "ordered iteration").That(global(ctx, i, "Joined")).Equals("abc")
+
+	m := global(ctx, i, "M").(*interpreter.Map)
+	assert.For(ctx, "keys").ThatSlice(m.Keys()).Equals([]interface{}{uint32(1), uint32(2), uint32(3)})
+}
+
+func TestSlices(t *testing.T) {
+	ctx := log.Testing(t)
+	i := compile(ctx, `
+class Pair {
+  u8  A
+  u32 B
+}
+u32[] S
+u32 Sum
+string Str
+cmd void create(u32 n) {
+  S = make!u32(n)
+  for j in 0 .. n { S[j] = j * 10 }
+}
+cmd void sum(u32* p, u32 n) {
+  s := p[0:n]
+  read(s)
+  Sum = 0
+  for j in 0 .. n { Sum += s[j] }
+}
+cmd void store(u32* p, u32 n) {
+  copy(p[0:n], S[1:n + 1])
+  write(p[0:n])
+}
+cmd void setPair(Pair* p) { p[0] = Pair(A: 1, B: 2) }
+cmd void getPair(Pair* p) { Sum = as!u32(p[0].A) + p[0].B }
+cmd void str(char* s) { Str = as!string(s) }
+cmd void outOfBounds() { Sum = S[len(S)] }
+`)
+	call(ctx, i, "create", 4)
+	s := global(ctx, i, "S").(interpreter.Slice)
+	assert.For(ctx, "count").That(s.Count).Equals(uint64(4))
+	assert.For(ctx, "new pool").That(s.Pool == memory.ApplicationPool).Equals(false)
+
+	app := i.Memory.ApplicationPool()
+	var reads, writes []memory.Range
+	app.OnRead = func(r memory.Range) { reads = append(reads, r) }
+	app.OnWrite = func(r memory.Range) { writes = append(writes, r) }
+
+	call(ctx, i, "store", 0x1000, 3)
+	assert.For(ctx, "write").ThatSlice(writes).Equals([]memory.Range{
+		{Base: 0x1000, Size: 12}, // copy()
+		{Base: 0x1000, Size: 12}, // write()
+	})
+	data := make([]byte, 12)
+	assert.With(ctx).ThatError(app.Slice(memory.Range{Base: 0x1000, Size: 12}).Get(ctx, 0, data)).Succeeded()
+	assert.For(ctx, "copied").ThatSlice(data).Equals([]byte{10, 0, 0, 0, 20, 0, 0, 0, 30, 0, 0, 0})
+
+	call(ctx, i, "sum", 0x1000, 3)
+	assert.For(ctx, "read").ThatSlice(reads).Equals([]memory.Range{{Base: 0x1000, Size: 12}})
+	assert.For(ctx, "sum").That(global(ctx, i, "Sum")).Equals(uint32(60))
+
+	call(ctx, i, "setPair", 0x2000)
+	assert.With(ctx).ThatError(app.Slice(memory.Range{Base: 0x2000, Size: 8}).Get(ctx, 0, data[:8])).Succeeded()
+	assert.For(ctx, "struct layout").ThatSlice(data[:8]).Equals([]byte{1, 0, 0, 0, 2, 0, 0, 0})
+	call(ctx, i, "getPair", 0x2000)
+	assert.For(ctx, "struct load").That(global(ctx, i, "Sum")).Equals(uint32(3))
+
+	app.Write(0x3000, memory.Blob([]byte("hello\x00world")))
+	call(ctx, i, "str", 0x3000)
+	assert.For(ctx, "string").That(global(ctx, i, "Str")).Equals("hello")
+
+	_, err := i.Call(ctx, "outOfBounds")
+	assert.For(ctx, "out of bounds").ThatError(err).Failed()
+}
+
+func TestExternsAndFence(t *testing.T) {
+	ctx := log.Testing(t)
+	i := compile(ctx, `
+u32 G
+extern u32 next(u32 a)
+cmd u32 c(u32 a) {
+  G = next(a)
+  fence
+  G = G + 1
+  return ?
+}
+`)
+	_, err := i.Call(ctx, "c", 1)
+	assert.For(ctx, "unimplemented extern").ThatError(err).Failed()
+
+	fenced := uint32(0)
+	i.Externs["next"] = func(ctx context.Context, args []interface{}) (interface{}, error) {
+		return args[0].(uint32) + 1, nil
+	}
+	i.OnFence = func(ctx context.Context) {
+		v, _ := i.Global("G")
+		fenced = v.(uint32)
+	}
+	res := call(ctx, i, "c", 1, 42)
+	assert.For(ctx, "fenced").That(fenced).Equals(uint32(2))
+	assert.For(ctx, "G").That(global(ctx, i, "G")).Equals(uint32(3))
+	assert.For(ctx, "observed").That(res).Equals(uint32(42))
+}
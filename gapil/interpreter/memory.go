@@ -0,0 +1,287 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interpreter
+
+import (
+	"bytes"
+
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/math/u64"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapil/semantic"
+	"github.com/google/gapid/gapis/memory"
+)
+
+// layoutOf returns the layout of the builtin or enum type ty, or nil if ty
+// has no primitive layout.
+func (f *frame) layoutOf(ty semantic.Type) *device.DataTypeLayout {
+	l := f.i.Layout
+	switch ty := semantic.Underlying(ty).(type) {
+	case *semantic.Enum:
+		return l.GetI32()
+	case *semantic.Pointer:
+		return l.GetPointer()
+	case *semantic.Builtin:
+		switch ty {
+		case semantic.BoolType, semantic.Int8Type, semantic.Uint8Type:
+			return l.GetI8()
+		case semantic.Int16Type, semantic.Uint16Type:
+			return l.GetI16()
+		case semantic.Int32Type, semantic.Uint32Type:
+			return l.GetI32()
+		case semantic.Int64Type, semantic.Uint64Type:
+			return l.GetI64()
+		case semantic.IntType, semantic.UintType:
+			return l.GetInteger()
+		case semantic.SizeType:
+			return l.GetSize()
+		case semantic.CharType:
+			return l.GetChar()
+		case semantic.Float32Type:
+			return l.GetF32()
+		case semantic.Float64Type:
+			return l.GetF64()
+		}
+	}
+	return nil
+}
+
+// sizeOf returns the size in bytes of the type ty when stored in memory.
+func (f *frame) sizeOf(ty semantic.Type) uint64 {
+	if l := f.layoutOf(ty); l != nil {
+		return uint64(l.GetSize())
+	}
+	switch ty := semantic.Underlying(ty).(type) {
+	case *semantic.Class:
+		size := uint64(0)
+		for _, field := range ty.Fields {
+			size = u64.AlignUp(size, f.alignOf(field.Type))
+			size += f.sizeOf(field.Type)
+		}
+		return u64.AlignUp(size, f.alignOf(ty))
+	case *semantic.StaticArray:
+		return f.sizeOf(ty.ValueType) * uint64(ty.Size)
+	}
+	f.fail(nil, "Type %v cannot be stored in memory", ty.Name())
+	return 0
+}
+
+// alignOf returns the alignment in bytes of the type ty when stored in memory.
+func (f *frame) alignOf(ty semantic.Type) uint64 {
+	if l := f.layoutOf(ty); l != nil {
+		return uint64(l.GetAlignment())
+	}
+	switch ty := semantic.Underlying(ty).(type) {
+	case *semantic.Class:
+		alignment := uint64(1)
+		for _, field := range ty.Fields {
+			if a := f.alignOf(field.Type); alignment < a {
+				alignment = a
+			}
+		}
+		return alignment
+	case *semantic.StaticArray:
+		return f.alignOf(ty.ValueType)
+	}
+	f.fail(nil, "Type %v cannot be stored in memory", ty.Name())
+	return 0
+}
+
+// encode writes the value v of type ty with e.
+func (f *frame) encode(e *memory.Encoder, ty semantic.Type, v interface{}) {
+	switch ty := semantic.Underlying(ty).(type) {
+	case *semantic.Enum:
+		e.U32(v.(uint32))
+	case *semantic.Pointer:
+		e.Pointer(v.(Pointer).Address)
+	case *semantic.Class:
+		o := v.(*Object)
+		e.Align(f.alignOf(ty))
+		for _, field := range ty.Fields {
+			f.encode(e, field.Type, o.Fields[field])
+		}
+		e.Align(f.alignOf(ty))
+	case *semantic.StaticArray:
+		for _, el := range v.([]interface{}) {
+			f.encode(e, ty.ValueType, el)
+		}
+	case *semantic.Builtin:
+		switch ty {
+		case semantic.BoolType:
+			e.Bool(v.(bool))
+		case semantic.Int8Type:
+			e.I8(v.(int8))
+		case semantic.Uint8Type:
+			e.U8(v.(uint8))
+		case semantic.Int16Type:
+			e.I16(v.(int16))
+		case semantic.Uint16Type:
+			e.U16(v.(uint16))
+		case semantic.Int32Type:
+			e.I32(v.(int32))
+		case semantic.Uint32Type:
+			e.U32(v.(uint32))
+		case semantic.Int64Type:
+			e.I64(v.(int64))
+		case semantic.Uint64Type:
+			e.U64(v.(uint64))
+		case semantic.IntType:
+			e.Int(v.(memory.Int))
+		case semantic.UintType:
+			e.Uint(v.(memory.Uint))
+		case semantic.SizeType:
+			e.Size(v.(memory.Size))
+		case semantic.CharType:
+			e.Char(v.(memory.Char))
+		case semantic.Float32Type:
+			e.F32(v.(float32))
+		case semantic.Float64Type:
+			e.F64(v.(float64))
+		default:
+			f.fail(nil, "Type %v cannot be stored in memory", ty.Name())
+		}
+	default:
+		f.fail(nil, "Type %v cannot be stored in memory", ty.Name())
+	}
+}
+
+// decode reads a value of type ty with d.
+func (f *frame) decode(d *memory.Decoder, ty semantic.Type) interface{} {
+	switch ty := semantic.Underlying(ty).(type) {
+	case *semantic.Enum:
+		return d.U32()
+	case *semantic.Pointer:
+		return Pointer{Address: d.Pointer(), Pool: memory.ApplicationPool}
+	case *semantic.Class:
+		o := &Object{Class: ty, Fields: make(map[*semantic.Field]interface{}, len(ty.Fields))}
+		d.Align(f.alignOf(ty))
+		for _, field := range ty.Fields {
+			o.Fields[field] = f.decode(d, field.Type)
+		}
+		d.Align(f.alignOf(ty))
+		return o
+	case *semantic.StaticArray:
+		out := make([]interface{}, ty.Size)
+		for i := range out {
+			out[i] = f.decode(d, ty.ValueType)
+		}
+		return out
+	case *semantic.Builtin:
+		switch ty {
+		case semantic.BoolType:
+			return d.Bool()
+		case semantic.Int8Type:
+			return d.I8()
+		case semantic.Uint8Type:
+			return d.U8()
+		case semantic.Int16Type:
+			return d.I16()
+		case semantic.Uint16Type:
+			return d.U16()
+		case semantic.Int32Type:
+			return d.I32()
+		case semantic.Uint32Type:
+			return d.U32()
+		case semantic.Int64Type:
+			return d.I64()
+		case semantic.Uint64Type:
+			return d.U64()
+		case semantic.IntType:
+			return d.Int()
+		case semantic.UintType:
+			return d.Uint()
+		case semantic.SizeType:
+			return d.Size()
+		case semantic.CharType:
+			return d.Char()
+		case semantic.Float32Type:
+			return d.F32()
+		case semantic.Float64Type:
+			return d.F64()
+		}
+	}
+	f.fail(nil, "Type %v cannot be loaded from memory", ty.Name())
+	return nil
+}
+
+// pool returns the memory pool with the given identifier.
+func (f *frame) pool(n semantic.Node, id memory.PoolID) *memory.Pool {
+	p, err := f.i.Memory.Get(id)
+	if err != nil {
+		f.fail(n, "%v", err)
+	}
+	return p
+}
+
+// rangeOf returns the memory range of the slice s of elements of type el.
+func (f *frame) rangeOf(s Slice, el semantic.Type) memory.Range {
+	return memory.Range{Base: s.Base, Size: s.Count * f.sizeOf(el)}
+}
+
+// load returns the value of type ty stored at the address addr of the pool.
+func (f *frame) load(n semantic.Node, pool memory.PoolID, addr uint64, ty semantic.Type) interface{} {
+	data := f.pool(n, pool).Slice(memory.Range{Base: addr, Size: f.sizeOf(ty)})
+	d := memory.NewDecoder(endian.Reader(data.NewReader(f.ctx), f.i.Layout.GetEndian()), f.i.Layout)
+	v := f.decode(d, ty)
+	if err := d.Error(); err != nil {
+		f.fail(n, "Failed to load %v at 0x%x: %v", ty.Name(), addr, err)
+	}
+	return v
+}
+
+// store writes the value v of type ty to the address addr of the pool.
+func (f *frame) store(n semantic.Node, pool memory.PoolID, addr uint64, ty semantic.Type, v interface{}) {
+	buf := &bytes.Buffer{}
+	e := memory.NewEncoder(endian.Writer(buf, f.i.Layout.GetEndian()), f.i.Layout)
+	f.encode(e, ty, v)
+	if err := e.Error(); err != nil {
+		f.fail(n, "Failed to store %v at 0x%x: %v", ty.Name(), addr, err)
+	}
+	f.pool(n, pool).Write(addr, memory.Blob(buf.Bytes()))
+}
+
+// bytesOf returns the contents of the memory range of the pool.
+func (f *frame) bytesOf(n semantic.Node, pool memory.PoolID, rng memory.Range) []byte {
+	out := make([]byte, rng.Size)
+	if err := f.pool(n, pool).Slice(rng).Get(f.ctx, 0, out); err != nil {
+		f.fail(n, "Failed to read memory %v: %v", rng, err)
+	}
+	return out
+}
+
+// readString returns the null-terminated string at the pointer p.
+func (f *frame) readString(p Pointer) string {
+	out := []byte{}
+	for addr := p.Address; ; addr++ {
+		c := f.bytesOf(nil, p.Pool, memory.Range{Base: addr, Size: 1})[0]
+		if c == 0 {
+			return string(out)
+		}
+		out = append(out, c)
+	}
+}
+
+// sliceToString returns the characters of the char slice s as a string.
+func (f *frame) sliceToString(s Slice) string {
+	return string(f.bytesOf(nil, s.Pool, memory.Range{Base: s.Base, Size: s.Count}))
+}
+
+// stringToSlice returns a char slice in a new pool holding the characters of
+// str.
+func (f *frame) stringToSlice(str string) Slice {
+	id, pool := f.i.Memory.New()
+	pool.Write(0, memory.Blob([]byte(str)))
+	return Slice{Count: uint64(len(str)), Pool: id}
+}
@@ -0,0 +1,229 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interpreter
+
+import (
+	"github.com/google/gapid/gapil/ast"
+	"github.com/google/gapid/gapil/semantic"
+	"github.com/google/gapid/gapis/memory"
+)
+
+// block executes the statements of the block b, returning true if a return
+// statement was executed.
+func (f *frame) block(b *semantic.Block) bool {
+	if b == nil {
+		return false
+	}
+	for _, s := range b.Statements {
+		if f.exec(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// exec executes the statement s, returning true if a return statement was
+// executed.
+func (f *frame) exec(s semantic.Statement) bool {
+	switch s := s.(type) {
+	case *semantic.Block:
+		return f.block(s)
+	case *semantic.DeclareLocal:
+		l := s.Local
+		f.locals[l] = copyValue(l.Type, f.cast(l.Value.ExpressionType(), l.Type, f.eval(l.Value)))
+	case *semantic.Assign:
+		f.assign(s)
+	case *semantic.ArrayAssign:
+		array := f.eval(s.To.Array).([]interface{})
+		i := f.arrayIndex(s.To, array)
+		ty := s.To.Type.ValueType
+		array[i] = f.assignOp(s, s.Operator, ty, array[i], f.cast(s.Value.ExpressionType(), ty, f.eval(s.Value)))
+	case *semantic.MapAssign:
+		m := f.evalMap(s.To.Map)
+		key := f.eval(s.To.Index)
+		ty := s.To.Type.ValueType
+		old, ok := m.Get(key)
+		if !ok {
+			old = f.zero(ty)
+		}
+		m.Set(key, f.assignOp(s, s.Operator, ty, old, f.cast(s.Value.ExpressionType(), ty, f.eval(s.Value))))
+	case *semantic.MapRemove:
+		f.evalMap(s.Map).Delete(f.eval(s.Key))
+	case *semantic.SliceAssign:
+		sl := f.eval(s.To.Slice).(Slice)
+		addr := f.sliceElement(s.To, sl)
+		ty := s.To.Type.To
+		v := f.cast(s.Value.ExpressionType(), ty, f.eval(s.Value))
+		if s.Operator != ast.OpAssign {
+			v = f.assignOp(s, s.Operator, ty, f.load(s, sl.Pool, addr, ty), v)
+		}
+		f.store(s, sl.Pool, addr, ty, v)
+	case *semantic.Branch:
+		if f.eval(s.Condition).(bool) {
+			return f.block(s.True)
+		}
+		return f.block(s.False)
+	case *semantic.Switch:
+		v := f.eval(s.Value)
+		for _, c := range s.Cases {
+			for _, cond := range c.Conditions {
+				if equal(v, f.eval(cond)) {
+					return f.block(c.Block)
+				}
+			}
+		}
+		return f.block(s.Default)
+	case *semantic.Iteration:
+		return f.iterate(s)
+	case *semantic.MapIteration:
+		m := f.evalMap(s.Map)
+		for i, k := range m.Keys() {
+			v, ok := m.Get(k)
+			if !ok {
+				continue // Removed by a previous iteration.
+			}
+			f.locals[s.IndexIterator] = convert(s.IndexIterator.Type, i)
+			f.locals[s.KeyIterator] = k
+			f.locals[s.ValueIterator] = v
+			if f.block(s.Block) {
+				return true
+			}
+		}
+	case *semantic.Call:
+		f.evalCall(s)
+	case *semantic.Return:
+		if s.Value != nil {
+			ty := s.Function.Return.Type
+			f.result = copyValue(ty, f.cast(s.Value.ExpressionType(), ty, f.eval(s.Value)))
+		}
+		return true
+	case *semantic.Abort:
+		panic(failure{ErrAborted})
+	case *semantic.Assert:
+		if !f.eval(s.Condition).(bool) {
+			f.fail(s, "Assertion failed")
+		}
+	case *semantic.Fence:
+		if c, ok := s.Statement.(*semantic.Copy); ok {
+			// The copy reads the source before the fence and writes the
+			// destination after it.
+			dst, data := f.copySource(c)
+			f.fence()
+			f.copyDestination(c, dst, data)
+		} else {
+			f.fence()
+		}
+	case *semantic.Read:
+		sl := f.eval(s.Slice).(Slice)
+		if cb := f.pool(s, sl.Pool).OnRead; cb != nil {
+			cb(f.rangeOf(sl, elementType(s.Slice)))
+		}
+	case *semantic.Write:
+		sl := f.eval(s.Slice).(Slice)
+		if cb := f.pool(s, sl.Pool).OnWrite; cb != nil {
+			cb(f.rangeOf(sl, elementType(s.Slice)))
+		}
+	case *semantic.Copy:
+		dst, data := f.copySource(s)
+		f.copyDestination(s, dst, data)
+	default:
+		f.fail(s, "Unsupported statement %T", s)
+	}
+	return false
+}
+
+// assign executes the assignment s to a global, parameter or class field.
+func (f *frame) assign(s *semantic.Assign) {
+	lhs := s.LHS
+	ty := lhs.ExpressionType()
+	v := f.cast(s.RHS.ExpressionType(), ty, f.eval(s.RHS))
+	switch lhs := lhs.(type) {
+	case *semantic.Ignore:
+	case *semantic.Global:
+		f.i.Globals[lhs] = f.assignOp(s, s.Operator, ty, f.i.Globals[lhs], v)
+	case *semantic.Parameter:
+		f.params[lhs] = f.assignOp(s, s.Operator, ty, f.params[lhs], v)
+	case *semantic.Member:
+		fields := f.object(lhs.Object)
+		fields[lhs.Field] = f.assignOp(s, s.Operator, ty, fields[lhs.Field], v)
+	default:
+		f.fail(s, "Cannot assign to %T", lhs)
+	}
+}
+
+// iterate executes the for loop s, returning true if a return statement was
+// executed.
+func (f *frame) iterate(s *semantic.Iteration) bool {
+	it := s.Iterator
+	from, to := f.eval(s.From), f.eval(s.To)
+	if isSigned(it.Type) {
+		for i, end := toInt64(from), toInt64(to); i < end; i++ {
+			f.locals[it] = convert(it.Type, i)
+			if f.block(s.Block) {
+				return true
+			}
+		}
+		return false
+	}
+	for i, end := toUint64(from), toUint64(to); i < end; i++ {
+		f.locals[it] = convert(it.Type, i)
+		if f.block(s.Block) {
+			return true
+		}
+	}
+	return false
+}
+
+// fence calls the OnFence callback.
+func (f *frame) fence() {
+	if f.i.OnFence != nil {
+		f.i.OnFence(f.ctx)
+	}
+}
+
+// copySource reads the source of the copy c, returning the destination slice
+// and the data to copy.
+func (f *frame) copySource(c *semantic.Copy) (Slice, memory.Data) {
+	src, dst := f.eval(c.Src).(Slice), f.eval(c.Dst).(Slice)
+	srcTy := elementType(c.Src)
+	dstTy := elementType(c.Dst)
+	srcSize, dstSize := f.sizeOf(srcTy), f.sizeOf(dstTy)
+	size := src.Count * srcSize
+	if n := dst.Count * dstSize; n < size {
+		size = n
+	}
+	src.Count = size / srcSize
+	srcPool := f.pool(c, src.Pool)
+	rng := f.rangeOf(src, srcTy)
+	if srcPool.OnRead != nil {
+		srcPool.OnRead(rng)
+	}
+	return dst, srcPool.Slice(rng)
+}
+
+// copyDestination writes the data read by copySource to the destination of
+// the copy c.
+func (f *frame) copyDestination(c *semantic.Copy, dst Slice, data memory.Data) {
+	dstPool := f.pool(c, dst.Pool)
+	dstPool.Write(dst.Base, data)
+	if dstPool.OnWrite != nil {
+		dstPool.OnWrite(memory.Range{Base: dst.Base, Size: data.Size()})
+	}
+}
+
+// elementType returns the element type of the slice expression e.
+func elementType(e semantic.Expression) semantic.Type {
+	return semantic.Underlying(e.ExpressionType()).(*semantic.Slice).To
+}
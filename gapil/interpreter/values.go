@@ -0,0 +1,229 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interpreter
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/gapid/gapil/semantic"
+	"github.com/google/gapid/gapis/memory"
+)
+
+// Object is the value of a class instance.
+// Class values are copied when they are stored, references to a class share
+// the same Object.
+type Object struct {
+	Class  *semantic.Class
+	Fields map[*semantic.Field]interface{}
+}
+
+// Field returns the value of the field with the given name.
+func (o *Object) Field(name string) interface{} {
+	for _, f := range o.Class.Fields {
+		if f.Name() == name {
+			return o.Fields[f]
+		}
+	}
+	return nil
+}
+
+// SetField sets the value of the field with the given name.
+func (o *Object) SetField(name string, value interface{}) {
+	for _, f := range o.Class.Fields {
+		if f.Name() == name {
+			o.Fields[f] = value
+			return
+		}
+	}
+	panic(fmt.Errorf("Class %v has no field '%v'", o.Class.Name(), name))
+}
+
+func (o *Object) String() string {
+	if o == nil {
+		return "null"
+	}
+	return fmt.Sprintf("%v%v", o.Class.Name(), o.Fields)
+}
+
+// Map is the value of a map.
+type Map struct {
+	Type    *semantic.Map
+	entries map[interface{}]interface{}
+}
+
+// NewMap returns a new empty map of the given type.
+func NewMap(ty *semantic.Map) *Map {
+	return &Map{Type: ty, entries: map[interface{}]interface{}{}}
+}
+
+// Len returns the number of entries in the map.
+func (m *Map) Len() int { return len(m.entries) }
+
+// Get returns the value for key, and whether the map contains key.
+func (m *Map) Get(key interface{}) (interface{}, bool) {
+	v, ok := m.entries[key]
+	return v, ok
+}
+
+// Set sets the value for key.
+func (m *Map) Set(key, value interface{}) { m.entries[key] = value }
+
+// Delete removes the entry for key.
+func (m *Map) Delete(key interface{}) { delete(m.entries, key) }
+
+// Keys returns the keys of the map in ascending order.
+func (m *Map) Keys() []interface{} {
+	keys := make([]interface{}, 0, len(m.entries))
+	for k := range m.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return less(keys[i], keys[j]) })
+	return keys
+}
+
+// Slice is the value of a slice: Count elements starting at the address Base
+// of the memory pool Pool. Root is the address of the start of the slice the
+// slice was created from.
+type Slice struct {
+	Root  uint64
+	Base  uint64
+	Count uint64
+	Pool  memory.PoolID
+}
+
+// Pointer is the value of a pointer.
+type Pointer struct {
+	Address uint64
+	Pool    memory.PoolID
+}
+
+// Message is the value of a message.
+type Message struct {
+	Name      string
+	Arguments map[string]interface{}
+}
+
+// zero returns the default value of the type ty.
+func (f *frame) zero(ty semantic.Type) interface{} {
+	switch ty := semantic.Underlying(ty).(type) {
+	case *semantic.Builtin:
+		switch ty {
+		case semantic.BoolType:
+			return false
+		case semantic.StringType:
+			return ""
+		case semantic.AnyType, semantic.VoidType:
+			return nil
+		case semantic.MessageType:
+			return (*Message)(nil)
+		}
+		return convert(ty, uint64(0))
+	case *semantic.Enum:
+		return uint32(0)
+	case *semantic.Class:
+		o := &Object{Class: ty, Fields: make(map[*semantic.Field]interface{}, len(ty.Fields))}
+		for _, field := range ty.Fields {
+			if field.Default != nil {
+				o.Fields[field] = f.cast(field.Default.ExpressionType(), field.Type, f.eval(field.Default))
+			} else {
+				o.Fields[field] = f.zero(field.Type)
+			}
+		}
+		return o
+	case *semantic.StaticArray:
+		out := make([]interface{}, ty.Size)
+		for i := range out {
+			out[i] = f.zero(ty.ValueType)
+		}
+		return out
+	case *semantic.Map:
+		return NewMap(ty)
+	case *semantic.Reference:
+		return (*Object)(nil)
+	case *semantic.Pointer:
+		return Pointer{Pool: memory.ApplicationPool}
+	case *semantic.Slice:
+		return Slice{Pool: memory.ApplicationPool}
+	}
+	return nil
+}
+
+// copyValue returns a copy of the value v of type ty, as stored in a
+// variable, field or container. Class values and static arrays are copied
+// deeply, all other values are returned as is.
+func copyValue(ty semantic.Type, v interface{}) interface{} {
+	switch ty := semantic.Underlying(ty).(type) {
+	case *semantic.Class:
+		o, ok := v.(*Object)
+		if !ok || o == nil {
+			return v
+		}
+		out := &Object{Class: o.Class, Fields: make(map[*semantic.Field]interface{}, len(o.Fields))}
+		for _, field := range ty.Fields {
+			out.Fields[field] = copyValue(field.Type, o.Fields[field])
+		}
+		return out
+	case *semantic.StaticArray:
+		a, ok := v.([]interface{})
+		if !ok {
+			return v
+		}
+		out := make([]interface{}, len(a))
+		for i, e := range a {
+			out[i] = copyValue(ty.ValueType, e)
+		}
+		return out
+	}
+	return v
+}
+
+// equal returns true if the values l and r are equal.
+func equal(l, r interface{}) bool {
+	if isNumber(l) && isNumber(r) {
+		return compare(l, r) == 0
+	}
+	switch l := l.(type) {
+	case []interface{}:
+		r, ok := r.([]interface{})
+		if !ok || len(l) != len(r) {
+			return false
+		}
+		for i := range l {
+			if !equal(l[i], r[i]) {
+				return false
+			}
+		}
+		return true
+	case *Map:
+		return l == r
+	}
+	return l == r
+}
+
+// less is the ordering used for the keys of maps. Numbers and strings are
+// ordered by value, all other keys are ordered by their string representation.
+func less(l, r interface{}) bool {
+	switch {
+	case isNumber(l) && isNumber(r):
+		return compare(l, r) < 0
+	}
+	if l, ok := l.(string); ok {
+		if r, ok := r.(string); ok {
+			return l < r
+		}
+	}
+	return fmt.Sprint(l) < fmt.Sprint(r)
+}
@@ -176,7 +176,12 @@ func (a *analyzer) begin(ctx context.Context, s *server) error {
 		}
 	}
 	va := validate.Options{
-		CheckUnused: s.config.CheckUnused,
+		CheckUnused:             s.config.CheckUnused,
+		CheckMapReads:           s.config.CheckMapReads,
+		CheckNoReplayWrites:     s.config.CheckNoReplayWrites,
+		CheckUnobservedPointers: s.config.CheckUnobservedPointers,
+		CheckShadowedLocals:     s.config.CheckShadowedLocals,
+		CheckEnumSwitches:       s.config.CheckEnumSwitches,
 	}
 
 	// Setup the new done signal and cancellation function.
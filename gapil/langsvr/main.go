@@ -87,10 +87,15 @@ var (
 // Config is is the configuration data sent from the client, held in the
 // "gfxapi" group.
 type Config struct {
-	Debug       bool     `json:"debug"`
-	LogToFiles  bool     `json:"logToFiles"`
-	IgnorePaths []string `json:"ignorePaths"`
-	CheckUnused bool     `json:"checkUnused"`
+	Debug                   bool     `json:"debug"`
+	LogToFiles              bool     `json:"logToFiles"`
+	IgnorePaths             []string `json:"ignorePaths"`
+	CheckUnused             bool     `json:"checkUnused"`
+	CheckMapReads           bool     `json:"checkMapReads"`
+	CheckNoReplayWrites     bool     `json:"checkNoReplayWrites"`
+	CheckUnobservedPointers bool     `json:"checkUnobservedPointers"`
+	CheckShadowedLocals     bool     `json:"checkShadowedLocals"`
+	CheckEnumSwitches       bool     `json:"checkEnumSwitches"`
 }
 
 type server struct {
@@ -0,0 +1,468 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/gapid/core/text/parse"
+	"github.com/google/gapid/gapil/ast"
+	"github.com/google/gapid/gapil/format"
+	"github.com/google/gapid/gapil/parser"
+)
+
+// maxLineLength is the length of a command signature beyond which its
+// parameters are written one per line.
+const maxLineLength = 100
+
+// extensionBase is the first enum value reserved for Vulkan extensions.
+const extensionBase = 1000000000
+
+// primitives maps the C types used by the registries to api types.
+var primitives = map[string]string{
+	"char":     "char",
+	"double":   "f64",
+	"float":    "f32",
+	"int":      "int",
+	"int8_t":   "s8",
+	"int16_t":  "s16",
+	"int32_t":  "s32",
+	"int64_t":  "s64",
+	"size_t":   "size",
+	"uint8_t":  "u8",
+	"uint16_t": "u16",
+	"uint32_t": "u32",
+	"uint64_t": "u64",
+	"void":     "void",
+}
+
+// keywords are the api keywords that cannot be used as identifiers.
+var keywords = map[string]bool{}
+
+func init() {
+	for _, k := range []string{
+		ast.KeywordAbort, ast.KeywordAPI, ast.KeywordAlias, ast.KeywordBitfield,
+		ast.KeywordCase, ast.KeywordClass, ast.KeywordCmd, ast.KeywordConst,
+		ast.KeywordDefault, ast.KeywordDefine, ast.KeywordDelete, ast.KeywordElse,
+		ast.KeywordEnum, ast.KeywordExtern, ast.KeywordFalse, ast.KeywordFence,
+		ast.KeywordFor, ast.KeywordIf, ast.KeywordImport, ast.KeywordIn,
+		ast.KeywordLabel, ast.KeywordNull, ast.KeywordReturn, ast.KeywordPseudonym,
+		ast.KeywordSwitch, ast.KeywordSub, ast.KeywordThis, ast.KeywordTrue,
+		ast.KeywordWhen, ast.KeywordApiIndex,
+	} {
+		keywords[k] = true
+	}
+}
+
+// Options controls what is imported from the registry.
+type Options struct {
+	// API restricts the imported declarations to those required for the named
+	// API, for example "gles2". If empty, all require blocks are imported.
+	API string
+}
+
+// Import writes formatted skeleton api declarations for the enums, types and
+// commands required by the named extensions to w.
+// Command bodies are left for the author to fill in.
+func Import(reg *Registry, extensions []string, options Options, w io.Writer) error {
+	i := newImporter(reg)
+	for _, name := range extensions {
+		ext, ok := i.extensions[name]
+		if !ok {
+			return fmt.Errorf("Extension '%s' not found in registry", name)
+		}
+		if err := i.extension(ext, options); err != nil {
+			return err
+		}
+	}
+
+	source := strings.TrimPrefix(i.out.String(), "\n")
+	m := parse.NewCSTMap()
+	api, errs := parser.Parse("import.api", source, m)
+	if len(errs) > 0 {
+		return fmt.Errorf("Generated invalid api source: %v\n%s", errs, source)
+	}
+	format.Format(api, m, w)
+	return nil
+}
+
+type importer struct {
+	types      map[string]*Type
+	enums      map[string]*Enums // by group name
+	values     map[string]*Enum  // by entry name
+	groups     map[string]*Enums // group owning the entry name
+	commands   map[string]*Command
+	extensions map[string]*Extension
+	done       map[string]bool // declarations already written
+	out        bytes.Buffer
+}
+
+func newImporter(reg *Registry) *importer {
+	i := &importer{
+		types:      map[string]*Type{},
+		enums:      map[string]*Enums{},
+		values:     map[string]*Enum{},
+		groups:     map[string]*Enums{},
+		commands:   map[string]*Command{},
+		extensions: map[string]*Extension{},
+		done:       map[string]bool{},
+	}
+	for _, t := range reg.Types {
+		i.types[t.Name()] = t
+	}
+	for _, g := range reg.Enums {
+		if g.Name != "" {
+			i.enums[g.Name] = g
+		}
+		for _, e := range g.Entries {
+			i.values[e.Name] = e
+			i.groups[e.Name] = g
+		}
+	}
+	for _, c := range reg.Commands {
+		i.commands[c.Name()] = c
+	}
+	for _, e := range reg.Extensions {
+		i.extensions[e.Name] = e
+		for _, r := range e.Requires {
+			for _, v := range r.Enums {
+				if _, ok := i.values[v.Name]; ok || v.Extends == "" {
+					continue
+				}
+				// Record the extension number for aliases of the value.
+				v := *v
+				if v.ExtNum == "" {
+					v.ExtNum = e.Number
+				}
+				i.values[v.Name] = &v
+			}
+		}
+	}
+	return i
+}
+
+func (i *importer) printf(msg string, args ...interface{}) {
+	fmt.Fprintf(&i.out, msg, args...)
+}
+
+func (i *importer) extension(ext *Extension, options Options) error {
+	number, _ := strconv.ParseInt(ext.Number, 10, 64)
+	defines := []*Enum{}
+	additions := map[string][]string{}
+	types, commands := []string{}, []string{}
+	for _, r := range ext.Requires {
+		if options.API != "" && r.API != "" && r.API != options.API {
+			continue
+		}
+		for _, e := range r.Enums {
+			extends := e.Extends
+			if extends == "" && e.Value == "" && e.BitPos == "" {
+				// A reference to a value declared elsewhere in the registry.
+				g, ok := i.groups[e.Name]
+				if !ok {
+					return fmt.Errorf("Enum '%s' not found in registry", e.Name)
+				}
+				e = i.values[e.Name]
+				switch {
+				case g.Name == "":
+					// gl.xml groups are unnamed.
+					extends = "GLenum"
+					if g.Type == "bitmask" {
+						extends = "GLbitfield"
+					}
+				case g.Type == "enum", g.Type == "bitmask":
+					extends = g.Name
+				}
+			}
+			if extends == "" {
+				defines = append(defines, e)
+				continue
+			}
+			v, err := i.value(e, number)
+			if err != nil {
+				return err
+			}
+			additions[extends] = append(additions[extends], entry(e.Name, v))
+		}
+		for _, t := range r.Types {
+			types = append(types, t.Name)
+		}
+		for _, c := range r.Commands {
+			commands = append(commands, c.Name)
+		}
+	}
+
+	annotation := fmt.Sprintf("@extension(%q)", ext.Name)
+	if len(defines) > 0 {
+		i.printf("\n")
+		for _, e := range defines {
+			i.printf("%s define %s %s\n", annotation, e.Name, define(e))
+		}
+	}
+
+	extended := make([]string, 0, len(additions))
+	for name := range additions {
+		extended = append(extended, name)
+	}
+	sort.Strings(extended)
+	for _, name := range extended {
+		i.printf("\n// %s adds the following entries to %s:\n", ext.Name, name)
+		for _, e := range additions[name] {
+			i.printf("//   %s\n", e)
+		}
+	}
+
+	for _, name := range types {
+		if err := i.typ(name, annotation); err != nil {
+			return err
+		}
+	}
+	for _, name := range commands {
+		if err := i.command(name, annotation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// value returns the numeric value of the enum entry e, added by the
+// extension with the given number.
+func (i *importer) value(e *Enum, number int64) (int64, error) {
+	switch {
+	case e.Alias != "":
+		a, ok := i.values[e.Alias]
+		if !ok {
+			return 0, fmt.Errorf("Enum alias '%s' not found in registry", e.Alias)
+		}
+		return i.value(a, number)
+	case e.BitPos != "":
+		bit, err := strconv.ParseUint(e.BitPos, 0, 6)
+		return 1 << bit, err
+	case e.Offset != "":
+		offset, err := strconv.ParseInt(e.Offset, 0, 64)
+		if e.ExtNum != "" {
+			number, _ = strconv.ParseInt(e.ExtNum, 10, 64)
+		}
+		v := extensionBase + (number-1)*1000 + offset
+		if e.Dir == "-" {
+			v = -v
+		}
+		return v, err
+	default:
+		return strconv.ParseInt(e.Value, 0, 64)
+	}
+}
+
+// entry returns the api enum entry declaration for the name and value.
+// Negative values are written as their 32-bit two's complement.
+func entry(name string, v int64) string {
+	if v < 0 {
+		return fmt.Sprintf("%s = 0x%08X, // %d", name, uint32(v), v)
+	}
+	return fmt.Sprintf("%s = 0x%08X,", name, v)
+}
+
+// define returns the api expression for the value of the constant e.
+func define(e *Enum) string {
+	if strings.HasPrefix(e.Value, `"`) {
+		return e.Value
+	}
+	if _, err := strconv.ParseInt(e.Value, 0, 64); err == nil {
+		return e.Value
+	}
+	if e.BitPos != "" {
+		if bit, err := strconv.ParseUint(e.BitPos, 0, 6); err == nil {
+			return fmt.Sprintf("0x%08X", uint64(1)<<bit)
+		}
+	}
+	// Expressions such as (~0U) have no api equivalent.
+	return fmt.Sprintf("0 // TODO: %s", e.Value)
+}
+
+func (i *importer) typ(name, annotation string) error {
+	if i.done[name] {
+		return nil
+	}
+	i.done[name] = true
+	t, ok := i.types[name]
+	if !ok {
+		return fmt.Errorf("Type '%s' not found in registry", name)
+	}
+	if t.Category == "union" {
+		// Classes cannot overlap fields, so only the first member is declared.
+		i.printf("\n// @union\n// %s also holds the alternatives:\n", name)
+		for _, m := range t.Members[1:] {
+			i.printf("//   %s %s\n", declType(m), identifier(m.Name))
+		}
+		i.printf("%s\n", annotation)
+	} else {
+		i.printf("\n%s\n", annotation)
+	}
+	switch {
+	case t.Alias != "":
+		i.printf("type %s %s\n", t.Alias, name)
+	case t.Category == "handle":
+		if t.TypeElem == "VK_DEFINE_HANDLE" {
+			i.printf("@replay_remap @dispatchHandle type size %s\n", name)
+		} else {
+			i.printf("@replay_remap @nonDispatchHandle type u64 %s\n", name)
+		}
+	case t.Category == "bitmask":
+		i.printf("type %s %s\n", apiType(t.TypeElem), name)
+		if t.Requires != "" {
+			return i.typ(t.Requires, annotation)
+		}
+	case t.Category == "enum":
+		g, ok := i.enums[name]
+		if !ok {
+			return fmt.Errorf("Enum '%s' not found in registry", name)
+		}
+		kind := ast.KeywordEnum
+		if g.Type == "bitmask" {
+			kind = ast.KeywordBitfield
+		}
+		i.printf("%s %s {\n", kind, name)
+		for _, e := range g.Entries {
+			v, err := i.value(e, 0)
+			if err != nil {
+				return err
+			}
+			i.printf("  %s\n", entry(e.Name, v))
+		}
+		i.printf("}\n")
+	case t.Category == "struct", t.Category == "union":
+		members := t.Members
+		if t.Category == "union" {
+			members = members[:1]
+		}
+		i.printf("@serialize\nclass %s {\n", name)
+		for _, m := range members {
+			i.printf("  %s %s\n", declType(m), identifier(m.Name))
+		}
+		i.printf("}\n")
+	case t.Category == "funcpointer":
+		i.printf("@external type void* %s\n", name)
+	default:
+		i.printf("type %s %s\n", apiType(t.TypeElem), name)
+	}
+	return nil
+}
+
+func (i *importer) command(name, annotation string) error {
+	if i.done[name] {
+		return nil
+	}
+	i.done[name] = true
+	c, ok := i.commands[name]
+	if !ok {
+		return fmt.Errorf("Command '%s' not found in registry", name)
+	}
+	if alias := c.Alias; alias != "" {
+		if c, ok = i.commands[alias]; !ok {
+			return fmt.Errorf("Command alias '%s' not found in registry", alias)
+		}
+	}
+	i.printf("\n%s\n", annotation)
+	if len(c.Params) > 0 {
+		if chain := i.dispatch(baseType(c.Params[0].Type)); len(chain) > 0 {
+			i.printf("@indirect(\"%s\")\n", strings.Join(chain, `", "`))
+		}
+	}
+	params := make([]string, len(c.Params))
+	for j, p := range c.Params {
+		params[j] = declType(p) + " " + identifier(p.Name)
+	}
+	signature := fmt.Sprintf("cmd %s %s(%s)", declType(c.Proto), name, strings.Join(params, ", "))
+	if len(signature) > maxLineLength {
+		// Long parameter lists are written one per line.
+		signature = fmt.Sprintf("cmd %s %s(\n    %s)", declType(c.Proto), name, strings.Join(params, ",\n    "))
+	}
+	i.printf("%s {\n  // TODO\n}\n", signature)
+	return nil
+}
+
+// dispatch returns the chain of dispatchable handles from the handle type
+// name to the VkInstance or VkDevice that owns it, or nil if name is not a
+// dispatchable handle.
+func (i *importer) dispatch(name string) []string {
+	t, ok := i.types[name]
+	if !ok || t.Category != "handle" || t.TypeElem != "VK_DEFINE_HANDLE" {
+		return nil
+	}
+	chain := []string{name}
+	for name != "VkInstance" && name != "VkDevice" && t != nil {
+		name, t = t.Parent, i.types[t.Parent]
+		if t != nil && t.TypeElem == "VK_DEFINE_HANDLE" {
+			chain = append(chain, name)
+		}
+	}
+	return chain
+}
+
+// identifier returns name, renamed if it collides with an api keyword.
+func identifier(name string) string {
+	if keywords[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// apiType returns the api name for the C type name.
+func apiType(name string) string {
+	if p, ok := primitives[name]; ok {
+		return p
+	}
+	return name
+}
+
+// baseType returns the name of the type in the C declaration d, stripped of
+// qualifiers and pointers.
+func baseType(d string) string {
+	for _, t := range strings.Fields(strings.Replace(d, "*", " ", -1)) {
+		if t != "const" && t != "struct" {
+			return t
+		}
+	}
+	return ""
+}
+
+// declType returns the api type for the C declaration d.
+func declType(d *Decl) string {
+	out := ""
+	for _, t := range strings.Fields(strings.Replace(d.Type, "*", " * ", -1)) {
+		switch t {
+		case "struct":
+		case "const":
+			if out == "" {
+				out = "const "
+			} else {
+				out += " const"
+			}
+		case "*":
+			out += "*"
+		default:
+			out += apiType(t)
+		}
+	}
+	for _, size := range d.Arrays {
+		out += "[" + size + "]"
+	}
+	return out
+}
@@ -0,0 +1,200 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package registry imports declarations from the Khronos XML API registries
+// (vk.xml, gl.xml) as skeleton api source.
+package registry
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Registry is the root of a Khronos XML API registry.
+type Registry struct {
+	Types      []*Type      `xml:"types>type"`
+	Enums      []*Enums     `xml:"enums"`
+	Commands   []*Command   `xml:"commands>command"`
+	Extensions []*Extension `xml:"extensions>extension"`
+}
+
+// Type is a type declared by the registry.
+// Depending on the category, the name is held either as an attribute or as a
+// child element.
+type Type struct {
+	NameAttr string  `xml:"name,attr"`
+	NameElem string  `xml:"name"`
+	TypeElem string  `xml:"type"`
+	Category string  `xml:"category,attr"`
+	Parent   string  `xml:"parent,attr"`
+	Requires string  `xml:"requires,attr"`
+	Alias    string  `xml:"alias,attr"`
+	Members  []*Decl `xml:"member"`
+}
+
+// Name returns the name of the type.
+func (t *Type) Name() string {
+	if t.NameAttr != "" {
+		return t.NameAttr
+	}
+	return t.NameElem
+}
+
+// Enums is a group of enumerated values or constants.
+// For vk.xml each enum or bitmask type has its own group, for gl.xml the
+// groups are unnamed and all values belong to GLenum or GLbitfield.
+type Enums struct {
+	Name    string  `xml:"name,attr"`
+	Type    string  `xml:"type,attr"`
+	Entries []*Enum `xml:"enum"`
+}
+
+// Enum is a single enumerated value, either declared in an Enums group or
+// referenced or added by an extension.
+type Enum struct {
+	Name    string `xml:"name,attr"`
+	Value   string `xml:"value,attr"`
+	BitPos  string `xml:"bitpos,attr"`
+	Offset  string `xml:"offset,attr"`
+	Dir     string `xml:"dir,attr"`
+	ExtNum  string `xml:"extnumber,attr"`
+	Extends string `xml:"extends,attr"`
+	Alias   string `xml:"alias,attr"`
+}
+
+// Command is a command declared by the registry.
+type Command struct {
+	NameAttr string  `xml:"name,attr"`
+	Alias    string  `xml:"alias,attr"`
+	Proto    *Decl   `xml:"proto"`
+	Params   []*Decl `xml:"param"`
+}
+
+// Name returns the name of the command.
+func (c *Command) Name() string {
+	if c.Proto != nil {
+		return c.Proto.Name
+	}
+	return c.NameAttr
+}
+
+// Extension lists the enums, types and commands introduced by an extension.
+type Extension struct {
+	Name     string     `xml:"name,attr"`
+	Number   string     `xml:"number,attr"`
+	Requires []*Require `xml:"require"`
+}
+
+// Require is a block of declarations required by an extension, optionally
+// restricted to a single API.
+type Require struct {
+	API      string  `xml:"api,attr"`
+	Enums    []*Enum `xml:"enum"`
+	Types    []*Ref  `xml:"type"`
+	Commands []*Ref  `xml:"command"`
+}
+
+// Ref is a reference by name to a type or command.
+type Ref struct {
+	Name string `xml:"name,attr"`
+}
+
+// Decl is a C declaration of a struct member, command parameter or command
+// return type, such as:
+//
+//	<member>const <type>char</type>* <name>pName</name>[<enum>N</enum>][4]</member>
+type Decl struct {
+	// Type is the C type text preceding the name, with the type element inlined.
+	Type string
+	// Name is the declared name.
+	Name string
+	// Arrays are the fixed array sizes following the name, outermost first.
+	// Arrays is empty if the declaration is not an array.
+	Arrays []string
+}
+
+// UnmarshalXML decodes the declaration from its mixed text and element
+// content.
+func (d *Decl) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	typ, post := &bytes.Buffer{}, &bytes.Buffer{}
+	out, element := typ, ""
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			element = tok.Name.Local
+			if element == "comment" {
+				if err := dec.Skip(); err != nil {
+					return err
+				}
+				element = ""
+			}
+		case xml.EndElement:
+			if tok.Name == start.Name {
+				d.Type = strings.TrimSpace(typ.String())
+				arrays, err := parseArrays(post.String())
+				if err != nil {
+					return fmt.Errorf("Declaration of '%s': %v", d.Name, err)
+				}
+				d.Arrays = arrays
+				return nil
+			}
+			element = ""
+		case xml.CharData:
+			if element == "name" {
+				d.Name = string(tok)
+				out = post
+			} else {
+				out.Write(tok)
+			}
+		}
+	}
+}
+
+// parseArrays returns the sizes of the array dimensions in s, the text
+// following the name of a declaration, such as "[4][N]".
+func parseArrays(s string) ([]string, error) {
+	var sizes []string
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		if s[0] != '[' {
+			return nil, fmt.Errorf("Unexpected '%s' after the name", s)
+		}
+		end := strings.IndexByte(s, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("Missing ']' in '%s'", s)
+		}
+		size := strings.TrimSpace(s[1:end])
+		if size == "" || strings.ContainsAny(size, "[]") {
+			return nil, fmt.Errorf("Invalid array size '%s'", s[:end+1])
+		}
+		sizes = append(sizes, size)
+		s = s[end+1:]
+	}
+	return sizes, nil
+}
+
+// Parse decodes a registry from r.
+func Parse(r io.Reader) (*Registry, error) {
+	reg := &Registry{}
+	if err := xml.NewDecoder(r).Decode(reg); err != nil {
+		return nil, err
+	}
+	return reg, nil
+}
@@ -0,0 +1,244 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapil/registry"
+)
+
+func load(ctx context.Context, path string) *registry.Registry {
+	f, err := os.Open(path)
+	assert.For(ctx, "open").ThatError(err).Succeeded()
+	defer f.Close()
+	reg, err := registry.Parse(f)
+	assert.For(ctx, "parse").ThatError(err).Succeeded()
+	return reg
+}
+
+func TestImportVulkan(t *testing.T) {
+	ctx := log.Testing(t)
+	reg := load(ctx, "testdata/vk.xml")
+	buf := &bytes.Buffer{}
+	err := registry.Import(reg, []string{"VK_KHR_swapchain", "VK_KHR_external_memory"}, registry.Options{}, buf)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+	assert.For(ctx, "api").ThatString(buf.String()).Equals(`@extension("VK_KHR_swapchain") define VK_KHR_SWAPCHAIN_SPEC_VERSION   68
+@extension("VK_KHR_swapchain") define VK_KHR_SWAPCHAIN_EXTENSION_NAME "VK_KHR_swapchain"
+
+// VK_KHR_swapchain adds the following entries to VkResult:
+//   VK_ERROR_OUT_OF_DATE_KHR = 0xC4653214, // -1000001004
+
+// VK_KHR_swapchain adds the following entries to VkStructureType:
+//   VK_STRUCTURE_TYPE_SWAPCHAIN_CREATE_INFO_KHR = 0x3B9ACDE8,
+//   VK_STRUCTURE_TYPE_PRESENT_INFO_KHR = 0x3B9ACDE9,
+//   VK_STRUCTURE_TYPE_PRESENT_INFO_KHX = 0x3B9ACDE9,
+
+@extension("VK_KHR_swapchain")
+@replay_remap @nonDispatchHandle type u64 VkSwapchainKHR
+
+@extension("VK_KHR_swapchain")
+@serialize
+class VkSwapchainCreateInfoKHR {
+  VkStructureType           sType
+  const void*               pNext
+  VkSwapchainCreateFlagsKHR flags
+  VkSurfaceKHR              surface
+  VkExtent2D                imageExtent
+  u32                       queueFamilyIndexCount
+  const u32*                pQueueFamilyIndices
+  VkPresentModeKHR          presentMode
+  VkBool32                  clipped
+  VkSwapchainKHR            oldSwapchain
+}
+
+@extension("VK_KHR_swapchain")
+@serialize
+class VkPresentInfoKHR {
+  VkStructureType       sType
+  const void*           pNext
+  u32                   waitSemaphoreCount
+  const VkSemaphore*    pWaitSemaphores
+  u32                   swapchainCount
+  const VkSwapchainKHR* pSwapchains
+  const u32*            pImageIndices
+  VkResult*             pResults
+}
+
+@extension("VK_KHR_swapchain")
+type VkFlags VkSwapchainCreateFlagsKHR
+
+@extension("VK_KHR_swapchain")
+bitfield VkSwapchainCreateFlagBitsKHR {
+  VK_SWAPCHAIN_CREATE_BIND_SFR_BIT_KHX  = 0x00000001,
+  VK_SWAPCHAIN_CREATE_PROTECTED_BIT_KHR = 0x00000002,
+}
+
+@extension("VK_KHR_swapchain")
+enum VkPresentModeKHR {
+  VK_PRESENT_MODE_IMMEDIATE_KHR    = 0x00000000,
+  VK_PRESENT_MODE_MAILBOX_KHR      = 0x00000001,
+  VK_PRESENT_MODE_FIFO_KHR         = 0x00000002,
+  VK_PRESENT_MODE_FIFO_RELAXED_KHR = 0x00000003,
+}
+
+@extension("VK_KHR_swapchain")
+@indirect("VkDevice")
+cmd VkResult vkCreateSwapchainKHR(
+    VkDevice                        device,
+    const VkSwapchainCreateInfoKHR* pCreateInfo,
+    const VkAllocationCallbacks*    pAllocator,
+    VkSwapchainKHR*                 pSwapchain) {
+  // TODO
+}
+
+@extension("VK_KHR_swapchain")
+@indirect("VkQueue", "VkDevice")
+cmd VkResult vkQueuePresentKHR(VkQueue queue, const VkPresentInfoKHR* pPresentInfo) {
+  // TODO
+}
+
+@extension("VK_KHR_swapchain")
+@indirect("VkPhysicalDevice", "VkInstance")
+cmd VkResult vkGetPhysicalDevicePresentModesKHX(
+    VkPhysicalDevice  physicalDevice,
+    VkSurfaceKHR      surface,
+    u32*              pPresentModeCount,
+    VkPresentModeKHR* pPresentModes) {
+  // TODO
+}
+
+@extension("VK_KHR_external_memory") define VK_KHR_EXTERNAL_MEMORY_SPEC_VERSION 1
+@extension("VK_KHR_external_memory") define VK_LUID_SIZE_KHR                    8
+@extension("VK_KHR_external_memory") define VK_QUEUE_FAMILY_EXTERNAL_KHR        0 // TODO: (~0U-1)
+
+// VK_KHR_external_memory adds the following entries to VkResult:
+//   VK_ERROR_INVALID_EXTERNAL_HANDLE_KHR = 0xC46420A5, // -1000071003
+
+// @union
+// VkClearColorValue also holds the alternatives:
+//   s32[4] int32
+//   u32[4] uint32
+@extension("VK_KHR_external_memory")
+@serialize
+class VkClearColorValue {
+  f32[4] float32
+}
+
+@extension("VK_KHR_external_memory")
+@serialize
+class VkDeviceCreateInfo {
+  const char* const* ppEnabledExtensionNames
+}
+`)
+}
+
+func TestImportGLES(t *testing.T) {
+	ctx := log.Testing(t)
+	reg := load(ctx, "testdata/gl.xml")
+	buf := &bytes.Buffer{}
+	err := registry.Import(reg, []string{"GL_KHR_blend_equation_advanced", "GL_KHR_debug"}, registry.Options{API: "gles2"}, buf)
+	assert.For(ctx, "err").ThatError(err).Succeeded()
+	assert.For(ctx, "api").ThatString(buf.String()).Equals(`// GL_KHR_blend_equation_advanced adds the following entries to GLenum:
+//   GL_MULTIPLY_KHR = 0x00009294,
+//   GL_SCREEN_KHR = 0x00009295,
+//   GL_OVERLAY_KHR = 0x00009296,
+
+@extension("GL_KHR_blend_equation_advanced")
+cmd void glBlendBarrierKHR() {
+  // TODO
+}
+
+// GL_KHR_debug adds the following entries to GLenum:
+//   GL_BUFFER_KHR = 0x000082E0,
+
+@extension("GL_KHR_debug")
+cmd void glDebugMessageControlKHR(
+    GLenum        source,
+    GLenum        type_,
+    GLenum        severity,
+    GLsizei       count,
+    const GLuint* ids,
+    GLboolean     enabled) {
+  // TODO
+}
+
+@extension("GL_KHR_debug")
+cmd void glObjectLabelKHR(GLenum identifier, GLuint name, GLsizei length, const GLchar* label_) {
+  // TODO
+}
+
+@extension("GL_KHR_debug")
+cmd void* glMapBufferOES(GLenum target, GLenum access) {
+  // TODO
+}
+`)
+}
+
+func TestImportUnknownExtension(t *testing.T) {
+	ctx := log.Testing(t)
+	reg := load(ctx, "testdata/gl.xml")
+	err := registry.Import(reg, []string{"GL_KHR_unknown"}, registry.Options{}, &bytes.Buffer{})
+	assert.For(ctx, "err").ThatError(err).HasMessage("Extension 'GL_KHR_unknown' not found in registry")
+}
+
+func TestParseDecl(t *testing.T) {
+	assert := assert.To(t)
+	for _, test := range []struct {
+		member string
+		decl   registry.Decl
+	}{
+		{`<type>uint32_t</type> <name>count</name>`,
+			registry.Decl{Type: "uint32_t", Name: "count"}},
+		{`const <type>char</type>* <name>pName</name>`,
+			registry.Decl{Type: "const char*", Name: "pName"}},
+		{`<type>char</type> <name>name</name>[<enum>VK_MAX_NAME_SIZE</enum>]`,
+			registry.Decl{Type: "char", Name: "name", Arrays: []string{"VK_MAX_NAME_SIZE"}}},
+		{`<type>float</type> <name>matrix</name>[3][ 4 ]`,
+			registry.Decl{Type: "float", Name: "matrix", Arrays: []string{"3", "4"}}},
+		{`<type>uint8_t</type> <name>data</name>[<enum>N</enum>][2] <comment>Data</comment>`,
+			registry.Decl{Type: "uint8_t", Name: "data", Arrays: []string{"N", "2"}}},
+	} {
+		reg, err := registry.Parse(strings.NewReader(
+			`<registry><types><type category="struct" name="S"><member>` + test.member + `</member></type></types></registry>`))
+		if !assert.For("%s err", test.member).ThatError(err).Succeeded() {
+			continue
+		}
+		assert.For("%s", test.member).That(*reg.Types[0].Members[0]).DeepEquals(test.decl)
+	}
+}
+
+func TestParseMalformedDecl(t *testing.T) {
+	assert := assert.To(t)
+	for _, member := range []string{
+		`<type>float</type> <name>a</name>]`,
+		`<type>float</type> <name>a</name>[`,
+		`<type>float</type> <name>a</name>[4`,
+		`<type>float</type> <name>a</name>[]`,
+		`<type>float</type> <name>a</name>[[4]]`,
+		`<type>float</type> <name>a</name>[4]x`,
+		`<type>float</type> <name>a</name>[4] ]`,
+	} {
+		_, err := registry.Parse(strings.NewReader(
+			`<registry><types><type category="struct" name="S"><member>` + member + `</member></type></types></registry>`))
+		assert.For("%s", member).ThatError(err).Failed()
+	}
+}
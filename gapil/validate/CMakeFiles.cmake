@@ -18,11 +18,17 @@
 # build and the file will be recreated, check in the new version.
 
 set(files
+    enum_switches.go
     inspect.go
     inspect_test.go
     issues.go
+    map_reads.go
+    no_replay_writes.go
     no_unused.go
+    shadowed_locals.go
+    unobserved_pointers.go
     validate.go
+    validate_test.go
 )
 set(dirs
     
@@ -0,0 +1,90 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"strings"
+
+	"github.com/google/gapid/gapil/resolver"
+	"github.com/google/gapid/gapil/semantic"
+)
+
+// maxMissingCases is the maximum number of missing cases listed in an issue.
+const maxMissingCases = 5
+
+// enumSwitches verifies that switches and selects over enum values without a
+// default case handle every entry of the enum. Switches using conditions that
+// are not enum entries are not checked.
+func enumSwitches(api *semantic.API, mappings *resolver.Mappings) Issues {
+	issues := Issues{}
+	check := func(at semantic.Node, value semantic.Expression, conditions []semantic.Expression) {
+		enum, ok := semantic.Underlying(value.ExpressionType()).(*semantic.Enum)
+		if !ok || enum.IsBitfield {
+			return
+		}
+		handled := map[uint32]bool{}
+		for _, c := range conditions {
+			e, ok := c.(*semantic.EnumEntry)
+			if !ok {
+				return
+			}
+			handled[e.Value] = true
+		}
+		missing := []string{}
+		for _, e := range enum.Entries {
+			if !handled[e.Value] {
+				handled[e.Value] = true // Aliases share a value.
+				missing = append(missing, e.Name())
+			}
+		}
+		if len(missing) == 0 {
+			return
+		}
+		list := missing
+		if len(list) > maxMissingCases {
+			list = append(list[:maxMissingCases:maxMissingCases], "...")
+		}
+		issues.addf(mappings.ParseNode(at), "Switch over %v does not handle %d entries: %v",
+			enum.Name(), len(missing), strings.Join(list, ", "))
+	}
+	var traverse func(n semantic.Node)
+	traverse = func(n semantic.Node) {
+		switch n := n.(type) {
+		case semantic.Type, *semantic.Function:
+			return // Don't traverse into these.
+		case *semantic.Switch:
+			if n.Default == nil {
+				conditions := []semantic.Expression{}
+				for _, c := range n.Cases {
+					conditions = append(conditions, c.Conditions...)
+				}
+				check(n, n.Value, conditions)
+			}
+		case *semantic.Select:
+			if n.Default == nil {
+				conditions := []semantic.Expression{}
+				for _, c := range n.Choices {
+					conditions = append(conditions, c.Conditions...)
+				}
+				check(n, n.Value, conditions)
+			}
+		}
+		semantic.Visit(n, traverse)
+	}
+	for _, f := range functions(api) {
+		traverse(f.Block)
+	}
+	return issues
+}
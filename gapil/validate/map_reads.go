@@ -0,0 +1,194 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"github.com/google/gapid/gapil/ast"
+	"github.com/google/gapid/gapil/resolver"
+	"github.com/google/gapid/gapil/semantic"
+	"github.com/google/gapid/gapil/semantic/printer"
+)
+
+// mapReads verifies that map entries are only read once they are known to be
+// in the map. An entry is known to be in the map after an 'in' test, an
+// assignment to the entry, or inside an iteration over the map.
+// Reads of maps holding references are not reported, as a missing entry is
+// conventionally tested for by comparing the reference with null.
+func mapReads(api *semantic.API, mappings *resolver.Mappings) Issues {
+	c := mapReadChecker{mappings: mappings, issues: Issues{}}
+	for _, f := range functions(api) {
+		c.block(f.Block, guards{})
+	}
+	return c.issues
+}
+
+// guards is the set of map entries known to be in their maps.
+type guards map[string]struct{}
+
+func (g guards) with(keys ...string) guards {
+	out := make(guards, len(g)+len(keys))
+	for k := range g {
+		out[k] = struct{}{}
+	}
+	out.add(keys...)
+	return out
+}
+
+func (g guards) add(keys ...string) {
+	for _, k := range keys {
+		g[k] = struct{}{}
+	}
+}
+
+// entry returns the guard key for the entry k of map m.
+func entry(m, k semantic.Expression) string {
+	return printer.New().WriteExpression(m).String() + "[" + printer.New().WriteExpression(k).String() + "]"
+}
+
+// tested returns the guard keys of the map entries known to be in their maps
+// when cond evaluates to result.
+func tested(cond semantic.Expression, result bool) []string {
+	switch cond := cond.(type) {
+	case *semantic.MapContains:
+		if result {
+			return []string{entry(cond.Map, cond.Key)}
+		}
+	case *semantic.UnaryOp:
+		if cond.Operator == ast.OpNot {
+			return tested(cond.Expression, !result)
+		}
+	case *semantic.BinaryOp:
+		switch {
+		case cond.Operator == ast.OpAnd && result, cond.Operator == ast.OpOr && !result:
+			return append(tested(cond.LHS, result), tested(cond.RHS, result)...)
+		}
+	}
+	return nil
+}
+
+// terminates returns true if the block b always ends with an abort or return.
+func terminates(b *semantic.Block) bool {
+	if b == nil || len(b.Statements) == 0 {
+		return false
+	}
+	switch s := b.Statements[len(b.Statements)-1].(type) {
+	case *semantic.Abort, *semantic.Return:
+		return true
+	case *semantic.Branch:
+		return terminates(s.True) && terminates(s.False)
+	}
+	return false
+}
+
+type mapReadChecker struct {
+	mappings *resolver.Mappings
+	issues   Issues
+}
+
+func (c *mapReadChecker) block(b *semantic.Block, g guards) {
+	if b == nil {
+		return
+	}
+	g = g.with()
+	for _, s := range b.Statements {
+		c.statement(s, g)
+	}
+}
+
+func (c *mapReadChecker) statement(s semantic.Node, g guards) {
+	switch s := s.(type) {
+	case *semantic.Block:
+		c.block(s, g)
+	case *semantic.Branch:
+		c.expression(s.Condition, g)
+		c.block(s.True, g.with(tested(s.Condition, true)...))
+		c.block(s.False, g.with(tested(s.Condition, false)...))
+		if terminates(s.True) {
+			g.add(tested(s.Condition, false)...)
+		}
+		if terminates(s.False) {
+			g.add(tested(s.Condition, true)...)
+		}
+	case *semantic.Assert:
+		c.expression(s.Condition, g)
+		g.add(tested(s.Condition, true)...)
+	case *semantic.Assign:
+		c.location(s.LHS, g)
+		c.expression(s.RHS, g)
+	case *semantic.MapAssign:
+		c.location(s.To.Map, g)
+		c.expression(s.To.Index, g)
+		c.expression(s.Value, g)
+		g.add(entry(s.To.Map, s.To.Index))
+	case *semantic.MapRemove:
+		c.location(s.Map, g)
+		c.expression(s.Key, g)
+		delete(g, entry(s.Map, s.Key))
+	case *semantic.MapIteration:
+		c.expression(s.Map, g)
+		c.block(s.Block, g.with(entry(s.Map, s.KeyIterator)))
+	default:
+		semantic.Visit(s, func(n semantic.Node) {
+			if e, ok := n.(semantic.Expression); ok {
+				c.expression(e, g)
+			} else {
+				c.statement(n, g)
+			}
+		})
+	}
+}
+
+// location checks the expressions used by the assignment target e.
+func (c *mapReadChecker) location(e semantic.Expression, g guards) {
+	switch e := e.(type) {
+	case *semantic.Member:
+		c.location(e.Object, g)
+	case *semantic.MapIndex:
+		c.location(e.Map, g)
+		c.expression(e.Index, g)
+	case *semantic.ArrayIndex:
+		c.location(e.Array, g)
+		c.expression(e.Index, g)
+	default:
+		c.expression(e, g)
+	}
+}
+
+func (c *mapReadChecker) expression(e semantic.Node, g guards) {
+	switch e := e.(type) {
+	case nil, semantic.Type, *semantic.Function, *semantic.Parameter, *semantic.Field, *semantic.Local:
+		// Declarations are not evaluated.
+		return
+	case *semantic.MapIndex:
+		c.expression(e.Map, g)
+		c.expression(e.Index, g)
+		if _, ok := semantic.Underlying(e.Type.ValueType).(*semantic.Reference); ok {
+			return
+		}
+		key := entry(e.Map, e.Index)
+		if _, ok := g[key]; !ok {
+			c.issues.addf(c.mappings.ParseNode(e), "Map entry %v may not be initialized", key)
+			g.add(key) // Only report the first read.
+		}
+		return
+	case *semantic.BinaryOp:
+		if e.Operator == ast.OpAnd || e.Operator == ast.OpOr {
+			c.expression(e.LHS, g)
+			c.expression(e.RHS, g.with(tested(e.LHS, e.Operator == ast.OpAnd)...))
+			return
+		}
+	}
+	semantic.Visit(e, func(n semantic.Node) { c.expression(n, g) })
+}
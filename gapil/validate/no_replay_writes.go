@@ -0,0 +1,52 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"strings"
+
+	"github.com/google/gapid/gapil/analysis"
+	"github.com/google/gapid/gapil/resolver"
+	"github.com/google/gapid/gapil/semantic"
+)
+
+// defaultNoReplayAnnotations is the list of annotations used to mark commands
+// that are not replayed, if Options.NoReplayAnnotations is empty.
+var defaultNoReplayAnnotations = []string{"no_replay"}
+
+// noReplayWrites verifies that commands annotated with one of annotations,
+// and so not replayed, do not write to the API state, as the replay state
+// would diverge from the state observed when capturing.
+func noReplayWrites(api *semantic.API, mappings *resolver.Mappings, annotations []string) Issues {
+	if len(annotations) == 0 {
+		annotations = defaultNoReplayAnnotations
+	}
+	issues := Issues{}
+	accesses := analysis.Accesses(api)
+	for _, f := range api.Functions {
+		for _, name := range annotations {
+			a := f.GetAnnotation(name)
+			if a == nil {
+				continue
+			}
+			if writes := accesses[f].Writes; len(writes) > 0 {
+				issues.addf(mappings.CST(a.AST), "Command %v is annotated @%v but writes to %v",
+					f.Name(), name, strings.Join(writes, ", "))
+			}
+			break
+		}
+	}
+	return issues
+}
@@ -0,0 +1,115 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"github.com/google/gapid/gapil/resolver"
+	"github.com/google/gapid/gapil/semantic"
+)
+
+// shadowedLocals verifies that no local or iterator has the same name as a
+// global, parameter or local of an enclosing scope.
+func shadowedLocals(api *semantic.API, mappings *resolver.Mappings) Issues {
+	c := shadowChecker{mappings: mappings, issues: Issues{}}
+	globals := &scope{names: map[string]semantic.Node{}}
+	for _, g := range api.Globals {
+		globals.names[g.Name()] = g
+	}
+	for _, f := range functions(api) {
+		params := &scope{names: map[string]semantic.Node{}, parent: globals}
+		for _, p := range f.FullParameters {
+			if p != f.Return {
+				params.names[p.Name()] = p
+			}
+		}
+		c.block(f.Block, params)
+	}
+	return c.issues
+}
+
+// scope holds the names declared in a block.
+type scope struct {
+	names  map[string]semantic.Node
+	parent *scope
+}
+
+func (s *scope) lookup(name string) semantic.Node {
+	for ; s != nil; s = s.parent {
+		if n, ok := s.names[name]; ok {
+			return n
+		}
+	}
+	return nil
+}
+
+type shadowChecker struct {
+	mappings *resolver.Mappings
+	issues   Issues
+}
+
+func (c *shadowChecker) block(b *semantic.Block, outer *scope) {
+	if b == nil {
+		return
+	}
+	s := &scope{names: map[string]semantic.Node{}, parent: outer}
+	for _, st := range b.Statements {
+		c.statement(st, s)
+	}
+}
+
+func (c *shadowChecker) statement(n semantic.Node, s *scope) {
+	switch n := n.(type) {
+	case *semantic.Block:
+		c.block(n, s)
+		return
+	case *semantic.DeclareLocal:
+		if n.AST != nil { // Temporaries added by the resolver have no AST.
+			c.declare(n, n.Local, s)
+		}
+		return
+	case *semantic.Iteration:
+		inner := &scope{names: map[string]semantic.Node{}, parent: s}
+		c.declare(n, n.Iterator, inner)
+		c.block(n.Block, inner)
+		return
+	case *semantic.MapIteration:
+		inner := &scope{names: map[string]semantic.Node{}, parent: s}
+		c.declare(n, n.IndexIterator, inner)
+		c.declare(n, n.KeyIterator, inner)
+		c.declare(n, n.ValueIterator, inner)
+		c.block(n.Block, inner)
+		return
+	case semantic.Expression:
+		return // Expressions do not declare locals.
+	}
+	semantic.Visit(n, func(child semantic.Node) { c.statement(child, s) })
+}
+
+// declare adds the local l, declared by the statement at, to the scope s.
+func (c *shadowChecker) declare(at semantic.Node, l *semantic.Local, s *scope) {
+	name := l.Name()
+	if name == "_" {
+		return
+	}
+	switch prev := s.lookup(name).(type) {
+	case *semantic.Global:
+		c.issues.addf(c.mappings.ParseNode(at), "Local %v shadows global %v", name, prev.Name())
+	case *semantic.Parameter:
+		c.issues.addf(c.mappings.ParseNode(at), "Local %v shadows parameter %v", name, prev.Name())
+	case *semantic.Local:
+		c.issues.addf(c.mappings.ParseNode(at), "Local %v shadows local %v", name, prev.Name())
+	}
+	s.names[name] = l
+}
@@ -0,0 +1,89 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"github.com/google/gapid/gapil/resolver"
+	"github.com/google/gapid/gapil/semantic"
+)
+
+// unobservedPointers verifies that the memory of each pointer parameter of
+// the commands is observed with a read, write, copy, index or string cast, or
+// that the pointer is passed on to a subroutine or extern.
+// Pointers declared through pseudonyms are assumed to be opaque handles and
+// are not checked.
+func unobservedPointers(api *semantic.API, mappings *resolver.Mappings) Issues {
+	issues := Issues{}
+	for _, f := range api.Functions {
+		observed := map[*semantic.Parameter]bool{}
+		var mark func(e semantic.Expression)
+		mark = func(e semantic.Expression) {
+			switch e := e.(type) {
+			case *semantic.Parameter:
+				observed[e] = true
+			case *semantic.Local:
+				mark(e.Value)
+			case *semantic.PointerRange:
+				mark(e.Pointer)
+			case *semantic.SliceRange:
+				mark(e.Slice)
+			case *semantic.Cast:
+				mark(e.Object)
+			}
+		}
+		var traverse func(n semantic.Node)
+		traverse = func(n semantic.Node) {
+			switch n := n.(type) {
+			case semantic.Type, *semantic.Function:
+				return // Don't traverse into these.
+			case *semantic.Read:
+				mark(n.Slice)
+			case *semantic.Write:
+				mark(n.Slice)
+			case *semantic.Copy:
+				mark(n.Src)
+				mark(n.Dst)
+			case *semantic.Clone:
+				mark(n.Slice)
+			case *semantic.SliceIndex:
+				mark(n.Slice)
+			case *semantic.SliceAssign:
+				mark(n.To.Slice)
+			case *semantic.Cast:
+				if n.Type == semantic.StringType {
+					mark(n.Object)
+				}
+			case *semantic.Call:
+				for _, a := range n.Arguments {
+					mark(a)
+				}
+			}
+			semantic.Visit(n, traverse)
+		}
+		traverse(f.Block)
+
+		for _, p := range f.CallParameters() {
+			if _, ok := p.Type.(*semantic.Pointer); !ok || observed[p] {
+				continue
+			}
+			if p.GetAnnotation(annoUnused) != nil {
+				continue
+			}
+			issues.addf(mappings.ParseNode(p), "Pointer parameter %v of %v is never read or written",
+				p.Name(), f.Name())
+		}
+	}
+	return issues
+}
@@ -28,12 +28,18 @@ import (
 
 // Options controls the validation that's performed.
 type Options struct {
-	CheckUnused bool // Should unused types, fields, etc be reported?
+	CheckUnused             bool     // Should unused types, fields, etc be reported?
+	CheckMapReads           bool     // Should reads of possibly uninitialized map entries be reported?
+	CheckNoReplayWrites     bool     // Should state writes by commands that are not replayed be reported?
+	CheckUnobservedPointers bool     // Should pointer parameters that are never read or written be reported?
+	CheckShadowedLocals     bool     // Should locals that shadow other declarations be reported?
+	CheckEnumSwitches       bool     // Should enum switches that do not handle every entry be reported?
+	NoReplayAnnotations     []string // Annotations of commands that are not replayed. Defaults to @no_replay.
 }
 
 // Validate performs a number of checks on the api file for correctness.
 // If any problems are found then they are returned as errors.
-// If options is nil then full validation is performed.
+// If options is nil then only the CheckUnused checks are performed.
 func Validate(api *semantic.API, mappings *resolver.Mappings, options *Options) Issues {
 	res := analysis.Analyze(api, mappings)
 	return WithAnalysis(api, mappings, options, res)
@@ -42,16 +48,50 @@ func Validate(api *semantic.API, mappings *resolver.Mappings, options *Options)
 // WithAnalysis performs a number of checks on the api file for
 // correctness using pre-built analysis results.
 // If any problems are found then they are returned as errors.
-// If options is nil then full validation is performed.
+// If options is nil then only the CheckUnused checks are performed.
 func WithAnalysis(api *semantic.API, mappings *resolver.Mappings, options *Options, analysis *analysis.Results) Issues {
 	if options == nil {
-		options = &Options{true}
+		options = &Options{CheckUnused: true}
 	}
 	issues := Issues{}
 	if options.CheckUnused {
 		issues = append(issues, noUnused(api, mappings)...)
 	}
+	if options.CheckMapReads {
+		issues = append(issues, mapReads(api, mappings)...)
+	}
+	if options.CheckNoReplayWrites {
+		issues = append(issues, noReplayWrites(api, mappings, options.NoReplayAnnotations)...)
+	}
+	if options.CheckUnobservedPointers {
+		issues = append(issues, unobservedPointers(api, mappings)...)
+	}
+	if options.CheckShadowedLocals {
+		issues = append(issues, shadowedLocals(api, mappings)...)
+	}
+	if options.CheckEnumSwitches {
+		issues = append(issues, enumSwitches(api, mappings)...)
+	}
 	issues = append(issues, inspect(api, mappings, analysis)...)
 	sort.Sort(issues)
 	return issues
 }
+
+// functions returns all the functions of the API that have a body.
+func functions(api *semantic.API) []*semantic.Function {
+	all := append([]*semantic.Function{}, api.Subroutines...)
+	all = append(all, api.Functions...)
+	for _, c := range api.Classes {
+		all = append(all, c.Methods...)
+	}
+	for _, p := range api.Pseudonyms {
+		all = append(all, p.Methods...)
+	}
+	out := []*semantic.Function{}
+	for _, f := range all {
+		if f.Block != nil {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,199 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapil/validate"
+)
+
+func check(ctx context.Context, options validate.Options, tests []test) {
+	for _, test := range tests {
+		api, mappings, err := compile(ctx, test.source)
+		if !assert.For(ctx, "compile").ThatError(err).Succeeded() {
+			continue
+		}
+		got := fmt.Sprint(validate.Validate(api, mappings, &options))
+		expected := strings.TrimSpace(test.expected)
+		if !assert.For(ctx, "issues").ThatString(got).Equals(expected) {
+			log.E(ctx, "test failed.\n  source: %v", test.source)
+		}
+	}
+}
+
+func TestMapReads(t *testing.T) {
+	check(log.Testing(t), validate.Options{CheckMapReads: true}, []test{
+		{`map!(u32, u32) M
+      u32 V
+      cmd void set(u32 k, u32 v) { M[k] = v }
+      cmd void f(u32 k) {
+        V = M[k]
+        V = M[k]
+      }`, `no_unreachables_test.api:5:13 Map entry M[k] may not be initialized`},
+
+		{`map!(u32, u32) M
+      u32 V
+      cmd void set(u32 k, u32 v) { M[k] = v }
+      cmd void f(u32 k) {
+        if k in M { V = M[k] }
+        if !(k in M) { abort }
+        V = M[k]
+      }`, ``},
+
+		{`map!(u32, u32) M
+      u32 V
+      cmd void set(u32 k, u32 v) { M[k] = v }
+      cmd void f(u32 k) {
+        assert(k in M)
+        V = M[k]
+      }`, ``},
+
+		{`map!(u32, u32) M
+      u32 V
+      cmd void set(u32 k, u32 v) { M[k] = v }
+      cmd void f(u32 k) {
+        M[k] = 1
+        V = M[k]
+        delete(M, k)
+        V = M[k]
+      }`, `no_unreachables_test.api:8:13 Map entry M[k] may not be initialized`},
+
+		{`map!(u32, u32) M
+      u32 V
+      cmd void set(u32 k, u32 v) { M[k] = v }
+      cmd void f(u32 k) {
+        for _, i, _ in M { V = M[i] }
+        if (k in M) && (M[k] == 2) { V = 1 }
+      }`, ``},
+
+		{`class C { u32 X }
+      map!(u32, ref!C) M
+      u32 V
+      cmd void add(u32 k) { M[k] = new!C() }
+      cmd void f(u32 k) {
+        c := M[k]
+        if c != null { V = c.X }
+      }`, ``},
+	})
+}
+
+func TestNoReplayWrites(t *testing.T) {
+	check(log.Testing(t), validate.Options{CheckNoReplayWrites: true}, []test{
+		{`u32 V
+      u32 W
+      sub void set() { W = 2 }
+      @no_replay
+      cmd void f() { set()  V = 1 }
+      @no_replay
+      cmd u32 g() { return V }
+      cmd void h() { V = 1 }`, `no_unreachables_test.api:4:7 Command f is annotated @no_replay but writes to V, W`},
+	})
+}
+
+func TestUnobservedPointers(t *testing.T) {
+	check(log.Testing(t), validate.Options{CheckUnobservedPointers: true}, []test{
+		{`type void* Handle
+      string S
+      sub void observe(u32* p) { read(p[0:1]) }
+      cmd void f(u32* a, u32* b, u32* c, u32* d, char* e, u32* unused, Handle h) {
+        x := c[0]
+        str := as!string(e)
+        read(a[0:1])
+        observe(d)
+        s := b[0:2]
+        write(s)
+        S = str
+      }`, `no_unreachables_test.api:4:59 Pointer parameter unused of f is never read or written`},
+	})
+}
+
+func TestShadowedLocals(t *testing.T) {
+	check(log.Testing(t), validate.Options{CheckShadowedLocals: true}, []test{
+		{`u32 G
+      cmd void f(u32 p) {
+        G := 1
+        p := 2
+        a := 3
+        if a == 3 {
+          a := 4
+          b := 5
+        }
+        b := 6
+        for i in 0 .. 3 { i := 7 }
+      }`, `
+no_unreachables_test.api:3:9 Local G shadows global G
+no_unreachables_test.api:4:9 Local p shadows parameter p
+no_unreachables_test.api:7:11 Local a shadows local a
+no_unreachables_test.api:11:27 Local i shadows local i
+`},
+	})
+}
+
+func TestEnumSwitches(t *testing.T) {
+	check(log.Testing(t), validate.Options{CheckEnumSwitches: true}, []test{
+		{`enum E {
+        E_A = 1
+        E_B = 2
+        E_C = 3
+        E_D = 3
+      }
+      u32 V
+      cmd void f(E e) {
+        switch e {
+          case E_A, E_B, E_C: V = 1
+        }
+        switch e {
+          case E_A: V = 1
+          default: V = 2
+        }
+        switch e {
+          case E_A: V = 1
+        }
+        V = switch e {
+          case E_B: 2
+        }
+      }`, `
+no_unreachables_test.api:16:9 Switch over E does not handle 2 entries: E_B, E_C
+no_unreachables_test.api:19:13 Switch over E does not handle 2 entries: E_A, E_C
+`},
+	})
+}
+
+func TestNilOptions(t *testing.T) {
+	ctx := log.Testing(t)
+	// With nil options, only unused declarations are reported.
+	api, mappings, err := compile(ctx, `enum E { A = 1  B = 2 }
+      enum Unused { X = 1 }
+      map!(u32, u32) M
+      u32 V
+      cmd void set(u32 k, u32 v) { M[k] = v }
+      cmd void f(u32 k, E e) {
+        V = M[k]
+        switch e {
+          case A: {}
+        }
+      }`)
+	if !assert.For(ctx, "compile").ThatError(err).Succeeded() {
+		return
+	}
+	got := fmt.Sprint(validate.Validate(api, mappings, nil))
+	assert.For(ctx, "issues").ThatString(got).Equals(`no_unreachables_test.api:2:7 Type Unused declared but never used`)
+}
@@ -19,6 +19,7 @@
 
 set(files
     api.go
+    buffer.go
     cmd_convert.go
     cmd_errors.go
     cmd_extras.go
@@ -42,6 +43,7 @@ set(files
     state.go
     service.proto
     service.pb.go
+    shader_inputs.go
     subcmd_idx.go
     subcmd_idx_test.go
     subcmd_idx_trie.go
@@ -52,6 +54,7 @@ set(dirs
     .vscode
     all
     core
+    fuzz
     gles
     gvr
     sync
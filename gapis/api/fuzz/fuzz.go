@@ -0,0 +1,101 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fuzz is a fuzzing harness for the generated API command mutators.
+//
+// Random sequences of commands with random, type-correct parameters are
+// mutated against a new state, catching panics, infinite loops, commands that
+// modify their own parameters and violated state invariants. Failing
+// sequences are minimized to short reproducer command lists.
+package fuzz
+
+import (
+	"context"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapil"
+	"github.com/google/gapid/gapis/api"
+)
+
+const maxErrors = 10
+
+// CommandNames returns the names of all the commands declared in the api
+// file at path.
+func CommandNames(path string) ([]string, error) {
+	processor := gapil.NewProcessor()
+	compiled, errs := processor.Resolve(path)
+	if err := gapil.CheckErrors(path, errs, maxErrors); err != nil {
+		return nil, err
+	}
+	out := []string{}
+	for _, f := range compiled.Functions {
+		out = append(out, f.Name())
+	}
+	for _, c := range compiled.Classes {
+		for _, m := range c.Methods {
+			out = append(out, m.Name())
+		}
+	}
+	for _, p := range compiled.Pseudonyms {
+		for _, m := range p.Methods {
+			out = append(out, m.Name())
+		}
+	}
+	return out, nil
+}
+
+// Fuzz mutates iterations random programs generated by c.Generator,
+// returning the minimized program and failure of the first program to fail,
+// or nil if no programs failed.
+func Fuzz(ctx context.Context, c Config, iterations int) (*Program, *Failure) {
+	length := c.Length
+	if length == 0 {
+		length = 32
+	}
+	for i := 0; i < iterations; i++ {
+		p := Program{Memory: c.Generator.Memory(), Cmds: c.Generator.Cmds(length)}
+		if f := Run(ctx, c, p); f != nil {
+			log.I(ctx, "Program %d failed with %v. Minimizing...", i, f)
+			p, f = Minimize(ctx, c, p, f)
+			return &p, f
+		}
+	}
+	return nil, nil
+}
+
+// Minimize returns the smallest program found by removing commands from p
+// that still fails in the same way as f, along with its failure.
+// A failure in the prologue cannot be minimized.
+func Minimize(ctx context.Context, c Config, p Program, f *Failure) (Program, *Failure) {
+	if f.Index < 0 {
+		return p, f
+	}
+	// Commands after the failing command play no part.
+	cmds := p.Cmds[:f.Index+1]
+	for chunk := len(cmds) / 2; chunk > 0; chunk /= 2 {
+		for start := 0; start < len(cmds); {
+			end := start + chunk
+			if end > len(cmds) {
+				end = len(cmds)
+			}
+			candidate := append(append([]api.Cmd{}, cmds[:start]...), cmds[end:]...)
+			if got := Run(ctx, c, Program{p.Memory, candidate}); f.same(got) {
+				cmds, f = candidate[:got.Index+1], got
+			} else {
+				start = end
+			}
+		}
+	}
+	return Program{p.Memory, cmds}, f
+}
@@ -0,0 +1,230 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fuzz_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/image"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapil/constset"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/fuzz"
+	"github.com/google/gapid/gapis/replay/builder"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+var fakeID = api.ID{4, 5, 6}
+
+type fakeAPI struct{}
+
+func (fakeAPI) Name() string { return "fake" }
+func (fakeAPI) ID() api.ID   { return fakeID }
+func (fakeAPI) Index() uint8 { return 14 }
+func (fakeAPI) ConstantSets() *constset.Pack {
+	return &constset.Pack{Sets: []constset.Set{{Entries: []constset.Entry{{V: 42}}}}}
+}
+func (fakeAPI) GetFramebufferAttachmentInfo(*api.GlobalState, uint64, api.FramebufferAttachment) (uint32, uint32, uint32, *image.Format, error) {
+	return 0, 0, 0, nil, nil
+}
+func (fakeAPI) Context(*api.GlobalState, uint64) api.Context { return nil }
+func (fakeAPI) CreateCmd(name string) api.Cmd {
+	switch name {
+	case "inc":
+		return &inc{fakeCmd{name}}
+	case "crash":
+		return &crash{fakeCmd{name}}
+	case "hang":
+		return &hang{fakeCmd{name}}
+	case "modify":
+		return &modify{fakeCmd: fakeCmd{name}}
+	case "nop":
+		return &nop{fakeCmd: fakeCmd{name}}
+	default:
+		return nil
+	}
+}
+
+type fakeState struct{ count int }
+
+func (*fakeState) Root(context.Context, *path.State) (path.Node, error) { return nil, nil }
+
+func state(s *api.GlobalState) *fakeState {
+	if st, ok := s.APIs[fakeID].(*fakeState); ok {
+		return st
+	}
+	st := &fakeState{}
+	s.APIs[fakeID] = st
+	return st
+}
+
+type fakeCmd struct{ name string }
+
+func (c *fakeCmd) Caller() api.CmdID                                                  { return api.CmdNoID }
+func (c *fakeCmd) SetCaller(api.CmdID)                                                {}
+func (c *fakeCmd) Thread() uint64                                                     { return 0 }
+func (c *fakeCmd) SetThread(uint64)                                                   {}
+func (c *fakeCmd) CmdName() string                                                    { return c.name }
+func (c *fakeCmd) API() api.API                                                       { return fakeAPI{} }
+func (c *fakeCmd) CmdFlags(context.Context, api.CmdID, *api.GlobalState) api.CmdFlags { return 0 }
+func (c *fakeCmd) Extras() *api.CmdExtras                                             { return nil }
+
+// inc increments the state counter.
+type inc struct{ fakeCmd }
+
+func (c *inc) Mutate(ctx context.Context, id api.CmdID, s *api.GlobalState, b *builder.Builder) error {
+	state(s).count++
+	return nil
+}
+
+// crash panics once the state counter reaches 3.
+type crash struct{ fakeCmd }
+
+func (c *crash) Mutate(ctx context.Context, id api.CmdID, s *api.GlobalState, b *builder.Builder) error {
+	if state(s).count >= 3 {
+		panic("boom")
+	}
+	return nil
+}
+
+// hang never returns.
+type hang struct{ fakeCmd }
+
+func (c *hang) Mutate(ctx context.Context, id api.CmdID, s *api.GlobalState, b *builder.Builder) error {
+	select {}
+}
+
+// modify changes its own parameter.
+type modify struct {
+	fakeCmd
+	Value uint32 `param:"value"`
+}
+
+func (c *modify) Mutate(ctx context.Context, id api.CmdID, s *api.GlobalState, b *builder.Builder) error {
+	c.Value++
+	return nil
+}
+
+// nop has parameters of many types, and does nothing with them.
+type nop struct {
+	fakeCmd
+	Enum   uint32          `param:"enum" constset:"0"`
+	Signed int16           `param:"signed"`
+	Float  float32         `param:"float"`
+	Str    string          `param:"str"`
+	Bool   bool            `param:"bool"`
+	Ptr    fakePointer     `param:"ptr"`
+	Result uint64          `result:"true"`
+	Map    map[int]float32 `param:"map"`
+}
+
+func (c *nop) Mutate(ctx context.Context, id api.CmdID, s *api.GlobalState, b *builder.Builder) error {
+	return api.Abort("nop")
+}
+
+type fakePointer struct{ addr uint64 }
+
+func (p *fakePointer) Assign(o interface{}) bool {
+	if o, ok := o.(interface{ Address() uint64 }); ok {
+		p.addr = o.Address()
+		return true
+	}
+	return false
+}
+
+func names(cmds []api.Cmd) []string {
+	out := make([]string, len(cmds))
+	for i, c := range cmds {
+		out[i] = c.CmdName()
+	}
+	return out
+}
+
+func TestGenerator(t *testing.T) {
+	ctx := log.Testing(t)
+	all := []string{"inc", "crash", "hang", "modify", "nop", "unknown"}
+	a := fuzz.NewGenerator(fakeAPI{}, all, 1)
+	b := fuzz.NewGenerator(fakeAPI{}, all, 1)
+	assert.For(ctx, "commands").ThatSlice(a.Commands).Equals([]string{"inc", "crash", "hang", "modify", "nop"})
+	assert.For(ctx, "memory").ThatSlice(a.Memory()).Equals(b.Memory())
+
+	g := fuzz.NewGenerator(fakeAPI{}, []string{"nop"}, 2)
+	enums, pointers := 0, 0
+	for i := 0; i < 100; i++ {
+		c := g.Cmd().(*nop)
+		if c.Enum == 42 {
+			enums++
+		}
+		if p := c.Ptr.addr; p != 0 {
+			pointers++
+			assert.For(ctx, "pointer").That(p >= fuzz.MemoryBase && p < fuzz.MemoryBase+fuzz.MemorySize).Equals(true)
+		}
+		assert.For(ctx, "map").That(c.Map == nil).Equals(true)
+	}
+	assert.For(ctx, "constant set values").That(enums > 50).Equals(true)
+	assert.For(ctx, "non-null pointers").That(pointers > 50).Equals(true)
+}
+
+func TestRunFailures(t *testing.T) {
+	ctx := log.Testing(t)
+	cmd := func(name string) api.Cmd { return fakeAPI{}.CreateCmd(name) }
+	atMost5 := func(ctx context.Context, c api.Cmd, s *api.GlobalState) error {
+		if n := state(s).count; n > 5 {
+			return fmt.Errorf("count is %d", n)
+		}
+		return nil
+	}
+	config := fuzz.Config{Invariants: []fuzz.Invariant{atMost5}, Timeout: 50 * time.Millisecond}
+	for _, test := range []struct {
+		name  string
+		cmds  []api.Cmd
+		kind  fuzz.Kind
+		index int
+	}{
+		{"panic", []api.Cmd{cmd("inc"), cmd("crash"), cmd("inc"), cmd("inc"), cmd("crash")}, fuzz.Panic, 4},
+		{"timeout", []api.Cmd{cmd("nop"), cmd("hang")}, fuzz.Timeout, 1},
+		{"modified", []api.Cmd{cmd("modify")}, fuzz.ModifiedCmd, 0},
+		{"invariant", []api.Cmd{cmd("inc"), cmd("inc"), cmd("inc"), cmd("inc"), cmd("inc"), cmd("nop"), cmd("inc")}, fuzz.InvariantViolated, 6},
+	} {
+		f := fuzz.Run(ctx, config, fuzz.Program{Cmds: test.cmds})
+		if !assert.For(ctx, "%v failure", test.name).That(f != nil).Equals(true) {
+			continue
+		}
+		assert.For(ctx, "%v kind", test.name).That(f.Kind).Equals(test.kind)
+		assert.For(ctx, "%v index", test.name).That(f.Index).Equals(test.index)
+	}
+
+	f := fuzz.Run(ctx, config, fuzz.Program{Cmds: []api.Cmd{cmd("inc"), cmd("nop"), cmd("crash")}})
+	assert.For(ctx, "success").That(f == nil).Equals(true)
+}
+
+func TestFuzzMinimizes(t *testing.T) {
+	ctx := log.Testing(t)
+	config := fuzz.Config{
+		Generator: fuzz.NewGenerator(fakeAPI{}, []string{"inc", "crash", "nop"}, 3),
+		Length:    64,
+	}
+	p, f := fuzz.Fuzz(ctx, config, 10)
+	if !assert.For(ctx, "failure").That(f != nil).Equals(true) {
+		return
+	}
+	assert.For(ctx, "kind").That(f.Kind).Equals(fuzz.Panic)
+	assert.For(ctx, "reproducer").ThatSlice(names(p.Cmds)).Equals([]string{"inc", "inc", "inc", "crash"})
+	assert.For(ctx, "failure index").That(f.Index).Equals(3)
+}
@@ -0,0 +1,212 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fuzz
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"strconv"
+
+	"github.com/google/gapid/core/data"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/memory"
+)
+
+const (
+	paramTag    = "param"
+	resultTag   = "result"
+	constsetTag = "constset"
+
+	// MemoryBase is the application pool address of the random memory that
+	// generated pointers point into.
+	MemoryBase = 0x10000
+	// MemorySize is the size in bytes of the random memory at MemoryBase.
+	MemorySize = 0x1000
+
+	// maxReused is the maximum number of generated values remembered per type.
+	maxReused = 16
+)
+
+// interesting holds integer values that commonly expose edge cases.
+var interesting = []uint64{
+	0, 1, 2, 3, 4, 7, 8, 15, 16, 31, 32, 63, 64, 127, 128, 255, 256, 1023, 1024,
+	math.MaxInt16, math.MaxUint16, math.MaxInt32, math.MaxUint32, math.MaxInt64,
+	math.MaxUint64, math.MaxUint64 - 1,
+}
+
+// interestingFloats holds floating-point values that commonly expose edge
+// cases.
+var interestingFloats = []float64{
+	0, 1, -1, 0.5, -0.5, math.MaxFloat32, -math.MaxFloat32, math.SmallestNonzeroFloat32,
+	math.Inf(1), math.Inf(-1), math.NaN(),
+}
+
+// Generator produces commands with random, type-correct parameters.
+type Generator struct {
+	// API is the API that the commands are created from.
+	API api.API
+	// Commands is the list of command names that the generator picks from.
+	Commands []string
+	// Rand is the source of randomness.
+	Rand *rand.Rand
+
+	// reused holds previously generated values by type. Values are reused so
+	// that commands are linked by the handles they create and consume.
+	reused map[reflect.Type][]reflect.Value
+}
+
+// NewGenerator returns a new Generator for the commands of a with the given
+// names, seeded with seed. Names that a does not create commands for are
+// ignored.
+func NewGenerator(a api.API, names []string, seed int64) *Generator {
+	g := &Generator{
+		API:    a,
+		Rand:   rand.New(rand.NewSource(seed)),
+		reused: map[reflect.Type][]reflect.Value{},
+	}
+	for _, n := range names {
+		if a.CreateCmd(n) != nil {
+			g.Commands = append(g.Commands, n)
+		}
+	}
+	return g
+}
+
+// Memory returns MemorySize bytes of random data to place at MemoryBase.
+func (g *Generator) Memory() []byte {
+	out := make([]byte, MemorySize)
+	g.Rand.Read(out)
+	return out
+}
+
+// Cmds returns count new random commands.
+func (g *Generator) Cmds(count int) []api.Cmd {
+	out := make([]api.Cmd, count)
+	for i := range out {
+		out[i] = g.Cmd()
+	}
+	return out
+}
+
+// Cmd returns a new random command with random parameters and result.
+func (g *Generator) Cmd() api.Cmd {
+	cmd := g.API.CreateCmd(g.Commands[g.Rand.Intn(len(g.Commands))])
+	v := reflect.ValueOf(cmd).Elem()
+	t := v.Type()
+	for i, count := 0, t.NumField(); i < count; i++ {
+		f, t := v.Field(i), t.Field(i)
+		_, isParam := t.Tag.Lookup(paramTag)
+		_, isResult := t.Tag.Lookup(resultTag)
+		if !(isParam || isResult) || !f.CanSet() {
+			continue
+		}
+		cs := -1
+		if s, ok := t.Tag.Lookup(constsetTag); ok {
+			cs, _ = strconv.Atoi(s)
+		}
+		g.value(f, cs)
+	}
+	return cmd
+}
+
+// value assigns a random value to v, using the constant set with the index cs
+// for integers if cs is not negative.
+func (g *Generator) value(v reflect.Value, cs int) {
+	ty := v.Type()
+	if reused := g.reused[ty]; len(reused) > 0 && g.Rand.Intn(3) == 0 {
+		v.Set(reused[g.Rand.Intn(len(reused))])
+		return
+	}
+
+	if p, ok := v.Addr().Interface().(data.Assignable); ok && v.Kind() == reflect.Struct {
+		addr := uint64(0)
+		if g.Rand.Intn(4) != 0 {
+			addr = MemoryBase + uint64(g.Rand.Intn(MemorySize))&^7
+		}
+		p.Assign(memory.BytePtr(addr, memory.ApplicationPool))
+	} else {
+		switch v.Kind() {
+		case reflect.Bool:
+			v.SetBool(g.Rand.Intn(2) == 0)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v.SetInt(int64(g.integer(cs)))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			v.SetUint(g.integer(cs))
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(g.float())
+		case reflect.String:
+			v.SetString(g.string())
+		default:
+			// Slices, maps and classes are left as their zero value.
+			return
+		}
+	}
+
+	reused := append(g.reused[ty], reflect.ValueOf(v.Interface()))
+	if len(reused) > maxReused {
+		reused = reused[1:]
+	}
+	g.reused[ty] = reused
+}
+
+// integer returns a random integer, biased towards small and interesting
+// values. If cs is not negative then the value is usually picked from the
+// API's constant set with that index.
+func (g *Generator) integer(cs int) uint64 {
+	if cs >= 0 && g.Rand.Intn(4) != 0 {
+		if pack := g.API.ConstantSets(); pack != nil && cs < len(pack.Sets) {
+			if set := pack.Sets[cs]; len(set.Entries) > 0 {
+				if !set.IsBitfield {
+					return set.Entries[g.Rand.Intn(len(set.Entries))].V
+				}
+				out := uint64(0)
+				for _, e := range set.Entries {
+					if g.Rand.Intn(len(set.Entries)) == 0 {
+						out |= e.V
+					}
+				}
+				return out
+			}
+		}
+	}
+	switch g.Rand.Intn(10) {
+	case 0, 1, 2, 3, 4:
+		return uint64(g.Rand.Intn(16))
+	case 5, 6, 7:
+		return interesting[g.Rand.Intn(len(interesting))]
+	case 8:
+		return -interesting[g.Rand.Intn(len(interesting))]
+	default:
+		return uint64(g.Rand.Int63())<<1 | uint64(g.Rand.Intn(2))
+	}
+}
+
+// float returns a random floating-point value.
+func (g *Generator) float() float64 {
+	if g.Rand.Intn(2) == 0 {
+		return interestingFloats[g.Rand.Intn(len(interestingFloats))]
+	}
+	return (g.Rand.Float64() - 0.5) * math.Pow(2, float64(g.Rand.Intn(64)))
+}
+
+// string returns a short random string.
+func (g *Generator) string() string {
+	out := make([]byte, g.Rand.Intn(8))
+	for i := range out {
+		out[i] = byte(' ' + g.Rand.Intn('~'-' '+1))
+	}
+	return string(out)
+}
@@ -0,0 +1,216 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fuzz
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"time"
+
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/memory"
+)
+
+// Kind is an enumerator of the ways a command can fail.
+type Kind int
+
+const (
+	// Panic is the failure of a command that panicked.
+	Panic = Kind(iota)
+	// Timeout is the failure of a command that did not finish in time,
+	// typically due to an infinite loop.
+	Timeout
+	// ModifiedCmd is the failure of a command that changed its own parameters.
+	ModifiedCmd
+	// InvariantViolated is the failure of a command that left the state
+	// violating an Invariant.
+	InvariantViolated
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Panic:
+		return "Panic"
+	case Timeout:
+		return "Timeout"
+	case ModifiedCmd:
+		return "ModifiedCmd"
+	case InvariantViolated:
+		return "InvariantViolated"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// Invariant is a check of the state after the command cmd has been mutated.
+// Invariants return an error describing any violation.
+type Invariant func(ctx context.Context, cmd api.Cmd, s *api.GlobalState) error
+
+// Program is a sequence of commands and the initial application memory that
+// they are mutated against.
+type Program struct {
+	// Memory is the data placed at MemoryBase in the application pool.
+	Memory []byte
+	// Cmds is the list of commands.
+	Cmds []api.Cmd
+}
+
+func (p Program) String() string {
+	buf := &bytes.Buffer{}
+	for i, c := range p.Cmds {
+		fmt.Fprintf(buf, "%d: %v\n", i, c)
+	}
+	return buf.String()
+}
+
+// Failure describes a command that failed while being mutated.
+type Failure struct {
+	// Kind is the way the command failed.
+	Kind Kind
+	// Index is the index of the failing command in the program.
+	Index int
+	// Cmd is the failing command.
+	Cmd api.Cmd
+	// Err is the panic value or error describing the failure.
+	Err interface{}
+	// Stack is the stack of the panic, if the failure is a Panic.
+	Stack string
+}
+
+func (f *Failure) String() string {
+	return fmt.Sprintf("%v in command %d %v: %v", f.Kind, f.Index, f.Cmd.CmdName(), f.Err)
+}
+
+// same returns true if f and o are considered to be the same failure.
+func (f *Failure) same(o *Failure) bool {
+	return o != nil && f.Kind == o.Kind && f.Cmd.CmdName() == o.Cmd.CmdName()
+}
+
+// Config holds the options for running and fuzzing commands.
+type Config struct {
+	// Generator creates the random commands.
+	Generator *Generator
+	// Prologue is a list of commands mutated before every program, for example
+	// to create and bind a context. The prologue is not minimized.
+	Prologue []api.Cmd
+	// Invariants are checked after every command of the program.
+	Invariants []Invariant
+	// Length is the number of commands in each program.
+	Length int
+	// Timeout is the time that each command has to be mutated in.
+	Timeout time.Duration
+	// MemoryLayout is the memory layout of the state.
+	MemoryLayout *device.MemoryLayout
+}
+
+func (c *Config) timeout() time.Duration {
+	if c.Timeout == 0 {
+		return time.Second
+	}
+	return c.Timeout
+}
+
+// Run mutates a new state with the prologue followed by the program p,
+// returning the first failure, or nil if all commands succeeded.
+// Commands that return errors, such as aborts, are not considered failures.
+// As goroutines cannot be killed, commands that time out are left running.
+func Run(ctx context.Context, c Config, p Program) *Failure {
+	layout := c.MemoryLayout
+	if layout == nil {
+		layout = device.Little64
+	}
+	s := api.NewStateWithEmptyAllocator(layout)
+	s.Memory.ApplicationPool().Write(MemoryBase, memory.Blob(p.Memory))
+
+	for i, cmd := range c.Prologue {
+		if f := c.mutate(ctx, api.CmdID(i), cmd, s); f != nil {
+			f.Index = -1
+			return f
+		}
+	}
+	for i, cmd := range p.Cmds {
+		id := api.CmdID(len(c.Prologue) + i)
+		f := c.mutate(ctx, id, cmd, s)
+		if f == nil {
+			for _, invariant := range c.Invariants {
+				if err := invariant(ctx, cmd, s); err != nil {
+					f = &Failure{Kind: InvariantViolated, Cmd: cmd, Err: err}
+					break
+				}
+			}
+		}
+		if f != nil {
+			f.Index = i
+			return f
+		}
+	}
+	return nil
+}
+
+// mutate mutates s with cmd, catching panics, timeouts and modifications
+// to the command.
+func (c *Config) mutate(ctx context.Context, id api.CmdID, cmd api.Cmd, s *api.GlobalState) *Failure {
+	before := params(cmd)
+	done := make(chan *Failure, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				stack := make([]byte, 1<<16)
+				stack = stack[:runtime.Stack(stack, false)]
+				done <- &Failure{Kind: Panic, Cmd: cmd, Err: r, Stack: string(stack)}
+			}
+		}()
+		cmd.Mutate(ctx, id, s, nil)
+		done <- nil
+	}()
+
+	select {
+	case f := <-done:
+		if f != nil {
+			return f
+		}
+	case <-time.After(c.timeout()):
+		return &Failure{Kind: Timeout, Cmd: cmd, Err: fmt.Sprintf("not finished after %v", c.timeout())}
+	}
+
+	// Parameters are compared by their printed form, as NaN != NaN.
+	if after := params(cmd); fmt.Sprintf("%#v", before) != fmt.Sprintf("%#v", after) {
+		return &Failure{Kind: ModifiedCmd, Cmd: cmd, Err: fmt.Sprintf("parameters changed from %v to %v", before, after)}
+	}
+	return nil
+}
+
+// params returns a copy of the parameter and result values of cmd.
+func params(cmd api.Cmd) []interface{} {
+	v := reflect.ValueOf(cmd)
+	for v.Kind() != reflect.Struct {
+		v = v.Elem()
+	}
+	t := v.Type()
+	out := []interface{}{}
+	for i, count := 0, t.NumField(); i < count; i++ {
+		f, t := v.Field(i), t.Field(i)
+		_, isParam := t.Tag.Lookup(paramTag)
+		_, isResult := t.Tag.Lookup(resultTag)
+		if (isParam || isResult) && f.CanInterface() {
+			out = append(out, f.Interface())
+		}
+	}
+	return out
+}
@@ -19,6 +19,7 @@
 
 set(files
     api.go
+    buffer_view.go
     compat.go
     compat_client.go
     compat_test.go
@@ -35,6 +36,7 @@ set(files
     externs.go
     extras.go
     find_issues.go
+    fuzz_test.go
     gles.go
     glsl_compat.go
     glsl_compat_test.go
@@ -48,6 +50,8 @@ set(files
     markers.go
     markers_test.go
     mutate.go
+    pixel_history.go
+    pixel_history_test.go
     read_texture.go
     read_framebuffer.go
     replay.go
@@ -55,11 +59,14 @@ set(files
     resolvables.proto
     resources.go
     resources_test.go
+    shader_inputs.go
+    shader_inputs_test.go
     state.go
     string.go
     stub_program.go
     stub_program_test.go
     texture_compat.go
+    texture_compat_test.go
     tweaker.go
     undefined_framebuffer.go
     version.go
@@ -0,0 +1,209 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/stream"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/resolve"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// BufferData implements the api.BufferDataProvider interface.
+func (API) BufferData(ctx context.Context, p *path.BufferView) ([]byte, *path.BufferLayout, error) {
+	if len(p.After.Indices) > 1 {
+		return nil, nil, log.Errf(ctx, nil, "GLES does not support subcommands")
+	}
+	cmd, err := resolve.Cmd(ctx, p.After)
+	if err != nil {
+		return nil, nil, err
+	}
+	s, err := resolve.GlobalState(ctx, p.After.GlobalStateAfter())
+	if err != nil {
+		return nil, nil, err
+	}
+	c := GetContext(s, cmd.Thread())
+	if c == nil {
+		return nil, nil, &service.ErrDataUnavailable{Reason: messages.ErrNoContextBound(cmd.Thread())}
+	}
+
+	var buffer *Buffer
+	layout := p.Layout
+	switch p.Binding.GetKind() {
+	case path.BufferBinding_None:
+		buffer = c.Objects.Shared.Buffers[BufferId(p.Buffer)]
+		if buffer == nil {
+			return nil, nil, fmt.Errorf("Buffer %v does not exist", p.Buffer)
+		}
+
+	case path.BufferBinding_VertexAttribute:
+		vaa := c.Bound.VertexArray.VertexAttributeArrays[AttributeLocation(p.Binding.Index)]
+		if vaa == nil {
+			return nil, nil, fmt.Errorf("Vertex attribute %v does not exist", p.Binding.Index)
+		}
+		vbb := c.Bound.VertexArray.VertexBufferBindings[vaa.Binding]
+		if vbb == nil || vbb.Buffer == 0 {
+			return nil, nil, fmt.Errorf("Vertex attribute %v is not backed by a buffer", p.Binding.Index)
+		}
+		buffer = c.Objects.Shared.Buffers[vbb.Buffer]
+		if buffer == nil {
+			return nil, nil, fmt.Errorf("Buffer %v of vertex attribute %v does not exist", vbb.Buffer, p.Binding.Index)
+		}
+		if layout == nil {
+			format, err := translateVertexFormat(vaa)
+			if err != nil {
+				return nil, nil, err
+			}
+			layout = &path.BufferLayout{
+				Offset: uint64(vbb.Offset) + uint64(vaa.RelativeOffset),
+				Stride: uint32(vbb.Stride),
+				Fields: []*path.BufferField{{Name: fmt.Sprintf("attribute %d", p.Binding.Index), Format: format}},
+			}
+		}
+
+	case path.BufferBinding_UniformBlock:
+		program := c.Bound.Program
+		if program == nil {
+			return nil, nil, &service.ErrDataUnavailable{Reason: messages.ErrNoProgramBound()}
+		}
+		block, ok := program.ActiveUniformBlocks[UniformBlockIndex(p.Binding.Index)]
+		if !ok {
+			return nil, nil, fmt.Errorf("Uniform block %v does not exist", p.Binding.Index)
+		}
+		binding, ok := c.Bound.UniformBuffers[GLuint(block.Binding)]
+		if !ok || binding.Binding == nil {
+			return nil, nil, fmt.Errorf("No buffer bound to uniform block '%v'", block.Name)
+		}
+		buffer = binding.Binding
+		if layout == nil {
+			fields, err := uniformBlockFields(block)
+			if err != nil {
+				return nil, nil, err
+			}
+			layout = &path.BufferLayout{Offset: uint64(binding.Start), Count: 1, Fields: fields}
+		}
+
+	default:
+		return nil, nil, fmt.Errorf("Unsupported buffer binding %v", p.Binding.Kind)
+	}
+
+	data := buffer.Data.Read(ctx, nil, s, nil)
+	return data, layout, nil
+}
+
+// uniformBlockFields returns the buffer fields of each uniform in the block.
+// Arrays and matrices are split into a field per element and column (or row
+// for row-major matrices).
+func uniformBlockFields(block ActiveUniformBlock) ([]*path.BufferField, error) {
+	uniforms := make([]ActiveUniform, 0, len(block.ActiveUniforms))
+	for _, u := range block.ActiveUniforms {
+		uniforms = append(uniforms, u)
+	}
+	sort.Slice(uniforms, func(i, j int) bool { return uniforms[i].Offset < uniforms[j].Offset })
+
+	xyzw := []stream.Channel{
+		stream.Channel_X,
+		stream.Channel_Y,
+		stream.Channel_Z,
+		stream.Channel_W,
+	}
+	fields := []*path.BufferField{}
+	for _, u := range uniforms {
+		format, ty := uniformFormatAndType(u.Type)
+		if format == api.UniformFormat_Sampler {
+			return nil, fmt.Errorf("Uniform '%v' of block '%v' has unsupported type %v", u.Name, block.Name, u.Type)
+		}
+		var dt stream.DataType
+		switch ty {
+		case api.UniformType_Int32:
+			dt = stream.S32
+		case api.UniformType_Uint32, api.UniformType_Bool:
+			dt = stream.U32
+		case api.UniformType_Float:
+			dt = stream.F32
+		case api.UniformType_Double:
+			dt = stream.F64
+		}
+		vectors, size := uniformShape(format)
+		if u.IsRowMajor != 0 {
+			vectors, size = size, vectors
+		}
+		f := &stream.Format{Components: make([]*stream.Component, size)}
+		for i := range f.Components {
+			f.Components[i] = &stream.Component{
+				DataType: &dt,
+				Sampling: stream.Linear,
+				Channel:  xyzw[i],
+			}
+		}
+
+		name := strings.TrimSuffix(u.Name, "[0]")
+		for e := 0; e < int(u.ArraySize); e++ {
+			elName, elOffset := name, int(u.Offset)
+			if u.ArraySize > 1 || name != u.Name {
+				elName, elOffset = fmt.Sprintf("%v[%d]", name, e), elOffset+e*int(u.ArrayStride)
+			}
+			for v := 0; v < vectors; v++ {
+				fieldName, fieldOffset := elName, elOffset
+				if vectors > 1 {
+					fieldName, fieldOffset = fmt.Sprintf("%v[%d]", elName, v), fieldOffset+v*int(u.MatrixStride)
+				}
+				fields = append(fields, &path.BufferField{Name: fieldName, Offset: uint32(fieldOffset), Format: f})
+			}
+		}
+	}
+	return fields, nil
+}
+
+// uniformShape returns the number of column vectors and the number of
+// components in each vector of the uniform format f.
+func uniformShape(f api.UniformFormat) (vectors, size int) {
+	switch f {
+	case api.UniformFormat_Vec2:
+		return 1, 2
+	case api.UniformFormat_Vec3:
+		return 1, 3
+	case api.UniformFormat_Vec4:
+		return 1, 4
+	case api.UniformFormat_Mat2:
+		return 2, 2
+	case api.UniformFormat_Mat3:
+		return 3, 3
+	case api.UniformFormat_Mat4:
+		return 4, 4
+	case api.UniformFormat_Mat2x3:
+		return 2, 3
+	case api.UniformFormat_Mat2x4:
+		return 2, 4
+	case api.UniformFormat_Mat3x2:
+		return 3, 2
+	case api.UniformFormat_Mat3x4:
+		return 3, 4
+	case api.UniformFormat_Mat4x2:
+		return 4, 2
+	case api.UniformFormat_Mat4x3:
+		return 4, 3
+	default:
+		return 1, 1
+	}
+}
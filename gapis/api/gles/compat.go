@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/google/gapid/core/data/deep"
+	"github.com/google/gapid/core/image"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/core/math/u32"
 	"github.com/google/gapid/core/os/device"
@@ -111,7 +112,7 @@ func getFeatures(ctx context.Context, version string, ext extensions) (features,
 		vertexHalfFloatOES:        ext.get("GL_OES_vertex_half_float"),
 		eglImageExternal:          ext.get("GL_OES_EGL_image_external"),
 		textureMultisample:        ext.get("ARB_texture_multisample"),
-		compressedTextureFormats:  getSupportedCompressedTextureFormats(ext),
+		compressedTextureFormats:  getSupportedCompressedTextureFormats(v, ext),
 		supportGenerateMipmapHint: v.IsES,
 	}
 
@@ -154,6 +155,9 @@ func compat(ctx context.Context, device *device.Instance) (transform.Transformer
 			err, glDev.Version, glDev.Extensions)
 	}
 
+	// The quality used to re-encode textures of unsupported compressed formats.
+	quality := image.GetEncoderQuality(ctx)
+
 	contexts := map[*Context]features{}
 
 	scratchBuffers := map[interface{}]scratchBuffer{}
@@ -543,18 +547,18 @@ func compat(ctx context.Context, device *device.Instance) (transform.Transformer
 
 		case *GlCompressedTexImage2D:
 			if _, supported := target.compressedTextureFormats[cmd.Internalformat]; !supported {
-				if err := decompressTexImage2D(ctx, id, cmd, s, out); err == nil {
+				if err := transcodeTexImage2D(ctx, id, cmd, target.getTranscodeFormat(cmd.Internalformat), quality, s, out); err == nil {
 					return
 				}
-				log.E(ctx, "Error decompressing texture: %v", err)
+				log.E(ctx, "Error transcoding texture: %v", err)
 			}
 
 		case *GlCompressedTexSubImage2D:
 			if _, supported := target.compressedTextureFormats[cmd.Internalformat]; !supported {
-				if err := decompressTexSubImage2D(ctx, id, cmd, s, out); err == nil {
+				if err := transcodeTexSubImage2D(ctx, id, cmd, target.getTranscodeFormat(cmd.Internalformat), quality, s, out); err == nil {
 					return
 				}
-				log.E(ctx, "Error decompressing texture: %v", err)
+				log.E(ctx, "Error transcoding texture: %v", err)
 			}
 
 		case *GlTexBufferEXT:
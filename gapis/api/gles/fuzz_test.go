@@ -0,0 +1,95 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device/bind"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/fuzz"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/memory"
+)
+
+var (
+	fuzzIterations = flag.Int("fuzz.iterations", 0, "Number of random command sequences mutated by TestFuzz")
+	fuzzSeed       = flag.Int64("fuzz.seed", 1, "Seed of the random command sequences mutated by TestFuzz")
+)
+
+// contextBound checks that the context bound by the prologue is still bound.
+func contextBound(ctx context.Context, cmd api.Cmd, s *api.GlobalState) error {
+	if GetContext(s, cmd.Thread()) == nil {
+		return fmt.Errorf("No context bound")
+	}
+	return nil
+}
+
+// buffersKeyedByID checks that every shared buffer is keyed by its own ID.
+func buffersKeyedByID(ctx context.Context, cmd api.Cmd, s *api.GlobalState) error {
+	c := GetContext(s, cmd.Thread())
+	if c == nil {
+		return nil
+	}
+	for id, b := range c.Objects.Shared.Buffers {
+		if b != nil && b.ID != id {
+			return fmt.Errorf("Buffer %v is keyed by %v", b.ID, id)
+		}
+	}
+	return nil
+}
+
+func TestFuzz(t *testing.T) {
+	if *fuzzIterations == 0 {
+		t.Skip("Fuzzing is disabled. Enable with -fuzz.iterations=N")
+	}
+	ctx := log.Testing(t)
+	ctx = bind.PutRegistry(ctx, bind.NewRegistry())
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	all, err := fuzz.CommandNames("gles.api")
+	if !assert.For(ctx, "CommandNames").ThatError(err).Succeeded() {
+		return
+	}
+	// Only fuzz the GL commands. The prologue binds the context that they use.
+	names := []string{}
+	for _, n := range all {
+		if strings.HasPrefix(n, "gl") && !strings.HasPrefix(n, "glX") {
+			names = append(names, n)
+		}
+	}
+
+	ctxHandle := memory.BytePtr(1, memory.ApplicationPool)
+	cb := CommandBuilder{Thread: 0}
+	config := fuzz.Config{
+		Generator: fuzz.NewGenerator(API{}, names, *fuzzSeed),
+		Prologue: []api.Cmd{
+			cb.EglCreateContext(memory.Nullptr, memory.Nullptr, memory.Nullptr, memory.Nullptr, ctxHandle),
+			api.WithExtras(
+				cb.EglMakeCurrent(memory.Nullptr, memory.Nullptr, memory.Nullptr, ctxHandle, 0),
+				NewStaticContextState(), NewDynamicContextState(64, 64, false)),
+		},
+		Invariants: []fuzz.Invariant{contextBound, buffersKeyedByID},
+	}
+	if p, f := fuzz.Fuzz(ctx, config, *fuzzIterations); f != nil {
+		log.E(ctx, "%v\n%vReproducer:\n%v", f, f.Stack, p)
+	}
+}
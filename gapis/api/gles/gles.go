@@ -125,6 +125,14 @@ func (b *Texture) GetID() TextureId {
 	}
 }
 
+func (b *Sampler) GetID() SamplerId {
+	if b != nil {
+		return b.ID
+	} else {
+		return 0
+	}
+}
+
 // GetFramebufferAttachmentInfo returns the width, height and format of the specified framebuffer attachment.
 func (API) GetFramebufferAttachmentInfo(state *api.GlobalState, thread uint64, attachment api.FramebufferAttachment) (width, height uint32, index uint32, format *image.Format, err error) {
 	s := GetState(state)
@@ -304,6 +304,52 @@ func getCompressedImageFormat(format GLenum) (*image.Format, error) {
 		return image.NewS3_DXT3_RGBA("GL_COMPRESSED_RGBA_S3TC_DXT3_EXT"), nil
 	case GLenum_GL_COMPRESSED_RGBA_S3TC_DXT5_EXT:
 		return image.NewS3_DXT5_RGBA("GL_COMPRESSED_RGBA_S3TC_DXT5_EXT"), nil
+
+	// PVRTC
+	case GLenum_GL_COMPRESSED_RGB_PVRTC_2BPPV1_IMG:
+		return image.NewPVRTC1_RGB_2BPP("GL_COMPRESSED_RGB_PVRTC_2BPPV1_IMG"), nil
+	case GLenum_GL_COMPRESSED_RGB_PVRTC_4BPPV1_IMG:
+		return image.NewPVRTC1_RGB_4BPP("GL_COMPRESSED_RGB_PVRTC_4BPPV1_IMG"), nil
+	case GLenum_GL_COMPRESSED_RGBA_PVRTC_2BPPV1_IMG:
+		return image.NewPVRTC1_RGBA_2BPP("GL_COMPRESSED_RGBA_PVRTC_2BPPV1_IMG"), nil
+	case GLenum_GL_COMPRESSED_RGBA_PVRTC_4BPPV1_IMG:
+		return image.NewPVRTC1_RGBA_4BPP("GL_COMPRESSED_RGBA_PVRTC_4BPPV1_IMG"), nil
+	case GLenum_GL_COMPRESSED_SRGB_PVRTC_2BPPV1_EXT:
+		return image.NewPVRTC1_SRGB_2BPP("GL_COMPRESSED_SRGB_PVRTC_2BPPV1_EXT"), nil
+	case GLenum_GL_COMPRESSED_SRGB_PVRTC_4BPPV1_EXT:
+		return image.NewPVRTC1_SRGB_4BPP("GL_COMPRESSED_SRGB_PVRTC_4BPPV1_EXT"), nil
+	case GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_2BPPV1_EXT:
+		return image.NewPVRTC1_SRGBA_2BPP("GL_COMPRESSED_SRGB_ALPHA_PVRTC_2BPPV1_EXT"), nil
+	case GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_4BPPV1_EXT:
+		return image.NewPVRTC1_SRGBA_4BPP("GL_COMPRESSED_SRGB_ALPHA_PVRTC_4BPPV1_EXT"), nil
+	case GLenum_GL_COMPRESSED_RGBA_PVRTC_2BPPV2_IMG:
+		return image.NewPVRTC2_RGBA_2BPP("GL_COMPRESSED_RGBA_PVRTC_2BPPV2_IMG"), nil
+	case GLenum_GL_COMPRESSED_RGBA_PVRTC_4BPPV2_IMG:
+		return image.NewPVRTC2_RGBA_4BPP("GL_COMPRESSED_RGBA_PVRTC_4BPPV2_IMG"), nil
+	case GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_2BPPV2_IMG:
+		return image.NewPVRTC2_SRGBA_2BPP("GL_COMPRESSED_SRGB_ALPHA_PVRTC_2BPPV2_IMG"), nil
+	case GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_4BPPV2_IMG:
+		return image.NewPVRTC2_SRGBA_4BPP("GL_COMPRESSED_SRGB_ALPHA_PVRTC_4BPPV2_IMG"), nil
+
+	// RGTC
+	case GLenum_GL_COMPRESSED_RED_RGTC1:
+		return image.NewBC4_R_U8_NORM("GL_COMPRESSED_RED_RGTC1"), nil
+	case GLenum_GL_COMPRESSED_SIGNED_RED_RGTC1:
+		return image.NewBC4_R_S8_NORM("GL_COMPRESSED_SIGNED_RED_RGTC1"), nil
+	case GLenum_GL_COMPRESSED_RG_RGTC2:
+		return image.NewBC5_RG_U8_NORM("GL_COMPRESSED_RG_RGTC2"), nil
+	case GLenum_GL_COMPRESSED_SIGNED_RG_RGTC2:
+		return image.NewBC5_RG_S8_NORM("GL_COMPRESSED_SIGNED_RG_RGTC2"), nil
+
+	// BPTC
+	case GLenum_GL_COMPRESSED_RGBA_BPTC_UNORM:
+		return image.NewBC7_RGBA_U8_NORM("GL_COMPRESSED_RGBA_BPTC_UNORM"), nil
+	case GLenum_GL_COMPRESSED_SRGB_ALPHA_BPTC_UNORM:
+		return image.NewBC7_SRGBA_U8_NORM("GL_COMPRESSED_SRGB_ALPHA_BPTC_UNORM"), nil
+	case GLenum_GL_COMPRESSED_RGB_BPTC_SIGNED_FLOAT:
+		return image.NewBC6H_RGB_S16_FLOAT("GL_COMPRESSED_RGB_BPTC_SIGNED_FLOAT"), nil
+	case GLenum_GL_COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT:
+		return image.NewBC6H_RGB_U16_FLOAT("GL_COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT"), nil
 	}
 
 	return nil, fmt.Errorf("Unsupported compressed format: %s", format)
@@ -0,0 +1,228 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/core/data/binary"
+	"github.com/google/gapid/core/image"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/transform"
+	"github.com/google/gapid/gapis/replay"
+	"github.com/google/gapid/gapis/replay/builder"
+	"github.com/google/gapid/gapis/replay/value"
+	"github.com/google/gapid/gapis/service"
+)
+
+// pixelHistory is a transform that instruments each draw call to the requested
+// framebuffer, up to and including the requested command. Each draw call is
+// scissored to the single pixel and drawn twice within occlusion queries:
+// first with depth, stencil and color writes disabled to find whether the draw
+// covers the pixel, then as-is to find whether it passed the depth and stencil
+// tests. The pixel is read back after each draw.
+//
+// The replay is not optimized by dead code elimination, as that would remove
+// the draw calls whose results are later overwritten, which are exactly the
+// draws the history is after. Instead, the commands after the requested
+// command are dropped.
+//
+// Each instrumented draw call is issued, and so mutates the state, twice.
+// The coverage draw has the framebuffer writes masked and any transform
+// feedback paused, but the side effects of its shaders (image stores, shader
+// storage buffer writes and atomic counters) still happen. Draws that depend
+// on such side effects of earlier draws may produce a different history.
+type pixelHistory struct {
+	req   pixelHistoryRequest
+	res   replay.Result
+	draws []*pixelHistoryDraw
+	err   error
+}
+
+// pixelHistoryDraw holds the results of a single instrumented draw call.
+type pixelHistoryDraw struct {
+	id      api.CmdID
+	covered bool
+	passed  bool
+	color   *image.Data
+}
+
+func newPixelHistory(req pixelHistoryRequest, res replay.Result) *pixelHistory {
+	return &pixelHistory{req: req, res: res}
+}
+
+// instrument returns true if the draw call issued on thread may write to the
+// requested pixel.
+func (t *pixelHistory) instrument(ctx context.Context, id api.CmdID, thread uint64, s *api.GlobalState) bool {
+	if !id.IsReal() || id > t.req.after {
+		return false
+	}
+	c := GetContext(s, thread)
+	if c == nil || c.Identifier != t.req.context || c.Bound.DrawFramebuffer.GetID() != t.req.fb {
+		return false
+	}
+	if c.Pixel.Scissor.Test == GLboolean_GL_TRUE {
+		b, x, y := c.Pixel.Scissor.Box, GLint(t.req.x), GLint(t.req.y)
+		if x < b.X || y < b.Y || x >= b.X+GLint(b.Width) || y >= b.Y+GLint(b.Height) {
+			return false // The pixel is scissored out.
+		}
+	}
+	for _, target := range []GLenum{GLenum_GL_ANY_SAMPLES_PASSED, GLenum_GL_ANY_SAMPLES_PASSED_CONSERVATIVE} {
+		if _, ok := c.Other.ActiveQueries[target]; ok {
+			log.W(ctx, "Cannot instrument draw call %v as it is within an occlusion query", id)
+			return false
+		}
+	}
+	return true
+}
+
+func (t *pixelHistory) Transform(ctx context.Context, id api.CmdID, cmd api.Cmd, out transform.Writer) {
+	if id.IsReal() && id > t.req.after {
+		return // Nothing after the requested command is part of the history.
+	}
+
+	s := out.State()
+	thread := cmd.Thread()
+	if _, ok := cmd.(drawCall); !ok || !t.instrument(ctx, id, thread, s) {
+		out.MutateAndWrite(ctx, id, cmd)
+		return
+	}
+
+	draw := &pixelHistoryDraw{id: id}
+	t.draws = append(t.draws, draw)
+
+	dID := id.Derived()
+	cb := CommandBuilder{Thread: thread}
+	pixel := newTweaker(out, dID, cb)
+	defer pixel.revert(ctx)
+	pixel.glEnable(ctx, GLenum_GL_SCISSOR_TEST)
+	pixel.glScissor(ctx, GLint(t.req.x), GLint(t.req.y), 1, 1)
+	covered, passed := pixel.glGenQuery(ctx), pixel.glGenQuery(ctx)
+
+	// Draw without any side effects on the framebuffer to find whether the draw
+	// call covers the pixel at all.
+	coverage := newTweaker(out, dID, cb)
+	coverage.glDisable(ctx, GLenum_GL_DEPTH_TEST)
+	coverage.glDisable(ctx, GLenum_GL_STENCIL_TEST)
+	coverage.glColorMask(ctx, GLboolean_GL_FALSE, GLboolean_GL_FALSE, GLboolean_GL_FALSE, GLboolean_GL_FALSE)
+	if tf := pixel.c.Bound.TransformFeedback; tf != nil && tf.Active == GLboolean_GL_TRUE && tf.Paused == GLboolean_GL_FALSE {
+		// Don't capture the primitives twice.
+		coverage.doAndUndo(ctx,
+			cb.GlPauseTransformFeedback(),
+			cb.GlResumeTransformFeedback())
+	}
+	mutateAndWriteEach(ctx, out, dID,
+		cb.GlBeginQuery(GLenum_GL_ANY_SAMPLES_PASSED, covered),
+		cmd,
+		cb.GlEndQuery(GLenum_GL_ANY_SAMPLES_PASSED))
+	coverage.revert(ctx)
+
+	// Perform the real draw call to find whether it passed the depth and
+	// stencil tests.
+	out.MutateAndWrite(ctx, dID, cb.GlBeginQuery(GLenum_GL_ANY_SAMPLES_PASSED, passed))
+	out.MutateAndWrite(ctx, id, cmd)
+	out.MutateAndWrite(ctx, dID, cb.GlEndQuery(GLenum_GL_ANY_SAMPLES_PASSED))
+
+	t.postQueryResult(ctx, out, dID, cb, covered, &draw.covered)
+	t.postQueryResult(ctx, out, dID, cb, passed, &draw.passed)
+
+	// Read back the pixel.
+	_, _, format, err := GetState(s).getFramebufferAttachmentInfo(thread, t.req.fb, t.req.attachment)
+	if err != nil {
+		t.fail(fmt.Errorf("Failed to read framebuffer after cmd %v: %v", id, err))
+		return
+	}
+	pixel.glBindFramebuffer_Read(ctx, t.req.fb)
+	if t.req.attachment != api.FramebufferAttachment_Depth {
+		readColorBuffer(ctx, pixel, uint32(t.req.attachment-api.FramebufferAttachment_Color0))
+	}
+	postColorData(ctx, s, int32(t.req.x), int32(t.req.y), 1, 1, format, out, id, thread, func(val interface{}, err error) {
+		if err != nil {
+			t.fail(err)
+			return
+		}
+		draw.color = val.(*image.Data)
+	})
+}
+
+// postQueryResult posts back whether the occlusion query passed any samples,
+// storing the result to passed.
+func (t *pixelHistory) postQueryResult(ctx context.Context, out transform.Writer, id api.CmdID, cb CommandBuilder, query QueryId, passed *bool) {
+	tmp := out.State().AllocOrPanic(ctx, 4)
+	out.MutateAndWrite(ctx, id, cb.Custom(func(ctx context.Context, s *api.GlobalState, b *builder.Builder) error {
+		b.ReserveMemory(tmp.Range())
+		cb.GlGetQueryObjectuiv(query, GLenum_GL_QUERY_RESULT, tmp.Ptr()).Call(ctx, s, b)
+		b.Post(value.ObservedPointer(tmp.Address()), 4, func(r binary.Reader, err error) error {
+			if err == nil {
+				*passed = r.Uint32() != 0
+				err = r.Error()
+			}
+			if err != nil {
+				err = fmt.Errorf("Could not read occlusion query result: %v", err)
+				t.fail(err)
+			}
+			return err
+		})
+		return nil
+	}))
+	tmp.Free()
+}
+
+// fail records the first error found during the replay.
+func (t *pixelHistory) fail(err error) {
+	if t.err == nil {
+		t.err = err
+	}
+}
+
+func (t *pixelHistory) Flush(ctx context.Context, out transform.Writer) {
+	cb := CommandBuilder{Thread: 0}
+	out.MutateAndWrite(ctx, api.CmdNoID, cb.Custom(func(ctx context.Context, s *api.GlobalState, b *builder.Builder) error {
+		// Wait for all the query results and pixels to be posted back before
+		// returning the history. See findIssues.Flush.
+		code := uint32(0xbeefcace)
+		b.Push(value.U32(code))
+		b.Post(b.Buffer(1), 4, func(r binary.Reader, err error) error {
+			if err != nil {
+				t.res(nil, err)
+				return err
+			}
+			if r.Uint32() != code {
+				err = fmt.Errorf("Flush did not get expected EOS code")
+				t.res(nil, err)
+				return err
+			}
+			if t.err != nil {
+				t.res(nil, t.err)
+				return nil
+			}
+			history := &service.PixelHistory{}
+			for _, d := range t.draws {
+				if d.covered {
+					history.Draws = append(history.Draws, &service.PixelHistoryDraw{
+						Command: t.req.capture.Command(uint64(d.id)),
+						Passed:  d.passed,
+						Color:   d.color,
+					})
+				}
+			}
+			t.res(history, nil)
+			return nil
+		})
+		return nil
+	}))
+}
@@ -0,0 +1,114 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/testcmd"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/memory"
+)
+
+func TestPixelHistoryInstrumentation(t *testing.T) {
+	assert := assert.To(t)
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	ctx = PutUnusedIDMap(ctx)
+
+	ctxHandle := memory.BytePtr(1, memory.ApplicationPool)
+	cb := CommandBuilder{Thread: 0}
+	cmds := []api.Cmd{
+		cb.EglCreateContext(memory.Nullptr, memory.Nullptr, memory.Nullptr, memory.Nullptr, ctxHandle),
+		api.WithExtras(
+			cb.EglMakeCurrent(memory.Nullptr, memory.Nullptr, memory.Nullptr, ctxHandle, 0),
+			NewStaticContextState(), NewDynamicContextState(64, 64, false)),
+		cb.GlDrawArrays(GLenum_GL_TRIANGLES, 0, 3), // 2: Instrumented.
+		cb.GlEnable(GLenum_GL_SCISSOR_TEST),
+		cb.GlScissor(0, 0, 4, 4),
+		cb.GlDrawArrays(GLenum_GL_TRIANGLES, 0, 3), // 5: Scissored out.
+		cb.GlDisable(GLenum_GL_SCISSOR_TEST),
+		cb.GlDrawArrays(GLenum_GL_TRIANGLES, 0, 3), // 7: Instrumented, requested.
+		cb.GlDrawArrays(GLenum_GL_TRIANGLES, 0, 3), // 8: Dropped.
+	}
+	const after = api.CmdID(7)
+
+	h := &capture.Header{Abi: device.WindowsX86_64}
+	capturePath, err := capture.New(ctx, "pixel history", h, cmds)
+	if err != nil {
+		panic(err)
+	}
+	ctx = capture.Put(ctx, capturePath)
+	c, _ := capture.Resolve(ctx)
+
+	// Find the identifier of the context to instrument.
+	s := c.NewState()
+	api.ForeachCmd(ctx, cmds[:2], func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		return cmd.Mutate(ctx, id, s, nil)
+	})
+
+	ph := newPixelHistory(pixelHistoryRequest{
+		after:      after,
+		context:    GetContext(s, 0).Identifier,
+		fb:         0,
+		attachment: api.FramebufferAttachment_Color0,
+		x:          10,
+		y:          10,
+		capture:    capturePath,
+	}, func(interface{}, error) {})
+
+	w := &testcmd.Writer{S: c.NewState()}
+	api.ForeachCmd(ctx, cmds, func(ctx context.Context, id api.CmdID, cmd api.Cmd) error {
+		ph.Transform(ctx, id, cmd, w)
+		return nil
+	})
+
+	assert.For("err").ThatError(ph.err).Succeeded()
+
+	instrumented := []api.CmdID{}
+	for _, d := range ph.draws {
+		instrumented = append(instrumented, d.id)
+	}
+	assert.For("instrumented").ThatSlice(instrumented).Equals([]api.CmdID{2, 7})
+
+	draws, queries := map[api.CmdID]int{}, 0
+	for _, c := range w.CmdsAndIDs {
+		switch cmd := c.Cmd.(type) {
+		case *GlDrawArrays:
+			draws[c.Id]++
+		case *GlBeginQuery:
+			queries++
+		case *GlScissor:
+			if cmd.Width == 1 && cmd.Height == 1 {
+				assert.For("scissor x").That(cmd.X).Equals(GLint(10))
+				assert.For("scissor y").That(cmd.Y).Equals(GLint(10))
+			}
+		}
+	}
+	// Instrumented draws are issued once for the coverage query and once for
+	// the depth and stencil test query.
+	assert.For("draws").That(draws).DeepEquals(map[api.CmdID]int{
+		api.CmdID(2).Derived(): 1, 2: 1,
+		5:                      1,
+		api.CmdID(7).Derived(): 1, 7: 1,
+	})
+	assert.For("queries").That(queries).Equals(4)
+}
@@ -77,7 +77,7 @@ func (t *readFramebuffer) depth(
 		defer t.revert(ctx)
 		t.glBindFramebuffer_Read(ctx, fb)
 
-		postColorData(ctx, s, int32(width), int32(height), format, out, id, thread, res)
+		postColorData(ctx, s, 0, 0, int32(width), int32(height), format, out, id, thread, res)
 	})
 }
 
@@ -91,7 +91,6 @@ func (t *readFramebuffer) color(
 
 	t.Add(id, func(ctx context.Context, out transform.Writer) {
 		s := out.State()
-		c := GetContext(s, thread)
 
 		if fb == 0 {
 			var err error
@@ -128,23 +127,10 @@ func (t *readFramebuffer) color(
 		defer t.revert(ctx)
 		t.glBindFramebuffer_Read(ctx, fb)
 
-		// TODO: These glReadBuffer calls need to be changed for on-device
-		//       replay. Note that glReadBuffer was only introduced in
-		//       OpenGL ES 3.0, and that GL_FRONT is not a legal enum value.
-		if c.Bound.DrawFramebuffer == c.Objects.Default.Framebuffer {
-			out.MutateAndWrite(ctx, dID, cb.Custom(func(ctx context.Context, s *api.GlobalState, b *builder.Builder) error {
-				// TODO: We assume here that the default framebuffer is
-				//       single-buffered. Once we support double-buffering we
-				//       need to decide whether to read from GL_FRONT or GL_BACK.
-				cb.GlReadBuffer(GLenum_GL_FRONT).Call(ctx, s, b)
-				return nil
-			}))
-		} else {
-			t.glReadBuffer(ctx, GLenum_GL_COLOR_ATTACHMENT0+GLenum(bufferIdx))
-		}
+		readColorBuffer(ctx, t, bufferIdx)
 
 		if inW == outW && inH == outH {
-			postColorData(ctx, s, outW, outH, fmt, out, id, thread, res)
+			postColorData(ctx, s, 0, 0, outW, outH, fmt, out, id, thread, res)
 		} else {
 			t.glScissor(ctx, 0, 0, GLsizei(inW), GLsizei(inH))
 			framebufferID := t.glGenFramebuffer(ctx)
@@ -159,15 +145,37 @@ func (t *readFramebuffer) color(
 			)
 			t.glBindFramebuffer_Read(ctx, framebufferID)
 
-			postColorData(ctx, s, outW, outH, fmt, out, id, thread, res)
+			postColorData(ctx, s, 0, 0, outW, outH, fmt, out, id, thread, res)
 		}
 
 	})
 }
 
+// readColorBuffer uses the tweaker to select the color buffer bufferIdx of the
+// bound draw framebuffer as the source of pixel reads.
+func readColorBuffer(ctx context.Context, t *tweaker, bufferIdx uint32) {
+	// TODO: These glReadBuffer calls need to be changed for on-device
+	//       replay. Note that glReadBuffer was only introduced in
+	//       OpenGL ES 3.0, and that GL_FRONT is not a legal enum value.
+	if t.c.Bound.DrawFramebuffer == t.c.Objects.Default.Framebuffer {
+		cb := t.cb
+		t.out.MutateAndWrite(ctx, t.dID, cb.Custom(func(ctx context.Context, s *api.GlobalState, b *builder.Builder) error {
+			// TODO: We assume here that the default framebuffer is
+			//       single-buffered. Once we support double-buffering we
+			//       need to decide whether to read from GL_FRONT or GL_BACK.
+			cb.GlReadBuffer(GLenum_GL_FRONT).Call(ctx, s, b)
+			return nil
+		}))
+	} else {
+		t.glReadBuffer(ctx, GLenum_GL_COLOR_ATTACHMENT0+GLenum(bufferIdx))
+	}
+}
+
+// postColorData posts back the width x height pixels of the read framebuffer
+// with the bottom left corner at x, y.
 func postColorData(ctx context.Context,
 	s *api.GlobalState,
-	width, height int32,
+	x, y, width, height int32,
 	sizedFormat GLenum,
 	out transform.Writer,
 	id api.CmdID,
@@ -195,7 +203,7 @@ func postColorData(ctx context.Context,
 		// depth buffer.
 
 		b.ReserveMemory(tmp.Range())
-		cb.GlReadPixels(0, 0, GLsizei(width), GLsizei(height), unsizedFormat, ty, tmp.Ptr()).
+		cb.GlReadPixels(GLint(x), GLint(y), GLsizei(width), GLsizei(height), unsizedFormat, ty, tmp.Ptr()).
 			Call(ctx, s, b)
 
 		b.Post(value.ObservedPointer(tmp.Address()), uint64(imageSize), func(r binary.Reader, err error) error {
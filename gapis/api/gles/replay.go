@@ -27,15 +27,20 @@ import (
 	"github.com/google/gapid/gapis/capture"
 	"github.com/google/gapid/gapis/config"
 	"github.com/google/gapid/gapis/memory"
+	"github.com/google/gapid/gapis/messages"
 	"github.com/google/gapid/gapis/replay"
+	"github.com/google/gapid/gapis/resolve"
 	"github.com/google/gapid/gapis/resolve/dependencygraph"
 	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
 )
 
 var (
 	// Interface compliance tests
 	_ = replay.QueryIssues(API{})
 	_ = replay.QueryFramebufferAttachment(API{})
+	_ = replay.FramebufferAttachmentSupport(API{})
+	_ = replay.QueryPixelHistory(API{})
 	_ = replay.Support(API{})
 )
 
@@ -68,6 +73,17 @@ type framebufferRequest struct {
 	wireframeOverlay bool
 }
 
+// pixelHistoryRequest requests the history of a single pixel of a framebuffer
+// attachment, up to and including the command after.
+type pixelHistoryRequest struct {
+	after      api.CmdID
+	context    ContextID
+	fb         FramebufferId
+	attachment api.FramebufferAttachment
+	x, y       uint32
+	capture    *path.Capture
+}
+
 // GetReplayPriority returns a uint32 representing the preference for
 // replaying this trace on the given device.
 // A lower number represents a higher priority, and Zero represents
@@ -113,6 +129,7 @@ func (a API) Replay(
 
 	var rf *readFramebuffer // Transform for all framebuffer reads.
 	var rt *readTexture     // Transform for all texture reads.
+	var ph *pixelHistory    // Transform for pixel history instrumentation.
 
 	optimize := true
 	wire := false
@@ -161,6 +178,13 @@ func (a API) Replay(
 			case replay.WireframeMode_Overlay:
 				transforms.Add(wireframeOverlay(ctx, req.after))
 			}
+
+		case pixelHistoryRequest:
+			// Every draw call to the framebuffer is needed, including those
+			// that are overwritten. pixelHistory drops the commands after the
+			// requested one instead.
+			optimize = false
+			ph = newPixelHistory(req, rr.Result)
 		}
 	}
 
@@ -187,6 +211,9 @@ func (a API) Replay(
 	if rf != nil {
 		transforms.Add(rf)
 	}
+	if ph != nil {
+		transforms.Add(ph)
+	}
 
 	// Device-dependent transforms.
 	if c, err := compat(ctx, device); err == nil {
@@ -239,6 +266,12 @@ func (a API) QueryIssues(
 	return res.([]replay.Issue), nil
 }
 
+// SupportsFramebufferAttachment returns false for the stencil attachment, as
+// the stencil buffer cannot be read back on GLES.
+func (a API) SupportsFramebufferAttachment(attachment api.FramebufferAttachment) bool {
+	return attachment != api.FramebufferAttachment_Stencil
+}
+
 func (a API) QueryFramebufferAttachment(
 	ctx context.Context,
 	intent replay.Intent,
@@ -272,6 +305,64 @@ func (a API) QueryFramebufferAttachment(
 	return res.(*image.Data), nil
 }
 
+func (a API) QueryPixelHistory(
+	ctx context.Context,
+	intent replay.Intent,
+	mgr *replay.Manager,
+	after []uint64,
+	attachment api.FramebufferAttachment,
+	x, y uint32,
+	hints *service.UsageHints) (*service.PixelHistory, error) {
+
+	if len(after) > 1 {
+		return nil, log.Errf(ctx, nil, "GLES does not support subcommands")
+	}
+	if attachment == api.FramebufferAttachment_Stencil {
+		return nil, fmt.Errorf("Stencil buffer attachments are not currently supported")
+	}
+
+	// Find the framebuffer drawn to by the command.
+	cmdPath := intent.Capture.Command(after[0])
+	cmd, err := resolve.Cmd(ctx, cmdPath)
+	if err != nil {
+		return nil, err
+	}
+	s, err := resolve.GlobalState(ctx, cmdPath.GlobalStateAfter())
+	if err != nil {
+		return nil, err
+	}
+	c := GetContext(s, cmd.Thread())
+	fb, err := getBoundFramebufferID(cmd.Thread(), s)
+	if err != nil {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrFramebufferUnavailable()}
+	}
+	width, height, _, err := GetState(s).getFramebufferAttachmentInfo(cmd.Thread(), fb, attachment)
+	if err != nil {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrFramebufferUnavailable()}
+	}
+	if x >= width {
+		return nil, &service.ErrInvalidArgument{Reason: messages.ErrValueOutOfBounds(uint64(x), "x", uint64(0), uint64(width-1))}
+	}
+	if y >= height {
+		return nil, &service.ErrInvalidArgument{Reason: messages.ErrValueOutOfBounds(uint64(y), "y", uint64(0), uint64(height-1))}
+	}
+
+	r := pixelHistoryRequest{
+		after:      api.CmdID(after[0]),
+		context:    c.Identifier,
+		fb:         fb,
+		attachment: attachment,
+		x:          x,
+		y:          y,
+		capture:    intent.Capture,
+	}
+	res, err := mgr.Replay(ctx, intent, uniqueConfig(), r, a, hints)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*service.PixelHistory), nil
+}
+
 // destroyResourcesAtEOS is a transform that destroys all textures,
 // framebuffers, buffers, shaders, programs and vertex-arrays that were not
 // destroyed by EOS.
@@ -163,32 +163,48 @@ func (t *Texture) ResourceData(ctx context.Context, s *api.GlobalState) (*api.Re
 		}
 		return api.NewResourceData(api.NewTexture(&api.Texture3D{Levels: levels})), nil
 
-	case GLenum_GL_TEXTURE_CUBE_MAP:
-		levels := make([]*api.CubemapLevel, len(t.Levels))
-		for i, level := range t.Levels {
-			levels[i] = &api.CubemapLevel{}
-			for j, face := range level.Layers {
-				img, err := face.ImageInfo(ctx, s)
-				if err != nil {
-					return nil, err
-				}
-				switch GLenum(j) + GLenum_GL_TEXTURE_CUBE_MAP_POSITIVE_X {
-				case GLenum_GL_TEXTURE_CUBE_MAP_NEGATIVE_X:
-					levels[i].NegativeX = img
-				case GLenum_GL_TEXTURE_CUBE_MAP_POSITIVE_X:
-					levels[i].PositiveX = img
-				case GLenum_GL_TEXTURE_CUBE_MAP_NEGATIVE_Y:
-					levels[i].NegativeY = img
-				case GLenum_GL_TEXTURE_CUBE_MAP_POSITIVE_Y:
-					levels[i].PositiveY = img
-				case GLenum_GL_TEXTURE_CUBE_MAP_NEGATIVE_Z:
-					levels[i].NegativeZ = img
-				case GLenum_GL_TEXTURE_CUBE_MAP_POSITIVE_Z:
-					levels[i].PositiveZ = img
+	case GLenum_GL_TEXTURE_CUBE_MAP, GLenum_GL_TEXTURE_CUBE_MAP_ARRAY:
+		// The layers of cube-map arrays are the six faces of each cube-map in
+		// turn.
+		numCubes := 1
+		if t.Kind == GLenum_GL_TEXTURE_CUBE_MAP_ARRAY {
+			numCubes = t.LayerCount() / 6
+		}
+		cubes := make([]*api.Cubemap, numCubes)
+		for c := range cubes {
+			levels := make([]*api.CubemapLevel, len(t.Levels))
+			for i, level := range t.Levels {
+				levels[i] = &api.CubemapLevel{}
+				for j, face := range level.Layers {
+					if int(j)/6 != c {
+						continue
+					}
+					img, err := face.ImageInfo(ctx, s)
+					if err != nil {
+						return nil, err
+					}
+					switch GLenum(j%6) + GLenum_GL_TEXTURE_CUBE_MAP_POSITIVE_X {
+					case GLenum_GL_TEXTURE_CUBE_MAP_NEGATIVE_X:
+						levels[i].NegativeX = img
+					case GLenum_GL_TEXTURE_CUBE_MAP_POSITIVE_X:
+						levels[i].PositiveX = img
+					case GLenum_GL_TEXTURE_CUBE_MAP_NEGATIVE_Y:
+						levels[i].NegativeY = img
+					case GLenum_GL_TEXTURE_CUBE_MAP_POSITIVE_Y:
+						levels[i].PositiveY = img
+					case GLenum_GL_TEXTURE_CUBE_MAP_NEGATIVE_Z:
+						levels[i].NegativeZ = img
+					case GLenum_GL_TEXTURE_CUBE_MAP_POSITIVE_Z:
+						levels[i].PositiveZ = img
+					}
 				}
 			}
+			cubes[c] = &api.Cubemap{Levels: levels}
+		}
+		if t.Kind == GLenum_GL_TEXTURE_CUBE_MAP {
+			return api.NewResourceData(api.NewTexture(cubes[0])), nil
 		}
-		return api.NewResourceData(api.NewTexture(&api.Cubemap{Levels: levels})), nil
+		return api.NewResourceData(api.NewTexture(&api.CubemapArray{Layers: cubes})), nil
 	}
 	return nil, &service.ErrDataUnavailable{Reason: messages.ErrNoTextureData(t.ResourceHandle())}
 }
@@ -390,134 +406,7 @@ func (p *Program) ResourceData(ctx context.Context, s *api.GlobalState) (*api.Re
 	for _, activeUniform := range p.ActiveUniforms {
 		uniform := p.Uniforms[activeUniform.Location]
 
-		var uniformFormat api.UniformFormat
-		var uniformType api.UniformType
-
-		switch activeUniform.Type {
-		case GLenum_GL_FLOAT:
-			uniformFormat = api.UniformFormat_Scalar
-			uniformType = api.UniformType_Float
-		case GLenum_GL_FLOAT_VEC2:
-			uniformFormat = api.UniformFormat_Vec2
-			uniformType = api.UniformType_Float
-		case GLenum_GL_FLOAT_VEC3:
-			uniformFormat = api.UniformFormat_Vec3
-			uniformType = api.UniformType_Float
-		case GLenum_GL_FLOAT_VEC4:
-			uniformFormat = api.UniformFormat_Vec4
-			uniformType = api.UniformType_Float
-		case GLenum_GL_INT:
-			uniformFormat = api.UniformFormat_Scalar
-			uniformType = api.UniformType_Int32
-		case GLenum_GL_INT_VEC2:
-			uniformFormat = api.UniformFormat_Vec2
-			uniformType = api.UniformType_Int32
-		case GLenum_GL_INT_VEC3:
-			uniformFormat = api.UniformFormat_Vec3
-			uniformType = api.UniformType_Int32
-		case GLenum_GL_INT_VEC4:
-			uniformFormat = api.UniformFormat_Vec4
-			uniformType = api.UniformType_Int32
-		case GLenum_GL_UNSIGNED_INT:
-			uniformFormat = api.UniformFormat_Scalar
-			uniformType = api.UniformType_Uint32
-		case GLenum_GL_UNSIGNED_INT_VEC2:
-			uniformFormat = api.UniformFormat_Vec2
-			uniformType = api.UniformType_Uint32
-		case GLenum_GL_UNSIGNED_INT_VEC3:
-			uniformFormat = api.UniformFormat_Vec3
-			uniformType = api.UniformType_Uint32
-		case GLenum_GL_UNSIGNED_INT_VEC4:
-			uniformFormat = api.UniformFormat_Vec4
-			uniformType = api.UniformType_Uint32
-		case GLenum_GL_BOOL:
-			uniformFormat = api.UniformFormat_Scalar
-			uniformType = api.UniformType_Bool
-		case GLenum_GL_BOOL_VEC2:
-			uniformFormat = api.UniformFormat_Vec2
-			uniformType = api.UniformType_Bool
-		case GLenum_GL_BOOL_VEC3:
-			uniformFormat = api.UniformFormat_Vec3
-			uniformType = api.UniformType_Bool
-		case GLenum_GL_BOOL_VEC4:
-			uniformFormat = api.UniformFormat_Vec4
-			uniformType = api.UniformType_Bool
-		case GLenum_GL_FLOAT_MAT2:
-			uniformFormat = api.UniformFormat_Mat2
-			uniformType = api.UniformType_Float
-		case GLenum_GL_FLOAT_MAT3:
-			uniformFormat = api.UniformFormat_Mat3
-			uniformType = api.UniformType_Float
-		case GLenum_GL_FLOAT_MAT4:
-			uniformFormat = api.UniformFormat_Mat4
-			uniformType = api.UniformType_Float
-		case GLenum_GL_FLOAT_MAT2x3:
-			uniformFormat = api.UniformFormat_Mat2x3
-			uniformType = api.UniformType_Float
-		case GLenum_GL_FLOAT_MAT2x4:
-			uniformFormat = api.UniformFormat_Mat2x4
-			uniformType = api.UniformType_Float
-		case GLenum_GL_FLOAT_MAT3x2:
-			uniformFormat = api.UniformFormat_Mat3x2
-			uniformType = api.UniformType_Float
-		case GLenum_GL_FLOAT_MAT3x4:
-			uniformFormat = api.UniformFormat_Mat3x4
-			uniformType = api.UniformType_Float
-		case GLenum_GL_FLOAT_MAT4x2:
-			uniformFormat = api.UniformFormat_Mat4x2
-			uniformType = api.UniformType_Float
-		case GLenum_GL_FLOAT_MAT4x3:
-			uniformFormat = api.UniformFormat_Mat4x3
-			uniformType = api.UniformType_Float
-		case GLenum_GL_SAMPLER_2D:
-			uniformFormat = api.UniformFormat_Sampler
-			uniformType = api.UniformType_Uint32
-		case GLenum_GL_SAMPLER_3D:
-			uniformFormat = api.UniformFormat_Sampler
-			uniformType = api.UniformType_Uint32
-		case GLenum_GL_SAMPLER_CUBE:
-			uniformFormat = api.UniformFormat_Sampler
-			uniformType = api.UniformType_Uint32
-		case GLenum_GL_SAMPLER_2D_SHADOW:
-			uniformFormat = api.UniformFormat_Sampler
-			uniformType = api.UniformType_Uint32
-		case GLenum_GL_SAMPLER_2D_ARRAY:
-			uniformFormat = api.UniformFormat_Sampler
-			uniformType = api.UniformType_Uint32
-		case GLenum_GL_SAMPLER_2D_ARRAY_SHADOW:
-			uniformFormat = api.UniformFormat_Sampler
-			uniformType = api.UniformType_Uint32
-		case GLenum_GL_SAMPLER_CUBE_SHADOW:
-			uniformFormat = api.UniformFormat_Sampler
-			uniformType = api.UniformType_Uint32
-		case GLenum_GL_INT_SAMPLER_2D:
-			uniformFormat = api.UniformFormat_Sampler
-			uniformType = api.UniformType_Uint32
-		case GLenum_GL_INT_SAMPLER_3D:
-			uniformFormat = api.UniformFormat_Sampler
-			uniformType = api.UniformType_Uint32
-		case GLenum_GL_INT_SAMPLER_CUBE:
-			uniformFormat = api.UniformFormat_Sampler
-			uniformType = api.UniformType_Uint32
-		case GLenum_GL_INT_SAMPLER_2D_ARRAY:
-			uniformFormat = api.UniformFormat_Sampler
-			uniformType = api.UniformType_Uint32
-		case GLenum_GL_UNSIGNED_INT_SAMPLER_2D:
-			uniformFormat = api.UniformFormat_Sampler
-			uniformType = api.UniformType_Uint32
-		case GLenum_GL_UNSIGNED_INT_SAMPLER_3D:
-			uniformFormat = api.UniformFormat_Sampler
-			uniformType = api.UniformType_Uint32
-		case GLenum_GL_UNSIGNED_INT_SAMPLER_CUBE:
-			uniformFormat = api.UniformFormat_Sampler
-			uniformType = api.UniformType_Uint32
-		case GLenum_GL_UNSIGNED_INT_SAMPLER_2D_ARRAY:
-			uniformFormat = api.UniformFormat_Sampler
-			uniformType = api.UniformType_Uint32
-		default:
-			uniformFormat = api.UniformFormat_Scalar
-			uniformType = api.UniformType_Float
-		}
+		uniformFormat, uniformType := uniformFormatAndType(activeUniform.Type)
 
 		uniforms = append(uniforms, &api.Uniform{
 			UniformLocation: uint32(activeUniform.Location),
@@ -531,6 +420,95 @@ func (p *Program) ResourceData(ctx context.Context, s *api.GlobalState) (*api.Re
 	return api.NewResourceData(&api.Program{Shaders: shaders, Uniforms: uniforms}), nil
 }
 
+// uniformFormatAndType returns the format and component type of the GLSL
+// uniform type ty.
+func uniformFormatAndType(ty GLenum) (api.UniformFormat, api.UniformType) {
+	switch ty {
+	case GLenum_GL_FLOAT:
+		return api.UniformFormat_Scalar, api.UniformType_Float
+	case GLenum_GL_FLOAT_VEC2:
+		return api.UniformFormat_Vec2, api.UniformType_Float
+	case GLenum_GL_FLOAT_VEC3:
+		return api.UniformFormat_Vec3, api.UniformType_Float
+	case GLenum_GL_FLOAT_VEC4:
+		return api.UniformFormat_Vec4, api.UniformType_Float
+	case GLenum_GL_INT:
+		return api.UniformFormat_Scalar, api.UniformType_Int32
+	case GLenum_GL_INT_VEC2:
+		return api.UniformFormat_Vec2, api.UniformType_Int32
+	case GLenum_GL_INT_VEC3:
+		return api.UniformFormat_Vec3, api.UniformType_Int32
+	case GLenum_GL_INT_VEC4:
+		return api.UniformFormat_Vec4, api.UniformType_Int32
+	case GLenum_GL_UNSIGNED_INT:
+		return api.UniformFormat_Scalar, api.UniformType_Uint32
+	case GLenum_GL_UNSIGNED_INT_VEC2:
+		return api.UniformFormat_Vec2, api.UniformType_Uint32
+	case GLenum_GL_UNSIGNED_INT_VEC3:
+		return api.UniformFormat_Vec3, api.UniformType_Uint32
+	case GLenum_GL_UNSIGNED_INT_VEC4:
+		return api.UniformFormat_Vec4, api.UniformType_Uint32
+	case GLenum_GL_BOOL:
+		return api.UniformFormat_Scalar, api.UniformType_Bool
+	case GLenum_GL_BOOL_VEC2:
+		return api.UniformFormat_Vec2, api.UniformType_Bool
+	case GLenum_GL_BOOL_VEC3:
+		return api.UniformFormat_Vec3, api.UniformType_Bool
+	case GLenum_GL_BOOL_VEC4:
+		return api.UniformFormat_Vec4, api.UniformType_Bool
+	case GLenum_GL_FLOAT_MAT2:
+		return api.UniformFormat_Mat2, api.UniformType_Float
+	case GLenum_GL_FLOAT_MAT3:
+		return api.UniformFormat_Mat3, api.UniformType_Float
+	case GLenum_GL_FLOAT_MAT4:
+		return api.UniformFormat_Mat4, api.UniformType_Float
+	case GLenum_GL_FLOAT_MAT2x3:
+		return api.UniformFormat_Mat2x3, api.UniformType_Float
+	case GLenum_GL_FLOAT_MAT2x4:
+		return api.UniformFormat_Mat2x4, api.UniformType_Float
+	case GLenum_GL_FLOAT_MAT3x2:
+		return api.UniformFormat_Mat3x2, api.UniformType_Float
+	case GLenum_GL_FLOAT_MAT3x4:
+		return api.UniformFormat_Mat3x4, api.UniformType_Float
+	case GLenum_GL_FLOAT_MAT4x2:
+		return api.UniformFormat_Mat4x2, api.UniformType_Float
+	case GLenum_GL_FLOAT_MAT4x3:
+		return api.UniformFormat_Mat4x3, api.UniformType_Float
+	case GLenum_GL_SAMPLER_2D:
+		return api.UniformFormat_Sampler, api.UniformType_Uint32
+	case GLenum_GL_SAMPLER_3D:
+		return api.UniformFormat_Sampler, api.UniformType_Uint32
+	case GLenum_GL_SAMPLER_CUBE:
+		return api.UniformFormat_Sampler, api.UniformType_Uint32
+	case GLenum_GL_SAMPLER_2D_SHADOW:
+		return api.UniformFormat_Sampler, api.UniformType_Uint32
+	case GLenum_GL_SAMPLER_2D_ARRAY:
+		return api.UniformFormat_Sampler, api.UniformType_Uint32
+	case GLenum_GL_SAMPLER_2D_ARRAY_SHADOW:
+		return api.UniformFormat_Sampler, api.UniformType_Uint32
+	case GLenum_GL_SAMPLER_CUBE_SHADOW:
+		return api.UniformFormat_Sampler, api.UniformType_Uint32
+	case GLenum_GL_INT_SAMPLER_2D:
+		return api.UniformFormat_Sampler, api.UniformType_Uint32
+	case GLenum_GL_INT_SAMPLER_3D:
+		return api.UniformFormat_Sampler, api.UniformType_Uint32
+	case GLenum_GL_INT_SAMPLER_CUBE:
+		return api.UniformFormat_Sampler, api.UniformType_Uint32
+	case GLenum_GL_INT_SAMPLER_2D_ARRAY:
+		return api.UniformFormat_Sampler, api.UniformType_Uint32
+	case GLenum_GL_UNSIGNED_INT_SAMPLER_2D:
+		return api.UniformFormat_Sampler, api.UniformType_Uint32
+	case GLenum_GL_UNSIGNED_INT_SAMPLER_3D:
+		return api.UniformFormat_Sampler, api.UniformType_Uint32
+	case GLenum_GL_UNSIGNED_INT_SAMPLER_CUBE:
+		return api.UniformFormat_Sampler, api.UniformType_Uint32
+	case GLenum_GL_UNSIGNED_INT_SAMPLER_2D_ARRAY:
+		return api.UniformFormat_Sampler, api.UniformType_Uint32
+	default:
+		return api.UniformFormat_Scalar, api.UniformType_Float
+	}
+}
+
 func uniformValue(ctx context.Context, s *api.GlobalState, kind api.UniformType, data U8ˢ) interface{} {
 	r := data.Reader(ctx, s)
 
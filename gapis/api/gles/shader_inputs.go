@@ -0,0 +1,132 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/resolve"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/box"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// ShaderInputs implements the api.ShaderInputsProvider interface.
+func (API) ShaderInputs(ctx context.Context, p *path.ShaderInputs) (*api.ShaderInputs, error) {
+	if len(p.Command.Indices) > 1 {
+		return nil, log.Errf(ctx, nil, "GLES does not support subcommands")
+	}
+	cmd, err := resolve.Cmd(ctx, p.Command)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := cmd.(drawCall); !ok {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNotADrawCall()}
+	}
+	s, err := resolve.GlobalState(ctx, p.Command.GlobalStateAfter())
+	if err != nil {
+		return nil, err
+	}
+	c := GetContext(s, cmd.Thread())
+	if c == nil {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNoContextBound(cmd.Thread())}
+	}
+	program := c.Bound.Program
+	if program == nil {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNoProgramBound()}
+	}
+
+	out := &api.ShaderInputs{}
+
+	for _, i := range program.ActiveUniforms.KeysSorted() {
+		au := program.ActiveUniforms[i]
+		if au.BlockIndex != 0xFFFFFFFF {
+			continue // Backed by a uniform buffer. Listed with the blocks below.
+		}
+		format, ty := uniformFormatAndType(au.Type)
+		name := strings.TrimSuffix(au.Name, "[0]")
+		for e := 0; e < int(au.ArraySize); e++ {
+			location := au.Location + UniformLocation(e)
+			elName := au.Name
+			if au.ArraySize > 1 {
+				elName = fmt.Sprintf("%v[%d]", name, e)
+			}
+			value := uniformValue(ctx, s, ty, program.Uniforms[location].Value)
+			out.Uniforms = append(out.Uniforms, &api.Uniform{
+				UniformLocation: uint32(location),
+				Name:            elName,
+				Format:          format,
+				Type:            ty,
+				Value:           box.NewValue(value),
+			})
+
+			if format != api.UniformFormat_Sampler {
+				continue
+			}
+			units, ok := value.([]uint32)
+			if !ok || len(units) == 0 {
+				continue
+			}
+			texture := &api.ShaderInputTexture{Name: elName, Binding: units[0]}
+			if tu := c.Objects.TextureUnits[TextureUnitId(units[0])]; tu != nil {
+				texture.Texture = uint64(samplerTexture(tu, au.Type).GetID())
+				texture.Sampler = uint64(tu.SamplerBinding.GetID())
+			}
+			out.Textures = append(out.Textures, texture)
+		}
+	}
+
+	for _, i := range program.ActiveUniformBlocks.KeysSorted() {
+		block := program.ActiveUniformBlocks[i]
+		buffer := &api.ShaderInputBuffer{Name: block.Name, Binding: uint32(block.Binding)}
+		if binding, ok := c.Bound.UniformBuffers[GLuint(block.Binding)]; ok && binding.Binding != nil {
+			buffer.Buffer = uint64(binding.Binding.GetID())
+			buffer.Offset = uint64(binding.Start)
+			buffer.Size = uint64(binding.Size)
+			buffer.View = p.Command.BoundBufferView(path.BufferBinding_UniformBlock, uint32(i), nil)
+		}
+		out.Buffers = append(out.Buffers, buffer)
+	}
+
+	return out, nil
+}
+
+// samplerTexture returns the texture bound to the texture unit tu for the
+// target sampled by the GLSL sampler type ty.
+func samplerTexture(tu *TextureUnit, ty GLenum) *Texture {
+	switch ty {
+	case GLenum_GL_SAMPLER_3D,
+		GLenum_GL_INT_SAMPLER_3D,
+		GLenum_GL_UNSIGNED_INT_SAMPLER_3D:
+		return tu.Binding3d
+	case GLenum_GL_SAMPLER_CUBE,
+		GLenum_GL_SAMPLER_CUBE_SHADOW,
+		GLenum_GL_INT_SAMPLER_CUBE,
+		GLenum_GL_UNSIGNED_INT_SAMPLER_CUBE:
+		return tu.BindingCubeMap
+	case GLenum_GL_SAMPLER_2D_ARRAY,
+		GLenum_GL_SAMPLER_2D_ARRAY_SHADOW,
+		GLenum_GL_INT_SAMPLER_2D_ARRAY,
+		GLenum_GL_UNSIGNED_INT_SAMPLER_2D_ARRAY:
+		return tu.Binding2dArray
+	default:
+		return tu.Binding2d
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles_test
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/gles"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/memory"
+	"github.com/google/gapid/gapis/service"
+)
+
+func TestShaderInputs(t *testing.T) {
+	assert := assert.To(t)
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	programInfo := &gles.ProgramInfo{
+		LinkStatus: gles.GLboolean_GL_TRUE,
+		ActiveUniforms: gles.UniformIndexːActiveUniformᵐ{
+			0: {
+				Name:       "scale",
+				Type:       gles.GLenum_GL_FLOAT,
+				Location:   0,
+				ArraySize:  1,
+				BlockIndex: 0xFFFFFFFF,
+			},
+			1: {
+				Name:       "tex",
+				Type:       gles.GLenum_GL_SAMPLER_2D,
+				Location:   1,
+				ArraySize:  1,
+				BlockIndex: 0xFFFFFFFF,
+			},
+			2: {
+				Name:       "block.color",
+				Type:       gles.GLenum_GL_FLOAT_VEC4,
+				Location:   -1,
+				ArraySize:  1,
+				BlockIndex: 0,
+				Offset:     0,
+			},
+		},
+		ActiveUniformBlocks: gles.UniformBlockIndexːActiveUniformBlockᵐ{
+			0: {
+				Name:           "block",
+				Binding:        2,
+				DataSize:       16,
+				ActiveUniforms: gles.UniformIndexːActiveUniformᵐ{},
+			},
+		},
+	}
+
+	ctxHandle := memory.BytePtr(1, memory.ApplicationPool)
+	cb := gles.CommandBuilder{Thread: 0}
+	cmds := []api.Cmd{
+		cb.EglCreateContext(memory.Nullptr, memory.Nullptr, memory.Nullptr, memory.Nullptr, ctxHandle),
+		api.WithExtras(
+			cb.EglMakeCurrent(memory.Nullptr, memory.Nullptr, memory.Nullptr, ctxHandle, 0),
+			gles.NewStaticContextState(), gles.NewDynamicContextState(64, 64, false)),
+		cb.GlCreateProgram(1),
+		api.WithExtras(cb.GlLinkProgram(1), programInfo),
+		cb.GlUseProgram(1),
+		cb.GlActiveTexture(gles.GLenum_GL_TEXTURE3),
+		cb.GlBindTexture(gles.GLenum_GL_TEXTURE_2D, 5),
+		cb.GlUniform1f(0, 2.5),
+		cb.GlUniform1i(1, 3),
+		cb.GlDrawArrays(gles.GLenum_GL_TRIANGLES, 0, 3),
+	}
+	draw := uint64(len(cmds) - 1)
+
+	h := &capture.Header{Abi: device.AndroidARMv7a}
+	capturePath, err := capture.New(ctx, "shader inputs", h, cmds)
+	if err != nil {
+		panic(err)
+	}
+	ctx = capture.Put(ctx, capturePath)
+
+	inputs, err := gles.API{}.ShaderInputs(ctx, capturePath.Command(draw).ShaderInputs())
+	if !assert.For("err").ThatError(err).Succeeded() {
+		return
+	}
+
+	if assert.For("uniforms").ThatSlice(inputs.Uniforms).IsLength(2) {
+		scale, tex := inputs.Uniforms[0], inputs.Uniforms[1]
+		assert.For("scale name").That(scale.Name).Equals("scale")
+		assert.For("scale format").That(scale.Format).Equals(api.UniformFormat_Scalar)
+		assert.For("scale value").That(scale.Value.Get()).DeepEquals([]float32{2.5})
+		assert.For("tex name").That(tex.Name).Equals("tex")
+		assert.For("tex location").That(tex.UniformLocation).Equals(uint32(1))
+		assert.For("tex format").That(tex.Format).Equals(api.UniformFormat_Sampler)
+		assert.For("tex value").That(tex.Value.Get()).DeepEquals([]uint32{3})
+	}
+
+	assert.For("textures").That(inputs.Textures).DeepEquals([]*api.ShaderInputTexture{
+		{Name: "tex", Binding: 3, Texture: 5},
+	})
+
+	// The uniform block has no buffer bound, so there is nothing to view.
+	assert.For("buffers").That(inputs.Buffers).DeepEquals([]*api.ShaderInputBuffer{
+		{Name: "block", Binding: 2},
+	})
+
+	// Commands that are not draw calls have no shader inputs.
+	_, err = gles.API{}.ShaderInputs(ctx, capturePath.Command(draw-1).ShaderInputs())
+	_, isDataUnavailable := err.(*service.ErrDataUnavailable)
+	assert.For("not a draw call").That(isDataUnavailable).Equals(true)
+}
@@ -162,11 +162,12 @@ func (tc *textureCompat) convertFormat(
 		case GLenum_GL_STENCIL_INDEX8: // TODO: not supported on desktop.
 		}
 
-		// Compressed formats are replaced by RGBA8
+		// Unsupported compressed formats are re-encoded to a supported
+		// compressed format, or replaced by RGBA8.
 		// TODO: What about SRGB?
 		if isCompressedFormat(*internalformat) {
 			if _, supported := tc.f.compressedTextureFormats[*internalformat]; !supported {
-				*internalformat = GLenum_GL_RGBA8
+				*internalformat = tc.f.getTranscodeFormat(*internalformat)
 			}
 		}
 	}
@@ -210,10 +211,13 @@ func (tc *textureCompat) postTexParameter(ctx context.Context, target, parameter
 	}
 }
 
-// decompressTexImage2D writes a glTexImage2D using the decompressed data for
-// the given glCompressedTexImage2D.
-func decompressTexImage2D(ctx context.Context, i api.CmdID, a *GlCompressedTexImage2D, s *api.GlobalState, out transform.Writer) error {
-	ctx = log.Enter(ctx, "decompressTexImage2D")
+// transcodeTexImage2D writes a command equivalent to the given
+// glCompressedTexImage2D, but with the data converted to the internal format
+// to. If to is GL_RGBA8 then a glTexImage2D using the decompressed data is
+// written, otherwise a glCompressedTexImage2D using the data re-encoded with
+// the quality q is written.
+func transcodeTexImage2D(ctx context.Context, i api.CmdID, a *GlCompressedTexImage2D, to GLenum, q image.Quality, s *api.GlobalState, out transform.Writer) error {
+	ctx = log.Enter(ctx, "transcodeTexImage2D")
 	dID := i.Derived()
 	c := GetContext(s, a.thread)
 	cb := CommandBuilder{Thread: a.thread}
@@ -239,6 +243,35 @@ func decompressTexImage2D(ctx context.Context, i api.CmdID, a *GlCompressedTexIm
 		Depth:  1,
 		Format: format,
 	}
+
+	if to != GLenum_GL_RGBA8 {
+		dstFormat, err := getCompressedImageFormat(to)
+		if err != nil {
+			return err
+		}
+		dst, err := src.Encode(ctx, dstFormat, q)
+		if err != nil {
+			return err
+		}
+
+		dstSize := dstFormat.Size(int(a.Width), int(a.Height), 1)
+
+		tmp := s.AllocOrPanic(ctx, uint64(dstSize))
+		out.MutateAndWrite(ctx, i, cb.GlCompressedTexImage2D(
+			a.Target,
+			a.Level,
+			to,
+			a.Width,
+			a.Height,
+			a.Border,
+			GLsizei(dstSize),
+			tmp.Ptr(),
+		).AddRead(tmp.Range(), dst.Bytes.ID()))
+		tmp.Free()
+
+		return nil
+	}
+
 	dst, err := src.Convert(ctx, image.RGBA_U8_NORM)
 	if err != nil {
 		return err
@@ -263,10 +296,13 @@ func decompressTexImage2D(ctx context.Context, i api.CmdID, a *GlCompressedTexIm
 	return nil
 }
 
-// decompressTexSubImage2D writes a glTexSubImage2D using the decompressed data for
-// the given glCompressedTexSubImage2D.
-func decompressTexSubImage2D(ctx context.Context, i api.CmdID, a *GlCompressedTexSubImage2D, s *api.GlobalState, out transform.Writer) error {
-	ctx = log.Enter(ctx, "decompressTexSubImage2D")
+// transcodeTexSubImage2D writes a command equivalent to the given
+// glCompressedTexSubImage2D, but with the data converted to the internal
+// format to. If to is GL_RGBA8 then a glTexSubImage2D using the decompressed
+// data is written, otherwise a glCompressedTexSubImage2D using the data
+// re-encoded with the quality q is written.
+func transcodeTexSubImage2D(ctx context.Context, i api.CmdID, a *GlCompressedTexSubImage2D, to GLenum, q image.Quality, s *api.GlobalState, out transform.Writer) error {
+	ctx = log.Enter(ctx, "transcodeTexSubImage2D")
 	dID := i.Derived()
 	c := GetContext(s, a.thread)
 	cb := CommandBuilder{Thread: a.thread}
@@ -292,6 +328,36 @@ func decompressTexSubImage2D(ctx context.Context, i api.CmdID, a *GlCompressedTe
 		Depth:  1,
 		Format: format,
 	}
+
+	if to != GLenum_GL_RGBA8 {
+		dstFormat, err := getCompressedImageFormat(to)
+		if err != nil {
+			return err
+		}
+		dst, err := src.Encode(ctx, dstFormat, q)
+		if err != nil {
+			return err
+		}
+
+		dstSize := dstFormat.Size(int(a.Width), int(a.Height), 1)
+
+		tmp := s.AllocOrPanic(ctx, uint64(dstSize))
+		out.MutateAndWrite(ctx, i, cb.GlCompressedTexSubImage2D(
+			a.Target,
+			a.Level,
+			a.Xoffset,
+			a.Yoffset,
+			a.Width,
+			a.Height,
+			to,
+			GLsizei(dstSize),
+			tmp.Ptr(),
+		).AddRead(tmp.Range(), dst.Bytes.ID()))
+		tmp.Free()
+
+		return nil
+	}
+
 	dst, err := src.Convert(ctx, image.RGBA_U8_NORM)
 	if err != nil {
 		return err
@@ -316,18 +382,90 @@ func decompressTexSubImage2D(ctx context.Context, i api.CmdID, a *GlCompressedTe
 	return nil
 }
 
+// getTranscodeFormat returns the internal format that data of the unsupported
+// compressed format internalformat should be converted to for replay. This is
+// the first compressed format supported by the replay device that can be
+// encoded and holds the same channels, falling back to GL_RGBA8 if there is
+// none. S3TC is preferred over ETC2, as desktop drivers that expose ETC2 often
+// decompress it in software.
+// Sources with blocks that are not a multiple of 4 texels wide and high are
+// always converted to GL_RGBA8, as their sub-image updates need not be
+// aligned to the 4x4 blocks of the candidate formats.
+func (f features) getTranscodeFormat(internalformat GLenum) GLenum {
+	switch internalformat {
+	case GLenum_GL_COMPRESSED_SIGNED_R11_EAC,
+		GLenum_GL_COMPRESSED_SIGNED_RG11_EAC,
+		GLenum_GL_COMPRESSED_SIGNED_RED_RGTC1,
+		GLenum_GL_COMPRESSED_SIGNED_RG_RGTC2:
+		return GLenum_GL_RGBA8 // There are no encoders for signed formats.
+	}
+
+	info, _ := subGetSizedFormatInfo(nil, nil, api.CmdNoID, nil, &api.GlobalState{}, nil, 0, nil, internalformat)
+	if info.BlockWidth%4 != 0 || info.BlockHeight%4 != 0 {
+		return GLenum_GL_RGBA8
+	}
+	var candidates []GLenum
+	switch info.UnsizedFormat {
+	case GLenum_GL_RED:
+		candidates = []GLenum{GLenum_GL_COMPRESSED_RED_RGTC1}
+	case GLenum_GL_RG:
+		candidates = []GLenum{GLenum_GL_COMPRESSED_RG_RGTC2}
+	case GLenum_GL_RGB:
+		if info.SRGB {
+			candidates = []GLenum{GLenum_GL_COMPRESSED_SRGB8_ETC2}
+		} else {
+			candidates = []GLenum{GLenum_GL_COMPRESSED_RGB_S3TC_DXT1_EXT, GLenum_GL_COMPRESSED_RGB8_ETC2}
+		}
+	case GLenum_GL_RGBA:
+		if info.SRGB {
+			candidates = []GLenum{GLenum_GL_COMPRESSED_SRGB8_ALPHA8_ETC2_EAC}
+		} else {
+			candidates = []GLenum{GLenum_GL_COMPRESSED_RGBA_S3TC_DXT5_EXT, GLenum_GL_COMPRESSED_RGBA8_ETC2_EAC}
+		}
+	}
+	for _, c := range candidates {
+		if _, supported := f.compressedTextureFormats[c]; supported {
+			return c
+		}
+	}
+	return GLenum_GL_RGBA8
+}
+
 // getSupportedCompressedTextureFormats returns the set of supported compressed
-// texture formats for a given extension list.
-func getSupportedCompressedTextureFormats(extensions extensions) map[GLenum]struct{} {
+// texture formats for a given version and extension list.
+func getSupportedCompressedTextureFormats(v *Version, extensions extensions) map[GLenum]struct{} {
 	supported := map[GLenum]struct{}{}
 	for extension := range extensions {
 		for _, format := range getExtensionTextureFormats(extension) {
 			supported[format] = struct{}{}
 		}
 	}
+	// ETC2 and EAC are core in OpenGL ES 3.0. They are also core in OpenGL 4.3
+	// and part of GL_ARB_ES3_compatibility, but desktop drivers commonly
+	// decompress them on the CPU, so they are not treated as native there.
+	// Desktop replays use S3TC instead.
+	if v.AtLeastES(3, 0) {
+		for _, format := range etc2TextureFormats {
+			supported[format] = struct{}{}
+		}
+	}
 	return supported
 }
 
+// etc2TextureFormats is the list of ETC2 and EAC compressed texture formats.
+var etc2TextureFormats = []GLenum{
+	GLenum_GL_COMPRESSED_R11_EAC,
+	GLenum_GL_COMPRESSED_SIGNED_R11_EAC,
+	GLenum_GL_COMPRESSED_RG11_EAC,
+	GLenum_GL_COMPRESSED_SIGNED_RG11_EAC,
+	GLenum_GL_COMPRESSED_RGB8_ETC2,
+	GLenum_GL_COMPRESSED_SRGB8_ETC2,
+	GLenum_GL_COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2,
+	GLenum_GL_COMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2,
+	GLenum_GL_COMPRESSED_RGBA8_ETC2_EAC,
+	GLenum_GL_COMPRESSED_SRGB8_ALPHA8_ETC2_EAC,
+}
+
 // getExtensionTextureFormats returns the list of compressed texture formats
 // enabled by a given extension
 func getExtensionTextureFormats(extension string) []GLenum {
@@ -385,6 +523,41 @@ func getExtensionTextureFormats(extension string) []GLenum {
 			GLenum_GL_COMPRESSED_SRGB8_ALPHA8_ASTC_12x10_KHR,
 			GLenum_GL_COMPRESSED_SRGB8_ALPHA8_ASTC_12x12_KHR,
 		}
+	case "GL_IMG_texture_compression_pvrtc":
+		return []GLenum{
+			GLenum_GL_COMPRESSED_RGB_PVRTC_2BPPV1_IMG,
+			GLenum_GL_COMPRESSED_RGB_PVRTC_4BPPV1_IMG,
+			GLenum_GL_COMPRESSED_RGBA_PVRTC_2BPPV1_IMG,
+			GLenum_GL_COMPRESSED_RGBA_PVRTC_4BPPV1_IMG,
+		}
+	case "GL_IMG_texture_compression_pvrtc2":
+		return []GLenum{
+			GLenum_GL_COMPRESSED_RGBA_PVRTC_2BPPV2_IMG,
+			GLenum_GL_COMPRESSED_RGBA_PVRTC_4BPPV2_IMG,
+		}
+	case "GL_EXT_pvrtc_sRGB":
+		return []GLenum{
+			GLenum_GL_COMPRESSED_SRGB_PVRTC_2BPPV1_EXT,
+			GLenum_GL_COMPRESSED_SRGB_PVRTC_4BPPV1_EXT,
+			GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_2BPPV1_EXT,
+			GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_4BPPV1_EXT,
+			GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_2BPPV2_IMG,
+			GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_4BPPV2_IMG,
+		}
+	case "GL_EXT_texture_compression_rgtc", "GL_ARB_texture_compression_rgtc":
+		return []GLenum{
+			GLenum_GL_COMPRESSED_RED_RGTC1,
+			GLenum_GL_COMPRESSED_SIGNED_RED_RGTC1,
+			GLenum_GL_COMPRESSED_RG_RGTC2,
+			GLenum_GL_COMPRESSED_SIGNED_RG_RGTC2,
+		}
+	case "GL_EXT_texture_compression_bptc", "GL_ARB_texture_compression_bptc":
+		return []GLenum{
+			GLenum_GL_COMPRESSED_RGBA_BPTC_UNORM,
+			GLenum_GL_COMPRESSED_SRGB_ALPHA_BPTC_UNORM,
+			GLenum_GL_COMPRESSED_RGB_BPTC_SIGNED_FLOAT,
+			GLenum_GL_COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT,
+		}
 	case "GL_EXT_texture_compression_latc", "GL_NV_texture_compression_latc":
 		return []GLenum{
 			GLenum_GL_COMPRESSED_LUMINANCE_LATC1_EXT,
@@ -405,6 +578,8 @@ func isCompressedFormat(internalformat GLenum) bool {
 		GLenum_GL_ATC_RGB_AMD,
 		GLenum_GL_COMPRESSED_LUMINANCE_ALPHA_LATC2_EXT,
 		GLenum_GL_COMPRESSED_LUMINANCE_LATC1_EXT,
+		GLenum_GL_COMPRESSED_R11_EAC,
+		GLenum_GL_COMPRESSED_RED_RGTC1,
 		GLenum_GL_COMPRESSED_RG11_EAC,
 		GLenum_GL_COMPRESSED_RGB8_ETC2,
 		GLenum_GL_COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2,
@@ -423,14 +598,26 @@ func isCompressedFormat(internalformat GLenum) bool {
 		GLenum_GL_COMPRESSED_RGBA_ASTC_8x5,
 		GLenum_GL_COMPRESSED_RGBA_ASTC_8x6,
 		GLenum_GL_COMPRESSED_RGBA_ASTC_8x8,
+		GLenum_GL_COMPRESSED_RGBA_BPTC_UNORM,
+		GLenum_GL_COMPRESSED_RGBA_PVRTC_2BPPV1_IMG,
+		GLenum_GL_COMPRESSED_RGBA_PVRTC_2BPPV2_IMG,
+		GLenum_GL_COMPRESSED_RGBA_PVRTC_4BPPV1_IMG,
+		GLenum_GL_COMPRESSED_RGBA_PVRTC_4BPPV2_IMG,
 		GLenum_GL_COMPRESSED_RGBA_S3TC_DXT1_EXT,
 		GLenum_GL_COMPRESSED_RGBA_S3TC_DXT3_EXT,
 		GLenum_GL_COMPRESSED_RGBA_S3TC_DXT5_EXT,
+		GLenum_GL_COMPRESSED_RGB_BPTC_SIGNED_FLOAT,
+		GLenum_GL_COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT,
+		GLenum_GL_COMPRESSED_RGB_PVRTC_2BPPV1_IMG,
+		GLenum_GL_COMPRESSED_RGB_PVRTC_4BPPV1_IMG,
 		GLenum_GL_COMPRESSED_RGB_S3TC_DXT1_EXT,
+		GLenum_GL_COMPRESSED_RG_RGTC2,
 		GLenum_GL_COMPRESSED_SIGNED_LUMINANCE_ALPHA_LATC2_EXT,
 		GLenum_GL_COMPRESSED_SIGNED_LUMINANCE_LATC1_EXT,
 		GLenum_GL_COMPRESSED_SIGNED_R11_EAC,
+		GLenum_GL_COMPRESSED_SIGNED_RED_RGTC1,
 		GLenum_GL_COMPRESSED_SIGNED_RG11_EAC,
+		GLenum_GL_COMPRESSED_SIGNED_RG_RGTC2,
 		GLenum_GL_COMPRESSED_SRGB8_ALPHA8_ASTC_10x10,
 		GLenum_GL_COMPRESSED_SRGB8_ALPHA8_ASTC_10x5,
 		GLenum_GL_COMPRESSED_SRGB8_ALPHA8_ASTC_10x6,
@@ -448,6 +635,13 @@ func isCompressedFormat(internalformat GLenum) bool {
 		GLenum_GL_COMPRESSED_SRGB8_ALPHA8_ETC2_EAC,
 		GLenum_GL_COMPRESSED_SRGB8_ETC2,
 		GLenum_GL_COMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2,
+		GLenum_GL_COMPRESSED_SRGB_ALPHA_BPTC_UNORM,
+		GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_2BPPV1_EXT,
+		GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_2BPPV2_IMG,
+		GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_4BPPV1_EXT,
+		GLenum_GL_COMPRESSED_SRGB_ALPHA_PVRTC_4BPPV2_IMG,
+		GLenum_GL_COMPRESSED_SRGB_PVRTC_2BPPV1_EXT,
+		GLenum_GL_COMPRESSED_SRGB_PVRTC_4BPPV1_EXT,
 		GLenum_GL_ETC1_RGB8_OES:
 		return true
 	}
@@ -0,0 +1,59 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gles
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+)
+
+func TestGetTranscodeFormat(t *testing.T) {
+	assert := assert.To(t)
+
+	desktop := features{compressedTextureFormats: map[GLenum]struct{}{
+		GLenum_GL_COMPRESSED_RGB_S3TC_DXT1_EXT:  {},
+		GLenum_GL_COMPRESSED_RGBA_S3TC_DXT5_EXT: {},
+		GLenum_GL_COMPRESSED_RED_RGTC1:          {},
+	}}
+	mobile := features{compressedTextureFormats: map[GLenum]struct{}{
+		GLenum_GL_COMPRESSED_RGB8_ETC2:      {},
+		GLenum_GL_COMPRESSED_RGBA8_ETC2_EAC: {},
+	}}
+
+	for _, test := range []struct {
+		name     string
+		features features
+		from     GLenum
+		expected GLenum
+	}{
+		{"desktop ASTC 4x4", desktop, GLenum_GL_COMPRESSED_RGBA_ASTC_4x4_KHR, GLenum_GL_COMPRESSED_RGBA_S3TC_DXT5_EXT},
+		{"desktop ASTC 8x8", desktop, GLenum_GL_COMPRESSED_RGBA_ASTC_8x8_KHR, GLenum_GL_COMPRESSED_RGBA_S3TC_DXT5_EXT},
+		{"desktop ASTC 12x12", desktop, GLenum_GL_COMPRESSED_RGBA_ASTC_12x12_KHR, GLenum_GL_COMPRESSED_RGBA_S3TC_DXT5_EXT},
+		{"desktop ASTC 5x4", desktop, GLenum_GL_COMPRESSED_RGBA_ASTC_5x4_KHR, GLenum_GL_RGBA8},
+		{"desktop ASTC 5x5", desktop, GLenum_GL_COMPRESSED_RGBA_ASTC_5x5_KHR, GLenum_GL_RGBA8},
+		{"desktop ASTC 6x6", desktop, GLenum_GL_COMPRESSED_RGBA_ASTC_6x6_KHR, GLenum_GL_RGBA8},
+		{"desktop ASTC 8x5", desktop, GLenum_GL_COMPRESSED_RGBA_ASTC_8x5_KHR, GLenum_GL_RGBA8},
+		{"desktop ASTC 10x10", desktop, GLenum_GL_COMPRESSED_RGBA_ASTC_10x10_KHR, GLenum_GL_RGBA8},
+		{"desktop ETC2", desktop, GLenum_GL_COMPRESSED_RGB8_ETC2, GLenum_GL_COMPRESSED_RGB_S3TC_DXT1_EXT},
+		{"desktop signed EAC", desktop, GLenum_GL_COMPRESSED_SIGNED_R11_EAC, GLenum_GL_RGBA8},
+		{"mobile ASTC 4x4", mobile, GLenum_GL_COMPRESSED_RGBA_ASTC_4x4_KHR, GLenum_GL_COMPRESSED_RGBA8_ETC2_EAC},
+		{"mobile ASTC 6x6", mobile, GLenum_GL_COMPRESSED_RGBA_ASTC_6x6_KHR, GLenum_GL_RGBA8},
+		{"mobile S3TC", mobile, GLenum_GL_COMPRESSED_RGB_S3TC_DXT1_EXT, GLenum_GL_COMPRESSED_RGB8_ETC2},
+	} {
+		got := test.features.getTranscodeFormat(test.from)
+		assert.For("%s", test.name).That(got).Equals(test.expected)
+	}
+}
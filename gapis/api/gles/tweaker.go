@@ -17,6 +17,7 @@ package gles
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/google/gapid/gapis/api"
 	"github.com/google/gapid/gapis/api/transform"
@@ -99,6 +100,38 @@ func (t *tweaker) glDepthMask(ctx context.Context, v GLboolean) {
 	}
 }
 
+// glColorMask sets the color write mask of all the draw buffers. If the draw
+// buffers had different masks, they are restored with glColorMaski.
+func (t *tweaker) glColorMask(ctx context.Context, r, g, b, a GLboolean) {
+	v := Vec4b{r, g, b, a}
+	indices := []DrawBufferIndex{}
+	changed := false
+	for i, o := range t.c.Pixel.ColorWritemask {
+		indices = append(indices, i)
+		changed = changed || o != v
+	}
+	if !changed {
+		return
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+	o := make([]Vec4b, len(indices))
+	uniform := true
+	for i, idx := range indices {
+		o[i] = t.c.Pixel.ColorWritemask[idx]
+		uniform = uniform && o[i] == o[0]
+	}
+	t.out.MutateAndWrite(ctx, t.dID, t.cb.GlColorMask(r, g, b, a))
+	t.undo = append(t.undo, func(ctx context.Context) {
+		if uniform {
+			t.out.MutateAndWrite(ctx, t.dID, t.cb.GlColorMask(o[0][0], o[0][1], o[0][2], o[0][3]))
+			return
+		}
+		for i, idx := range indices {
+			t.out.MutateAndWrite(ctx, t.dID, t.cb.GlColorMaski(idx, o[i][0], o[i][1], o[i][2], o[i][3]))
+		}
+	})
+}
+
 func (t *tweaker) glDepthFunc(ctx context.Context, v GLenum) {
 	if o := t.c.Pixel.Depth.Func; o != v {
 		t.doAndUndo(ctx,
@@ -233,6 +266,17 @@ func (t *tweaker) glGenVertexArray(ctx context.Context) VertexArrayId {
 	return id
 }
 
+func (t *tweaker) glGenQuery(ctx context.Context) QueryId {
+	id := QueryId(newUnusedID(ctx, 'Q', func(x uint32) bool {
+		return t.c.Objects.Queries[QueryId(x)] != nil || t.c.Objects.GeneratedNames.Queries[QueryId(x)]
+	}))
+	tmp := t.AllocData(ctx, id)
+	t.doAndUndo(ctx,
+		t.cb.GlGenQueries(1, tmp.Ptr()).AddWrite(tmp.Data()),
+		t.cb.GlDeleteQueries(1, tmp.Ptr()).AddRead(tmp.Data()))
+	return id
+}
+
 func (t *tweaker) glCreateProgram(ctx context.Context) ProgramId {
 	id := ProgramId(newUnusedID(ctx, 'P', func(x uint32) bool {
 		return t.c.Objects.Shared.Programs[ProgramId(x)] != nil || t.c.Objects.Shared.Shaders[ShaderId(x)] != nil
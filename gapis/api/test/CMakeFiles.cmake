@@ -22,6 +22,7 @@ set(files
     convert.go
     doc.go
     enum.go
+    fuzz_test.go
     intrinsics_test.go
     mutate.go
     mutate_test.go
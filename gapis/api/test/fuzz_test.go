@@ -0,0 +1,66 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/api/fuzz"
+	"github.com/google/gapid/gapis/database"
+)
+
+var (
+	fuzzIterations = flag.Int("fuzz.iterations", 0, "Number of random command sequences mutated by TestFuzz")
+	fuzzSeed       = flag.Int64("fuzz.seed", 1, "Seed of the random command sequences mutated by TestFuzz")
+)
+
+// u8sMatchesMake checks that u8s holds as many elements as requested by the
+// last cmdMake.
+func u8sMatchesMake(ctx context.Context, cmd api.Cmd, s *api.GlobalState) error {
+	if c, ok := cmd.(*CmdMake); ok {
+		if got := GetState(s).U8s.Count(); got != uint64(c.Cnt) {
+			return fmt.Errorf("u8s has %d elements after cmdMake(%d)", got, c.Cnt)
+		}
+	}
+	return nil
+}
+
+func TestFuzz(t *testing.T) {
+	if *fuzzIterations == 0 {
+		t.Skip("Fuzzing is disabled. Enable with -fuzz.iterations=N")
+	}
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+
+	names, err := fuzz.CommandNames("test.api")
+	if !assert.For(ctx, "CommandNames").ThatError(err).Succeeded() {
+		return
+	}
+	config := fuzz.Config{
+		Generator:    fuzz.NewGenerator(API{}, names, *fuzzSeed),
+		Invariants:   []fuzz.Invariant{u8sMatchesMake},
+		MemoryLayout: device.Little32,
+	}
+	if p, f := fuzz.Fuzz(ctx, config, *fuzzIterations); f != nil {
+		log.E(ctx, "%v\n%vReproducer:\n%v", f, f.Stack, p)
+	}
+}
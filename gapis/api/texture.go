@@ -95,6 +95,37 @@ func (t *Texture1D) Thumbnail(ctx context.Context, w, h, d uint32) (*image.Info,
 	return m.best, nil
 }
 
+// Interface compliance check
+var _ = image.Convertable((*Texture1DArray)(nil))
+var _ = image.Thumbnailer((*Texture1DArray)(nil))
+
+// ConvertTo returns this Texture1DArray with each layer and mip-level
+// converted to the requested format.
+func (t *Texture1DArray) ConvertTo(ctx context.Context, f *image.Format) (interface{}, error) {
+	out := &Texture1DArray{
+		Layers: make([]*Texture1D, len(t.Layers)),
+	}
+	for i, l := range t.Layers {
+		l, err := l.ConvertTo(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		out.Layers[i] = l.(*Texture1D)
+	}
+	return out, nil
+}
+
+// Thumbnail returns the image that most closely matches the desired size.
+func (t *Texture1DArray) Thumbnail(ctx context.Context, w, h, d uint32) (*image.Info, error) {
+	m := imageMatcher{width: w, height: 1, depth: 1}
+	for _, layer := range t.Layers {
+		for _, level := range layer.Levels {
+			m.consider(level)
+		}
+	}
+	return m.best, nil
+}
+
 // Interface compliance check
 var _ = image.Convertable((*Texture2D)(nil))
 var _ = image.Thumbnailer((*Texture2D)(nil))
@@ -228,6 +259,39 @@ func (t *Cubemap) Thumbnail(ctx context.Context, w, h, d uint32) (*image.Info, e
 	return m.best, nil
 }
 
+// Interface compliance check
+var _ = image.Convertable((*CubemapArray)(nil))
+var _ = image.Thumbnailer((*CubemapArray)(nil))
+
+// ConvertTo returns this CubemapArray with each layer and mip-level face
+// converted to the requested format.
+func (t *CubemapArray) ConvertTo(ctx context.Context, f *image.Format) (interface{}, error) {
+	out := &CubemapArray{
+		Layers: make([]*Cubemap, len(t.Layers)),
+	}
+	for i, l := range t.Layers {
+		l, err := l.ConvertTo(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		out.Layers[i] = l.(*Cubemap)
+	}
+	return out, nil
+}
+
+// Thumbnail returns the image that most closely matches the desired size.
+func (t *CubemapArray) Thumbnail(ctx context.Context, w, h, d uint32) (*image.Info, error) {
+	m := imageMatcher{width: w, height: h, depth: 1}
+	for _, layer := range t.Layers {
+		for _, l := range layer.Levels {
+			for _, face := range l.faces() {
+				m.consider(face)
+			}
+		}
+	}
+	return m.best, nil
+}
+
 // Interface compliance check
 var _ = image.Convertable((*Texture)(nil))
 var _ = image.Thumbnailer((*Texture)(nil))
@@ -20,6 +20,7 @@
 set(files
     api.go
     buffer_command.go
+    buffer_view.go
     command_buffer_rebuilder.go
     constant_sets.go
     convert.go
@@ -38,6 +39,8 @@ set(files
     resolvables.pb.go
     resolvables.proto
     resources.go
+    shader_inputs.go
+    shader_reflection.go
     state.go
     vulkan.go
     vulkan_terminator.go
@@ -0,0 +1,108 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/gapis/resolve"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// BufferData implements the api.BufferDataProvider interface.
+func (API) BufferData(ctx context.Context, p *path.BufferView) ([]byte, *path.BufferLayout, error) {
+	s, err := resolve.GlobalState(ctx, p.After.GlobalStateAfter())
+	if err != nil {
+		return nil, nil, err
+	}
+	c := getStateObject(s)
+
+	var buffer *BufferObject
+	layout := p.Layout
+	switch p.Binding.GetKind() {
+	case path.BufferBinding_None:
+		b, ok := c.Buffers[VkBuffer(p.Buffer)]
+		if !ok {
+			return nil, nil, fmt.Errorf("Buffer %v does not exist", p.Buffer)
+		}
+		buffer = b
+
+	case path.BufferBinding_VertexAttribute:
+		b, l, err := boundVertexAttribute(c, p.Binding.Index)
+		if err != nil {
+			return nil, nil, err
+		}
+		buffer = b
+		if layout == nil {
+			layout = l
+		}
+
+	default:
+		return nil, nil, fmt.Errorf("Unsupported buffer binding %v", p.Binding.Kind)
+	}
+
+	if buffer.Memory == nil {
+		return nil, nil, fmt.Errorf("Buffer %v is not bound to memory", buffer.VulkanHandle)
+	}
+	offset := uint64(buffer.MemoryOffset)
+	size := uint64(buffer.Info.Size)
+	data := buffer.Memory.Data.Slice(offset, offset+size, s.MemoryLayout).Read(ctx, nil, s, nil)
+	return data, layout, nil
+}
+
+// boundVertexAttribute returns the buffer bound to the vertex attribute at
+// location by the last draw call, along with the layout of the attribute.
+func boundVertexAttribute(c *State, location uint32) (*BufferObject, *path.BufferLayout, error) {
+	if c.LastBoundQueue == nil {
+		return nil, nil, fmt.Errorf("No previous queue submission")
+	}
+	lastDrawInfo, ok := c.LastDrawInfos[c.LastBoundQueue.VulkanHandle]
+	if !ok || lastDrawInfo.GraphicsPipeline == nil {
+		return nil, nil, fmt.Errorf("There have been no previous draws")
+	}
+
+	vertexInput := lastDrawInfo.GraphicsPipeline.VertexInputState
+	for _, i := range vertexInput.AttributeDescriptions.KeysSorted() {
+		attribute := vertexInput.AttributeDescriptions.Get(i)
+		if attribute.Location != location {
+			continue
+		}
+		if !vertexInput.BindingDescriptions.Contains(attribute.Binding) {
+			return nil, nil, fmt.Errorf("Vertex attribute %v has no binding description", location)
+		}
+		binding := vertexInput.BindingDescriptions.Get(attribute.Binding)
+		if !lastDrawInfo.BoundVertexBuffers.Contains(binding.Binding) {
+			return nil, nil, fmt.Errorf("No vertex buffer bound to binding %v", binding.Binding)
+		}
+		bound := lastDrawInfo.BoundVertexBuffers.Get(binding.Binding)
+		if bound.Buffer == nil {
+			return nil, nil, fmt.Errorf("No vertex buffer bound to binding %v", binding.Binding)
+		}
+		format, err := translateVertexFormat(attribute.Format)
+		if err != nil {
+			return nil, nil, err
+		}
+		return bound.Buffer, &path.BufferLayout{
+			Offset: uint64(bound.Offset) + uint64(attribute.Offset),
+			Stride: uint32(binding.Stride),
+			Fields: []*path.BufferField{{
+				Name:   fmt.Sprintf("binding=%v, location=%v", binding.Binding, location),
+				Format: format,
+			}},
+		}, nil
+	}
+	return nil, nil, fmt.Errorf("Vertex attribute %v does not exist", location)
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/google/gapid/gapis/service"
 	"github.com/google/gapid/gapis/service/path"
 	"github.com/google/gapid/gapis/shadertools"
+	"github.com/google/gapid/gapis/shadertools/spirv"
 )
 
 func (t *ImageObject) IsResource() bool {
@@ -337,21 +338,21 @@ func getImageFormatFromVulkanFormat(vkfmt VkFormat) (*image.Format, error) {
 	case VkFormat_VK_FORMAT_BC3_SRGB_BLOCK:
 		return image.NewS3_DXT5_RGBA("VK_FORMAT_BC3_SRGB_BLOCK"), nil
 	case VkFormat_VK_FORMAT_BC4_UNORM_BLOCK:
-		return nil, &unsupportedVulkanFormatError{Format: vkfmt}
+		return image.NewBC4_R_U8_NORM("VK_FORMAT_BC4_UNORM_BLOCK"), nil
 	case VkFormat_VK_FORMAT_BC4_SNORM_BLOCK:
-		return nil, &unsupportedVulkanFormatError{Format: vkfmt}
+		return image.NewBC4_R_S8_NORM("VK_FORMAT_BC4_SNORM_BLOCK"), nil
 	case VkFormat_VK_FORMAT_BC5_UNORM_BLOCK:
-		return nil, &unsupportedVulkanFormatError{Format: vkfmt}
+		return image.NewBC5_RG_U8_NORM("VK_FORMAT_BC5_UNORM_BLOCK"), nil
 	case VkFormat_VK_FORMAT_BC5_SNORM_BLOCK:
-		return nil, &unsupportedVulkanFormatError{Format: vkfmt}
+		return image.NewBC5_RG_S8_NORM("VK_FORMAT_BC5_SNORM_BLOCK"), nil
 	case VkFormat_VK_FORMAT_BC6H_UFLOAT_BLOCK:
-		return nil, &unsupportedVulkanFormatError{Format: vkfmt}
+		return image.NewBC6H_RGB_U16_FLOAT("VK_FORMAT_BC6H_UFLOAT_BLOCK"), nil
 	case VkFormat_VK_FORMAT_BC6H_SFLOAT_BLOCK:
-		return nil, &unsupportedVulkanFormatError{Format: vkfmt}
+		return image.NewBC6H_RGB_S16_FLOAT("VK_FORMAT_BC6H_SFLOAT_BLOCK"), nil
 	case VkFormat_VK_FORMAT_BC7_UNORM_BLOCK:
-		return nil, &unsupportedVulkanFormatError{Format: vkfmt}
+		return image.NewBC7_RGBA_U8_NORM("VK_FORMAT_BC7_UNORM_BLOCK"), nil
 	case VkFormat_VK_FORMAT_BC7_SRGB_BLOCK:
-		return nil, &unsupportedVulkanFormatError{Format: vkfmt}
+		return image.NewBC7_SRGBA_U8_NORM("VK_FORMAT_BC7_SRGB_BLOCK"), nil
 	case VkFormat_VK_FORMAT_ETC2_R8G8B8_UNORM_BLOCK:
 		return image.NewETC2_RGB_U8_NORM("VK_FORMAT_ETC2_R8G8B8_UNORM_BLOCK"), nil
 	case VkFormat_VK_FORMAT_ETC2_R8G8B8_SRGB_BLOCK:
@@ -502,44 +503,70 @@ func (t *ImageObject) ResourceData(ctx context.Context, s *api.GlobalState) (*ap
 	if err != nil {
 		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNoTextureData(t.ResourceHandle())}
 	}
+	// layer returns the mip-levels of the layer with the given index.
+	layer := func(index uint32) []*image.Info {
+		levels := make([]*image.Info, len(t.Layers[index].Levels))
+		for i, level := range t.Layers[index].Levels {
+			levels[i] = &image.Info{
+				Format: format,
+				Width:  level.Width,
+				Height: level.Height,
+				Depth:  level.Depth,
+				Bytes:  image.NewID(level.Data.ResourceID(ctx, s)),
+			}
+		}
+		return levels
+	}
+	numLayers := uint32(len(t.Layers))
+
 	switch t.Info.ImageType {
+	case VkImageType_VK_IMAGE_TYPE_1D:
+		if numLayers == 1 {
+			return api.NewResourceData(api.NewTexture(&api.Texture1D{Levels: layer(0)})), nil
+		}
+		layers := make([]*api.Texture1D, numLayers)
+		for i := range layers {
+			layers[i] = &api.Texture1D{Levels: layer(uint32(i))}
+		}
+		return api.NewResourceData(api.NewTexture(&api.Texture1DArray{Layers: layers})), nil
+
 	case VkImageType_VK_IMAGE_TYPE_2D:
 		// If this image has VK_IMAGE_CREATE_CUBE_COMPATIBLE_BIT set, it should have six layers to
-		// represent a cubemap
-		if uint32(t.Info.Flags)&uint32(VkImageCreateFlagBits_VK_IMAGE_CREATE_CUBE_COMPATIBLE_BIT) != 0 {
-			// Cubemap
-			cubeMapLevels := make([]*api.CubemapLevel, len(t.Layers[0].Levels))
-			for l := range cubeMapLevels {
-				cubeMapLevels[l] = &api.CubemapLevel{}
-			}
-			for layerIndex, imageLayer := range t.Layers {
-				for levelIndex, imageLevel := range imageLayer.Levels {
-					img := &image.Info{
-						Format: format,
-						Width:  imageLevel.Width,
-						Height: imageLevel.Height,
-						Depth:  imageLevel.Depth,
-						Bytes:  image.NewID(imageLevel.Data.ResourceID(ctx, s)),
-					}
-					if !setCubemapFace(img, cubeMapLevels[levelIndex], layerIndex) {
-						return nil, &service.ErrDataUnavailable{Reason: messages.ErrNoTextureData(t.ResourceHandle())}
+		// represent a cubemap, or a multiple of six to represent a cubemap array.
+		if uint32(t.Info.Flags)&uint32(VkImageCreateFlagBits_VK_IMAGE_CREATE_CUBE_COMPATIBLE_BIT) != 0 && numLayers%6 == 0 {
+			cubes := make([]*api.Cubemap, numLayers/6)
+			for i := range cubes {
+				cubeMapLevels := make([]*api.CubemapLevel, len(t.Layers[0].Levels))
+				for l := range cubeMapLevels {
+					cubeMapLevels[l] = &api.CubemapLevel{}
+				}
+				for face := uint32(0); face < 6; face++ {
+					for levelIndex, img := range layer(uint32(i)*6 + face) {
+						if !setCubemapFace(img, cubeMapLevels[levelIndex], face) {
+							return nil, &service.ErrDataUnavailable{Reason: messages.ErrNoTextureData(t.ResourceHandle())}
+						}
 					}
 				}
+				cubes[i] = &api.Cubemap{Levels: cubeMapLevels}
+			}
+			if len(cubes) == 1 {
+				return api.NewResourceData(api.NewTexture(cubes[0])), nil
 			}
-			return api.NewResourceData(api.NewTexture(&api.Cubemap{Levels: cubeMapLevels})), nil
+			return api.NewResourceData(api.NewTexture(&api.CubemapArray{Layers: cubes})), nil
 		}
 
-		levels := make([]*image.Info, len(t.Layers[0].Levels))
-		for i, level := range t.Layers[0].Levels {
-			levels[i] = &image.Info{
-				Format: format,
-				Width:  level.Width,
-				Height: level.Height,
-				Depth:  level.Depth,
-				Bytes:  image.NewID(level.Data.ResourceID(ctx, s)),
-			}
+		if numLayers == 1 {
+			return api.NewResourceData(api.NewTexture(&api.Texture2D{Levels: layer(0)})), nil
 		}
-		return api.NewResourceData(api.NewTexture(&api.Texture2D{Levels: levels})), nil
+		layers := make([]*api.Texture2D, numLayers)
+		for i := range layers {
+			layers[i] = &api.Texture2D{Levels: layer(uint32(i))}
+		}
+		return api.NewResourceData(api.NewTexture(&api.Texture2DArray{Layers: layers})), nil
+
+	case VkImageType_VK_IMAGE_TYPE_3D:
+		return api.NewResourceData(api.NewTexture(&api.Texture3D{Levels: layer(0)})), nil
+
 	default:
 		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNoTextureData(t.ResourceHandle())}
 	}
@@ -580,7 +607,13 @@ func (s *ShaderModuleObject) ResourceData(ctx context.Context, t *api.GlobalStat
 	ctx = log.Enter(ctx, "ShaderModuleObject.ResourceData()")
 	words := s.Words.Read(ctx, nil, t, nil)
 	source := shadertools.DisassembleSpirvBinary(words)
-	return api.NewResourceData(&api.Shader{Type: api.ShaderType_Spirv, Source: source}), nil
+	shader := &api.Shader{Type: api.ShaderType_Spirv, Source: source}
+	if r, err := spirv.Reflect(words); err == nil {
+		shader.Reflection = shaderReflection(r)
+	} else {
+		log.W(ctx, "Couldn't reflect shader module %v: %v", s.VulkanHandle, err)
+	}
+	return api.NewResourceData(shader), nil
 }
 
 func (shader *ShaderModuleObject) SetResourceData(
@@ -0,0 +1,270 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/resolve"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+	"github.com/google/gapid/gapis/shadertools/spirv"
+)
+
+// vkWholeSize is the value of VK_WHOLE_SIZE.
+const vkWholeSize = VkDeviceSize(0xFFFFFFFFFFFFFFFF)
+
+// ShaderInputs implements the api.ShaderInputsProvider interface.
+//
+// p must refer to a draw subcommand of a VkQueueSubmit. The state after such
+// a path is cut at the subcommand, so the last draw info of the queue is that
+// of the draw itself.
+func (API) ShaderInputs(ctx context.Context, p *path.ShaderInputs) (*api.ShaderInputs, error) {
+	if len(p.Command.Indices) < 2 || p.Command.Indices[0] == 0 {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNotADrawCall()}
+	}
+	id := p.Command.Indices[0]
+	cmd, err := resolve.Cmd(ctx, p.Command.Capture.Command(id))
+	if err != nil {
+		return nil, err
+	}
+	submit, ok := cmd.(*VkQueueSubmit)
+	if !ok {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNotADrawCall()}
+	}
+	before, err := resolve.GlobalState(ctx, p.Command.Capture.Command(id-1).GlobalStateAfter())
+	if err != nil {
+		return nil, err
+	}
+	if ref, ok := subcommandReference(ctx, before, submit, p.Command.Indices[1:]); !ok || !isDrawCommand(ref.Type) {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNotADrawCall()}
+	}
+
+	s, err := resolve.GlobalState(ctx, p.Command.GlobalStateAfter())
+	if err != nil {
+		return nil, err
+	}
+	c := getStateObject(s)
+	if c.LastBoundQueue == nil {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNotADrawCall()}
+	}
+	lastDrawInfo, ok := c.LastDrawInfos[c.LastBoundQueue.VulkanHandle]
+	if !ok || lastDrawInfo.GraphicsPipeline == nil {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNotADrawCall()}
+	}
+
+	reflected := map[[2]uint32]spirv.Descriptor{}
+	pushConstants := []*spirv.Variable{}
+	for _, r := range pipelineReflection(ctx, s, lastDrawInfo.GraphicsPipeline) {
+		for _, d := range r.Descriptors {
+			key := [2]uint32{d.Set, d.Binding}
+			if _, ok := reflected[key]; !ok {
+				reflected[key] = d
+			}
+		}
+		if r.PushConstants != nil {
+			pushConstants = append(pushConstants, r.PushConstants)
+		}
+	}
+	// descriptorName returns the name of the element i of the descriptor
+	// binding, or an empty string if unknown.
+	descriptorName := func(set, binding, i uint32) string {
+		d, ok := reflected[[2]uint32{set, binding}]
+		switch {
+		case !ok:
+			return ""
+		case d.Count == 1:
+			return d.Name
+		default:
+			return fmt.Sprintf("%v[%d]", d.Name, i)
+		}
+	}
+
+	out := &api.ShaderInputs{}
+	for _, set := range lastDrawInfo.DescriptorSets.KeysSorted() {
+		descriptorSet := lastDrawInfo.DescriptorSets.Get(set)
+		if descriptorSet == nil {
+			continue
+		}
+		for _, binding := range descriptorSet.Bindings.KeysSorted() {
+			descriptors := descriptorSet.Bindings.Get(binding)
+			switch descriptors.BindingType {
+			case VkDescriptorType_VK_DESCRIPTOR_TYPE_UNIFORM_BUFFER,
+				VkDescriptorType_VK_DESCRIPTOR_TYPE_STORAGE_BUFFER,
+				VkDescriptorType_VK_DESCRIPTOR_TYPE_UNIFORM_BUFFER_DYNAMIC,
+				VkDescriptorType_VK_DESCRIPTOR_TYPE_STORAGE_BUFFER_DYNAMIC:
+				for _, i := range descriptors.BufferBinding.KeysSorted() {
+					info := descriptors.BufferBinding.Get(i)
+					if info == nil {
+						continue
+					}
+					buffer := &api.ShaderInputBuffer{
+						Name:    descriptorName(set, binding, i),
+						Set:     set,
+						Binding: binding,
+						Buffer:  uint64(info.Buffer),
+						Offset:  uint64(info.Offset),
+						Size:    uint64(info.Range),
+					}
+					if info.Range == vkWholeSize {
+						buffer.Size = 0
+						if b, ok := c.Buffers[info.Buffer]; ok && b.Info.Size > info.Offset {
+							buffer.Size = uint64(b.Info.Size - info.Offset)
+						}
+					}
+					if d, ok := reflected[[2]uint32{set, binding}]; ok && d.Type.Kind == spirv.TypeStruct {
+						layout := blockLayout(d.Type, buffer.Offset, buffer.Size)
+						buffer.View = p.Command.BufferView(buffer.Buffer, layout)
+					}
+					out.Buffers = append(out.Buffers, buffer)
+				}
+
+			case VkDescriptorType_VK_DESCRIPTOR_TYPE_UNIFORM_TEXEL_BUFFER,
+				VkDescriptorType_VK_DESCRIPTOR_TYPE_STORAGE_TEXEL_BUFFER:
+				for _, i := range descriptors.BufferViewBindings.KeysSorted() {
+					view, ok := c.BufferViews[descriptors.BufferViewBindings.Get(i)]
+					if !ok || view.Buffer == nil {
+						continue
+					}
+					buffer := &api.ShaderInputBuffer{
+						Name:    descriptorName(set, binding, i),
+						Set:     set,
+						Binding: binding,
+						Buffer:  uint64(view.Buffer.VulkanHandle),
+						Offset:  uint64(view.Offset),
+						Size:    uint64(view.Range),
+					}
+					if view.Range == vkWholeSize {
+						buffer.Size = uint64(view.Buffer.Info.Size - view.Offset)
+					}
+					out.Buffers = append(out.Buffers, buffer)
+				}
+
+			default:
+				for _, i := range descriptors.ImageBinding.KeysSorted() {
+					info := descriptors.ImageBinding.Get(i)
+					if info == nil {
+						continue
+					}
+					out.Textures = append(out.Textures, &api.ShaderInputTexture{
+						Name:    descriptorName(set, binding, i),
+						Set:     set,
+						Binding: binding,
+						Texture: uint64(info.ImageView),
+						Sampler: uint64(info.Sampler),
+					})
+				}
+			}
+		}
+	}
+
+	if len(lastDrawInfo.PushConstants) > 0 {
+		size := uint32(0)
+		for offset := range lastDrawInfo.PushConstants {
+			if offset >= size {
+				size = offset + 1
+			}
+		}
+		out.PushConstants = make([]byte, size)
+		for offset, b := range lastDrawInfo.PushConstants {
+			out.PushConstants[offset] = b
+		}
+	}
+
+	decoded := map[uint32]bool{}
+	for _, pc := range pushConstants {
+		if pc.Type.Kind != spirv.TypeStruct {
+			continue
+		}
+		for _, u := range blockUniforms(pc.Type, out.PushConstants) {
+			if !decoded[u.UniformLocation] {
+				decoded[u.UniformLocation] = true
+				out.Uniforms = append(out.Uniforms, u)
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// subcommandReference returns the command buffer command at idx in submit,
+// where idx holds the submit info, command buffer and command indices,
+// followed by the secondary command buffer and command indices for commands
+// executed by vkCmdExecuteCommands. s is the state before submit.
+func subcommandReference(ctx context.Context, s *api.GlobalState, submit *VkQueueSubmit, idx api.SubCmdIdx) (CommandReference, bool) {
+	if (len(idx) != 3 && len(idx) != 5) || idx[0] >= uint64(submit.SubmitCount) {
+		return CommandReference{}, false
+	}
+	c := GetState(s)
+	l := s.MemoryLayout
+	info := submit.PSubmits.Slice(uint64(0), uint64(submit.SubmitCount), l).Index(idx[0], l).Read(ctx, submit, s, nil)
+	if idx[1] >= uint64(info.CommandBufferCount) {
+		return CommandReference{}, false
+	}
+	buffer := info.PCommandBuffers.Slice(uint64(0), uint64(info.CommandBufferCount), l).Index(idx[1], l).Read(ctx, submit, s, nil)
+	bufferObject, ok := c.CommandBuffers[buffer]
+	if !ok {
+		return CommandReference{}, false
+	}
+	ref, ok := bufferObject.CommandReferences[uint32(idx[2])]
+	if !ok || len(idx) == 3 {
+		return ref, ok
+	}
+	if ref.Type != CommandType_cmd_vkCmdExecuteCommands {
+		return CommandReference{}, false
+	}
+	execute := bufferObject.BufferCommands.VkCmdExecuteCommands[ref.MapIndex]
+	secondary, ok := c.CommandBuffers[execute.CommandBuffers[uint32(idx[3])]]
+	if !ok {
+		return CommandReference{}, false
+	}
+	ref, ok = secondary.CommandReferences[uint32(idx[4])]
+	return ref, ok
+}
+
+// isDrawCommand returns true if t is the type of a draw command.
+func isDrawCommand(t CommandType) bool {
+	switch t {
+	case CommandType_cmd_vkCmdDraw,
+		CommandType_cmd_vkCmdDrawIndexed,
+		CommandType_cmd_vkCmdDrawIndirect,
+		CommandType_cmd_vkCmdDrawIndexedIndirect:
+		return true
+	}
+	return false
+}
+
+// pipelineReflection returns the reflection of the shader modules of each
+// stage of the pipeline. Modules that cannot be reflected are skipped.
+func pipelineReflection(ctx context.Context, s *api.GlobalState, pipeline *GraphicsPipelineObject) []*spirv.Reflection {
+	out := []*spirv.Reflection{}
+	for _, i := range pipeline.Stages.KeysSorted() {
+		module := pipeline.Stages.Get(i).Module
+		if module == nil {
+			continue
+		}
+		r, err := spirv.Reflect(module.Words.Read(ctx, nil, s, nil))
+		if err != nil {
+			log.W(ctx, "Couldn't reflect shader module %v: %v", module.VulkanHandle, err)
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
@@ -0,0 +1,342 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulkan
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/google/gapid/core/stream"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/service/box"
+	"github.com/google/gapid/gapis/service/path"
+	"github.com/google/gapid/gapis/shadertools/spirv"
+)
+
+// maxRuntimeArrayElements is the maximum number of elements of a runtime
+// sized array listed in the fields of a block.
+const maxRuntimeArrayElements = 256
+
+// shaderReflection converts the reflection of a shader module to its service
+// representation.
+func shaderReflection(r *spirv.Reflection) *api.ShaderReflection {
+	out := &api.ShaderReflection{}
+	for _, e := range r.EntryPoints {
+		out.EntryPoints = append(out.EntryPoints, &api.ShaderEntryPoint{
+			Name:  e.Name,
+			Stage: shaderStage(e.Stage),
+		})
+	}
+	for _, d := range r.Descriptors {
+		descriptor := &api.ShaderDescriptor{
+			Name:    d.Name,
+			Set:     d.Set,
+			Binding: d.Binding,
+			Kind:    api.ShaderDescriptor_Kind(d.Kind),
+			Count:   d.Count,
+			Type:    d.Type.String(),
+		}
+		if d.Type.Kind == spirv.TypeStruct {
+			descriptor.Block = shaderBlock(d.Type.Name, d.Type)
+		}
+		out.Descriptors = append(out.Descriptors, descriptor)
+	}
+	if pc := r.PushConstants; pc != nil && pc.Type.Kind == spirv.TypeStruct {
+		out.PushConstants = shaderBlock(pc.Name, pc.Type)
+	}
+	for _, v := range r.Inputs {
+		out.Inputs = append(out.Inputs, &api.ShaderVariable{Name: v.Name, Location: v.Location, Type: v.Type.String()})
+	}
+	for _, v := range r.Outputs {
+		out.Outputs = append(out.Outputs, &api.ShaderVariable{Name: v.Name, Location: v.Location, Type: v.Type.String()})
+	}
+	for _, c := range r.SpecConstants {
+		constant := &api.ShaderSpecConstant{Name: c.Name, Id: c.ID, Type: c.Type.String()}
+		if c.Value != nil {
+			constant.Value = box.NewValue(c.Value)
+		}
+		out.SpecConstants = append(out.SpecConstants, constant)
+	}
+	return out
+}
+
+// shaderStage returns the service shader type of the SPIR-V execution model t.
+func shaderStage(t spirv.ExecutionModel) api.ShaderType {
+	switch t {
+	case spirv.ExecutionModelVertex:
+		return api.ShaderType_Vertex
+	case spirv.ExecutionModelTessControl:
+		return api.ShaderType_TessControl
+	case spirv.ExecutionModelTessEvaluation:
+		return api.ShaderType_TessEvaluation
+	case spirv.ExecutionModelGeometry:
+		return api.ShaderType_Geometry
+	case spirv.ExecutionModelFragment:
+		return api.ShaderType_Fragment
+	case spirv.ExecutionModelCompute:
+		return api.ShaderType_Compute
+	default:
+		return api.ShaderType_Spirv
+	}
+}
+
+// shaderBlock returns the layout of the block with the struct type t.
+func shaderBlock(name string, t *spirv.Type) *api.ShaderBlock {
+	block := &api.ShaderBlock{Name: name, Size: t.Size(0, false)}
+	for _, m := range t.Members {
+		block.Members = append(block.Members, &api.ShaderBlockMember{
+			Name:   m.Name,
+			Type:   m.Type.String(),
+			Offset: m.Offset,
+			Size:   m.Type.Size(m.MatrixStride, m.RowMajor),
+		})
+	}
+	return block
+}
+
+// blockMember is a scalar, vector or matrix within a block.
+type blockMember struct {
+	name         string
+	offset       uint32
+	ty           *spirv.Type
+	matrixStride uint32
+	rowMajor     bool
+}
+
+// flattenBlock appends the scalar, vector and matrix members of the type t at
+// offset to out, expanding structs and arrays. Runtime sized arrays are
+// expanded to the elements that fit within limit bytes.
+func flattenBlock(out []blockMember, name string, offset uint32, t *spirv.Type, matrixStride uint32, rowMajor bool, limit uint32) []blockMember {
+	switch t.Kind {
+	case spirv.TypeStruct:
+		for _, m := range t.Members {
+			memberName := m.Name
+			if name != "" {
+				memberName = name + "." + m.Name
+			}
+			out = flattenBlock(out, memberName, offset+m.Offset, m.Type, m.MatrixStride, m.RowMajor, limit)
+		}
+	case spirv.TypeArray:
+		count := t.Count
+		if count == 0 && t.ArrayStride > 0 && limit > offset {
+			count = (limit - offset) / t.ArrayStride
+			if count > maxRuntimeArrayElements {
+				count = maxRuntimeArrayElements
+			}
+		}
+		for i := uint32(0); i < count; i++ {
+			elName := fmt.Sprintf("%v[%d]", name, i)
+			out = flattenBlock(out, elName, offset+i*t.ArrayStride, t.Elem, matrixStride, rowMajor, limit)
+		}
+	case spirv.TypeBool, spirv.TypeInt, spirv.TypeFloat,
+		spirv.TypeVector, spirv.TypeMatrix:
+		out = append(out, blockMember{name, offset, t, matrixStride, rowMajor})
+	}
+	return out
+}
+
+// shape returns the number of column vectors and the number of rows of the
+// member, along with its scalar type.
+func (m blockMember) shape() (columns, rows uint32, scalar *spirv.Type) {
+	switch m.ty.Kind {
+	case spirv.TypeVector:
+		return 1, m.ty.Count, m.ty.Elem
+	case spirv.TypeMatrix:
+		return m.ty.Count, m.ty.Elem.Count, m.ty.Elem.Elem
+	default:
+		return 1, 1, m.ty
+	}
+}
+
+// scalarSize returns the size in bytes of the scalar type t.
+func scalarSize(t *spirv.Type) uint32 {
+	if t.Kind == spirv.TypeBool {
+		return 4
+	}
+	return t.Width / 8
+}
+
+// blockLayout returns the buffer layout of the block with the struct type t
+// bound at offset, with size bytes bound.
+func blockLayout(t *spirv.Type, offset, size uint64) *path.BufferLayout {
+	xyzw := []stream.Channel{
+		stream.Channel_X,
+		stream.Channel_Y,
+		stream.Channel_Z,
+		stream.Channel_W,
+	}
+	fields := []*path.BufferField{}
+	for _, m := range flattenBlock(nil, "", 0, t, 0, false, uint32(size)) {
+		columns, rows, scalar := m.shape()
+		var dt stream.DataType
+		switch {
+		case scalar.Kind == spirv.TypeFloat && scalar.Width == 16:
+			dt = stream.F16
+		case scalar.Kind == spirv.TypeFloat && scalar.Width == 64:
+			dt = stream.F64
+		case scalar.Kind == spirv.TypeFloat:
+			dt = stream.F32
+		case scalar.Kind == spirv.TypeInt && scalar.Signed && scalar.Width == 64:
+			dt = stream.S64
+		case scalar.Kind == spirv.TypeInt && scalar.Width == 64:
+			dt = stream.U64
+		case scalar.Kind == spirv.TypeInt && scalar.Signed:
+			dt = stream.S32
+		default:
+			dt = stream.U32
+		}
+		vectors, components := columns, rows
+		if m.rowMajor {
+			vectors, components = rows, columns
+		}
+		if components > uint32(len(xyzw)) {
+			continue
+		}
+		f := &stream.Format{Components: make([]*stream.Component, components)}
+		for i := range f.Components {
+			f.Components[i] = &stream.Component{
+				DataType: &dt,
+				Sampling: stream.Linear,
+				Channel:  xyzw[i],
+			}
+		}
+		for v := uint32(0); v < vectors; v++ {
+			name, fieldOffset := m.name, m.offset
+			if vectors > 1 {
+				name, fieldOffset = fmt.Sprintf("%v[%d]", m.name, v), fieldOffset+v*m.matrixStride
+			}
+			fields = append(fields, &path.BufferField{Name: name, Offset: fieldOffset, Format: f})
+		}
+	}
+	return &path.BufferLayout{Offset: offset, Count: 1, Fields: fields}
+}
+
+// blockUniforms decodes the members of the block with the struct type t from
+// data, such as the push constants.
+func blockUniforms(t *spirv.Type, data []byte) []*api.Uniform {
+	out := []*api.Uniform{}
+	for _, m := range flattenBlock(nil, "", 0, t, 0, false, uint32(len(data))) {
+		columns, rows, scalar := m.shape()
+		format, ok := uniformFormat(columns, rows)
+		if !ok {
+			continue
+		}
+		size := scalarSize(scalar)
+		offsets := make([]uint32, 0, columns*rows)
+		for c := uint32(0); c < columns; c++ {
+			for r := uint32(0); r < rows; r++ {
+				if m.rowMajor {
+					offsets = append(offsets, m.offset+r*m.matrixStride+c*size)
+				} else {
+					offsets = append(offsets, m.offset+c*m.matrixStride+r*size)
+				}
+			}
+		}
+		if offsets[len(offsets)-1]+size > uint32(len(data)) {
+			continue // Not pushed.
+		}
+		read := func(o uint32) uint64 {
+			switch size {
+			case 1:
+				return uint64(data[o])
+			case 2:
+				return uint64(binary.LittleEndian.Uint16(data[o:]))
+			case 8:
+				return binary.LittleEndian.Uint64(data[o:])
+			default:
+				return uint64(binary.LittleEndian.Uint32(data[o:]))
+			}
+		}
+
+		u := &api.Uniform{UniformLocation: m.offset, Name: m.name, Format: format}
+		switch {
+		case scalar.Kind == spirv.TypeBool:
+			u.Type = api.UniformType_Bool
+			v := make([]bool, len(offsets))
+			for i, o := range offsets {
+				v[i] = read(o) != 0
+			}
+			u.Value = box.NewValue(v)
+		case scalar.Kind == spirv.TypeFloat && size == 8:
+			u.Type = api.UniformType_Double
+			v := make([]float64, len(offsets))
+			for i, o := range offsets {
+				v[i] = math.Float64frombits(read(o))
+			}
+			u.Value = box.NewValue(v)
+		case scalar.Kind == spirv.TypeFloat && size == 4:
+			u.Type = api.UniformType_Float
+			v := make([]float32, len(offsets))
+			for i, o := range offsets {
+				v[i] = math.Float32frombits(uint32(read(o)))
+			}
+			u.Value = box.NewValue(v)
+		case scalar.Kind == spirv.TypeInt && scalar.Signed:
+			u.Type = api.UniformType_Int32
+			v := make([]int32, len(offsets))
+			for i, o := range offsets {
+				v[i] = int32(read(o))
+			}
+			u.Value = box.NewValue(v)
+		case scalar.Kind == spirv.TypeInt:
+			u.Type = api.UniformType_Uint32
+			v := make([]uint32, len(offsets))
+			for i, o := range offsets {
+				v[i] = uint32(read(o))
+			}
+			u.Value = box.NewValue(v)
+		default:
+			continue
+		}
+		out = append(out, u)
+	}
+	return out
+}
+
+// uniformFormat returns the uniform format with the given number of columns
+// and rows.
+func uniformFormat(columns, rows uint32) (api.UniformFormat, bool) {
+	switch {
+	case columns == 1 && rows == 1:
+		return api.UniformFormat_Scalar, true
+	case columns == 1 && rows == 2:
+		return api.UniformFormat_Vec2, true
+	case columns == 1 && rows == 3:
+		return api.UniformFormat_Vec3, true
+	case columns == 1 && rows == 4:
+		return api.UniformFormat_Vec4, true
+	case columns == 2 && rows == 2:
+		return api.UniformFormat_Mat2, true
+	case columns == 3 && rows == 3:
+		return api.UniformFormat_Mat3, true
+	case columns == 4 && rows == 4:
+		return api.UniformFormat_Mat4, true
+	case columns == 2 && rows == 3:
+		return api.UniformFormat_Mat2x3, true
+	case columns == 2 && rows == 4:
+		return api.UniformFormat_Mat2x4, true
+	case columns == 3 && rows == 2:
+		return api.UniformFormat_Mat3x2, true
+	case columns == 3 && rows == 4:
+		return api.UniformFormat_Mat3x4, true
+	case columns == 4 && rows == 2:
+		return api.UniformFormat_Mat4x2, true
+	case columns == 4 && rows == 3:
+		return api.UniformFormat_Mat4x3, true
+	default:
+		return 0, false
+	}
+}
@@ -22,8 +22,11 @@ set(files
     capture.pb.go
     capture.proto
     context.go
+    counters.go
     decoder.go
     encoder.go
+    log.go
+    sidecar.go
     doc.go
 )
 set(dirs
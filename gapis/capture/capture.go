@@ -48,6 +48,15 @@ type Capture struct {
 	Commands []api.Cmd
 	APIs     []api.API
 	Observed interval.U64RangeList
+	// Log is the device log recorded while the capture was taken, or nil if
+	// no log was recorded.
+	Log *Log
+	// Counters are the device performance counters sampled while the capture
+	// was taken, or nil if no counters were sampled.
+	Counters *Counters
+	// offsets holds, for each command, the offset of the capture stream at
+	// the end of the command. It is nil if the capture was not decoded.
+	offsets []uint64
 }
 
 func init() {
@@ -143,10 +152,13 @@ func ResolveFromPath(ctx context.Context, p *path.Capture) (*Capture, error) {
 
 // Import imports the capture by name and data, and stores it in the database.
 func Import(ctx context.Context, name string, data []byte) (*path.Capture, error) {
-	id, err := database.Store(ctx, &Record{
-		Name: name,
-		Data: data,
-	})
+	return ImportRecord(ctx, &Record{Name: name, Data: data})
+}
+
+// ImportRecord imports the capture record, which may also hold the data
+// recorded alongside the capture, and stores it in the database.
+func ImportRecord(ctx context.Context, r *Record) (*path.Capture, error) {
+	id, err := database.Store(ctx, r)
 	if err != nil {
 		return nil, err
 	}
@@ -216,7 +228,10 @@ func fromProto(ctx context.Context, r *Record) (*Capture, error) {
 	if d.header == nil {
 		return nil, log.Err(ctx, nil, "Capture was missing header chunk")
 	}
-	return d.builder.build(r.Name, d.header), nil
+	c := d.builder.build(r.Name, d.header)
+	c.Log = r.Log
+	c.Counters = r.Counters
+	return c, nil
 }
 
 type builder struct {
@@ -225,6 +240,7 @@ type builder struct {
 	seenAPIs map[api.ID]struct{}
 	observed interval.U64RangeList
 	cmds     []api.Cmd
+	offsets  []uint64
 }
 
 func newBuilder() *builder {
@@ -285,5 +301,6 @@ func (b *builder) build(name string, header *Header) *Capture {
 		Commands: b.cmds,
 		Observed: b.observed,
 		APIs:     b.apis,
+		offsets:  b.offsets,
 	}
 }
@@ -56,3 +56,90 @@ func TestCaptureExportImport(t *testing.T) {
 
 	assert.For(ctx, "got").That(ic.Commands).DeepEquals(cmds)
 }
+
+func TestCaptureLog(t *testing.T) {
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	header := &capture.Header{Abi: device.WindowsX86_64}
+	cmds := []api.Cmd{testcmd.P, testcmd.Q}
+	p, err := capture.New(ctx, "test", header, cmds)
+	if !assert.For(ctx, "capture.New").ThatError(err).Succeeded() {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	err = capture.Export(capture.Put(ctx, p), p, buf)
+	if !assert.For(ctx, "capture.Export").ThatError(err).Succeeded() {
+		return
+	}
+
+	first := &capture.LogMessage{Text: "first", CaptureOffset: 0}
+	last := &capture.LogMessage{Text: "last", CaptureOffset: uint64(buf.Len()) + 1}
+	l := &capture.Log{Messages: []*capture.LogMessage{first, last}}
+	ip, err := capture.ImportRecord(ctx, &capture.Record{Name: "imported", Data: buf.Bytes(), Log: l})
+	if !assert.For(ctx, "capture.ImportRecord").ThatError(err).Succeeded() {
+		return
+	}
+
+	ic, err := capture.Resolve(capture.Put(ctx, ip))
+	if !assert.For(ctx, "capture.Resolve").ThatError(err).Succeeded() {
+		return
+	}
+
+	assert.For(ctx, "first command").That(ic.LogCommand(first)).Equals(api.CmdID(0))
+	assert.For(ctx, "last command").That(ic.LogCommand(last)).Equals(api.CmdID(1))
+	assert.For(ctx, "messages").That(ic.LogMessages(0, 1)).DeepEquals([]*capture.LogMessage{first, last})
+	assert.For(ctx, "last messages").That(ic.LogMessages(1, 1)).DeepEquals([]*capture.LogMessage{last})
+}
+
+func TestCaptureLogOffsets(t *testing.T) {
+	assert := assert.To(t)
+	ctx := log.Testing(t)
+	ctx = database.Put(ctx, database.NewInMemory(ctx))
+	header := &capture.Header{Abi: device.WindowsX86_64}
+	p2, q2 := *testcmd.P, *testcmd.Q
+	cmds := []api.Cmd{testcmd.P, testcmd.Q, &p2, &q2}
+
+	// ends[i] is the size of the stream holding the first i+1 commands, which
+	// is the stream offset at the end of command i.
+	ends := make([]uint64, len(cmds))
+	var data []byte
+	for i := range cmds {
+		p, err := capture.New(ctx, "test", header, cmds[:i+1])
+		if !assert.For("capture.New").ThatError(err).Succeeded() {
+			return
+		}
+		buf := &bytes.Buffer{}
+		err = capture.Export(capture.Put(ctx, p), p, buf)
+		if !assert.For("capture.Export").ThatError(err).Succeeded() {
+			return
+		}
+		ends[i], data = uint64(buf.Len()), buf.Bytes()
+	}
+
+	msgs := []*capture.LogMessage{
+		{Text: "start", CaptureOffset: 0},
+		{Text: "end of 0", CaptureOffset: ends[0]},
+		{Text: "during 1", CaptureOffset: ends[0] + 1},
+		{Text: "end of 1", CaptureOffset: ends[1]},
+		{Text: "during 3", CaptureOffset: ends[2] + 1},
+		{Text: "after", CaptureOffset: ends[3] + 1},
+	}
+	expected := []api.CmdID{0, 0, 1, 1, 3, 3}
+
+	l := &capture.Log{Messages: msgs}
+	ip, err := capture.ImportRecord(ctx, &capture.Record{Name: "imported", Data: data, Log: l})
+	if !assert.For("capture.ImportRecord").ThatError(err).Succeeded() {
+		return
+	}
+	ic, err := capture.Resolve(capture.Put(ctx, ip))
+	if !assert.For("capture.Resolve").ThatError(err).Succeeded() {
+		return
+	}
+
+	for i, m := range msgs {
+		assert.For("%s", m.Text).That(ic.LogCommand(m)).Equals(expected[i])
+	}
+	assert.For("messages 1-2").That(ic.LogMessages(1, 2)).DeepEquals(msgs[2:4])
+	assert.For("messages 2-2").That(ic.LogMessages(2, 2)).DeepEquals([]*capture.LogMessage{})
+}
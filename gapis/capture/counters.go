@@ -0,0 +1,55 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import "context"
+
+// CountersExtension is the suffix appended to the capture file path to give
+// the path of the file holding the device performance counters sampled with
+// the capture.
+const CountersExtension = ".counters"
+
+// ReadCounters reads the device performance counters stored next to the
+// capture file at capturePath. If there is no counters file, then
+// ReadCounters returns nil.
+func ReadCounters(ctx context.Context, capturePath string) (*Counters, error) {
+	c := &Counters{}
+	if found, err := readSidecar(ctx, capturePath, CountersExtension, c); !found {
+		return nil, err
+	}
+	return c, nil
+}
+
+// WriteCounters writes the device performance counters c next to the capture
+// file at capturePath.
+func WriteCounters(ctx context.Context, c *Counters, capturePath string) error {
+	return writeSidecar(ctx, capturePath, CountersExtension, c)
+}
+
+// Add appends the sample s to the counter with the given name, adding the
+// counter if it does not already exist.
+func (c *Counters) Add(name, unit string, s *CounterSample) {
+	for _, counter := range c.Counters {
+		if counter.Name == name {
+			counter.Samples = append(counter.Samples, s)
+			return
+		}
+	}
+	c.Counters = append(c.Counters, &Counter{
+		Name:    name,
+		Unit:    unit,
+		Samples: []*CounterSample{s},
+	})
+}
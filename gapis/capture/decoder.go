@@ -19,6 +19,7 @@ type decoder struct {
 	header  *Header
 	builder *builder
 	groups  map[uint64]interface{}
+	offset  uint64 // stream offset at the end of the current chunk.
 }
 
 func newDecoder() *decoder {
@@ -28,6 +29,11 @@ func newDecoder() *decoder {
 	}
 }
 
+func (d *decoder) ChunkEnd(ctx context.Context, offset uint64) error {
+	d.offset = offset
+	return nil
+}
+
 func (d *decoder) BeginGroup(ctx context.Context, msg proto.Message, id uint64) error {
 	obj, err := d.decode(ctx, msg)
 	if err != nil {
@@ -53,6 +59,7 @@ func (d *decoder) EndGroup(ctx context.Context, id uint64) error {
 	switch obj := obj.(type) {
 	case *cmdGroup:
 		id := d.builder.addCmd(ctx, obj.cmd)
+		d.builder.offsets = append(d.builder.offsets, d.offset)
 		for _, c := range obj.children {
 			c.SetCaller(id)
 		}
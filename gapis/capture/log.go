@@ -0,0 +1,73 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+)
+
+// LogExtension is the suffix appended to the capture file path to give the
+// path of the file holding the device log recorded with the capture.
+const LogExtension = ".logcat"
+
+// ReadLog reads the device log stored next to the capture file at
+// capturePath. If there is no log file, then ReadLog returns nil.
+func ReadLog(ctx context.Context, capturePath string) (*Log, error) {
+	l := &Log{}
+	if found, err := readSidecar(ctx, capturePath, LogExtension, l); !found {
+		return nil, err
+	}
+	return l, nil
+}
+
+// WriteLog writes the device log l next to the capture file at capturePath.
+func WriteLog(ctx context.Context, l *Log, capturePath string) error {
+	return writeSidecar(ctx, capturePath, LogExtension, l)
+}
+
+// Time returns the device time the message was logged.
+func (m *LogMessage) Time() time.Time {
+	return time.Unix(0, m.Timestamp)
+}
+
+// LogSeverity returns the severity of the message.
+func (m *LogMessage) LogSeverity() log.Severity {
+	return log.Severity(m.Severity)
+}
+
+// LogCommand returns the identifier of the command that was being traced when
+// the device log message m was received.
+func (c *Capture) LogCommand(m *LogMessage) api.CmdID {
+	return c.CommandAt(m.CaptureOffset)
+}
+
+// LogMessages returns the device log messages that were received while the
+// commands in the range [first, last] were being traced.
+func (c *Capture) LogMessages(first, last api.CmdID) []*LogMessage {
+	if c.Log == nil {
+		return nil
+	}
+	out := []*LogMessage{}
+	for _, m := range c.Log.Messages {
+		if id := c.LogCommand(m); id != api.CmdNoID && id >= first && id <= last {
+			out = append(out, m)
+		}
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+)
+
+// readSidecar reads the message stored next to the capture file at
+// capturePath, in the file with the suffix ext, into msg.
+// If there is no such file, then readSidecar returns false.
+func readSidecar(ctx context.Context, capturePath, ext string, msg proto.Message) (bool, error) {
+	data, err := ioutil.ReadFile(capturePath + ext)
+	switch {
+	case os.IsNotExist(err):
+		return false, nil
+	case err != nil:
+		return false, log.Errf(ctx, err, "Reading %v", capturePath+ext)
+	}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return false, log.Errf(ctx, err, "Decoding %v", capturePath+ext)
+	}
+	return true, nil
+}
+
+// writeSidecar writes msg next to the capture file at capturePath, in the
+// file with the suffix ext.
+func writeSidecar(ctx context.Context, capturePath, ext string, msg proto.Message) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return log.Errf(ctx, err, "Encoding %v", capturePath+ext)
+	}
+	if err := ioutil.WriteFile(capturePath+ext, data, 0666); err != nil {
+		return log.Errf(ctx, err, "Writing %v", capturePath+ext)
+	}
+	return nil
+}
+
+// CommandAt returns the identifier of the command that was being traced when
+// offset bytes of the capture stream had been received. If the capture was
+// not decoded from a capture stream, then CommandAt returns api.CmdNoID.
+func (c *Capture) CommandAt(offset uint64) api.CmdID {
+	if len(c.offsets) == 0 {
+		return api.CmdNoID
+	}
+	i := sort.Search(len(c.offsets), func(i int) bool { return c.offsets[i] >= offset })
+	if i == len(c.offsets) {
+		// The offset is past the end of the last command.
+		i--
+	}
+	return api.CmdID(i)
+}
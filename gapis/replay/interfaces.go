@@ -62,6 +62,29 @@ type QueryFramebufferAttachment interface {
 		hints *service.UsageHints) (*image.Data, error)
 }
 
+// FramebufferAttachmentSupport is the interface optionally implemented by
+// types implementing QueryFramebufferAttachment that cannot read back every
+// kind of framebuffer attachment.
+type FramebufferAttachmentSupport interface {
+	// SupportsFramebufferAttachment returns true if the content of attachment
+	// can be returned by QueryFramebufferAttachment.
+	SupportsFramebufferAttachment(attachment api.FramebufferAttachment) bool
+}
+
+// QueryPixelHistory is the interface implemented by types that can return the
+// draw calls that touched a single pixel of a framebuffer attachment, up to a
+// particular point in a capture.
+type QueryPixelHistory interface {
+	QueryPixelHistory(
+		ctx context.Context,
+		intent Intent,
+		mgr *Manager,
+		after []uint64,
+		attachment api.FramebufferAttachment,
+		x, y uint32,
+		hints *service.UsageHints) (*service.PixelHistory, error)
+}
+
 // Issue represents a single replay issue reported by QueryIssues.
 type Issue struct {
 	Command  api.CmdID        // The command that reported the issue.
@@ -20,10 +20,13 @@
 set(files
     as.go
     atoms.go
+    buffer_view.go
+    buffer_view_test.go
     command_tree.go
     commands.go
     constant_set.go
     contexts.go
+    counters.go
     doc.go
     errors.go
     events.go
@@ -32,13 +35,16 @@ set(files
     follow.go
     framebuffer_attachment.go
     framebuffer_attachment_data.go
+    framebuffer_attachments.go
     framebuffer_changes.go
     framebuffer_observation.go
     get.go
     get_set_test.go
     index_limits.go
+    log.go
     memory.go
     mesh.go
+    pixel_history.go
     report.go
     requests_test.go
     resolvables.pb.go
@@ -49,6 +55,7 @@ set(files
     resources.go
     service.go
     set.go
+    shader_inputs.go
     state.go
     state_tree.go
     state_tree_test.go
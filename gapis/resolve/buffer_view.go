@@ -0,0 +1,129 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/core/data/endian"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/stream"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// BufferView resolves and returns the decoded rows of the buffer from the
+// path p.
+func BufferView(ctx context.Context, p *path.BufferView) (*service.BufferView, error) {
+	cmd, err := Cmd(ctx, p.After)
+	if err != nil {
+		return nil, err
+	}
+
+	a := cmd.API()
+	if a == nil {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrStateUnavailable()}
+	}
+
+	bp, ok := a.(api.BufferDataProvider)
+	if !ok {
+		log.E(ctx, "API %s does not implement BufferDataProvider", a.Name())
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrStateUnavailable()}
+	}
+
+	data, layout, err := bp.BufferData(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := decodeBufferRows(data, layout)
+	if err != nil {
+		return nil, err
+	}
+	return &service.BufferView{Layout: layout, Rows: rows}, nil
+}
+
+// decodeBufferRows decodes each field of each row of data described by the
+// layout l.
+func decodeBufferRows(data []byte, l *path.BufferLayout) ([]*service.BufferRow, error) {
+	rowSize := uint64(0)
+	for _, f := range l.Fields {
+		if end := uint64(f.Offset) + uint64(f.Format.Stride()); end > rowSize {
+			rowSize = end
+		}
+	}
+	stride := uint64(l.Stride)
+	if stride == 0 {
+		stride = rowSize
+	}
+
+	size := uint64(len(data))
+	if rowSize == 0 || l.Offset+rowSize > size {
+		return nil, nil
+	}
+	count := (size-l.Offset-rowSize)/stride + 1
+	if l.Count != 0 && uint64(l.Count) < count {
+		count = uint64(l.Count)
+	}
+
+	rows := make([]*service.BufferRow, count)
+	for i := range rows {
+		rows[i] = &service.BufferRow{
+			Offset: l.Offset + uint64(i)*stride,
+			Fields: make([]*service.BufferFieldValue, len(l.Fields)),
+		}
+	}
+
+	for i, f := range l.Fields {
+		// Gather the field from each row so it can be converted in one go.
+		fieldSize := uint64(f.Format.Stride())
+		packed := make([]byte, 0, fieldSize*count)
+		for _, r := range rows {
+			start := r.Offset + uint64(f.Offset)
+			packed = append(packed, data[start:start+fieldSize]...)
+		}
+
+		decodedFmt := &stream.Format{Components: make([]*stream.Component, len(f.Format.Components))}
+		for j, c := range f.Format.Components {
+			decodedFmt.Components[j] = &stream.Component{
+				DataType: &stream.F64,
+				Sampling: stream.Linear,
+				Channel:  c.Channel,
+			}
+		}
+		decoded, err := stream.Convert(decodedFmt, f.Format, packed)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode field '%v': %v", f.Name, err)
+		}
+
+		r := endian.Reader(bytes.NewReader(decoded), device.LittleEndian)
+		for _, row := range rows {
+			v := &service.BufferFieldValue{Components: make([]float64, len(decodedFmt.Components))}
+			for j := range v.Components {
+				v.Components[j] = r.Float64()
+			}
+			row.Fields[i] = v
+		}
+		if err := r.Error(); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
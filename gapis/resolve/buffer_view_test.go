@@ -0,0 +1,149 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/stream"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+func TestDecodeBufferRows(t *testing.T) {
+	assert := assert.To(t)
+
+	field := func(name string, offset uint32, dt stream.DataType, sampling *stream.Sampling, count int) *path.BufferField {
+		xyzw := []stream.Channel{stream.Channel_X, stream.Channel_Y, stream.Channel_Z, stream.Channel_W}
+		f := &stream.Format{Components: make([]*stream.Component, count)}
+		for i := range f.Components {
+			f.Components[i] = &stream.Component{DataType: &dt, Sampling: sampling, Channel: xyzw[i]}
+		}
+		return &path.BufferField{Name: name, Offset: offset, Format: f}
+	}
+	row := func(offset uint64, fields ...[]float64) *service.BufferRow {
+		r := &service.BufferRow{Offset: offset}
+		for _, f := range fields {
+			r.Fields = append(r.Fields, &service.BufferFieldValue{Components: f})
+		}
+		return r
+	}
+
+	bytes := []byte{0, 10, 20, 30, 40, 50, 60, 70}
+	for _, test := range []struct {
+		name     string
+		data     []byte
+		layout   *path.BufferLayout
+		expected []*service.BufferRow
+	}{
+		{
+			"Packed rows",
+			bytes,
+			&path.BufferLayout{Fields: []*path.BufferField{
+				field("a", 0, stream.U8, stream.Linear, 1),
+				field("b", 1, stream.U8, stream.Linear, 1),
+			}},
+			[]*service.BufferRow{
+				row(0, []float64{0}, []float64{10}),
+				row(2, []float64{20}, []float64{30}),
+				row(4, []float64{40}, []float64{50}),
+				row(6, []float64{60}, []float64{70}),
+			},
+		}, {
+			"Offset and stride",
+			bytes,
+			&path.BufferLayout{Offset: 1, Stride: 3, Fields: []*path.BufferField{
+				field("a", 1, stream.U8, stream.Linear, 1),
+			}},
+			// Rows of 2 bytes start at 1 and 4. A row at 7 would overflow.
+			[]*service.BufferRow{
+				row(1, []float64{20}),
+				row(4, []float64{50}),
+			},
+		}, {
+			"Vector field",
+			bytes,
+			&path.BufferLayout{Stride: 4, Fields: []*path.BufferField{
+				field("v", 1, stream.U8, stream.Linear, 3),
+			}},
+			[]*service.BufferRow{
+				row(0, []float64{10, 20, 30}),
+				row(4, []float64{50, 60, 70}),
+			},
+		}, {
+			"Count clamps rows",
+			bytes,
+			&path.BufferLayout{Count: 3, Fields: []*path.BufferField{
+				field("a", 0, stream.U16, stream.Linear, 1),
+			}},
+			[]*service.BufferRow{
+				row(0, []float64{10 << 8}),
+				row(2, []float64{20 + 30<<8}),
+				row(4, []float64{40 + 50<<8}),
+			},
+		}, {
+			"Count larger than buffer",
+			bytes,
+			&path.BufferLayout{Offset: 4, Count: 100, Fields: []*path.BufferField{
+				field("a", 0, stream.U16, stream.Linear, 1),
+			}},
+			[]*service.BufferRow{
+				row(4, []float64{40 + 50<<8}),
+				row(6, []float64{60 + 70<<8}),
+			},
+		}, {
+			"Normalized",
+			[]byte{0, 255, 0x80, 0x7f},
+			&path.BufferLayout{Fields: []*path.BufferField{
+				field("u", 0, stream.U8, stream.LinearNormalized, 2),
+				field("s", 2, stream.S8, stream.LinearNormalized, 2),
+			}},
+			[]*service.BufferRow{
+				row(0, []float64{0, 1}, []float64{-1, 1}),
+			},
+		}, {
+			"Signed",
+			[]byte{0xfe, 0xff, 0x02, 0x00},
+			&path.BufferLayout{Fields: []*path.BufferField{
+				field("a", 0, stream.S16, stream.Linear, 2),
+			}},
+			[]*service.BufferRow{
+				row(0, []float64{-2, 2}),
+			},
+		}, {
+			"Float",
+			[]byte{0x00, 0x00, 0xc0, 0x3f},
+			&path.BufferLayout{Fields: []*path.BufferField{
+				field("a", 0, stream.F32, stream.Linear, 1),
+			}},
+			[]*service.BufferRow{
+				row(0, []float64{1.5}),
+			},
+		}, {
+			"Row larger than buffer",
+			bytes,
+			&path.BufferLayout{Offset: 6, Fields: []*path.BufferField{
+				field("a", 0, stream.F32, stream.Linear, 1),
+			}},
+			nil,
+		},
+	} {
+		rows, err := decodeBufferRows(test.data, test.layout)
+		if assert.For("%s err", test.name).ThatError(err).Succeeded() {
+			assert.For("%s rows", test.name).That(rows).DeepEquals(test.expected)
+		}
+	}
+}
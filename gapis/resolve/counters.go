@@ -0,0 +1,56 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// Counters resolves and returns the device performance counters sampled
+// while the capture was taken from the path p.
+func Counters(ctx context.Context, p *path.Counters) (*service.Counters, error) {
+	c, err := capture.ResolveFromPath(ctx, p.Capture)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &service.Counters{}
+	if c.Counters == nil {
+		return out, nil
+	}
+	for _, counter := range c.Counters.Counters {
+		samples := make([]*service.CounterSample, len(counter.Samples))
+		for i, s := range counter.Samples {
+			samples[i] = &service.CounterSample{
+				Timestamp: s.Timestamp,
+				Value:     s.Value,
+			}
+			if id := c.CommandAt(s.CaptureOffset); id != api.CmdNoID {
+				samples[i].Command = p.Capture.Command(uint64(id))
+			}
+		}
+		out.List = append(out.List, &service.Counter{
+			Name:    counter.Name,
+			Unit:    counter.Unit,
+			Samples: samples,
+		})
+	}
+	return out, nil
+}
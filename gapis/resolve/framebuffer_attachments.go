@@ -0,0 +1,139 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gapid/core/image"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/replay"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// FramebufferAttachments resolves every attachment of the framebuffer bound
+// after the command of the path p. Attachments that are not bound, or that
+// cannot be read back by the command's API, are omitted.
+func FramebufferAttachments(ctx context.Context, p *path.FramebufferAttachments) (*service.FramebufferAttachments, error) {
+	changes, err := FramebufferChanges(ctx, p.After.Capture)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, err := Cmd(ctx, p.After)
+	if err != nil {
+		return nil, err
+	}
+	// GLES rejects requests for the stencil attachment. Skip those attachments
+	// instead of returning images that fail to resolve.
+	support, _ := cmd.API().(replay.FramebufferAttachmentSupport)
+
+	settings := &service.RenderSettings{MaxWidth: p.MaxWidth, MaxHeight: p.MaxHeight}
+	if settings.MaxWidth == 0 {
+		settings.MaxWidth = 0xFFFFFFFF
+	}
+	if settings.MaxHeight == 0 {
+		settings.MaxHeight = 0xFFFFFFFF
+	}
+	hints := &service.UsageHints{}
+
+	out := &service.FramebufferAttachments{}
+	for _, att := range []api.FramebufferAttachment{
+		api.FramebufferAttachment_Color0,
+		api.FramebufferAttachment_Color1,
+		api.FramebufferAttachment_Color2,
+		api.FramebufferAttachment_Color3,
+		api.FramebufferAttachment_Depth,
+		api.FramebufferAttachment_Stencil,
+	} {
+		if support != nil && !support.SupportsFramebufferAttachment(att) {
+			continue // Attachment can't be read back.
+		}
+		info, err := changes.attachments[att].after(ctx, api.SubCmdIdx(p.After.Indices))
+		if err != nil || info.err != nil {
+			continue // Attachment not bound.
+		}
+		img, err := FramebufferAttachment(ctx, p.Device, p.After, att, settings, hints)
+		if err != nil {
+			return nil, err
+		}
+		a := &service.FramebufferAttachmentImage{
+			Attachment: att,
+			Format:     info.format,
+			Width:      info.width,
+			Height:     info.height,
+			Image:      img,
+		}
+		if att == api.FramebufferAttachment_Depth || att == api.FramebufferAttachment_Stencil {
+			id, err := database.Store(ctx, &FramebufferAttachmentVisualResolvable{
+				Image:      img,
+				Attachment: att,
+			})
+			if err != nil {
+				return nil, err
+			}
+			a.Visual = path.NewImageInfo(id)
+		}
+		out.Attachments = append(out.Attachments, a)
+	}
+	return out, nil
+}
+
+// Resolve implements the database.Resolver interface.
+func (r *FramebufferAttachmentVisualResolvable) Resolve(ctx context.Context) (interface{}, error) {
+	info, err := ImageInfo(ctx, r.Image)
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := database.Resolve(ctx, info.Bytes.ID())
+	if err != nil {
+		return nil, err
+	}
+	data := &image.Data{
+		Bytes:  bytes.([]byte),
+		Width:  info.Width,
+		Height: info.Height,
+		Depth:  info.Depth,
+		Format: info.Format,
+	}
+
+	var visual *image.Data
+	switch r.Attachment {
+	case api.FramebufferAttachment_Depth:
+		visual, err = data.NormalizeDepth()
+	case api.FramebufferAttachment_Stencil:
+		visual, err = data.StencilPalette()
+	default:
+		return nil, fmt.Errorf("Framebuffer attachment %v has no visualization", r.Attachment)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := database.Store(ctx, visual.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return &image.Info{
+		Width:  visual.Width,
+		Height: visual.Height,
+		Depth:  visual.Depth,
+		Format: visual.Format,
+		Bytes:  image.NewID(id),
+	}, nil
+}
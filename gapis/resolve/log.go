@@ -0,0 +1,53 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/capture"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// Log resolves and returns the device log recorded while the capture was
+// taken from the path p.
+func Log(ctx context.Context, p *path.Log) (*service.Log, error) {
+	c, err := capture.ResolveFromPath(ctx, p.Capture)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &service.Log{}
+	if c.Log == nil {
+		return out, nil
+	}
+	out.Messages = make([]*service.LogMessage, len(c.Log.Messages))
+	for i, m := range c.Log.Messages {
+		out.Messages[i] = &service.LogMessage{
+			Timestamp: m.Timestamp,
+			Severity:  service.Severity(m.Severity),
+			Tag:       m.Tag,
+			ProcessId: m.ProcessId,
+			ThreadId:  m.ThreadId,
+			Text:      m.Text,
+		}
+		if id := c.LogCommand(m); id != api.CmdNoID {
+			out.Messages[i].Command = p.Capture.Command(uint64(id))
+		}
+	}
+	return out, nil
+}
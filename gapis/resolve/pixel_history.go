@@ -0,0 +1,81 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/database"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/replay"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// PixelHistory resolves the history of the pixel described by the path p.
+func PixelHistory(ctx context.Context, p *path.PixelHistory) (*service.PixelHistory, error) {
+	obj, err := database.Build(ctx, &PixelHistoryResolvable{p})
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*service.PixelHistory), nil
+}
+
+// Resolve implements the database.Resolver interface.
+func (r *PixelHistoryResolvable) Resolve(ctx context.Context) (interface{}, error) {
+	intent := replay.Intent{
+		Device:  r.Path.Device,
+		Capture: r.Path.Command.Capture,
+	}
+
+	cmd, err := Cmd(ctx, r.Path.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	a := cmd.API()
+	if a == nil {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrFramebufferUnavailable()}
+	}
+
+	query, ok := a.(replay.QueryPixelHistory)
+	if !ok {
+		// Only GLES implements pixel history. Vulkan support needs the
+		// draws of each submitted command buffer to be instrumented with
+		// occlusion queries, and is deferred to a separate change.
+		// TODO: Implement QueryPixelHistory for Vulkan.
+		return nil, log.Errf(ctx, nil, "Pixel history is not supported for the %s API", a.Name())
+	}
+
+	res, err := query.QueryPixelHistory(
+		ctx,
+		intent,
+		replay.GetManager(ctx),
+		r.Path.Command.Indices,
+		api.FramebufferAttachment(r.Path.Attachment),
+		r.Path.X,
+		r.Path.Y,
+		&service.UsageHints{},
+	)
+	if err != nil {
+		if _, ok := err.(*service.ErrDataUnavailable); ok {
+			return nil, err
+		}
+		return nil, log.Err(ctx, err, "Couldn't get pixel history")
+	}
+	return res, nil
+}
@@ -263,6 +263,8 @@ func ResolveInternal(ctx context.Context, p path.Node) (interface{}, error) {
 		return As(ctx, p)
 	case *path.Blob:
 		return Blob(ctx, p)
+	case *path.BufferView:
+		return BufferView(ctx, p)
 	case *path.Capture:
 		return Capture(ctx, p)
 	case *path.Command:
@@ -281,10 +283,14 @@ func ResolveInternal(ctx context.Context, p path.Node) (interface{}, error) {
 		return Context(ctx, p)
 	case *path.Contexts:
 		return Contexts(ctx, p)
+	case *path.Counters:
+		return Counters(ctx, p)
 	case *path.Device:
 		return Device(ctx, p)
 	case *path.Events:
 		return Events(ctx, p)
+	case *path.FramebufferAttachments:
+		return FramebufferAttachments(ctx, p)
 	case *path.FramebufferObservation:
 		return FramebufferObservation(ctx, p)
 	case *path.Field:
@@ -293,6 +299,8 @@ func ResolveInternal(ctx context.Context, p path.Node) (interface{}, error) {
 		return GlobalState(ctx, p)
 	case *path.ImageInfo:
 		return ImageInfo(ctx, p)
+	case *path.Log:
+		return Log(ctx, p)
 	case *path.MapIndex:
 		return MapIndex(ctx, p)
 	case *path.Memory:
@@ -301,6 +309,8 @@ func ResolveInternal(ctx context.Context, p path.Node) (interface{}, error) {
 		return Mesh(ctx, p)
 	case *path.Parameter:
 		return Parameter(ctx, p)
+	case *path.PixelHistory:
+		return PixelHistory(ctx, p)
 	case *path.Report:
 		return Report(ctx, p)
 	case *path.ResourceData:
@@ -309,6 +319,8 @@ func ResolveInternal(ctx context.Context, p path.Node) (interface{}, error) {
 		return Resources(ctx, p.Capture)
 	case *path.Result:
 		return Result(ctx, p)
+	case *path.ShaderInputs:
+		return ShaderInputs(ctx, p)
 	case *path.Slice:
 		return Slice(ctx, p)
 	case *path.State:
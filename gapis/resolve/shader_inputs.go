@@ -0,0 +1,46 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/gapis/api"
+	"github.com/google/gapid/gapis/messages"
+	"github.com/google/gapid/gapis/service"
+	"github.com/google/gapid/gapis/service/path"
+)
+
+// ShaderInputs resolves and returns the inputs bound to the shaders of the
+// draw call from the path p.
+func ShaderInputs(ctx context.Context, p *path.ShaderInputs) (*api.ShaderInputs, error) {
+	cmd, err := Cmd(ctx, p.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	a := cmd.API()
+	if a == nil {
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNotADrawCall()}
+	}
+
+	sp, ok := a.(api.ShaderInputsProvider)
+	if !ok {
+		log.E(ctx, "API %s does not implement ShaderInputsProvider", a.Name())
+		return nil, &service.ErrDataUnavailable{Reason: messages.ErrNotADrawCall()}
+	}
+	return sp.ShaderInputs(ctx, p)
+}
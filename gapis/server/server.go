@@ -179,7 +179,14 @@ func (s *server) LoadCapture(ctx context.Context, path string) (*path.Capture, e
 	if err != nil {
 		return nil, err
 	}
-	p, err := capture.Import(ctx, name, in)
+	r := &capture.Record{Name: name, Data: in}
+	if r.Log, err = capture.ReadLog(ctx, path); err != nil {
+		log.W(ctx, "Ignoring capture log: %v", err)
+	}
+	if r.Counters, err = capture.ReadCounters(ctx, path); err != nil {
+		log.W(ctx, "Ignoring capture counters: %v", err)
+	}
+	p, err := capture.ImportRecord(ctx, r)
 	if err != nil {
 		return nil, err
 	}
@@ -60,6 +60,7 @@ func (n *API) Path() *Any                       { return &Any{&Any_Api{n}} }
 func (n *ArrayIndex) Path() *Any                { return &Any{&Any_ArrayIndex{n}} }
 func (n *As) Path() *Any                        { return &Any{&Any_As{n}} }
 func (n *Blob) Path() *Any                      { return &Any{&Any_Blob{n}} }
+func (n *BufferView) Path() *Any                { return &Any{&Any_BufferView{n}} }
 func (n *Capture) Path() *Any                   { return &Any{&Any_Capture{n}} }
 func (n *ConstantSet) Path() *Any               { return &Any{&Any_ConstantSet{n}} }
 func (n *Command) Path() *Any                   { return &Any{&Any_Command{n}} }
@@ -69,20 +70,25 @@ func (n *CommandTreeNode) Path() *Any           { return &Any{&Any_CommandTreeNo
 func (n *CommandTreeNodeForCommand) Path() *Any { return &Any{&Any_CommandTreeNodeForCommand{n}} }
 func (n *Context) Path() *Any                   { return &Any{&Any_Context{n}} }
 func (n *Contexts) Path() *Any                  { return &Any{&Any_Contexts{n}} }
+func (n *Counters) Path() *Any                  { return &Any{&Any_Counters{n}} }
 func (n *Device) Path() *Any                    { return &Any{&Any_Device{n}} }
 func (n *Events) Path() *Any                    { return &Any{&Any_Events{n}} }
 func (n *FramebufferObservation) Path() *Any    { return &Any{&Any_Fbo{n}} }
+func (n *FramebufferAttachments) Path() *Any    { return &Any{&Any_FramebufferAttachments{n}} }
 func (n *Field) Path() *Any                     { return &Any{&Any_Field{n}} }
 func (n *GlobalState) Path() *Any               { return &Any{&Any_GlobalState{n}} }
 func (n *ImageInfo) Path() *Any                 { return &Any{&Any_ImageInfo{n}} }
+func (n *Log) Path() *Any                       { return &Any{&Any_Log{n}} }
 func (n *MapIndex) Path() *Any                  { return &Any{&Any_MapIndex{n}} }
 func (n *Memory) Path() *Any                    { return &Any{&Any_Memory{n}} }
 func (n *Mesh) Path() *Any                      { return &Any{&Any_Mesh{n}} }
 func (n *Parameter) Path() *Any                 { return &Any{&Any_Parameter{n}} }
+func (n *PixelHistory) Path() *Any              { return &Any{&Any_PixelHistory{n}} }
 func (n *Report) Path() *Any                    { return &Any{&Any_Report{n}} }
 func (n *ResourceData) Path() *Any              { return &Any{&Any_ResourceData{n}} }
 func (n *Resources) Path() *Any                 { return &Any{&Any_Resources{n}} }
 func (n *Result) Path() *Any                    { return &Any{&Any_Result{n}} }
+func (n *ShaderInputs) Path() *Any              { return &Any{&Any_ShaderInputs{n}} }
 func (n *Slice) Path() *Any                     { return &Any{&Any_Slice{n}} }
 func (n *State) Path() *Any                     { return &Any{&Any_State{n}} }
 func (n *StateTree) Path() *Any                 { return &Any{&Any_StateTree{n}} }
@@ -94,6 +100,7 @@ func (n API) Parent() Node                       { return nil }
 func (n ArrayIndex) Parent() Node                { return oneOfNode(n.Array) }
 func (n As) Parent() Node                        { return oneOfNode(n.From) }
 func (n Blob) Parent() Node                      { return nil }
+func (n BufferView) Parent() Node                { return n.After }
 func (n Capture) Parent() Node                   { return nil }
 func (n ConstantSet) Parent() Node               { return n.Api }
 func (n Command) Parent() Node                   { return n.Capture }
@@ -103,20 +110,25 @@ func (n CommandTreeNode) Parent() Node           { return nil }
 func (n CommandTreeNodeForCommand) Parent() Node { return n.Command }
 func (n Context) Parent() Node                   { return n.Capture }
 func (n Contexts) Parent() Node                  { return n.Capture }
+func (n Counters) Parent() Node                  { return n.Capture }
 func (n Device) Parent() Node                    { return nil }
 func (n Events) Parent() Node                    { return n.Capture }
 func (n FramebufferObservation) Parent() Node    { return n.Command }
+func (n FramebufferAttachments) Parent() Node    { return n.After }
 func (n Field) Parent() Node                     { return oneOfNode(n.Struct) }
 func (n GlobalState) Parent() Node               { return n.After }
 func (n ImageInfo) Parent() Node                 { return nil }
+func (n Log) Parent() Node                       { return n.Capture }
 func (n MapIndex) Parent() Node                  { return oneOfNode(n.Map) }
 func (n Memory) Parent() Node                    { return n.After }
 func (n Mesh) Parent() Node                      { return oneOfNode(n.Object) }
 func (n Parameter) Parent() Node                 { return n.Command }
+func (n PixelHistory) Parent() Node              { return n.Command }
 func (n Report) Parent() Node                    { return n.Capture }
 func (n ResourceData) Parent() Node              { return n.After }
 func (n Resources) Parent() Node                 { return n.Capture }
 func (n Result) Parent() Node                    { return n.Command }
+func (n ShaderInputs) Parent() Node              { return n.Command }
 func (n Slice) Parent() Node                     { return oneOfNode(n.Array) }
 func (n State) Parent() Node                     { return n.After }
 func (n StateTree) Parent() Node                 { return n.State }
@@ -126,6 +138,7 @@ func (n Thumbnail) Parent() Node                 { return oneOfNode(n.Object) }
 
 func (n *API) SetParent(p Node)                       {}
 func (n *Blob) SetParent(p Node)                      {}
+func (n *BufferView) SetParent(p Node)                { n.After, _ = p.(*Command) }
 func (n *Capture) SetParent(p Node)                   {}
 func (n *ConstantSet) SetParent(p Node)               { n.Api, _ = p.(*API) }
 func (n *Command) SetParent(p Node)                   { n.Capture, _ = p.(*Capture) }
@@ -135,17 +148,22 @@ func (n *CommandTreeNode) SetParent(p Node)           {}
 func (n *CommandTreeNodeForCommand) SetParent(p Node) { n.Command, _ = p.(*Command) }
 func (n *Context) SetParent(p Node)                   { n.Capture, _ = p.(*Capture) }
 func (n *Contexts) SetParent(p Node)                  { n.Capture, _ = p.(*Capture) }
+func (n *Counters) SetParent(p Node)                  { n.Capture, _ = p.(*Capture) }
 func (n *Device) SetParent(p Node)                    {}
 func (n *Events) SetParent(p Node)                    { n.Capture, _ = p.(*Capture) }
 func (n *FramebufferObservation) SetParent(p Node)    { n.Command, _ = p.(*Command) }
+func (n *FramebufferAttachments) SetParent(p Node)    { n.After, _ = p.(*Command) }
 func (n *GlobalState) SetParent(p Node)               { n.After, _ = p.(*Command) }
 func (n *ImageInfo) SetParent(p Node)                 {}
+func (n *Log) SetParent(p Node)                       { n.Capture, _ = p.(*Capture) }
 func (n *Memory) SetParent(p Node)                    { n.After, _ = p.(*Command) }
 func (n *Parameter) SetParent(p Node)                 { n.Command, _ = p.(*Command) }
+func (n *PixelHistory) SetParent(p Node)              { n.Command, _ = p.(*Command) }
 func (n *Report) SetParent(p Node)                    { n.Capture, _ = p.(*Capture) }
 func (n *ResourceData) SetParent(p Node)              { n.After, _ = p.(*Command) }
 func (n *Resources) SetParent(p Node)                 { n.Capture, _ = p.(*Capture) }
 func (n *Result) SetParent(p Node)                    { n.Command, _ = p.(*Command) }
+func (n *ShaderInputs) SetParent(p Node)              { n.Command, _ = p.(*Command) }
 func (n *State) SetParent(p Node)                     { n.After, _ = p.(*Command) }
 func (n *StateTree) SetParent(p Node)                 { n.State, _ = p.(*State) }
 func (n *StateTreeNode) SetParent(p Node)             {}
@@ -167,6 +185,16 @@ func (n As) Format(f fmt.State, c rune) {
 // Format implements fmt.Formatter to print the version.
 func (n Blob) Format(f fmt.State, c rune) { fmt.Fprintf(f, "blob<%x>", n.Id) }
 
+// Format implements fmt.Formatter to print the version.
+func (n BufferView) Format(f fmt.State, c rune) {
+	switch {
+	case n.Binding.GetKind() != BufferBinding_None:
+		fmt.Fprintf(f, "%v.buffer-view<%v %d>", n.Parent(), n.Binding.Kind, n.Binding.Index)
+	default:
+		fmt.Fprintf(f, "%v.buffer-view<%x>", n.Parent(), n.Buffer)
+	}
+}
+
 // Format implements fmt.Formatter to print the version.
 func (n Capture) Format(f fmt.State, c rune) { fmt.Fprintf(f, "capture<%x>", n.Id) }
 
@@ -204,6 +232,9 @@ func (n Context) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.[%x]", n.Paren
 // Format implements fmt.Formatter to print the version.
 func (n Contexts) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.contexts", n.Parent()) }
 
+// Format implements fmt.Formatter to print the version.
+func (n Counters) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.counters", n.Parent()) }
+
 // Format implements fmt.Formatter to print the version.
 func (n Device) Format(f fmt.State, c rune) { fmt.Fprintf(f, "device<%x>", n.Id) }
 
@@ -213,12 +244,20 @@ func (n Events) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.events", n.Pare
 // Format implements fmt.Formatter to print the version.
 func (n Field) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.%v", n.Parent(), n.Name) }
 
+// Format implements fmt.Formatter to print the version.
+func (n FramebufferAttachments) Format(f fmt.State, c rune) {
+	fmt.Fprintf(f, "%v.framebuffer-attachments", n.Parent())
+}
+
 // Format implements fmt.Formatter to print the version.
 func (n GlobalState) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.global-state", n.Parent()) }
 
 // Format implements fmt.Formatter to print the version.
 func (n ImageInfo) Format(f fmt.State, c rune) { fmt.Fprintf(f, "image-info<%x>", n.Id) }
 
+// Format implements fmt.Formatter to print the version.
+func (n Log) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.log", n.Parent()) }
+
 // Format implements fmt.Formatter to print the version.
 func (n MapIndex) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v[%x]", n.Parent(), n.Key) }
 
@@ -231,6 +270,11 @@ func (n Mesh) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.mesh", n.Parent()
 // Format implements fmt.Formatter to print the version.
 func (n Parameter) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.%v", n.Parent(), n.Name) }
 
+// Format implements fmt.Formatter to print the version.
+func (n PixelHistory) Format(f fmt.State, c rune) {
+	fmt.Fprintf(f, "%v.pixel-history<%v,%v>", n.Parent(), n.X, n.Y)
+}
+
 // Format implements fmt.Formatter to print the version.
 func (n Report) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.report", n.Parent()) }
 
@@ -245,6 +289,9 @@ func (n Resources) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.resources",
 // Format implements fmt.Formatter to print the version.
 func (n Result) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.result", n.Parent()) }
 
+// Format implements fmt.Formatter to print the version.
+func (n ShaderInputs) Format(f fmt.State, c rune) { fmt.Fprintf(f, "%v.shader-inputs", n.Parent()) }
+
 // Format implements fmt.Formatter to print the version.
 func (n Slice) Format(f fmt.State, c rune) {
 	fmt.Fprintf(f, "%v[%v:%v]", n.Parent(), n.Start, n.End)
@@ -511,6 +558,16 @@ func (n *Capture) Resources() *Resources {
 	return &Resources{Capture: n}
 }
 
+// Counters returns the path node to the capture's device performance counters.
+func (n *Capture) Counters() *Counters {
+	return &Counters{Capture: n}
+}
+
+// Log returns the path node to the capture's device log.
+func (n *Capture) Log() *Log {
+	return &Log{Capture: n}
+}
+
 // Report returns the path node to the capture's report.
 func (n *Capture) Report(d *Device, f *CommandFilter) *Report {
 	return &Report{Capture: n, Device: d, Filter: f}
@@ -592,6 +649,25 @@ func (n *Command) ResourceAfter(id *ID) *ResourceData {
 	}
 }
 
+// BufferView returns the path node to the rows of the buffer with the given
+// API handle after this command, decoded with the given layout.
+func (n *Command) BufferView(buffer uint64, layout *BufferLayout) *BufferView {
+	return &BufferView{After: n, Buffer: buffer, Layout: layout}
+}
+
+// BoundBufferView returns the path node to the rows of the buffer bound to
+// the given binding point after this command. If layout is nil, then the
+// layout is inferred from the binding.
+func (n *Command) BoundBufferView(kind BufferBinding_Kind, index uint32, layout *BufferLayout) *BufferView {
+	return &BufferView{After: n, Binding: &BufferBinding{Kind: kind, Index: index}, Layout: layout}
+}
+
+// FramebufferAttachments returns the path node to the attachments of the
+// framebuffer bound after this command.
+func (n *Command) FramebufferAttachments(d *Device) *FramebufferAttachments {
+	return &FramebufferAttachments{After: n, Device: d}
+}
+
 // FramebufferObservation returns the path node to framebuffer observation
 // after this command.
 func (n *Command) FramebufferObservation() *FramebufferObservation {
@@ -639,11 +715,23 @@ func (n *Command) Parameter(name string) *Parameter {
 	return &Parameter{Name: name, Command: n}
 }
 
+// PixelHistory returns the path node to the history of the pixel at (x, y)
+// of the given framebuffer attachment, up to and including this command.
+func (n *Command) PixelHistory(d *Device, attachment, x, y uint32) *PixelHistory {
+	return &PixelHistory{Command: n, Device: d, Attachment: attachment, X: x, Y: y}
+}
+
 // Result returns the path node to the command's result.
 func (n *Command) Result() *Result {
 	return &Result{Command: n}
 }
 
+// ShaderInputs returns the path node to the inputs bound to the shaders of
+// this draw call.
+func (n *Command) ShaderInputs() *ShaderInputs {
+	return &ShaderInputs{Command: n}
+}
+
 // Tree returns the path node to the state tree for this state.
 func (n *State) Tree() *StateTree {
 	return &StateTree{State: n}
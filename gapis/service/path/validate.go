@@ -112,6 +112,40 @@ func (n *Blob) Validate() error {
 	return checkIsValid(n, n.Id, "id")
 }
 
+// Validate checks the path is valid.
+func (n *BufferView) Validate() error {
+	if n.Binding.GetKind() == BufferBinding_None {
+		return anyErr(
+			checkNotNilAndValidate(n, n.After, "after"),
+			checkNotNilAndValidate(n, n.Layout, "layout"),
+			checkBufferLayout(n, n.Layout),
+		)
+	}
+	return anyErr(
+		checkNotNilAndValidate(n, n.After, "after"),
+		checkBufferLayout(n, n.Layout),
+	)
+}
+
+// checkBufferLayout checks that each field of the optional layout l has a
+// format with components of known data types, so that it can be decoded.
+func checkBufferLayout(n Node, l *BufferLayout) error {
+	if l == nil {
+		return nil
+	}
+	for i, f := range l.Fields {
+		if f == nil || f.Format == nil {
+			return fmt.Errorf("Invalid path '%v': layout.fields[%d].format must not be nil", n, i)
+		}
+		for j, c := range f.Format.Components {
+			if t := c.GetDataType(); !t.IsInteger() && !t.IsFloat() && !t.IsFixed() {
+				return fmt.Errorf("Invalid path '%v': layout.fields[%d].format.components[%d] has an unknown data type", n, i, j)
+			}
+		}
+	}
+	return nil
+}
+
 // Validate checks the path is valid.
 func (n *Capture) Validate() error {
 	return checkIsValid(n, n.Id, "id")
@@ -172,6 +206,11 @@ func (n *Contexts) Validate() error {
 	return checkNotNilAndValidate(n, n.Capture, "capture")
 }
 
+// Validate checks the path is valid.
+func (n *Counters) Validate() error {
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
 // Validate checks the path is valid.
 func (n *Device) Validate() error {
 	return checkIsValid(n, n.Id, "id")
@@ -182,6 +221,11 @@ func (n *Events) Validate() error {
 	return checkNotNilAndValidate(n, protoutil.OneOf(n.Capture), "capture")
 }
 
+// Validate checks the path is valid.
+func (n *FramebufferAttachments) Validate() error {
+	return checkNotNilAndValidate(n, n.After, "after")
+}
+
 // Validate checks the path is valid.
 func (n *FramebufferObservation) Validate() error {
 	return checkNotNilAndValidate(n, protoutil.OneOf(n.Command), "command")
@@ -205,6 +249,11 @@ func (n *ImageInfo) Validate() error {
 	return checkNotNilAndValidate(n, n.Id, "id")
 }
 
+// Validate checks the path is valid.
+func (n *Log) Validate() error {
+	return checkNotNilAndValidate(n, n.Capture, "capture")
+}
+
 // Validate checks the path is valid.
 func (n *MapIndex) Validate() error {
 	return anyErr(
@@ -231,6 +280,14 @@ func (n *Parameter) Validate() error {
 	)
 }
 
+// Validate checks the path is valid.
+func (n *PixelHistory) Validate() error {
+	return anyErr(
+		checkNotNilAndValidate(n, n.Command, "command"),
+		checkNotNilAndValidate(n, n.Device, "device"),
+	)
+}
+
 // Validate checks the path is valid.
 func (n *Report) Validate() error {
 	return checkNotNilAndValidate(n, n.Capture, "capture")
@@ -254,6 +311,11 @@ func (n *Result) Validate() error {
 	return checkNotNilAndValidate(n, n.Command, "command")
 }
 
+// Validate checks the path is valid.
+func (n *ShaderInputs) Validate() error {
+	return checkNotNilAndValidate(n, n.Command, "command")
+}
+
 // Validate checks the path is valid.
 func (n *Slice) Validate() error {
 	return checkNotNilAndValidate(n, protoutil.OneOf(n.Array), "array")
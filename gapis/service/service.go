@@ -161,6 +161,8 @@ func NewValue(v interface{}) *Value {
 	switch v := v.(type) {
 	case nil:
 		return &Value{}
+	case *BufferView:
+		return &Value{&Value_BufferView{v}}
 	case *Capture:
 		return &Value{&Value_Capture{v}}
 	case *Context:
@@ -175,12 +177,20 @@ func NewValue(v interface{}) *Value {
 		return &Value{&Value_CommandTreeNode{v}}
 	case *ConstantSet:
 		return &Value{&Value_ConstantSet{v}}
+	case *Counters:
+		return &Value{&Value_Counters{v}}
 	case *Event:
 		return &Value{&Value_Event{v}}
 	case *Events:
 		return &Value{&Value_Events{v}}
+	case *FramebufferAttachments:
+		return &Value{&Value_FramebufferAttachments{v}}
+	case *Log:
+		return &Value{&Value_Log{v}}
 	case *Memory:
 		return &Value{&Value_Memory{v}}
+	case *PixelHistory:
+		return &Value{&Value_PixelHistory{v}}
 	case *path.Any:
 		return &Value{&Value_Path{v}}
 	case path.Node:
@@ -197,6 +207,8 @@ func NewValue(v interface{}) *Value {
 		return &Value{&Value_Command{v}}
 	case *api.Mesh:
 		return &Value{&Value_Mesh{v}}
+	case *api.ShaderInputs:
+		return &Value{&Value_ShaderInputs{v}}
 	case *api.ResourceData:
 		return &Value{&Value_ResourceData{v}}
 	case *image.Info:
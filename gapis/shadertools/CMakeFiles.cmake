@@ -22,4 +22,5 @@ set(files
 )
 set(dirs
     cc
+    spirv
 )
@@ -0,0 +1,773 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package spirv provides reflection of SPIR-V shader modules.
+//
+// The package is pure Go so that it can be used and tested without the
+// native shader tools.
+package spirv
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Reflection describes the interface of a SPIR-V module.
+type Reflection struct {
+	EntryPoints   []EntryPoint   // The entry points of the module.
+	Descriptors   []Descriptor   // The descriptors, sorted by set and binding.
+	PushConstants *Variable      // The push constant block, or nil if unused.
+	Inputs        []Variable     // The input variables, sorted by location.
+	Outputs       []Variable     // The output variables, sorted by location.
+	SpecConstants []SpecConstant // The specialization constants, sorted by id.
+}
+
+// EntryPoint is an entry point of a SPIR-V module.
+type EntryPoint struct {
+	Name  string
+	Stage ExecutionModel
+}
+
+// ExecutionModel is the enumerator of SPIR-V execution models.
+type ExecutionModel uint32
+
+const (
+	ExecutionModelVertex         ExecutionModel = 0
+	ExecutionModelTessControl    ExecutionModel = 1
+	ExecutionModelTessEvaluation ExecutionModel = 2
+	ExecutionModelGeometry       ExecutionModel = 3
+	ExecutionModelFragment       ExecutionModel = 4
+	ExecutionModelCompute        ExecutionModel = 5
+)
+
+func (m ExecutionModel) String() string {
+	switch m {
+	case ExecutionModelVertex:
+		return "Vertex"
+	case ExecutionModelTessControl:
+		return "TessControl"
+	case ExecutionModelTessEvaluation:
+		return "TessEvaluation"
+	case ExecutionModelGeometry:
+		return "Geometry"
+	case ExecutionModelFragment:
+		return "Fragment"
+	case ExecutionModelCompute:
+		return "Compute"
+	default:
+		return fmt.Sprintf("ExecutionModel(%d)", uint32(m))
+	}
+}
+
+// DescriptorKind is the enumerator of descriptor types.
+type DescriptorKind int
+
+const (
+	DescriptorUniformBuffer DescriptorKind = iota
+	DescriptorStorageBuffer
+	DescriptorSampler
+	DescriptorSampledImage
+	DescriptorCombinedImageSampler
+	DescriptorStorageImage
+	DescriptorUniformTexelBuffer
+	DescriptorStorageTexelBuffer
+	DescriptorInputAttachment
+)
+
+func (k DescriptorKind) String() string {
+	switch k {
+	case DescriptorUniformBuffer:
+		return "UniformBuffer"
+	case DescriptorStorageBuffer:
+		return "StorageBuffer"
+	case DescriptorSampler:
+		return "Sampler"
+	case DescriptorSampledImage:
+		return "SampledImage"
+	case DescriptorCombinedImageSampler:
+		return "CombinedImageSampler"
+	case DescriptorStorageImage:
+		return "StorageImage"
+	case DescriptorUniformTexelBuffer:
+		return "UniformTexelBuffer"
+	case DescriptorStorageTexelBuffer:
+		return "StorageTexelBuffer"
+	case DescriptorInputAttachment:
+		return "InputAttachment"
+	default:
+		return fmt.Sprintf("DescriptorKind(%d)", int(k))
+	}
+}
+
+// Descriptor is a resource bound to the module through a descriptor set.
+type Descriptor struct {
+	Name    string
+	Set     uint32
+	Binding uint32
+	Kind    DescriptorKind
+	Count   uint32 // Number of array elements. 0 for runtime arrays.
+	Type    *Type  // The type of a single element.
+}
+
+// Variable is an interface variable of the module.
+type Variable struct {
+	Name     string
+	Location uint32
+	Type     *Type
+}
+
+// SpecConstant is a specialization constant of the module.
+type SpecConstant struct {
+	Name  string
+	ID    uint32
+	Type  *Type
+	Value interface{} // The default value, as a bool, int32, uint32, int64, uint64, float32 or float64.
+}
+
+// TypeKind is the enumerator of SPIR-V type kinds.
+type TypeKind int
+
+const (
+	TypeUnknown TypeKind = iota
+	TypeBool
+	TypeInt
+	TypeFloat
+	TypeVector
+	TypeMatrix
+	TypeArray
+	TypeStruct
+	TypeImage
+	TypeSampler
+	TypeSampledImage
+)
+
+// Type is a SPIR-V data type.
+type Type struct {
+	Kind        TypeKind
+	Name        string   // The name of a struct type.
+	Width       uint32   // The bit width of an int or float type.
+	Signed      bool     // Whether an int type is signed.
+	Elem        *Type    // The component, column, element or image type.
+	Count       uint32   // The components, columns or array elements. 0 for runtime arrays.
+	ArrayStride uint32   // The bytes between array elements.
+	Members     []Member // The members of a struct type.
+	Dim         uint32   // The SPIR-V Dim of an image type.
+	Arrayed     bool     // Whether an image type is arrayed.
+	Multisample bool     // Whether an image type is multisampled.
+	Storage     bool     // Whether an image type is used without a sampler.
+	Depth       bool     // Whether an image type is a depth image.
+}
+
+// Member is a member of a struct type.
+type Member struct {
+	Name         string
+	Offset       uint32
+	Type         *Type
+	MatrixStride uint32 // The bytes between the columns (or rows) of a matrix.
+	RowMajor     bool
+}
+
+// Size returns the size of the type in bytes when laid out in a buffer, or 0
+// if unknown. matrixStride and rowMajor are the layout of matrices in the type.
+func (t *Type) Size(matrixStride uint32, rowMajor bool) uint32 {
+	switch t.Kind {
+	case TypeBool:
+		return 4
+	case TypeInt, TypeFloat:
+		return t.Width / 8
+	case TypeVector:
+		return t.Count * t.Elem.Size(0, false)
+	case TypeMatrix:
+		if rowMajor {
+			return t.Elem.Count * matrixStride
+		}
+		return t.Count * matrixStride
+	case TypeArray:
+		return t.Count * t.ArrayStride
+	case TypeStruct:
+		size := uint32(0)
+		for _, m := range t.Members {
+			if end := m.Offset + m.Type.Size(m.MatrixStride, m.RowMajor); end > size {
+				size = end
+			}
+		}
+		return size
+	default:
+		return 0
+	}
+}
+
+// String returns the GLSL name of the type.
+func (t *Type) String() string {
+	switch t.Kind {
+	case TypeBool:
+		return "bool"
+	case TypeInt:
+		if t.Signed {
+			return "int"
+		}
+		return "uint"
+	case TypeFloat:
+		if t.Width == 64 {
+			return "double"
+		}
+		return "float"
+	case TypeVector:
+		return fmt.Sprintf("%vvec%d", t.Elem.prefix(), t.Count)
+	case TypeMatrix:
+		if t.Count == t.Elem.Count {
+			return fmt.Sprintf("%vmat%d", t.Elem.Elem.prefix(), t.Count)
+		}
+		return fmt.Sprintf("%vmat%dx%d", t.Elem.Elem.prefix(), t.Count, t.Elem.Count)
+	case TypeArray:
+		if t.Count == 0 {
+			return fmt.Sprintf("%v[]", t.Elem)
+		}
+		return fmt.Sprintf("%v[%d]", t.Elem, t.Count)
+	case TypeStruct:
+		if t.Name == "" {
+			return "struct"
+		}
+		return t.Name
+	case TypeImage:
+		kind := "texture"
+		switch {
+		case t.Dim == spvDimSubpassData:
+			return t.Elem.prefix() + "subpassInput"
+		case t.Storage:
+			kind = "image"
+		}
+		return t.Elem.prefix() + kind + t.dimName()
+	case TypeSampler:
+		return "sampler"
+	case TypeSampledImage:
+		s := t.Elem.Elem.prefix() + "sampler" + t.Elem.dimName()
+		if t.Elem.Depth {
+			s += "Shadow"
+		}
+		return s
+	default:
+		return "unknown"
+	}
+}
+
+// prefix returns the GLSL type name prefix for vectors, matrices and images
+// with components of the scalar type t.
+func (t *Type) prefix() string {
+	switch {
+	case t == nil:
+		return ""
+	case t.Kind == TypeBool:
+		return "b"
+	case t.Kind == TypeInt && t.Signed:
+		return "i"
+	case t.Kind == TypeInt:
+		return "u"
+	case t.Kind == TypeFloat && t.Width == 64:
+		return "d"
+	default:
+		return ""
+	}
+}
+
+// dimName returns the GLSL suffix for the dimensions of the image type t.
+func (t *Type) dimName() string {
+	s := ""
+	switch t.Dim {
+	case spvDim1D:
+		s = "1D"
+	case spvDim2D:
+		s = "2D"
+	case spvDim3D:
+		s = "3D"
+	case spvDimCube:
+		s = "Cube"
+	case spvDimRect:
+		s = "2DRect"
+	case spvDimBuffer:
+		s = "Buffer"
+	}
+	if t.Multisample {
+		s += "MS"
+	}
+	if t.Arrayed {
+		s += "Array"
+	}
+	return s
+}
+
+// SPIR-V enumerants used by the reflection.
+const (
+	spvMagic = 0x07230203
+
+	spvOpName                  = 5
+	spvOpMemberName            = 6
+	spvOpEntryPoint            = 15
+	spvOpTypeBool              = 20
+	spvOpTypeInt               = 21
+	spvOpTypeFloat             = 22
+	spvOpTypeVector            = 23
+	spvOpTypeMatrix            = 24
+	spvOpTypeImage             = 25
+	spvOpTypeSampler           = 26
+	spvOpTypeSampledImage      = 27
+	spvOpTypeArray             = 28
+	spvOpTypeRuntimeArray      = 29
+	spvOpTypeStruct            = 30
+	spvOpTypePointer           = 32
+	spvOpConstantTrue          = 41
+	spvOpConstantFalse         = 42
+	spvOpConstant              = 43
+	spvOpSpecConstantTrue      = 48
+	spvOpSpecConstantFalse     = 49
+	spvOpSpecConstant          = 50
+	spvOpVariable              = 59
+	spvOpDecorate              = 71
+	spvOpMemberDecorate        = 72
+	spvDecorationSpecID        = 1
+	spvDecorationBlock         = 2
+	spvDecorationBufferBlock   = 3
+	spvDecorationRowMajor      = 4
+	spvDecorationArrayStride   = 6
+	spvDecorationMatrixStride  = 7
+	spvDecorationBuiltIn       = 11
+	spvDecorationLocation      = 30
+	spvDecorationBinding       = 33
+	spvDecorationDescriptorSet = 34
+	spvDecorationOffset        = 35
+	spvStorageUniformConstant  = 0
+	spvStorageInput            = 1
+	spvStorageUniform          = 2
+	spvStorageOutput           = 3
+	spvStorageFunction         = 7
+	spvStoragePushConstant     = 9
+	spvStorageStorageBuffer    = 12
+	spvDim1D                   = 0
+	spvDim2D                   = 1
+	spvDim3D                   = 2
+	spvDimCube                 = 3
+	spvDimRect                 = 4
+	spvDimBuffer               = 5
+	spvDimSubpassData          = 6
+)
+
+// memberID identifies a member of a struct type.
+type memberID struct{ id, member uint32 }
+
+// reflector holds the instructions of a SPIR-V module needed to reflect it.
+type reflector struct {
+	names             map[uint32]string
+	memberNames       map[memberID]string
+	decorations       map[uint32]map[uint32][]uint32
+	memberDecorations map[memberID]map[uint32][]uint32
+	types             map[uint32][]uint32 // Type id to the operands of its instruction.
+	typeOps           map[uint32]uint32   // Type id to the opcode of its instruction.
+	constants         map[uint32][]uint32 // Constant id to its result type and value words.
+	specConstants     []uint32            // Ids of the specialization constants.
+	variables         []uint32            // Ids of the global variables.
+	built             map[uint32]*Type
+}
+
+// Reflect parses the given SPIR-V binary words and returns the
+// interface of the module.
+func Reflect(words []uint32) (*Reflection, error) {
+	if len(words) < 5 {
+		return nil, fmt.Errorf("SPIR-V binary is too short (%d words)", len(words))
+	}
+	switch words[0] {
+	case spvMagic:
+	case swapWord(spvMagic):
+		swapped := make([]uint32, len(words))
+		for i, w := range words {
+			swapped[i] = swapWord(w)
+		}
+		words = swapped
+	default:
+		return nil, fmt.Errorf("Invalid SPIR-V magic number 0x%x", words[0])
+	}
+
+	r := &reflector{
+		names:             map[uint32]string{},
+		memberNames:       map[memberID]string{},
+		decorations:       map[uint32]map[uint32][]uint32{},
+		memberDecorations: map[memberID]map[uint32][]uint32{},
+		types:             map[uint32][]uint32{},
+		typeOps:           map[uint32]uint32{},
+		constants:         map[uint32][]uint32{},
+		built:             map[uint32]*Type{},
+	}
+	out := &Reflection{}
+	variableTypes := map[uint32]uint32{}
+	variableStorage := map[uint32]uint32{}
+
+	for i := 5; i < len(words); {
+		count, op := int(words[i]>>16), words[i]&0xffff
+		if count == 0 || i+count > len(words) {
+			return nil, fmt.Errorf("Invalid SPIR-V instruction at word %d", i)
+		}
+		args := words[i+1 : i+count]
+		i += count
+
+		if len(args) < minOperands(op) {
+			return nil, fmt.Errorf("SPIR-V instruction %d has too few operands", op)
+		}
+		switch op {
+		case spvOpName:
+			r.names[args[0]], _ = spirvString(args[1:])
+		case spvOpMemberName:
+			r.memberNames[memberID{args[0], args[1]}], _ = spirvString(args[2:])
+		case spvOpEntryPoint:
+			name, _ := spirvString(args[2:])
+			if stage := ExecutionModel(args[0]); stage <= ExecutionModelCompute {
+				out.EntryPoints = append(out.EntryPoints, EntryPoint{Name: name, Stage: stage})
+			}
+		case spvOpDecorate:
+			d, ok := r.decorations[args[0]]
+			if !ok {
+				d = map[uint32][]uint32{}
+				r.decorations[args[0]] = d
+			}
+			d[args[1]] = args[2:]
+		case spvOpMemberDecorate:
+			id := memberID{args[0], args[1]}
+			d, ok := r.memberDecorations[id]
+			if !ok {
+				d = map[uint32][]uint32{}
+				r.memberDecorations[id] = d
+			}
+			d[args[2]] = args[3:]
+		case spvOpTypeBool, spvOpTypeInt, spvOpTypeFloat, spvOpTypeVector,
+			spvOpTypeMatrix, spvOpTypeImage, spvOpTypeSampler, spvOpTypeSampledImage,
+			spvOpTypeArray, spvOpTypeRuntimeArray, spvOpTypeStruct, spvOpTypePointer:
+			r.types[args[0]], r.typeOps[args[0]] = args[1:], op
+		case spvOpConstantTrue, spvOpConstantFalse, spvOpConstant:
+			r.constants[args[1]] = constantWords(op, args)
+		case spvOpSpecConstantTrue, spvOpSpecConstantFalse, spvOpSpecConstant:
+			r.constants[args[1]] = constantWords(op, args)
+			r.specConstants = append(r.specConstants, args[1])
+		case spvOpVariable:
+			if args[2] != spvStorageFunction {
+				r.variables = append(r.variables, args[1])
+				variableTypes[args[1]], variableStorage[args[1]] = args[0], args[2]
+			}
+		}
+	}
+
+	for _, id := range r.variables {
+		pointer := r.types[variableTypes[id]]
+		if r.typeOps[variableTypes[id]] != spvOpTypePointer || len(pointer) < 2 {
+			return nil, fmt.Errorf("Variable %%%d does not have a pointer type", id)
+		}
+		ty, err := r.typeOf(pointer[1])
+		if err != nil {
+			return nil, err
+		}
+		name := r.names[id]
+		if name == "" && ty.Kind == TypeStruct {
+			name = ty.Name
+		}
+		decorations := r.decorations[id]
+
+		switch storage := variableStorage[id]; storage {
+		case spvStorageInput, spvStorageOutput:
+			location, ok := decorations[spvDecorationLocation]
+			if !ok || len(location) == 0 {
+				continue // Built-in variable.
+			}
+			v := Variable{Name: name, Location: location[0], Type: ty}
+			if storage == spvStorageInput {
+				out.Inputs = append(out.Inputs, v)
+			} else {
+				out.Outputs = append(out.Outputs, v)
+			}
+
+		case spvStoragePushConstant:
+			out.PushConstants = &Variable{Name: name, Type: ty}
+
+		case spvStorageUniformConstant, spvStorageUniform, spvStorageStorageBuffer:
+			d := Descriptor{Name: name, Count: 1, Type: ty}
+			if set, ok := decorations[spvDecorationDescriptorSet]; ok && len(set) > 0 {
+				d.Set = set[0]
+			}
+			if binding, ok := decorations[spvDecorationBinding]; ok && len(binding) > 0 {
+				d.Binding = binding[0]
+			}
+			if ty.Kind == TypeArray {
+				d.Count, d.Type = ty.Count, ty.Elem
+			}
+			kind, ok := r.descriptorKind(storage, d.Type, pointer[1])
+			if !ok {
+				continue
+			}
+			d.Kind = kind
+			out.Descriptors = append(out.Descriptors, d)
+		}
+	}
+
+	for _, id := range r.specConstants {
+		specID, ok := r.decorations[id][spvDecorationSpecID]
+		if !ok || len(specID) == 0 {
+			continue // Composed from other specialization constants.
+		}
+		c := r.constants[id]
+		ty, err := r.typeOf(c[0])
+		if err != nil {
+			return nil, err
+		}
+		out.SpecConstants = append(out.SpecConstants, SpecConstant{
+			Name:  r.names[id],
+			ID:    specID[0],
+			Type:  ty,
+			Value: constantValue(ty, c[1:]),
+		})
+	}
+
+	sort.Slice(out.Descriptors, func(i, j int) bool {
+		a, b := out.Descriptors[i], out.Descriptors[j]
+		return a.Set < b.Set || (a.Set == b.Set && a.Binding < b.Binding)
+	})
+	sort.Slice(out.Inputs, func(i, j int) bool { return out.Inputs[i].Location < out.Inputs[j].Location })
+	sort.Slice(out.Outputs, func(i, j int) bool { return out.Outputs[i].Location < out.Outputs[j].Location })
+	sort.Slice(out.SpecConstants, func(i, j int) bool { return out.SpecConstants[i].ID < out.SpecConstants[j].ID })
+	return out, nil
+}
+
+// descriptorKind returns the kind of descriptor used for a variable of the
+// given storage class with elements of type ty. id is the type id of the
+// variable, which holds the block decorations of buffers.
+func (r *reflector) descriptorKind(storage uint32, ty *Type, id uint32) (DescriptorKind, bool) {
+	if storage == spvStorageStorageBuffer {
+		return DescriptorStorageBuffer, true
+	}
+	if storage == spvStorageUniform {
+		for r.typeOps[id] == spvOpTypeArray || r.typeOps[id] == spvOpTypeRuntimeArray {
+			id = r.types[id][0]
+		}
+		if _, ok := r.decorations[id][spvDecorationBufferBlock]; ok {
+			return DescriptorStorageBuffer, true
+		}
+		return DescriptorUniformBuffer, true
+	}
+	switch ty.Kind {
+	case TypeSampler:
+		return DescriptorSampler, true
+	case TypeSampledImage:
+		if ty.Elem.Dim == spvDimBuffer {
+			return DescriptorUniformTexelBuffer, true
+		}
+		return DescriptorCombinedImageSampler, true
+	case TypeImage:
+		switch {
+		case ty.Dim == spvDimSubpassData:
+			return DescriptorInputAttachment, true
+		case ty.Dim == spvDimBuffer && ty.Storage:
+			return DescriptorStorageTexelBuffer, true
+		case ty.Dim == spvDimBuffer:
+			return DescriptorUniformTexelBuffer, true
+		case ty.Storage:
+			return DescriptorStorageImage, true
+		default:
+			return DescriptorSampledImage, true
+		}
+	}
+	return 0, false
+}
+
+// typeOf returns the type with the given id.
+func (r *reflector) typeOf(id uint32) (*Type, error) {
+	if t, ok := r.built[id]; ok {
+		if t == nil {
+			return nil, fmt.Errorf("SPIR-V type %%%d is recursive", id)
+		}
+		return t, nil
+	}
+	args, ok := r.types[id]
+	if !ok {
+		return nil, fmt.Errorf("SPIR-V type %%%d is not declared", id)
+	}
+	r.built[id] = nil
+
+	t := &Type{}
+	var err error
+	elem := func(i int) *Type {
+		if err != nil {
+			return nil
+		}
+		if i >= len(args) {
+			err = fmt.Errorf("SPIR-V type %%%d has too few operands", id)
+			return nil
+		}
+		var e *Type
+		e, err = r.typeOf(args[i])
+		return e
+	}
+	operand := func(i int) uint32 {
+		if i >= len(args) {
+			if err == nil {
+				err = fmt.Errorf("SPIR-V type %%%d has too few operands", id)
+			}
+			return 0
+		}
+		return args[i]
+	}
+
+	switch r.typeOps[id] {
+	case spvOpTypeBool:
+		t.Kind = TypeBool
+	case spvOpTypeInt:
+		t.Kind, t.Width, t.Signed = TypeInt, operand(0), operand(1) != 0
+	case spvOpTypeFloat:
+		t.Kind, t.Width = TypeFloat, operand(0)
+	case spvOpTypeVector:
+		t.Kind, t.Elem, t.Count = TypeVector, elem(0), operand(1)
+	case spvOpTypeMatrix:
+		t.Kind, t.Elem, t.Count = TypeMatrix, elem(0), operand(1)
+	case spvOpTypeImage:
+		t.Kind, t.Elem, t.Dim = TypeImage, elem(0), operand(1)
+		t.Depth = operand(2) == 1
+		t.Arrayed = operand(3) != 0
+		t.Multisample = operand(4) != 0
+		t.Storage = operand(5) == 2
+	case spvOpTypeSampler:
+		t.Kind = TypeSampler
+	case spvOpTypeSampledImage:
+		t.Kind, t.Elem = TypeSampledImage, elem(0)
+	case spvOpTypeArray:
+		t.Kind, t.Elem = TypeArray, elem(0)
+		if c, ok := r.constants[operand(1)]; ok && len(c) > 1 {
+			t.Count = c[1]
+		}
+		t.ArrayStride = r.decoration(id, spvDecorationArrayStride)
+	case spvOpTypeRuntimeArray:
+		t.Kind, t.Elem = TypeArray, elem(0)
+		t.ArrayStride = r.decoration(id, spvDecorationArrayStride)
+	case spvOpTypeStruct:
+		t.Kind, t.Name = TypeStruct, r.names[id]
+		t.Members = make([]Member, len(args))
+		for i := range args {
+			m := memberID{id, uint32(i)}
+			d := r.memberDecorations[m]
+			_, rowMajor := d[spvDecorationRowMajor]
+			t.Members[i] = Member{
+				Name:         r.memberNames[m],
+				Type:         elem(i),
+				Offset:       firstOperand(d[spvDecorationOffset]),
+				MatrixStride: firstOperand(d[spvDecorationMatrixStride]),
+				RowMajor:     rowMajor,
+			}
+		}
+	case spvOpTypePointer:
+		return nil, fmt.Errorf("SPIR-V type %%%d is a pointer", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.built[id] = t
+	return t, nil
+}
+
+// decoration returns the first operand of the decoration of id, or 0.
+func (r *reflector) decoration(id, decoration uint32) uint32 {
+	return firstOperand(r.decorations[id][decoration])
+}
+
+func firstOperand(operands []uint32) uint32 {
+	if len(operands) == 0 {
+		return 0
+	}
+	return operands[0]
+}
+
+// minOperands returns the minimum number of operands of the instructions
+// handled by the reflection.
+func minOperands(op uint32) int {
+	switch op {
+	case spvOpName, spvOpTypeBool, spvOpTypeSampler, spvOpTypeFloat, spvOpTypeStruct:
+		return 1
+	case spvOpDecorate, spvOpTypeSampledImage, spvOpTypeRuntimeArray,
+		spvOpConstantTrue, spvOpConstantFalse, spvOpSpecConstantTrue, spvOpSpecConstantFalse:
+		return 2
+	case spvOpMemberName, spvOpEntryPoint, spvOpTypeInt, spvOpTypeVector, spvOpTypeMatrix,
+		spvOpTypeArray, spvOpTypePointer, spvOpMemberDecorate, spvOpConstant, spvOpSpecConstant,
+		spvOpVariable:
+		return 3
+	case spvOpTypeImage:
+		return 7
+	default:
+		return 0
+	}
+}
+
+// constantWords returns the result type and value words of the constant
+// instruction with the given opcode and operands.
+func constantWords(op uint32, args []uint32) []uint32 {
+	switch op {
+	case spvOpConstantTrue, spvOpSpecConstantTrue:
+		return []uint32{args[0], 1}
+	case spvOpConstantFalse, spvOpSpecConstantFalse:
+		return []uint32{args[0], 0}
+	default:
+		return append([]uint32{args[0]}, args[2:]...)
+	}
+}
+
+// constantValue returns the value of the constant of type ty encoded in
+// words.
+func constantValue(ty *Type, words []uint32) interface{} {
+	if len(words) == 0 {
+		return nil
+	}
+	bits := uint64(words[0])
+	if len(words) > 1 {
+		bits |= uint64(words[1]) << 32
+	}
+	switch {
+	case ty.Kind == TypeBool:
+		return bits != 0
+	case ty.Kind == TypeFloat && ty.Width == 64:
+		return math.Float64frombits(bits)
+	case ty.Kind == TypeFloat:
+		return math.Float32frombits(uint32(bits))
+	case ty.Kind == TypeInt && ty.Width == 64 && ty.Signed:
+		return int64(bits)
+	case ty.Kind == TypeInt && ty.Width == 64:
+		return bits
+	case ty.Kind == TypeInt && ty.Signed:
+		return int32(bits)
+	default:
+		return uint32(bits)
+	}
+}
+
+// spirvString decodes the nul-terminated literal string at the start of
+// words, returning the string and the number of words it used.
+func spirvString(words []uint32) (string, int) {
+	var b bytes.Buffer
+	for i, w := range words {
+		for j := uint(0); j < 4; j++ {
+			c := byte(w >> (j * 8))
+			if c == 0 {
+				return b.String(), i + 1
+			}
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), len(words)
+}
+
+func swapWord(w uint32) uint32 {
+	return w>>24 | (w>>8)&0xff00 | (w<<8)&0xff0000 | w<<24
+}
@@ -0,0 +1,357 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spirv
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+)
+
+// SPIR-V enumerants only used by the test modules.
+const (
+	spvOpMemoryModel      = 14
+	spvOpExecutionMode    = 16
+	spvOpCapability       = 17
+	spvOpTypeVoid         = 19
+	spvOpTypeFunction     = 33
+	spvOpFunction         = 54
+	spvOpFunctionEnd      = 56
+	spvOpLabel            = 248
+	spvOpReturn           = 253
+	spvDecorationColMajor = 5
+)
+
+// op returns the words of an instruction. Operands are uint32 words or
+// strings, which are encoded as nul-terminated literal strings.
+func op(opcode uint32, operands ...interface{}) []uint32 {
+	words := []uint32{0}
+	for _, o := range operands {
+		switch o := o.(type) {
+		case uint32:
+			words = append(words, o)
+		case int:
+			words = append(words, uint32(o))
+		case string:
+			b := append([]byte(o), 0)
+			for len(b)%4 != 0 {
+				b = append(b, 0)
+			}
+			for i := 0; i < len(b); i += 4 {
+				words = append(words, binary.LittleEndian.Uint32(b[i:]))
+			}
+		}
+	}
+	words[0] = uint32(len(words))<<16 | opcode
+	return words
+}
+
+// module returns the words of a SPIR-V module with the given instructions.
+func module(instructions ...[]uint32) []uint32 {
+	words := []uint32{spvMagic, 0x00010000, 0, 100, 0}
+	for _, i := range instructions {
+		words = append(words, i...)
+	}
+	return words
+}
+
+// testModule is the SPIR-V for the fragment shader:
+//
+//	layout(constant_id = 0) const float factor = 1.5;
+//	layout(constant_id = 1) const bool enabled = true;
+//	layout(constant_id = 3) const int count = 7;
+//	layout(set = 0, binding = 0) uniform UBO { mat4 mvp; vec4 color; } ubo;
+//	layout(set = 0, binding = 1) buffer SSBO { uint n; float data[]; } ssbo;
+//	layout(set = 1, binding = 0, rgba8) uniform image2D img;
+//	layout(set = 1, binding = 2) uniform sampler2D tex[4];
+//	layout(push_constant) uniform PC { vec2 scale; float bias; };
+//	layout(location = 1) in vec3 normal;
+//	layout(location = 0) in vec4 pos;
+//	layout(location = 0) out vec4 color;
+var testModule = module(
+	op(spvOpCapability, 1),
+	op(spvOpMemoryModel, 0, 1),
+	op(spvOpEntryPoint, 4, 4, "main", 34, 36, 38, 39),
+	op(spvOpExecutionMode, 4, 7),
+	op(spvOpName, 4, "main"),
+	op(spvOpName, 9, "UBO"),
+	op(spvOpMemberName, 9, 0, "mvp"),
+	op(spvOpMemberName, 9, 1, "color"),
+	op(spvOpName, 11, "ubo"),
+	op(spvOpName, 14, "SSBO"),
+	op(spvOpMemberName, 14, 0, "n"),
+	op(spvOpMemberName, 14, 1, "data"),
+	op(spvOpName, 16, "ssbo"),
+	op(spvOpName, 22, "tex"),
+	op(spvOpName, 24, "PC"),
+	op(spvOpMemberName, 24, 0, "scale"),
+	op(spvOpMemberName, 24, 1, "bias"),
+	op(spvOpName, 28, "count"),
+	op(spvOpName, 30, "enabled"),
+	op(spvOpName, 31, "factor"),
+	op(spvOpName, 34, "normal"),
+	op(spvOpName, 36, "pos"),
+	op(spvOpName, 38, "color"),
+	op(spvOpName, 45, "img"),
+	op(spvOpMemberDecorate, 9, 0, spvDecorationColMajor),
+	op(spvOpMemberDecorate, 9, 0, spvDecorationOffset, 0),
+	op(spvOpMemberDecorate, 9, 0, spvDecorationMatrixStride, 16),
+	op(spvOpMemberDecorate, 9, 1, spvDecorationOffset, 64),
+	op(spvOpDecorate, 9, spvDecorationBlock),
+	op(spvOpDecorate, 11, spvDecorationDescriptorSet, 0),
+	op(spvOpDecorate, 11, spvDecorationBinding, 0),
+	op(spvOpDecorate, 13, spvDecorationArrayStride, 4),
+	op(spvOpMemberDecorate, 14, 0, spvDecorationOffset, 0),
+	op(spvOpMemberDecorate, 14, 1, spvDecorationOffset, 4),
+	op(spvOpDecorate, 14, spvDecorationBufferBlock),
+	op(spvOpDecorate, 16, spvDecorationDescriptorSet, 0),
+	op(spvOpDecorate, 16, spvDecorationBinding, 1),
+	op(spvOpDecorate, 22, spvDecorationDescriptorSet, 1),
+	op(spvOpDecorate, 22, spvDecorationBinding, 2),
+	op(spvOpMemberDecorate, 24, 0, spvDecorationOffset, 0),
+	op(spvOpMemberDecorate, 24, 1, spvDecorationOffset, 8),
+	op(spvOpDecorate, 24, spvDecorationBlock),
+	op(spvOpDecorate, 28, spvDecorationSpecID, 3),
+	op(spvOpDecorate, 30, spvDecorationSpecID, 1),
+	op(spvOpDecorate, 31, spvDecorationSpecID, 0),
+	op(spvOpDecorate, 34, spvDecorationLocation, 1),
+	op(spvOpDecorate, 36, spvDecorationLocation, 0),
+	op(spvOpDecorate, 38, spvDecorationLocation, 0),
+	op(spvOpDecorate, 39, spvDecorationBuiltIn, 15),
+	op(spvOpDecorate, 45, spvDecorationDescriptorSet, 1),
+	op(spvOpDecorate, 45, spvDecorationBinding, 0),
+	op(spvOpTypeVoid, 2),
+	op(spvOpTypeFunction, 3, 2),
+	op(spvOpTypeFloat, 6, 32),
+	op(spvOpTypeVector, 7, 6, 4),
+	op(spvOpTypeMatrix, 8, 7, 4),
+	op(spvOpTypeStruct, 9, 8, 7),
+	op(spvOpTypePointer, 10, spvStorageUniform, 9),
+	op(spvOpVariable, 10, 11, spvStorageUniform),
+	op(spvOpTypeInt, 12, 32, 0),
+	op(spvOpTypeRuntimeArray, 13, 6),
+	op(spvOpTypeStruct, 14, 12, 13),
+	op(spvOpTypePointer, 15, spvStorageUniform, 14),
+	op(spvOpVariable, 15, 16, spvStorageUniform),
+	op(spvOpTypeImage, 17, 6, spvDim2D, 0, 0, 0, 1, 0),
+	op(spvOpTypeSampledImage, 18, 17),
+	op(spvOpConstant, 12, 19, 4),
+	op(spvOpTypeArray, 20, 18, 19),
+	op(spvOpTypePointer, 21, spvStorageUniformConstant, 20),
+	op(spvOpVariable, 21, 22, spvStorageUniformConstant),
+	op(spvOpTypeVector, 23, 6, 2),
+	op(spvOpTypeStruct, 24, 23, 6),
+	op(spvOpTypePointer, 25, spvStoragePushConstant, 24),
+	op(spvOpVariable, 25, 26, spvStoragePushConstant),
+	op(spvOpTypeInt, 27, 32, 1),
+	op(spvOpSpecConstant, 27, 28, 7),
+	op(spvOpTypeBool, 29),
+	op(spvOpSpecConstantTrue, 29, 30),
+	op(spvOpSpecConstant, 6, 31, 0x3fc00000),
+	op(spvOpTypeVector, 32, 6, 3),
+	op(spvOpTypePointer, 33, spvStorageInput, 32),
+	op(spvOpVariable, 33, 34, spvStorageInput),
+	op(spvOpTypePointer, 35, spvStorageInput, 7),
+	op(spvOpVariable, 35, 36, spvStorageInput),
+	op(spvOpTypePointer, 37, spvStorageOutput, 7),
+	op(spvOpVariable, 37, 38, spvStorageOutput),
+	op(spvOpVariable, 35, 39, spvStorageInput),
+	op(spvOpTypePointer, 40, spvStorageFunction, 6),
+	op(spvOpTypeImage, 43, 6, spvDim2D, 0, 0, 0, 2, 4),
+	op(spvOpTypePointer, 44, spvStorageUniformConstant, 43),
+	op(spvOpVariable, 44, 45, spvStorageUniformConstant),
+	op(spvOpFunction, 2, 4, 0, 3),
+	op(spvOpLabel, 41),
+	op(spvOpVariable, 40, 42, spvStorageFunction),
+	op(spvOpReturn),
+	op(spvOpFunctionEnd),
+)
+
+type descriptor struct {
+	name         string
+	set, binding uint32
+	kind         DescriptorKind
+	count        uint32
+	ty           string
+}
+
+type member struct {
+	name   string
+	offset uint32
+	ty     string
+}
+
+func members(t *Type) []member {
+	out := []member{}
+	for _, m := range t.Members {
+		out = append(out, member{m.Name, m.Offset, m.Type.String()})
+	}
+	return out
+}
+
+func TestReflect(t *testing.T) {
+	assert := assert.To(t)
+
+	r, err := Reflect(testModule)
+	if !assert.For("err").ThatError(err).Succeeded() {
+		return
+	}
+
+	assert.For("entry points").That(r.EntryPoints).DeepEquals([]EntryPoint{
+		{Name: "main", Stage: ExecutionModelFragment},
+	})
+
+	descriptors := []descriptor{}
+	for _, d := range r.Descriptors {
+		descriptors = append(descriptors, descriptor{d.Name, d.Set, d.Binding, d.Kind, d.Count, d.Type.String()})
+	}
+	assert.For("descriptors").That(descriptors).DeepEquals([]descriptor{
+		{"ubo", 0, 0, DescriptorUniformBuffer, 1, "UBO"},
+		{"ssbo", 0, 1, DescriptorStorageBuffer, 1, "SSBO"},
+		{"img", 1, 0, DescriptorStorageImage, 1, "image2D"},
+		{"tex", 1, 2, DescriptorCombinedImageSampler, 4, "sampler2D"},
+	})
+
+	ubo := r.Descriptors[0].Type
+	assert.For("ubo members").That(members(ubo)).DeepEquals([]member{
+		{"mvp", 0, "mat4"},
+		{"color", 64, "vec4"},
+	})
+	assert.For("ubo matrix stride").That(ubo.Members[0].MatrixStride).Equals(uint32(16))
+	assert.For("ubo size").That(ubo.Size(0, false)).Equals(uint32(80))
+
+	ssbo := r.Descriptors[1].Type
+	assert.For("ssbo members").That(members(ssbo)).DeepEquals([]member{
+		{"n", 0, "uint"},
+		{"data", 4, "float[]"},
+	})
+	data := ssbo.Members[1].Type
+	assert.For("runtime array kind").That(data.Kind).Equals(TypeArray)
+	assert.For("runtime array count").That(data.Count).Equals(uint32(0))
+	assert.For("runtime array stride").That(data.ArrayStride).Equals(uint32(4))
+
+	if assert.For("push constants").That(r.PushConstants).IsNotNil() {
+		assert.For("push constants name").That(r.PushConstants.Name).Equals("PC")
+		assert.For("push constants members").That(members(r.PushConstants.Type)).DeepEquals([]member{
+			{"scale", 0, "vec2"},
+			{"bias", 8, "float"},
+		})
+		assert.For("push constants size").That(r.PushConstants.Type.Size(0, false)).Equals(uint32(12))
+	}
+
+	variables := func(vs []Variable) []member {
+		out := []member{}
+		for _, v := range vs {
+			out = append(out, member{v.Name, v.Location, v.Type.String()})
+		}
+		return out
+	}
+	assert.For("inputs").That(variables(r.Inputs)).DeepEquals([]member{
+		{"pos", 0, "vec4"},
+		{"normal", 1, "vec3"},
+	})
+	assert.For("outputs").That(variables(r.Outputs)).DeepEquals([]member{
+		{"color", 0, "vec4"},
+	})
+
+	type specConstant struct {
+		name  string
+		id    uint32
+		ty    string
+		value interface{}
+	}
+	specConstants := []specConstant{}
+	for _, c := range r.SpecConstants {
+		specConstants = append(specConstants, specConstant{c.Name, c.ID, c.Type.String(), c.Value})
+	}
+	assert.For("spec constants").That(specConstants).DeepEquals([]specConstant{
+		{"factor", 0, "float", float32(1.5)},
+		{"enabled", 1, "bool", true},
+		{"count", 3, "int", int32(7)},
+	})
+}
+
+func TestReflectByteSwapped(t *testing.T) {
+	assert := assert.To(t)
+
+	swapped := make([]uint32, len(testModule))
+	for i, w := range testModule {
+		swapped[i] = swapWord(w)
+	}
+	expected, err := Reflect(testModule)
+	assert.For("err").ThatError(err).Succeeded()
+	got, err := Reflect(swapped)
+	assert.For("swapped err").ThatError(err).Succeeded()
+	assert.For("swapped").That(got).DeepEquals(expected)
+}
+
+func TestReflectInvalid(t *testing.T) {
+	assert := assert.To(t)
+
+	for _, test := range []struct {
+		name  string
+		words []uint32
+	}{
+		{"empty", nil},
+		{"header only", testModule[:4]},
+		{"bad magic", append([]uint32{0xdeadbeef}, testModule[1:]...)},
+		{"truncated module", testModule[:len(testModule)/2]},
+		{"truncated instruction", module(op(spvOpName, 1, "truncated"))[:7]},
+		{"zero word count", module(op(spvOpCapability, 1), []uint32{spvOpCapability})},
+		{"short image type", module(
+			op(spvOpTypeFloat, 1, 32),
+			op(spvOpTypeImage, 2, 1, spvDim2D, 0, 0, 0),
+		)},
+		{"short variable", module(op(spvOpVariable, 1, 2))},
+		{"undeclared type", module(
+			op(spvOpTypePointer, 1, spvStorageUniformConstant, 2),
+			op(spvOpVariable, 1, 3, spvStorageUniformConstant),
+		)},
+	} {
+		_, err := Reflect(test.words)
+		assert.For("%s", test.name).ThatError(err).Failed()
+	}
+}
+
+func TestReflectExample(t *testing.T) {
+	assert := assert.To(t)
+
+	data, err := ioutil.ReadFile("../cc/spirv_example.spv")
+	if !assert.For("read").ThatError(err).Succeeded() {
+		return
+	}
+	words := make([]uint32, len(data)/4)
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint32(data[i*4:])
+	}
+
+	r, err := Reflect(words)
+	if !assert.For("err").ThatError(err).Succeeded() {
+		return
+	}
+	assert.For("entry points").That(r.EntryPoints).DeepEquals([]EntryPoint{
+		{Name: "main", Stage: ExecutionModelFragment},
+	})
+	if assert.For("descriptors").ThatSlice(r.Descriptors).IsLength(1) {
+		d := r.Descriptors[0]
+		assert.For("name").That(d.Name).Equals("_ShadowMapTexture")
+		assert.For("kind").That(d.Kind).Equals(DescriptorCombinedImageSampler)
+		assert.For("type").That(d.Type.String()).Equals("sampler2DShadow")
+	}
+	assert.For("inputs").ThatSlice(r.Inputs).IsEmpty()
+	assert.For("push constants").That(r.PushConstants).IsNil()
+}
@@ -74,12 +74,15 @@ func (a *artifacts) apply(ctx context.Context, artifact *Artifact) error {
 }
 
 func (a *artifacts) search(ctx context.Context, query *search.Query, handler ArtifactHandler) error {
-	filter := eval.Filter(ctx, query, reflect.TypeOf(&Artifact{}), event.AsHandler(ctx, handler))
-	initial := event.AsProducer(ctx, a.entries)
+	initial, err := eval.Select(ctx, query, reflect.TypeOf(&Artifact{}), a.entries)
+	if err != nil {
+		return err
+	}
+	out := event.AsHandler(ctx, handler)
 	if query.Monitor {
-		return event.Monitor(ctx, &a.mu, a.onAdd.Listen, initial, filter)
+		return event.Monitor(ctx, &a.mu, eval.Listen(ctx, query, reflect.TypeOf(&Artifact{}), a.onAdd.Listen), initial, out)
 	}
-	return event.Feed(ctx, filter, initial)
+	return event.Feed(ctx, out, initial)
 }
 
 type zipEntry struct {
@@ -169,6 +172,8 @@ func (a *artifacts) get(ctx context.Context, id string, builderAbi *device.ABI)
 		"gapid/gapit":                          &toolSet.Host.Gapit,
 		"gapid/libVkLayer_VirtualSwapchain.so": &toolSet.Host.VirtualSwapChainLib,
 		"gapid/VirtualSwapchainLayer.json":     &toolSet.Host.VirtualSwapChainJson,
+		"gapid/libgapii.so":                    &toolSet.Host.GraphicsSpyLib,
+		"gapid/GraphicsSpyLayer.json":          &toolSet.Host.GraphicsSpyJson,
 	}
 	for _, f := range zipFile.File {
 		f.Name = filepath.ToSlash(f.Name)
@@ -83,12 +83,15 @@ func (p *packages) apply(ctx context.Context, pkg *Package) error {
 }
 
 func (p *packages) search(ctx context.Context, query *search.Query, handler PackageHandler) error {
-	filter := eval.Filter(ctx, query, packageClass, event.AsHandler(ctx, handler))
-	initial := event.AsProducer(ctx, p.entries)
+	initial, err := eval.Select(ctx, query, packageClass, p.entries)
+	if err != nil {
+		return err
+	}
+	out := event.AsHandler(ctx, handler)
 	if query.Monitor {
-		return event.Monitor(ctx, &p.mu, p.onChange.Listen, initial, filter)
+		return event.Monitor(ctx, &p.mu, eval.Listen(ctx, query, packageClass, p.onChange.Listen), initial, out)
 	}
-	return event.Feed(ctx, filter, initial)
+	return event.Feed(ctx, out, initial)
 }
 
 func (p *packages) update(ctx context.Context, pkg *Package) error {
@@ -159,6 +162,18 @@ func (pkg *Package) mergeTool(ctx context.Context, tool *ToolSet) {
 			if tool.Host.Gapit != "" {
 				t.Host.Gapit = tool.Host.Gapit
 			}
+			if tool.Host.VirtualSwapChainLib != "" {
+				t.Host.VirtualSwapChainLib = tool.Host.VirtualSwapChainLib
+			}
+			if tool.Host.VirtualSwapChainJson != "" {
+				t.Host.VirtualSwapChainJson = tool.Host.VirtualSwapChainJson
+			}
+			if tool.Host.GraphicsSpyLib != "" {
+				t.Host.GraphicsSpyLib = tool.Host.GraphicsSpyLib
+			}
+			if tool.Host.GraphicsSpyJson != "" {
+				t.Host.GraphicsSpyJson = tool.Host.GraphicsSpyJson
+			}
 			for _, android := range tool.Android {
 				if android.GapidApk != "" {
 					if a := t.FindAndroidToolSet(android.Abi); a != nil {
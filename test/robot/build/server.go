@@ -16,8 +16,11 @@ package build
 
 import (
 	"context"
+	"reflect"
 
+	"github.com/google/gapid/core/event"
 	"github.com/google/gapid/test/robot/search"
+	"github.com/google/gapid/test/robot/search/eval"
 
 	"google.golang.org/grpc"
 
@@ -41,6 +44,15 @@ func (s *server) SearchArtifacts(query *search.Query, stream Service_SearchArtif
 	return s.store.SearchArtifacts(ctx, query, func(ctx context.Context, e *Artifact) error { return stream.Send(e) })
 }
 
+// SearchArtifactRows implements ServiceServer.SearchArtifactRows
+// It aggregates the results of SearchArtifacts on the provided Store implementation.
+func (s *server) SearchArtifactRows(query *search.Query, stream Service_SearchArtifactRowsServer) error {
+	ctx := stream.Context()
+	return eval.Aggregate(ctx, query, reflect.TypeOf(&Artifact{}), func(ctx context.Context, h event.Handler) error {
+		return s.store.SearchArtifacts(ctx, query, func(ctx context.Context, e *Artifact) error { return h(ctx, e) })
+	}, func(ctx context.Context, row *search.Row) error { return stream.Send(row) })
+}
+
 // SearchPackages implements ServiceServer.SearchPackages
 // It delegates the call to the provided Store implementation.
 func (s *server) SearchPackages(query *search.Query, stream Service_SearchPackagesServer) error {
@@ -48,6 +60,15 @@ func (s *server) SearchPackages(query *search.Query, stream Service_SearchPackag
 	return s.store.SearchPackages(ctx, query, func(ctx context.Context, e *Package) error { return stream.Send(e) })
 }
 
+// SearchPackageRows implements ServiceServer.SearchPackageRows
+// It aggregates the results of SearchPackages on the provided Store implementation.
+func (s *server) SearchPackageRows(query *search.Query, stream Service_SearchPackageRowsServer) error {
+	ctx := stream.Context()
+	return eval.Aggregate(ctx, query, reflect.TypeOf(&Package{}), func(ctx context.Context, h event.Handler) error {
+		return s.store.SearchPackages(ctx, query, func(ctx context.Context, e *Package) error { return h(ctx, e) })
+	}, func(ctx context.Context, row *search.Row) error { return stream.Send(row) })
+}
+
 // SearchTracks implements ServiceServer.SearchTrackst
 // It delegates the call to the provided Store implementation.
 func (s *server) SearchTracks(query *search.Query, stream Service_SearchTracksServer) error {
@@ -55,6 +76,15 @@ func (s *server) SearchTracks(query *search.Query, stream Service_SearchTracksSe
 	return s.store.SearchTracks(ctx, query, func(ctx context.Context, e *Track) error { return stream.Send(e) })
 }
 
+// SearchTrackRows implements ServiceServer.SearchTrackRows
+// It aggregates the results of SearchTracks on the provided Store implementation.
+func (s *server) SearchTrackRows(query *search.Query, stream Service_SearchTrackRowsServer) error {
+	ctx := stream.Context()
+	return eval.Aggregate(ctx, query, reflect.TypeOf(&Track{}), func(ctx context.Context, h event.Handler) error {
+		return s.store.SearchTracks(ctx, query, func(ctx context.Context, e *Track) error { return h(ctx, e) })
+	}, func(ctx context.Context, row *search.Row) error { return stream.Send(row) })
+}
+
 // Add implements ServiceServer.Add
 // It delegates the call to the provided Store implementation.
 func (s *server) Add(outer xctx.Context, request *AddRequest) (*AddResponse, error) {
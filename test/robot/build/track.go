@@ -83,12 +83,15 @@ func (t *tracks) apply(ctx context.Context, track *Track) error {
 }
 
 func (t *tracks) search(ctx context.Context, query *search.Query, handler TrackHandler) error {
-	filter := eval.Filter(ctx, query, trackClass, event.AsHandler(ctx, handler))
-	initial := event.AsProducer(ctx, t.entries)
+	initial, err := eval.Select(ctx, query, trackClass, t.entries)
+	if err != nil {
+		return err
+	}
+	out := event.AsHandler(ctx, handler)
 	if query.Monitor {
-		return event.Monitor(ctx, &t.mu, t.onChange.Listen, initial, filter)
+		return event.Monitor(ctx, &t.mu, eval.Listen(ctx, query, trackClass, t.onChange.Listen), initial, out)
 	}
-	return event.Feed(ctx, filter, initial)
+	return event.Feed(ctx, out, initial)
 }
 
 func (t *tracks) createOrUpdate(ctx context.Context, track *Track) (*Track, string, error) {
@@ -58,12 +58,15 @@ func (l *devices) apply(ctx context.Context, entry *Device) error {
 }
 
 func (l *devices) search(ctx context.Context, query *search.Query, handler DeviceHandler) error {
-	filter := eval.Filter(ctx, query, reflect.TypeOf(&Device{}), event.AsHandler(ctx, handler))
-	initial := event.AsProducer(ctx, l.entries)
+	initial, err := eval.Select(ctx, query, reflect.TypeOf(&Device{}), l.entries)
+	if err != nil {
+		return err
+	}
+	out := event.AsHandler(ctx, handler)
 	if query.Monitor {
-		return event.Monitor(ctx, &l.mu, l.onChange.Listen, initial, filter)
+		return event.Monitor(ctx, &l.mu, eval.Listen(ctx, query, reflect.TypeOf(&Device{}), l.onChange.Listen), initial, out)
 	}
-	return event.Feed(ctx, filter, initial)
+	return event.Feed(ctx, out, initial)
 }
 
 func (l *devices) uniqueName(ctx context.Context, name string) string {
@@ -52,12 +52,15 @@ func (m *local) SearchDevices(ctx context.Context, query *search.Query, handler
 // SearchWorkers implements Manager.SearchWorkers
 // It searches the set of persisted workers, and supports monitoring of workers as they are registered.
 func (m *local) SearchWorkers(ctx context.Context, query *search.Query, handler WorkerHandler) error {
-	filter := eval.Filter(ctx, query, reflect.TypeOf(&Worker{}), event.AsHandler(ctx, handler))
-	initial := event.AsProducer(ctx, m.entries)
+	initial, err := eval.Select(ctx, query, reflect.TypeOf(&Worker{}), m.entries)
+	if err != nil {
+		return err
+	}
+	out := event.AsHandler(ctx, handler)
 	if query.Monitor {
-		return event.Monitor(ctx, &m.mu, m.onChange.Listen, initial, filter)
+		return event.Monitor(ctx, &m.mu, eval.Listen(ctx, query, reflect.TypeOf(&Worker{}), m.onChange.Listen), initial, out)
 	}
-	return event.Feed(ctx, filter, initial)
+	return event.Feed(ctx, out, initial)
 }
 
 // GetWorker implements Manager.GetWorker
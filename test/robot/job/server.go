@@ -16,8 +16,11 @@ package job
 
 import (
 	"context"
+	"reflect"
 
+	"github.com/google/gapid/core/event"
 	"github.com/google/gapid/test/robot/search"
+	"github.com/google/gapid/test/robot/search/eval"
 
 	"google.golang.org/grpc"
 
@@ -41,6 +44,15 @@ func (s *server) SearchDevices(query *search.Query, stream Service_SearchDevices
 	return s.manager.SearchDevices(ctx, query, func(ctx context.Context, e *Device) error { return stream.Send(e) })
 }
 
+// SearchDeviceRows implements ServiceServer.SearchDeviceRows
+// It aggregates the results of SearchDevices on the provided Manager implementation.
+func (s *server) SearchDeviceRows(query *search.Query, stream Service_SearchDeviceRowsServer) error {
+	ctx := stream.Context()
+	return eval.Aggregate(ctx, query, reflect.TypeOf(&Device{}), func(ctx context.Context, h event.Handler) error {
+		return s.manager.SearchDevices(ctx, query, func(ctx context.Context, e *Device) error { return h(ctx, e) })
+	}, func(ctx context.Context, row *search.Row) error { return stream.Send(row) })
+}
+
 // SearchWorkers implements ServiceServer.SearchWorkers
 // It delegates the call to the provided Manager implementation.
 func (s *server) SearchWorkers(query *search.Query, stream Service_SearchWorkersServer) error {
@@ -48,6 +60,15 @@ func (s *server) SearchWorkers(query *search.Query, stream Service_SearchWorkers
 	return s.manager.SearchWorkers(ctx, query, func(ctx context.Context, e *Worker) error { return stream.Send(e) })
 }
 
+// SearchWorkerRows implements ServiceServer.SearchWorkerRows
+// It aggregates the results of SearchWorkers on the provided Manager implementation.
+func (s *server) SearchWorkerRows(query *search.Query, stream Service_SearchWorkerRowsServer) error {
+	ctx := stream.Context()
+	return eval.Aggregate(ctx, query, reflect.TypeOf(&Worker{}), func(ctx context.Context, h event.Handler) error {
+		return s.manager.SearchWorkers(ctx, query, func(ctx context.Context, e *Worker) error { return h(ctx, e) })
+	}, func(ctx context.Context, row *search.Row) error { return stream.Send(row) })
+}
+
 // GetWorker implements ServiceServer.GetWorker
 // It delegates the call to the provided Manager implementation.
 func (s *server) GetWorker(ctx xctx.Context, request *GetWorkerRequest) (*GetWorkerResponse, error) {
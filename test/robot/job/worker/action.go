@@ -140,12 +140,15 @@ func (a *Actions) ByInput(ctx context.Context, input proto.Message) Action {
 
 // Search runs the query for each entry in the action list, and hands the matches to the action handler.
 func (a *Actions) Search(ctx context.Context, query *search.Query, handler interface{}) error {
-	filter := eval.Filter(ctx, query, reflect.TypeOf(a.nullAction), event.AsHandler(ctx, handler))
-	initial := event.AsProducer(ctx, a.entries)
+	initial, err := eval.Select(ctx, query, reflect.TypeOf(a.nullAction), a.entries)
+	if err != nil {
+		return err
+	}
+	out := event.AsHandler(ctx, handler)
 	if query.Monitor {
-		return event.Monitor(ctx, &a.mu, a.onChange.Listen, initial, filter)
+		return event.Monitor(ctx, &a.mu, eval.Listen(ctx, query, reflect.TypeOf(a.nullAction), a.onChange.Listen), initial, out)
 	}
-	return event.Feed(ctx, filter, initial)
+	return event.Feed(ctx, out, initial)
 }
 
 // EquivalentAction returns true if an action is the same task being performed on the same devices.
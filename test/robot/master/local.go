@@ -55,12 +55,15 @@ func (m *local) Close(ctx context.Context) {
 // Search implements Master.Search
 // It searches the set of active satellites, and supports monitoring of satellites as they start orbiting.
 func (m *local) Search(ctx context.Context, query *search.Query, handler SatelliteHandler) error {
-	filter := eval.Filter(ctx, query, satelliteClass, event.AsHandler(ctx, handler))
-	initial := m.producer(ctx)
+	initial, err := eval.Select(ctx, query, satelliteClass, m.satelliteInfos())
+	if err != nil {
+		return err
+	}
+	out := event.AsHandler(ctx, handler)
 	if query.Monitor {
-		return event.Monitor(ctx, &m.satelliteLock, m.onChange.Listen, initial, filter)
+		return event.Monitor(ctx, &m.satelliteLock, eval.Listen(ctx, query, satelliteClass, m.onChange.Listen), initial, out)
 	}
-	return event.Feed(ctx, filter, initial)
+	return event.Feed(ctx, out, initial)
 }
 
 // Orbit implements Master.Orbit
@@ -87,16 +90,15 @@ func (m *local) getSatellites() []*satellite {
 	return append([]*satellite(nil), m.satellites...)
 }
 
-func (m *local) producer(ctx context.Context) event.Producer {
-	i := 0
-	return func(ctx context.Context) interface{} {
-		if i >= len(m.satellites) {
-			return nil
-		}
-		res := m.satellites[i]
-		i++
-		return res
+// satelliteInfos returns the descriptions of the satellites currently orbiting
+// the master.
+func (m *local) satelliteInfos() []*Satellite {
+	sats := m.getSatellites()
+	infos := make([]*Satellite, len(sats))
+	for i, sat := range sats {
+		infos[i] = sat.info
 	}
+	return infos
 }
 
 func (m *local) addSatellite(ctx context.Context, services ServiceList) *satellite {
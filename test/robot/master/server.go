@@ -16,8 +16,11 @@ package master
 
 import (
 	"context"
+	"reflect"
 
+	"github.com/google/gapid/core/event"
 	"github.com/google/gapid/test/robot/search"
+	"github.com/google/gapid/test/robot/search/eval"
 
 	"google.golang.org/grpc"
 
@@ -43,6 +46,15 @@ func (s *server) Search(query *search.Query, stream Service_SearchServer) error
 	return s.master.Search(ctx, query, func(ctx context.Context, e *Satellite) error { return stream.Send(e) })
 }
 
+// SearchRows implements ServiceServer.SearchRows
+// It aggregates the results of Search on the provided Master implementation.
+func (s *server) SearchRows(query *search.Query, stream Service_SearchRowsServer) error {
+	ctx := stream.Context()
+	return eval.Aggregate(ctx, query, reflect.TypeOf(&Satellite{}), func(ctx context.Context, h event.Handler) error {
+		return s.master.Search(ctx, query, func(ctx context.Context, e *Satellite) error { return h(ctx, e) })
+	}, func(ctx context.Context, row *search.Row) error { return stream.Send(row) })
+}
+
 // Orbit implements ServiceServer.Orbit
 // It delegates the call to the provided Master implementation.
 func (s *server) Orbit(request *OrbitRequest, stream Service_OrbitServer) error {
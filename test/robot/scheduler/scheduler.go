@@ -54,6 +54,12 @@ func Tick(ctx context.Context, managers *monitor.Managers, data *monitor.Data) [
 				continue
 			}
 			for _, subj := range data.Subjects.All() {
+				if subj.GetHost() != nil {
+					if err := s.doHostTrace(ctx, subj, tools); err != nil {
+						errs = append(errs, err)
+					}
+					continue
+				}
 				androidTools := s.getAndroidTools(ctx, subj)
 				if androidTools == nil {
 					continue
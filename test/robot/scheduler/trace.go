@@ -28,6 +28,10 @@ func (s schedule) doTrace(ctx context.Context, subj *monitor.Subject, tools *bui
 	if !s.worker.Supports(job.Trace) {
 		return nil
 	}
+	// APKs are only traced on attached devices, never on the host itself.
+	if s.worker.Host == s.worker.Target {
+		return nil
+	}
 	ctx = log.Enter(ctx, "Trace")
 	ctx = log.V{"Package": s.pkg.Id}.Bind(ctx)
 	input := &trace.Input{
@@ -40,6 +44,40 @@ func (s schedule) doTrace(ctx context.Context, subj *monitor.Subject, tools *bui
 			GapidAbi: androidTools.Abi,
 		},
 	}
+	return s.startTrace(ctx, input)
+}
+
+func (s schedule) doHostTrace(ctx context.Context, subj *monitor.Subject, tools *build.ToolSet) error {
+	if !s.worker.Supports(job.Trace) {
+		return nil
+	}
+	// Host applications are traced on the host itself, never on an attached device.
+	if s.worker.Host != s.worker.Target {
+		return nil
+	}
+	if tools.Host.GraphicsSpyLib == "" || tools.Host.GraphicsSpyJson == "" {
+		return nil
+	}
+	app := subj.GetHost()
+	host := s.data.FindDevice(s.worker.Host)
+	if host == nil || host.Information.GetConfiguration().GetOS().GetKind() != app.OS {
+		return nil
+	}
+	ctx = log.Enter(ctx, "Trace")
+	ctx = log.V{"Package": s.pkg.Id}.Bind(ctx)
+	input := &trace.Input{
+		Subject:         subj.Id,
+		Gapit:           tools.Host.Gapit,
+		Package:         s.pkg.Id,
+		Hints:           subj.Hints,
+		Host:            app,
+		GraphicsSpyLib:  tools.Host.GraphicsSpyLib,
+		GraphicsSpyJson: tools.Host.GraphicsSpyJson,
+	}
+	return s.startTrace(ctx, input)
+}
+
+func (s schedule) startTrace(ctx context.Context, input *trace.Input) error {
 	action := &trace.Action{
 		Input:  input,
 		Host:   s.worker.Host,
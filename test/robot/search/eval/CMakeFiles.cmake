@@ -18,8 +18,11 @@
 # build and the file will be recreated, check in the new version.
 
 set(files
+    aggregate.go
     doc.go
     eval.go
+    select.go
+    select_test.go
 )
 set(dirs
     
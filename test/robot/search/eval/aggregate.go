@@ -0,0 +1,128 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/google/gapid/core/event"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/test/robot/search"
+)
+
+// RowHandler is the type for a function that receives the rows of an aggregated search.
+type RowHandler func(context.Context, *search.Row) error
+
+// Aggregated returns true if the query has group or fields expressions, and so
+// its results must be delivered as rows rather than entries.
+func Aggregated(query *search.Query) bool {
+	return len(query.Group) > 0 || len(query.Fields) > 0
+}
+
+// Aggregate applies the group and fields expressions of a query to the results of a search.
+// run is invoked with a handler that must be given each entry matching the query, and the
+// resulting rows are passed to handler.
+// If the query is grouped, a row holding the group keys followed by the number of
+// entries in the group is delivered for each group once run returns. Otherwise a row
+// holding the fields is delivered for each entry.
+// A query cannot have both group and fields expressions.
+func Aggregate(ctx context.Context, query *search.Query, klass reflect.Type, run func(context.Context, event.Handler) error, handler RowHandler) error {
+	if !Aggregated(query) {
+		return log.Err(ctx, nil, "Query has no group or fields expressions")
+	}
+	if len(query.Group) > 0 && len(query.Fields) > 0 {
+		return log.Err(ctx, nil, "Cannot select fields of a grouped query")
+	}
+	groups, err := NewGrouper(ctx, query, klass)
+	if err != nil {
+		return err
+	}
+	if groups == nil {
+		fields, err := Fields(ctx, query, klass)
+		if err != nil {
+			return err
+		}
+		return run(ctx, func(ctx context.Context, entry interface{}) error {
+			return handler(ctx, NewRow(fields(ctx, entry)))
+		})
+	}
+	if query.Monitor {
+		return log.Err(ctx, nil, "Cannot group the results of a monitored search")
+	}
+	if err := run(ctx, func(ctx context.Context, entry interface{}) error {
+		groups.Add(ctx, entry)
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, g := range groups.Groups() {
+		if err := handler(ctx, NewRow(append(g.Key, g.Count))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewRow builds a result row from a list of values.
+func NewRow(values []interface{}) *search.Row {
+	row := &search.Row{Values: make([]*search.Value, len(values))}
+	for i, v := range values {
+		row.Values[i] = NewValue(v)
+	}
+	return row
+}
+
+// NewValue converts a value to its search result form.
+// Values that are not booleans, numbers or strings are converted to their string form.
+func NewValue(v interface{}) *search.Value {
+	if s, ok := v.(fmt.Stringer); ok {
+		return &search.Value{Is: &search.Value_String_{String_: s.String()}}
+	}
+	r := reflect.ValueOf(v)
+	switch r.Kind() {
+	case reflect.Bool:
+		return &search.Value{Is: &search.Value_Boolean{Boolean: r.Bool()}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &search.Value{Is: &search.Value_Signed{Signed: r.Int()}}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &search.Value{Is: &search.Value_Unsigned{Unsigned: r.Uint()}}
+	case reflect.Float32, reflect.Float64:
+		return &search.Value{Is: &search.Value_Double{Double: r.Float()}}
+	case reflect.String:
+		return &search.Value{Is: &search.Value_String_{String_: r.String()}}
+	default:
+		return &search.Value{Is: &search.Value_String_{String_: fmt.Sprint(v)}}
+	}
+}
+
+// ValueOf returns the go value held by a search result value.
+func ValueOf(v *search.Value) interface{} {
+	switch v := v.Is.(type) {
+	case *search.Value_Boolean:
+		return v.Boolean
+	case *search.Value_String_:
+		return v.String_
+	case *search.Value_Signed:
+		return v.Signed
+	case *search.Value_Unsigned:
+		return v.Unsigned
+	case *search.Value_Double:
+		return v.Double
+	default:
+		return nil
+	}
+}
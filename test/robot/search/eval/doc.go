@@ -15,4 +15,7 @@
 // Package eval supplies logic for automatically applying a search query to
 // a set of records.
 // The main entry point is eval.Compile, that builds and returns a Matcher.
+// eval.Select applies the filter, ordering and limit of a query to a set of
+// entries, and eval.Aggregate turns the selected entries into the rows
+// requested by the group and fields expressions of the query.
 package eval
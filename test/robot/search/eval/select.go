@@ -0,0 +1,218 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/google/gapid/core/event"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/test/robot/search"
+)
+
+// Projector is the type for a function that extracts the requested fields from a value.
+type Projector func(context.Context, interface{}) []interface{}
+
+// Group is a set of entries that share the same group key.
+type Group struct {
+	// Key is the value of each of the query group expressions for this group.
+	Key []interface{}
+	// Count is the number of entries in the group.
+	Count int
+}
+
+// Grouper collects values into groups using the group expressions of a query.
+type Grouper struct {
+	key    Projector
+	groups []*Group
+	byKey  map[string]*Group
+}
+
+type compare func(ctx context.Context, a, b interface{}) int
+
+// Select returns a producer of the entries that match the query, sorted and limited as
+// requested by the query.
+// entries must be a slice of values of type klass.
+// The produced entries have already been matched against the query, so they must not be
+// passed through Filter again.
+func Select(ctx context.Context, query *search.Query, klass reflect.Type, entries interface{}) (event.Producer, error) {
+	pred, err := Compile(ctx, query, klass)
+	if err != nil {
+		return nil, err
+	}
+	cmp, err := compileOrder(ctx, query.Order, klass)
+	if err != nil {
+		return nil, err
+	}
+	list := reflect.ValueOf(entries)
+	selected := []interface{}{}
+	for i := 0; i < list.Len(); i++ {
+		v := list.Index(i).Interface()
+		if pred(ctx, v) {
+			selected = append(selected, v)
+		}
+	}
+	if cmp != nil {
+		sort.SliceStable(selected, func(i, j int) bool { return cmp(ctx, selected[i], selected[j]) < 0 })
+	}
+	if query.Limit > 0 && int64(len(selected)) > query.Limit {
+		selected = selected[:query.Limit]
+	}
+	return event.AsProducer(ctx, selected), nil
+}
+
+// Listen returns a listener that registers handlers with listener, but only passes on
+// the events that match the query.
+// It is used to monitor for new entries once the initial entries have been delivered
+// from Select.
+func Listen(ctx context.Context, query *search.Query, klass reflect.Type, listener event.Listener) event.Listener {
+	return func(ctx context.Context, handler event.Handler) {
+		listener(ctx, Filter(ctx, query, klass, handler))
+	}
+}
+
+// Fields compiles the fields list of the query into a projector.
+// If the query has no fields, a nil projector is returned.
+func Fields(ctx context.Context, query *search.Query, klass reflect.Type) (Projector, error) {
+	return compileProjection(ctx, query.Fields, klass)
+}
+
+// NewGrouper builds a grouper for the group expressions of the query.
+// If the query has no group expressions, a nil grouper is returned.
+func NewGrouper(ctx context.Context, query *search.Query, klass reflect.Type) (*Grouper, error) {
+	key, err := compileProjection(ctx, query.Group, klass)
+	if key == nil || err != nil {
+		return nil, err
+	}
+	return &Grouper{key: key, byKey: map[string]*Group{}}, nil
+}
+
+// Add adds the value to the group that matches its key.
+func (g *Grouper) Add(ctx context.Context, value interface{}) {
+	key := g.key(ctx, value)
+	id := fmt.Sprintf("%#v", key)
+	group := g.byKey[id]
+	if group == nil {
+		group = &Group{Key: key}
+		g.byKey[id] = group
+		g.groups = append(g.groups, group)
+	}
+	group.Count++
+}
+
+// Groups returns the groups collected so far, in the order they were first seen.
+func (g *Grouper) Groups() []*Group {
+	return g.groups
+}
+
+func compileProjection(ctx context.Context, exprs []*search.Expression, klass reflect.Type) (Projector, error) {
+	if len(exprs) == 0 {
+		return nil, nil
+	}
+	evals := make([]eval, len(exprs))
+	for i, expr := range exprs {
+		e, _, err := compileExpression(ctx, expr, klass)
+		if err != nil {
+			return nil, err
+		}
+		evals[i] = e
+	}
+	return func(ctx context.Context, value interface{}) []interface{} {
+		result := make([]interface{}, len(evals))
+		for i, e := range evals {
+			result[i] = e(ctx, value)
+		}
+		return result
+	}, nil
+}
+
+func compileOrder(ctx context.Context, order []*search.Order, klass reflect.Type) (compare, error) {
+	if len(order) == 0 {
+		return nil, nil
+	}
+	keys := make([]compare, len(order))
+	for i, o := range order {
+		key, kt, err := compileExpression(ctx, o.Key, klass)
+		if err != nil {
+			return nil, err
+		}
+		cmp, err := compareKind(ctx, kt)
+		if err != nil {
+			return nil, err
+		}
+		descending := o.Descending
+		keys[i] = func(ctx context.Context, a, b interface{}) int {
+			r := cmp(ctx, key(ctx, a), key(ctx, b))
+			if descending {
+				return -r
+			}
+			return r
+		}
+	}
+	return func(ctx context.Context, a, b interface{}) int {
+		for _, k := range keys {
+			if r := k(ctx, a, b); r != 0 {
+				return r
+			}
+		}
+		return 0
+	}, nil
+}
+
+func compareKind(ctx context.Context, t reflect.Type) (compare, error) {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(ctx context.Context, a, b interface{}) int {
+			x, y := reflect.ValueOf(a).Int(), reflect.ValueOf(b).Int()
+			return compareOrdered(x < y, x > y)
+		}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(ctx context.Context, a, b interface{}) int {
+			x, y := reflect.ValueOf(a).Uint(), reflect.ValueOf(b).Uint()
+			return compareOrdered(x < y, x > y)
+		}, nil
+	case reflect.Float32, reflect.Float64:
+		return func(ctx context.Context, a, b interface{}) int {
+			x, y := reflect.ValueOf(a).Float(), reflect.ValueOf(b).Float()
+			return compareOrdered(x < y, x > y)
+		}, nil
+	case reflect.String:
+		return func(ctx context.Context, a, b interface{}) int {
+			x, y := reflect.ValueOf(a).String(), reflect.ValueOf(b).String()
+			return compareOrdered(x < y, x > y)
+		}, nil
+	case reflect.Bool:
+		return func(ctx context.Context, a, b interface{}) int {
+			x, y := reflect.ValueOf(a).Bool(), reflect.ValueOf(b).Bool()
+			return compareOrdered(!x && y, x && !y)
+		}, nil
+	default:
+		return nil, log.Errf(ctx, nil, "Cannot order by values of type %v", t)
+	}
+}
+
+func compareOrdered(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/event"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/test/robot/search"
+	"github.com/google/gapid/test/robot/search/eval"
+	"github.com/google/gapid/test/robot/search/query"
+)
+
+type entry struct {
+	Host   string
+	Id     int64
+	Passed bool
+}
+
+var (
+	entryClass = reflect.TypeOf(&entry{})
+	entries    = []*entry{
+		{Host: "b", Id: 3, Passed: true},
+		{Host: "a", Id: 1, Passed: false},
+		{Host: "c", Id: 5, Passed: false},
+		{Host: "a", Id: 4, Passed: true},
+		{Host: "b", Id: 2, Passed: false},
+	}
+)
+
+func ids(ctx context.Context, p event.Producer) []int64 {
+	result := []int64{}
+	event.Feed(ctx, func(ctx context.Context, e interface{}) error {
+		result = append(result, e.(*entry).Id)
+		return nil
+	}, p)
+	return result
+}
+
+func rows(ctx context.Context, q *search.Query) ([][]interface{}, error) {
+	result := [][]interface{}{}
+	err := eval.Aggregate(ctx, q, entryClass, func(ctx context.Context, h event.Handler) error {
+		initial, err := eval.Select(ctx, q, entryClass, entries)
+		if err != nil {
+			return err
+		}
+		return event.Feed(ctx, h, initial)
+	}, func(ctx context.Context, row *search.Row) error {
+		values := make([]interface{}, len(row.Values))
+		for i, v := range row.Values {
+			values[i] = eval.ValueOf(v)
+		}
+		result = append(result, values)
+		return nil
+	})
+	return result, err
+}
+
+func TestSelect(t *testing.T) {
+	ctx := log.Testing(t)
+	assert := assert.To(t)
+	id, host := query.Name("Id"), query.Name("Host")
+	for _, test := range []struct {
+		name     string
+		query    query.Builder
+		expected []int64
+	}{
+		{"all", query.Bool(true), []int64{3, 1, 5, 4, 2}},
+		{"filter", query.Name("Passed"), []int64{3, 4}},
+		{"order", query.Bool(true).OrderBy(id, false), []int64{1, 2, 3, 4, 5}},
+		{"descending", query.Bool(true).OrderBy(id, true), []int64{5, 4, 3, 2, 1}},
+		{"secondary", query.Bool(true).OrderBy(host, false).OrderBy(id, true), []int64{4, 1, 3, 2, 5}},
+		{"stable", query.Bool(true).OrderBy(host, false), []int64{1, 4, 3, 2, 5}},
+		{"limit", query.Bool(true).Limit(2), []int64{3, 1}},
+		{"order limit", query.Bool(true).OrderBy(id, true).Limit(2), []int64{5, 4}},
+		{"filter limit", query.Not(query.Name("Passed")).Limit(2), []int64{1, 5}},
+	} {
+		p, err := eval.Select(ctx, test.query.Query(), entryClass, entries)
+		assert.For("%s err", test.name).ThatError(err).Succeeded()
+		assert.For("Select %s", test.name).ThatSlice(ids(ctx, p)).Equals(test.expected)
+	}
+}
+
+func TestSelectInvalidOrder(t *testing.T) {
+	ctx := log.Testing(t)
+	assert := assert.To(t)
+	q := query.Bool(true).OrderBy(query.Name("Missing"), false).Query()
+	_, err := eval.Select(ctx, q, entryClass, entries)
+	assert.For("err").ThatError(err).Failed()
+}
+
+func TestListen(t *testing.T) {
+	ctx := log.Testing(t)
+	assert := assert.To(t)
+	q := query.Name("Passed").Query()
+	got := []int64{}
+	var added event.Broadcast
+	eval.Listen(ctx, q, entryClass, added.Listen)(ctx, func(ctx context.Context, e interface{}) error {
+		got = append(got, e.(*entry).Id)
+		return nil
+	})
+	for _, e := range []*entry{{Id: 6, Passed: true}, {Id: 7, Passed: false}, {Id: 8, Passed: true}} {
+		added.Send(ctx, e)
+	}
+	assert.For("listened").ThatSlice(got).Equals([]int64{6, 8})
+}
+
+func TestAggregate(t *testing.T) {
+	ctx := log.Testing(t)
+	assert := assert.To(t)
+	id, host, passed := query.Name("Id"), query.Name("Host"), query.Name("Passed")
+	for _, test := range []struct {
+		name     string
+		query    query.Builder
+		expected [][]interface{}
+	}{
+		{"fields", query.Bool(true).OrderBy(id, false).Limit(2).Fields(host, id),
+			[][]interface{}{{"a", int64(1)}, {"b", int64(2)}}},
+		{"group", query.Bool(true).GroupBy(host),
+			[][]interface{}{{"b", int64(2)}, {"a", int64(2)}, {"c", int64(1)}}},
+		{"group filtered", query.Not(passed).OrderBy(host, false).GroupBy(host),
+			[][]interface{}{{"a", int64(1)}, {"b", int64(1)}, {"c", int64(1)}}},
+		{"group keys", query.Bool(true).GroupBy(host, passed).Limit(3),
+			[][]interface{}{{"b", true, int64(1)}, {"a", false, int64(1)}, {"c", false, int64(1)}}},
+	} {
+		got, err := rows(ctx, test.query.Query())
+		assert.For("%s err", test.name).ThatError(err).Succeeded()
+		assert.For("Aggregate %s", test.name).That(got).DeepEquals(test.expected)
+	}
+}
+
+func TestAggregateErrors(t *testing.T) {
+	ctx := log.Testing(t)
+	assert := assert.To(t)
+	_, err := rows(ctx, query.Bool(true).Query())
+	assert.For("no group or fields").ThatError(err).Failed()
+	q := query.Bool(true).GroupBy(query.Name("Host")).Query()
+	q.Monitor = true
+	_, err = rows(ctx, q)
+	assert.For("monitored group").ThatError(err).Failed()
+	_, err = rows(ctx, query.Bool(true).GroupBy(query.Name("Host")).Fields(query.Name("Id")).Query())
+	assert.For("grouped fields").ThatError(err).Failed()
+}
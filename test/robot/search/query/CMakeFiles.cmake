@@ -19,6 +19,7 @@
 
 set(files
     builder.go
+    clause.go
     doc.go
     expression.go
     replace.go
@@ -19,6 +19,7 @@ import "github.com/google/gapid/test/robot/search"
 // Builder is the type used to allow fluent construction of search queries.
 type Builder struct {
 	e *search.Expression
+	c clauses
 }
 
 // Expression creates a builder from a search expression.
@@ -39,7 +40,13 @@ func (b Builder) Expression() *search.Expression {
 
 // Query returns the content of the builder as a completed search query.
 func (b Builder) Query() *search.Query {
-	return &search.Query{Expression: b.Expression()}
+	return &search.Query{
+		Expression: b.Expression(),
+		Order:      b.c.order,
+		Limit:      b.c.limit,
+		Group:      b.c.group,
+		Fields:     b.c.fields,
+	}
 }
 
 // Bool builds a boolean literal search expression.
@@ -0,0 +1,65 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import "github.com/google/gapid/test/robot/search"
+
+// clauses holds the parts of a query that are not part of the filter expression.
+// The slices are never modified in place, so they can be safely shared between builders.
+type clauses struct {
+	order  []*search.Order
+	limit  int64
+	group  []*search.Expression
+	fields []*search.Expression
+}
+
+// OrderBy returns a builder that sorts the matching entries by key.
+// Multiple calls add secondary sort keys.
+func (b Builder) OrderBy(key Builder, descending bool) Builder {
+	order := &search.Order{Key: key.Expression(), Descending: descending}
+	b.c.order = append(b.c.order[:len(b.c.order):len(b.c.order)], order)
+	return b
+}
+
+// Limit returns a builder that returns at most count matching entries.
+// The limit is applied after ordering, and before grouping.
+func (b Builder) Limit(count int64) Builder {
+	b.c.limit = count
+	return b
+}
+
+// GroupBy returns a builder that groups the matching entries by the supplied keys,
+// reporting the number of entries in each group.
+// It cannot be combined with Fields.
+func (b Builder) GroupBy(keys ...Builder) Builder {
+	b.c.group = appendExpressions(b.c.group, keys)
+	return b
+}
+
+// Fields returns a builder that reports only the supplied values for each matching entry.
+// It cannot be combined with GroupBy.
+func (b Builder) Fields(values ...Builder) Builder {
+	b.c.fields = appendExpressions(b.c.fields, values)
+	return b
+}
+
+func appendExpressions(list []*search.Expression, values []Builder) []*search.Expression {
+	result := make([]*search.Expression, len(list), len(list)+len(values))
+	copy(result, list)
+	for _, v := range values {
+		result = append(result, v.Expression())
+	}
+	return result
+}
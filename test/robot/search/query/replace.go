@@ -21,7 +21,7 @@ import (
 
 // Replace substitues expr for match in the expression tree.
 func (b Builder) Replace(match Builder, expr Builder) Builder {
-	return Expression(replace(b.Expression(), match.Expression(), expr.Expression()))
+	return Builder{e: replace(b.Expression(), match.Expression(), expr.Expression()), c: b.c}
 }
 
 // Set is a small helper on top of Replace for the common case of identifier substitution.
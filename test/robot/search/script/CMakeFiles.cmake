@@ -18,6 +18,8 @@
 # build and the file will be recreated, check in the new version.
 
 set(files
+    clause.go
+    clause.lingo
     constants.go
     constants.lingo
     doc.go
@@ -18,6 +18,13 @@
 // It's intended use is for places where you want a user to be able to type a query
 // in a fairly natural language. Programmatic uses should prefer using the query
 // package directly.
+//
+// A query is a filter expression followed by optional clauses, for example:
+//   Status == 3 order by Host, Id desc limit 10 select Host, Id
+//   Status == 3 order by Host limit 10 group by Host
+// The limit is applied to the ordered entries, and the grouping to the limited
+// entries. A grouped query reports the keys and the number of entries of each
+// group, and cannot also select fields.
 package script
 
 // The following are the imports that generated source files pull in when present
@@ -17,10 +17,13 @@ package grpc
 import (
 	"context"
 	"io"
+	"reflect"
 
+	"github.com/google/gapid/core/event"
 	"github.com/google/gapid/core/event/task"
 	"github.com/google/gapid/core/log"
 	"github.com/google/gapid/test/robot/search"
+	"github.com/google/gapid/test/robot/search/eval"
 	"github.com/google/gapid/test/robot/stash"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
@@ -45,6 +48,15 @@ func (s *storeServer) Search(query *search.Query, stream Service_SearchServer) e
 	})
 }
 
+// SearchRows implements ServiceServer.SearchRows
+// It aggregates the results of Search on the provided Service implementation.
+func (s *storeServer) SearchRows(query *search.Query, stream Service_SearchRowsServer) error {
+	ctx := stream.Context()
+	return eval.Aggregate(ctx, query, reflect.TypeOf(&stash.Entity{}), func(ctx context.Context, h event.Handler) error {
+		return s.service.Search(ctx, query, func(ctx context.Context, e *stash.Entity) error { return h(ctx, e) })
+	}, func(ctx context.Context, row *search.Row) error { return stream.Send(row) })
+}
+
 type uploader struct {
 	w io.WriteCloser
 }
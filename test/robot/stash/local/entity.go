@@ -60,10 +60,13 @@ func (e *entityIndex) Lookup(ctx context.Context, id string) (*stash.Entity, err
 }
 
 func (e *entityIndex) Search(ctx context.Context, query *search.Query, handler stash.EntityHandler) error {
-	filter := eval.Filter(ctx, query, entityClass, event.AsHandler(ctx, handler))
-	initial := event.AsProducer(ctx, e.entities)
+	initial, err := eval.Select(ctx, query, entityClass, e.entities)
+	if err != nil {
+		return err
+	}
+	out := event.AsHandler(ctx, handler)
 	if query.Monitor {
-		return event.Monitor(ctx, &e.mu, e.onAdd.Listen, initial, filter)
+		return event.Monitor(ctx, &e.mu, eval.Listen(ctx, query, entityClass, e.onAdd.Listen), initial, out)
 	}
-	return event.Feed(ctx, filter, initial)
+	return event.Feed(ctx, out, initial)
 }
@@ -19,6 +19,8 @@
 
 set(files
     doc.go
+    hostapp.go
+    hostapp_test.go
     local.go
     remote.go
     server.go
@@ -0,0 +1,226 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subject
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/os/file"
+)
+
+// analyzeHostApp checks that data is a host application archive that holds
+// the launch executable described by app.
+func analyzeHostApp(ctx context.Context, data []byte, app *HostApp) (*HostApp, error) {
+	if app.Launch == "" {
+		return nil, log.Err(ctx, nil, "Host application has no launch command")
+	}
+	launch := path.Clean(app.Launch)
+	found := false
+	target := device.UnknownOS
+	err := walkHostApp(ctx, bytes.NewReader(data), func(h *tar.Header, r io.Reader) error {
+		if path.Clean(h.Name) == launch && h.Typeflag == tar.TypeReg {
+			found = true
+			header := make([]byte, 4)
+			n, _ := io.ReadFull(r, header)
+			target = executableOS(header[:n])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, log.Errf(ctx, nil, "Launch command %s not found in host application archive", app.Launch)
+	}
+	info := *app
+	info.Launch = launch
+	if info.Name == "" {
+		info.Name = path.Base(launch)
+	}
+	switch {
+	case target == device.UnknownOS && info.OS == device.UnknownOS:
+		return nil, log.Errf(ctx, nil, "Cannot detect the operating system of launch command %s, it must be given explicitly", app.Launch)
+	case target != device.UnknownOS && info.OS != device.UnknownOS && target != info.OS:
+		return nil, log.Errf(ctx, nil, "Launch command %s was built for %v, not %v", app.Launch, target, info.OS)
+	case target != device.UnknownOS:
+		info.OS = target
+	}
+	return &info, nil
+}
+
+// executableOS returns the operating system an executable was built for, using
+// the first bytes of the file.
+// UnknownOS is returned if the file is not a recognised executable format.
+func executableOS(header []byte) device.OSKind {
+	switch {
+	case bytes.HasPrefix(header, []byte{0x7f, 'E', 'L', 'F'}):
+		return device.Linux
+	case bytes.HasPrefix(header, []byte{'M', 'Z'}):
+		return device.Windows
+	case bytes.HasPrefix(header, []byte{0xfe, 0xed, 0xfa, 0xce}),
+		bytes.HasPrefix(header, []byte{0xfe, 0xed, 0xfa, 0xcf}),
+		bytes.HasPrefix(header, []byte{0xce, 0xfa, 0xed, 0xfe}),
+		bytes.HasPrefix(header, []byte{0xcf, 0xfa, 0xed, 0xfe}),
+		bytes.HasPrefix(header, []byte{0xca, 0xfe, 0xba, 0xbe}):
+		return device.OSX
+	default:
+		return device.UnknownOS
+	}
+}
+
+// outsideArchive returns true if the cleaned, slash separated path p is not
+// inside the archive root.
+func outsideArchive(p string) bool {
+	return path.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../")
+}
+
+// maxSymlinks is the maximum number of symlinks followed when resolving a path
+// in an extracted host application.
+const maxSymlinks = 255
+
+// resolveInside resolves the slash separated path p relative to root,
+// following the symlinks already extracted under root.
+// It returns the resolved path relative to root, or false if p leads outside
+// of root.
+func resolveInside(root, p string) (string, bool) {
+	resolved := []string{}
+	pending := strings.Split(p, "/")
+	links := 0
+	for len(pending) > 0 {
+		c := pending[0]
+		pending = pending[1:]
+		switch c {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return "", false
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+		full := filepath.Join(root, filepath.FromSlash(path.Join(append(resolved, c)...)))
+		info, err := os.Lstat(full)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			resolved = append(resolved, c)
+			continue
+		}
+		link, err := os.Readlink(full)
+		if links++; err != nil || links > maxSymlinks || path.IsAbs(filepath.ToSlash(link)) {
+			return "", false
+		}
+		pending = append(strings.Split(filepath.ToSlash(link), "/"), pending...)
+	}
+	return path.Join(resolved...), true
+}
+
+// ExtractHostApp unpacks the host application archive read from r into dir.
+// Entries and symlinks that would resolve outside of dir, including through
+// symlinks extracted earlier, are rejected.
+func ExtractHostApp(ctx context.Context, r io.Reader, dir file.Path) error {
+	root := dir.System()
+	return walkHostApp(ctx, r, func(h *tar.Header, r io.Reader) error {
+		name := path.Clean(h.Name)
+		if outsideArchive(name) {
+			return log.Errf(ctx, nil, "Invalid path %s in host application archive", h.Name)
+		}
+		parent, ok := resolveInside(root, path.Dir(name))
+		if !ok {
+			return log.Errf(ctx, nil, "Path %s leaves the host application archive", h.Name)
+		}
+		target := dir.Join(parent, path.Base(name))
+		switch h.Typeflag {
+		case tar.TypeDir:
+			if _, ok := resolveInside(root, name); !ok {
+				return log.Errf(ctx, nil, "Path %s leaves the host application archive", h.Name)
+			}
+			return os.MkdirAll(target.System(), 0755)
+		case tar.TypeReg:
+			if err := os.MkdirAll(target.Parent().System(), 0755); err != nil {
+				return err
+			}
+			if err := removeSymlink(target); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target.System(), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(h.Mode)&os.ModePerm)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(f, r)
+			return err
+		case tar.TypeSymlink:
+			if path.IsAbs(h.Linkname) || outsideArchive(path.Join(path.Dir(name), h.Linkname)) {
+				return log.Errf(ctx, nil, "Symlink %s to %s leaves the host application archive", h.Name, h.Linkname)
+			}
+			// The link is not cleaned, as ".." must be applied after any symlink
+			// before it is followed.
+			if _, ok := resolveInside(root, parent+"/"+h.Linkname); !ok {
+				return log.Errf(ctx, nil, "Symlink %s to %s leaves the host application archive", h.Name, h.Linkname)
+			}
+			if err := os.MkdirAll(target.Parent().System(), 0755); err != nil {
+				return err
+			}
+			if err := removeSymlink(target); err != nil {
+				return err
+			}
+			return os.Symlink(h.Linkname, target.System())
+		default:
+			log.W(ctx, "Skipping unsupported entry %s in host application archive", h.Name)
+			return nil
+		}
+	})
+}
+
+// removeSymlink removes target if it is a symlink, so that it is replaced
+// rather than written through.
+func removeSymlink(target file.Path) error {
+	info, err := os.Lstat(target.System())
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return nil
+	}
+	return os.Remove(target.System())
+}
+
+func walkHostApp(ctx context.Context, r io.Reader, visit func(*tar.Header, io.Reader) error) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return log.Err(ctx, err, "Host application is not a gzipped tarball")
+	}
+	defer gz.Close()
+	archive := tar.NewReader(gz)
+	for {
+		h, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return log.Err(ctx, err, "Corrupt host application archive")
+		}
+		if err := visit(h, archive); err != nil {
+			return err
+		}
+	}
+}
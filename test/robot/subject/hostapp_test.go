@@ -0,0 +1,171 @@
+// Copyright (C) 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subject_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/google/gapid/core/assert"
+	"github.com/google/gapid/core/log"
+	"github.com/google/gapid/core/os/device"
+	"github.com/google/gapid/core/os/file"
+	"github.com/google/gapid/test/robot/record"
+	"github.com/google/gapid/test/robot/stash"
+	"github.com/google/gapid/test/robot/stash/local"
+	"github.com/google/gapid/test/robot/subject"
+)
+
+type entry struct {
+	name     string
+	link     string
+	contents string
+}
+
+func archive(entries ...entry) []byte {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	w := tar.NewWriter(gz)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Mode: 0755}
+		switch {
+		case e.link != "":
+			h.Typeflag, h.Linkname = tar.TypeSymlink, e.link
+		case e.name[len(e.name)-1] == '/':
+			h.Typeflag = tar.TypeDir
+		default:
+			h.Typeflag, h.Size = tar.TypeReg, int64(len(e.contents))
+		}
+		w.WriteHeader(h)
+		w.Write([]byte(e.contents))
+	}
+	w.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+const elf = "\x7fELF\x02\x01\x01"
+
+func newSubjects(ctx context.Context, assert assert.Manager) (subject.Subjects, *stash.Client) {
+	library := record.NewLibrary(ctx)
+	shelf, err := record.NewNullShelf(ctx)
+	assert.For("shelf").ThatError(err).Succeeded()
+	library.Add(ctx, shelf)
+	store := local.NewMemoryService()
+	subjects, err := subject.NewLocal(ctx, library, store)
+	assert.For("subjects").ThatError(err).Succeeded()
+	return subjects, store
+}
+
+func TestAddHostApp(t *testing.T) {
+	ctx := log.Testing(t)
+	assert := assert.To(t)
+	subjects, store := newSubjects(ctx, assert)
+	data := archive(
+		entry{name: "bin/"},
+		entry{name: "bin/app", contents: elf},
+		entry{name: "run.sh", contents: "#!/bin/sh\n"},
+	)
+	id, err := store.UploadBytes(ctx, stash.Upload{Name: []string{"app.tar.gz"}}, data)
+	assert.For("upload").ThatError(err).Succeeded()
+
+	s, created, err := subjects.Add(ctx, id, nil, &subject.HostApp{Launch: "./bin/app", Args: []string{"-x"}})
+	assert.For("add").ThatError(err).Succeeded()
+	assert.For("created").That(created).Equals(true)
+	host := s.GetHost()
+	assert.For("host").That(host).IsNotNil()
+	assert.For("launch").That(host.Launch).Equals("bin/app")
+	assert.For("name").That(host.Name).Equals("app")
+	assert.For("os").That(host.OS).Equals(device.Linux)
+
+	for _, test := range []struct {
+		name string
+		app  *subject.HostApp
+	}{
+		{"no launch", &subject.HostApp{}},
+		{"missing launch", &subject.HostApp{Launch: "bin/missing"}},
+		{"directory launch", &subject.HostApp{Launch: "bin"}},
+		{"os mismatch", &subject.HostApp{Launch: "bin/app", OS: device.Windows}},
+		{"unknown os", &subject.HostApp{Launch: "run.sh"}},
+	} {
+		_, _, err := subjects.Add(ctx, id, nil, test.app)
+		assert.For("%s", test.name).ThatError(err).Failed()
+	}
+
+	s, _, err = subjects.Add(ctx, id, nil, &subject.HostApp{Launch: "run.sh", OS: device.OSX})
+	assert.For("explicit os").ThatError(err).Succeeded()
+	assert.For("explicit os").That(s.GetHost().OS).Equals(device.OSX)
+}
+
+func TestExtractHostApp(t *testing.T) {
+	ctx := log.Testing(t)
+	assert := assert.To(t)
+	tmp, err := ioutil.TempDir("", "hostapp")
+	assert.For("tempdir").ThatError(err).Succeeded()
+	defer os.RemoveAll(tmp)
+
+	dir := file.Abs(tmp).Join("ok")
+	data := archive(
+		entry{name: "bin/app", contents: elf},
+		entry{name: "lib/libfoo.so.1", contents: "foo"},
+		entry{name: "lib/libfoo.so", link: "libfoo.so.1"},
+		entry{name: "bin/lib", link: "../lib"},
+		entry{name: "bin/tool", link: "app"},
+		entry{name: "bin/tool", contents: "tool"},
+	)
+	err = subject.ExtractHostApp(ctx, bytes.NewReader(data), dir)
+	assert.For("extract").ThatError(err).Succeeded()
+	got, err := ioutil.ReadFile(dir.Join("bin", "app").System())
+	assert.For("app").ThatError(err).Succeeded()
+	assert.For("app").ThatString(string(got)).Equals(elf)
+	got, err = ioutil.ReadFile(dir.Join("bin", "lib", "libfoo.so").System())
+	assert.For("symlink").ThatError(err).Succeeded()
+	assert.For("symlink").ThatString(string(got)).Equals("foo")
+	got, err = ioutil.ReadFile(dir.Join("bin", "app").System())
+	assert.For("replaced symlink").ThatError(err).Succeeded()
+	assert.For("replaced symlink").ThatString(string(got)).Equals(elf)
+
+	for _, test := range []struct {
+		name    string
+		entries []entry
+	}{
+		{"absolute path", []entry{{name: "/etc/passwd", contents: "x"}}},
+		{"parent path", []entry{{name: "../escape", contents: "x"}}},
+		{"nested parent path", []entry{{name: "bin/../../escape", contents: "x"}}},
+		{"absolute symlink", []entry{{name: "passwd", link: "/etc/passwd"}}},
+		{"parent symlink", []entry{{name: "up", link: ".."}}},
+		{"nested parent symlink", []entry{{name: "bin/up", link: "../../etc"}}},
+		{"symlink chain", []entry{
+			{name: "x", link: "."},
+			{name: "x/y", link: ".."},
+			{name: "x/y/escape", contents: "x"},
+		}},
+		{"symlink through symlink", []entry{
+			{name: "x", link: "."},
+			{name: "y", link: "x/../escape"},
+			{name: "y", contents: "x"},
+		}},
+	} {
+		dir := file.Abs(tmp).Join(test.name)
+		err := subject.ExtractHostApp(ctx, bytes.NewReader(archive(test.entries...)), dir)
+		assert.For("%s", test.name).ThatError(err).Failed()
+		assert.For("%s outside", test.name).That(file.Abs(tmp).Join("escape").Exists()).Equals(false)
+	}
+}
@@ -68,16 +68,19 @@ func (s *local) apply(ctx context.Context, subject *Subject) error {
 // Search implements Subjects.Search
 // It searches the set of persisted subjects, and supports monitoring of subjects as they arrive.
 func (s *local) Search(ctx context.Context, query *search.Query, handler Handler) error {
-	filter := eval.Filter(ctx, query, reflect.TypeOf(&Subject{}), event.AsHandler(ctx, handler))
-	initial := event.AsProducer(ctx, s.subjects)
+	initial, err := eval.Select(ctx, query, reflect.TypeOf(&Subject{}), s.subjects)
+	if err != nil {
+		return err
+	}
+	out := event.AsHandler(ctx, handler)
 	if query.Monitor {
-		return event.Monitor(ctx, &s.mu, s.onAdd.Listen, initial, filter)
+		return event.Monitor(ctx, &s.mu, eval.Listen(ctx, query, reflect.TypeOf(&Subject{}), s.onAdd.Listen), initial, out)
 	}
-	return event.Feed(ctx, filter, initial)
+	return event.Feed(ctx, out, initial)
 }
 
 // Add implements Subjects.Add
-func (s *local) Add(ctx context.Context, id string, hints *Hints) (*Subject, bool, error) {
+func (s *local) Add(ctx context.Context, id string, hints *Hints, host *HostApp) (*Subject, bool, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if subject, ok := s.byID[id]; ok {
@@ -90,17 +93,19 @@ func (s *local) Add(ctx context.Context, id string, hints *Hints) (*Subject, boo
 	if len(data) == 0 {
 		return nil, false, nil
 	}
-	// TODO: support non apk subjects
-	info, err := apk.Analyze(ctx, data)
-	if err != nil {
-		return nil, false, err
-	}
-	subject := &Subject{
-		Id: id,
-		Information: &Subject_APK{
-			APK: info,
-		},
-		Hints: hints,
+	subject := &Subject{Id: id, Hints: hints}
+	if host != nil {
+		info, err := analyzeHostApp(ctx, data, host)
+		if err != nil {
+			return nil, false, err
+		}
+		subject.Information = &Subject_Host{Host: info}
+	} else {
+		info, err := apk.Analyze(ctx, data)
+		if err != nil {
+			return nil, false, err
+		}
+		subject.Information = &Subject_APK{APK: info}
 	}
 	if err := s.ledger.Add(ctx, subject); err != nil {
 		return nil, false, err
@@ -16,8 +16,11 @@ package subject
 
 import (
 	"context"
+	"reflect"
 
+	"github.com/google/gapid/core/event"
 	"github.com/google/gapid/test/robot/search"
+	"github.com/google/gapid/test/robot/search/eval"
 
 	"google.golang.org/grpc"
 
@@ -37,7 +40,7 @@ func Serve(ctx context.Context, grpcServer *grpc.Server, subjects Subjects) erro
 // Add implements ServiceServer.Add
 // It delegates the call to the provided Subjects implementation.
 func (s *server) Add(ctx xctx.Context, request *AddRequest) (*AddResponse, error) {
-	subj, created, err := s.subjects.Add(ctx, request.Id, request.Hints)
+	subj, created, err := s.subjects.Add(ctx, request.Id, request.Hints, request.Host)
 	if err != nil {
 		return nil, err
 	}
@@ -50,3 +53,12 @@ func (s *server) Search(query *search.Query, stream Service_SearchServer) error
 	ctx := stream.Context()
 	return s.subjects.Search(ctx, query, func(ctx context.Context, e *Subject) error { return stream.Send(e) })
 }
+
+// SearchRows implements ServiceServer.SearchRows
+// It aggregates the results of Search on the provided Subjects implementation.
+func (s *server) SearchRows(query *search.Query, stream Service_SearchRowsServer) error {
+	ctx := stream.Context()
+	return eval.Aggregate(ctx, query, reflect.TypeOf(&Subject{}), func(ctx context.Context, h event.Handler) error {
+		return s.subjects.Search(ctx, query, func(ctx context.Context, e *Subject) error { return h(ctx, e) })
+	}, func(ctx context.Context, row *search.Row) error { return stream.Send(row) })
+}
@@ -28,5 +28,7 @@ type Subjects interface {
 	// Search returns a iterator of matching subjects from the store.
 	Search(context.Context, *search.Query, Handler) error
 	// Add adds a new subject to the set.
-	Add(ctx context.Context, id string, hints *Hints) (*Subject, bool, error)
+	// If host is not nil, the subject is a host application archive launched as described by host,
+	// otherwise it is an APK.
+	Add(ctx context.Context, id string, hints *Hints, host *HostApp) (*Subject, bool, error)
 }
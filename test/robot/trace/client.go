@@ -17,6 +17,7 @@ package trace
 import (
 	"context"
 	"fmt"
+	"os"
 	"sync"
 	"time"
 
@@ -28,9 +29,11 @@ import (
 	"github.com/google/gapid/core/os/device/host"
 	"github.com/google/gapid/core/os/file"
 	"github.com/google/gapid/core/os/shell"
+	"github.com/google/gapid/core/text"
 	"github.com/google/gapid/test/robot/job"
 	"github.com/google/gapid/test/robot/job/worker"
 	"github.com/google/gapid/test/robot/stash"
+	robotsubject "github.com/google/gapid/test/robot/subject"
 
 	_ "github.com/google/gapid/gapidapk"
 )
@@ -106,6 +109,8 @@ func Run(ctx context.Context, store *stash.Client, manager Manager, tempDir file
 		runners:  make(map[string]*runner),
 	}
 	c.registry.Listen(c)
+	// The host is always available as a trace target for host applications.
+	c.registry.AddDevice(ctx, bind.Host(ctx))
 
 	go func() {
 		if err := adb.Monitor(ctx, c.registry, 15*time.Second); err != nil {
@@ -127,7 +132,11 @@ func (r *runner) trace(ctx context.Context, t *Task) error {
 	}
 	var output *Output
 	err := worker.RetryFunction(ctx, 4, time.Millisecond*100, func() (err error) {
-		output, err = doTrace(ctx, t.Action, t.Input, r.store, r.device, r.tempDir)
+		if t.Input.Host != nil {
+			output, err = doHostTrace(ctx, t.Action, t.Input, r.store, r.tempDir)
+		} else {
+			output, err = doTrace(ctx, t.Action, t.Input, r.store, r.device, r.tempDir)
+		}
 		return
 	})
 	status := job.Succeeded
@@ -187,6 +196,80 @@ func doTrace(ctx context.Context, action string, in *Input, store *stash.Client,
 		"-record-errors",
 		"-gapii-device", d.Instance().Serial,
 	}
+	return runGapit(ctx, store, gapit, tracefile, params)
+}
+
+func doHostTrace(ctx context.Context, action string, in *Input, store *stash.Client, tempDir file.Path) (*Output, error) {
+	subject := tempDir.Join(action + ".tar.gz")
+	subjectDir := tempDir.Join(action + "_subject")
+	tracefile := tempDir.Join(action + ".gfxtrace")
+	extractedDir := tempDir.Join(action + "_tools")
+	extractedLayout := layout.BinLayout(extractedDir)
+
+	gapit, err := extractedLayout.Gapit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	spyLib, err := extractedLayout.Library(ctx, layout.LibGraphicsSpy)
+	if err != nil {
+		return nil, err
+	}
+	spyJson, err := extractedLayout.Json(ctx, layout.LibGraphicsSpy)
+	if err != nil {
+		return nil, err
+	}
+
+	traceTime, err := ptypes.Duration(in.GetHints().GetTraceTime())
+	if err != nil {
+		traceTime = time.Minute // TODO: support Robot-wide override
+	}
+
+	defer func() {
+		file.Remove(subject)
+		file.RemoveAll(subjectDir)
+		file.Remove(tracefile)
+		file.RemoveAll(extractedDir)
+	}()
+	for _, f := range []struct {
+		in  string
+		out file.Path
+	}{
+		{in.Subject, subject},
+		{in.Gapit, gapit},
+		{in.GraphicsSpyLib, spyLib},
+		{in.GraphicsSpyJson, spyJson},
+	} {
+		if err := store.GetFile(ctx, f.in, f.out); err != nil {
+			return nil, err
+		}
+	}
+	archive, err := os.Open(subject.System())
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	if err := robotsubject.ExtractHostApp(ctx, archive, subjectDir); err != nil {
+		return nil, err
+	}
+	params := []string{
+		"trace",
+		"-out", tracefile.System(),
+		"-local-app", subjectDir.Join(in.Host.Launch).System(),
+		"-local-args", text.JoinArgs(in.Host.Args),
+		"-local-workingdir", subjectDir.System(),
+		"-for", traceTime.String(),
+		"-disable-pcs",
+		"-observe-frames", "5",
+		"-record-errors",
+	}
+	if in.Host.API != "" {
+		params = append(params, "-api", in.Host.API)
+	}
+	return runGapit(ctx, store, gapit, tracefile, params)
+}
+
+// runGapit runs the gapit trace command, and uploads the log and the trace file to the store.
+func runGapit(ctx context.Context, store *stash.Client, gapit file.Path, tracefile file.Path, params []string) (*Output, error) {
 	cmd := shell.Command(gapit.System(), params...)
 	output, callErr := cmd.Call(ctx)
 	if err := worker.NeedsRetry(output, "Failed to connect to the GAPIS server"); err != nil {
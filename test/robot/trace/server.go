@@ -16,9 +16,12 @@ package trace
 
 import (
 	"context"
+	"reflect"
 
+	"github.com/google/gapid/core/event"
 	"github.com/google/gapid/test/robot/job/worker"
 	"github.com/google/gapid/test/robot/search"
+	"github.com/google/gapid/test/robot/search/eval"
 	"google.golang.org/grpc"
 
 	xctx "golang.org/x/net/context"
@@ -41,6 +44,15 @@ func (s *server) Search(query *search.Query, stream Service_SearchServer) error
 	return s.manager.Search(ctx, query, func(ctx context.Context, e *Action) error { return stream.Send(e) })
 }
 
+// SearchRows implements ServiceServer.SearchRows
+// It aggregates the results of Search on the provided Manager implementation.
+func (s *server) SearchRows(query *search.Query, stream Service_SearchRowsServer) error {
+	ctx := stream.Context()
+	return eval.Aggregate(ctx, query, reflect.TypeOf(&Action{}), func(ctx context.Context, h event.Handler) error {
+		return s.manager.Search(ctx, query, func(ctx context.Context, e *Action) error { return h(ctx, e) })
+	}, func(ctx context.Context, row *search.Row) error { return stream.Send(row) })
+}
+
 // Register implements ServiceServer.Register
 // It delegates the call to the provided Manager implementation.
 func (s *server) Register(request *worker.RegisterRequest, stream Service_RegisterServer) error {